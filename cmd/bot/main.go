@@ -2,31 +2,40 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/rg/aiops/internal/api"
 	"github.com/rg/aiops/internal/bot"
 	"github.com/rg/aiops/internal/claude"
 	"github.com/rg/aiops/internal/config"
 	ctx "github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
 	"github.com/rg/aiops/internal/messaging/telegram"
+	"github.com/rg/aiops/internal/metrics"
 	"github.com/rg/aiops/internal/security"
 	"github.com/rg/aiops/internal/storage"
 )
 
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
-	// Initialize structured logger with configurable log level
+	// Initialize structured logger with configurable log level. Recent errors
+	// are also kept in memory for /debug.
 	logLevel := parseLogLevel(os.Getenv("LOG_LEVEL"))
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel,
+	errorLog := bot.NewErrorLog(10)
+	logger := slog.New(errorLog.Handler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:     logLevel,
 		AddSource: true,
-	}))
+	})))
 	slog.SetDefault(logger)
 
-	slog.Info("Starting aiops bot", "log_level", logLevel.String())
+	slog.Info("Starting aiops bot", "version", version, "log_level", logLevel.String())
 
 	cfg, err := config.Load()
 	if err != nil {
@@ -42,6 +51,22 @@ func main() {
 		os.Exit(1)
 	}
 	defer store.Close()
+	if cfg.Storage.SnapshotDir != "" {
+		store.SetSnapshotDir(cfg.Storage.SnapshotDir)
+	}
+	if cfg.Storage.BatchInterval > 0 {
+		store.EnableWriteBatching(cfg.Storage.BatchSize, cfg.Storage.BatchInterval)
+	}
+	if cfg.Storage.MaxContentLen > 0 {
+		store.SetMaxContentLen(cfg.Storage.MaxContentLen)
+	}
+	store.SetMaxOpenConns(cfg.Storage.MaxOpenConns)
+	if cfg.Storage.ReplicaDSN != "" {
+		if err := store.EnableReadReplica(cfg.Storage.ReplicaDSN); err != nil {
+			slog.Error("Failed to initialize read replica", "error", err)
+			os.Exit(1)
+		}
+	}
 	slog.Info("Database initialized successfully")
 
 	sanitizer, err := security.NewSanitizer(cfg.Security.SecretPatterns)
@@ -49,6 +74,8 @@ func main() {
 		slog.Error("Failed to initialize sanitizer", "error", err)
 		os.Exit(1)
 	}
+	sanitizer.SetMetricsEnabled(cfg.Security.RedactionStats)
+	sanitizer.SetRedactionMode(security.RedactionMode(cfg.Security.RedactionMode))
 	slog.Info("Security sanitizer initialized", "patterns_count", len(cfg.Security.SecretPatterns))
 
 	// SessionManager must be created before ContextManager (used to cleanup orphaned sessions)
@@ -59,6 +86,14 @@ func main() {
 		cfg.Claude.MaxConcurrentSessions,
 		cfg.Claude.QueryTimeout,
 	)
+	sessionManager.SetRecordRawOutput(cfg.Storage.RecordRawOutput)
+	sessionManager.SetSerializeResumes(cfg.Claude.ResumesSerialized())
+	systemPrompt, err := cfg.Claude.SystemPrompt()
+	if err != nil {
+		slog.Error("Failed to load system prompt", "error", err)
+		os.Exit(1)
+	}
+	sessionManager.SetSystemPrompt(systemPrompt)
 	slog.Info("Session manager initialized",
 		"max_sessions", cfg.Claude.MaxConcurrentSessions,
 		"timeout", cfg.Claude.QueryTimeout)
@@ -69,11 +104,37 @@ func main() {
 	validator, err := ctx.NewValidator(store, cfg.Claude.ProjectPath, cfg.Context.ValidationEnabled)
 	if err != nil {
 		slog.Warn("Validator initialization failed", "error", err)
+	} else {
+		validator.SetKeywords(cfg.Context.ValidationKeywords)
+		if err := validator.SetDenyPatterns(cfg.Context.ValidationDenyPatterns); err != nil {
+			slog.Error("Invalid context.validation_deny_patterns", "error", err)
+			os.Exit(1)
+		}
 	}
-	slog.Info("Context validator initialized", "enabled", cfg.Context.ValidationEnabled)
+	slog.Info("Context validator initialized",
+		"enabled", cfg.Context.ValidationEnabled,
+		"custom_keywords", len(cfg.Context.ValidationKeywords),
+		"deny_patterns", len(cfg.Context.ValidationDenyPatterns))
 
 	executor := claude.NewExecutor(sessionManager, cfg.Claude.ProjectPath, cfg.Claude.QueryTimeout)
-	slog.Info("Claude executor initialized")
+	executor.SetTracing(cfg.Claude.TraceQueries)
+	slog.Info("Claude executor initialized", "tracing", cfg.Claude.TraceQueries)
+
+	if validator != nil && cfg.Context.ValidationMode == "llm" {
+		validator.SetLLMClassifier(executor, cfg.Context.ValidationLLMModel, cfg.Context.ValidationLLMTimeout)
+		slog.Info("LLM query validation enabled",
+			"model", cfg.Context.ValidationLLMModel,
+			"timeout", cfg.Context.ValidationLLMTimeout)
+	}
+
+	if canary := cfg.Claude.Canary; canary.Percent > 0 {
+		executor.SetCanary(claude.Canary{
+			Percent: canary.Percent,
+			Model:   canary.Model,
+			Prompt:  canary.Prompt,
+		})
+		slog.Info("Canary replies enabled", "percent", canary.Percent, "model", canary.Model, "prompt", canary.Prompt != "")
+	}
 
 	// Validate Claude CLI is available
 	if err := sessionManager.ValidateCLI(); err != nil {
@@ -82,21 +143,48 @@ func main() {
 	}
 	slog.Info("Claude CLI validated successfully")
 
+	// Sessions are in memory only; recreate those of contexts still active
+	// so counts and idle cleanup include them after a restart
+	if contexts, err := store.GetAllContexts(false); err != nil {
+		slog.Warn("Failed to load active contexts to restore sessions", "error", err)
+	} else {
+		slog.Info("Restored sessions of active contexts", "sessions", sessionManager.Rehydrate(contexts))
+	}
+
 	expiryWorker := ctx.NewExpiryWorker(store, sessionManager, cfg.Context.CleanupInterval)
 	// Wire up cleanup callback to remove per-chat locks and prevent memory leaks
 	expiryWorker.SetCleanupCallback(contextManager.RemoveChatLock)
+	expiryWorker.SetReconcileDuplicates(cfg.Context.ReconcileDuplicates)
 	workerCtx, cancelWorker := context.WithCancel(context.Background())
 	defer cancelWorker()
 
 	go expiryWorker.Start(workerCtx)
 	slog.Info("Expiry worker started", "interval", cfg.Context.CleanupInterval)
 
-	platform, err := telegram.NewClient(cfg.Telegram.Token)
+	go claude.NewIdleReaper(sessionManager, cfg.Claude.IdleCleanupInterval, cfg.Claude.MaxIdle).Start(workerCtx)
+
+	if mp := cfg.Claude.MemoryPressure; mp.ThresholdMB > 0 {
+		memoryMonitor := claude.NewMemoryMonitor(
+			sessionManager,
+			uint64(mp.ThresholdMB)*1024*1024,
+			mp.CheckInterval,
+			mp.EvictCount,
+		)
+		go memoryMonitor.Start(workerCtx)
+	}
+
+	platform, err := newPlatform(cfg)
 	if err != nil {
-		slog.Error("Failed to create Telegram client", "error", err)
+		slog.Error("Failed to create messaging client", "platform", cfg.Messaging.Platform, "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Messaging client initialized", "platform", cfg.Messaging.Platform)
+	onTelegram := cfg.Messaging.Platform == "telegram"
+
+	if _, err := bot.ParseAllowList(cfg.Telegram.AllowedChatIDs); err != nil {
+		slog.Error("Invalid telegram.allowed_chat_ids", "error", err)
 		os.Exit(1)
 	}
-	slog.Info("Telegram client initialized")
 
 	handler := bot.NewHandler(
 		platform,
@@ -111,13 +199,297 @@ func main() {
 	)
 	slog.Info("Bot handler initialized", "allowed_chats", len(cfg.Telegram.AllowedChatIDs))
 
+	if label := cfg.Environment.Label(); label != "" {
+		handler.SetEnvironmentLabel(label, cfg.Environment.AllResponses)
+	}
+	handler.SetAdminIDs(cfg.Telegram.AdminChatIDs)
+	handler.SetObserverIDs(cfg.Telegram.ObserverUserIDs)
+
+	reactions := cfg.Telegram.Reactions
+	for outcome, emoji := range map[string]string{"processing": reactions.Processing, "success": reactions.Success, "error": reactions.Error} {
+		if onTelegram && emoji != "" && !telegram.IsAllowedReaction(emoji) {
+			slog.Warn("Configured reaction is not supported by Telegram, the default will be used instead",
+				"outcome", outcome, "emoji", emoji)
+		}
+	}
+	handler.SetReactions(bot.Reactions{
+		Processing: reactions.Processing,
+		Success:    reactions.Success,
+		Error:      reactions.Error,
+	})
+	handler.SetSnapshotsEnabled(cfg.Storage.SnapshotsEnabled)
+	handler.SetPreferencesEnabled(cfg.Context.PreferencesEnabled)
+	handler.SetContextOverridesEnabled(cfg.Context.Overrides.Enabled, cfg.Context.Overrides.MaxLength)
+	handler.SetSummarizeEnabled(cfg.Context.Summarize.Enabled, cfg.Context.Summarize.Model, cfg.Context.Summarize.Timeout)
+	handler.SetChatRedactionsEnabled(cfg.Security.ChatRedactions)
+	handler.SetQueryRedaction(cfg.Security.RedactQueries)
+	handler.SetHeavyRedactionCheck(cfg.Security.HeavyRedaction.Threshold, cfg.Security.HeavyRedaction.NotifyUser)
+	handler.SetRedactionStatsEnabled(cfg.Security.RedactionStats)
+	handler.SetAnalyticsEnabled(cfg.Storage.CommandAnalytics)
+	handler.SetSessionTrailEnabled(cfg.Storage.SessionTrail)
+	handler.SetSessionTreeEnabled(cfg.Storage.SessionTree)
+	handler.SetRawOutputRecording(cfg.Storage.RecordRawOutput)
+	handler.SetCSVExport(cfg.Storage.CSVExport)
+	if len(cfg.Context.TransferRules) > 0 {
+		policy, err := bot.ParseTransferPolicy(cfg.Context.TransferRules)
+		if err != nil {
+			slog.Error("Ignoring invalid context.transfer_rules entries", "error", err)
+		}
+		handler.SetTransferPolicy(policy)
+	}
+	handler.SetResponseDeadline(cfg.Telegram.ResponseDeadline)
+	handler.SetErrorEscalation(bot.ErrorEscalation{
+		Threshold:    cfg.Telegram.ErrorEscalation.Threshold,
+		Contact:      cfg.Telegram.ErrorEscalation.Contact,
+		NotifyAdmins: cfg.Telegram.ErrorEscalation.NotifyAdmins,
+	})
+
+	if cfg.Telegram.ReactionShortcuts.Enabled {
+		shortcuts := bot.DefaultReactionShortcuts
+		if len(cfg.Telegram.ReactionShortcuts.Actions) > 0 {
+			shortcuts = make(map[string]bot.ReactionAction, len(cfg.Telegram.ReactionShortcuts.Actions))
+			for emoji, action := range cfg.Telegram.ReactionShortcuts.Actions {
+				shortcuts[emoji] = bot.ReactionAction(action)
+			}
+		}
+		for emoji, action := range shortcuts {
+			if onTelegram && !telegram.IsAllowedReaction(emoji) {
+				slog.Warn("Reaction shortcut uses an emoji Telegram won't deliver", "emoji", emoji, "action", action)
+			}
+		}
+		handler.SetReactionShortcuts(shortcuts)
+		if source, ok := platform.(messaging.ReactionSource); ok {
+			source.SetReactionHandler(handler.HandleReaction)
+		} else {
+			slog.Warn("Messaging platform does not deliver reactions, shortcuts will not trigger", "platform", cfg.Messaging.Platform)
+		}
+		slog.Info("Reaction shortcuts enabled", "shortcuts", len(shortcuts))
+	}
+
+	if cfg.Incidents.Enabled {
+		var webhook *bot.IncidentWebhook
+		if cfg.Incidents.WebhookURL != "" {
+			webhook = bot.NewIncidentWebhook(cfg.Incidents.WebhookURL, cfg.Incidents.WebhookTimeout)
+		}
+		handler.SetIncidentsEnabled(true, webhook)
+		slog.Info("Incident linking enabled", "webhook", webhook != nil)
+	}
+
+	if oc := cfg.OnCall; oc.Enabled {
+		users := make([]bot.OnCallUser, 0, len(oc.Rotation.Users))
+		for _, u := range oc.Rotation.Users {
+			users = append(users, bot.OnCallUser{Name: u.Name, ChatID: u.ChatID})
+		}
+		handler.SetOnCallResolver(bot.NewStaticRotation(oc.Rotation.Start, oc.Rotation.Shift, users))
+		slog.Info("On-call handoff enabled", "users", len(users), "shift", oc.Rotation.Shift)
+	}
+
+	if m := cfg.Maintenance; len(m.Windows) > 0 {
+		windows, loc, err := maintenanceWindows(m)
+		if err != nil {
+			slog.Error("Invalid maintenance configuration", "error", err)
+			os.Exit(1)
+		}
+		go bot.NewMaintenanceScheduler(handler, windows, loc).Start(workerCtx)
+		slog.Info("Maintenance windows scheduled", "windows", len(windows), "timezone", loc)
+	}
+
+	var readPool *bot.ReadPool
+	if cfg.Storage.ReadWorkers > 0 {
+		readPool = bot.NewReadPool(cfg.Storage.ReadWorkers, cfg.Storage.ReadQueueSize)
+		handler.SetReadPool(readPool)
+		slog.Info("Read pool enabled", "workers", cfg.Storage.ReadWorkers, "queue_size", cfg.Storage.ReadQueueSize)
+	}
+
+	if len(cfg.Security.Confirmation.Commands) > 0 {
+		handler.SetConfirmationTracker(bot.NewConfirmationTracker(
+			cfg.Security.Confirmation.Commands,
+			cfg.Security.Confirmation.Window,
+			cfg.Security.Confirmation.RequireDistinctUser,
+		))
+		slog.Info("Destructive command confirmation enabled",
+			"commands", cfg.Security.Confirmation.Commands,
+			"window", cfg.Security.Confirmation.Window)
+	}
+
+	if cfg.Tools.LoopThreshold > 0 {
+		handler.SetToolLoopThreshold(cfg.Tools.LoopThreshold)
+		slog.Info("Tool loop detection enabled", "threshold", cfg.Tools.LoopThreshold)
+	}
+
+	handler.SetMediaCaptionsEnabled(cfg.Telegram.MediaCaptions)
+	handler.SetAnswerThreads(cfg.Telegram.AnswerThreads)
+	handler.SetStreaming(cfg.Claude.Streaming)
+
+	if len(cfg.Telegram.SandboxChatIDs) > 0 {
+		handler.SetSandboxChatIDs(cfg.Telegram.SandboxChatIDs)
+		slog.Info("Sandbox chats enabled", "chats", len(cfg.Telegram.SandboxChatIDs))
+	}
+
+	if cfg.Tools.MaxPerResponse > 0 {
+		handler.SetMaxToolsPerResponse(cfg.Tools.MaxPerResponse)
+		slog.Info("Tool execution cap enabled", "max_per_response", cfg.Tools.MaxPerResponse)
+	}
+
+	if cfg.Telegram.CommandDedupWindow > 0 {
+		handler.SetCommandDeduplicator(bot.NewCommandDeduplicator(cfg.Telegram.CommandDedupWindow))
+		slog.Info("Command deduplication enabled", "window", cfg.Telegram.CommandDedupWindow)
+	}
+
+	if len(cfg.Tools.Modes) > 0 || cfg.Tools.DefaultMode != "" {
+		handler.SetToolClassifier(claude.NewToolClassifier(cfg.Tools.Modes, cfg.Tools.DefaultMode))
+		slog.Info("Tool mode classification enabled", "tools", len(cfg.Tools.Modes), "default_mode", cfg.Tools.DefaultMode)
+	}
+
+	if cfg.Tools.K8sSummary.Enabled {
+		extractor, err := claude.NewResourceExtractor(cfg.Tools.K8sSummary.Resources)
+		if err != nil {
+			slog.Error("Invalid tools.k8s_summary.resources", "error", err)
+			os.Exit(1)
+		}
+		handler.SetResourceSummary(extractor)
+		slog.Info("Kubernetes resource summary enabled", "custom_patterns", len(cfg.Tools.K8sSummary.Resources))
+	}
+
+	if cfg.Claude.QueueNotifyAfter > 0 {
+		sessionManager.SetQueueNotifier(cfg.Claude.QueueNotifyAfter, handler.NotifyQueuePosition)
+		slog.Info("Queue position notifications enabled", "after", cfg.Claude.QueueNotifyAfter)
+	}
+
+	if qa := cfg.Claude.QueueAlert; qa.Threshold > 0 {
+		queueMonitor := claude.NewQueueMonitor(sessionManager, qa.Threshold, qa.Duration, qa.CheckInterval)
+		queueMonitor.SetOnChange(func(backlogged bool, depth int) {
+			if !backlogged {
+				handler.NotifyAdmins("✅ Query queue backlog cleared.")
+				return
+			}
+			handler.NotifyAdmins(fmt.Sprintf("🚦 Query queue backlog: %d queries have been waiting for a free slot for over %s.", depth, qa.Duration))
+		})
+		go queueMonitor.Start(workerCtx)
+	}
+
+	if len(cfg.Claude.AllowedModels) > 0 {
+		handler.SetModels(cfg.Claude.Model, cfg.Claude.AllowedModels)
+		slog.Info("Per-chat model selection enabled", "models", cfg.Claude.AllowedModels)
+	}
+	if len(cfg.Claude.Projects) > 0 {
+		handler.SetProjects(cfg.Claude.Projects)
+		slog.Info("Per-chat project selection enabled", "projects", len(cfg.Claude.Projects))
+	}
+
+	var healthChecker *claude.HealthChecker
+	if cfg.Claude.HealthInterval > 0 {
+		healthChecker = claude.NewHealthChecker(sessionManager, cfg.Claude.HealthInterval)
+		healthChecker.SetOnChange(func(status claude.HealthStatus) {
+			if status.Healthy {
+				handler.NotifyAdmins("✅ Claude CLI is healthy again.")
+				return
+			}
+			handler.NotifyAdmins(fmt.Sprintf("🚨 Claude CLI health check failed: %v", status.LastError))
+		})
+		handler.SetHealthChecker(healthChecker)
+		go healthChecker.Start(workerCtx)
+	}
+
+	if cfg.Telegram.Inline.Enabled {
+		if source, ok := platform.(messaging.InlineQuerySource); ok {
+			handler.SetInlineQueries(cfg.Telegram.Inline.CacheTTL)
+			source.SetInlineQueryHandler(handler.HandleInlineQuery)
+			slog.Info("Inline queries enabled", "cache_ttl", cfg.Telegram.Inline.CacheTTL)
+		} else {
+			slog.Warn("Messaging platform has no inline mode, inline queries disabled", "platform", cfg.Messaging.Platform)
+		}
+	}
+
+	if cfg.Claude.WorkspaceCheckTTL > 0 {
+		sessionManager.SetWorkspaceCheck(cfg.Claude.WorkspaceCheckTTL, func(err error) {
+			if err == nil {
+				handler.NotifyAdmins("✅ Project workspace is available again.")
+				return
+			}
+			handler.NotifyAdmins(fmt.Sprintf("🚨 Project workspace unavailable: %v", err))
+		})
+		slog.Info("Project workspace checks enabled", "ttl", cfg.Claude.WorkspaceCheckTTL)
+	}
+
+	if cfg.Telegram.DebugCommand {
+		handler.SetDebugInfo(bot.DebugInfo{
+			Version: version,
+			Config:  cfg.String(),
+			Errors:  errorLog,
+		})
+	}
+
+	if cfg.Telegram.CommandMenu {
+		if menu, ok := platform.(messaging.CommandMenu); ok {
+			if err := menu.SetCommands(handler.BotCommands()); err != nil {
+				slog.Warn("Failed to register command menu", "error", err)
+			}
+		} else {
+			slog.Warn("Messaging platform has no command menu", "platform", cfg.Messaging.Platform)
+		}
+	}
+
 	// Initialize middleware with rate limiting
 	middleware := bot.NewMiddleware(cfg.Telegram.RateLimit, cfg.Telegram.RateWindow, platform)
+	middleware.SetRateLimitExempt(cfg.Telegram.SandboxChatIDs)
+	middleware.SetRateLimitOverrides(rateLimitOverrides(cfg.Telegram.RateLimitOverrides))
 	middleware.StartCleanupWorker()
 	slog.Info("Middleware initialized", "rate_limit", cfg.Telegram.RateLimit, "rate_window", cfg.Telegram.RateWindow)
 
-	// Wrap handler with middleware chain: Logger -> RateLimit -> Handler
-	wrappedHandler := middleware.Logger(middleware.RateLimit(handler.HandleMessage))
+	// Wrap handler with middleware chain: Logger -> [PasteMerger] -> RateLimit -> Handler
+	// Paste merging runs before rate limiting so a split paste counts as one request
+	pipeline := middleware.RateLimit(handler.HandleMessage)
+	if cfg.Telegram.PasteMerge.Enabled {
+		pipeline = bot.NewPasteMerger(cfg.Telegram.PasteMerge.Window, cfg.Telegram.PasteMerge.MinLength).Wrap(pipeline)
+		slog.Info("Paste merging enabled",
+			"window", cfg.Telegram.PasteMerge.Window,
+			"min_length", cfg.Telegram.PasteMerge.MinLength)
+	}
+	wrappedHandler := middleware.Logger(pipeline)
+	// Retries re-run a query, so they count against the chat's rate limit too
+	handler.SetRetryHandler(middleware.Logger(middleware.RateLimit(handler.HandleMessage)))
+
+	var apiServer *api.Server
+	if cfg.API.Enabled {
+		apiServer = api.NewServer(
+			cfg.API.Addr,
+			cfg.API.Token,
+			contextManager,
+			sessionManager,
+			executor,
+			sanitizer,
+			store,
+		)
+		apiServer.SetReadinessCheck(func() error {
+			if err := sessionManager.WorkspaceReady(); err != nil {
+				return err
+			}
+			if healthChecker != nil {
+				return healthChecker.Ready()
+			}
+			return nil
+		})
+		go func() {
+			if err := apiServer.Start(); err != nil {
+				slog.Error("API server stopped with error", "error", err)
+			}
+		}()
+		slog.Info("API server started", "addr", cfg.API.Addr)
+	}
+
+	var metricsServer *metrics.Server
+	if cfg.Metrics.Addr != "" {
+		metrics.RegisterActivityGauges(sessionManager.GetActiveSessionCount, store.GetActiveContextCount)
+		metrics.RegisterDBPoolGauges(store.PoolStats)
+		metricsServer = metrics.NewServer(cfg.Metrics.Addr)
+		go func() {
+			if err := metricsServer.Start(); err != nil {
+				slog.Error("Metrics server stopped with error", "error", err)
+			}
+		}()
+		slog.Info("Metrics server started", "addr", cfg.Metrics.Addr)
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -134,6 +506,18 @@ func main() {
 		cancelWorker()
 		middleware.Stop()
 
+		// Stop accepting API requests; in-flight ones finish within the shutdown timeout
+		if apiServer != nil {
+			if err := apiServer.Shutdown(shutdownCtx); err != nil {
+				slog.Warn("API server shutdown error", "error", err)
+			}
+		}
+		if metricsServer != nil {
+			if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+				slog.Warn("Metrics server shutdown error", "error", err)
+			}
+		}
+
 		activeCount := sessionManager.GetActiveSessionCount()
 		slog.Info("Waiting for active sessions to complete", "count", activeCount, "timeout", "30s")
 
@@ -167,6 +551,16 @@ func main() {
 		// Stop Telegram client gracefully
 		platform.Stop()
 
+		// Let queued /history and /sessions requests finish before closing storage
+		if readPool != nil {
+			readPool.Close()
+		}
+
+		// os.Exit skips deferred calls, so close storage explicitly to flush batched writes
+		if err := store.Close(); err != nil {
+			slog.Warn("Failed to close storage", "error", err)
+		}
+
 		os.Exit(0)
 	}()
 
@@ -194,3 +588,33 @@ func parseLogLevel(level string) slog.Level {
 		return slog.LevelInfo
 	}
 }
+
+// rateLimitOverrides converts configured rate limit overrides for the middleware.
+func rateLimitOverrides(cfg config.RateLimitOverrides) (map[string]bot.RateLimit, map[messaging.ChatType]bot.RateLimit) {
+	byChat := make(map[string]bot.RateLimit, len(cfg.Chats))
+	for id, o := range cfg.Chats {
+		byChat[id] = bot.RateLimit{Limit: o.Limit, Window: o.Window}
+	}
+	byType := make(map[messaging.ChatType]bot.RateLimit, len(cfg.ChatTypes))
+	for chatType, o := range cfg.ChatTypes {
+		byType[messaging.ChatType(chatType)] = bot.RateLimit{Limit: o.Limit, Window: o.Window}
+	}
+	return byChat, byType
+}
+
+// maintenanceWindows parses configured maintenance windows for the scheduler.
+func maintenanceWindows(cfg config.MaintenanceConfig) ([]bot.MaintenanceWindow, *time.Location, error) {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load maintenance.timezone: %w", err)
+	}
+	windows := make([]bot.MaintenanceWindow, 0, len(cfg.Windows))
+	for i, w := range cfg.Windows {
+		schedule, err := bot.ParseSchedule(w.Schedule)
+		if err != nil {
+			return nil, nil, fmt.Errorf("maintenance.windows[%d]: %w", i, err)
+		}
+		windows = append(windows, bot.MaintenanceWindow{Schedule: schedule, Duration: w.Duration, Message: w.Message})
+	}
+	return windows, loc, nil
+}
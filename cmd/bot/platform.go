@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rg/aiops/internal/config"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/messaging/discord"
+	"github.com/rg/aiops/internal/messaging/slack"
+	"github.com/rg/aiops/internal/messaging/telegram"
+)
+
+// newPlatform creates the messaging client selected by messaging.platform.
+func newPlatform(cfg *config.Config) (messaging.Platform, error) {
+	switch cfg.Messaging.Platform {
+	case "telegram":
+		client, err := telegram.NewClient(cfg.Telegram.Token)
+		if err != nil {
+			return nil, err
+		}
+		client.SetFormatFallback(cfg.Telegram.FormatFallback)
+		client.SetStallTimeout(cfg.Telegram.UpdateStallTimeout)
+		return client, nil
+	case "slack":
+		client, err := slack.NewClient(cfg.Slack.Token)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case "discord":
+		client, err := discord.NewClient(cfg.Discord.Token)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unsupported messaging platform: %q", cfg.Messaging.Platform)
+	}
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/storage"
+)
+
+const (
+	// maxRequestBodySize bounds POST /query payloads
+	maxRequestBodySize = 64 * 1024
+	// maxQuerySize matches the limit applied to chat messages
+	maxQuerySize = 10000
+	// apiChatType is stored as the chat type for contexts created via the API
+	apiChatType = "api"
+	// apiChatPrefix namespaces API chat IDs so callers can't reach chat platform contexts
+	apiChatPrefix = apiChatType + ":"
+)
+
+// ContextManager provides per-chat conversation contexts.
+type ContextManager interface {
+	GetOrCreate(chatID, chatType string) (*storage.ChatContext, error)
+	Refresh(chatID string) error
+}
+
+// SessionTracker registers in-memory sessions before execution.
+type SessionTracker interface {
+	GetOrCreateSession(chatID, sessionID string) (*claude.Session, error)
+}
+
+// QueryExecutor runs a query against Claude.
+type QueryExecutor interface {
+	Execute(sessionID, query, claudeSessionID string, chat claude.QueryOptions) (*claude.ClaudeJSONOutput, error)
+}
+
+// Sanitizer redacts secrets from responses.
+type Sanitizer interface {
+	Sanitize(text string) string
+}
+
+// MessageStore persists conversation history.
+type MessageStore interface {
+	SaveMessage(chatID, sessionID, userID, username, role, content string) error
+	UpdateClaudeSessionID(chatID, claudeSessionID string) error
+}
+
+// Server exposes the Claude-backed assistant over HTTP for programmatic use.
+// All endpoints require a bearer token.
+type Server struct {
+	contextManager ContextManager
+	sessions       SessionTracker
+	executor       QueryExecutor
+	sanitizer      Sanitizer
+	store          MessageStore
+	token          string
+	httpServer     *http.Server
+	readiness      func() error
+}
+
+type queryRequest struct {
+	ChatID string `json:"chat_id"`
+	Query  string `json:"query"`
+}
+
+type queryResponse struct {
+	ChatID   string `json:"chat_id"`
+	Response string `json:"response"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func NewServer(
+	addr, token string,
+	contextManager ContextManager,
+	sessions SessionTracker,
+	executor QueryExecutor,
+	sanitizer Sanitizer,
+	store MessageStore,
+) *Server {
+	s := &Server{
+		contextManager: contextManager,
+		sessions:       sessions,
+		executor:       executor,
+		sanitizer:      sanitizer,
+		store:          store,
+		token:          token,
+	}
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.Routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// SetReadinessCheck sets the check reported by GET /readyz. A nil error
+// means ready.
+func (s *Server) SetReadinessCheck(check func() error) {
+	s.readiness = check
+}
+
+// Routes returns the HTTP handler with all API endpoints.
+// /readyz is unauthenticated so orchestrators can probe it.
+func (s *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /query", s.requireToken(http.HandlerFunc(s.handleQuery)))
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	return mux
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if s.readiness != nil {
+		if err := s.readiness(); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: err.Error()})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Start serves HTTP until Shutdown is called.
+func (s *Server) Start() error {
+	slog.Info("API server listening", "addr", s.httpServer.Addr)
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// requireToken rejects requests without a matching bearer token.
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			slog.Warn("API request with invalid token", "remote_addr", r.RemoteAddr, "path", r.URL.Path)
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid JSON body"})
+		return
+	}
+
+	req.ChatID = strings.TrimSpace(req.ChatID)
+	req.Query = strings.TrimSpace(req.Query)
+	if req.ChatID == "" || req.Query == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "chat_id and query are required"})
+		return
+	}
+	if len(req.Query) > maxQuerySize {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "query too long"})
+		return
+	}
+
+	slog.Info("API query received", "chat_id", req.ChatID, "query_length", len(req.Query))
+
+	response, err := s.runQuery(apiChatPrefix+req.ChatID, req.Query)
+	if err != nil {
+		slog.Error("API query failed", "chat_id", req.ChatID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "failed to execute query"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, queryResponse{ChatID: req.ChatID, Response: response})
+}
+
+// runQuery mirrors the chat pipeline: context, session, execution, sanitization, persistence.
+// chatID must already carry apiChatPrefix.
+func (s *Server) runQuery(chatID, query string) (string, error) {
+	ctx, err := s.contextManager.GetOrCreate(chatID, apiChatType)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.contextManager.Refresh(chatID); err != nil {
+		slog.Warn("Failed to refresh context", "chat_id", chatID, "error", err)
+	}
+
+	if err := s.store.SaveMessage(chatID, ctx.SessionID, "", "", storage.RoleUser, s.sanitizer.Sanitize(query)); err != nil {
+		slog.Error("Failed to save user message", "chat_id", chatID, "error", err)
+	}
+
+	if _, err := s.sessions.GetOrCreateSession(chatID, ctx.SessionID); err != nil {
+		return "", err
+	}
+
+	response, err := s.executor.Execute(ctx.SessionID, query, ctx.ClaudeSessionID, claude.QueryOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	if ctx.ClaudeSessionID == "" && response.SessionID != "" {
+		if err := s.store.UpdateClaudeSessionID(chatID, response.SessionID); err != nil {
+			slog.Warn("Failed to save Claude session ID", "chat_id", chatID, "error", err)
+		}
+	}
+
+	sanitized := s.sanitizer.Sanitize(response.Result)
+
+	if err := s.store.SaveMessage(chatID, ctx.SessionID, "", "", storage.RoleAssistant, sanitized); err != nil {
+		return "", err
+	}
+
+	return sanitized, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write API response", "error", err)
+	}
+}
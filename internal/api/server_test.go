@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/security"
+	"github.com/rg/aiops/internal/storage"
+)
+
+type fakeContextManager struct{}
+
+func (f *fakeContextManager) GetOrCreate(chatID, chatType string) (*storage.ChatContext, error) {
+	return &storage.ChatContext{ChatID: chatID, ChatType: chatType, SessionID: "session-1", IsActive: true}, nil
+}
+
+func (f *fakeContextManager) Refresh(chatID string) error {
+	return nil
+}
+
+type fakeSessions struct{}
+
+func (f *fakeSessions) GetOrCreateSession(chatID, sessionID string) (*claude.Session, error) {
+	return &claude.Session{SessionID: sessionID, ChatID: chatID}, nil
+}
+
+type fakeExecutor struct {
+	queries []string
+	result  string
+}
+
+func (f *fakeExecutor) Execute(sessionID, query, claudeSessionID string, chat claude.QueryOptions) (*claude.ClaudeJSONOutput, error) {
+	f.queries = append(f.queries, query)
+	return &claude.ClaudeJSONOutput{Result: f.result, SessionID: "claude-1"}, nil
+}
+
+type fakeStore struct {
+	saved   []string
+	chatIDs []string
+}
+
+func (f *fakeStore) SaveMessage(chatID, sessionID, userID, username, role, content string) error {
+	f.saved = append(f.saved, role+":"+content)
+	f.chatIDs = append(f.chatIDs, chatID)
+	return nil
+}
+
+func (f *fakeStore) UpdateClaudeSessionID(chatID, claudeSessionID string) error {
+	return nil
+}
+
+func newTestServer(t *testing.T, executor *fakeExecutor, store *fakeStore) *Server {
+	t.Helper()
+	sanitizer, err := security.NewSanitizer(security.DefaultPatterns)
+	if err != nil {
+		t.Fatalf("NewSanitizer failed: %v", err)
+	}
+	return NewServer(":0", "secret-token", &fakeContextManager{}, &fakeSessions{}, executor, sanitizer, store)
+}
+
+func doQuery(s *Server, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/query", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.Routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestQuery_RequiresToken(t *testing.T) {
+	executor := &fakeExecutor{result: "ok"}
+	s := newTestServer(t, executor, &fakeStore{})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"missing token", ""},
+		{"wrong token", "wrong-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doQuery(s, tt.token, `{"chat_id":"svc","query":"pods"}`)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("Status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+
+	if len(executor.queries) != 0 {
+		t.Errorf("Executor should not be called without valid token, got %d calls", len(executor.queries))
+	}
+}
+
+func TestQuery_ReturnsSanitizedResponse(t *testing.T) {
+	executor := &fakeExecutor{result: "Found config: api_key=supersecret123"}
+	store := &fakeStore{}
+	s := newTestServer(t, executor, store)
+
+	rec := doQuery(s, "secret-token", `{"chat_id":"svc","query":"show config"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	if len(executor.queries) != 1 || executor.queries[0] != "show config" {
+		t.Errorf("Executor queries = %v, want [show config]", executor.queries)
+	}
+
+	var resp queryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if strings.Contains(resp.Response, "supersecret123") {
+		t.Errorf("Response should be sanitized, got %q", resp.Response)
+	}
+	if !strings.Contains(resp.Response, "REDACTED") {
+		t.Errorf("Response should contain redaction marker, got %q", resp.Response)
+	}
+	if len(store.saved) != 2 {
+		t.Errorf("Expected user and assistant messages saved, got %v", store.saved)
+	}
+}
+
+func TestQuery_NamespacesChatAndRedactsQuery(t *testing.T) {
+	executor := &fakeExecutor{result: "ok"}
+	store := &fakeStore{}
+	s := newTestServer(t, executor, store)
+
+	// A Telegram chat ID must not resolve to that chat's context
+	rec := doQuery(s, "secret-token", `{"chat_id":"-100123","query":"use api_key=supersecret123"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	var resp queryResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.ChatID != "-100123" {
+		t.Errorf("Response chat_id = %q, want the caller's ID", resp.ChatID)
+	}
+	for _, id := range store.chatIDs {
+		if id != "api:-100123" {
+			t.Errorf("Stored chat ID = %q, want api:-100123", id)
+		}
+	}
+	if len(store.saved) == 0 || strings.Contains(store.saved[0], "supersecret123") {
+		t.Errorf("Stored query should be redacted, got %v", store.saved)
+	}
+}
+
+func TestQuery_InvalidBody(t *testing.T) {
+	s := newTestServer(t, &fakeExecutor{}, &fakeStore{})
+
+	for _, body := range []string{"not json", `{"chat_id":"svc"}`, `{"query":"pods"}`} {
+		rec := doQuery(s, "secret-token", body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Body %q: status = %d, want 400", body, rec.Code)
+		}
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	s := newTestServer(t, &fakeExecutor{}, &fakeStore{})
+
+	var readyErr error
+	s.SetReadinessCheck(func() error { return readyErr })
+
+	probe := func() int {
+		rec := httptest.NewRecorder()
+		s.Routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	if code := probe(); code != http.StatusOK {
+		t.Errorf("Healthy /readyz status = %d, want %d", code, http.StatusOK)
+	}
+
+	readyErr = errors.New("claude CLI is not executable")
+	if code := probe(); code != http.StatusServiceUnavailable {
+		t.Errorf("Unhealthy /readyz status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
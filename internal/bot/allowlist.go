@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AllowList decides which messages the bot answers. Entries can be:
+//
+//	123456789                   a chat or user ID
+//	group:-100123               any sender in that chat
+//	group:-100123/user:42       only user 42 in that chat ("user:*" for any member)
+//	user:42                     that user in any chat
+//	-1001999999..-1001000000    any chat or user ID in the inclusive range
+type AllowList struct {
+	ids     map[string]bool
+	chats   map[string]bool
+	users   map[string]bool
+	members map[string]map[string]bool // chat ID -> user IDs, "*" for any
+	ranges  []idRange
+}
+
+// idRange is an inclusive range of numeric IDs.
+type idRange struct {
+	lo, hi int64
+}
+
+// ParseAllowList parses allow-list entries. Invalid entries are reported
+// together in the error; the returned list still holds the valid ones.
+func ParseAllowList(entries []string) (*AllowList, error) {
+	a := &AllowList{
+		ids:     make(map[string]bool),
+		chats:   make(map[string]bool),
+		users:   make(map[string]bool),
+		members: make(map[string]map[string]bool),
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		if err := a.add(strings.TrimSpace(entry)); err != nil {
+			errs = append(errs, fmt.Errorf("allow-list entry %q: %w", entry, err))
+		}
+	}
+	return a, errors.Join(errs...)
+}
+
+func (a *AllowList) add(entry string) error {
+	switch {
+	case entry == "":
+		return errors.New("empty entry")
+	case strings.HasPrefix(entry, "group:"):
+		chat, member, scoped := strings.Cut(strings.TrimPrefix(entry, "group:"), "/")
+		if chat == "" {
+			return errors.New("missing chat ID")
+		}
+		if !scoped {
+			a.chats[chat] = true
+			return nil
+		}
+		user, ok := strings.CutPrefix(member, "user:")
+		if !ok || user == "" {
+			return errors.New(`expected "group:<chat>/user:<id|*>"`)
+		}
+		if a.members[chat] == nil {
+			a.members[chat] = make(map[string]bool)
+		}
+		a.members[chat][user] = true
+	case strings.HasPrefix(entry, "user:"):
+		user := strings.TrimPrefix(entry, "user:")
+		if user == "" || user == "*" {
+			return errors.New(`"user:" needs an ID; use "group:<chat>/user:*" to allow a group's members`)
+		}
+		a.users[user] = true
+	case strings.Contains(entry, ".."):
+		from, to, _ := strings.Cut(entry, "..")
+		lo, errLo := strconv.ParseInt(from, 10, 64)
+		hi, errHi := strconv.ParseInt(to, 10, 64)
+		if errLo != nil || errHi != nil || lo > hi {
+			return errors.New(`expected a numeric range "<low>..<high>"`)
+		}
+		a.ranges = append(a.ranges, idRange{lo: lo, hi: hi})
+	default:
+		a.ids[entry] = true
+	}
+	return nil
+}
+
+// Match reports whether a message in chatID from userID is allowed because
+// of the chat, the user, or both. A nil AllowList allows nothing.
+func (a *AllowList) Match(chatID, userID string) (byChat, byUser bool) {
+	if a == nil {
+		return false, false
+	}
+	byChat = a.ids[chatID] || a.chats[chatID] || a.inRange(chatID)
+	if userID != "" {
+		byUser = a.ids[userID] || a.users[userID] || a.inRange(userID)
+	}
+	if members := a.members[chatID]; members["*"] {
+		byChat = true
+	} else if userID != "" && members[userID] {
+		byChat, byUser = true, true
+	}
+	return byChat, byUser
+}
+
+// Allows reports whether a message in chatID from userID may be answered.
+func (a *AllowList) Allows(chatID, userID string) bool {
+	byChat, byUser := a.Match(chatID, userID)
+	return byChat || byUser
+}
+
+func (a *AllowList) inRange(id string) bool {
+	if len(a.ranges) == 0 {
+		return false
+	}
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return false
+	}
+	for _, r := range a.ranges {
+		if n >= r.lo && n <= r.hi {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAllowList_Match(t *testing.T) {
+	a, err := ParseAllowList([]string{
+		"111",
+		"group:-100123",
+		"group:-100456/user:42",
+		"group:-100789/user:*",
+		"user:77",
+		"-1002000..-1001000",
+	})
+	if err != nil {
+		t.Fatalf("ParseAllowList() error = %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		chatID, userID string
+		byChat, byUser bool
+	}{
+		{"DM by plain ID", "111", "111", true, true},
+		{"DM denied", "222", "222", false, false},
+		{"DM by user entry", "77", "77", false, true},
+		{"group member", "-100123", "999", true, false},
+		{"allowed user in unlisted group", "-100555", "77", false, true},
+		{"plain user ID in unlisted group", "-100555", "111", false, true},
+		{"scoped member", "-100456", "42", true, true},
+		{"scoped group, other member denied", "-100456", "43", false, false},
+		{"wildcard members", "-100789", "5", true, false},
+		{"chat in range", "-1001500", "5", true, false},
+		{"user in range", "-100555", "-1001000", false, true},
+		{"outside range denied", "-1002001", "5", false, false},
+		{"scoped member elsewhere denied", "-100555", "42", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			byChat, byUser := a.Match(tt.chatID, tt.userID)
+			if byChat != tt.byChat || byUser != tt.byUser {
+				t.Errorf("Match(%s, %s) = %v, %v; want %v, %v", tt.chatID, tt.userID, byChat, byUser, tt.byChat, tt.byUser)
+			}
+			if want := tt.byChat || tt.byUser; a.Allows(tt.chatID, tt.userID) != want {
+				t.Errorf("Allows(%s, %s) = %v, want %v", tt.chatID, tt.userID, !want, want)
+			}
+		})
+	}
+}
+
+func TestParseAllowList_InvalidEntries(t *testing.T) {
+	a, err := ParseAllowList([]string{"111", "", "group:", "group:-1/member:2", "user:*", "5..1", "a..b"})
+	if err == nil {
+		t.Fatal("Expected errors for invalid entries")
+	}
+	for _, want := range []string{`""`, `"group:"`, `"group:-1/member:2"`, `"user:*"`, `"5..1"`, `"a..b"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error should mention %s: %v", want, err)
+		}
+	}
+	if !a.Allows("111", "111") {
+		t.Error("Valid entries should still be usable")
+	}
+}
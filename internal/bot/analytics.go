@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// defaultAnalyticsPeriod is used when /analytics is called without a period.
+const defaultAnalyticsPeriod = 7 * 24 * time.Hour
+
+// SetAnalyticsEnabled records slash command usage and registers the admin
+// /analytics command.
+func (h *Handler) SetAnalyticsEnabled(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/analytics"); exists {
+		return
+	}
+	h.analytics = true
+	h.commands.Register(CommandHandler{
+		Name:        "/analytics",
+		Description: "Show command usage and query volume",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleAnalyticsCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+}
+
+// recordCommandUsage stores a command invocation when analytics are enabled.
+// Failures are logged and never block the command.
+func (h *Handler) recordCommandUsage(msg *messaging.IncomingMessage, command string) {
+	if !h.analytics {
+		return
+	}
+	if err := h.storage.RecordCommandUsage(msg.ChatID, msg.From.ID, command); err != nil {
+		slog.Warn("Failed to record command usage", "chat_id", msg.ChatID, "command", command, "error", err)
+	}
+}
+
+// handleAnalyticsCommand handles /analytics [period], e.g. /analytics 24h or /analytics 30d.
+func (h *Handler) handleAnalyticsCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /analytics command", "chat_id", chatID, "args", fields)
+
+	period := defaultAnalyticsPeriod
+	if len(fields) > 1 {
+		p, err := parsePeriod(fields[1])
+		if err != nil {
+			return h.sendText(chatID, "Usage: `/analytics [period]`, e.g. `24h`, `7d`, `30d`", replyToMessageID)
+		}
+		period = p
+	}
+	since := time.Now().Add(-period)
+
+	usage, err := h.storage.GetCommandUsage(since)
+	if err != nil {
+		slog.Error("Failed to get command usage", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to load analytics.", replyToMessageID)
+	}
+
+	queries, err := h.storage.GetQueryCount(since)
+	if err != nil {
+		slog.Error("Failed to get query count", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to load analytics.", replyToMessageID)
+	}
+
+	return h.sendText(chatID, formatAnalyticsResponse(period, usage, queries), replyToMessageID)
+}
+
+// parsePeriod parses a duration that may use a "d" suffix for days.
+func parsePeriod(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid period %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid period %q", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("period must be positive: %q", s)
+	}
+	return d, nil
+}
+
+// formatAnalyticsResponse renders command usage with a share of total invocations.
+func formatAnalyticsResponse(period time.Duration, usage []storage.CommandUsageCount, queries int) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("📊 *Usage Analytics* (last %s)\n\n", formatPeriod(period)))
+	b.WriteString(fmt.Sprintf("*Claude queries:* %d\n", queries))
+
+	total := 0
+	for _, u := range usage {
+		total += u.Count
+	}
+	b.WriteString(fmt.Sprintf("*Commands:* %d\n\n", total))
+
+	if total == 0 {
+		b.WriteString("No commands used in this period.")
+		return b.String()
+	}
+
+	for _, u := range usage {
+		b.WriteString(fmt.Sprintf("%s — %d (%.0f%%)\n", u.Command, u.Count, float64(u.Count)*100/float64(total)))
+	}
+	return b.String()
+}
+
+// formatPeriod renders whole days as "7d" and anything else as a duration.
+func formatPeriod(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
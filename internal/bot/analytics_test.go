@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"24h", 24 * time.Hour, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"0d", 0, true},
+		{"-1h", 0, true},
+		{"week", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePeriod(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePeriod(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatAnalyticsResponse(t *testing.T) {
+	usage := []storage.CommandUsageCount{{Command: "/status", Count: 3}, {Command: "/new", Count: 1}}
+	got := formatAnalyticsResponse(7*24*time.Hour, usage, 12)
+
+	for _, want := range []string{"last 7d", "Claude queries:* 12", "Commands:* 4", "/status — 3 (75%)", "/new — 1 (25%)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatAnalyticsResponse() missing %q:\n%s", want, got)
+		}
+	}
+
+	if empty := formatAnalyticsResponse(time.Hour, nil, 0); !strings.Contains(empty, "No commands used") {
+		t.Errorf("Empty analytics should say no commands used, got %q", empty)
+	}
+}
+
+func TestAnalytics_RecordsDispatchedCommands(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetAnalyticsEnabled(true)
+
+	user := &messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "user"}}
+	admin := &messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}}
+
+	_ = h.dispatchCommand(user, []string{"/help"})
+	_ = h.dispatchCommand(user, []string{"/help"})
+	_ = h.dispatchCommand(user, []string{"/bogus"})     // Unknown commands are not recorded
+	_ = h.dispatchCommand(user, []string{"/analytics"}) // Denied, not recorded
+	_ = h.dispatchCommand(admin, []string{"/analytics", "24h"})
+
+	texts := platform.sentTexts()
+	last := texts[len(texts)-1]
+	if !strings.Contains(last, "/help — 2") || !strings.Contains(last, "/analytics — 1") || strings.Contains(last, "/bogus") {
+		t.Errorf("Unexpected analytics output:\n%s", last)
+	}
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+const (
+	// defaultAuditEntries is how many entries /audit shows without a count.
+	defaultAuditEntries = 20
+	// maxAuditEntries caps the count /audit accepts.
+	maxAuditEntries = 200
+	// maxAuditDetailLen truncates each command or query in /audit.
+	maxAuditDetailLen = 100
+)
+
+// recordAudit records who sent msg, and whether it is a command or a query,
+// or a denied message when the sender isn't allowed. Messages the bot ignores,
+// media without text and queries in sandbox chats, where nothing is stored,
+// are skipped. The text is redacted first. Failures are logged and never
+// block the message.
+func (h *Handler) recordAudit(msg *messaging.IncomingMessage, allowed bool) {
+	action := storage.AuditQuery
+	switch {
+	case !allowed:
+		action = storage.AuditDenied
+	case msg.Text == "" || !h.shouldProcessMessage(msg):
+		return
+	case strings.HasPrefix(msg.Text, "/"):
+		action = storage.AuditCommand
+	case h.isSandbox(msg.ChatID):
+		return
+	}
+	if err := h.storage.RecordAudit(msg.ChatID, msg.From.ID, action, h.sanitize(msg.ChatID, msg.Text)); err != nil {
+		slog.Warn("Failed to record audit entry", "chat_id", msg.ChatID, "action", action, "error", err)
+	}
+}
+
+// handleAuditCommand handles /audit [count], listing the most recent
+// commands, queries and denied messages across all chats with who sent them.
+func (h *Handler) handleAuditCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /audit command", "chat_id", chatID, "args", fields)
+
+	limit := defaultAuditEntries
+	if len(fields) > 1 {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 || n > maxAuditEntries {
+			return h.sendText(chatID, fmt.Sprintf("❌ Invalid count.\n\nUsage: `/audit [count]`, up to %d entries", maxAuditEntries), replyToMessageID)
+		}
+		limit = n
+	}
+
+	entries, err := h.storage.GetRecentAuditEntries(limit)
+	if err != nil {
+		slog.Error("Failed to get audit entries", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to load the audit log.", replyToMessageID)
+	}
+	if len(entries) == 0 {
+		return h.sendText(chatID, "📋 The audit log is empty.", replyToMessageID)
+	}
+
+	// Entries may quote anything users sent, so send them without markup
+	_, err = h.sendChunks(chatID, formatAuditEntries(entries, h.batchSanitizer()), replyToMessageID, true)
+	return err
+}
+
+// formatAuditEntries renders audit entries, newest first, one per line with
+// each command or query redacted by sanitize and truncated.
+func formatAuditEntries(entries []storage.AuditEntry, sanitize func(chatID, text string) string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("📋 Audit log: last %d commands, queries and denials, newest first (UTC)\n\n", len(entries)))
+	for _, e := range entries {
+		user := e.UserID
+		if user == "" {
+			user = "unknown"
+		}
+		detail := strings.Join(strings.Fields(sanitize(e.ChatID, e.Detail)), " ")
+		b.WriteString(fmt.Sprintf("%s chat %s user %s %s: %s\n",
+			e.Time.UTC().Format(time.DateTime), e.ChatID, user, e.Event, truncateText(detail, maxAuditDetailLen)))
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestHandleMessage_RecordsAudit(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer([]string{`hunter2`})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, sm, time.Hour), nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1", "group-1"})
+	h.SetAdminIDs([]string{"alice"})
+
+	for _, msg := range []*messaging.IncomingMessage{
+		{ChatID: "1", MessageID: "1", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: "check the pods, password=hunter2"},
+		{ChatID: "1", MessageID: "2", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: "/status"},
+		// Not addressed to the bot, so not acted on
+		{ChatID: "group-1", MessageID: "3", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "bob"}, Text: "lunch?"},
+		// Not whitelisted
+		{ChatID: "2", MessageID: "4", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "mallory"}, Text: "/status"},
+		{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: "/audit"},
+	} {
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q) error = %v", msg.Text, err)
+		}
+	}
+
+	entries, err := store.GetRecentAuditEntries(10)
+	if err != nil {
+		t.Fatalf("GetRecentAuditEntries() error = %v", err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.UserID+" "+e.Event+" "+e.Detail)
+	}
+	want := []string{"alice command /audit", "mallory denied /status", "alice command /status", "alice query check the pods, password=***REDACTED***"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Audit entries = %q, want %q", got, want)
+	}
+
+	texts := platform.sentTexts()
+	reply := texts[len(texts)-1]
+	for _, w := range []string{"last 4 commands, queries and denials", "chat 2 user mallory denied: /status", "chat 1 user alice query: check the pods"} {
+		if !strings.Contains(reply, w) {
+			t.Errorf("/audit reply = %q, want it to contain %q", reply, w)
+		}
+	}
+}
+
+func TestFormatAuditEntries(t *testing.T) {
+	at := time.Date(2024, 6, 10, 15, 4, 5, 0, time.UTC)
+	entries := []storage.AuditEntry{
+		{Time: at, ChatID: "1", UserID: "alice", Event: storage.AuditQuery, Detail: "token hunter2\nwhy?" + strings.Repeat("x", 200)},
+		{Time: at, ChatID: "2", Event: storage.AuditCommand, Detail: "/new"},
+	}
+	redact := func(_, text string) string { return strings.ReplaceAll(text, "hunter2", "***REDACTED***") }
+
+	got := formatAuditEntries(entries, redact)
+	for _, w := range []string{
+		"2024-06-10 15:04:05 chat 1 user alice query: token ***REDACTED*** why?xxx",
+		"...\n",
+		"2024-06-10 15:04:05 chat 2 user unknown command: /new",
+	} {
+		if !strings.Contains(got, w) {
+			t.Errorf("formatAuditEntries() = %q, want it to contain %q", got, w)
+		}
+	}
+	if strings.Contains(got, "hunter2") {
+		t.Errorf("formatAuditEntries() = %q, want details redacted", got)
+	}
+}
+
+func TestAuditCommand_InvalidCount(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+	if err := h.handleAuditCommand("1", []string{"/audit", "0"}, "5"); err != nil {
+		t.Fatalf("handleAuditCommand() error = %v", err)
+	}
+	if err := h.handleAuditCommand("1", []string{"/audit"}, "5"); err != nil {
+		t.Fatalf("handleAuditCommand() error = %v", err)
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 2 || !strings.Contains(texts[0], "Invalid count") || !strings.Contains(texts[1], "audit log is empty") {
+		t.Errorf("Replies = %q, want a usage hint and an empty log", texts)
+	}
+}
@@ -0,0 +1,15 @@
+package bot
+
+import "github.com/rg/aiops/internal/claude"
+
+// canaryLabel marks answers produced by the canary model or prompt.
+const canaryLabel = "[canary]"
+
+// withCanaryLabel prefixes text with canaryLabel when the response came from
+// the canary, so both variants can be compared in the chat and in history.
+func withCanaryLabel(text string, response *claude.ClaudeJSONOutput) string {
+	if !response.Canary {
+		return text
+	}
+	return canaryLabel + " " + text
+}
@@ -0,0 +1,40 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestCanaryResponsesAreLabeled(t *testing.T) {
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	executor := claude.NewExecutor(sm, "", 0)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, executor, sanitizer, nil, []string{"1"})
+	h.SetSandboxChatIDs([]string{"1"})
+
+	executor.SetCanary(claude.Canary{Percent: 100, Model: "opus"})
+	_ = h.HandleMessage(&messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, Text: "canary question"})
+	executor.SetCanary(claude.Canary{})
+	_ = h.HandleMessage(&messaging.IncomingMessage{ChatID: "1", MessageID: "6", ChatType: messaging.ChatTypePrivate, Text: "default question"})
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 responses, got %v", texts)
+	}
+	if !strings.HasPrefix(texts[0], canaryLabel+" ") || !strings.Contains(texts[0], "--model opus") {
+		t.Errorf("Canary response = %q, want %q label and canary model", texts[0], canaryLabel)
+	}
+	if strings.Contains(texts[1], canaryLabel) {
+		t.Errorf("Default response should not be labeled, got %q", texts[1])
+	}
+}
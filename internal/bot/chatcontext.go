@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// defaultContextOverrideLen bounds /setcontext text when no limit is configured.
+const defaultContextOverrideLen = 2000
+
+// SetContextOverridesEnabled registers the admin /setcontext command and adds
+// each chat's stored snippet to its queries. Snippets longer than maxLen
+// characters are rejected.
+func (h *Handler) SetContextOverridesEnabled(enabled bool, maxLen int) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/setcontext"); exists {
+		return
+	}
+	if maxLen <= 0 {
+		maxLen = defaultContextOverrideLen
+	}
+	h.contextOverrideLen = maxLen
+	h.commands.Register(CommandHandler{
+		Name:        "/setcontext",
+		Description: "Set extra context Claude gets for this chat (/setcontext clear to remove)",
+		AdminOnly:   true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleSetContextCommand(msg.ChatID, commandArgText(msg.Text), msg.MessageID)
+		},
+	})
+}
+
+// commandArgText returns everything after the command word, preserving line
+// breaks that strings.Fields would drop.
+func commandArgText(text string) string {
+	text = strings.TrimSpace(text)
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSpace(text[i:])
+}
+
+// handleSetContextCommand handles /setcontext [text|clear].
+func (h *Handler) handleSetContextCommand(chatID, text, replyToMessageID string) error {
+	slog.Info("Processing /setcontext command", "chat_id", chatID, "length", len(text))
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /setcontext", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", replyToMessageID)
+	}
+	if ctx == nil {
+		return h.sendText(chatID, "❌ No session yet. Send a message first, then set its context.", replyToMessageID)
+	}
+
+	switch text {
+	case "":
+		current, err := h.storage.GetContextOverride(chatID)
+		if err != nil {
+			slog.Error("Failed to get context override", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to retrieve chat context.", replyToMessageID)
+		}
+		if current == "" {
+			return h.sendText(chatID, "📎 No extra context set for this chat.\n\nUsage: `/setcontext <text>` or `/setcontext clear`", replyToMessageID)
+		}
+		return h.sendText(chatID, fmt.Sprintf("📎 *Chat context:*\n```\n%s\n```", current), replyToMessageID)
+	case "clear":
+		if err := h.storage.SetContextOverride(chatID, ""); err != nil {
+			slog.Error("Failed to clear context override", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to clear chat context.", replyToMessageID)
+		}
+		return h.sendText(chatID, "✅ Chat context cleared.", replyToMessageID)
+	}
+
+	cleaned := sanitizeContextOverride(h.sanitizer.Sanitize(text))
+	if n := utf8.RuneCountInString(cleaned); n > h.contextOverrideLen {
+		return h.sendText(chatID, fmt.Sprintf("❌ Context is too long (%d characters, max %d).", n, h.contextOverrideLen), replyToMessageID)
+	}
+	if cleaned == "" {
+		return h.sendText(chatID, "Usage: `/setcontext <text>` or `/setcontext clear`", replyToMessageID)
+	}
+
+	if err := h.storage.SetContextOverride(chatID, cleaned); err != nil {
+		slog.Error("Failed to set context override", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to save chat context.", replyToMessageID)
+	}
+	return h.sendText(chatID, fmt.Sprintf("✅ Chat context saved (%d characters). It will be added to every query in this chat.", utf8.RuneCountInString(cleaned)), replyToMessageID)
+}
+
+// sanitizeContextOverride drops control characters other than newlines and
+// tabs and trims surrounding whitespace.
+func sanitizeContextOverride(text string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, text)
+	return strings.TrimSpace(cleaned)
+}
+
+// applyContextOverride prefixes query with the chat's stored context snippet.
+// Claude already loads the project's CLAUDE.md; the snippet extends it for
+// this chat only.
+func (h *Handler) applyContextOverride(chatID, query string) string {
+	if h.contextOverrideLen == 0 {
+		return query
+	}
+	override, err := h.storage.GetContextOverride(chatID)
+	if err != nil {
+		slog.Warn("Failed to load context override", "chat_id", chatID, "error", err)
+		return query
+	}
+	if override == "" {
+		return query
+	}
+	return fmt.Sprintf("Additional context for this chat:\n%s\n\n%s", override, query)
+}
@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestSetContextCommand_AppliesToThatChatOnly(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "group", "session-1", time.Hour)
+	_, _ = store.CreateContext("2", "group", "session-2", time.Hour)
+
+	sanitizer, _ := security.NewSanitizer([]string{`password\s*=\s*(\S+)`})
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, sanitizer, store, []string{"1", "2"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetContextOverridesEnabled(true, 100)
+
+	msg := &messaging.IncomingMessage{
+		ChatID: "1",
+		From:   messaging.User{ID: "admin"},
+		Text:   "/setcontext Payments cluster is prod-eu.\nNamespace: payments\x07 password=hunter2",
+	}
+	if err := h.dispatchCommand(msg, strings.Fields(msg.Text)); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+
+	got := h.applyContextOverride("1", "why are pods crashing?")
+	if !strings.Contains(got, "Payments cluster is prod-eu.\nNamespace: payments") || !strings.HasSuffix(got, "why are pods crashing?") {
+		t.Errorf("Override not applied to chat 1:\n%s", got)
+	}
+	if strings.Contains(got, "\x07") || strings.Contains(got, "hunter2") {
+		t.Errorf("Override should be sanitized:\n%q", got)
+	}
+	if got := h.applyContextOverride("2", "hello"); got != "hello" {
+		t.Errorf("Override leaked into chat 2: %q", got)
+	}
+
+	// The override survives a session reset
+	_, _ = store.CreateContext("1", "group", "session-3", time.Hour)
+	if got := h.applyContextOverride("1", "q"); !strings.Contains(got, "prod-eu") {
+		t.Errorf("Override should survive a new session, got %q", got)
+	}
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}, Text: "/setcontext clear"}, []string{"/setcontext", "clear"})
+	if got := h.applyContextOverride("1", "q"); got != "q" {
+		t.Errorf("Override should be cleared, got %q", got)
+	}
+}
+
+func TestSetContextCommand_RejectsLongTextAndNonAdmins(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "group", "session-1", time.Hour)
+
+	sanitizer, _ := security.NewSanitizer(nil)
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, sanitizer, store, []string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetContextOverridesEnabled(true, 10)
+
+	long := "/setcontext " + strings.Repeat("x", 11)
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}, Text: long}, strings.Fields(long))
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "user"}, Text: "/setcontext hi"}, []string{"/setcontext", "hi"})
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 || !strings.Contains(texts[0], "too long") {
+		t.Fatalf("Expected a too-long rejection, got %v", texts)
+	}
+	if override, _ := store.GetContextOverride("1"); override != "" {
+		t.Errorf("Nothing should be stored, got %q", override)
+	}
+}
+
+func TestApplyContextOverride_Disabled(t *testing.T) {
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, nil, nil, nil)
+	if got := h.applyContextOverride("1", "q"); got != "q" {
+		t.Errorf("applyContextOverride() = %q, want query unchanged", got)
+	}
+}
@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// CommandFunc handles a slash command. fields holds the command followed by its arguments.
+type CommandFunc func(msg *messaging.IncomingMessage, fields []string) error
+
+// CommandHandler describes a slash command that can be registered with the handler.
+type CommandHandler struct {
+	Name        string // Command including the leading slash, e.g. "/status"
+	Description string // One-line description shown in /help
+	AdminOnly   bool   // Restrict to IDs configured via SetAdminIDs
+	ReadOnly    bool   // Only shows information, so observers may run it
+	Handler     CommandFunc
+}
+
+// CommandRegistry maps command names to their handlers, preserving registration
+// order so /help lists commands consistently.
+type CommandRegistry struct {
+	commands map[string]*CommandHandler
+	order    []string
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{
+		commands: make(map[string]*CommandHandler),
+	}
+}
+
+// Register adds a command to the registry. Like http.ServeMux, it panics on
+// invalid or duplicate registrations since those are programming errors.
+func (r *CommandRegistry) Register(cmd CommandHandler) {
+	if !strings.HasPrefix(cmd.Name, "/") || strings.ContainsAny(cmd.Name, " \t\n") {
+		panic(fmt.Sprintf("bot: invalid command name %q", cmd.Name))
+	}
+	if cmd.Handler == nil {
+		panic(fmt.Sprintf("bot: nil handler for command %s", cmd.Name))
+	}
+	if _, exists := r.commands[cmd.Name]; exists {
+		panic(fmt.Sprintf("bot: command %s registered twice", cmd.Name))
+	}
+
+	r.commands[cmd.Name] = &cmd
+	r.order = append(r.order, cmd.Name)
+}
+
+// Lookup returns the handler registered for name.
+func (r *CommandRegistry) Lookup(name string) (*CommandHandler, bool) {
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+// Commands returns all registered commands in registration order.
+func (r *CommandRegistry) Commands() []*CommandHandler {
+	cmds := make([]*CommandHandler, 0, len(r.order))
+	for _, name := range r.order {
+		cmds = append(cmds, r.commands[name])
+	}
+	return cmds
+}
+
+// formatCommandList renders one "/name - description" line per command,
+// skipping admin-only commands unless includeAdmin is set.
+func formatCommandList(cmds []*CommandHandler, includeAdmin bool) string {
+	var b strings.Builder
+	for _, cmd := range cmds {
+		if cmd.AdminOnly && !includeAdmin {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("%s - %s", cmd.Name, cmd.Description))
+		if cmd.AdminOnly {
+			b.WriteString(" (admin)")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// BotCommands returns the registered commands for the platform's command menu.
+// Admin-only commands are offered only in the configured admin chats.
+func (h *Handler) BotCommands() []messaging.BotCommand {
+	adminIDs := make([]string, 0, len(h.adminIDs))
+	for id := range h.adminIDs {
+		adminIDs = append(adminIDs, id)
+	}
+	sort.Strings(adminIDs)
+
+	var cmds []messaging.BotCommand
+	for _, cmd := range h.commands.Commands() {
+		entry := messaging.BotCommand{
+			Name:        strings.TrimPrefix(cmd.Name, "/"),
+			Description: cmd.Description,
+		}
+		if cmd.AdminOnly {
+			if len(adminIDs) == 0 {
+				continue
+			}
+			entry.ChatIDs = adminIDs
+		}
+		cmds = append(cmds, entry)
+	}
+	return cmds
+}
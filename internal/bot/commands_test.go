@@ -0,0 +1,219 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func newTestHandlerWithPlatform(platform messaging.Platform) *Handler {
+	return NewHandler(platform, nil, nil, nil, nil, nil, nil, nil, []string{"1"})
+}
+
+func TestCommandRegistry_RegisterAndLookup(t *testing.T) {
+	r := NewCommandRegistry()
+	r.Register(CommandHandler{Name: "/b", Description: "second", Handler: func(*messaging.IncomingMessage, []string) error { return nil }})
+	r.Register(CommandHandler{Name: "/a", Description: "first", Handler: func(*messaging.IncomingMessage, []string) error { return nil }})
+
+	if _, ok := r.Lookup("/a"); !ok {
+		t.Error("Lookup(/a) should find registered command")
+	}
+	if _, ok := r.Lookup("/missing"); ok {
+		t.Error("Lookup(/missing) should not find anything")
+	}
+
+	cmds := r.Commands()
+	if len(cmds) != 2 || cmds[0].Name != "/b" || cmds[1].Name != "/a" {
+		t.Errorf("Commands() should preserve registration order, got %v", cmds)
+	}
+}
+
+func TestCommandRegistry_RegisterPanics(t *testing.T) {
+	noop := func(*messaging.IncomingMessage, []string) error { return nil }
+
+	tests := []struct {
+		name string
+		cmd  CommandHandler
+	}{
+		{"missing slash", CommandHandler{Name: "status", Handler: noop}},
+		{"contains space", CommandHandler{Name: "/a b", Handler: noop}},
+		{"nil handler", CommandHandler{Name: "/nil"}},
+		{"duplicate", CommandHandler{Name: "/dup", Handler: noop}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewCommandRegistry()
+			r.Register(CommandHandler{Name: "/dup", Handler: noop})
+
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) should panic", tt.cmd.Name)
+				}
+			}()
+			r.Register(tt.cmd)
+		})
+	}
+}
+
+func TestDispatchCommand(t *testing.T) {
+	platform := newFakePlatform()
+	h := newTestHandlerWithPlatform(platform)
+	h.SetAdminIDs([]string{"admin"})
+
+	var gotFields []string
+	h.RegisterCommand(CommandHandler{
+		Name:        "/echo",
+		Description: "Echo arguments",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			gotFields = fields
+			return nil
+		},
+	})
+	adminCalled := false
+	h.RegisterCommand(CommandHandler{
+		Name:        "/secret",
+		Description: "Admin command",
+		AdminOnly:   true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			adminCalled = true
+			return nil
+		},
+	})
+
+	userMsg := &messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "user"}}
+
+	if err := h.dispatchCommand(userMsg, []string{"/echo", "hello"}); err != nil {
+		t.Fatalf("dispatchCommand(/echo) error: %v", err)
+	}
+	if len(gotFields) != 2 || gotFields[1] != "hello" {
+		t.Errorf("/echo fields = %v, want [/echo hello]", gotFields)
+	}
+
+	_ = h.dispatchCommand(userMsg, []string{"/secret"})
+	if adminCalled {
+		t.Error("Non-admin should not run admin-only command")
+	}
+
+	adminMsg := &messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}}
+	_ = h.dispatchCommand(adminMsg, []string{"/secret"})
+	if !adminCalled {
+		t.Error("Admin should run admin-only command")
+	}
+
+	_ = h.dispatchCommand(userMsg, []string{"/bogus"})
+	texts := platform.sentTexts()
+	last := texts[len(texts)-1]
+	if !strings.Contains(last, "Unknown command: /bogus") || !strings.Contains(last, "/echo - Echo arguments") {
+		t.Errorf("Unknown command reply should list registered commands, got %q", last)
+	}
+	if strings.Contains(last, "/secret") {
+		t.Error("Unknown command reply should not list admin commands to non-admins")
+	}
+}
+
+func TestHelpReflectsRegistry(t *testing.T) {
+	platform := newFakePlatform()
+	h := newTestHandlerWithPlatform(platform)
+	h.SetAdminIDs([]string{"admin"})
+	h.SetSnapshotsEnabled(true)
+	h.RegisterCommand(CommandHandler{
+		Name:        "/deploy",
+		Description: "Deploy the thing",
+		Handler:     func(*messaging.IncomingMessage, []string) error { return nil },
+	})
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "user"}}, []string{"/help"})
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}}, []string{"/help"})
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 help messages, got %d", len(texts))
+	}
+	userHelp, adminHelp := texts[0], texts[1]
+
+	for _, cmd := range h.commands.Commands() {
+		if cmd.AdminOnly {
+			continue
+		}
+		if !strings.Contains(userHelp, cmd.Name+" - "+cmd.Description) {
+			t.Errorf("Help should list %s", cmd.Name)
+		}
+	}
+	if strings.Contains(userHelp, "/snapshot") {
+		t.Error("Help for non-admins should hide admin commands")
+	}
+	if !strings.Contains(adminHelp, "/snapshot") || !strings.Contains(adminHelp, "(admin)") {
+		t.Error("Help for admins should list admin commands")
+	}
+}
+
+func TestBotCommandsReflectRegistry(t *testing.T) {
+	h := newTestHandlerWithPlatform(newFakePlatform())
+	h.SetSnapshotsEnabled(true)
+	h.RegisterCommand(CommandHandler{
+		Name:        "/deploy",
+		Description: "Deploy the thing",
+		Handler:     func(*messaging.IncomingMessage, []string) error { return nil },
+	})
+
+	byName := func(cmds []messaging.BotCommand) map[string]messaging.BotCommand {
+		m := make(map[string]messaging.BotCommand)
+		for _, c := range cmds {
+			m[c.Name] = c
+		}
+		return m
+	}
+
+	// Without admins, admin-only commands are left out entirely
+	got := byName(h.BotCommands())
+	if _, ok := got["snapshot"]; ok {
+		t.Error("Admin command should be hidden when no admins are configured")
+	}
+	if got["deploy"].Description != "Deploy the thing" || len(got["deploy"].ChatIDs) != 0 {
+		t.Errorf("deploy = %+v, want public command with its description", got["deploy"])
+	}
+	for _, cmd := range h.commands.Commands() {
+		if _, ok := got[strings.TrimPrefix(cmd.Name, "/")]; !ok && !cmd.AdminOnly {
+			t.Errorf("Menu should list %s", cmd.Name)
+		}
+	}
+
+	h.SetAdminIDs([]string{"200", "100"})
+	got = byName(h.BotCommands())
+	snapshot, ok := got["snapshot"]
+	if !ok || len(snapshot.ChatIDs) != 2 || snapshot.ChatIDs[0] != "100" || snapshot.ChatIDs[1] != "200" {
+		t.Errorf("snapshot = %+v, want scoped to admin chats", snapshot)
+	}
+}
+
+func TestSessionsCommand_AdminOnly(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1", "2"})
+	h.SetAdminIDs([]string{"admin"})
+	_, _ = store.CreateContext("2", "private", "session-2", time.Hour)
+	_ = store.UpdateClaudeSessionID("2", "claude-secret")
+
+	if err := h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "bob"}}, []string{"/sessions"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+	if err := h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}}, []string{"/sessions"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 replies, got %q", texts)
+	}
+	if !strings.Contains(texts[0], "admins only") || strings.Contains(texts[0], "claude-secret") {
+		t.Errorf("Non-admin reply = %q, want it refused", texts[0])
+	}
+	if !strings.Contains(texts[1], "claude-secret") {
+		t.Errorf("Admin reply = %q, want the other chat's session", texts[1])
+	}
+}
@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// pendingConfirmation tracks the first invocation of a destructive command.
+type pendingConfirmation struct {
+	userID      string
+	requestedAt time.Time
+}
+
+// ConfirmationTracker requires destructive commands in groups to be issued twice
+// within a window before they execute. Pending requests are tracked per chat and
+// invocation, so only a repeat with the same arguments confirms one. When requireDistinctUser is set, the confirming invocation must come
+// from a different user than the one who initiated it.
+type ConfirmationTracker struct {
+	commands            map[string]bool
+	window              time.Duration
+	requireDistinctUser bool
+	pending             map[string]*pendingConfirmation
+	mu                  sync.Mutex
+	now                 func() time.Time
+}
+
+// NewConfirmationTracker creates a tracker for the given commands (e.g. "/new").
+func NewConfirmationTracker(commands []string, window time.Duration, requireDistinctUser bool) *ConfirmationTracker {
+	cmdMap := make(map[string]bool)
+	for _, cmd := range commands {
+		cmdMap[cmd] = true
+	}
+	return &ConfirmationTracker{
+		commands:            cmdMap,
+		window:              window,
+		requireDistinctUser: requireDistinctUser,
+		pending:             make(map[string]*pendingConfirmation),
+		now:                 time.Now,
+	}
+}
+
+// RequiresConfirmation reports whether the command needs a second invocation.
+func (ct *ConfirmationTracker) RequiresConfirmation(command string) bool {
+	return ct.commands[command]
+}
+
+// Window returns how long a pending request waits for confirmation.
+func (ct *ConfirmationTracker) Window() time.Duration {
+	return ct.window
+}
+
+// RequiresDistinctUser reports whether a different user must confirm.
+func (ct *ConfirmationTracker) RequiresDistinctUser() bool {
+	return ct.requireDistinctUser
+}
+
+// Confirm registers an invocation of command with args in chatID by userID.
+// Returns true if this invocation confirms an earlier pending request with the
+// same arguments (the command should execute now), false if it only opened a
+// new request.
+func (ct *ConfirmationTracker) Confirm(chatID, command string, args []string, userID string) bool {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	key := chatID + "|" + strings.Join(append([]string{command}, args...), " ")
+	now := ct.now()
+	ct.purgeExpiredLocked(now)
+
+	if p, exists := ct.pending[key]; exists {
+		if ct.requireDistinctUser && p.userID == userID {
+			// Same user repeating doesn't count - keep waiting for someone else
+			return false
+		}
+		delete(ct.pending, key)
+		return true
+	}
+
+	ct.pending[key] = &pendingConfirmation{
+		userID:      userID,
+		requestedAt: now,
+	}
+	return false
+}
+
+// purgeExpiredLocked drops requests that were never confirmed within the
+// window, so abandoned ones don't accumulate. The caller must hold ct.mu.
+func (ct *ConfirmationTracker) purgeExpiredLocked(now time.Time) {
+	for key, p := range ct.pending {
+		if now.Sub(p.requestedAt) > ct.window {
+			delete(ct.pending, key)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestConfirmationTracker_RequiresConfirmation(t *testing.T) {
+	ct := NewConfirmationTracker([]string{"/new"}, time.Minute, false)
+
+	if !ct.RequiresConfirmation("/new") {
+		t.Error("/new should require confirmation")
+	}
+	if ct.RequiresConfirmation("/status") {
+		t.Error("/status should not require confirmation")
+	}
+}
+
+func TestConfirmationTracker_SecondUserConfirms(t *testing.T) {
+	ct := NewConfirmationTracker([]string{"/new"}, time.Minute, true)
+
+	if ct.Confirm("chat1", "/new", nil, "alice") {
+		t.Fatal("First invocation should not confirm")
+	}
+	if ct.Confirm("chat1", "/new", nil, "alice") {
+		t.Error("Same user should not confirm when a distinct user is required")
+	}
+	if !ct.Confirm("chat1", "/new", nil, "bob") {
+		t.Error("Second user should confirm the pending request")
+	}
+
+	// Pending request is consumed after confirmation
+	if ct.Confirm("chat1", "/new", nil, "carol") {
+		t.Error("Confirmation should reset after executing")
+	}
+}
+
+func TestConfirmationTracker_SameUserTwice(t *testing.T) {
+	ct := NewConfirmationTracker([]string{"/new"}, time.Minute, false)
+
+	if ct.Confirm("chat1", "/new", nil, "alice") {
+		t.Fatal("First invocation should not confirm")
+	}
+	if !ct.Confirm("chat1", "/new", nil, "alice") {
+		t.Error("Same user repeating within window should confirm")
+	}
+}
+
+func TestConfirmationTracker_Timeout(t *testing.T) {
+	ct := NewConfirmationTracker([]string{"/new"}, time.Minute, false)
+	now := time.Now()
+	ct.now = func() time.Time { return now }
+
+	ct.Confirm("chat1", "/new", nil, "alice")
+
+	// Confirmation arrives after the window - opens a fresh request instead
+	now = now.Add(2 * time.Minute)
+	if ct.Confirm("chat1", "/new", nil, "bob") {
+		t.Error("Confirmation after window should not execute")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !ct.Confirm("chat1", "/new", nil, "alice") {
+		t.Error("Confirmation within the new window should execute")
+	}
+}
+
+func TestConfirmationTracker_PurgesExpired(t *testing.T) {
+	ct := NewConfirmationTracker([]string{"/new"}, time.Minute, false)
+	now := time.Now()
+	ct.now = func() time.Time { return now }
+
+	ct.Confirm("chat1", "/new", nil, "alice")
+	ct.Confirm("chat2", "/new", nil, "alice")
+
+	now = now.Add(2 * time.Minute)
+	ct.Confirm("chat3", "/new", nil, "alice")
+	if len(ct.pending) != 1 {
+		t.Errorf("Pending = %d, want only the fresh request kept", len(ct.pending))
+	}
+}
+
+func TestConfirmationTracker_PerChat(t *testing.T) {
+	ct := NewConfirmationTracker([]string{"/new"}, time.Minute, false)
+
+	ct.Confirm("chat1", "/new", nil, "alice")
+	if ct.Confirm("chat2", "/new", nil, "alice") {
+		t.Error("Pending request in chat1 should not confirm chat2")
+	}
+}
+
+func TestConfirmationTracker_PerArguments(t *testing.T) {
+	ct := NewConfirmationTracker([]string{"/kill"}, time.Minute, true)
+
+	ct.Confirm("chat1", "/kill", []string{"session-1"}, "alice")
+	if ct.Confirm("chat1", "/kill", []string{"session-2"}, "bob") {
+		t.Error("A different argument should not confirm another user's request")
+	}
+	if !ct.Confirm("chat1", "/kill", []string{"session-1"}, "bob") {
+		t.Error("The same arguments should confirm")
+	}
+}
+
+func TestConfirmDestructiveCommand(t *testing.T) {
+	platform := newFakePlatform()
+	h := &Handler{platform: platform}
+	h.SetConfirmationTracker(NewConfirmationTracker([]string{"/new"}, time.Minute, true))
+
+	groupMsg := func(userID string) *messaging.IncomingMessage {
+		return &messaging.IncomingMessage{
+			ChatID:   "-100",
+			ChatType: messaging.ChatTypeGroup,
+			From:     messaging.User{ID: userID},
+			Text:     "/new",
+		}
+	}
+
+	if h.confirmDestructiveCommand(groupMsg("alice"), "/new", nil) {
+		t.Fatal("First /new in group should wait for confirmation")
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "Another member must send /new") {
+		t.Errorf("Expected confirmation prompt, got %v", texts)
+	}
+
+	if !h.confirmDestructiveCommand(groupMsg("bob"), "/new", nil) {
+		t.Error("Second user should confirm /new")
+	}
+
+	// DMs and non-destructive commands are never gated
+	dm := &messaging.IncomingMessage{ChatID: "1", ChatType: messaging.ChatTypePrivate, Text: "/new"}
+	if !h.confirmDestructiveCommand(dm, "/new", nil) {
+		t.Error("/new in DM should execute immediately")
+	}
+	if !h.confirmDestructiveCommand(groupMsg("alice"), "/status", nil) {
+		t.Error("/status should execute immediately")
+	}
+}
+
+func TestDispatchCommand_ConfirmsAfterPermissionChecks(t *testing.T) {
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, nil, []string{"-100"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetConfirmationTracker(NewConfirmationTracker([]string{"/kill"}, time.Minute, false))
+
+	user := &messaging.IncomingMessage{ChatID: "-100", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "user"}, Text: "/kill"}
+	_ = h.dispatchCommand(user, []string{"/kill"})
+	if texts := platform.sentTexts(); len(texts) != 1 || !strings.Contains(texts[0], "admins only") {
+		t.Fatalf("Non-admin got %q, want the admin-only refusal", texts)
+	}
+	if len(h.confirmations.pending) != 0 {
+		t.Error("A refused command should not open a confirmation request")
+	}
+
+	admin := &messaging.IncomingMessage{ChatID: "-100", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "admin"}, Text: "/kill"}
+	_ = h.dispatchCommand(admin, []string{"/kill"})
+	if texts := platform.sentTexts(); len(texts) != 2 || !strings.Contains(texts[1], "Send /kill again") {
+		t.Errorf("Admin got %q, want a confirmation prompt", texts)
+	}
+}
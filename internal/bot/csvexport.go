@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+const (
+	csvExportMessages = "messages"
+	csvExportTools    = "tools"
+	csvExportAudit    = "audit"
+
+	// maxCSVExportRows bounds how many rows a single /exportcsv loads.
+	maxCSVExportRows = 50000
+	// defaultCSVExportDays is the range exported when no dates are given.
+	defaultCSVExportDays = 7
+
+	csvExportDateLayout = "2006-01-02"
+	csvExportUsage      = "Usage: `/exportcsv <messages|tools|audit> [from] [to]`\n\n" +
+		"Dates are YYYY-MM-DD in UTC and both are included. Without dates the last 7 days are exported.\n\n" +
+		"Example: `/exportcsv tools 2024-06-01 2024-06-30`"
+)
+
+// SetCSVExport registers the admin /exportcsv command, which uploads
+// messages, tool executions or the audit log of every chat for a date range
+// as a CSV file. Content is redacted like answers are.
+func (h *Handler) SetCSVExport(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/exportcsv"); exists {
+		return
+	}
+	h.commands.Register(CommandHandler{
+		Name:        "/exportcsv",
+		Description: "Download messages, tools or the audit log of all chats as CSV (/exportcsv <messages|tools|audit> [from] [to])",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.runRead(msg, func() error {
+				return h.handleCSVExportCommand(msg.ChatID, fields, msg.MessageID, time.Now())
+			})
+		},
+	})
+}
+
+// handleCSVExportCommand handles /exportcsv <kind> [from] [to].
+func (h *Handler) handleCSVExportCommand(chatID string, fields []string, replyToMessageID string, now time.Time) error {
+	slog.Info("Processing /exportcsv command", "chat_id", chatID, "args", fields)
+
+	if len(fields) < 2 || len(fields) > 4 {
+		return h.sendText(chatID, csvExportUsage, replyToMessageID)
+	}
+	kind := strings.ToLower(fields[1])
+	from, to, err := parseCSVExportRange(fields[2:], now)
+	if err != nil {
+		return h.sendText(chatID, fmt.Sprintf("❌ Can't export: %v.\n\n%s", err, csvExportUsage), replyToMessageID)
+	}
+
+	var content []byte
+	var rows int
+	switch kind {
+	case csvExportMessages:
+		var messages []*storage.Message
+		if messages, err = h.storage.GetMessagesBetween(from, to, maxCSVExportRows); err == nil {
+			content, err = h.formatMessagesCSV(messages)
+			rows = len(messages)
+		}
+	case csvExportTools:
+		var tools []*storage.ToolExecution
+		if tools, err = h.storage.GetToolExecutionsBetween(from, to, maxCSVExportRows); err == nil {
+			content, err = h.formatToolsCSV(tools)
+			rows = len(tools)
+		}
+	case csvExportAudit:
+		var entries []storage.AuditEntry
+		if entries, err = h.storage.GetAuditEntriesBetween(from, to, maxCSVExportRows); err == nil {
+			content, err = h.formatAuditCSV(entries)
+			rows = len(entries)
+		}
+	default:
+		return h.sendText(chatID, csvExportUsage, replyToMessageID)
+	}
+	if err != nil {
+		slog.Error("Failed to export CSV", "chat_id", chatID, "kind", kind, "error", err)
+		return h.sendError(chatID, "Failed to export "+kind+".", replyToMessageID)
+	}
+
+	lastDay := to.AddDate(0, 0, -1).Format(csvExportDateLayout)
+	filename := fmt.Sprintf("%s-%s-to-%s.csv", kind, from.Format(csvExportDateLayout), lastDay)
+	caption := fmt.Sprintf("📊 %d %s rows from %s to %s (UTC)", rows, kind, from.Format(csvExportDateLayout), lastDay)
+	if rows == maxCSVExportRows {
+		caption += fmt.Sprintf("\n⚠️ Limited to the first %d rows; export a shorter range for the rest.", maxCSVExportRows)
+	}
+
+	if _, err := h.platform.SendDocument(chatID, filename, content, caption); err != nil {
+		slog.Error("Failed to send /exportcsv document", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to upload the export.", replyToMessageID)
+	}
+	return nil
+}
+
+// parseCSVExportRange returns the [from, to) range covering the given
+// inclusive dates, defaulting to the last defaultCSVExportDays days up to
+// and including today.
+func parseCSVExportRange(args []string, now time.Time) (time.Time, time.Time, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -(defaultCSVExportDays - 1))
+	to := today
+
+	if len(args) >= 1 {
+		d, err := time.Parse(csvExportDateLayout, args[0])
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start date %q", args[0])
+		}
+		from = d
+	}
+	if len(args) >= 2 {
+		d, err := time.Parse(csvExportDateLayout, args[1])
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end date %q", args[1])
+		}
+		to = d
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("the end date is before the start date")
+	}
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// formatMessagesCSV renders messages as CSV with redacted content.
+func (h *Handler) formatMessagesCSV(messages []*storage.Message) ([]byte, error) {
+	sanitize := h.batchSanitizer()
+	records := [][]string{{"created_at", "chat_id", "session_id", "user_id", "role", "content"}}
+	for _, m := range messages {
+		records = append(records, []string{
+			m.CreatedAt.UTC().Format(time.RFC3339), m.ChatID, m.SessionID, m.UserID, m.Role, sanitize(m.ChatID, m.Content),
+		})
+	}
+	return encodeCSV(records)
+}
+
+// formatToolsCSV renders tool executions as CSV with redacted input and output.
+func (h *Handler) formatToolsCSV(tools []*storage.ToolExecution) ([]byte, error) {
+	sanitize := h.batchSanitizer()
+	records := [][]string{{"created_at", "chat_id", "session_id", "tool", "status", "input", "output"}}
+	for _, t := range tools {
+		records = append(records, []string{
+			t.CreatedAt.UTC().Format(time.RFC3339), t.ChatID, t.SessionID, t.ToolName, t.Status,
+			sanitize(t.ChatID, t.Input), sanitize(t.ChatID, t.Output),
+		})
+	}
+	return encodeCSV(records)
+}
+
+// formatAuditCSV renders audit log entries as CSV with redacted details,
+// which include the queries users sent.
+func (h *Handler) formatAuditCSV(entries []storage.AuditEntry) ([]byte, error) {
+	sanitize := h.batchSanitizer()
+	records := [][]string{{"created_at", "chat_id", "user_id", "event", "detail"}}
+	for _, e := range entries {
+		records = append(records, []string{e.Time.UTC().Format(time.RFC3339), e.ChatID, e.UserID, e.Event, sanitize(e.ChatID, e.Detail)})
+	}
+	return encodeCSV(records)
+}
+
+// encodeCSV writes records as RFC 4180 CSV, quoting fields with commas,
+// quotes or line breaks.
+func encodeCSV(records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestCSVExport_Messages(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.SaveMessage("1", "session-1", "alice", "", "user", `Is "payments", the service, up?`+"\nSecond line")
+	_ = store.SaveMessage("1", "session-1", "", "", "assistant", "Yes, password=hunter2")
+
+	sanitizer, err := security.NewSanitizer([]string{`password=\S+`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, sanitizer, store, []string{"1", "admin"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetCSVExport(true)
+
+	msg := &messaging.IncomingMessage{ChatID: "admin", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "admin"}, Text: "/exportcsv messages"}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+	if len(platform.documents) != 1 {
+		t.Fatalf("Expected one CSV document, got %d (replies %q)", len(platform.documents), platform.sentTexts())
+	}
+	doc := platform.documents[0]
+	if !strings.HasPrefix(doc.Filename, "messages-") || !strings.HasSuffix(doc.Filename, ".csv") || !strings.Contains(doc.Caption, "2 messages rows") {
+		t.Errorf("Unexpected document %q with caption %q", doc.Filename, doc.Caption)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(doc.Content))).ReadAll()
+	if err != nil {
+		t.Fatalf("Export is not valid CSV: %v\n%s", err, doc.Content)
+	}
+	if len(records) != 3 || strings.Join(records[0], ",") != "created_at,chat_id,session_id,user_id,role,content" {
+		t.Fatalf("Unexpected records: %q", records)
+	}
+	if got := records[1][5]; got != `Is "payments", the service, up?`+"\nSecond line" {
+		t.Errorf("Content with commas, quotes and newlines should round-trip, got %q", got)
+	}
+	if !strings.Contains(string(doc.Content), `"Is ""payments"", the service, up?`) {
+		t.Errorf("Quotes should be doubled inside a quoted field, got %s", doc.Content)
+	}
+	if records[2][5] != "Yes, ***REDACTED***" || records[1][3] != "alice" {
+		t.Errorf("Unexpected rows: %q", records[1:])
+	}
+}
+
+func TestCSVExport_ToolsAndAudit(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.SaveToolExecution("1", "session-1", "Bash", "success", `kubectl get pods -l "app=a,b"`, "pod-1, Running")
+	_ = store.RecordAudit("1", "alice", storage.AuditCommand, "/status")
+
+	sanitizer, _ := security.NewSanitizer(nil)
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, sanitizer, store, []string{"1"})
+
+	today := time.Now().UTC().Format("2006-01-02")
+	for kind, header := range map[string]string{
+		"tools": "created_at,chat_id,session_id,tool,status,input,output",
+		"audit": "created_at,chat_id,user_id,event,detail",
+	} {
+		platform := newFakePlatform()
+		h.platform = platform
+		if err := h.handleCSVExportCommand("1", []string{"/exportcsv", kind, today, today}, "5", time.Now()); err != nil {
+			t.Fatalf("handleCSVExportCommand(%s) error = %v", kind, err)
+		}
+		if len(platform.documents) != 1 {
+			t.Fatalf("Expected a %s document, got replies %q", kind, platform.sentTexts())
+		}
+		records, err := csv.NewReader(strings.NewReader(string(platform.documents[0].Content))).ReadAll()
+		if err != nil || len(records) != 2 || strings.Join(records[0], ",") != header {
+			t.Fatalf("%s export = %q, %v", kind, records, err)
+		}
+		if kind == "tools" && (records[1][5] != `kubectl get pods -l "app=a,b"` || records[1][6] != "pod-1, Running") {
+			t.Errorf("Tool row = %q", records[1])
+		}
+		if kind == "audit" && (records[1][3] != "command" || records[1][4] != "/status") {
+			t.Errorf("Audit row = %q", records[1])
+		}
+	}
+}
+
+func TestParseCSVExportRange(t *testing.T) {
+	now := time.Date(2024, 6, 10, 15, 0, 0, 0, time.UTC)
+
+	from, to, err := parseCSVExportRange(nil, now)
+	if err != nil || !from.Equal(time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2024, 6, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Default range = %v - %v, %v; want the last 7 days including today", from, to, err)
+	}
+
+	from, to, err = parseCSVExportRange([]string{"2024-06-01", "2024-06-01"}, now)
+	if err != nil || to.Sub(from) != 24*time.Hour {
+		t.Errorf("Single day range = %v - %v, %v", from, to, err)
+	}
+
+	for _, args := range [][]string{{"yesterday"}, {"2024-06-01", "06/02"}, {"2024-06-02", "2024-06-01"}} {
+		if _, _, err := parseCSVExportRange(args, now); err == nil {
+			t.Errorf("parseCSVExportRange(%q) should fail", args)
+		}
+	}
+}
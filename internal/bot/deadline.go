@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
+)
+
+// deadlineApologyText is sent once a message has waited longer than the
+// response deadline. The real answer still follows when it is ready.
+const deadlineApologyText = "⏳ Sorry, this is taking too long. I'm still working on it and will reply when done."
+
+// SetResponseDeadline sets the end-to-end time allowed for answering a
+// message, covering validation, execution and sending. 0 disables.
+func (h *Handler) SetResponseDeadline(d time.Duration) {
+	h.deadline = d
+}
+
+// withResponseDeadline runs fn and, if it has not returned within the
+// response deadline, apologises to the user and records an SLO breach.
+func (h *Handler) withResponseDeadline(msg *messaging.IncomingMessage, fn func() error) error {
+	if h.deadline <= 0 {
+		return fn()
+	}
+
+	startedAt := time.Now()
+	timer := time.AfterFunc(h.deadline, func() {
+		metrics.ResponseDeadlineBreaches.Inc()
+		slog.Warn("Response deadline exceeded",
+			"chat_id", msg.ChatID,
+			"message_id", msg.MessageID,
+			"deadline", h.deadline)
+		if err := h.sendText(msg.ChatID, deadlineApologyText, msg.MessageID); err != nil {
+			slog.Warn("Failed to send deadline apology", "chat_id", msg.ChatID, "error", err)
+		}
+	})
+
+	err := fn()
+	if !timer.Stop() {
+		slog.Info("Message answered after deadline",
+			"chat_id", msg.ChatID,
+			"message_id", msg.MessageID,
+			"elapsed", time.Since(startedAt))
+	}
+	return err
+}
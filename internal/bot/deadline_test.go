@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
+)
+
+func TestWithResponseDeadline_SlowPipelineApologises(t *testing.T) {
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, nil, nil)
+	h.SetResponseDeadline(10 * time.Millisecond)
+
+	before := testutil.ToFloat64(metrics.ResponseDeadlineBreaches)
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "2"}
+
+	err := h.withResponseDeadline(msg, func() error {
+		time.Sleep(50 * time.Millisecond)
+		return h.sendText(msg.ChatID, "answer", msg.MessageID)
+	})
+	if err != nil {
+		t.Fatalf("withResponseDeadline() error = %v", err)
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 || texts[0] != deadlineApologyText || texts[1] != "answer" {
+		t.Errorf("Expected apology followed by answer, got %v", texts)
+	}
+	if got := testutil.ToFloat64(metrics.ResponseDeadlineBreaches) - before; got != 1 {
+		t.Errorf("Expected 1 recorded breach, got %v", got)
+	}
+}
+
+func TestWithResponseDeadline_FastPipeline(t *testing.T) {
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, nil, nil)
+	h.SetResponseDeadline(time.Second)
+
+	before := testutil.ToFloat64(metrics.ResponseDeadlineBreaches)
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "2"}
+
+	_ = h.withResponseDeadline(msg, func() error {
+		return h.sendText(msg.ChatID, "answer", msg.MessageID)
+	})
+
+	if texts := platform.sentTexts(); len(texts) != 1 {
+		t.Errorf("Expected only the answer, got %v", texts)
+	}
+	if got := testutil.ToFloat64(metrics.ResponseDeadlineBreaches) - before; got != 0 {
+		t.Errorf("Expected no breach, got %v", got)
+	}
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// DebugInfo is the static part of the /debug report.
+type DebugInfo struct {
+	Version string
+	Config  string    // Configuration summary with secrets masked
+	Errors  *ErrorLog // Optional source of recent errors
+}
+
+// SetDebugInfo registers the admin /debug command, which reports info along
+// with live session, storage and Claude CLI state.
+func (h *Handler) SetDebugInfo(info DebugInfo) {
+	if _, exists := h.commands.Lookup("/debug"); exists {
+		return
+	}
+	h.debug = &info
+	h.commands.Register(CommandHandler{
+		Name:        "/debug",
+		Description: "Show a diagnostic report for support tickets",
+		AdminOnly:   true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleDebugCommand(msg.ChatID, msg.MessageID)
+		},
+	})
+}
+
+// handleDebugCommand sends the diagnostic report as plain text, since the
+// config and error lines are not valid Markdown.
+func (h *Handler) handleDebugCommand(chatID, replyToMessageID string) error {
+	slog.Info("Processing /debug command", "chat_id", chatID)
+	report := h.sanitizer.Sanitize(h.formatDebugReport())
+	_, err := h.sendChunks(chatID, report, replyToMessageID, true)
+	return err
+}
+
+// formatDebugReport collects the diagnostic bundle. Failures to read a value
+// are reported inline rather than aborting the report.
+func (h *Handler) formatDebugReport() string {
+	var b strings.Builder
+	b.WriteString("🩺 Debug report\n\n")
+
+	version := h.debug.Version
+	if version == "" {
+		version = "unknown"
+	}
+	b.WriteString(fmt.Sprintf("Version: %s\n", version))
+
+	if schema, err := h.storage.SchemaVersion(); err != nil {
+		b.WriteString(fmt.Sprintf("Schema: error: %v\n", err))
+	} else {
+		b.WriteString(fmt.Sprintf("Schema: %s\n", schema))
+	}
+
+	if h.sessionManager != nil {
+		b.WriteString(fmt.Sprintf("Active sessions: %d\n", h.sessionManager.GetActiveSessionCount()))
+	}
+	if count, err := h.storage.GetActiveContextCount(); err != nil {
+		b.WriteString(fmt.Sprintf("Active contexts: error: %v\n", err))
+	} else {
+		b.WriteString(fmt.Sprintf("Active contexts: %d\n", count))
+	}
+
+	switch {
+	case h.health == nil:
+		b.WriteString("Claude CLI: not monitored\n")
+	case h.health.Status().Healthy:
+		b.WriteString(fmt.Sprintf("Claude CLI: healthy (checked %s)\n", h.health.Status().LastCheck.Format("Jan 2 15:04:05")))
+	default:
+		status := h.health.Status()
+		b.WriteString(fmt.Sprintf("Claude CLI: unhealthy (checked %s): %v\n", status.LastCheck.Format("Jan 2 15:04:05"), status.LastError))
+	}
+
+	b.WriteString("\nRecent errors:\n")
+	var errs []string
+	if h.debug.Errors != nil {
+		errs = h.debug.Errors.Recent()
+	}
+	if len(errs) == 0 {
+		b.WriteString("  none\n")
+	}
+	for _, e := range errs {
+		b.WriteString(fmt.Sprintf("  %s\n", truncateText(e, 300)))
+	}
+
+	if h.debug.Config != "" {
+		b.WriteString("\n")
+		b.WriteString(h.debug.Config)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestDebugCommand_Report(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+
+	sm := claude.NewSessionManager("/nonexistent", t.TempDir(), "", 2, time.Second)
+	_, _ = sm.GetOrCreateSession("1", "session-1")
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	errorLog := NewErrorLog(2)
+	logger := slog.New(errorLog.Handler(slog.NewTextHandler(&strings.Builder{}, nil)))
+	logger.Error("Old failure")
+	logger.With("chat_id", "1").Error("Execution error", "error", "boom")
+	logger.Error("Save failed")
+	logger.Warn("Not an error")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, nil, sanitizer, store, []string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetDebugInfo(DebugInfo{Version: "v1.2.3", Config: "Configuration:\n  Telegram Token: 1234...abcd\n", Errors: errorLog})
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "user"}}, []string{"/debug"})
+	if len(platform.sent) != 1 || strings.Contains(platform.sentTexts()[0], "Debug report") {
+		t.Fatalf("/debug should be admin-only, got %v", platform.sentTexts())
+	}
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}}, []string{"/debug"})
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected a debug report, got %v", texts)
+	}
+	report := texts[1]
+	for _, want := range []string{
+		"Version: v1.2.3",
+		"Schema: 0",
+		"Active sessions: 1",
+		"Active contexts: 1",
+		"Claude CLI: not monitored",
+		"Execution error chat_id=1 error=boom",
+		"Save failed",
+		"Telegram Token: 1234...abcd",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "Old failure") || strings.Contains(report, "Not an error") {
+		t.Errorf("Report should keep only the last 2 errors:\n%s", report)
+	}
+	if !platform.sent[1].PlainText {
+		t.Error("Debug report should be sent as plain text")
+	}
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"sync"
+	"time"
+)
+
+// CommandDeduplicator makes slash commands idempotent per source message.
+// A client that redelivers the same command message within the TTL has the
+// duplicate dropped, so e.g. /new does not clean up twice.
+type CommandDeduplicator struct {
+	ttl  time.Duration
+	seen map[string]time.Time
+	mu   sync.Mutex
+	now  func() time.Time
+}
+
+// NewCommandDeduplicator creates a deduplicator that remembers commands for ttl.
+func NewCommandDeduplicator(ttl time.Duration) *CommandDeduplicator {
+	return &CommandDeduplicator{
+		ttl:  ttl,
+		seen: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+// IsDuplicate records the (chat, command, message) key and reports whether
+// it was already seen within the TTL.
+func (d *CommandDeduplicator) IsDuplicate(chatID, command, messageID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+
+	// Prune expired keys so the map stays bounded by recent traffic
+	for key, seenAt := range d.seen {
+		if now.Sub(seenAt) > d.ttl {
+			delete(d.seen, key)
+		}
+	}
+
+	key := chatID + "|" + command + "|" + messageID
+	if _, exists := d.seen[key]; exists {
+		return true
+	}
+	d.seen[key] = now
+	return false
+}
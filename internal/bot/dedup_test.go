@@ -0,0 +1,77 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestCommandDeduplicator_IsDuplicate(t *testing.T) {
+	d := NewCommandDeduplicator(time.Minute)
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	if d.IsDuplicate("chat1", "/new", "42") {
+		t.Error("First delivery should not be a duplicate")
+	}
+	if !d.IsDuplicate("chat1", "/new", "42") {
+		t.Error("Second delivery of the same message should be a duplicate")
+	}
+	if d.IsDuplicate("chat1", "/new", "43") {
+		t.Error("Different message ID should not be a duplicate")
+	}
+	if d.IsDuplicate("chat2", "/new", "42") {
+		t.Error("Different chat should not be a duplicate")
+	}
+
+	// After the TTL the key is forgotten
+	now = now.Add(2 * time.Minute)
+	if d.IsDuplicate("chat1", "/new", "42") {
+		t.Error("Delivery after TTL should not be a duplicate")
+	}
+}
+
+func TestHandleMessage_DuplicateCommandExecutesOnce(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+	h.SetCommandDeduplicator(NewCommandDeduplicator(time.Minute))
+
+	calls := 0
+	h.RegisterCommand(CommandHandler{
+		Name:        "/ping",
+		Description: "Count calls",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			calls++
+			return nil
+		},
+	})
+
+	msg := &messaging.IncomingMessage{
+		ChatID:    "1",
+		ChatType:  messaging.ChatTypePrivate,
+		MessageID: "100",
+		From:      messaging.User{ID: "1"},
+		Text:      "/ping",
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Duplicate command executed %d times, want 1", calls)
+	}
+
+	// A new message with the same command still runs
+	next := *msg
+	next.MessageID = "101"
+	_ = h.HandleMessage(&next)
+	if calls != 2 {
+		t.Errorf("New command message executed %d times total, want 2", calls)
+	}
+}
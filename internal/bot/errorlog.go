@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// ErrorLog keeps the most recent error-level log records for /debug.
+type ErrorLog struct {
+	mu      sync.Mutex
+	entries []string
+	size    int
+}
+
+// NewErrorLog creates an ErrorLog that remembers the last size errors.
+func NewErrorLog(size int) *ErrorLog {
+	if size <= 0 {
+		size = 10
+	}
+	return &ErrorLog{size: size}
+}
+
+// Handler wraps next so error records are also kept in the log.
+func (l *ErrorLog) Handler(next slog.Handler) slog.Handler {
+	return &errorLogHandler{next: next, log: l}
+}
+
+// Recent returns the remembered errors, oldest first.
+func (l *ErrorLog) Recent() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.entries...)
+}
+
+func (l *ErrorLog) add(entry string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > l.size {
+		l.entries = l.entries[len(l.entries)-l.size:]
+	}
+}
+
+// errorLogHandler passes records to the wrapped handler, copying errors into
+// the ErrorLog as one "time message key=value ..." line each.
+type errorLogHandler struct {
+	next  slog.Handler
+	log   *ErrorLog
+	attrs []slog.Attr
+}
+
+func (h *errorLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= slog.LevelError || h.next.Enabled(ctx, level)
+}
+
+func (h *errorLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		var b strings.Builder
+		b.WriteString(r.Time.Format("Jan 2 15:04:05"))
+		b.WriteString(" ")
+		b.WriteString(r.Message)
+		appendAttr := func(a slog.Attr) bool {
+			b.WriteString(fmt.Sprintf(" %s=%v", a.Key, a.Value))
+			return true
+		}
+		for _, a := range h.attrs {
+			appendAttr(a)
+		}
+		r.Attrs(appendAttr)
+		h.log.add(b.String())
+	}
+	if !h.next.Enabled(ctx, r.Level) {
+		return nil
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *errorLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &errorLogHandler{
+		next:  h.next.WithAttrs(attrs),
+		log:   h.log,
+		attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...),
+	}
+}
+
+func (h *errorLogHandler) WithGroup(name string) slog.Handler {
+	return &errorLogHandler{next: h.next.WithGroup(name), log: h.log, attrs: h.attrs}
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// ErrorEscalation points users at a human once Threshold queries in a row have
+// failed in their chat. Contact is included in the message, e.g. "Ask in
+// #ops-support or page @oncall". NotifyAdmins also alerts bot admins.
+type ErrorEscalation struct {
+	Threshold    int
+	Contact      string
+	NotifyAdmins bool
+}
+
+// failureTracker counts consecutive failed queries per chat. Counts are kept
+// in memory only and start over on restart.
+type failureTracker struct {
+	threshold int
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newFailureTracker(threshold int) *failureTracker {
+	return &failureTracker{
+		threshold: threshold,
+		counts:    make(map[string]int),
+	}
+}
+
+// fail records a failed query in chatID and reports whether this failure is
+// the one that reached the threshold. Further failures don't report again
+// until a success resets the count.
+func (t *failureTracker) fail(chatID string) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counts[chatID]++
+	return t.counts[chatID], t.counts[chatID] == t.threshold
+}
+
+// succeed resets chatID's count of consecutive failures.
+func (t *failureTracker) succeed(chatID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.counts, chatID)
+}
+
+// SetErrorEscalation enables escalation messages for chats whose queries keep
+// failing. A threshold of zero or less disables it.
+func (h *Handler) SetErrorEscalation(escalation ErrorEscalation) {
+	if escalation.Threshold <= 0 {
+		return
+	}
+	h.escalation = escalation
+	h.failures = newFailureTracker(escalation.Threshold)
+}
+
+// recordQueryFailure counts a failed query and, when the chat reaches the
+// escalation threshold, tells it who to contact.
+func (h *Handler) recordQueryFailure(chatID string, queryErr error) {
+	if h.failures == nil {
+		return
+	}
+	count, escalate := h.failures.fail(chatID)
+	if !escalate {
+		return
+	}
+
+	slog.Warn("Escalating repeated query failures", "chat_id", chatID, "failures", count, "error", queryErr)
+	if err := h.sendText(chatID, formatEscalation(count, h.escalation.Contact), ""); err != nil {
+		slog.Warn("Failed to send escalation message", "chat_id", chatID, "error", err)
+	}
+	if h.escalation.NotifyAdmins {
+		h.NotifyAdmins(fmt.Sprintf("🆘 Chat %s: %d queries in a row have failed.\nLast error: %v", chatID, count, queryErr))
+	}
+}
+
+// recordQuerySuccess resets the chat's run of failures.
+func (h *Handler) recordQuerySuccess(chatID string) {
+	if h.failures != nil {
+		h.failures.succeed(chatID)
+	}
+}
+
+// formatEscalation builds the message sent to a chat after count failures.
+func formatEscalation(count int, contact string) string {
+	text := fmt.Sprintf("🆘 The last %d requests here have failed. This looks like more than a one-off problem.", count)
+	if contact == "" {
+		return text + "\n\nPlease contact a bot administrator."
+	}
+	return text + "\n\n" + contact
+}
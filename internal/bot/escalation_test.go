@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestFailureTracker_ReportsThresholdOnce(t *testing.T) {
+	tracker := newFailureTracker(3)
+
+	var reported []int
+	for i := 0; i < 5; i++ {
+		if count, escalate := tracker.fail("1"); escalate {
+			reported = append(reported, count)
+		}
+	}
+	if len(reported) != 1 || reported[0] != 3 {
+		t.Fatalf("Expected a single report at the 3rd failure, got %v", reported)
+	}
+
+	if _, escalate := tracker.fail("2"); escalate {
+		t.Error("Failures in another chat should be counted separately")
+	}
+
+	tracker.succeed("1")
+	for i := 1; i <= 3; i++ {
+		if _, escalate := tracker.fail("1"); escalate != (i == 3) {
+			t.Errorf("After reset, failure %d escalate = %v", i, escalate)
+		}
+	}
+}
+
+func TestErrorEscalation_SentOnceUntilSuccess(t *testing.T) {
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := "#!/bin/sh\ncase \"$*\" in *broken*) exit 1;; esac\necho \"args: $*\"\n"
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+
+	sm := claude.NewSessionManager(cli, t.TempDir(), "", 2, 10*time.Second)
+	executor := claude.NewExecutor(sm, "", 0)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, executor, sanitizer, nil, []string{"1"})
+	h.SetSandboxChatIDs([]string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetErrorEscalation(ErrorEscalation{Threshold: 2, Contact: "Ask in #ops-support.", NotifyAdmins: true})
+
+	send := func(text string) {
+		t.Helper()
+		msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, Text: text}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q) error = %v", text, err)
+		}
+	}
+	escalations := func() (chat, admin int) {
+		for _, m := range platform.sent {
+			if !strings.HasPrefix(m.Text, "🆘") {
+				continue
+			}
+			if m.ChatID == "admin" {
+				admin++
+			} else {
+				chat++
+			}
+		}
+		return chat, admin
+	}
+
+	for i := 0; i < 3; i++ {
+		send("broken query")
+	}
+	if chat, admin := escalations(); chat != 1 || admin != 1 {
+		t.Fatalf("After 3 failures got %d chat and %d admin escalations, want 1 each", chat, admin)
+	}
+	last := platform.sentTexts()
+	if !strings.Contains(strings.Join(last, "\n"), "Ask in #ops-support.") {
+		t.Errorf("Escalation should include the contact, got %v", last)
+	}
+
+	send("working query")
+	send("broken query")
+	if chat, _ := escalations(); chat != 1 {
+		t.Fatalf("A success should reset the count, got %d escalations after one more failure", chat)
+	}
+	send("broken query")
+	if chat, admin := escalations(); chat != 2 || admin != 2 {
+		t.Errorf("Expected a second escalation after the threshold is reached again, got %d chat and %d admin", chat, admin)
+	}
+}
@@ -0,0 +1,32 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rg/aiops/internal/claude"
+)
+
+func TestExecutionErrorText(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"max_turns", &claude.ResultError{Subtype: claude.SubtypeMaxTurns}, "⚠️ Claude hit the max-turns limit"},
+		{"during_execution", fmt.Errorf("wrapped: %w", &claude.ResultError{Subtype: claude.SubtypeExecutionError}), "⚠️ Claude ran into an error"},
+		{"other_result_error", &claude.ResultError{Subtype: "success"}, "⚠️ Claude couldn't complete"},
+		{"workspace", claude.ErrWorkspaceUnavailable, "❌ Project workspace unavailable"},
+		{"generic", errors.New("exit status 1"), "❌ Failed to execute query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := executionErrorText(tt.err); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("executionErrorText() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/rg/aiops/internal/storage"
+)
+
+const (
+	exportFormatMarkdown = "md"
+	exportFormatJSON     = "json"
+
+	// maxExportMessages bounds how many messages a single /export loads.
+	maxExportMessages = 10000
+)
+
+// exportMessage is the JSON representation of a message in /export output.
+type exportMessage struct {
+	Role      string    `json:"role"`
+	UserID    string    `json:"user_id,omitempty"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// exportDocument is the top-level JSON object produced by /export json.
+type exportDocument struct {
+	ChatID          string          `json:"chat_id"`
+	SessionID       string          `json:"session_id"`
+	ClaudeSessionID string          `json:"claude_session_id,omitempty"`
+	ExportedAt      time.Time       `json:"exported_at"`
+	Messages        []exportMessage `json:"messages"`
+}
+
+// handleExportCommand handles /export [md|json]. Unlike /history, message
+// content is not truncated; the whole session is uploaded as a file.
+func (h *Handler) handleExportCommand(chatID, format, replyToMessageID string) error {
+	slog.Info("Processing /export command", "chat_id", chatID, "format", format)
+
+	if format != exportFormatMarkdown && format != exportFormatJSON {
+		return h.sendText(chatID, "Usage: `/export [md|json]`", replyToMessageID)
+	}
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /export", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve conversation history.", replyToMessageID)
+	}
+	if ctx == nil || !ctx.IsActive {
+		return h.sendText(chatID, "📜 No active session. Start chatting to build history!", replyToMessageID)
+	}
+
+	messages, err := h.storage.GetRecentMessagesBySession(chatID, ctx.SessionID, maxExportMessages)
+	if err != nil {
+		slog.Error("Failed to get messages for /export", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve messages.", replyToMessageID)
+	}
+	if len(messages) == 0 {
+		return h.sendText(chatID, "📜 No messages in this session yet.", replyToMessageID)
+	}
+
+	now := time.Now()
+	var content []byte
+	if format == exportFormatJSON {
+		content, err = formatExportJSON(ctx, messages, now)
+		if err != nil {
+			slog.Error("Failed to encode /export", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to export conversation history.", replyToMessageID)
+		}
+	} else {
+		content = []byte(formatExportMarkdown(ctx, messages, now))
+	}
+
+	filename := fmt.Sprintf("history-%s.%s", now.Format("20060102-150405"), format)
+	caption := fmt.Sprintf("📜 %d messages from session %s", len(messages), ctx.SessionID)
+
+	if _, err := h.platform.SendDocument(chatID, filename, content, caption); err != nil {
+		slog.Error("Failed to send /export document", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to upload conversation history.", replyToMessageID)
+	}
+	return nil
+}
+
+// formatExportMarkdown renders the full session as a Markdown document.
+func formatExportMarkdown(ctx *storage.ChatContext, messages []*storage.Message, exportedAt time.Time) string {
+	var b strings.Builder
+
+	b.WriteString("# Conversation History\n\n")
+	b.WriteString(fmt.Sprintf("- Chat: `%s`\n", ctx.ChatID))
+	b.WriteString(fmt.Sprintf("- Session: `%s`\n", ctx.SessionID))
+	if ctx.ClaudeSessionID != "" {
+		b.WriteString(fmt.Sprintf("- Claude Session: `%s`\n", ctx.ClaudeSessionID))
+	}
+	b.WriteString(fmt.Sprintf("- Exported: %s\n", exportedAt.Format(time.RFC3339)))
+	b.WriteString(fmt.Sprintf("- Messages: %d\n", len(messages)))
+
+	for _, msg := range messages {
+		role := "👤 User"
+		switch msg.Role {
+		case storage.RoleAssistant:
+			role = "🤖 Assistant"
+		case storage.RoleSummary, storage.RoleSystem:
+			role = roleLabel(msg.Role)
+		}
+		b.WriteString(fmt.Sprintf("\n## %s — %s\n\n", role, msg.CreatedAt.Format(time.RFC3339)))
+		b.WriteString(msg.Content)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// formatExportJSON renders the full session as indented JSON.
+func formatExportJSON(ctx *storage.ChatContext, messages []*storage.Message, exportedAt time.Time) ([]byte, error) {
+	doc := exportDocument{
+		ChatID:          ctx.ChatID,
+		SessionID:       ctx.SessionID,
+		ClaudeSessionID: ctx.ClaudeSessionID,
+		ExportedAt:      exportedAt,
+		Messages:        make([]exportMessage, 0, len(messages)),
+	}
+	for _, msg := range messages {
+		doc.Messages = append(doc.Messages, exportMessage{
+			Role:      msg.Role,
+			UserID:    msg.UserID,
+			Content:   msg.Content,
+			CreatedAt: msg.CreatedAt,
+		})
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
@@ -0,0 +1,88 @@
+package bot
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestExportCommand_Markdown(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	long := strings.Repeat("x", maxHistoryContentLen*2)
+	_ = store.SaveMessage("1", "session-1", "42", "", "user", "what is wrong?")
+	_ = store.SaveMessage("1", "session-1", "", "", "assistant", long)
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+
+	if err := h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "42"}}, []string{"/export"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+
+	if len(platform.documents) != 1 {
+		t.Fatalf("Expected 1 document, got %d (messages: %v)", len(platform.documents), platform.sentTexts())
+	}
+	doc := platform.documents[0]
+	if !strings.HasSuffix(doc.Filename, ".md") {
+		t.Errorf("Filename = %q, want .md suffix", doc.Filename)
+	}
+	content := string(doc.Content)
+	for _, want := range []string{"`session-1`", "what is wrong?", long} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Export missing %.40q", want)
+		}
+	}
+}
+
+func TestExportCommand_JSON(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.SaveMessage("1", "session-1", "42", "", "user", "hello")
+	_ = store.SaveMessage("1", "session-1", "", "", "assistant", "hi")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1"}, []string{"/export", "JSON"})
+
+	if len(platform.documents) != 1 {
+		t.Fatalf("Expected 1 document, got %d", len(platform.documents))
+	}
+	var got exportDocument
+	if err := json.Unmarshal(platform.documents[0].Content, &got); err != nil {
+		t.Fatalf("Export is not valid JSON: %v", err)
+	}
+	if got.SessionID != "session-1" || len(got.Messages) != 2 {
+		t.Fatalf("Unexpected export: %+v", got)
+	}
+	if got.Messages[0].UserID != "42" || got.Messages[0].Content != "hello" || got.Messages[1].Role != "assistant" {
+		t.Errorf("Unexpected messages: %+v", got.Messages)
+	}
+}
+
+func TestExportCommand_NoSessionOrBadFormat(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1"}, []string{"/export"})
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1"}, []string{"/export", "pdf"})
+
+	if len(platform.documents) != 0 {
+		t.Errorf("Expected no documents, got %d", len(platform.documents))
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 2 || !strings.Contains(texts[0], "No active session") || !strings.Contains(texts[1], "Usage") {
+		t.Errorf("Unexpected replies: %v", texts)
+	}
+}
@@ -0,0 +1,140 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// fakePlatform records outgoing messages and reactions for handler tests.
+type fakePlatform struct {
+	mu        sync.Mutex
+	sent      []*messaging.OutgoingMessage
+	reactions []string
+	documents []fakeDocument
+	edits     []fakeEdit
+	chatType  messaging.ChatType
+	nextID    int
+}
+
+// fakeDocument is a file uploaded through SendDocument.
+type fakeDocument struct {
+	ChatID   string
+	Filename string
+	Content  []byte
+	Caption  string
+}
+
+// fakeEdit is a message edit made through EditMessage.
+type fakeEdit struct {
+	MessageID string
+	Text      string
+}
+
+func newFakePlatform() *fakePlatform {
+	return &fakePlatform{chatType: messaging.ChatTypePrivate}
+}
+
+func (f *fakePlatform) SendMessage(msg *messaging.OutgoingMessage) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, msg)
+	f.nextID++
+	return strconv.Itoa(f.nextID), nil
+}
+
+func (f *fakePlatform) SendDocument(chatID, filename string, content []byte, caption string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.documents = append(f.documents, fakeDocument{ChatID: chatID, Filename: filename, Content: content, Caption: caption})
+	f.nextID++
+	return strconv.Itoa(f.nextID), nil
+}
+
+func (f *fakePlatform) EditMessage(chatID, messageID, text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.edits = append(f.edits, fakeEdit{MessageID: messageID, Text: text})
+	return nil
+}
+
+func (f *fakePlatform) AddReaction(chatID, messageID, emoji string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reactions = append(f.reactions, emoji)
+	return nil
+}
+
+func (f *fakePlatform) SendTyping(chatID string) error {
+	return nil
+}
+
+func (f *fakePlatform) GetChatType(chatID string) (messaging.ChatType, error) {
+	return f.chatType, nil
+}
+
+func (f *fakePlatform) IsGroupOrChannel(chatID string) bool {
+	return f.chatType.IsGroupOrChannel()
+}
+
+func (f *fakePlatform) Start(handler messaging.MessageHandler) error {
+	return nil
+}
+
+func (f *fakePlatform) Stop() {}
+
+// sentTexts returns the text of every message sent so far.
+func (f *fakePlatform) sentTexts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	texts := make([]string, 0, len(f.sent))
+	for _, msg := range f.sent {
+		texts = append(texts, msg.Text)
+	}
+	return texts
+}
+
+// newTestSanitizer returns a sanitizer without patterns.
+func newTestSanitizer(t *testing.T) *security.Sanitizer {
+	t.Helper()
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	return sanitizer
+}
+
+// setupTestStorage opens a storage backed by the repository's real migrations.
+// It temporarily changes into the module root so the migrations glob resolves.
+func setupTestStorage(t *testing.T) (*storage.Storage, func()) {
+	t.Helper()
+
+	tmpDir, err := os.MkdirTemp("", "aiops-bot-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+
+	oldWd, _ := os.Getwd()
+	if err := os.Chdir(filepath.Join(oldWd, "..", "..")); err != nil {
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to chdir to module root: %v", err)
+	}
+
+	store, err := storage.NewStorage(filepath.Join(tmpDir, "test.db"))
+	os.Chdir(oldWd)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+
+	return store, func() {
+		store.Close()
+		os.RemoveAll(tmpDir)
+	}
+}
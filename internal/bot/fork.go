@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// forkTimeout bounds the Claude call that copies the conversation.
+const forkTimeout = 2 * time.Minute
+
+// forkNote tells Claude about the fork when copying the conversation.
+const forkNote = "This conversation was just forked so the user can explore an alternative " +
+	"without affecting the original investigation. Nothing has changed yet. Reply with OK only."
+
+// handleForkCommand handles /fork. It continues the chat in a copy of its
+// session, so a hypothesis can be explored without polluting the original:
+// Claude's conversation is forked and the session's messages are copied. The
+// original session is left as it was, and /resume switches back to it.
+func (h *Handler) handleForkCommand(msg *messaging.IncomingMessage) error {
+	chatID, replyTo := msg.ChatID, msg.MessageID
+	slog.Info("Processing /fork command", "chat_id", chatID)
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /fork", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", replyTo)
+	}
+	if ctx == nil || !ctx.IsActive {
+		return h.sendText(chatID, "ℹ️ No active session to fork.", replyTo)
+	}
+	if ctx.ClaudeSessionID == "" {
+		return h.sendText(chatID, "ℹ️ Nothing to fork yet. Ask a question first.", replyTo)
+	}
+
+	if err := h.platform.SendTyping(chatID); err != nil {
+		slog.Warn("Failed to send typing indicator", "chat_id", chatID, "error", err)
+	}
+
+	callCtx, cancel := context.WithTimeout(context.Background(), forkTimeout)
+	defer cancel()
+	forkedClaudeID, err := h.executor.ForkConversation(callCtx, ctx.ClaudeSessionID, h.chatProjectPath(chatID), forkNote)
+	if err != nil {
+		slog.Error("Failed to fork Claude conversation", "chat_id", chatID, "session_id", ctx.SessionID, "error", err)
+		return h.sendError(chatID, "Failed to fork the session. Please try again.", replyTo)
+	}
+
+	newSessionID := h.contextManager.GenerateSessionID()
+	result, err := h.storage.ForkContext(chatID, newSessionID)
+	if err != nil {
+		slog.Error("Failed to fork context", "chat_id", chatID, "session_id", ctx.SessionID, "error", err)
+		return h.sendError(chatID, "Failed to fork the session. Please try again.", replyTo)
+	}
+	// Until this succeeds the fork resumes the original conversation, so
+	// questions in it would pollute the original
+	if err := h.storage.UpdateClaudeSessionID(chatID, forkedClaudeID); err != nil {
+		slog.Error("Failed to save forked Claude session ID", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to switch to the forked session. Use /new to start over.", replyTo)
+	}
+
+	// The original session lives on only in storage
+	if err := h.sessionManager.KillSession(ctx.SessionID); err != nil {
+		slog.Debug("Failed to remove original session from manager", "session_id", ctx.SessionID, "error", err)
+	}
+
+	// Shows the fork's lineage in /history
+	note := "Forked from session " + shortSessionID(result.ParentSessionID)
+	if err := h.storage.SaveMessage(chatID, newSessionID, msg.From.ID, "", storage.RoleSystem, note); err != nil {
+		slog.Warn("Failed to save fork note", "chat_id", chatID, "error", err)
+	}
+
+	slog.Info("Forked session",
+		"chat_id", chatID,
+		"parent_session_id", result.ParentSessionID,
+		"claude_session_id", result.ClaudeSessionID,
+		"forked_claude_session_id", forkedClaudeID,
+		"messages", result.MessagesCopied)
+
+	return h.sendText(chatID, fmt.Sprintf("🍴 *Session forked*\n\n"+
+		"This chat now continues in a copy of the session, with its %d messages. "+
+		"Explore freely: the original session `%s` is kept as it was. "+
+		"Use `/resume %s` to go back to it.\n\n"+
+		"Use /new to start over instead.",
+		result.MessagesCopied, shortSessionID(result.ParentSessionID), result.ClaudeSessionID), replyTo)
+}
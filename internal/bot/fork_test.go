@@ -0,0 +1,122 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestForkCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	// The fake CLI starts a new conversation when asked to fork
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+resume=""
+fork=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --resume) resume="$2"; shift ;;
+    --fork-session) fork=1 ;;
+  esac
+  shift
+done
+id="$resume"
+[ -n "$fork" ] && id="$resume-fork"
+echo '{"type":"result","subtype":"success","result":"OK","session_id":"'"$id"'"}'
+`
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := claude.NewSessionManager(cli, t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, sm, time.Hour), nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1"})
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "9", From: messaging.User{ID: "alice"}, Text: "/fork"}
+	if err := h.handleForkCommand(msg); err != nil {
+		t.Fatalf("handleForkCommand() error = %v", err)
+	}
+	if texts := platform.sentTexts(); len(texts) != 1 || !strings.Contains(texts[0], "No active session") {
+		t.Fatalf("Without a session got %q", texts)
+	}
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("1", "claude-1")
+	_ = store.SaveMessage("1", "session-1", "alice", "", storage.RoleUser, "why is checkout down?")
+	_ = store.SaveMessage("1", "session-1", "alice", "", storage.RoleAssistant, "The pods are OOMKilled.")
+
+	if err := h.handleForkCommand(msg); err != nil {
+		t.Fatalf("handleForkCommand() error = %v", err)
+	}
+	texts := platform.sentTexts()
+	if got := texts[len(texts)-1]; !strings.Contains(got, "Session forked") || !strings.Contains(got, "2 messages") {
+		t.Errorf("Reply = %q, want the fork confirmed", got)
+	}
+
+	ctx, _ := store.GetContext("1")
+	if ctx.SessionID == "session-1" || ctx.ClaudeSessionID != "claude-1-fork" || !ctx.IsActive {
+		t.Fatalf("Context = %+v, want an active fork on the forked Claude conversation", ctx)
+	}
+
+	messages, _ := store.GetRecentMessagesBySession("1", ctx.SessionID, 10)
+	if len(messages) != 3 || messages[0].Content != "why is checkout down?" {
+		t.Fatalf("Forked messages = %+v, want the copied history and a fork note", messages)
+	}
+	if last := messages[2]; last.Role != storage.RoleSystem || last.Content != "Forked from session session-" {
+		t.Errorf("Last message = %+v, want a system note naming the original session", last)
+	}
+	if n, _ := store.GetMessageCountBySession("1", "session-1"); n != 2 {
+		t.Errorf("Original session has %d messages, want 2 left in place", n)
+	}
+
+	// The original session stays resumable, and so does the fork it leaves
+	resume := &messaging.IncomingMessage{ChatID: "1", MessageID: "10", From: messaging.User{ID: "alice"}, Text: "/resume claude-1"}
+	if err := h.handleResumeCommand(resume, strings.Fields(resume.Text)); err != nil {
+		t.Fatalf("handleResumeCommand() error = %v", err)
+	}
+	texts = platform.sentTexts()
+	if got := texts[len(texts)-1]; !strings.Contains(got, "Session resumed") || !strings.Contains(got, "/resume claude-1-fork") {
+		t.Errorf("Reply = %q, want the original resumed", got)
+	}
+	if ctx, _ := store.GetContext("1"); ctx.SessionID != "session-1" || ctx.ClaudeSessionID != "claude-1" || !ctx.IsActive {
+		t.Fatalf("Context after /resume = %+v, want the original session back", ctx)
+	}
+
+	resume.Text = "/resume claude-1-fork"
+	if err := h.handleResumeCommand(resume, strings.Fields(resume.Text)); err != nil {
+		t.Fatalf("handleResumeCommand() error = %v", err)
+	}
+	if ctx, _ := store.GetContext("1"); ctx.ClaudeSessionID != "claude-1-fork" || ctx.SessionID == "session-1" {
+		t.Errorf("Context after resuming the fork = %+v, want the fork back", ctx)
+	}
+}
+
+func TestForkCommand_NoQuestionYet(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "9", Text: "/fork"}
+	if err := h.handleForkCommand(msg); err != nil {
+		t.Fatalf("handleForkCommand() error = %v", err)
+	}
+	if texts := platform.sentTexts(); len(texts) != 1 || !strings.Contains(texts[0], "Ask a question first") {
+		t.Errorf("Reply = %q, want a hint to ask first", texts)
+	}
+}
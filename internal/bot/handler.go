@@ -1,14 +1,18 @@
 package bot
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rg/aiops/internal/claude"
 	"github.com/rg/aiops/internal/context"
 	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
 	"github.com/rg/aiops/internal/security"
 	"github.com/rg/aiops/internal/storage"
 )
@@ -18,6 +22,8 @@ const (
 	maxTelegramMessageLen = 4000
 	// maxHistoryContentLen is the max length for message content in /history output
 	maxHistoryContentLen = 500
+	// historyPageSize is the number of messages per /history page
+	historyPageSize = 20
 )
 
 type Handler struct {
@@ -29,9 +35,83 @@ type Handler struct {
 	executor       *claude.Executor
 	sanitizer      *security.Sanitizer
 	storage        *storage.Storage
-	allowedChatIDs map[string]bool
+	allowList      *AllowList
+	confirmations  *ConfirmationTracker
+	toolClassifier *claude.ToolClassifier
+	adminIDs       map[string]bool
+	observerIDs    map[string]bool
+	commands       *CommandRegistry
+	envLabel       string
+	envLabelAll    bool
+	health         *claude.HealthChecker
+	commandDedup   *CommandDeduplicator
+	prefsEnabled   bool
+	toolLoopLimit  int
+	maxTools       int
+	analytics      bool
+	readPool       *ReadPool
+	reactions      Reactions
+	deadline       time.Duration
+
+	incidents       bool
+	incidentWebhook *IncidentWebhook
+
+	shortcuts  map[string]ReactionAction
+	retryQuery func(msg *messaging.IncomingMessage) error
+
+	sandboxChatIDs map[string]bool
+
+	contextOverrideLen int // 0 = /setcontext disabled
+	mediaCaptions      bool
+
+	streaming      bool
+	streamInterval time.Duration
+
+	debug *DebugInfo // nil = /debug disabled
+
+	defaultModel  string
+	allowedModels []string // Empty = /model disabled
+
+	projects map[string]string // Project name -> directory; empty = /project disabled
+
+	chatRedactions bool
+	redactQueries  bool // Send questions to Claude redacted, not just store them so
+
+	redactionThreshold float64 // Flag answers this much redacted; 0 = off
+	redactionNotify    bool    // Tell the user when an answer is flagged
+
+	resources *claude.ResourceExtractor // nil = no Kubernetes overview
+
+	onCall OnCallResolver // nil = /handoff disabled
+
+	inlineCache *inlineCache // nil = inline queries disabled
+
+	escalation ErrorEscalation
+	failures   *failureTracker // nil = escalation disabled
+
+	recordRaw bool // Store raw CLI output for /replay
+
+	transferPolicy *TransferPolicy // nil = any transfer allowed
+
+	maintenance maintenanceState
+
+	answerThreads bool // Answer in a forum topic per session
+
+	summaryModel   string
+	summaryTimeout time.Duration // 0 = /summarize disabled
+}
+
+// Reactions are the emoji added to a user's message at each stage of handling
+// a query. Empty Success or Error leaves the Processing reaction in place.
+type Reactions struct {
+	Processing string
+	Success    string
+	Error      string
 }
 
+// DefaultReactions marks a message as being processed and nothing else.
+var DefaultReactions = Reactions{Processing: "👀"}
+
 func NewHandler(
 	platform messaging.Platform,
 	contextManager *context.Manager,
@@ -43,13 +123,12 @@ func NewHandler(
 	storage *storage.Storage,
 	allowedChatIDs []string,
 ) *Handler {
-	// Build allowed chat IDs map for O(1) lookup
-	allowedMap := make(map[string]bool)
-	for _, chatID := range allowedChatIDs {
-		allowedMap[chatID] = true
+	allowList, err := ParseAllowList(allowedChatIDs)
+	if err != nil {
+		slog.Error("Ignoring invalid allowed_chat_ids entries", "error", err)
 	}
 
-	return &Handler{
+	h := &Handler{
 		platform:       platform,
 		contextManager: contextManager,
 		expiryWorker:   expiryWorker,
@@ -58,10 +137,388 @@ func NewHandler(
 		executor:       executor,
 		sanitizer:      sanitizer,
 		storage:        storage,
-		allowedChatIDs: allowedMap,
+		allowList:      allowList,
+		commands:       NewCommandRegistry(),
+		reactions:      DefaultReactions,
+	}
+	h.registerBuiltinCommands()
+	return h
+}
+
+// registerBuiltinCommands registers the core slash commands. Order here is
+// the order they appear in /help.
+func (h *Handler) registerBuiltinCommands() {
+	h.commands.Register(CommandHandler{
+		Name:        "/status",
+		Description: "Show session information and statistics",
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleStatusCommand(msg.ChatID, h.isAdmin(msg), msg.MessageID)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/help",
+		Description: "Display this help message",
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleHelpCommand(msg.ChatID, h.isAdmin(msg), msg.MessageID)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/whoami",
+		Description: "Show your user ID, this chat's ID and whether you are authorized",
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleWhoAmICommand(msg)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/history",
+		Description: "Show conversation history, newest first (/history <page>, /history mine for your messages only)",
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			mine := len(fields) > 1 && fields[1] == "mine"
+			page := 1
+			if len(fields) > 1 && !mine {
+				n, err := strconv.Atoi(fields[1])
+				if err != nil || n < 1 {
+					return h.sendText(msg.ChatID, "❌ Invalid page number.\n\nUsage: `/history [page]` or `/history mine`", msg.MessageID)
+				}
+				page = n
+			}
+			return h.runRead(msg, func() error {
+				return h.handleHistoryCommand(msg.ChatID, msg.From.ID, mine, page, msg.MessageID)
+			})
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/export",
+		Description: "Download full conversation history as a file (/export json for JSON)",
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			format := exportFormatMarkdown
+			if len(fields) > 1 {
+				format = strings.ToLower(fields[1])
+			}
+			return h.runRead(msg, func() error {
+				return h.handleExportCommand(msg.ChatID, format, msg.MessageID)
+			})
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/search",
+		Description: "Find messages in this session (or all sessions with /search all) that mention some text",
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.runRead(msg, func() error {
+				return h.handleSearchCommand(msg.ChatID, fields, msg.MessageID)
+			})
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/tools",
+		Description: "Show tools Claude ran in this session, with their input and output",
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.runRead(msg, func() error {
+				return h.handleToolsCommand(msg.ChatID, msg.MessageID)
+			})
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/session",
+		Description: "Show Claude session ID for transfer",
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleSessionCommand(msg.ChatID, msg.MessageID)
+		},
+	})
+	// Admin-only: it exposes every chat's session IDs, which /resume accepts
+	h.commands.Register(CommandHandler{
+		Name:        "/sessions",
+		Description: "List all sessions across all chats",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.runRead(msg, func() error {
+				return h.handleSessionsCommand(msg.ChatID, msg.MessageID)
+			})
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/kill",
+		Description: "Stop a runaway session's running and queued queries and deactivate it (/kill <session_id>)",
+		AdminOnly:   true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleKillCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/resume",
+		Description: "Reactivate expired session or transfer from another chat",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleResumeCommand(msg, fields)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/new",
+		Description: "Reset session and start fresh",
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleNewCommand(msg.ChatID, msg.MessageID)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/fork",
+		Description: "Continue in a copy of this session to explore an alternative, keeping the original as it was",
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleForkCommand(msg)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/ttl",
+		Description: "Show or change how long this chat's session lasts (/ttl 8h, /ttl default)",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleTTLCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/maintenance",
+		Description: "Show or toggle maintenance mode, which pauses answers (/maintenance on [message], /maintenance off)",
+		AdminOnly:   true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleMaintenanceCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+	h.commands.Register(CommandHandler{
+		Name:        "/audit",
+		Description: "Show who sent the most recent commands, queries and denied messages in all chats (/audit [count])",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.runRead(msg, func() error {
+				return h.handleAuditCommand(msg.ChatID, fields, msg.MessageID)
+			})
+		},
+	})
+}
+
+// SetReactions overrides the per-outcome reactions. Empty fields keep their
+// current value.
+func (h *Handler) SetReactions(r Reactions) {
+	if r.Processing != "" {
+		h.reactions.Processing = r.Processing
+	}
+	if r.Success != "" {
+		h.reactions.Success = r.Success
+	}
+	if r.Error != "" {
+		h.reactions.Error = r.Error
+	}
+}
+
+// react adds emoji to the user's message. Reactions are cosmetic, so failures
+// are only logged.
+func (h *Handler) react(msg *messaging.IncomingMessage, emoji string) {
+	if emoji == "" {
+		return
+	}
+	if err := h.platform.AddReaction(msg.ChatID, msg.MessageID, emoji); err != nil {
+		slog.Warn("Failed to add reaction",
+			"chat_id", msg.ChatID,
+			"message_id", msg.MessageID,
+			"emoji", emoji,
+			"error", err)
+	}
+}
+
+// readPoolBusyText is sent when a read-heavy command cannot be queued.
+const readPoolBusyText = "⏳ Too many history and session requests right now. Please try again shortly."
+
+// SetReadPool routes read-heavy commands through a bounded worker pool.
+// Without a pool they run on the message-handling goroutine.
+func (h *Handler) SetReadPool(p *ReadPool) {
+	h.readPool = p
+}
+
+// runRead executes a read-heavy command, via the read pool when configured.
+func (h *Handler) runRead(msg *messaging.IncomingMessage, fn func() error) error {
+	if h.readPool == nil {
+		return fn()
+	}
+	if !h.readPool.Submit(fn) {
+		slog.Warn("Read pool queue full, rejecting command", "chat_id", msg.ChatID, "pending", h.readPool.Pending())
+		return h.sendText(msg.ChatID, readPoolBusyText, msg.MessageID)
+	}
+	return nil
+}
+
+// RegisterCommand adds a custom slash command. It panics if the name is
+// invalid or already registered.
+func (h *Handler) RegisterCommand(cmd CommandHandler) {
+	h.commands.Register(cmd)
+}
+
+// SetConfirmationTracker enables two-step confirmation for destructive commands in groups.
+func (h *Handler) SetConfirmationTracker(ct *ConfirmationTracker) {
+	h.confirmations = ct
+}
+
+// SetAdminIDs sets the user/chat IDs allowed to run admin-only commands.
+func (h *Handler) SetAdminIDs(adminIDs []string) {
+	h.adminIDs = make(map[string]bool)
+	for _, id := range adminIDs {
+		h.adminIDs[id] = true
+	}
+}
+
+// SetObserverIDs marks users who can read the bot's answers and use
+// read-only commands but can't trigger queries or change sessions.
+func (h *Handler) SetObserverIDs(userIDs []string) {
+	h.observerIDs = make(map[string]bool)
+	for _, id := range userIDs {
+		h.observerIDs[id] = true
+	}
+}
+
+// isObserver reports whether userID is limited to read-only access.
+func (h *Handler) isObserver(userID string) bool {
+	return h.observerIDs[userID]
+}
+
+// SetSnapshotsEnabled registers the admin /snapshot command when enabled.
+func (h *Handler) SetSnapshotsEnabled(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/snapshot"); exists {
+		return
+	}
+	h.commands.Register(CommandHandler{
+		Name:        "/snapshot",
+		Description: "Create, list or restore database snapshots",
+		AdminOnly:   true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleSnapshotCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+}
+
+// isAdmin reports whether the message sender or chat is in the admin list.
+func (h *Handler) isAdmin(msg *messaging.IncomingMessage) bool {
+	return h.adminIDs[msg.From.ID] || h.adminIDs[msg.ChatID]
+}
+
+// SetEnvironmentLabel prefixes /status and /new confirmations with label.
+// When allResponses is set, Claude responses are labelled as well.
+func (h *Handler) SetEnvironmentLabel(label string, allResponses bool) {
+	h.envLabel = label
+	h.envLabelAll = allResponses
+}
+
+// withEnvLabel prefixes text with the environment label. Labels are applied to
+// confirmations always, and to other responses only when all-responses is enabled.
+func (h *Handler) withEnvLabel(text string, confirmation bool) string {
+	if h.envLabel == "" || strings.TrimSpace(text) == "" {
+		return text
+	}
+	if !confirmation && !h.envLabelAll {
+		return text
+	}
+	return h.envLabel + " " + text
+}
+
+// SetCommandDeduplicator drops redelivered copies of the same command message.
+func (h *Handler) SetCommandDeduplicator(d *CommandDeduplicator) {
+	h.commandDedup = d
+}
+
+// SetHealthChecker enables reporting Claude CLI health in /status.
+func (h *Handler) SetHealthChecker(hc *claude.HealthChecker) {
+	h.health = hc
+}
+
+// NotifyAdmins sends text to every configured admin ID. Failures are logged,
+// since an admin may not have started a DM with the bot.
+func (h *Handler) NotifyAdmins(text string) {
+	for id := range h.adminIDs {
+		outMsg := &messaging.OutgoingMessage{ChatID: id, Text: text}
+		if _, err := h.platform.SendMessage(outMsg); err != nil {
+			slog.Warn("Failed to notify admin", "admin_id", id, "error", err)
+		}
 	}
 }
 
+// NotifyQueuePosition tells a chat its query is waiting for a free slot.
+// Intended as a claude.QueueNotifier.
+func (h *Handler) NotifyQueuePosition(chatID string, position int) {
+	outMsg := &messaging.OutgoingMessage{
+		ChatID: chatID,
+		Text:   fmt.Sprintf("⏳ You're #%d in the queue. Your request will start shortly.", position),
+	}
+	if _, err := h.platform.SendMessage(outMsg); err != nil {
+		slog.Warn("Failed to send queue position", "chat_id", chatID, "position", position, "error", err)
+	}
+}
+
+// formatHealthWarning returns a /status line for an unhealthy CLI, or "".
+func formatHealthWarning(status claude.HealthStatus) string {
+	if status.Healthy {
+		return ""
+	}
+	return fmt.Sprintf("\n\n⚠️ *Claude CLI unhealthy* (since %s): %v",
+		status.LastCheck.Format("3:04 PM"), status.LastError)
+}
+
+// formatLoadStatus describes how busy the bot is and whether a new question
+// would have to wait for a free query slot.
+func formatLoadStatus(sessions, running, limit, waiting int) string {
+	status := fmt.Sprintf("\n\n🖥 *Bot load:* %d/%d queries running, %d active sessions", running, limit, sessions)
+	if running >= limit {
+		return status + fmt.Sprintf("\n⏳ All query slots are busy; a new question would wait in the queue behind %d others.", waiting)
+	}
+	return status + "\n✅ A new question would start right away."
+}
+
+// formatQueueStatus renders the query queue numbers shown to admins in /status.
+func formatQueueStatus(depth int, dropped int64) string {
+	return fmt.Sprintf("\n\n🚦 *Query queue:* %d waiting, %d dropped", depth, dropped)
+}
+
+// formatPoolStatus describes database connection pool usage for admins,
+// warning when every connection is in use.
+func formatPoolStatus(stats sql.DBStats) string {
+	status := fmt.Sprintf("\n🗄 *DB connections:* %d in use, %d idle of %d max, %d waits",
+		stats.InUse, stats.Idle, stats.MaxOpenConnections, stats.WaitCount)
+	if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+		status += "\n⚠️ Connection pool saturated; queries are waiting for connections."
+	}
+	return status
+}
+
+// SetToolLoopThreshold flags responses in which the same tool call was
+// repeated at least threshold times. 0 disables detection.
+func (h *Handler) SetToolLoopThreshold(threshold int) {
+	h.toolLoopLimit = threshold
+}
+
+// SetMaxToolsPerResponse caps how many tool executions are stored per
+// response. Extra tools are replaced by a single "N more omitted" entry.
+// 0 keeps every tool.
+func (h *Handler) SetMaxToolsPerResponse(max int) {
+	h.maxTools = max
+}
+
+// SetMediaCaptionsEnabled makes captions on photos, documents and videos
+// count as queries. The media itself is not sent to Claude.
+func (h *Handler) SetMediaCaptionsEnabled(enabled bool) {
+	h.mediaCaptions = enabled
+}
+
+// SetToolClassifier enables flagging responses that used write-mode tools.
+func (h *Handler) SetToolClassifier(c *claude.ToolClassifier) {
+	h.toolClassifier = c
+}
+
 func (h *Handler) HandleMessage(msg *messaging.IncomingMessage) error {
 	slog.Info("Received message",
 		"chat_id", msg.ChatID,
@@ -69,10 +526,16 @@ func (h *Handler) HandleMessage(msg *messaging.IncomingMessage) error {
 		"text", truncateText(msg.Text, 100))
 
 	// Check whitelist - can contain both user IDs and chat/group IDs
-	if !h.allowedChatIDs[msg.ChatID] && !h.allowedChatIDs[msg.From.ID] {
+	allowed := h.allowList.Allows(msg.ChatID, msg.From.ID)
+	h.recordAudit(msg, allowed)
+	if !allowed {
 		slog.Warn("Ignoring non-whitelisted message",
 			"chat_id", msg.ChatID,
 			"user_id", msg.From.ID)
+		// /whoami still answers so the caller can find the IDs to whitelist
+		if fields := strings.Fields(msg.Text); len(fields) > 0 && fields[0] == "/whoami" {
+			return h.handleWhoAmICommand(msg)
+		}
 		// Send permission denied message to user
 		outMsg := &messaging.OutgoingMessage{
 			ChatID:           msg.ChatID,
@@ -110,6 +573,13 @@ func (h *Handler) HandleMessage(msg *messaging.IncomingMessage) error {
 		return nil // Silently ignore (not an error)
 	}
 
+	// Non-text messages: captions can be answered when enabled, anything else
+	// gets an explanation instead of an empty query
+	if !msg.Kind.IsText() && (!h.mediaCaptions || msg.Text == "") {
+		slog.Info("Rejecting non-text message", "chat_id", msg.ChatID, "kind", msg.Kind)
+		return h.sendText(msg.ChatID, formatUnsupportedMessage(msg.Kind, h.mediaCaptions), msg.MessageID)
+	}
+
 	// Check for slash commands
 	if strings.HasPrefix(msg.Text, "/") {
 		fields := strings.Fields(msg.Text)
@@ -117,51 +587,40 @@ func (h *Handler) HandleMessage(msg *messaging.IncomingMessage) error {
 			return nil // Ignore whitespace-only messages starting with /
 		}
 		cmd := fields[0]
-		switch cmd {
-		case "/new":
-			return h.handleNewCommand(msg.ChatID, msg.MessageID)
-		case "/status":
-			return h.handleStatusCommand(msg.ChatID, msg.MessageID)
-		case "/help":
-			return h.handleHelpCommand(msg.ChatID, msg.MessageID)
-		case "/history":
-			return h.handleHistoryCommand(msg.ChatID, msg.MessageID)
-		case "/session":
-			return h.handleSessionCommand(msg.ChatID, msg.MessageID)
-		case "/sessions":
-			return h.handleSessionsCommand(msg.ChatID, msg.MessageID)
-		case "/resume":
-			return h.handleResumeCommand(msg.ChatID, fields, msg.MessageID)
-		default:
-			// Unknown slash command - return helpful message
-			outMsg := &messaging.OutgoingMessage{
-				ChatID: msg.ChatID,
-				Text: fmt.Sprintf("❓ Unknown command: %s\n\nAvailable commands:\n"+
-					"/status - Show session info\n"+
-					"/help - Show help message\n"+
-					"/history - Export conversation history\n"+
-					"/session - Show session ID for transfer\n"+
-					"/sessions - List all sessions\n"+
-					"/resume - Resume or transfer a session\n"+
-					"/new - Reset session\n\n"+
-					"For other queries, just ask without using a slash command.",
-					cmd),
-				ReplyToMessageID: msg.MessageID,
-			}
-			_, err := h.platform.SendMessage(outMsg)
-			return err
+
+		// Drop redelivered commands before confirmation so a duplicate can't confirm itself
+		if h.commandDedup != nil && h.commandDedup.IsDuplicate(msg.ChatID, cmd, msg.MessageID) {
+			slog.Info("Ignoring duplicate command",
+				"chat_id", msg.ChatID,
+				"command", cmd,
+				"message_id", msg.MessageID)
+			return nil
 		}
+
+		return h.dispatchCommand(msg, fields)
+	}
+
+	if reply, on := h.maintenanceReply(); on {
+		slog.Info("Refusing query during maintenance", "chat_id", msg.ChatID)
+		return h.sendText(msg.ChatID, reply, msg.MessageID)
 	}
 
+	if h.isSandbox(msg.ChatID) {
+		return h.withResponseDeadline(msg, func() error {
+			return h.handleSandboxQuery(msg)
+		})
+	}
+
+	return h.withResponseDeadline(msg, func() error {
+		return h.handleQuery(msg)
+	})
+}
+
+// handleQuery runs a regular (non-command) message through Claude and sends the response.
+func (h *Handler) handleQuery(msg *messaging.IncomingMessage) error {
 	// Add reaction BEFORE processing (not for slash commands - they're instant)
 	// This provides immediate feedback that the bot is working
-	if err := h.platform.AddReaction(msg.ChatID, msg.MessageID, "👀"); err != nil {
-		slog.Warn("Failed to add eyes reaction",
-			"chat_id", msg.ChatID,
-			"message_id", msg.MessageID,
-			"error", err)
-		// Continue processing even if reaction fails (non-blocking)
-	}
+	h.react(msg, h.reactions.Processing)
 
 	chatType, err := h.platform.GetChatType(msg.ChatID)
 	if err != nil {
@@ -178,7 +637,9 @@ func (h *Handler) HandleMessage(msg *messaging.IncomingMessage) error {
 		slog.Warn("Failed to refresh context", "chat_id", msg.ChatID, "error", err)
 	}
 
-	if err := h.storage.SaveMessage(msg.ChatID, ctx.SessionID, "user", msg.Text); err != nil {
+	// Secrets pasted into questions must not persist in history
+	redactedText := h.sanitize(msg.ChatID, msg.Text)
+	if err := h.storage.SaveMessage(msg.ChatID, ctx.SessionID, msg.From.ID, msg.From.Username, storage.RoleUser, redactedText); err != nil {
 		// Log error but continue - user message loss is acceptable, we still want to respond
 		slog.Error("Failed to save user message", "chat_id", msg.ChatID, "error", err)
 	}
@@ -209,12 +670,39 @@ func (h *Handler) HandleMessage(msg *messaging.IncomingMessage) error {
 		return h.sendError(msg.ChatID, "Failed to initialize Claude process. Please try again later.", msg.MessageID)
 	}
 
+	prefs := h.loadPreferences(msg.ChatID)
+	startedAt := time.Now()
+
+	// Answers in a session topic can't reply to the question, which is outside it
+	threadID := h.answerThread(msg, ctx)
+	answerReplyTo := msg.MessageID
+	if threadID != "" {
+		answerReplyTo = ""
+	}
+
 	// Execute query with Claude session ID for conversation isolation
-	response, err := h.executor.Execute(ctx.SessionID, msg.Text, ctx.ClaudeSessionID)
+	text := msg.Text
+	if h.redactQueries {
+		text = redactedText
+	}
+	query := h.applyContextOverride(msg.ChatID, prefs.applyToQuery(text))
+	var progress *streamProgress
+	var response *claude.ClaudeJSONOutput
+	chatOpts := claude.QueryOptions{Model: h.chatModel(msg.ChatID), ProjectPath: h.chatProjectPath(msg.ChatID)}
+	if h.streaming {
+		progress = h.newStreamProgress(msg.ChatID, threadID, answerReplyTo)
+		response, err = h.executor.ExecuteStream(ctx.SessionID, query, ctx.ClaudeSessionID, chatOpts, progress.update)
+	} else {
+		response, err = h.executor.Execute(ctx.SessionID, query, ctx.ClaudeSessionID, chatOpts)
+	}
 	if err != nil {
-		slog.Error("Execution error", "chat_id", msg.ChatID, "session_id", ctx.SessionID, "query", msg.Text, "error", err)
-		return h.sendError(msg.ChatID, "Failed to execute query. The service may be temporarily unavailable.", msg.MessageID)
+		slog.Error("Execution error", "chat_id", msg.ChatID, "session_id", ctx.SessionID, "query", redactedText, "error", err)
+		h.react(msg, h.reactions.Error)
+		sendErr := h.sendText(msg.ChatID, executionErrorText(err), msg.MessageID)
+		h.recordQueryFailure(msg.ChatID, err)
+		return sendErr
 	}
+	h.recordQuerySuccess(msg.ChatID)
 
 	// If this was the first message, store the Claude session ID
 	if ctx.ClaudeSessionID == "" && response.SessionID != "" {
@@ -225,52 +713,237 @@ func (h *Handler) HandleMessage(msg *messaging.IncomingMessage) error {
 		}
 	}
 
-	sanitized := h.sanitizer.Sanitize(response.Result)
+	sanitized := withCanaryLabel(h.sanitize(msg.ChatID, response.Result), response)
+	redactionNotice := h.checkRedaction(msg.ChatID, ctx.SessionID, response.Result)
 
-	// Critical: Don't send response if we can't persist it (prevents data loss)
-	if err := h.storage.SaveMessage(msg.ChatID, ctx.SessionID, "assistant", sanitized); err != nil {
+	// Critical: Don't send response if we can't persist it (prevents data loss).
+	// With write batching the save is queued, so only validation errors surface here.
+	if err := h.storage.SaveMessage(msg.ChatID, ctx.SessionID, msg.From.ID, msg.From.Username, storage.RoleAssistant, sanitized); err != nil {
 		slog.Error("Failed to save assistant message", "chat_id", msg.ChatID, "error", err)
 		return h.sendError(msg.ChatID, "Failed to save response. Please try again.", msg.MessageID)
 	}
 
-	tools := claude.ExtractToolExecutions(response.Result)
-	for _, tool := range tools {
-		if err := h.storage.SaveToolExecution(msg.ChatID, ctx.SessionID, tool.ToolName, tool.Status); err != nil {
+	tools := response.Tools
+	h.saveToolExecutions(msg.ChatID, ctx.SessionID, tools)
+	h.saveRawOutput(msg.ChatID, ctx.SessionID, redactedText, response)
+	if response.TraceID != "" {
+		if err := h.storage.SaveQueryTrace(msg.ChatID, ctx.SessionID, msg.From.ID, response.TraceID); err != nil {
+			slog.Warn("Failed to save query trace", "chat_id", msg.ChatID, "trace_id", response.TraceID, "error", err)
+		}
+	}
+
+	warnings, writeTools, repeats := h.toolWarnings(tools)
+	if len(writeTools) > 0 {
+		slog.Warn("Response used write-mode tools", "chat_id", msg.ChatID, "tools", writeTools)
+	}
+	if len(repeats) > 0 {
+		slog.Warn("Repeated identical tool calls detected", "chat_id", msg.ChatID, "repeats", repeats)
+		for _, r := range repeats {
+			metrics.ToolLoopsDetected.WithLabelValues(toolMetricLabel(r.ToolName)).Inc()
+		}
+	}
+	sanitized = warnings + redactionNotice + sanitized + h.resourceOverview(sanitized)
+
+	sanitized = prefs.applyToResponse(sanitized, time.Since(startedAt), len(tools))
+	sentIDs, err := h.sendAnswer(progress, msg.ChatID, threadID, h.withEnvLabel(sanitized, false), answerReplyTo, prefs.Plain)
+	if err != nil {
+		return err
+	}
+	h.linkResponse(msg, ctx.SessionID, redactedText, sanitized, sentIDs)
+	h.react(msg, h.reactions.Success)
+	return nil
+}
+
+// toolWarnings returns the warnings shown above an answer that used tools,
+// along with the write-mode tools and repeated calls that caused them.
+func (h *Handler) toolWarnings(tools []claude.ToolExecution) (string, []string, []claude.ToolRepeat) {
+	var warnings string
+	var writeTools []string
+	// Flag responses where Claude used tools that can modify resources
+	if h.toolClassifier != nil {
+		if writeTools = h.toolClassifier.WriteTools(tools); len(writeTools) > 0 {
+			warnings = formatWriteToolsWarning(writeTools)
+		}
+	}
+	// Flag responses where Claude appears to have looped on the same tool call
+	repeats := claude.DetectToolLoops(tools, h.toolLoopLimit)
+	if len(repeats) > 0 {
+		warnings = formatToolLoopWarning(repeats) + warnings
+	}
+	return warnings, writeTools, repeats
+}
+
+// saveToolExecutions persists the tools used in one response with secrets
+// redacted, keeping at most maxTools and recording the remainder as a single
+// note.
+func (h *Handler) saveToolExecutions(chatID, sessionID string, tools []claude.ToolExecution) {
+	kept, omitted := claude.LimitToolExecutions(tools, h.maxTools)
+	// Tools often read files holding secrets, so nothing is stored unredacted
+	sanitize := h.batchSanitizer()
+	for _, tool := range kept {
+		input, output := sanitize(chatID, tool.Input), sanitize(chatID, tool.Output)
+		if err := h.storage.SaveToolExecution(chatID, sessionID, tool.ToolName, tool.Status, input, output); err != nil {
 			slog.Warn("Failed to save tool execution",
-				"chat_id", msg.ChatID,
+				"chat_id", chatID,
 				"tool", tool.ToolName,
 				"error", err)
 		}
 	}
+	if omitted > 0 {
+		slog.Info("Omitted tool executions beyond per-response cap", "chat_id", chatID, "max", h.maxTools, "omitted", omitted)
+		if err := h.storage.SaveToolExecution(chatID, sessionID, formatOmittedToolsNote(omitted), "success", "", ""); err != nil {
+			slog.Warn("Failed to save omitted tools note", "chat_id", chatID, "error", err)
+		}
+	}
+}
+
+// unsupportedMessageLabels describes non-text messages in the reply sent for them.
+var unsupportedMessageLabels = map[messaging.MessageKind]string{
+	messaging.MessageKindPhoto:    "🖼 Photo received",
+	messaging.MessageKindDocument: "📄 File received",
+	messaging.MessageKindVideo:    "🎬 Video received",
+	messaging.MessageKindVoice:    "🎤 Voice message received",
+	messaging.MessageKindAudio:    "🎵 Audio received",
+	messaging.MessageKindSticker:  "Sticker received",
+	messaging.MessageKindLocation: "📍 Location received",
+	messaging.MessageKindContact:  "👤 Contact received",
+}
+
+// formatUnsupportedMessage builds the reply for a message the bot can't answer.
+func formatUnsupportedMessage(kind messaging.MessageKind, captions bool) string {
+	label, ok := unsupportedMessageLabels[kind]
+	if !ok {
+		label = "Message received"
+	}
+	text := label + ", but I can only process text right now."
+	if captions && (kind == messaging.MessageKindPhoto || kind == messaging.MessageKindDocument || kind == messaging.MessageKindVideo) {
+		text += " Add a caption with your question."
+	}
+	return text
+}
+
+// formatOmittedToolsNote names the placeholder stored in place of tools
+// dropped by the per-response cap.
+func formatOmittedToolsNote(omitted int) string {
+	return fmt.Sprintf("… %d more omitted", omitted)
+}
+
+// formatWriteToolsWarning builds the banner prepended to responses that used write tools.
+func formatWriteToolsWarning(writeTools []string) string {
+	return fmt.Sprintf("⚠️ *This action modified resources* (%s)\n\n", strings.Join(writeTools, ", "))
+}
+
+// formatToolLoopWarning builds the banner prepended to responses with repeated tool calls.
+func formatToolLoopWarning(repeats []claude.ToolRepeat) string {
+	parts := make([]string, 0, len(repeats))
+	for _, r := range repeats {
+		parts = append(parts, fmt.Sprintf("%s ×%d", truncateText(r.ToolName, 50), r.Count))
+	}
+	return fmt.Sprintf("🔁 *Possible tool loop detected* (%s)\n\n", strings.Join(parts, ", "))
+}
+
+// toolMetricLabel reduces a parsed tool line to its tool name, keeping metric
+// label cardinality bounded.
+func toolMetricLabel(toolLine string) string {
+	if fields := strings.Fields(toolLine); len(fields) > 0 {
+		return fields[0]
+	}
+	return "unknown"
+}
 
-	return h.sendResponse(msg.ChatID, sanitized, msg.MessageID)
+// confirmDestructiveCommand returns true if cmd may execute now. For commands that
+// require confirmation in groups, the first invocation only opens a pending request
+// and asks for a confirming repeat with the same args within the window.
+func (h *Handler) confirmDestructiveCommand(msg *messaging.IncomingMessage, cmd string, args []string) bool {
+	if h.confirmations == nil || !msg.ChatType.IsGroupOrChannel() || !h.confirmations.RequiresConfirmation(cmd) {
+		return true
+	}
+
+	if h.confirmations.Confirm(msg.ChatID, cmd, args, msg.From.ID) {
+		slog.Info("Destructive command confirmed", "chat_id", msg.ChatID, "user_id", msg.From.ID, "command", cmd, "args", args)
+		return true
+	}
+
+	slog.Info("Destructive command awaiting confirmation", "chat_id", msg.ChatID, "user_id", msg.From.ID, "command", cmd, "args", args)
+	invocation := strings.Join(append([]string{cmd}, args...), " ")
+	confirm := fmt.Sprintf("Send %s again within %s to confirm.", invocation, formatDuration(h.confirmations.Window()))
+	if h.confirmations.RequiresDistinctUser() {
+		confirm = fmt.Sprintf("Another member must send %s within %s to confirm.", invocation, formatDuration(h.confirmations.Window()))
+	}
+	outMsg := &messaging.OutgoingMessage{
+		ChatID:           msg.ChatID,
+		Text:             fmt.Sprintf("⚠️ %s affects everyone in this chat.\n\n%s", cmd, confirm),
+		ReplyToMessageID: msg.MessageID,
+	}
+	if _, err := h.platform.SendMessage(outMsg); err != nil {
+		slog.Warn("Failed to send confirmation request", "chat_id", msg.ChatID, "error", err)
+	}
+	return false
 }
 
 func (h *Handler) sendResponse(chatID, text string, replyToMessageID string) error {
+	_, err := h.sendChunks(chatID, text, replyToMessageID, false)
+	return err
+}
+
+// sendChunks splits text into platform-sized chunks and sends them as a reply
+// chain, returning the IDs of the sent messages. When plain is set, chunks are
+// sent without Markdown parsing.
+func (h *Handler) sendChunks(chatID, text string, replyToMessageID string, plain bool) ([]string, error) {
+	return h.sendChunksInThread(chatID, "", text, replyToMessageID, plain)
+}
+
+// sendChunksInThread is sendChunks into a forum topic; an empty threadID
+// sends to the chat itself.
+func (h *Handler) sendChunksInThread(chatID, threadID, text string, replyToMessageID string, plain bool) ([]string, error) {
 	if strings.TrimSpace(text) == "" {
 		text = "I received your message but have no response to provide."
 	}
 
 	chunks := splitResponse(text, maxTelegramMessageLen)
 	currentReplyTo := replyToMessageID // First chunk replies to user message
+	sentIDs := make([]string, 0, len(chunks))
 
 	for i, chunk := range chunks {
 		outMsg := &messaging.OutgoingMessage{
 			ChatID:           chatID,
 			Text:             chunk,
 			ReplyToMessageID: currentReplyTo,
+			PlainText:        plain,
+			ThreadID:         threadID,
 		}
 
 		sentMessageID, err := h.platform.SendMessage(outMsg)
 		if err != nil {
-			return fmt.Errorf("failed to send response chunk %d: %w", i+1, err)
+			return sentIDs, fmt.Errorf("failed to send response chunk %d: %w", i+1, err)
 		}
 
+		sentIDs = append(sentIDs, sentMessageID)
+
 		// Subsequent chunks reply to previous chunk (creates chain)
 		currentReplyTo = sentMessageID
 	}
 
-	return nil
+	return sentIDs, nil
+}
+
+// executionErrorText is the user-facing explanation of a failed query.
+func executionErrorText(err error) string {
+	if errors.Is(err, claude.ErrWorkspaceUnavailable) {
+		return "❌ Project workspace unavailable. Admins have been alerted; please try again later."
+	}
+	var resultErr *claude.ResultError
+	if errors.As(err, &resultErr) {
+		switch resultErr.Subtype {
+		case claude.SubtypeMaxTurns:
+			return "⚠️ Claude hit the max-turns limit before finishing. Try a narrower question or split it into steps."
+		case claude.SubtypeExecutionError:
+			return "⚠️ Claude ran into an error while working on this. Please try again."
+		default:
+			return "⚠️ Claude couldn't complete this request. Please try again."
+		}
+	}
+	return "❌ Failed to execute query. The service may be temporarily unavailable."
 }
 
 func (h *Handler) sendError(chatID, errorMsg string, replyToMessageID string) error {
@@ -283,9 +956,69 @@ func (h *Handler) sendError(chatID, errorMsg string, replyToMessageID string) er
 	return err
 }
 
+// sendText sends a plain reply to the given message.
+func (h *Handler) sendText(chatID, text string, replyToMessageID string) error {
+	outMsg := &messaging.OutgoingMessage{
+		ChatID:           chatID,
+		Text:             text,
+		ReplyToMessageID: replyToMessageID,
+	}
+	_, err := h.platform.SendMessage(outMsg)
+	return err
+}
+
+// dispatchCommand looks up a slash command in the registry and runs it,
+// enforcing admin restrictions.
+func (h *Handler) dispatchCommand(msg *messaging.IncomingMessage, fields []string) error {
+	cmd, ok := h.commands.Lookup(fields[0])
+	if !ok {
+		return h.sendUnknownCommand(msg.ChatID, fields[0], h.isAdmin(msg), msg.MessageID)
+	}
+	if cmd.AdminOnly && !h.isAdmin(msg) {
+		return h.sendAdminOnly(msg.ChatID, msg.MessageID)
+	}
+	if !cmd.ReadOnly && h.isObserver(msg.From.ID) {
+		return h.sendText(msg.ChatID, "👀 Observers can only use read-only commands like /status and /history.", msg.MessageID)
+	}
+	// Destructive commands in groups need a second invocation to take effect.
+	// Only users allowed to run the command can open or confirm a request.
+	if !h.confirmDestructiveCommand(msg, cmd.Name, fields[1:]) {
+		return nil
+	}
+	h.recordCommandUsage(msg, cmd.Name)
+	return cmd.Handler(msg, fields)
+}
+
+// sendUnknownCommand replies with the list of available commands.
+func (h *Handler) sendUnknownCommand(chatID, cmd string, isAdmin bool, replyToMessageID string) error {
+	outMsg := &messaging.OutgoingMessage{
+		ChatID: chatID,
+		Text: fmt.Sprintf("❓ Unknown command: %s\n\nAvailable commands:\n%s\n"+
+			"For other queries, just ask without using a slash command.",
+			cmd, formatCommandList(h.commands.Commands(), isAdmin)),
+		ReplyToMessageID: replyToMessageID,
+	}
+	_, err := h.platform.SendMessage(outMsg)
+	return err
+}
+
+// sendAdminOnly tells a non-admin that the command is restricted.
+func (h *Handler) sendAdminOnly(chatID string, replyToMessageID string) error {
+	outMsg := &messaging.OutgoingMessage{
+		ChatID:           chatID,
+		Text:             "🔒 This command is available to admins only.",
+		ReplyToMessageID: replyToMessageID,
+	}
+	_, err := h.platform.SendMessage(outMsg)
+	return err
+}
+
 func (h *Handler) handleNewCommand(chatID string, replyToMessageID string) error {
 	slog.Info("Processing /new command", "chat_id", chatID)
 
+	// Collect the incident summary before cleanup deletes the session's messages
+	incidentSummary := h.buildIncidentSummary(chatID)
+
 	// Trigger full cleanup (kills process, deletes data, deactivates)
 	if err := h.expiryWorker.ManualCleanup(chatID); err != nil {
 		slog.Error("Failed to cleanup session for /new command",
@@ -302,17 +1035,19 @@ func (h *Handler) handleNewCommand(chatID string, replyToMessageID string) error
 		return err
 	}
 
+	h.postIncidentSummary(incidentSummary)
+
 	// Send success confirmation
 	outMsg := &messaging.OutgoingMessage{
 		ChatID:           chatID,
-		Text:             "✅ Session reset complete! Your next message will start a fresh conversation with Claude.",
+		Text:             h.withEnvLabel("✅ Session reset complete! Your next message will start a fresh conversation with Claude.", true),
 		ReplyToMessageID: replyToMessageID,
 	}
 	_, err := h.platform.SendMessage(outMsg)
 	return err
 }
 
-func (h *Handler) handleStatusCommand(chatID string, replyToMessageID string) error {
+func (h *Handler) handleStatusCommand(chatID string, isAdmin bool, replyToMessageID string) error {
 	slog.Info("Processing /status command", "chat_id", chatID)
 
 	// Get context
@@ -322,10 +1057,24 @@ func (h *Handler) handleStatusCommand(chatID string, replyToMessageID string) er
 		return h.sendError(chatID, "Failed to retrieve session status.", replyToMessageID)
 	}
 
+	// Everyone sees how busy the bot is, explaining slow answers; detailed
+	// queue numbers are bot-wide, so only admins see them
+	var queueStatus string
+	if h.sessionManager != nil {
+		queueStatus = formatLoadStatus(h.sessionManager.GetActiveSessionCount(), h.sessionManager.GetActiveQueryCount(),
+			h.sessionManager.GetMaxConcurrentQueries(), h.sessionManager.GetQueueDepth())
+	}
+	if isAdmin && h.sessionManager != nil {
+		queueStatus += formatQueueStatus(h.sessionManager.GetQueueDepth(), h.sessionManager.GetDroppedQueries())
+	}
+	if isAdmin {
+		queueStatus += formatPoolStatus(h.storage.PoolStats())
+	}
+
 	if ctx == nil || !ctx.IsActive {
 		outMsg := &messaging.OutgoingMessage{
 			ChatID:           chatID,
-			Text:             "ℹ️ No active session. Send a message to start a new conversation with Claude.",
+			Text:             h.withEnvLabel("ℹ️ No active session. Send a message to start a new conversation with Claude."+queueStatus, true),
 			ReplyToMessageID: replyToMessageID,
 		}
 		_, err := h.platform.SendMessage(outMsg)
@@ -347,28 +1096,38 @@ func (h *Handler) handleStatusCommand(chatID string, replyToMessageID string) er
 	}
 
 	response := formatStatusResponse(ctx, msgCount, len(tools))
+	if incidentID := h.sessionIncident(chatID, ctx.SessionID); incidentID != "" {
+		response += fmt.Sprintf("\n*Incident:* `%s`", incidentID)
+	}
+	if h.health != nil {
+		response += formatHealthWarning(h.health.Status())
+	}
+	response += queueStatus
 	outMsg := &messaging.OutgoingMessage{
 		ChatID:           chatID,
-		Text:             response,
+		Text:             h.withEnvLabel(response, true),
 		ReplyToMessageID: replyToMessageID,
 	}
 	_, err = h.platform.SendMessage(outMsg)
 	return err
 }
 
-func (h *Handler) handleHelpCommand(chatID string, replyToMessageID string) error {
+func (h *Handler) handleHelpCommand(chatID string, isAdmin bool, replyToMessageID string) error {
 	slog.Info("Processing /help command", "chat_id", chatID)
 	outMsg := &messaging.OutgoingMessage{
 		ChatID:           chatID,
-		Text:             getHelpText(),
+		Text:             formatHelpText(h.commands.Commands(), isAdmin),
 		ReplyToMessageID: replyToMessageID,
 	}
 	_, err := h.platform.SendMessage(outMsg)
 	return err
 }
 
-func (h *Handler) handleHistoryCommand(chatID string, replyToMessageID string) error {
-	slog.Info("Processing /history command", "chat_id", chatID)
+// handleHistoryCommand shows one page of the current session's history,
+// page 1 being the most recent messages. When mine is set, only messages sent
+// by (or answering) userID are included, unpaged.
+func (h *Handler) handleHistoryCommand(chatID, userID string, mine bool, page int, replyToMessageID string) error {
+	slog.Info("Processing /history command", "chat_id", chatID, "mine", mine, "page", page)
 
 	ctx, err := h.storage.GetContext(chatID)
 	if err != nil {
@@ -386,12 +1145,27 @@ func (h *Handler) handleHistoryCommand(chatID string, replyToMessageID string) e
 		return err
 	}
 
-	messages, err := h.storage.GetRecentMessagesBySession(chatID, ctx.SessionID, 1000)
+	var messages []*storage.Message
+	total := 0
+	if mine {
+		messages, err = h.storage.GetMessagesByUser(chatID, ctx.SessionID, userID, 1000)
+	} else {
+		total, err = h.storage.GetMessageCountBySession(chatID, ctx.SessionID)
+		if err == nil {
+			if pages := historyPageCount(total); total > 0 && page > pages {
+				return h.sendText(chatID, fmt.Sprintf("📜 There is no page %d; this session's history has %d page(s).", page, pages), replyToMessageID)
+			}
+			messages, err = h.storage.GetRecentMessagesBySessionPaged(chatID, ctx.SessionID, historyPageSize, (page-1)*historyPageSize)
+		}
+	}
 	if err != nil {
 		slog.Error("Failed to get messages for /history", "chat_id", chatID, "error", err)
 		return h.sendError(chatID, "Failed to retrieve messages.", replyToMessageID)
 	}
 
+	if len(messages) == 0 && mine {
+		return h.sendText(chatID, "📜 You have no messages in this session yet.", replyToMessageID)
+	}
 	if len(messages) == 0 {
 		outMsg := &messaging.OutgoingMessage{
 			ChatID:           chatID,
@@ -402,10 +1176,39 @@ func (h *Handler) handleHistoryCommand(chatID string, replyToMessageID string) e
 		return err
 	}
 
-	response := formatHistoryResponse(ctx, messages)
+	title := "Conversation History"
+	if mine {
+		title = "Your Conversation History"
+	}
+	response := formatHistoryResponseTitled(title, ctx, messages)
+	if !mine {
+		response += formatHistoryPageFooter(page, total)
+	}
 	return h.sendResponse(chatID, response, replyToMessageID)
 }
 
+// historyPageCount returns how many /history pages total messages fill.
+func historyPageCount(total int) int {
+	return (total + historyPageSize - 1) / historyPageSize
+}
+
+// formatHistoryPageFooter tells the user which page they are on and how to
+// reach its neighbours. Empty when everything fits on one page.
+func formatHistoryPageFooter(page, total int) string {
+	pages := historyPageCount(total)
+	if pages <= 1 {
+		return ""
+	}
+	var nav []string
+	if page < pages {
+		nav = append(nav, fmt.Sprintf("older: /history %d", page+1))
+	}
+	if page > 1 {
+		nav = append(nav, fmt.Sprintf("newer: /history %d", page-1))
+	}
+	return fmt.Sprintf("\n\n📄 Page %d of %d (%d messages) · %s", page, pages, total, strings.Join(nav, " · "))
+}
+
 func (h *Handler) handleSessionCommand(chatID string, replyToMessageID string) error {
 	slog.Info("Processing /session command", "chat_id", chatID)
 
@@ -463,7 +1266,8 @@ func (h *Handler) handleSessionCommand(chatID string, replyToMessageID string) e
 	return err
 }
 
-func (h *Handler) handleResumeCommand(chatID string, fields []string, replyToMessageID string) error {
+func (h *Handler) handleResumeCommand(msg *messaging.IncomingMessage, fields []string) error {
+	chatID, replyToMessageID := msg.ChatID, msg.MessageID
 	slog.Info("Processing /resume command", "chat_id", chatID, "args", fields)
 
 	// /resume without args: reactivate current chat's own session
@@ -473,7 +1277,7 @@ func (h *Handler) handleResumeCommand(chatID string, fields []string, replyToMes
 
 	// /resume <session_id>: transfer session from another chat
 	claudeSessionID := strings.TrimSpace(fields[1])
-	return h.handleResumeFromSession(chatID, claudeSessionID, replyToMessageID)
+	return h.handleResumeFromSession(msg, claudeSessionID)
 }
 
 // handleResumeOwnSession reactivates the current chat's own expired session.
@@ -556,8 +1360,80 @@ func (h *Handler) handleResumeOwnSession(chatID string, replyToMessageID string)
 	return err
 }
 
+// transferSession moves source's Claude session to targetChatID under a new
+// session ID and drops the source's in-memory session.
+func (h *Handler) transferSession(source *storage.ChatContext, targetChatID, targetChatType string) (*storage.TransferResult, error) {
+	if !h.transferPolicy.Allows(source.ChatType, targetChatType) {
+		slog.Warn("Session transfer refused by policy",
+			"source_chat_id", source.ChatID,
+			"source_chat_type", source.ChatType,
+			"target_chat_id", targetChatID,
+			"target_chat_type", targetChatType)
+		return nil, errTransferNotAllowed
+	}
+
+	result, err := h.storage.TransferSession(
+		source.ChatID,
+		targetChatID,
+		targetChatType,
+		h.contextManager.GenerateSessionID(),
+		h.contextManager.GetTTL(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove source session from SessionManager memory
+	if err := h.sessionManager.KillSession(source.SessionID); err != nil {
+		slog.Debug("Failed to remove source session from manager", "session_id", source.SessionID, "error", err)
+	}
+
+	slog.Info("Session transferred",
+		"source_chat_id", result.SourceChatID,
+		"target_chat_id", result.TargetChatID,
+		"claude_session_id", result.ClaudeSessionID,
+		"messages", result.MessagesTransferred,
+		"tools", result.ToolsTransferred,
+		"source_was_active", result.SourceWasActive)
+	return result, nil
+}
+
+// handleResumeForkedSession switches the chat back to a session it forked
+// away from. The session it leaves can be resumed the same way.
+func (h *Handler) handleResumeForkedSession(chatID, claudeSessionID, replyToMessageID string) error {
+	current, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /resume", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session information.", replyToMessageID)
+	}
+
+	restored, err := h.storage.RestoreForkedSession(chatID, claudeSessionID, h.contextManager.GetTTL())
+	if err != nil || !restored {
+		slog.Error("Failed to restore forked session", "chat_id", chatID, "claude_session_id", claudeSessionID, "restored", restored, "error", err)
+		return h.sendError(chatID, "Failed to resume the session. Please try again.", replyToMessageID)
+	}
+
+	// The session the chat left lives on only in storage
+	if current != nil {
+		if err := h.sessionManager.KillSession(current.SessionID); err != nil {
+			slog.Debug("Failed to remove replaced session from manager", "session_id", current.SessionID, "error", err)
+		}
+	}
+
+	slog.Info("Restored forked session", "chat_id", chatID, "claude_session_id", claudeSessionID)
+
+	text := fmt.Sprintf("✅ *Session resumed*\n\nThis chat is back on session `%s`.", claudeSessionID)
+	if current != nil && current.ClaudeSessionID != "" {
+		text += fmt.Sprintf("\nUse `/resume %s` to switch back to the one you left.", current.ClaudeSessionID)
+	}
+	return h.sendText(chatID, text, replyToMessageID)
+}
+
 // handleResumeFromSession transfers a session from another chat to this one.
-func (h *Handler) handleResumeFromSession(chatID, claudeSessionID string, replyToMessageID string) error {
+// Only admins and users who took part in the source chat may transfer it, so
+// a leaked session ID can't be used to take over another chat's conversation.
+func (h *Handler) handleResumeFromSession(msg *messaging.IncomingMessage, claudeSessionID string) error {
+	chatID, replyToMessageID := msg.ChatID, msg.MessageID
 	slog.Info("Processing /resume (from session)", "chat_id", chatID, "claude_session_id", claudeSessionID)
 
 	// Find the source context
@@ -568,6 +1444,20 @@ func (h *Handler) handleResumeFromSession(chatID, claudeSessionID string, replyT
 	}
 
 	if sourceCtx == nil {
+		// A chat that forked away from the session can switch back to it
+		var forkChatID string
+		forkChatID, err = h.storage.GetForkedSessionChat(claudeSessionID)
+		if err != nil {
+			slog.Error("Failed to lookup forked session", "chat_id", chatID, "claude_session_id", claudeSessionID, "error", err)
+			return h.sendError(chatID, "Failed to lookup session.", replyToMessageID)
+		}
+		if forkChatID == chatID {
+			return h.handleResumeForkedSession(chatID, claudeSessionID, replyToMessageID)
+		}
+		if forkChatID != "" {
+			return h.sendText(chatID, "ℹ️ This session was forked in another chat. Resume it there first, then transfer it here.", replyToMessageID)
+		}
+
 		outMsg := &messaging.OutgoingMessage{
 			ChatID: chatID,
 			Text: "❌ Session not found. Possible reasons:\n" +
@@ -596,6 +1486,22 @@ func (h *Handler) handleResumeFromSession(chatID, claudeSessionID string, replyT
 		return h.handleResumeOwnSession(chatID, replyToMessageID)
 	}
 
+	if !h.isAdmin(msg) {
+		participant, err := h.storage.IsChatParticipant(sourceCtx.ChatID, msg.From.ID)
+		if err != nil {
+			slog.Error("Failed to check source chat participant", "chat_id", chatID, "source_chat_id", sourceCtx.ChatID, "error", err)
+			return h.sendError(chatID, "Failed to lookup session.", replyToMessageID)
+		}
+		if !participant {
+			slog.Warn("Refusing to transfer session from a chat the user didn't take part in",
+				"chat_id", chatID,
+				"user_id", msg.From.ID,
+				"source_chat_id", sourceCtx.ChatID,
+				"claude_session_id", claudeSessionID)
+			return h.sendText(chatID, "🔒 You can only resume sessions from chats you took part in. Ask an admin to transfer it.", replyToMessageID)
+		}
+	}
+
 	// Get target chat type
 	chatType, err := h.platform.GetChatType(chatID)
 	if err != nil {
@@ -603,17 +1509,10 @@ func (h *Handler) handleResumeFromSession(chatID, claudeSessionID string, replyT
 		return h.sendError(chatID, "Failed to determine chat type.", replyToMessageID)
 	}
 
-	// Generate new session ID for target
-	newSessionID := h.contextManager.GenerateSessionID()
-
-	// Execute transfer
-	result, err := h.storage.TransferSession(
-		sourceCtx.ChatID,
-		chatID,
-		chatType.String(),
-		newSessionID,
-		h.contextManager.GetTTL(),
-	)
+	result, err := h.transferSession(sourceCtx, chatID, chatType.String())
+	if errors.Is(err, errTransferNotAllowed) {
+		return h.sendText(chatID, formatTransferRefusal(sourceCtx.ChatType, chatType.String()), replyToMessageID)
+	}
 	if err != nil {
 		slog.Error("Failed to transfer session",
 			"source_chat_id", sourceCtx.ChatID,
@@ -623,19 +1522,6 @@ func (h *Handler) handleResumeFromSession(chatID, claudeSessionID string, replyT
 		return h.sendError(chatID, "Failed to transfer session. Please try again.", replyToMessageID)
 	}
 
-	// Remove source session from SessionManager memory
-	if err := h.sessionManager.KillSession(sourceCtx.SessionID); err != nil {
-		slog.Debug("Failed to remove source session from manager", "session_id", sourceCtx.SessionID, "error", err)
-	}
-
-	slog.Info("Session transferred",
-		"source_chat_id", result.SourceChatID,
-		"target_chat_id", result.TargetChatID,
-		"claude_session_id", result.ClaudeSessionID,
-		"messages", result.MessagesTransferred,
-		"tools", result.ToolsTransferred,
-		"source_was_active", result.SourceWasActive)
-
 	// Notify source chat only if it was active
 	if result.SourceWasActive {
 		notifyMsg := &messaging.OutgoingMessage{
@@ -853,18 +1739,13 @@ func formatDurationAgo(d time.Duration) string {
 	return "just now"
 }
 
-func getHelpText() string {
-	return `🤖 *AIOps Bot - Available Commands*
-
-/status - Show session information and statistics
-/help - Display this help message
-/history - Export conversation history
-/session - Show Claude session ID for transfer
-/sessions - List all sessions across all chats
-/resume - Reactivate expired session or transfer from another chat
-/new - Reset session and start fresh
+// formatHelpText builds the /help message from the registered commands.
+func formatHelpText(cmds []*CommandHandler, includeAdmin bool) string {
+	return "🤖 *AIOps Bot - Available Commands*\n\n" +
+		formatCommandList(cmds, includeAdmin) + "\n" + helpTips
+}
 
-💡 *Usage Tips*
+const helpTips = `💡 *Usage Tips*
 • Sessions expire after 2 hours of inactivity
 • Each message extends the session TTL
 • All MCP tools are read-only for safety
@@ -879,12 +1760,39 @@ To continue a conversation in another chat (e.g., move from group to DM):
 "Check ArgoCD app status"
 "Get recent Datadog alerts"
 "Search Jira for incidents"`
+
+// roleLabel names a message's role for display. Summaries and system notes
+// are marked so they aren't mistaken for conversation turns.
+func roleLabel(role string) string {
+	switch role {
+	case storage.RoleAssistant:
+		return "Assistant"
+	case storage.RoleSummary:
+		return "📝 Session summary"
+	case storage.RoleSystem:
+		return "⚙️ System note"
+	default:
+		return "User"
+	}
+}
+
+// messageAuthor names the user a message is attributed to: their username,
+// else their ID, else "" for legacy rows.
+func messageAuthor(msg *storage.Message) string {
+	if msg.Username != "" {
+		return "@" + msg.Username
+	}
+	return msg.UserID
 }
 
 func formatHistoryResponse(ctx *storage.ChatContext, messages []*storage.Message) string {
+	return formatHistoryResponseTitled("Conversation History", ctx, messages)
+}
+
+func formatHistoryResponseTitled(title string, ctx *storage.ChatContext, messages []*storage.Message) string {
 	var b strings.Builder
 
-	b.WriteString("📜 *Conversation History*\n\n")
+	b.WriteString(fmt.Sprintf("📜 *%s*\n\n", title))
 	b.WriteString(fmt.Sprintf("*Session:* `%s`\n", ctx.SessionID))
 
 	if len(messages) > 0 {
@@ -902,13 +1810,21 @@ func formatHistoryResponse(ctx *storage.ChatContext, messages []*storage.Message
 	b.WriteString("---\n\n")
 
 	for _, msg := range messages {
-		roleLabel := "User"
-		if msg.Role == "assistant" {
-			roleLabel = "Assistant"
-		}
-
 		timestamp := msg.CreatedAt.Format("3:04 PM")
-		b.WriteString(fmt.Sprintf("*[%s] %s:*\n", timestamp, roleLabel))
+		switch msg.Role {
+		case storage.RoleSummary, storage.RoleSystem:
+			// Not conversation turns, so set apart from them
+			b.WriteString(fmt.Sprintf("_[%s] %s_\n", timestamp, roleLabel(msg.Role)))
+		case storage.RoleUser:
+			// Group members need to know who asked; in DMs it's always the same user
+			if author := messageAuthor(msg); author != "" && ctx.ChatType != string(messaging.ChatTypePrivate) {
+				b.WriteString(fmt.Sprintf("*[%s] %s* `%s`:\n", timestamp, roleLabel(msg.Role), author))
+				break
+			}
+			b.WriteString(fmt.Sprintf("*[%s] %s:*\n", timestamp, roleLabel(msg.Role)))
+		default:
+			b.WriteString(fmt.Sprintf("*[%s] %s:*\n", timestamp, roleLabel(msg.Role)))
+		}
 
 		// Truncate very long messages (use runes to avoid breaking UTF-8)
 		content := msg.Content
@@ -994,6 +1910,11 @@ func formatSessionsResponse(contexts []*storage.ChatContext) string {
 // DMs: Always respond
 // Groups: Respond only if mentioned, replied to, or slash command
 func (h *Handler) shouldProcessMessage(msg *messaging.IncomingMessage) bool {
+	// Observers never trigger queries; their commands are gated in dispatchCommand
+	if h.isObserver(msg.From.ID) && !strings.HasPrefix(msg.Text, "/") {
+		return false
+	}
+
 	// DMs: Always respond
 	if msg.ChatType == messaging.ChatTypePrivate {
 		return true
@@ -1,11 +1,17 @@
 package bot
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
 	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
 	"github.com/rg/aiops/internal/storage"
 )
 
@@ -131,8 +137,9 @@ func TestFormatDurationAgo(t *testing.T) {
 	}
 }
 
-func TestGetHelpText(t *testing.T) {
-	helpText := getHelpText()
+func TestFormatHelpText(t *testing.T) {
+	h := NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	helpText := formatHelpText(h.commands.Commands(), false)
 
 	// Verify help text contains expected commands
 	expectedCommands := []string{"/status", "/help", "/history", "/new"}
@@ -234,6 +241,58 @@ func TestFormatHistoryResponse(t *testing.T) {
 	}
 }
 
+func TestFormatHistoryResponse_SummaryAndSystem(t *testing.T) {
+	ctx := &storage.ChatContext{
+		SessionID: "test-session",
+	}
+
+	now := time.Now()
+	messages := []*storage.Message{
+		{Role: storage.RoleUser, Content: "Why is checkout down?", CreatedAt: now.Add(-3 * time.Minute)},
+		{Role: storage.RoleSystem, Content: "Session restored", CreatedAt: now.Add(-2 * time.Minute)},
+		{Role: storage.RoleSummary, Content: "Checkout pods were OOMKilled", CreatedAt: now},
+	}
+
+	response := formatHistoryResponse(ctx, messages)
+
+	if !strings.Contains(response, "] 📝 Session summary_\nCheckout pods were OOMKilled") {
+		t.Errorf("Summary should be set apart from conversation turns, got:\n%s", response)
+	}
+	if !strings.Contains(response, "] ⚙️ System note_\nSession restored") {
+		t.Errorf("System note should be set apart from conversation turns, got:\n%s", response)
+	}
+	if !strings.Contains(response, "] User:*\nWhy is checkout down?") {
+		t.Errorf("User turn should keep its label, got:\n%s", response)
+	}
+}
+
+func TestFormatHistoryResponse_GroupAuthors(t *testing.T) {
+	now := time.Now()
+	messages := []*storage.Message{
+		{Role: storage.RoleUser, UserID: "42", Username: "alice", Content: "Why is checkout down?", CreatedAt: now.Add(-3 * time.Minute)},
+		{Role: storage.RoleAssistant, UserID: "42", Username: "alice", Content: "Pods are OOMKilled.", CreatedAt: now.Add(-2 * time.Minute)},
+		{Role: storage.RoleUser, UserID: "43", Content: "And payments?", CreatedAt: now.Add(-time.Minute)},
+		{Role: storage.RoleUser, Content: "Legacy question", CreatedAt: now},
+	}
+
+	group := formatHistoryResponse(&storage.ChatContext{SessionID: "s1", ChatType: "group"}, messages)
+	for _, want := range []string{
+		"] User* `@alice`:\nWhy is checkout down?",
+		"] Assistant:*\nPods are OOMKilled.",
+		"] User* `43`:\nAnd payments?",
+		"] User:*\nLegacy question",
+	} {
+		if !strings.Contains(group, want) {
+			t.Errorf("Group history should contain %q, got:\n%s", want, group)
+		}
+	}
+
+	private := formatHistoryResponse(&storage.ChatContext{SessionID: "s1", ChatType: "private"}, messages)
+	if strings.Contains(private, "@alice") || !strings.Contains(private, "] User:*\nWhy is checkout down?") {
+		t.Errorf("Private history should omit authors, got:\n%s", private)
+	}
+}
+
 func TestFormatHistoryResponse_LongMessage(t *testing.T) {
 	ctx := &storage.ChatContext{
 		SessionID: "test-session",
@@ -275,15 +334,15 @@ func TestNewHandler(t *testing.T) {
 		t.Fatal("Expected non-nil handler")
 	}
 
-	// Check allowed chat IDs map was built correctly
+	// Check allow list was built correctly
 	for _, id := range allowedChatIDs {
-		if !handler.allowedChatIDs[id] {
+		if !handler.allowList.Allows(id, "") {
 			t.Errorf("Expected chat ID %s to be allowed", id)
 		}
 	}
 
 	// Check a non-allowed ID
-	if handler.allowedChatIDs["999"] {
+	if handler.allowList.Allows("999", "") {
 		t.Error("Chat ID 999 should not be allowed")
 	}
 }
@@ -402,3 +461,403 @@ func TestShouldProcessMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatWriteToolsWarning(t *testing.T) {
+	got := formatWriteToolsWarning([]string{"Bash", "Edit"})
+
+	if !strings.Contains(got, "This action modified resources") {
+		t.Errorf("Warning should mention modified resources, got %q", got)
+	}
+	if !strings.Contains(got, "Bash, Edit") {
+		t.Errorf("Warning should list write tools, got %q", got)
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	h := &Handler{}
+	h.SetAdminIDs([]string{"42", "-100"})
+
+	tests := []struct {
+		name string
+		msg  *messaging.IncomingMessage
+		want bool
+	}{
+		{"admin user", &messaging.IncomingMessage{ChatID: "-200", From: messaging.User{ID: "42"}}, true},
+		{"admin chat", &messaging.IncomingMessage{ChatID: "-100", From: messaging.User{ID: "7"}}, true},
+		{"regular user", &messaging.IncomingMessage{ChatID: "7", From: messaging.User{ID: "7"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.isAdmin(tt.msg); got != tt.want {
+				t.Errorf("isAdmin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithEnvLabel(t *testing.T) {
+	tests := []struct {
+		name         string
+		label        string
+		allResponses bool
+		confirmation bool
+		text         string
+		want         string
+	}{
+		{"disabled", "", true, true, "✅ Done", "✅ Done"},
+		{"confirmation labelled", "[PROD]", false, true, "✅ Done", "[PROD] ✅ Done"},
+		{"response unlabelled by default", "[PROD]", false, false, "answer", "answer"},
+		{"response labelled when all", "[PROD]", true, false, "answer", "[PROD] answer"},
+		{"empty text untouched", "[PROD]", true, false, "  ", "  "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{}
+			h.SetEnvironmentLabel(tt.label, tt.allResponses)
+			got := h.withEnvLabel(tt.text, tt.confirmation)
+			if got != tt.want {
+				t.Errorf("withEnvLabel(%q, %v) = %q, want %q", tt.text, tt.confirmation, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleHistoryCommand_Mine(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx, err := store.CreateContext("-100", "group", "session-1", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateContext failed: %v", err)
+	}
+	_ = store.SaveMessage("-100", ctx.SessionID, "alice", "", "user", "alice asks")
+	_ = store.SaveMessage("-100", ctx.SessionID, "alice", "", "assistant", "answer to alice")
+	_ = store.SaveMessage("-100", ctx.SessionID, "bob", "", "user", "bob asks")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"-100"})
+
+	if err := h.handleHistoryCommand("-100", "alice", true, 1, "1"); err != nil {
+		t.Fatalf("handleHistoryCommand(mine) error: %v", err)
+	}
+	if err := h.handleHistoryCommand("-100", "alice", false, 1, "2"); err != nil {
+		t.Fatalf("handleHistoryCommand(all) error: %v", err)
+	}
+	if err := h.handleHistoryCommand("-100", "carol", true, 1, "3"); err != nil {
+		t.Fatalf("handleHistoryCommand(mine, no messages) error: %v", err)
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 3 {
+		t.Fatalf("Expected 3 replies, got %d", len(texts))
+	}
+
+	mine, all, empty := texts[0], texts[1], texts[2]
+	if !strings.Contains(mine, "Your Conversation History") || !strings.Contains(mine, "alice asks") ||
+		!strings.Contains(mine, "answer to alice") || strings.Contains(mine, "bob asks") {
+		t.Errorf("/history mine should only include alice's messages, got %q", mine)
+	}
+	if !strings.Contains(all, "bob asks") || !strings.Contains(all, "alice asks") {
+		t.Errorf("/history should include all messages, got %q", all)
+	}
+	if !strings.Contains(empty, "no messages") {
+		t.Errorf("Expected no-messages reply for carol, got %q", empty)
+	}
+}
+
+func TestHandleHistoryCommand_Pages(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx, err := store.CreateContext("1", "private", "session-1", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateContext failed: %v", err)
+	}
+	total := 2*historyPageSize + 5
+	for i := 1; i <= total; i++ {
+		_ = store.SaveMessage("1", ctx.SessionID, "alice", "", "user", fmt.Sprintf("message #%d.", i))
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+
+	for _, text := range []string{"/history", "/history 3", "/history 4", "/history x"} {
+		msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: text}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q) error = %v", text, err)
+		}
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 4 {
+		t.Fatalf("Expected 4 replies, got %q", texts)
+	}
+	newest, oldest := texts[0], texts[1]
+	if !strings.Contains(newest, fmt.Sprintf("message #%d.", total)) || strings.Contains(newest, "message #25.") {
+		t.Errorf("Page 1 should hold the newest messages only, got %q", newest)
+	}
+	if !strings.Contains(newest, "Page 1 of 3") || !strings.Contains(newest, "/history 2") {
+		t.Errorf("Page 1 should link to page 2, got %q", newest)
+	}
+	if !strings.Contains(oldest, "message #1.") || !strings.Contains(oldest, "message #5.") || strings.Contains(oldest, "message #6.") {
+		t.Errorf("Page 3 should hold the 5 oldest messages, got %q", oldest)
+	}
+	if !strings.Contains(oldest, "Page 3 of 3") || strings.Contains(oldest, "older:") {
+		t.Errorf("Last page should only link to newer messages, got %q", oldest)
+	}
+	if !strings.Contains(texts[2], "no page 4") {
+		t.Errorf("Expected an out-of-range reply, got %q", texts[2])
+	}
+	if !strings.Contains(texts[3], "Invalid page") {
+		t.Errorf("Expected an invalid page reply, got %q", texts[3])
+	}
+}
+
+func TestFormatHistoryPageFooter(t *testing.T) {
+	if got := formatHistoryPageFooter(1, historyPageSize); got != "" {
+		t.Errorf("Single page should have no footer, got %q", got)
+	}
+	got := formatHistoryPageFooter(2, 3*historyPageSize)
+	if !strings.Contains(got, "Page 2 of 3") || !strings.Contains(got, "older: /history 3") || !strings.Contains(got, "newer: /history 1") {
+		t.Errorf("Middle page footer = %q", got)
+	}
+}
+
+func TestFormatHealthWarning(t *testing.T) {
+	if got := formatHealthWarning(claude.HealthStatus{Healthy: true}); got != "" {
+		t.Errorf("formatHealthWarning(healthy) = %q, want empty", got)
+	}
+
+	got := formatHealthWarning(claude.HealthStatus{Healthy: false, LastError: errors.New("auth expired"), LastCheck: time.Now()})
+	if !strings.Contains(got, "unhealthy") || !strings.Contains(got, "auth expired") {
+		t.Errorf("formatHealthWarning(unhealthy) = %q, want warning with error", got)
+	}
+}
+
+func TestNotifyAdmins(t *testing.T) {
+	platform := newFakePlatform()
+	h := newTestHandlerWithPlatform(platform)
+	h.SetAdminIDs([]string{"admin1", "admin2"})
+
+	h.NotifyAdmins("🚨 alert")
+
+	if len(platform.sent) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d", len(platform.sent))
+	}
+	for _, msg := range platform.sent {
+		if !h.adminIDs[msg.ChatID] || msg.Text != "🚨 alert" {
+			t.Errorf("Unexpected notification %+v", msg)
+		}
+	}
+}
+
+func TestNotifyQueuePosition(t *testing.T) {
+	platform := newFakePlatform()
+	h := newTestHandlerWithPlatform(platform)
+
+	h.NotifyQueuePosition("chat1", 2)
+
+	if len(platform.sent) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(platform.sent))
+	}
+	if platform.sent[0].ChatID != "chat1" || !strings.Contains(platform.sent[0].Text, "#2 in the queue") {
+		t.Errorf("Unexpected queue message %+v", platform.sent[0])
+	}
+}
+
+func TestFormatToolLoopWarning(t *testing.T) {
+	got := formatToolLoopWarning([]claude.ToolRepeat{{ToolName: "kubectl get pods", Count: 4}})
+	if !strings.Contains(got, "Possible tool loop") || !strings.Contains(got, "kubectl get pods ×4") {
+		t.Errorf("formatToolLoopWarning() = %q", got)
+	}
+}
+
+func TestToolMetricLabel(t *testing.T) {
+	tests := map[string]string{
+		"kubectl get pods -n prod": "kubectl",
+		"Bash":                     "Bash",
+		"":                         "unknown",
+	}
+	for in, want := range tests {
+		if got := toolMetricLabel(in); got != want {
+			t.Errorf("toolMetricLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSetReactions_KeepsDefaultsForEmptyFields(t *testing.T) {
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, nil, nil, nil)
+
+	h.SetReactions(Reactions{Success: "👍"})
+
+	want := Reactions{Processing: "👀", Success: "👍"}
+	if h.reactions != want {
+		t.Errorf("reactions = %+v, want %+v", h.reactions, want)
+	}
+}
+
+func TestReact_SkipsEmptyEmoji(t *testing.T) {
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, nil, nil)
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "2"}
+
+	h.react(msg, h.reactions.Success)
+	h.react(msg, h.reactions.Processing)
+
+	if len(platform.reactions) != 1 || platform.reactions[0] != "👀" {
+		t.Errorf("reactions = %v, want [👀]", platform.reactions)
+	}
+}
+
+func TestSaveToolExecutions_CapsPerResponse(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+	h.SetMaxToolsPerResponse(2)
+
+	tools := []claude.ToolExecution{
+		{ToolName: "kubectl get pods", Status: "success"},
+		{ToolName: "kubectl get svc", Status: "success"},
+		{ToolName: "kubectl get nodes", Status: "success"},
+		{ToolName: "kubectl logs api", Status: "error"},
+	}
+	h.saveToolExecutions("1", "session-1", tools)
+
+	saved, err := store.GetToolExecutionsBySession("1", "session-1", 10)
+	if err != nil {
+		t.Fatalf("GetToolExecutionsBySession() error = %v", err)
+	}
+	if len(saved) != 3 {
+		t.Fatalf("Expected 2 tools and a note, got %d", len(saved))
+	}
+	names := make(map[string]bool)
+	for _, tool := range saved {
+		names[tool.ToolName] = true
+	}
+	for _, want := range []string{"kubectl get pods", "kubectl get svc", "… 2 more omitted"} {
+		if !names[want] {
+			t.Errorf("Expected %q to be saved, got %v", want, names)
+		}
+	}
+}
+
+func TestSaveToolExecutions_Redacted(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+
+	sanitizer, err := security.NewSanitizer(security.DefaultPatterns)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, sanitizer, store, []string{"1"})
+
+	h.saveToolExecutions("1", "session-1", []claude.ToolExecution{{
+		ToolName: "Read",
+		Status:   "success",
+		Input:    "cat .env password=hunter2",
+		Output:   "DEBUG=1\napi_key=sk-live-12345\nREGION=eu",
+	}})
+
+	saved, err := store.GetToolExecutionsBySession("1", "session-1", 10)
+	if err != nil || len(saved) != 1 {
+		t.Fatalf("GetToolExecutionsBySession() = %v, %v", saved, err)
+	}
+	if strings.Contains(saved[0].Output, "sk-live-12345") || !strings.Contains(saved[0].Output, "REDACTED") {
+		t.Errorf("Stored output should be redacted, got %q", saved[0].Output)
+	}
+	if !strings.Contains(saved[0].Output, "REGION=eu") {
+		t.Errorf("Only the secret should be redacted, got %q", saved[0].Output)
+	}
+	if strings.Contains(saved[0].Input, "hunter2") {
+		t.Errorf("Stored input should be redacted, got %q", saved[0].Input)
+	}
+}
+
+func TestHandleMessage_NonText(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+
+	voice := &messaging.IncomingMessage{ChatID: "1", ChatType: messaging.ChatTypePrivate, Kind: messaging.MessageKindVoice}
+	if err := h.HandleMessage(voice); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+	// Captions are rejected too while media captions are disabled
+	photo := &messaging.IncomingMessage{ChatID: "1", ChatType: messaging.ChatTypePrivate, Kind: messaging.MessageKindPhoto, Text: "what is this?"}
+	_ = h.HandleMessage(photo)
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 replies, got %v", texts)
+	}
+	if !strings.Contains(texts[0], "Voice message received") || !strings.Contains(texts[0], "only process text") {
+		t.Errorf("Unexpected voice reply: %q", texts[0])
+	}
+	if !strings.Contains(texts[1], "Photo received") {
+		t.Errorf("Unexpected photo reply: %q", texts[1])
+	}
+}
+
+func TestFormatUnsupportedMessage(t *testing.T) {
+	if got := formatUnsupportedMessage(messaging.MessageKindPhoto, true); !strings.Contains(got, "Add a caption") {
+		t.Errorf("Expected caption hint, got %q", got)
+	}
+	if got := formatUnsupportedMessage(messaging.MessageKindVoice, true); strings.Contains(got, "caption") {
+		t.Errorf("Voice messages can't carry a useful caption, got %q", got)
+	}
+	if got := formatUnsupportedMessage(messaging.MessageKindOther, false); !strings.HasPrefix(got, "Message received") {
+		t.Errorf("Unexpected fallback: %q", got)
+	}
+}
+
+func TestFormatPoolStatus(t *testing.T) {
+	got := formatPoolStatus(sql.DBStats{MaxOpenConnections: 50, InUse: 3, Idle: 7, WaitCount: 12})
+	if !strings.Contains(got, "3 in use, 7 idle of 50 max, 12 waits") || strings.Contains(got, "saturated") {
+		t.Errorf("formatPoolStatus() = %q", got)
+	}
+	if got := formatPoolStatus(sql.DBStats{MaxOpenConnections: 5, InUse: 5}); !strings.Contains(got, "saturated") {
+		t.Errorf("Full pool should be flagged, got %q", got)
+	}
+}
+
+func TestHandleQuery_SavesQueryTrace(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	executor := claude.NewExecutor(sm, "", 0)
+	executor.SetTracing(true)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	h := NewHandler(newFakePlatform(), context.NewManager(store, sm, time.Hour), nil, nil, sm, executor, sanitizer, store, []string{"1"})
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: "check the pods"}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+
+	entries, err := store.GetAuditEntriesBetween(time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetAuditEntriesBetween() error = %v", err)
+	}
+	var traces []storage.AuditEntry
+	for _, e := range entries {
+		if e.Event == "trace" {
+			traces = append(traces, e)
+		}
+	}
+	if len(traces) != 1 || traces[0].Detail == "" || traces[0].UserID != "alice" {
+		t.Errorf("Trace audit entries = %+v, want alice's query with a trace ID", traces)
+	}
+}
@@ -0,0 +1,214 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// incidentIDPattern accepts tracker keys like INC-123, PD:Q1W2E3 or SEC/42.
+var incidentIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/-]{0,63}$`)
+
+// IncidentSummary is posted to the incident webhook when a linked session is reset.
+type IncidentSummary struct {
+	IncidentID      string    `json:"incident_id"`
+	ChatID          string    `json:"chat_id"`
+	SessionID       string    `json:"session_id"`
+	ClaudeSessionID string    `json:"claude_session_id,omitempty"`
+	MessageCount    int       `json:"message_count"`
+	ToolCount       int       `json:"tool_count"`
+	StartedAt       time.Time `json:"started_at"`
+	ClosedAt        time.Time `json:"closed_at"`
+}
+
+// IncidentWebhook posts session summaries to an external incident system.
+type IncidentWebhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewIncidentWebhook creates a webhook that POSTs JSON summaries to url.
+func NewIncidentWebhook(url string, timeout time.Duration) *IncidentWebhook {
+	return &IncidentWebhook{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Notify posts the summary and fails on any non-2xx response.
+func (w *IncidentWebhook) Notify(ctx context.Context, summary IncidentSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode incident summary: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build incident webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post incident summary: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("incident webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SetIncidentsEnabled registers the /incident command. When webhook is
+// non-nil, a summary is posted to it when a linked session is reset with /new.
+func (h *Handler) SetIncidentsEnabled(enabled bool, webhook *IncidentWebhook) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/incident"); exists {
+		return
+	}
+	h.incidents = true
+	h.incidentWebhook = webhook
+	h.commands.Register(CommandHandler{
+		Name:        "/incident",
+		Description: "Link this session to an incident ID (/incident clear to unlink)",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleIncidentCommand(msg, fields)
+		},
+	})
+}
+
+// handleIncidentCommand handles /incident, /incident <id> and /incident clear.
+func (h *Handler) handleIncidentCommand(msg *messaging.IncomingMessage, fields []string) error {
+	chatID, replyTo := msg.ChatID, msg.MessageID
+	slog.Info("Processing /incident command", "chat_id", chatID, "args", fields)
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /incident", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", replyTo)
+	}
+	if ctx == nil || !ctx.IsActive {
+		return h.sendText(chatID, "ℹ️ No active session. Send a message first, then link it with `/incident <id>`.", replyTo)
+	}
+
+	if len(fields) < 2 {
+		incidentID, err := h.storage.GetSessionIncident(ctx.SessionID)
+		if err != nil {
+			slog.Error("Failed to get session incident", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to retrieve incident.", replyTo)
+		}
+		if incidentID == "" {
+			return h.sendText(chatID, "🚨 No incident linked. Usage: `/incident <id>` or `/incident clear`", replyTo)
+		}
+		return h.sendText(chatID, fmt.Sprintf("🚨 Linked incident: `%s`", incidentID), replyTo)
+	}
+
+	if fields[1] == "clear" {
+		if err := h.storage.ClearSessionIncident(ctx.SessionID); err != nil {
+			slog.Error("Failed to clear session incident", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to unlink incident.", replyTo)
+		}
+		slog.Info("Unlinked session from incident",
+			"chat_id", chatID,
+			"session_id", ctx.SessionID,
+			"user_id", msg.From.ID)
+		return h.sendText(chatID, "✅ Incident unlinked from this session.", replyTo)
+	}
+
+	incidentID := fields[1]
+	if !incidentIDPattern.MatchString(incidentID) {
+		return h.sendText(chatID, "❌ Invalid incident ID. Use letters, digits and `._:/-` (max 64 characters).", replyTo)
+	}
+
+	if err := h.storage.SetSessionIncident(chatID, ctx.SessionID, incidentID); err != nil {
+		slog.Error("Failed to link session incident", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to link incident.", replyTo)
+	}
+	slog.Info("Linked session to incident",
+		"chat_id", chatID,
+		"session_id", ctx.SessionID,
+		"incident_id", incidentID,
+		"user_id", msg.From.ID)
+
+	return h.sendText(chatID, fmt.Sprintf("✅ Session linked to incident `%s`.", incidentID), replyTo)
+}
+
+// sessionIncident returns the incident linked to a session, or "" when
+// incidents are disabled or the lookup fails.
+func (h *Handler) sessionIncident(chatID, sessionID string) string {
+	if !h.incidents {
+		return ""
+	}
+	incidentID, err := h.storage.GetSessionIncident(sessionID)
+	if err != nil {
+		slog.Warn("Failed to get session incident", "chat_id", chatID, "error", err)
+		return ""
+	}
+	return incidentID
+}
+
+// buildIncidentSummary collects the summary for the chat's linked session.
+// It must run before /new deletes the session's messages. Returns nil when
+// there is no webhook or no linked incident.
+func (h *Handler) buildIncidentSummary(chatID string) *IncidentSummary {
+	if h.incidentWebhook == nil {
+		return nil
+	}
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil || ctx == nil {
+		return nil
+	}
+	incidentID := h.sessionIncident(chatID, ctx.SessionID)
+	if incidentID == "" {
+		return nil
+	}
+
+	msgCount, err := h.storage.GetMessageCountBySession(chatID, ctx.SessionID)
+	if err != nil {
+		slog.Warn("Failed to get message count for incident summary", "chat_id", chatID, "error", err)
+	}
+	tools, err := h.storage.GetToolExecutionsBySession(chatID, ctx.SessionID, 1000)
+	if err != nil {
+		slog.Warn("Failed to get tool executions for incident summary", "chat_id", chatID, "error", err)
+	}
+
+	return &IncidentSummary{
+		IncidentID:      incidentID,
+		ChatID:          chatID,
+		SessionID:       ctx.SessionID,
+		ClaudeSessionID: ctx.ClaudeSessionID,
+		MessageCount:    msgCount,
+		ToolCount:       len(tools),
+		StartedAt:       ctx.CreatedAt,
+		ClosedAt:        time.Now(),
+	}
+}
+
+// postIncidentSummary sends summary to the incident webhook. Failures are
+// logged only; they must not fail the session reset.
+func (h *Handler) postIncidentSummary(summary *IncidentSummary) {
+	if summary == nil || h.incidentWebhook == nil {
+		return
+	}
+	if err := h.incidentWebhook.Notify(context.Background(), *summary); err != nil {
+		slog.Warn("Failed to post incident summary",
+			"chat_id", summary.ChatID,
+			"incident_id", summary.IncidentID,
+			"error", err)
+		return
+	}
+	slog.Info("Posted incident summary",
+		"chat_id", summary.ChatID,
+		"incident_id", summary.IncidentID,
+		"session_id", summary.SessionID)
+}
@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestIncidentCommand_LinkAndStatus(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if _, err := store.CreateContext("1", "private", "session-1", time.Hour); err != nil {
+		t.Fatalf("CreateContext failed: %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	h.SetIncidentsEnabled(true, nil)
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "10", From: messaging.User{ID: "u1"}}
+	if err := h.dispatchCommand(msg, []string{"/incident", "bad id!"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+	if err := h.dispatchCommand(msg, []string{"/incident", "INC-42"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+	if err := h.dispatchCommand(msg, []string{"/status"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+
+	texts := platform.sentTexts()
+	if !strings.Contains(texts[0], "Invalid incident ID") {
+		t.Errorf("Expected invalid ID rejection, got %q", texts[0])
+	}
+	if got, _ := store.GetSessionIncident("session-1"); got != "INC-42" {
+		t.Errorf("Expected stored incident INC-42, got %q", got)
+	}
+	if !strings.Contains(texts[2], "*Incident:* `INC-42`") {
+		t.Errorf("Expected incident in /status, got:\n%s", texts[2])
+	}
+
+	if err := h.dispatchCommand(msg, []string{"/incident", "clear"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+	if got, _ := store.GetSessionIncident("session-1"); got != "" {
+		t.Errorf("Expected incident cleared, got %q", got)
+	}
+}
+
+func TestIncidentWebhook_Notify(t *testing.T) {
+	var got IncidentSummary
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	w := NewIncidentWebhook(srv.URL, time.Second)
+	if err := w.Notify(context.Background(), IncidentSummary{IncidentID: "INC-1", SessionID: "s", MessageCount: 3}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if got.IncidentID != "INC-1" || got.MessageCount != 3 {
+		t.Errorf("Unexpected payload %+v", got)
+	}
+}
+
+func TestIncidentWebhook_NotifyErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	if err := NewIncidentWebhook(srv.URL, time.Second).Notify(context.Background(), IncidentSummary{}); err == nil {
+		t.Error("Expected error for non-2xx response")
+	}
+}
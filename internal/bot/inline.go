@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// maxInlineCacheEntries bounds how many inline answers are kept.
+const maxInlineCacheEntries = 100
+
+// SetInlineQueries answers inline queries ("@bot query" typed in any chat)
+// from allow-listed users, running each in a throwaway Claude session.
+// Answers are reused for the same user and query text for cacheTTL.
+func (h *Handler) SetInlineQueries(cacheTTL time.Duration) {
+	h.inlineCache = newInlineCache(cacheTTL)
+}
+
+// HandleInlineQuery returns the answer to offer for an inline query, or ""
+// to offer nothing. Only users allowed by user ID may query inline, since
+// there is no chat to check; observers are refused as they can't run queries.
+func (h *Handler) HandleInlineQuery(q *messaging.IncomingInlineQuery) (string, error) {
+	if h.inlineCache == nil || q.Query == "" {
+		return "", nil
+	}
+	if _, byUser := h.allowList.Match("", q.From.ID); !byUser || h.isObserver(q.From.ID) {
+		slog.Warn("Ignoring inline query from unauthorized user", "user_id", q.From.ID, "username", q.From.Username)
+		return "", nil
+	}
+
+	if answer, ok := h.inlineCache.get(q.From.ID, q.Query); ok {
+		slog.Info("Answering inline query from cache", "user_id", q.From.ID)
+		return answer, nil
+	}
+
+	slog.Info("Processing inline query", "user_id", q.From.ID, "query_length", len(q.Query))
+	// The user's private chat shares their ID, so queue notices reach them
+	response, err := h.executeStandalone(q.From.ID, q.Query)
+	if err != nil {
+		slog.Error("Inline query execution error", "user_id", q.From.ID, "error", err)
+		return "", err
+	}
+
+	answer := h.sanitizer.Sanitize(response.Result)
+	h.inlineCache.put(q.From.ID, q.Query, answer)
+	return answer, nil
+}
+
+// inlineCache remembers recent inline answers by user and normalized query
+// text. Answers are never shared between users.
+type inlineCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]inlineCacheEntry
+}
+
+type inlineCacheEntry struct {
+	answer  string
+	expires time.Time
+}
+
+func newInlineCache(ttl time.Duration) *inlineCache {
+	return &inlineCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]inlineCacheEntry),
+	}
+}
+
+func inlineCacheKey(userID, query string) string {
+	return userID + "|" + strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+func (c *inlineCache) get(userID, query string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[inlineCacheKey(userID, query)]
+	if !ok || !c.now().Before(entry.expires) {
+		return "", false
+	}
+	return entry.answer, true
+}
+
+// put stores answer, dropping expired entries first and the entry closest to
+// expiry if the cache is still full.
+func (c *inlineCache) put(userID, query, answer string) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) >= maxInlineCacheEntries {
+		var oldest string
+		for key, entry := range c.entries {
+			if oldest == "" || entry.expires.Before(c.entries[oldest].expires) {
+				oldest = key
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[inlineCacheKey(userID, query)] = inlineCacheEntry{answer: answer, expires: now.Add(c.ttl)}
+}
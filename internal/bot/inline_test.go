@@ -0,0 +1,90 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestHandleInlineQuery(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	executor := claude.NewExecutor(sm, "", 0)
+	sanitizer, err := security.NewSanitizer([]string{`token=\S+`})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	h := NewHandler(newFakePlatform(), nil, nil, nil, sm, executor, sanitizer, store, []string{"user:42", "group:-100"})
+	h.SetObserverIDs([]string{"7"})
+	h.SetInlineQueries(time.Minute)
+
+	query := &messaging.IncomingInlineQuery{ID: "q1", From: messaging.User{ID: "42"}, Query: "check token=abc"}
+	answer, err := h.HandleInlineQuery(query)
+	if err != nil {
+		t.Fatalf("HandleInlineQuery() error = %v", err)
+	}
+	if !strings.Contains(answer, "args:") || !strings.Contains(answer, "check") {
+		t.Errorf("Answer = %q, want the CLI output", answer)
+	}
+	if strings.Contains(answer, "abc") {
+		t.Errorf("Answer = %q, want secrets redacted", answer)
+	}
+	if sm.GetActiveSessionCount() != 0 {
+		t.Errorf("Inline query left %d sessions behind", sm.GetActiveSessionCount())
+	}
+
+	// Same text, differently spaced: served from cache
+	h.inlineCache.entries[inlineCacheKey("42", query.Query)] = inlineCacheEntry{answer: "cached", expires: time.Now().Add(time.Minute)}
+	cached, err := h.HandleInlineQuery(&messaging.IncomingInlineQuery{ID: "q2", From: messaging.User{ID: "42"}, Query: "Check   token=abc"})
+	if err != nil || cached != "cached" {
+		t.Errorf("HandleInlineQuery() = %q, %v; want cached answer", cached, err)
+	}
+
+	for _, userID := range []string{"99", "7", ""} {
+		answer, err := h.HandleInlineQuery(&messaging.IncomingInlineQuery{ID: "q3", From: messaging.User{ID: userID}, Query: "check token=abc"})
+		if err != nil || answer != "" {
+			t.Errorf("User %q got %q, %v; want no answer", userID, answer, err)
+		}
+	}
+}
+
+func TestHandleInlineQuery_Disabled(t *testing.T) {
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, nil, nil, []string{"42"})
+	answer, err := h.HandleInlineQuery(&messaging.IncomingInlineQuery{ID: "q1", From: messaging.User{ID: "42"}, Query: "hi"})
+	if err != nil || answer != "" {
+		t.Errorf("HandleInlineQuery() = %q, %v; want nothing when disabled", answer, err)
+	}
+}
+
+func TestInlineCache_Expiry(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := newInlineCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.put("42", "Why?", "because")
+	if got, ok := cache.get("42", "why?"); !ok || got != "because" {
+		t.Errorf("get() = %q, %v; want cached answer", got, ok)
+	}
+	if _, ok := cache.get("43", "why?"); ok {
+		t.Error("get() should not serve one user's answer to another")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.get("42", "why?"); ok {
+		t.Error("get() should miss once the entry expires")
+	}
+
+	for i := 0; i < maxInlineCacheEntries+5; i++ {
+		cache.put("42", strings.Repeat("q", i+1), "a")
+	}
+	if n := len(cache.entries); n != maxInlineCacheEntries {
+		t.Errorf("cache holds %d entries, want %d", n, maxInlineCacheEntries)
+	}
+}
@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rg/aiops/internal/storage"
+)
+
+// killNotice tells a chat that an admin ended its session.
+const killNotice = "🛑 An admin stopped this chat's session. Send a message to start a fresh one."
+
+// handleKillCommand handles /kill <session_id>, taking either the Claude
+// session ID shown by /sessions or the bot's session ID. It stops the
+// session's running queries, forgets the session and deactivates the owning
+// chat's context, so a query stuck in a loop stops consuming budget. Queries
+// still waiting for a slot are stopped too.
+func (h *Handler) handleKillCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /kill command", "chat_id", chatID, "args", fields)
+
+	if len(fields) < 2 || strings.TrimSpace(fields[1]) == "" {
+		return h.sendText(chatID, "Usage: `/kill <session_id>`\n\nUse /sessions to find the session ID.", replyToMessageID)
+	}
+	id := strings.TrimSpace(fields[1])
+
+	ctx, err := h.storage.GetContextByClaudeSessionID(id)
+	if err == nil && ctx == nil {
+		ctx, err = h.storage.GetContextBySessionID(id)
+	}
+	if err != nil {
+		slog.Error("Failed to lookup session for /kill", "chat_id", chatID, "session_id", id, "error", err)
+		return h.sendError(chatID, "Failed to lookup session.", replyToMessageID)
+	}
+	if ctx == nil {
+		return h.sendText(chatID, fmt.Sprintf("❌ Session `%s` not found.", id), replyToMessageID)
+	}
+
+	cancelled := h.sessionManager.CancelQueries(ctx.SessionID)
+	if err := h.sessionManager.KillSession(ctx.SessionID); err != nil {
+		slog.Debug("Failed to remove killed session from manager", "session_id", ctx.SessionID, "error", err)
+	}
+
+	if ctx.IsActive {
+		if err := h.storage.DeactivateContext(ctx.ChatID); err != nil {
+			slog.Error("Failed to deactivate killed session", "chat_id", ctx.ChatID, "session_id", ctx.SessionID, "error", err)
+			return h.sendError(chatID, "Stopped the session's queries, but failed to deactivate it.", replyToMessageID)
+		}
+	}
+
+	slog.Warn("Killed session",
+		"admin_chat_id", chatID,
+		"chat_id", ctx.ChatID,
+		"session_id", ctx.SessionID,
+		"claude_session_id", ctx.ClaudeSessionID,
+		"cancelled_queries", cancelled,
+		"was_active", ctx.IsActive)
+
+	if ctx.ChatID != chatID && (ctx.IsActive || cancelled > 0) {
+		if err := h.sendText(ctx.ChatID, killNotice, ""); err != nil {
+			slog.Warn("Failed to notify chat of killed session", "chat_id", ctx.ChatID, "error", err)
+		}
+	}
+
+	return h.sendText(chatID, formatKillResult(ctx, cancelled), replyToMessageID)
+}
+
+// formatKillResult reports what /kill stopped: running or queued queries, or
+// only the stored context.
+func formatKillResult(ctx *storage.ChatContext, cancelled int) string {
+	var b strings.Builder
+	b.WriteString("🛑 *Session killed*\n\n")
+	b.WriteString(fmt.Sprintf("*Chat:* `%s`\n*Session:* `%s`\n\n", ctx.ChatID, ctx.SessionID))
+	if cancelled > 0 {
+		b.WriteString(fmt.Sprintf("Stopped %d running or queued Claude query(ies).\n", cancelled))
+	} else {
+		b.WriteString("No running or queued Claude query was found.\n")
+	}
+	if ctx.IsActive {
+		b.WriteString("The chat's context was deactivated; its next message starts a fresh session.")
+	} else {
+		b.WriteString("The chat's context was already inactive.")
+	}
+	return b.String()
+}
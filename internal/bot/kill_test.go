@@ -0,0 +1,188 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestKillCommand_StopsRunningQuery(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	// The fake CLI marks that it started, then hangs like a looping agent
+	dir := t.TempDir()
+	started := filepath.Join(dir, "started")
+	cli := filepath.Join(dir, "claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\ntouch '"+started+"'\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := claude.NewSessionManager(cli, t.TempDir(), "", 2, time.Minute)
+
+	_, _ = store.CreateContext("chat-1", "group", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat-1", "claude-1")
+	_, _ = sm.GetOrCreateSession("chat-1", "session-1")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, nil, nil, store, []string{"admin", "chat-1"})
+	h.SetAdminIDs([]string{"admin"})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := sm.ExecuteQuery("session-1", "loop forever", "claude-1")
+		done <- err
+	}()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Fake CLI never started")
+		}
+	}
+
+	msg := &messaging.IncomingMessage{ChatID: "admin", From: messaging.User{ID: "admin"}}
+	if err := h.dispatchCommand(msg, []string{"/kill", "claude-1"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected the killed query to fail")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Killed query kept running")
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 || texts[0] != killNotice {
+		t.Fatalf("Replies = %q, want a notice to the chat and a report", texts)
+	}
+	if !strings.Contains(texts[1], "Stopped 1 running or queued Claude query") || !strings.Contains(texts[1], "context was deactivated") {
+		t.Errorf("Report = %q, want the query stopped and the context deactivated", texts[1])
+	}
+	if ctx, _ := store.GetContext("chat-1"); ctx.IsActive {
+		t.Error("Killed session should be deactivated")
+	}
+	if sm.GetActiveSessionCount() != 0 {
+		t.Errorf("ActiveSessionCount = %d, want the session forgotten", sm.GetActiveSessionCount())
+	}
+}
+
+func TestKillCommand_StopsQueuedQuery(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	// The fake CLI hangs; with one slot, the second chat's query queues
+	dir := t.TempDir()
+	cli := filepath.Join(dir, "claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := claude.NewSessionManager(cli, t.TempDir(), "", 1, time.Minute)
+	_, _ = sm.GetOrCreateSession("chat-0", "session-0")
+	_, _ = store.CreateContext("chat-1", "group", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat-1", "claude-1")
+	_, _ = sm.GetOrCreateSession("chat-1", "session-1")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, nil, nil, store, []string{"admin", "chat-1"})
+	h.SetAdminIDs([]string{"admin"})
+
+	running := make(chan error, 1)
+	go func() {
+		_, err := sm.ExecuteQuery("session-0", "loop forever", "")
+		running <- err
+	}()
+	defer func() {
+		sm.CancelQueries("session-0")
+		<-running
+	}()
+	for deadline := time.Now().Add(5 * time.Second); sm.GetActiveQueryCount() == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("First query never started")
+		}
+	}
+
+	queued := make(chan error, 1)
+	go func() {
+		_, err := sm.ExecuteQuery("session-1", "hello", "claude-1")
+		queued <- err
+	}()
+	for deadline := time.Now().Add(5 * time.Second); sm.GetQueueDepth() == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Second query never queued")
+		}
+	}
+
+	msg := &messaging.IncomingMessage{ChatID: "admin", From: messaging.User{ID: "admin"}}
+	if err := h.dispatchCommand(msg, []string{"/kill", "claude-1"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+
+	select {
+	case err := <-queued:
+		if err == nil {
+			t.Error("Expected the killed query to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Killed query kept waiting for a slot")
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 2 || !strings.Contains(texts[1], "Stopped 1 running or queued Claude query") {
+		t.Errorf("Replies = %q, want the queued query reported as stopped", texts)
+	}
+}
+
+func TestKillCommand_WithoutRunningProcess(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager("/nonexistent", t.TempDir(), "", 2, time.Minute)
+	_, _ = store.CreateContext("chat-1", "group", "session-1", time.Hour)
+	_, _ = store.CreateContext("chat-2", "group", "session-2", time.Hour)
+	_ = store.DeactivateContext("chat-2")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, nil, nil, store, []string{"admin"})
+	h.SetAdminIDs([]string{"admin"})
+	msg := &messaging.IncomingMessage{ChatID: "admin", From: messaging.User{ID: "admin"}}
+
+	for _, args := range [][]string{
+		{"/kill"},
+		{"/kill", "missing"},
+		// Sessions not yet talking to Claude are found by the bot's session ID
+		{"/kill", "session-1"},
+		{"/kill", "session-2"},
+	} {
+		if err := h.dispatchCommand(msg, args); err != nil {
+			t.Fatalf("dispatchCommand(%v) error = %v", args, err)
+		}
+	}
+
+	texts := platform.sentTexts()
+	want := []string{
+		"Usage: `/kill <session_id>`",
+		"not found",
+		killNotice,
+		"No running or queued Claude query was found.\nThe chat's context was deactivated",
+		"No running or queued Claude query was found.\nThe chat's context was already inactive",
+	}
+	if len(texts) != len(want) {
+		t.Fatalf("Expected %d replies, got %d: %q", len(want), len(texts), texts)
+	}
+	for i, w := range want {
+		if !strings.Contains(texts[i], w) {
+			t.Errorf("Reply %d = %q, want it to contain %q", i, texts[i], w)
+		}
+	}
+	if ctx, _ := store.GetContext("chat-1"); ctx.IsActive {
+		t.Error("Killed session should be deactivated")
+	}
+}
@@ -0,0 +1,214 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// maintenanceCheckInterval is how often the scheduler looks for windows
+// starting or ending. Schedules have minute resolution.
+const maintenanceCheckInterval = 30 * time.Second
+
+// maintenanceState is whether the bot is in maintenance mode, during which
+// queries are refused but commands still work.
+type maintenanceState struct {
+	mu      sync.Mutex
+	active  bool
+	until   time.Time // Zero = until turned off
+	message string    // Shown instead of answers; empty uses a default
+}
+
+// StartMaintenance puts the bot in maintenance mode until the given time
+// (zero for until EndMaintenance) and tells every chat with an active session.
+// It reports false, changing nothing, if maintenance was already on.
+func (h *Handler) StartMaintenance(until time.Time, message string) bool {
+	h.maintenance.mu.Lock()
+	if h.maintenance.active {
+		h.maintenance.mu.Unlock()
+		return false
+	}
+	h.maintenance.active = true
+	h.maintenance.until = until
+	h.maintenance.message = message
+	h.maintenance.mu.Unlock()
+
+	slog.Info("Maintenance mode started", "until", until)
+	h.notifyActiveChats("🔧 Maintenance has started. " + formatMaintenanceNotice(until, message))
+	return true
+}
+
+// EndMaintenance leaves maintenance mode and tells every chat with an active
+// session. It reports false, changing nothing, if maintenance was off.
+func (h *Handler) EndMaintenance() bool {
+	h.maintenance.mu.Lock()
+	if !h.maintenance.active {
+		h.maintenance.mu.Unlock()
+		return false
+	}
+	h.maintenance.active = false
+	h.maintenance.until = time.Time{}
+	h.maintenance.message = ""
+	h.maintenance.mu.Unlock()
+
+	slog.Info("Maintenance mode ended")
+	h.notifyActiveChats("✅ Maintenance is over. Questions are being answered again.")
+	return true
+}
+
+// InMaintenance reports whether the bot is in maintenance mode.
+func (h *Handler) InMaintenance() bool {
+	h.maintenance.mu.Lock()
+	defer h.maintenance.mu.Unlock()
+	return h.maintenance.active
+}
+
+// maintenanceReply returns the reply to a query during maintenance, and
+// false when the bot is not in maintenance mode.
+func (h *Handler) maintenanceReply() (string, bool) {
+	h.maintenance.mu.Lock()
+	defer h.maintenance.mu.Unlock()
+	if !h.maintenance.active {
+		return "", false
+	}
+	return "🔧 " + formatMaintenanceNotice(h.maintenance.until, h.maintenance.message), true
+}
+
+// formatMaintenanceNotice explains that queries are paused and until when.
+func formatMaintenanceNotice(until time.Time, message string) string {
+	if message == "" {
+		message = "The bot is under maintenance and not answering questions."
+	}
+	if until.IsZero() {
+		return message + " Commands like /history still work."
+	}
+	return fmt.Sprintf("%s Expected back at %s. Commands like /history still work.",
+		message, until.Format("Jan 2, 15:04 MST"))
+}
+
+// notifyActiveChats sends text to every chat with an active session.
+func (h *Handler) notifyActiveChats(text string) {
+	contexts, err := h.storage.GetAllContexts(false)
+	if err != nil {
+		slog.Error("Failed to list active chats for maintenance notice", "error", err)
+		return
+	}
+	for _, c := range contexts {
+		outMsg := &messaging.OutgoingMessage{ChatID: c.ChatID, Text: text}
+		if _, err := h.platform.SendMessage(outMsg); err != nil {
+			slog.Warn("Failed to send maintenance notice", "chat_id", c.ChatID, "error", err)
+		}
+	}
+}
+
+// handleMaintenanceCommand handles /maintenance [on [message]|off], which
+// shows or toggles maintenance mode by hand.
+func (h *Handler) handleMaintenanceCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /maintenance command", "chat_id", chatID, "args", fields)
+
+	if len(fields) < 2 {
+		status := "🟢 Maintenance mode is off."
+		if reply, on := h.maintenanceReply(); on {
+			status = "Maintenance mode is on:\n" + reply
+		}
+		return h.sendText(chatID, status+"\n\nUsage: `/maintenance on [message]` or `/maintenance off`", replyToMessageID)
+	}
+
+	switch strings.ToLower(fields[1]) {
+	case "on":
+		if !h.StartMaintenance(time.Time{}, strings.Join(fields[2:], " ")) {
+			return h.sendText(chatID, "Maintenance mode is already on.", replyToMessageID)
+		}
+		return h.sendText(chatID, "🔧 Maintenance mode is on. Turn it off with `/maintenance off`.", replyToMessageID)
+	case "off":
+		if !h.EndMaintenance() {
+			return h.sendText(chatID, "Maintenance mode is already off.", replyToMessageID)
+		}
+		return h.sendText(chatID, "✅ Maintenance mode is off.", replyToMessageID)
+	default:
+		return h.sendText(chatID, "❌ Unknown option.\n\nUsage: `/maintenance on [message]` or `/maintenance off`", replyToMessageID)
+	}
+}
+
+// MaintenanceWindow is a recurring period of maintenance that begins
+// whenever Schedule fires and lasts Duration.
+type MaintenanceWindow struct {
+	Schedule *Schedule
+	Duration time.Duration
+	Message  string // Optional; shown instead of the default notice
+}
+
+// MaintenanceScheduler puts the handler in maintenance mode during
+// scheduled windows.
+type MaintenanceScheduler struct {
+	handler  *Handler
+	windows  []MaintenanceWindow
+	location *time.Location // Schedules are evaluated in this time zone
+
+	inWindow bool // The last check fell inside a window
+	started  bool // Maintenance was turned on by the current window
+}
+
+// NewMaintenanceScheduler returns a scheduler for windows, evaluated in loc.
+func NewMaintenanceScheduler(h *Handler, windows []MaintenanceWindow, loc *time.Location) *MaintenanceScheduler {
+	return &MaintenanceScheduler{handler: h, windows: windows, location: loc}
+}
+
+// Start checks the windows until ctx is cancelled.
+func (s *MaintenanceScheduler) Start(ctx context.Context) {
+	slog.Info("Maintenance scheduler started", "windows", len(s.windows))
+	s.check(time.Now())
+
+	ticker := time.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.check(time.Now())
+		case <-ctx.Done():
+			slog.Info("Maintenance scheduler stopped")
+			return
+		}
+	}
+}
+
+// check starts or ends maintenance as of now. Maintenance turned on by hand
+// is left alone: a window starting during it doesn't take it over, so the
+// window's end doesn't turn it off. Turning maintenance off by hand during a
+// window keeps it off until the next window.
+func (s *MaintenanceScheduler) check(now time.Time) {
+	end, window, inWindow := s.currentWindow(now.In(s.location))
+	switch {
+	case inWindow && !s.inWindow:
+		s.started = s.handler.StartMaintenance(end, window.Message)
+		if !s.started {
+			slog.Info("Maintenance window started while maintenance was already on", "until", end)
+		}
+	case !inWindow && s.inWindow && s.started:
+		s.handler.EndMaintenance()
+		s.started = false
+	}
+	s.inWindow = inWindow
+}
+
+// currentWindow returns the window in effect at now and when it ends. When
+// windows overlap, the one ending last wins.
+func (s *MaintenanceScheduler) currentWindow(now time.Time) (time.Time, MaintenanceWindow, bool) {
+	var end time.Time
+	var current MaintenanceWindow
+	for _, w := range s.windows {
+		start, ok := w.Schedule.LastBefore(now, w.Duration)
+		if !ok {
+			continue
+		}
+		if e := start.Add(w.Duration); e.After(end) {
+			end, current = e, w
+		}
+	}
+	return end, current, !end.IsZero()
+}
@@ -0,0 +1,148 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestMaintenanceScheduler_Window(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+
+	schedule, _ := ParseSchedule("0 2 * * 0") // Sundays at 02:00
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("No time zone data: %v", err)
+	}
+	s := NewMaintenanceScheduler(h, []MaintenanceWindow{{Schedule: schedule, Duration: 2 * time.Hour, Message: "Upgrading clusters."}}, berlin)
+
+	sunday := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 2, hour, minute, 0, 0, berlin)
+	}
+
+	s.check(sunday(1, 59))
+	if h.InMaintenance() {
+		t.Fatal("Maintenance should not start before the window")
+	}
+
+	// Checks run in UTC; the schedule is in Berlin time
+	s.check(sunday(2, 0).UTC())
+	if !h.InMaintenance() {
+		t.Fatal("Maintenance should start when the window opens")
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "Maintenance has started") || !strings.Contains(texts[0], "Upgrading clusters.") {
+		t.Fatalf("Expected a start notice to the active chat, got %q", texts)
+	}
+
+	s.check(sunday(3, 59))
+	if !h.InMaintenance() || len(platform.sentTexts()) != 1 {
+		t.Fatal("Maintenance should continue through the window without repeating the notice")
+	}
+
+	query := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: "are pods ok?"}
+	if err := h.HandleMessage(query); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+	texts = platform.sentTexts()
+	if len(texts) != 2 || !strings.Contains(texts[1], "Upgrading clusters.") || !strings.Contains(texts[1], "04:00") {
+		t.Fatalf("Expected the query to be refused until 04:00, got %q", texts)
+	}
+
+	s.check(sunday(4, 0))
+	if h.InMaintenance() {
+		t.Fatal("Maintenance should end when the window closes")
+	}
+	texts = platform.sentTexts()
+	if len(texts) != 3 || !strings.Contains(texts[2], "Maintenance is over") {
+		t.Fatalf("Expected an end notice, got %q", texts)
+	}
+}
+
+func TestMaintenanceScheduler_LeavesManualMaintenance(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+	schedule, _ := ParseSchedule("0 2 * * *")
+	s := NewMaintenanceScheduler(h, []MaintenanceWindow{{Schedule: schedule, Duration: time.Hour}}, time.UTC)
+	at := func(hour, minute int) time.Time { return time.Date(2024, 6, 3, hour, minute, 0, 0, time.UTC) }
+
+	// Turned on by hand before the window: the window's end doesn't turn it off
+	h.StartMaintenance(time.Time{}, "")
+	s.check(at(2, 0))
+	s.check(at(3, 0))
+	if !h.InMaintenance() {
+		t.Fatal("Maintenance turned on by hand should outlast the window")
+	}
+	h.EndMaintenance()
+
+	// Turned off by hand during the window: it stays off
+	s.check(at(2, 0).AddDate(0, 0, 1))
+	h.EndMaintenance()
+	s.check(at(2, 30).AddDate(0, 0, 1))
+	if h.InMaintenance() {
+		t.Fatal("Maintenance turned off by hand should stay off for the rest of the window")
+	}
+}
+
+func TestMaintenanceScheduler_StopsOnCancel(t *testing.T) {
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, nil, nil, []string{"1"})
+	s := NewMaintenanceScheduler(h, nil, time.UTC)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Start(ctx)
+		close(done)
+	}()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Start() should return when its context is cancelled")
+	}
+}
+
+func TestMaintenanceCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1", "admin"})
+	h.SetAdminIDs([]string{"admin"})
+
+	send := func(from, text string) {
+		msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: from}, Text: text}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q) error = %v", text, err)
+		}
+	}
+
+	send("alice", "/maintenance on")
+	if h.InMaintenance() {
+		t.Fatal("Non-admins must not toggle maintenance")
+	}
+	send("admin", "/maintenance on Back soon.")
+	if !h.InMaintenance() {
+		t.Fatal("/maintenance on should start maintenance")
+	}
+	send("admin", "/maintenance")
+	texts := platform.sentTexts()
+	if last := texts[len(texts)-1]; !strings.Contains(last, "is on") || !strings.Contains(last, "Back soon.") {
+		t.Errorf("Status should show the maintenance message, got %q", last)
+	}
+	send("admin", "/maintenance off")
+	if h.InMaintenance() {
+		t.Fatal("/maintenance off should end maintenance")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
 )
 
 const (
@@ -21,6 +22,16 @@ type RateLimiter struct {
 	mu       sync.Mutex
 	limit    int
 	window   time.Duration
+
+	// Overrides of limit/window, by chat ID first and then by chat type
+	chatLimits map[string]RateLimit
+	typeLimits map[messaging.ChatType]RateLimit
+}
+
+// RateLimit allows Limit requests per Window.
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
 }
 
 func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
@@ -31,12 +42,52 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 	}
 }
 
+// SetOverrides gives specific chats, or all chats of a type, a different
+// quota than the default. A chat's own override wins over its type's.
+func (rl *RateLimiter) SetOverrides(byChat map[string]RateLimit, byType map[messaging.ChatType]RateLimit) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.chatLimits = byChat
+	rl.typeLimits = byType
+}
+
+// limitFor returns the quota that applies to chatID. Must hold rl.mu.
+func (rl *RateLimiter) limitFor(chatID string, chatType messaging.ChatType) RateLimit {
+	if l, ok := rl.chatLimits[chatID]; ok {
+		return l
+	}
+	if l, ok := rl.typeLimits[chatType]; ok && chatType != "" {
+		return l
+	}
+	return RateLimit{Limit: rl.limit, Window: rl.window}
+}
+
+// maxWindow returns the longest window of any quota. Must hold rl.mu.
+func (rl *RateLimiter) maxWindow() time.Duration {
+	longest := rl.window
+	for _, l := range rl.chatLimits {
+		longest = max(longest, l.Window)
+	}
+	for _, l := range rl.typeLimits {
+		longest = max(longest, l.Window)
+	}
+	return longest
+}
+
+// Allow reports whether chatID may make another request under its own
+// override or the default quota.
 func (rl *RateLimiter) Allow(chatID string) bool {
+	return rl.AllowChat(chatID, "")
+}
+
+// AllowChat is Allow that also considers overrides for chatType.
+func (rl *RateLimiter) AllowChat(chatID string, chatType messaging.ChatType) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
+	quota := rl.limitFor(chatID, chatType)
 	now := time.Now()
-	cutoff := now.Add(-rl.window)
+	cutoff := now.Add(-quota.Window)
 
 	requests, exists := rl.requests[chatID]
 	if !exists {
@@ -51,7 +102,7 @@ func (rl *RateLimiter) Allow(chatID string) bool {
 		}
 	}
 
-	if len(validRequests) >= rl.limit {
+	if len(validRequests) >= quota.Limit {
 		rl.requests[chatID] = validRequests
 		return false
 	}
@@ -66,7 +117,7 @@ func (rl *RateLimiter) Cleanup() {
 	defer rl.mu.Unlock()
 
 	now := time.Now()
-	cutoff := now.Add(-rl.window * cleanupWindowMultiplier)
+	cutoff := now.Add(-rl.maxWindow() * cleanupWindowMultiplier)
 
 	for chatID, requests := range rl.requests {
 		var validRequests []time.Time
@@ -87,6 +138,7 @@ func (rl *RateLimiter) Cleanup() {
 type Middleware struct {
 	rateLimiter *RateLimiter
 	platform    messaging.Platform
+	exempt      map[string]bool
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
@@ -102,10 +154,28 @@ func NewMiddleware(rateLimit int, window time.Duration, platform messaging.Platf
 	}
 }
 
+// SetRateLimitExempt lets messages from the given chats bypass rate limiting.
+func (m *Middleware) SetRateLimitExempt(chatIDs []string) {
+	m.exempt = make(map[string]bool, len(chatIDs))
+	for _, id := range chatIDs {
+		m.exempt[id] = true
+	}
+}
+
+// SetRateLimitOverrides replaces the default quota for the given chats and
+// chat types.
+func (m *Middleware) SetRateLimitOverrides(byChat map[string]RateLimit, byType map[messaging.ChatType]RateLimit) {
+	m.rateLimiter.SetOverrides(byChat, byType)
+}
+
 func (m *Middleware) RateLimit(handler messaging.MessageHandler) messaging.MessageHandler {
 	return func(msg *messaging.IncomingMessage) error {
-		if !m.rateLimiter.Allow(msg.ChatID) {
+		if m.exempt[msg.ChatID] {
+			return handler(msg)
+		}
+		if !m.rateLimiter.AllowChat(msg.ChatID, msg.ChatType) {
 			slog.Warn("Rate limit exceeded", "chat_id", msg.ChatID)
+			metrics.RateLimitRejections.Inc()
 			if m.platform != nil {
 				outMsg := &messaging.OutgoingMessage{
 					ChatID:           msg.ChatID,
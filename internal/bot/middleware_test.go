@@ -1,10 +1,13 @@
 package bot
 
 import (
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
 )
 
 func TestNewRateLimiter(t *testing.T) {
@@ -82,6 +85,52 @@ func TestRateLimiter_Allow_WindowExpiry(t *testing.T) {
 	}
 }
 
+func TestRateLimiter_Overrides(t *testing.T) {
+	rl := NewRateLimiter(2, time.Minute)
+	rl.SetOverrides(
+		map[string]RateLimit{"ops": {Limit: 4, Window: time.Minute}},
+		map[messaging.ChatType]RateLimit{
+			messaging.ChatTypeGroup:   {Limit: 1, Window: time.Minute},
+			messaging.ChatTypePrivate: {Limit: 3, Window: time.Minute},
+		},
+	)
+
+	tests := []struct {
+		chatID   string
+		chatType messaging.ChatType
+		want     int
+	}{
+		{"ops", messaging.ChatTypeGroup, 4},       // Chat override wins over type
+		{"general", messaging.ChatTypeGroup, 1},   // Type override
+		{"dm", messaging.ChatTypePrivate, 3},      // Type override
+		{"channel", messaging.ChatTypeChannel, 2}, // Default
+	}
+	for _, tt := range tests {
+		allowed := 0
+		for i := 0; i < 10; i++ {
+			if rl.AllowChat(tt.chatID, tt.chatType) {
+				allowed++
+			}
+		}
+		if allowed != tt.want {
+			t.Errorf("%s (%s): allowed %d requests, want %d", tt.chatID, tt.chatType, allowed, tt.want)
+		}
+	}
+}
+
+func TestRateLimiter_Cleanup_KeepsLongestOverrideWindow(t *testing.T) {
+	rl := NewRateLimiter(10, 50*time.Millisecond)
+	rl.SetOverrides(map[string]RateLimit{"ops": {Limit: 1, Window: time.Hour}}, nil)
+
+	rl.AllowChat("ops", messaging.ChatTypeGroup)
+	time.Sleep(120 * time.Millisecond)
+	rl.Cleanup()
+
+	if rl.AllowChat("ops", messaging.ChatTypeGroup) {
+		t.Error("Cleanup should not forget requests still inside an override's window")
+	}
+}
+
 func TestRateLimiter_Cleanup(t *testing.T) {
 	rl := NewRateLimiter(10, 50*time.Millisecond)
 
@@ -140,10 +189,34 @@ func TestMiddleware_RateLimit(t *testing.T) {
 	}
 
 	// 3rd call should be rate limited
+	before := testutil.ToFloat64(metrics.RateLimitRejections)
 	wrappedHandler(msg)
 	if callCount != 2 {
 		t.Errorf("Expected still 2 calls after rate limiting, got %d", callCount)
 	}
+	if got := testutil.ToFloat64(metrics.RateLimitRejections) - before; got != 1 {
+		t.Errorf("RateLimitRejections increased by %v, want 1", got)
+	}
+}
+
+func TestMiddleware_RateLimit_NotifiesUser(t *testing.T) {
+	platform := newFakePlatform()
+	m := NewMiddleware(1, time.Minute, platform)
+	defer m.Stop()
+
+	wrappedHandler := m.RateLimit(func(msg *messaging.IncomingMessage) error { return nil })
+
+	msg := &messaging.IncomingMessage{ChatID: "test-chat", MessageID: "7"}
+	wrappedHandler(msg)
+	wrappedHandler(msg)
+
+	if len(platform.sent) != 1 {
+		t.Fatalf("Expected 1 rate limit notification, got %d", len(platform.sent))
+	}
+	sent := platform.sent[0]
+	if sent.ChatID != "test-chat" || sent.ReplyToMessageID != "7" || !strings.Contains(sent.Text, "Rate limit exceeded") {
+		t.Errorf("Unexpected notification: %+v", sent)
+	}
 }
 
 func TestMiddleware_Logger(t *testing.T) {
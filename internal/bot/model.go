@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// SetModels registers the /model command, letting each chat pick one of
+// allowed instead of defaultModel. Only listed names reach the CLI's --model
+// flag. An empty allowed list leaves /model disabled.
+func (h *Handler) SetModels(defaultModel string, allowed []string) {
+	if len(allowed) == 0 {
+		return
+	}
+	if _, exists := h.commands.Lookup("/model"); exists {
+		return
+	}
+	h.defaultModel = defaultModel
+	h.allowedModels = allowed
+	h.commands.Register(CommandHandler{
+		Name:        "/model",
+		Description: "Show or change the Claude model for this chat (/model opus, /model default)",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleModelCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+}
+
+// handleModelCommand handles /model [name|default]. Without an argument it
+// reports the chat's model and the allowed choices.
+func (h *Handler) handleModelCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /model command", "chat_id", chatID, "args", fields)
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /model", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", replyToMessageID)
+	}
+	if ctx == nil {
+		return h.sendText(chatID, "❌ No session yet. Send a message first, then choose its model.", replyToMessageID)
+	}
+
+	choices := "`" + strings.Join(h.allowedModels, "`, `") + "`"
+	if len(fields) < 2 {
+		current := h.chatModel(chatID)
+		source := "set for this chat"
+		if current == "" {
+			current, source = h.defaultModelName(), "default"
+		}
+		return h.sendText(chatID, fmt.Sprintf("🧠 *Model:* %s (%s)\n\nAvailable: %s\nUse `/model <name>` to change it or `/model default` to reset.",
+			current, source, choices), replyToMessageID)
+	}
+
+	model := strings.ToLower(fields[1])
+	if model == "default" {
+		model = ""
+	} else if !slices.Contains(h.allowedModels, model) {
+		return h.sendText(chatID, fmt.Sprintf("❌ Unknown model `%s`. Available: %s", fields[1], choices), replyToMessageID)
+	}
+
+	if err := h.storage.SetChatModel(chatID, model); err != nil {
+		slog.Error("Failed to set chat model", "chat_id", chatID, "model", model, "error", err)
+		return h.sendError(chatID, "Failed to update the model.", replyToMessageID)
+	}
+
+	if model == "" {
+		return h.sendText(chatID, fmt.Sprintf("✅ Model reset to the default (%s).", h.defaultModelName()), replyToMessageID)
+	}
+	return h.sendText(chatID, fmt.Sprintf("✅ Model set to %s for this chat.", model), replyToMessageID)
+}
+
+// chatModel returns the model chosen for the chat, or "" for the configured
+// default. A stored model that is no longer allowed is ignored.
+func (h *Handler) chatModel(chatID string) string {
+	if len(h.allowedModels) == 0 {
+		return ""
+	}
+	model, err := h.storage.GetChatModel(chatID)
+	if err != nil {
+		slog.Warn("Failed to load chat model", "chat_id", chatID, "error", err)
+		return ""
+	}
+	if model != "" && !slices.Contains(h.allowedModels, model) {
+		slog.Warn("Ignoring chat model that is no longer allowed", "chat_id", chatID, "model", model)
+		return ""
+	}
+	return model
+}
+
+// defaultModelName describes the model used when a chat has none set.
+func (h *Handler) defaultModelName() string {
+	if h.defaultModel == "" {
+		return "Claude CLI default"
+	}
+	return h.defaultModel
+}
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestModelCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "group", "session-1", 2*time.Hour)
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	h.SetModels("sonnet", []string{"sonnet", "opus"})
+	msg := &messaging.IncomingMessage{ChatID: "1"}
+
+	for _, args := range [][]string{
+		{"/model"},
+		{"/model", "Opus"},
+		{"/model"},
+		{"/model", "--dangerously-skip-permissions"},
+		{"/model", "default"},
+		{"/model"},
+	} {
+		if err := h.dispatchCommand(msg, args); err != nil {
+			t.Fatalf("dispatchCommand(%v) error = %v", args, err)
+		}
+	}
+
+	texts := platform.sentTexts()
+	want := []string{
+		"sonnet (default)",
+		"set to opus",
+		"opus (set for this chat)",
+		"Unknown model",
+		"reset to the default (sonnet)",
+		"sonnet (default)",
+	}
+	if len(texts) != len(want) {
+		t.Fatalf("Expected %d replies, got %d: %v", len(want), len(texts), texts)
+	}
+	for i, w := range want {
+		if !strings.Contains(texts[i], w) {
+			t.Errorf("Reply %d = %q, want it to contain %q", i, texts[i], w)
+		}
+	}
+}
+
+func TestChatModel_IgnoresModelsNoLongerAllowed(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "group", "session-1", 2*time.Hour)
+	_ = store.SetChatModel("1", "opus")
+
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	if got := h.chatModel("1"); got != "" {
+		t.Errorf("chatModel() with /model disabled = %q, want empty", got)
+	}
+
+	h.SetModels("", []string{"opus"})
+	if got := h.chatModel("1"); got != "opus" {
+		t.Errorf("chatModel() = %q, want opus", got)
+	}
+
+	h.allowedModels = []string{"haiku"}
+	if got := h.chatModel("1"); got != "" {
+		t.Errorf("chatModel() for a removed model = %q, want empty", got)
+	}
+}
@@ -0,0 +1,63 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestObserverCanViewButNotQuery(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"group"})
+	h.SetSandboxChatIDs([]string{"group"})
+	h.SetObserverIDs([]string{"intern"})
+
+	send := func(userID, text string) []string {
+		t.Helper()
+		before := len(platform.sent)
+		msg := &messaging.IncomingMessage{
+			ChatID:          "group",
+			MessageID:       "1",
+			ChatType:        messaging.ChatTypeGroup,
+			From:            messaging.User{ID: userID},
+			Text:            text,
+			IsMentioningBot: !strings.HasPrefix(text, "/"),
+		}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q from %s) error = %v", text, userID, err)
+		}
+		return platform.sentTexts()[before:]
+	}
+
+	// Observer: mentions are ignored, read-only commands work, others are refused
+	if replies := send("intern", "@bot what's failing?"); len(replies) != 0 {
+		t.Errorf("Observer mention should not trigger a query, got %v", replies)
+	}
+	if replies := send("intern", "/status"); len(replies) != 1 || !strings.Contains(replies[0], "No active session") {
+		t.Errorf("Observer should see /status, got %v", replies)
+	}
+	if replies := send("intern", "/new"); len(replies) != 1 || !strings.Contains(replies[0], "Observers can only use read-only commands") {
+		t.Errorf("Observer /new should be refused, got %v", replies)
+	}
+
+	// Normal user: can query and view status
+	if replies := send("engineer", "@bot what's failing?"); len(replies) != 1 || !strings.Contains(replies[0], "args:") {
+		t.Errorf("Normal user mention should trigger a query, got %v", replies)
+	}
+	if replies := send("engineer", "/status"); len(replies) != 1 || !strings.Contains(replies[0], "No active session") {
+		t.Errorf("Normal user should see /status, got %v", replies)
+	}
+}
@@ -0,0 +1,141 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// OnCallUser is the person a session is handed off to. ChatID is their
+// private chat with the bot.
+type OnCallUser struct {
+	Name   string
+	ChatID string
+}
+
+// OnCallResolver finds who is on call, e.g. from a static schedule or an
+// external paging service.
+type OnCallResolver interface {
+	CurrentOnCall(now time.Time) (OnCallUser, error)
+}
+
+// StaticRotation is an OnCallResolver that cycles through users, each taking
+// one shift, starting with the first user at start.
+type StaticRotation struct {
+	start time.Time
+	shift time.Duration
+	users []OnCallUser
+}
+
+// NewStaticRotation creates a rotation of users with fixed-length shifts.
+func NewStaticRotation(start time.Time, shift time.Duration, users []OnCallUser) *StaticRotation {
+	return &StaticRotation{start: start, shift: shift, users: users}
+}
+
+// CurrentOnCall returns the user whose shift covers now. Times before start
+// count backwards through the rotation.
+func (r *StaticRotation) CurrentOnCall(now time.Time) (OnCallUser, error) {
+	if len(r.users) == 0 || r.shift <= 0 {
+		return OnCallUser{}, errors.New("on-call rotation is empty")
+	}
+	elapsed := now.Sub(r.start)
+	shifts := int64(elapsed / r.shift)
+	if elapsed < 0 && elapsed%r.shift != 0 {
+		shifts-- // Round towards the earlier shift
+	}
+	n := int64(len(r.users))
+	return r.users[((shifts%n)+n)%n], nil
+}
+
+// SetOnCallResolver registers the /handoff command, which transfers the
+// chat's session to the on-call user's DM.
+func (h *Handler) SetOnCallResolver(resolver OnCallResolver) {
+	if resolver == nil {
+		return
+	}
+	if _, exists := h.commands.Lookup("/handoff"); exists {
+		return
+	}
+	h.onCall = resolver
+	h.commands.Register(CommandHandler{
+		Name:        "/handoff",
+		Description: "Transfer this chat's session to whoever is on call",
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleHandoffCommand(msg)
+		},
+	})
+}
+
+// handleHandoffCommand handles /handoff.
+func (h *Handler) handleHandoffCommand(msg *messaging.IncomingMessage) error {
+	chatID := msg.ChatID
+	slog.Info("Processing /handoff command", "chat_id", chatID, "user_id", msg.From.ID)
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /handoff", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", msg.MessageID)
+	}
+	if ctx == nil || !ctx.IsActive || ctx.ClaudeSessionID == "" {
+		return h.sendText(chatID, "❌ No active session to hand off. Send a message first.", msg.MessageID)
+	}
+
+	onCall, err := h.onCall.CurrentOnCall(time.Now())
+	if err != nil {
+		slog.Error("Failed to resolve on-call user", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to find who is on call.", msg.MessageID)
+	}
+	name := onCall.Name
+	if name == "" {
+		name = onCall.ChatID
+	}
+	if onCall.ChatID == chatID {
+		return h.sendText(chatID, fmt.Sprintf("ℹ️ %s is on call and already owns this session.", name), msg.MessageID)
+	}
+	// The on-call's DM is the chat with them, so both IDs are the same
+	if !h.allowList.Allows(onCall.ChatID, onCall.ChatID) {
+		slog.Warn("On-call user is not allowed to use the bot", "chat_id", chatID, "oncall_chat_id", onCall.ChatID)
+		return h.sendText(chatID, fmt.Sprintf("❌ %s is on call but isn't allowed to use this bot.", name), msg.MessageID)
+	}
+
+	result, err := h.transferSession(ctx, onCall.ChatID, messaging.ChatTypePrivate.String())
+	if errors.Is(err, errTransferNotAllowed) {
+		return h.sendText(chatID, formatTransferRefusal(ctx.ChatType, messaging.ChatTypePrivate.String()), msg.MessageID)
+	}
+	if err != nil {
+		slog.Error("Failed to hand off session",
+			"source_chat_id", chatID,
+			"target_chat_id", onCall.ChatID,
+			"claude_session_id", ctx.ClaudeSessionID,
+			"error", err)
+		return h.sendError(chatID, "Failed to hand off session. Please try again.", msg.MessageID)
+	}
+
+	notified := true
+	notifyMsg := &messaging.OutgoingMessage{
+		ChatID: onCall.ChatID,
+		Text: fmt.Sprintf("📟 *Session Handed Off*\n\n"+
+			"A session from another chat was handed to you as the on-call.\n\n"+
+			"*Claude Session ID:* `%s`\n"+
+			"*Messages restored:* %d\n"+
+			"*Tools restored:* %d\n\n"+
+			"Send a message here to continue the conversation.",
+			result.ClaudeSessionID,
+			result.MessagesTransferred,
+			result.ToolsTransferred),
+	}
+	if _, err := h.platform.SendMessage(notifyMsg); err != nil {
+		slog.Warn("Failed to notify on-call user", "oncall_chat_id", onCall.ChatID, "error", err)
+		notified = false
+	}
+
+	text := fmt.Sprintf("✅ Session handed off to %s (on call).\n\nThis chat's session is now inactive. Use `/resume %s` to take it back.",
+		name, result.ClaudeSessionID)
+	if !notified {
+		text += "\n\n⚠️ They couldn't be notified; they may need to start a chat with the bot first."
+	}
+	return h.sendText(chatID, text, msg.MessageID)
+}
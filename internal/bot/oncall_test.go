@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+type fakeOnCallResolver struct {
+	user OnCallUser
+	err  error
+}
+
+func (f *fakeOnCallResolver) CurrentOnCall(time.Time) (OnCallUser, error) {
+	return f.user, f.err
+}
+
+func TestStaticRotation_CurrentOnCall(t *testing.T) {
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	r := NewStaticRotation(start, 24*time.Hour, []OnCallUser{{Name: "alice"}, {Name: "bob"}, {Name: "carol"}})
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{start, "alice"},
+		{start.Add(23 * time.Hour), "alice"},
+		{start.Add(24 * time.Hour), "bob"},
+		{start.Add(3 * 24 * time.Hour), "alice"},
+		{start.Add(-time.Hour), "carol"},
+	}
+	for _, tt := range tests {
+		got, err := r.CurrentOnCall(tt.at)
+		if err != nil || got.Name != tt.want {
+			t.Errorf("CurrentOnCall(%s) = %v, %v; want %s", tt.at, got.Name, err, tt.want)
+		}
+	}
+
+	if _, err := NewStaticRotation(start, time.Hour, nil).CurrentOnCall(start); err == nil {
+		t.Error("Empty rotation should return an error")
+	}
+}
+
+func TestHandoffCommand_TransfersToOnCall(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("group", "group", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("group", "claude-1")
+	_ = store.SaveMessage("group", "session-1", "", "", "user", "disk is full on db-1")
+
+	sm := claude.NewSessionManager("/nonexistent", t.TempDir(), "", 2, time.Second)
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, nil, time.Hour), nil, nil, sm, nil, nil, store, []string{"group", "oncall-dm"})
+	resolver := &fakeOnCallResolver{user: OnCallUser{Name: "alice", ChatID: "oncall-dm"}}
+	h.SetOnCallResolver(resolver)
+
+	msg := &messaging.IncomingMessage{ChatID: "group", From: messaging.User{ID: "bob"}}
+	if err := h.dispatchCommand(msg, []string{"/handoff"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+
+	target, _ := store.GetContext("oncall-dm")
+	if target == nil || !target.IsActive || target.ClaudeSessionID != "claude-1" {
+		t.Fatalf("On-call chat context = %+v, want the transferred session", target)
+	}
+	if source, _ := store.GetContext("group"); source.IsActive {
+		t.Error("Source chat should be inactive after handoff")
+	}
+
+	if len(platform.sent) != 2 {
+		t.Fatalf("Expected on-call notification and confirmation, got %v", platform.sentTexts())
+	}
+	if platform.sent[0].ChatID != "oncall-dm" || !strings.Contains(platform.sent[0].Text, "Messages restored:* 1") {
+		t.Errorf("On-call notification = %+v", platform.sent[0])
+	}
+	if platform.sent[1].ChatID != "group" || !strings.Contains(platform.sent[1].Text, "handed off to alice") {
+		t.Errorf("Confirmation = %+v", platform.sent[1])
+	}
+
+	// Nothing left to hand off, and resolver failures are reported
+	resolver.err = errors.New("pager down")
+	_ = h.dispatchCommand(msg, []string{"/handoff"})
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "oncall-dm"}, []string{"/handoff"})
+	texts := platform.sentTexts()
+	if !strings.Contains(texts[2], "No active session") || !strings.Contains(texts[3], "Failed to find who is on call") {
+		t.Errorf("Unexpected replies: %v", texts[2:])
+	}
+}
+
+func TestHandoffCommand_OnCallNotAllowed(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("group", "group", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("group", "claude-1")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, nil, time.Hour), nil, nil, nil, nil, nil, store, []string{"group"})
+	h.SetOnCallResolver(&fakeOnCallResolver{user: OnCallUser{Name: "alice", ChatID: "stranger"}})
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "group"}, []string{"/handoff"})
+	if texts := platform.sentTexts(); len(texts) != 1 || !strings.Contains(texts[0], "isn't allowed") {
+		t.Errorf("Expected refusal, got %v", texts)
+	}
+	if source, _ := store.GetContext("group"); !source.IsActive {
+		t.Error("Session should stay in place when the on-call can't use the bot")
+	}
+}
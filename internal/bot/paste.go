@@ -0,0 +1,120 @@
+package bot
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// PasteMerger reassembles long pastes that Telegram splits across several
+// messages. A message that looks incomplete (an unclosed ``` code fence, or a
+// long message without a sentence terminator) is buffered, and subsequent
+// messages from the same user in the same chat arriving within the window are
+// appended to it. The combined message is dispatched once it looks complete
+// or when the window passes without a new part.
+type PasteMerger struct {
+	window    time.Duration
+	minLength int
+	pending   map[string]*pendingPaste
+	mu        sync.Mutex
+}
+
+type pendingPaste struct {
+	first *messaging.IncomingMessage
+	parts []string
+	timer *time.Timer
+}
+
+// NewPasteMerger creates a merger. minLength is the length at which a message
+// without a terminator is considered a possible split paste.
+func NewPasteMerger(window time.Duration, minLength int) *PasteMerger {
+	return &PasteMerger{
+		window:    window,
+		minLength: minLength,
+		pending:   make(map[string]*pendingPaste),
+	}
+}
+
+// Wrap returns a handler that buffers split pastes before calling next.
+func (pm *PasteMerger) Wrap(next messaging.MessageHandler) messaging.MessageHandler {
+	return func(msg *messaging.IncomingMessage) error {
+		key := msg.ChatID + "|" + msg.From.ID
+
+		pm.mu.Lock()
+		if p, exists := pm.pending[key]; exists && !strings.HasPrefix(msg.Text, "/") {
+			p.timer.Stop()
+			p.parts = append(p.parts, msg.Text)
+
+			if pm.looksIncomplete(strings.Join(p.parts, "\n")) {
+				// Still mid-paste - wait for the next part
+				p.timer = time.AfterFunc(pm.window, func() { pm.flush(key, p, next) })
+				pm.mu.Unlock()
+				return nil
+			}
+
+			delete(pm.pending, key)
+			pm.mu.Unlock()
+			return next(mergePaste(p))
+		}
+
+		if strings.HasPrefix(msg.Text, "/") || !pm.looksIncomplete(msg.Text) {
+			pm.mu.Unlock()
+			return next(msg)
+		}
+
+		p := &pendingPaste{first: msg, parts: []string{msg.Text}}
+		p.timer = time.AfterFunc(pm.window, func() { pm.flush(key, p, next) })
+		pm.pending[key] = p
+		pm.mu.Unlock()
+
+		slog.Debug("Buffering possible split paste", "chat_id", msg.ChatID, "user_id", msg.From.ID, "length", len(msg.Text))
+		return nil
+	}
+}
+
+// flush dispatches a buffered paste after its window expires.
+func (pm *PasteMerger) flush(key string, p *pendingPaste, next messaging.MessageHandler) {
+	pm.mu.Lock()
+	if pm.pending[key] != p {
+		// Already dispatched by a completing part
+		pm.mu.Unlock()
+		return
+	}
+	delete(pm.pending, key)
+	pm.mu.Unlock()
+
+	if err := next(mergePaste(p)); err != nil {
+		slog.Error("Error handling merged paste", "chat_id", p.first.ChatID, "error", err)
+	}
+}
+
+// looksIncomplete reports whether text looks like the first part of a split paste.
+func (pm *PasteMerger) looksIncomplete(text string) bool {
+	// Odd number of fences means a code block was opened but not closed
+	if strings.Count(text, "```")%2 == 1 {
+		return true
+	}
+
+	if len(text) < pm.minLength {
+		return false
+	}
+
+	trimmed := strings.TrimSpace(text)
+	return !strings.HasSuffix(trimmed, ".") &&
+		!strings.HasSuffix(trimmed, "?") &&
+		!strings.HasSuffix(trimmed, "!") &&
+		!strings.HasSuffix(trimmed, "```")
+}
+
+// mergePaste combines buffered parts into a single message that replies to the first part.
+func mergePaste(p *pendingPaste) *messaging.IncomingMessage {
+	merged := *p.first
+	merged.Text = strings.Join(p.parts, "\n")
+	if len(p.parts) > 1 {
+		slog.Info("Merged split paste", "chat_id", merged.ChatID, "parts", len(p.parts), "length", len(merged.Text))
+	}
+	return &merged
+}
@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+type recordingHandler struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+func (r *recordingHandler) handle(msg *messaging.IncomingMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.texts = append(r.texts, msg.Text)
+	return nil
+}
+
+func (r *recordingHandler) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.texts...)
+}
+
+func pasteMsg(text string) *messaging.IncomingMessage {
+	return &messaging.IncomingMessage{ChatID: "chat1", MessageID: "1", From: messaging.User{ID: "user1"}, Text: text}
+}
+
+func TestPasteMerger_ReassemblesCodeFence(t *testing.T) {
+	pm := NewPasteMerger(time.Second, 3000)
+	rec := &recordingHandler{}
+	handler := pm.Wrap(rec.handle)
+
+	handler(pasteMsg("why does this fail?\n```yaml\napiVersion: apps/v1\nkind: Deployment"))
+	handler(pasteMsg("spec:\n  replicas: 3"))
+	handler(pasteMsg("  template: {}\n```"))
+
+	got := rec.received()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 merged query, got %d: %v", len(got), got)
+	}
+	for _, part := range []string{"kind: Deployment", "replicas: 3", "template: {}"} {
+		if !strings.Contains(got[0], part) {
+			t.Errorf("Merged query missing %q: %q", part, got[0])
+		}
+	}
+}
+
+func TestPasteMerger_FlushesAfterWindow(t *testing.T) {
+	pm := NewPasteMerger(50*time.Millisecond, 20)
+	rec := &recordingHandler{}
+	handler := pm.Wrap(rec.handle)
+
+	handler(pasteMsg("a long line without any terminator"))
+	handler(pasteMsg("continued here"))
+
+	if got := rec.received(); len(got) != 0 {
+		t.Fatalf("Expected paste to be buffered, got %v", got)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	got := rec.received()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 merged query after window, got %d", len(got))
+	}
+	if got[0] != "a long line without any terminator\ncontinued here" {
+		t.Errorf("Merged query = %q", got[0])
+	}
+}
+
+func TestPasteMerger_PassesThroughCompleteMessages(t *testing.T) {
+	pm := NewPasteMerger(time.Second, 3000)
+	rec := &recordingHandler{}
+	handler := pm.Wrap(rec.handle)
+
+	handler(pasteMsg("show pods in production"))
+	handler(pasteMsg("/status"))
+	handler(pasteMsg("```\nkubectl get pods\n```"))
+
+	if got := rec.received(); len(got) != 3 {
+		t.Errorf("Expected 3 immediate dispatches, got %d: %v", len(got), got)
+	}
+}
+
+func TestPasteMerger_SeparateUsers(t *testing.T) {
+	pm := NewPasteMerger(50*time.Millisecond, 3000)
+	rec := &recordingHandler{}
+	handler := pm.Wrap(rec.handle)
+
+	handler(pasteMsg("```\nfirst user paste"))
+	other := pasteMsg("second user question")
+	other.From.ID = "user2"
+	handler(other)
+
+	got := rec.received()
+	if len(got) != 1 || got[0] != "second user question" {
+		t.Errorf("Other user's message should pass through immediately, got %v", got)
+	}
+}
@@ -0,0 +1,199 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// Preference keys accepted by /prefs.
+const (
+	prefPlain    = "plain"    // on: send responses without Markdown formatting
+	prefFooter   = "footer"   // on: append duration and tool count to responses
+	prefLanguage = "language" // Ask Claude to answer in this language
+	prefStyle    = "style"    // concise or detailed
+)
+
+// preferenceHelp describes each key and its accepted values, in display order.
+var preferenceHelp = []struct{ key, values string }{
+	{prefPlain, "on|off"},
+	{prefFooter, "on|off"},
+	{prefLanguage, "<language>"},
+	{prefStyle, "concise|detailed"},
+}
+
+// maxPreferenceValueLen bounds free-form values such as language.
+const maxPreferenceValueLen = 32
+
+// Preferences are per-chat display settings, persisted as a key/value blob.
+type Preferences struct {
+	Plain    bool
+	Footer   bool
+	Language string
+	Style    string
+}
+
+// parsePreferences converts stored key/value pairs into Preferences.
+// Unknown keys are ignored.
+func parsePreferences(raw map[string]string) Preferences {
+	return Preferences{
+		Plain:    raw[prefPlain] == "on",
+		Footer:   raw[prefFooter] == "on",
+		Language: raw[prefLanguage],
+		Style:    raw[prefStyle],
+	}
+}
+
+// validatePreference checks a key/value pair set via /prefs.
+func validatePreference(key, value string) error {
+	switch key {
+	case prefPlain, prefFooter:
+		if value != "on" && value != "off" {
+			return fmt.Errorf("%s must be on or off", key)
+		}
+	case prefStyle:
+		if value != "concise" && value != "detailed" {
+			return fmt.Errorf("style must be concise or detailed")
+		}
+	case prefLanguage:
+		if len(value) > maxPreferenceValueLen || strings.ContainsAny(value, "\n\r") {
+			return fmt.Errorf("language must be a single short word or phrase")
+		}
+	default:
+		return fmt.Errorf("unknown preference %q", key)
+	}
+	return nil
+}
+
+// applyToQuery adds instructions for language and style to a user query.
+func (p Preferences) applyToQuery(query string) string {
+	var instructions []string
+	if p.Language != "" {
+		instructions = append(instructions, fmt.Sprintf("Respond in %s.", p.Language))
+	}
+	switch p.Style {
+	case "concise":
+		instructions = append(instructions, "Keep the answer brief.")
+	case "detailed":
+		instructions = append(instructions, "Give a detailed answer.")
+	}
+	if len(instructions) == 0 {
+		return query
+	}
+	return query + "\n\n(" + strings.Join(instructions, " ") + ")"
+}
+
+// applyToResponse appends the footer to a response when enabled.
+func (p Preferences) applyToResponse(text string, elapsed time.Duration, toolCount int) string {
+	if !p.Footer {
+		return text
+	}
+	return text + fmt.Sprintf("\n\n⏱ %s · 🔧 %d tools", elapsed.Round(100*time.Millisecond), toolCount)
+}
+
+// SetPreferencesEnabled enables the /prefs command and applies stored
+// preferences to queries and responses.
+func (h *Handler) SetPreferencesEnabled(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/prefs"); exists {
+		return
+	}
+	h.prefsEnabled = true
+	h.commands.Register(CommandHandler{
+		Name:        "/prefs",
+		Description: "View or change display preferences for this chat",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handlePrefsCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+}
+
+// loadPreferences returns the chat's preferences, or defaults when disabled
+// or unavailable.
+func (h *Handler) loadPreferences(chatID string) Preferences {
+	if !h.prefsEnabled {
+		return Preferences{}
+	}
+	raw, err := h.storage.GetPreferences(chatID)
+	if err != nil {
+		slog.Warn("Failed to load preferences, using defaults", "chat_id", chatID, "error", err)
+		return Preferences{}
+	}
+	return parsePreferences(raw)
+}
+
+// handlePrefsCommand handles /prefs, /prefs set <key> <value>, /prefs unset <key>
+// and /prefs reset.
+func (h *Handler) handlePrefsCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /prefs command", "chat_id", chatID, "args", fields)
+
+	raw, err := h.storage.GetPreferences(chatID)
+	if err != nil {
+		slog.Error("Failed to get preferences", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to load preferences.", replyToMessageID)
+	}
+
+	if len(fields) < 2 {
+		return h.sendText(chatID, formatPreferences(raw), replyToMessageID)
+	}
+
+	switch fields[1] {
+	case "set":
+		if len(fields) < 4 {
+			return h.sendText(chatID, "Usage: `/prefs set <key> <value>`", replyToMessageID)
+		}
+		key, value := fields[2], strings.Join(fields[3:], " ")
+		if err := validatePreference(key, value); err != nil {
+			return h.sendText(chatID, fmt.Sprintf("❌ %v", err), replyToMessageID)
+		}
+		raw[key] = value
+	case "unset":
+		if len(fields) < 3 {
+			return h.sendText(chatID, "Usage: `/prefs unset <key>`", replyToMessageID)
+		}
+		delete(raw, fields[2])
+	case "reset":
+		raw = map[string]string{}
+	default:
+		return h.sendText(chatID, "Usage: `/prefs`, `/prefs set <key> <value>`, `/prefs unset <key>`, `/prefs reset`", replyToMessageID)
+	}
+
+	if err := h.storage.SetPreferences(chatID, raw); err != nil {
+		slog.Error("Failed to save preferences", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to save preferences.", replyToMessageID)
+	}
+
+	return h.sendText(chatID, "✅ Preferences updated.\n\n"+formatPreferences(raw), replyToMessageID)
+}
+
+// formatPreferences renders the current preferences and available keys.
+func formatPreferences(raw map[string]string) string {
+	var b strings.Builder
+	b.WriteString("⚙️ *Chat Preferences*\n\n")
+
+	if len(raw) == 0 {
+		b.WriteString("_All defaults_\n")
+	} else {
+		keys := make([]string, 0, len(raw))
+		for k := range raw {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(fmt.Sprintf("• %s: `%s`\n", k, raw[k]))
+		}
+	}
+
+	b.WriteString("\n*Available:*\n")
+	for _, p := range preferenceHelp {
+		b.WriteString(fmt.Sprintf("• %s %s\n", p.key, p.values))
+	}
+	b.WriteString("\nUse `/prefs set <key> <value>` to change.")
+	return b.String()
+}
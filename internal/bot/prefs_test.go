@@ -0,0 +1,103 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePreferences(t *testing.T) {
+	p := parsePreferences(map[string]string{
+		"plain":    "on",
+		"footer":   "off",
+		"language": "German",
+		"style":    "concise",
+		"unknown":  "x",
+	})
+
+	want := Preferences{Plain: true, Footer: false, Language: "German", Style: "concise"}
+	if p != want {
+		t.Errorf("parsePreferences() = %+v, want %+v", p, want)
+	}
+}
+
+func TestValidatePreference(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantErr    bool
+	}{
+		{"plain", "on", false},
+		{"plain", "yes", true},
+		{"footer", "off", false},
+		{"style", "detailed", false},
+		{"style", "verbose", true},
+		{"language", "Brazilian Portuguese", false},
+		{"language", strings.Repeat("x", 40), true},
+		{"color", "red", true},
+	}
+
+	for _, tt := range tests {
+		err := validatePreference(tt.key, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validatePreference(%q, %q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPreferences_Apply(t *testing.T) {
+	if got := (Preferences{}).applyToQuery("show pods"); got != "show pods" {
+		t.Errorf("Default prefs should not change query, got %q", got)
+	}
+
+	q := Preferences{Language: "German", Style: "concise"}.applyToQuery("show pods")
+	if !strings.HasPrefix(q, "show pods") || !strings.Contains(q, "Respond in German.") || !strings.Contains(q, "brief") {
+		t.Errorf("applyToQuery() = %q, want language and style instructions", q)
+	}
+
+	if got := (Preferences{}).applyToResponse("answer", time.Second, 2); got != "answer" {
+		t.Errorf("Footer off should not change response, got %q", got)
+	}
+	r := Preferences{Footer: true}.applyToResponse("answer", 90*time.Second, 2)
+	if !strings.Contains(r, "1m30s") || !strings.Contains(r, "2 tools") {
+		t.Errorf("applyToResponse() = %q, want duration and tool count footer", r)
+	}
+}
+
+func TestHandlePrefsCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	h.SetPreferencesEnabled(true)
+
+	steps := []struct {
+		args string
+		want string
+	}{
+		{"/prefs", "All defaults"},
+		{"/prefs set plain on", "plain: `on`"},
+		{"/prefs set language Brazilian Portuguese", "language: `Brazilian Portuguese`"},
+		{"/prefs set style verbose", "style must be concise or detailed"},
+		{"/prefs unset plain", "Preferences updated"},
+	}
+	for _, step := range steps {
+		if err := h.handlePrefsCommand("1", strings.Fields(step.args), "1"); err != nil {
+			t.Fatalf("%s: error %v", step.args, err)
+		}
+		texts := platform.sentTexts()
+		if last := texts[len(texts)-1]; !strings.Contains(last, step.want) {
+			t.Errorf("%s: reply %q should contain %q", step.args, last, step.want)
+		}
+	}
+
+	prefs := h.loadPreferences("1")
+	if prefs.Plain || prefs.Language != "Brazilian Portuguese" {
+		t.Errorf("loadPreferences() = %+v, want language only", prefs)
+	}
+
+	_ = h.handlePrefsCommand("1", []string{"/prefs", "reset"}, "1")
+	if prefs := h.loadPreferences("1"); prefs != (Preferences{}) {
+		t.Errorf("Preferences after reset = %+v, want defaults", prefs)
+	}
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// SetProjects registers the /project command, letting each chat run its
+// queries in one of projects (name -> directory) instead of the configured
+// project path. An empty map leaves /project disabled.
+func (h *Handler) SetProjects(projects map[string]string) {
+	if len(projects) == 0 {
+		return
+	}
+	if _, exists := h.commands.Lookup("/project"); exists {
+		return
+	}
+	h.projects = projects
+	h.commands.Register(CommandHandler{
+		Name:        "/project",
+		Description: "Show or change the project this chat works on (/project cluster-a, /project default)",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleProjectCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+}
+
+// handleProjectCommand handles /project [name|default]. Without an argument
+// it reports the chat's project and the available ones. Claude keeps
+// conversations per directory, so changing the project resets the session.
+func (h *Handler) handleProjectCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /project command", "chat_id", chatID, "args", fields)
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /project", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", replyToMessageID)
+	}
+	if ctx == nil {
+		return h.sendText(chatID, "❌ No session yet. Send a message first, then choose its project.", replyToMessageID)
+	}
+
+	names := make([]string, 0, len(h.projects))
+	for name := range h.projects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	choices := "`" + strings.Join(names, "`, `") + "`"
+
+	current := h.chatProject(chatID)
+	if len(fields) < 2 {
+		status := "default"
+		if current != "" {
+			status = current + " (set for this chat)"
+		}
+		return h.sendText(chatID, fmt.Sprintf("📁 *Project:* %s\n\nAvailable: %s\nUse `/project <name>` to change it or `/project default` to reset.",
+			status, choices), replyToMessageID)
+	}
+
+	project := strings.ToLower(fields[1])
+	if project == "default" {
+		project = ""
+	} else if _, ok := h.projects[project]; !ok {
+		return h.sendText(chatID, fmt.Sprintf("❌ Unknown project `%s`. Available: %s", fields[1], choices), replyToMessageID)
+	}
+	if project == current {
+		return h.sendText(chatID, "ℹ️ This chat already works on that project.", replyToMessageID)
+	}
+
+	if err := h.storage.SetChatProject(chatID, project); err != nil {
+		slog.Error("Failed to set chat project", "chat_id", chatID, "project", project, "error", err)
+		return h.sendError(chatID, "Failed to update the project.", replyToMessageID)
+	}
+
+	reply := "✅ Project reset to the default."
+	if project != "" {
+		reply = fmt.Sprintf("✅ Project set to %s for this chat.", project)
+	}
+	// The conversation can't be resumed from another directory
+	if ctx.IsActive && ctx.ClaudeSessionID != "" && h.expiryWorker != nil {
+		if err := h.expiryWorker.ManualCleanup(chatID); err != nil {
+			slog.Error("Failed to reset session after project change", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Project changed, but the session could not be reset. Use /new before asking.", replyToMessageID)
+		}
+		reply += " The session was reset, so your next message starts a fresh conversation there."
+	}
+	return h.sendText(chatID, reply, replyToMessageID)
+}
+
+// chatProject returns the name of the chat's project, or "" for the default.
+// A stored project that is no longer configured is ignored.
+func (h *Handler) chatProject(chatID string) string {
+	if len(h.projects) == 0 {
+		return ""
+	}
+	project, err := h.storage.GetChatProject(chatID)
+	if err != nil {
+		slog.Warn("Failed to load chat project", "chat_id", chatID, "error", err)
+		return ""
+	}
+	if _, ok := h.projects[project]; project != "" && !ok {
+		slog.Warn("Ignoring chat project that is no longer configured", "chat_id", chatID, "project", project)
+		return ""
+	}
+	return project
+}
+
+// chatProjectPath returns the directory of the chat's project, or "" for the
+// configured project path.
+func (h *Handler) chatProjectPath(chatID string) string {
+	return h.projects[h.chatProject(chatID)]
+}
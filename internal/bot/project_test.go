@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestProjectCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "group", "session-1", 2*time.Hour)
+	_ = store.UpdateClaudeSessionID("1", "claude-1")
+
+	sm := claude.NewSessionManager("/bin/true", t.TempDir(), "", 2, time.Minute)
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, context.NewExpiryWorker(store, sm, time.Minute), nil, sm, nil, nil, store, []string{"1"})
+	h.SetProjects(map[string]string{"cluster-a": "/srv/a", "cluster-b": "/srv/b"})
+	msg := &messaging.IncomingMessage{ChatID: "1"}
+
+	for _, args := range [][]string{
+		{"/project"},
+		{"/project", "Cluster-A"},
+		{"/project"},
+		{"/project", "cluster-a"},
+		{"/project", "../etc"},
+		{"/project", "default"},
+	} {
+		if err := h.dispatchCommand(msg, args); err != nil {
+			t.Fatalf("dispatchCommand(%v) error = %v", args, err)
+		}
+	}
+
+	texts := platform.sentTexts()
+	want := []string{
+		"`cluster-a`, `cluster-b`",
+		"set to cluster-a for this chat. The session was reset",
+		"cluster-a (set for this chat)",
+		"already works on that project",
+		"Unknown project",
+		"reset to the default.",
+	}
+	if len(texts) != len(want) {
+		t.Fatalf("Expected %d replies, got %d: %v", len(want), len(texts), texts)
+	}
+	for i, w := range want {
+		if !strings.Contains(texts[i], w) {
+			t.Errorf("Reply %d = %q, want it to contain %q", i, texts[i], w)
+		}
+	}
+
+	// Only the first change had a conversation to reset
+	if strings.Contains(texts[5], "session was reset") {
+		t.Errorf("Reply = %q, want no reset without a conversation", texts[5])
+	}
+	if ctx, _ := store.GetContext("1"); ctx.IsActive {
+		t.Errorf("Context = %+v, want the session reset", ctx)
+	}
+}
+
+func TestChatProjectPath_IgnoresProjectsNoLongerConfigured(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "group", "session-1", 2*time.Hour)
+	_ = store.SetChatProject("1", "cluster-a")
+
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	if got := h.chatProjectPath("1"); got != "" {
+		t.Errorf("chatProjectPath() with /project disabled = %q, want empty", got)
+	}
+
+	h.SetProjects(map[string]string{"cluster-a": "/srv/a"})
+	if got := h.chatProjectPath("1"); got != "/srv/a" {
+		t.Errorf("chatProjectPath() = %q, want /srv/a", got)
+	}
+
+	h.projects = map[string]string{"cluster-b": "/srv/b"}
+	if got := h.chatProjectPath("1"); got != "" {
+		t.Errorf("chatProjectPath() for a removed project = %q, want empty", got)
+	}
+}
@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// ReadPool runs read-heavy commands (e.g. /history, /sessions) on a fixed
+// number of workers so a burst of them cannot saturate the database or block
+// the message-handling goroutine. Jobs wait in a bounded queue; when the queue
+// is full, Submit rejects the job instead of blocking.
+type ReadPool struct {
+	jobs      chan func() error
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewReadPool starts workers goroutines with room for queueSize pending jobs.
+func NewReadPool(workers, queueSize int) *ReadPool {
+	p := &ReadPool{
+		jobs: make(chan func() error, queueSize),
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+func (p *ReadPool) worker() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		if err := job(); err != nil {
+			slog.Error("Read pool job failed", "error", err)
+		}
+	}
+}
+
+// Submit queues job for execution. It returns false if the queue is full.
+func (p *ReadPool) Submit(job func() error) bool {
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pending returns the number of jobs waiting for a worker.
+func (p *ReadPool) Pending() int {
+	return len(p.jobs)
+}
+
+// Close stops accepting jobs and waits for queued ones to finish.
+func (p *ReadPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.jobs)
+	})
+	p.wg.Wait()
+}
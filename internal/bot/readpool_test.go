@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestReadPool_BoundsConcurrency(t *testing.T) {
+	const workers = 3
+	p := NewReadPool(workers, 100)
+
+	var running, peak, done atomic.Int32
+	for i := 0; i < 50; i++ {
+		ok := p.Submit(func() error {
+			n := running.Add(1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			running.Add(-1)
+			done.Add(1)
+			return nil
+		})
+		if !ok {
+			t.Fatalf("Submit %d rejected with a roomy queue", i)
+		}
+	}
+	p.Close()
+
+	if done.Load() != 50 {
+		t.Errorf("Expected all 50 jobs to run before Close returns, got %d", done.Load())
+	}
+	if peak.Load() > workers {
+		t.Errorf("Expected at most %d concurrent jobs, saw %d", workers, peak.Load())
+	}
+}
+
+func TestReadPool_RejectsWhenQueueFull(t *testing.T) {
+	p := NewReadPool(1, 1)
+	defer p.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	p.Submit(func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	if !p.Submit(func() error { return nil }) {
+		t.Fatal("Expected second job to be queued")
+	}
+	if p.Submit(func() error { return nil }) {
+		t.Error("Expected third job to be rejected while the queue is full")
+	}
+	close(release)
+}
+
+func TestHandler_ReadPoolBusyReply(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	h.SetAdminIDs([]string{"1"})
+
+	// A pool with no workers never drains, so the queue fills immediately
+	p := NewReadPool(0, 1)
+	h.SetReadPool(p)
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "10"}
+	if err := h.dispatchCommand(msg, []string{"/sessions"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+	if len(platform.sentTexts()) != 0 {
+		t.Errorf("Queued command should not reply synchronously, got %v", platform.sentTexts())
+	}
+
+	if err := h.dispatchCommand(msg, []string{"/sessions"}); err != nil {
+		t.Fatalf("dispatchCommand() error = %v", err)
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 1 || texts[0] != readPoolBusyText {
+		t.Errorf("Expected busy reply, got %v", texts)
+	}
+}
+
+func BenchmarkReadPool(b *testing.B) {
+	p := NewReadPool(4, b.N)
+	var wg sync.WaitGroup
+	wg.Add(b.N)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Submit(func() error {
+			defer wg.Done()
+			return nil
+		})
+	}
+	wg.Wait()
+	p.Close()
+}
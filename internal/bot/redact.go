@@ -0,0 +1,242 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
+)
+
+const (
+	// maxRedactionTerms bounds how many terms one chat can add with /redact.
+	maxRedactionTerms = 50
+	// minRedactionTermLen keeps /redact from blanking out whole answers with
+	// one- or two-letter terms.
+	minRedactionTermLen = 3
+	// maxRedactionTermLen is the longest term /redact accepts, in characters.
+	maxRedactionTermLen = 100
+)
+
+// SetQueryRedaction sends questions to Claude with secrets redacted. They are
+// stored redacted either way.
+func (h *Handler) SetQueryRedaction(enabled bool) {
+	h.redactQueries = enabled
+}
+
+// SetChatRedactionsEnabled registers the /redact command and removes each
+// chat's stored terms from its answers, on top of the global patterns.
+func (h *Handler) SetChatRedactionsEnabled(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/redact"); exists {
+		return
+	}
+	h.chatRedactions = true
+	h.commands.Register(CommandHandler{
+		Name:        "/redact",
+		Description: "Hide extra terms from answers in this chat (/redact add <term>, /redact remove <term>)",
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleRedactCommand(msg.ChatID, fields, commandArgText(commandArgText(msg.Text)), msg.MessageID)
+		},
+	})
+}
+
+// handleRedactCommand handles /redact [add|remove <term>]. Without an action
+// it lists the chat's terms. term is the text after the action so terms may
+// contain spaces.
+func (h *Handler) handleRedactCommand(chatID string, fields []string, term, replyToMessageID string) error {
+	slog.Info("Processing /redact command", "chat_id", chatID, "args_count", len(fields))
+
+	const usage = "Usage: `/redact add <term>` or `/redact remove <term>`"
+	if len(fields) < 2 {
+		terms, err := h.storage.GetRedactionTerms(chatID)
+		if err != nil {
+			slog.Error("Failed to get redaction terms", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to retrieve redaction terms.", replyToMessageID)
+		}
+		if len(terms) == 0 {
+			return h.sendText(chatID, "🙈 No extra redaction terms for this chat.\n\n"+usage, replyToMessageID)
+		}
+		var b strings.Builder
+		b.WriteString("🙈 *Redacted in this chat:*\n")
+		for _, t := range terms {
+			b.WriteString(fmt.Sprintf("• `%s`\n", t))
+		}
+		b.WriteString("\n" + usage)
+		return h.sendText(chatID, b.String(), replyToMessageID)
+	}
+
+	if term == "" {
+		return h.sendText(chatID, usage, replyToMessageID)
+	}
+
+	switch strings.ToLower(fields[1]) {
+	case "add":
+		if n := utf8.RuneCountInString(term); n < minRedactionTermLen || n > maxRedactionTermLen {
+			return h.sendText(chatID, fmt.Sprintf("❌ Terms must be %d to %d characters long.", minRedactionTermLen, maxRedactionTermLen), replyToMessageID)
+		}
+		terms, err := h.storage.GetRedactionTerms(chatID)
+		if err != nil {
+			slog.Error("Failed to get redaction terms", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to retrieve redaction terms.", replyToMessageID)
+		}
+		if len(terms) >= maxRedactionTerms {
+			return h.sendText(chatID, fmt.Sprintf("❌ This chat already has %d redaction terms. Remove one first.", maxRedactionTerms), replyToMessageID)
+		}
+		if err := h.storage.AddRedactionTerm(chatID, term); err != nil {
+			slog.Error("Failed to add redaction term", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to save redaction term.", replyToMessageID)
+		}
+		return h.sendText(chatID, "✅ Term added. It will be redacted from answers in this chat.", replyToMessageID)
+	case "remove":
+		removed, err := h.storage.RemoveRedactionTerm(chatID, term)
+		if err != nil {
+			slog.Error("Failed to remove redaction term", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to remove redaction term.", replyToMessageID)
+		}
+		if !removed {
+			return h.sendText(chatID, "❌ That term isn't redacted in this chat.", replyToMessageID)
+		}
+		return h.sendText(chatID, "✅ Term removed.", replyToMessageID)
+	}
+	return h.sendText(chatID, usage, replyToMessageID)
+}
+
+// sanitize redacts secrets matching the global patterns and, when enabled,
+// the chat's own redaction terms.
+func (h *Handler) sanitize(chatID, text string) string {
+	if !h.chatRedactions {
+		return h.sanitizer.Sanitize(text)
+	}
+	terms, err := h.storage.GetRedactionTerms(chatID)
+	if err != nil {
+		slog.Warn("Failed to load redaction terms", "chat_id", chatID, "error", err)
+	}
+	return h.sanitizer.SanitizeWithTerms(text, terms)
+}
+
+// batchSanitizer returns a redaction function like sanitize for redacting
+// many texts, possibly from many chats, loading each chat's terms once.
+func (h *Handler) batchSanitizer() func(chatID, text string) string {
+	terms := make(map[string][]string)
+	return func(chatID, text string) string {
+		if text == "" {
+			return ""
+		}
+		if !h.chatRedactions {
+			return h.sanitizer.Sanitize(text)
+		}
+		chatTerms, ok := terms[chatID]
+		if !ok {
+			var err error
+			if chatTerms, err = h.storage.GetRedactionTerms(chatID); err != nil {
+				slog.Warn("Failed to load redaction terms", "chat_id", chatID, "error", err)
+			}
+			terms[chatID] = chatTerms
+		}
+		return h.sanitizer.SanitizeWithTerms(text, chatTerms)
+	}
+}
+
+// SetHeavyRedactionCheck flags answers of which redaction removes at least
+// threshold (0-1) and registers the admin /unredact command. Such answers are
+// logged and kept unredacted for /unredact; with notify the user is told too.
+// A threshold of 0 disables the check.
+func (h *Handler) SetHeavyRedactionCheck(threshold float64, notify bool) {
+	if threshold <= 0 {
+		return
+	}
+	h.redactionThreshold = threshold
+	h.redactionNotify = notify
+	if _, exists := h.commands.Lookup("/unredact"); exists {
+		return
+	}
+	h.commands.Register(CommandHandler{
+		Name:        "/unredact",
+		Description: "Show a heavily redacted answer as Claude gave it, in a private chat (/unredact <id>)",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleUnredactCommand(msg, fields)
+		},
+	})
+}
+
+// checkRedaction keeps original, the answer before redaction, when redaction
+// removes at least the configured share of it. It returns a notice for the
+// user, or "" when there is nothing to tell them.
+func (h *Handler) checkRedaction(chatID, sessionID, original string) string {
+	if h.redactionThreshold <= 0 {
+		return ""
+	}
+	var terms []string
+	if h.chatRedactions {
+		var err error
+		if terms, err = h.storage.GetRedactionTerms(chatID); err != nil {
+			slog.Warn("Failed to load redaction terms", "chat_id", chatID, "error", err)
+		}
+	}
+	fraction := h.sanitizer.RedactedFraction(original, terms)
+	if fraction < h.redactionThreshold {
+		return ""
+	}
+
+	metrics.HeavyRedactions.Inc()
+	id, err := h.storage.SaveRedactedAnswer(chatID, sessionID, original, fraction)
+	if err != nil {
+		slog.Error("Failed to save heavily redacted answer", "chat_id", chatID, "error", err)
+	}
+	slog.Warn("Answer heavily redacted", "chat_id", chatID, "session_id", sessionID,
+		"redacted_percent", int(fraction*100), "redacted_answer_id", id)
+
+	if !h.redactionNotify {
+		return ""
+	}
+	notice := fmt.Sprintf("⚠️ %d%% of this answer was redacted as possible secrets.", int(fraction*100))
+	if id > 0 {
+		notice += fmt.Sprintf(" An admin can review it with `/unredact %d`.", id)
+	}
+	return notice + "\n\n"
+}
+
+// handleUnredactCommand handles /unredact <id>, sending the original answer
+// as a file. It only works in private chats so the answer can't leak into a
+// group.
+func (h *Handler) handleUnredactCommand(msg *messaging.IncomingMessage, fields []string) error {
+	chatID, replyTo := msg.ChatID, msg.MessageID
+	slog.Info("Processing /unredact command", "chat_id", chatID, "user_id", msg.From.ID, "args", fields)
+
+	if msg.ChatType != messaging.ChatTypePrivate {
+		return h.sendText(chatID, "🔒 Unredacted answers are only shown in a private chat with the bot.", replyTo)
+	}
+	if len(fields) < 2 {
+		return h.sendText(chatID, "Usage: `/unredact <id>`, with the ID from a heavy redaction notice or log.", replyTo)
+	}
+	id, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || id <= 0 {
+		return h.sendText(chatID, "❌ Invalid ID.\n\nUsage: `/unredact <id>`", replyTo)
+	}
+
+	answer, err := h.storage.GetRedactedAnswer(id)
+	if err != nil {
+		slog.Error("Failed to get redacted answer", "id", id, "error", err)
+		return h.sendError(chatID, "Failed to retrieve the answer.", replyTo)
+	}
+	if answer == nil {
+		return h.sendText(chatID, fmt.Sprintf("❌ No redacted answer #%d.", id), replyTo)
+	}
+
+	slog.Warn("Admin viewed unredacted answer", "id", id, "answer_chat_id", answer.ChatID, "user_id", msg.From.ID)
+	caption := fmt.Sprintf("🔓 Answer #%d in chat %s (%s), %d%% redacted. Contains possible secrets.",
+		answer.ID, answer.ChatID, answer.CreatedAt.Format("2006-01-02 15:04:05"), int(answer.Fraction*100))
+	if _, err := h.platform.SendDocument(chatID, fmt.Sprintf("unredacted-%d.txt", answer.ID), []byte(answer.Original), caption); err != nil {
+		slog.Error("Failed to send unredacted answer", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to upload the answer.", replyTo)
+	}
+	return nil
+}
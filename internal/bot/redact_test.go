@@ -0,0 +1,195 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestRedactCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+	h.SetChatRedactionsEnabled(true)
+
+	for _, text := range []string{
+		"/redact",
+		"/redact add Project Falcon",
+		"/redact add ab",
+		"/redact",
+		"/redact remove Project Falcon",
+		"/redact remove Project Falcon",
+		"/redact add",
+	} {
+		msg := &messaging.IncomingMessage{ChatID: "1", Text: text}
+		if err := h.dispatchCommand(msg, strings.Fields(text)); err != nil {
+			t.Fatalf("dispatchCommand(%q) error = %v", text, err)
+		}
+	}
+
+	texts := platform.sentTexts()
+	want := []string{
+		"No extra redaction terms",
+		"Term added",
+		"3 to 100 characters",
+		"`Project Falcon`",
+		"Term removed",
+		"isn't redacted",
+		"Usage:",
+	}
+	if len(texts) != len(want) {
+		t.Fatalf("Expected %d replies, got %d: %v", len(want), len(texts), texts)
+	}
+	for i, w := range want {
+		if !strings.Contains(texts[i], w) {
+			t.Errorf("Reply %d = %q, want it to contain %q", i, texts[i], w)
+		}
+	}
+}
+
+func TestChatRedactionTermsAppliedToResponses(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer(security.DefaultPatterns)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1", "2"})
+	h.SetSandboxChatIDs([]string{"1", "2"})
+	h.SetChatRedactionsEnabled(true)
+	_ = store.AddRedactionTerm("1", "falcon")
+
+	query := "deploy FALCON with token=abc123"
+	_ = h.HandleMessage(&messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, Text: query})
+	_ = h.HandleMessage(&messaging.IncomingMessage{ChatID: "2", MessageID: "6", ChatType: messaging.ChatTypePrivate, Text: query})
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 responses, got %v", texts)
+	}
+	if strings.Contains(texts[0], "FALCON") || strings.Contains(texts[0], "abc123") {
+		t.Errorf("Chat term and global pattern should both be redacted, got %q", texts[0])
+	}
+	if !strings.Contains(texts[1], "FALCON") || strings.Contains(texts[1], "abc123") {
+		t.Errorf("Other chats should only get global redaction, got %q", texts[1])
+	}
+}
+
+func TestQueriesRedactedBeforeStoring(t *testing.T) {
+	for _, redactQueries := range []bool{false, true} {
+		store, cleanup := setupTestStorage(t)
+		defer cleanup()
+
+		// The fake CLI records the arguments it was run with, prompt included
+		dir := t.TempDir()
+		argsFile := filepath.Join(dir, "args")
+		cli := filepath.Join(dir, "claude")
+		if err := os.WriteFile(cli, []byte("#!/bin/sh\necho \"$*\" > "+argsFile+"\necho ok\n"), 0o755); err != nil {
+			t.Fatalf("Failed to write fake CLI: %v", err)
+		}
+		sm := claude.NewSessionManager(cli, t.TempDir(), "", 2, 10*time.Second)
+		sanitizer, err := security.NewSanitizer(security.DefaultPatterns)
+		if err != nil {
+			t.Fatalf("NewSanitizer() error = %v", err)
+		}
+
+		h := NewHandler(newFakePlatform(), context.NewManager(store, sm, time.Hour), nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1"})
+		h.SetQueryRedaction(redactQueries)
+
+		msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: "why does token=abc123 fail?"}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage() error = %v", err)
+		}
+
+		messages, err := store.GetRecentMessages("1", 10)
+		if err != nil || len(messages) != 2 {
+			t.Fatalf("GetRecentMessages() = %v, %v; want question and answer", messages, err)
+		}
+		for _, m := range messages {
+			if m.Role == "user" && (strings.Contains(m.Content, "abc123") || !strings.Contains(m.Content, "REDACTED")) {
+				t.Errorf("redactQueries=%v: stored question should be redacted, got %q", redactQueries, m.Content)
+			}
+		}
+
+		args, err := os.ReadFile(argsFile)
+		if err != nil {
+			t.Fatalf("Fake CLI was not run: %v", err)
+		}
+		if got := strings.Contains(string(args), "abc123"); got == redactQueries {
+			t.Errorf("redactQueries=%v: Claude got %q", redactQueries, args)
+		}
+	}
+}
+
+func TestHeavyRedaction(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	// The fake CLI answers with little more than a long hash, which the
+	// example config's base64 pattern matches
+	hash := strings.Repeat("a1b2c3d4e5", 6)
+	cli := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho 'digest: "+hash+"'\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := claude.NewSessionManager(cli, t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer([]string{`token[s]?\s*[:=]\s*["']?([^"'\s]+)`, `[A-Za-z0-9+/]{40,}={0,2}`})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, sm, time.Hour), nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1", "admin"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetHeavyRedactionCheck(0.5, true)
+
+	before := testutil.ToFloat64(metrics.HeavyRedactions)
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: "show the image digest for the api deployment"}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+	if got := testutil.ToFloat64(metrics.HeavyRedactions) - before; got != 1 {
+		t.Errorf("HeavyRedactions increased by %v, want 1", got)
+	}
+	texts := platform.sentTexts()
+	answer := texts[len(texts)-1]
+	if !strings.Contains(answer, "% of this answer was redacted") || !strings.Contains(answer, "/unredact 1") || strings.Contains(answer, hash) {
+		t.Errorf("Answer = %q, want a redacted answer with a heavy redaction notice", answer)
+	}
+
+	// Admins can read the original only in a private chat
+	unredact := &messaging.IncomingMessage{ChatID: "admin", MessageID: "6", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "admin"}, Text: "/unredact 1"}
+	if err := h.HandleMessage(unredact); err != nil {
+		t.Fatalf("HandleMessage(/unredact) error = %v", err)
+	}
+	if len(platform.documents) != 0 {
+		t.Fatalf("Unredacted answer sent to a group: %+v", platform.documents)
+	}
+	unredact.ChatType = messaging.ChatTypePrivate
+	unredact.MessageID = "7"
+	if err := h.HandleMessage(unredact); err != nil {
+		t.Fatalf("HandleMessage(/unredact) error = %v", err)
+	}
+	if len(platform.documents) != 1 || !strings.Contains(string(platform.documents[0].Content), hash) {
+		t.Errorf("Documents = %+v, want the unredacted answer", platform.documents)
+	}
+
+	// Lightly redacted answers pass silently
+	if notice := h.checkRedaction("1", "session", "the token=abc is set, and the rest of this answer is long enough to stay readable"); notice != "" {
+		t.Errorf("checkRedaction() = %q, want no notice below the threshold", notice)
+	}
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+// SetRedactionStatsEnabled registers the admin /redactions command, which
+// shows how often each secret pattern has fired.
+func (h *Handler) SetRedactionStatsEnabled(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/redactions"); exists {
+		return
+	}
+	h.commands.Register(CommandHandler{
+		Name:        "/redactions",
+		Description: "Show which secret patterns redacted output since startup",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleRedactionsCommand(msg.ChatID, msg.MessageID)
+		},
+	})
+}
+
+func (h *Handler) handleRedactionsCommand(chatID, replyToMessageID string) error {
+	slog.Info("Processing /redactions command", "chat_id", chatID)
+	_, err := h.sendChunks(chatID, formatRedactionStats(h.sanitizer.HitCounts()), replyToMessageID, false)
+	return err
+}
+
+// formatRedactionStats lists the patterns that fired, most frequent first.
+// Patterns that never matched are left out.
+func formatRedactionStats(counts []security.PatternHits) string {
+	var total int64
+	var b strings.Builder
+	for _, c := range counts {
+		if c.Hits == 0 {
+			continue
+		}
+		total += c.Hits
+		b.WriteString(fmt.Sprintf("• %d × `%s`\n", c.Hits, c.Pattern))
+	}
+	if total == 0 {
+		return "🔐 No secrets redacted since the bot started."
+	}
+	return fmt.Sprintf("🔐 *Redactions since startup:* %d\n\n%s", total, b.String())
+}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestRedactionsCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sanitizer, err := security.NewSanitizer([]string{`token=\S+`, `password=\S+`})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, sanitizer, store, []string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetRedactionStatsEnabled(true)
+
+	admin := &messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}}
+	_ = h.dispatchCommand(admin, []string{"/redactions"})
+
+	sanitizer.Sanitize("password=a token=b token=c")
+	_ = h.dispatchCommand(admin, []string{"/redactions"})
+
+	user := &messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "user"}}
+	_ = h.dispatchCommand(user, []string{"/redactions"})
+
+	texts := platform.sentTexts()
+	if len(texts) != 3 {
+		t.Fatalf("Expected 3 replies, got %d: %v", len(texts), texts)
+	}
+	if !strings.Contains(texts[0], "No secrets redacted") {
+		t.Errorf("Reply before any redaction = %q", texts[0])
+	}
+	if !strings.Contains(texts[1], "since startup:* 3") {
+		t.Errorf("Reply should report 3 redactions, got %q", texts[1])
+	}
+	if strings.Index(texts[1], "token") > strings.Index(texts[1], "password") {
+		t.Errorf("Most frequent pattern should be listed first, got %q", texts[1])
+	}
+	if strings.Contains(texts[2], "Redactions") {
+		t.Errorf("/redactions should be admin-only, got %q", texts[2])
+	}
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// SetRawOutputRecording stores the raw Claude CLI output behind every answer
+// and registers the admin /replay command. The session manager must record
+// raw output too (claude.SessionManager.SetRecordRawOutput).
+func (h *Handler) SetRawOutputRecording(enabled bool) {
+	if !enabled {
+		return
+	}
+	h.recordRaw = true
+	if _, exists := h.commands.Lookup("/replay"); exists {
+		return
+	}
+	h.commands.Register(CommandHandler{
+		Name:        "/replay",
+		Description: "Re-render a recorded answer without asking Claude again",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleReplayCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+}
+
+// saveRawOutput records response's raw output when recording is enabled.
+// query must already be redacted.
+func (h *Handler) saveRawOutput(chatID, sessionID, query string, response *claude.ClaudeJSONOutput) {
+	if !h.recordRaw || response.Raw == "" {
+		return
+	}
+	if err := h.storage.SaveRawOutput(chatID, sessionID, query, response.Raw, response.Canary); err != nil {
+		slog.Warn("Failed to save raw output", "chat_id", chatID, "error", err)
+	}
+}
+
+// handleReplayCommand handles /replay [id]. Without an ID it replays the
+// chat's latest recorded answer.
+func (h *Handler) handleReplayCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /replay command", "chat_id", chatID, "args", fields)
+
+	var id int64
+	if len(fields) >= 2 {
+		parsed, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || parsed <= 0 {
+			return h.sendText(chatID, "❌ Invalid recording ID.\n\nUsage: `/replay [id]`", replyToMessageID)
+		}
+		id = parsed
+	}
+
+	rec, err := h.storage.GetRawOutput(chatID, id)
+	if err != nil {
+		slog.Error("Failed to get raw output", "chat_id", chatID, "id", id, "error", err)
+		return h.sendError(chatID, "Failed to retrieve recording.", replyToMessageID)
+	}
+	if rec == nil {
+		return h.sendText(chatID, "❌ No recorded answer found in this chat.", replyToMessageID)
+	}
+
+	answer, err := h.renderRecordedAnswer(rec)
+	if err != nil {
+		slog.Error("Failed to parse raw output", "chat_id", chatID, "id", rec.ID, "error", err)
+		return h.sendError(chatID, fmt.Sprintf("Recording #%d could not be parsed.", rec.ID), replyToMessageID)
+	}
+
+	header := fmt.Sprintf("🔁 Replay #%d (%s)\n\n", rec.ID, rec.CreatedAt.Format("2006-01-02 15:04:05"))
+	_, err = h.sendChunks(chatID, header+answer, replyToMessageID, false)
+	return err
+}
+
+// renderRecordedAnswer rebuilds the answer the user was sent from its raw
+// output, redacted with the chat's current patterns. Display preferences are
+// not applied.
+func (h *Handler) renderRecordedAnswer(rec *storage.RawOutput) (string, error) {
+	response, err := claude.ParseOutput(rec.Raw)
+	if err != nil {
+		return "", err
+	}
+	response.Canary = rec.Canary
+
+	warnings, _, _ := h.toolWarnings(response.Tools)
+	answer := warnings + withCanaryLabel(h.sanitize(rec.ChatID, response.Result), response)
+	return h.withEnvLabel(answer, false), nil
+}
@@ -0,0 +1,101 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+func TestReplay_RendersRecordedAnswerAsSent(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+echo '{"type":"system","subtype":"init","session_id":"claude-1"}'
+echo '{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"kubectl get pods"}}]}}'
+echo '{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"kubectl get pods"}}]}}'
+echo '{"type":"result","subtype":"success","result":"Pods are fine, password=hunter2","session_id":"claude-1"}'
+`
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+
+	sm := claude.NewSessionManager(cli, t.TempDir(), "", 2, 10*time.Second)
+	sm.SetRecordRawOutput(true)
+	sanitizer, err := security.NewSanitizer([]string{`password=(\S+)`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, sm, time.Hour), nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetToolLoopThreshold(2)
+	h.SetRawOutputRecording(true)
+
+	query := &messaging.IncomingMessage{ChatID: "1", MessageID: "10", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "admin"}, Text: "are pods ok? password=s3cret"}
+	if err := h.HandleMessage(query); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 1 || strings.Contains(texts[0], "hunter2") {
+		t.Fatalf("Expected one sanitized answer, got %q", texts)
+	}
+	original := texts[0]
+	if !strings.Contains(original, "Possible tool loop") {
+		t.Fatalf("Expected the answer to carry a tool loop warning, got %q", original)
+	}
+
+	rec, err := store.GetRawOutput("1", 0)
+	if err != nil || rec == nil {
+		t.Fatalf("GetRawOutput() = %v, %v; want the recorded output", rec, err)
+	}
+	if !strings.Contains(rec.Raw, "hunter2") || !strings.HasPrefix(rec.Query, "are pods ok?") || strings.Contains(rec.Query, "s3cret") {
+		t.Errorf("Recording should hold the raw output and redacted query, got %+v", rec)
+	}
+
+	replay := &messaging.IncomingMessage{ChatID: "1", MessageID: "11", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "admin"}, Text: "/replay"}
+	if err := h.HandleMessage(replay); err != nil {
+		t.Fatalf("HandleMessage(/replay) error = %v", err)
+	}
+	texts = platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected a replay message, got %q", texts)
+	}
+	header, body, _ := strings.Cut(texts[1], "\n\n")
+	if !strings.HasPrefix(header, "🔁 Replay #1") {
+		t.Errorf("Replay header = %q", header)
+	}
+	if body != original {
+		t.Errorf("Replayed answer = %q, want what was sent: %q", body, original)
+	}
+}
+
+func TestReplay_NoRecording(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetRawOutputRecording(true)
+
+	for _, text := range []string{"/replay", "/replay 42", "/replay abc"} {
+		msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "admin"}, Text: text}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q) error = %v", text, err)
+		}
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 3 || !strings.Contains(texts[0], "No recorded answer") || !strings.Contains(texts[1], "No recorded answer") || !strings.Contains(texts[2], "Invalid recording ID") {
+		t.Errorf("Unexpected replies: %q", texts)
+	}
+}
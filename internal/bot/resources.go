@@ -0,0 +1,87 @@
+package bot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rg/aiops/internal/claude"
+)
+
+// maxOverviewUnhealthy bounds how many unhealthy resources the overview names
+// per resource type.
+const maxOverviewUnhealthy = 5
+
+// SetResourceSummary appends a compact overview of the Kubernetes resources
+// listed in each answer, such as kubectl get output, below it.
+func (h *Handler) SetResourceSummary(e *claude.ResourceExtractor) {
+	h.resources = e
+}
+
+// resourceOverview returns the overview appended to answer, or "" when the
+// summary is disabled or answer lists no resources.
+func (h *Handler) resourceOverview(answer string) string {
+	if h.resources == nil {
+		return ""
+	}
+	tables := h.resources.Extract(answer)
+	if len(tables) == 0 {
+		return ""
+	}
+	return "\n\n" + formatResourceOverview(tables)
+}
+
+// formatResourceOverview lists each resource type with its count, status
+// counts and the resources whose status needs attention.
+func formatResourceOverview(tables []claude.ResourceTable) string {
+	var b strings.Builder
+	b.WriteString("📋 Kubernetes overview:")
+	for _, table := range tables {
+		counts := make(map[string]int)
+		var unhealthy []string
+		for _, r := range table.Resources {
+			if r.Status != "" {
+				counts[r.Status]++
+			}
+			if !r.Healthy() {
+				name := r.Name
+				if r.Namespace != "" {
+					name = r.Namespace + "/" + r.Name
+				}
+				unhealthy = append(unhealthy, fmt.Sprintf("%s (%s)", name, r.Status))
+			}
+		}
+
+		b.WriteString(fmt.Sprintf("\n• %s: %d", table.Type, len(table.Resources)))
+		if len(counts) > 0 {
+			b.WriteString(" (" + formatStatusCounts(counts) + ")")
+		}
+		if len(unhealthy) > maxOverviewUnhealthy {
+			unhealthy = append(unhealthy[:maxOverviewUnhealthy], fmt.Sprintf("… %d more", len(unhealthy)-maxOverviewUnhealthy))
+		}
+		if len(unhealthy) > 0 {
+			b.WriteString("\n  ⚠️ " + strings.Join(unhealthy, ", "))
+		}
+	}
+	return b.String()
+}
+
+// formatStatusCounts lists statuses by descending count, then by name.
+func formatStatusCounts(counts map[string]int) string {
+	statuses := make([]string, 0, len(counts))
+	for s := range counts {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if counts[statuses[i]] != counts[statuses[j]] {
+			return counts[statuses[i]] > counts[statuses[j]]
+		}
+		return statuses[i] < statuses[j]
+	})
+
+	parts := make([]string, len(statuses))
+	for i, s := range statuses {
+		parts[i] = fmt.Sprintf("%d %s", counts[s], s)
+	}
+	return strings.Join(parts, ", ")
+}
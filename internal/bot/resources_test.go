@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rg/aiops/internal/claude"
+)
+
+func TestResourceOverview(t *testing.T) {
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, nil, nil, nil)
+	answer := "```\n" +
+		"NAME                     READY   STATUS             RESTARTS   AGE\n" +
+		"api-7d9f8b-abcde         1/1     Running            0          2d\n" +
+		"api-7d9f8b-fghij         1/1     Running            0          2d\n" +
+		"worker-5c6d7-xyz12       0/1     CrashLoopBackOff   5          1h\n" +
+		"```"
+
+	if got := h.resourceOverview(answer); got != "" {
+		t.Errorf("Overview should be empty when disabled, got %q", got)
+	}
+
+	extractor, err := claude.NewResourceExtractor(nil)
+	if err != nil {
+		t.Fatalf("NewResourceExtractor() error = %v", err)
+	}
+	h.SetResourceSummary(extractor)
+
+	want := "\n\n📋 Kubernetes overview:\n" +
+		"• pods: 3 (2 Running, 1 CrashLoopBackOff)\n" +
+		"  ⚠️ worker-5c6d7-xyz12 (CrashLoopBackOff)"
+	if got := h.resourceOverview(answer); got != want {
+		t.Errorf("resourceOverview() = %q, want %q", got, want)
+	}
+	if got := h.resourceOverview("All pods look healthy."); got != "" {
+		t.Errorf("Overview should be empty without listings, got %q", got)
+	}
+}
+
+func TestFormatResourceOverview_CapsUnhealthy(t *testing.T) {
+	table := claude.ResourceTable{Type: "pods"}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		table.Resources = append(table.Resources, claude.Resource{Namespace: "ns", Name: name, Status: "Pending"})
+	}
+	table.Resources = append(table.Resources, claude.Resource{Name: "svc"})
+
+	got := formatResourceOverview([]claude.ResourceTable{table})
+	if !strings.Contains(got, "• pods: 8 (7 Pending)") {
+		t.Errorf("Expected count and status breakdown, got %q", got)
+	}
+	if !strings.Contains(got, "ns/e (Pending), … 2 more") || strings.Contains(got, "ns/f") {
+		t.Errorf("Expected 5 unhealthy resources named, got %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// SetSandboxChatIDs marks chats whose queries always run in a fresh Claude
+// session. Nothing is stored for them and no context is created or resumed.
+func (h *Handler) SetSandboxChatIDs(chatIDs []string) {
+	if len(chatIDs) == 0 {
+		return
+	}
+	h.sandboxChatIDs = make(map[string]bool, len(chatIDs))
+	for _, id := range chatIDs {
+		h.sandboxChatIDs[id] = true
+	}
+}
+
+// isSandbox reports whether chatID is a sandbox chat.
+func (h *Handler) isSandbox(chatID string) bool {
+	return h.sandboxChatIDs[chatID]
+}
+
+// handleSandboxQuery answers msg without touching storage or the chat's context.
+func (h *Handler) handleSandboxQuery(msg *messaging.IncomingMessage) error {
+	h.react(msg, h.reactions.Processing)
+
+	if err := h.platform.SendTyping(msg.ChatID); err != nil {
+		slog.Warn("Failed to send typing indicator", "chat_id", msg.ChatID, "error", err)
+	}
+
+	response, err := h.executeStandalone(msg.ChatID, msg.Text)
+	if err != nil {
+		slog.Error("Sandbox execution error", "chat_id", msg.ChatID, "error", err)
+		h.react(msg, h.reactions.Error)
+		sendErr := h.sendText(msg.ChatID, executionErrorText(err), msg.MessageID)
+		h.recordQueryFailure(msg.ChatID, err)
+		return sendErr
+	}
+	h.recordQuerySuccess(msg.ChatID)
+
+	sanitized := withCanaryLabel(h.sanitize(msg.ChatID, response.Result), response)
+	if _, err := h.sendChunks(msg.ChatID, h.withEnvLabel(sanitized, false), msg.MessageID, false); err != nil {
+		return err
+	}
+	h.react(msg, h.reactions.Success)
+	return nil
+}
+
+// executeStandalone runs query in a throwaway Claude session that is never
+// resumed and is removed once the query finishes.
+func (h *Handler) executeStandalone(chatID, query string) (*claude.ClaudeJSONOutput, error) {
+	sessionID := "sandbox-" + uuid.New().String()
+	if _, err := h.sessionManager.GetOrCreateSession(chatID, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to create sandbox session: %w", err)
+	}
+	defer func() {
+		if err := h.sessionManager.KillSession(sessionID); err != nil {
+			slog.Debug("Failed to remove sandbox session", "session_id", sessionID, "error", err)
+		}
+	}()
+
+	return h.executor.Execute(sessionID, query, "", claude.QueryOptions{})
+}
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+// fakeClaudeCLI writes a script that echoes its arguments back as the response.
+func fakeClaudeCLI(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho \"args: $*\"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	return path
+}
+
+func TestSandboxChat_FreshSessionNothingStored(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	executor := claude.NewExecutor(sm, "", 0)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, executor, sanitizer, store, []string{"1"})
+	h.SetSandboxChatIDs([]string{"1"})
+
+	for _, text := range []string{"first question", "second question"} {
+		msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, Text: text}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q) error = %v", text, err)
+		}
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 responses, got %v", texts)
+	}
+	for i, want := range []string{"first question", "second question"} {
+		if !strings.Contains(texts[i], want) {
+			t.Errorf("Response %d = %q, want it to contain %q", i, texts[i], want)
+		}
+		if strings.Contains(texts[i], "--resume") {
+			t.Errorf("Sandbox query should never resume a session, got %q", texts[i])
+		}
+	}
+
+	if ctx, _ := store.GetContext("1"); ctx != nil {
+		t.Errorf("Expected no context for sandbox chat, got %+v", ctx)
+	}
+	if count, _ := store.GetMessageCount("1"); count != 0 {
+		t.Errorf("Expected no stored messages, got %d", count)
+	}
+	if n := sm.GetActiveSessionCount(); n != 0 {
+		t.Errorf("Expected sandbox sessions to be removed, got %d active", n)
+	}
+}
+
+func TestMiddleware_RateLimitExempt(t *testing.T) {
+	m := NewMiddleware(1, time.Minute, nil)
+	defer m.Stop()
+	m.SetRateLimitExempt([]string{"sandbox"})
+
+	calls := 0
+	wrapped := m.RateLimit(func(msg *messaging.IncomingMessage) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		wrapped(&messaging.IncomingMessage{ChatID: "sandbox"})
+	}
+	if calls != 3 {
+		t.Errorf("Expected exempt chat to bypass rate limit, got %d calls", calls)
+	}
+}
+
+func TestSandboxChat_WorkspaceUnavailable(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	project := filepath.Join(t.TempDir(), "missing")
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), project, "", 2, 10*time.Second)
+	executor := claude.NewExecutor(sm, "", 0)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, executor, sanitizer, store, []string{"1"})
+	h.SetSandboxChatIDs([]string{"1"})
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, Text: "status?"}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "Project workspace unavailable") {
+		t.Errorf("Expected a workspace error, got %v", texts)
+	}
+}
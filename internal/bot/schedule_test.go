@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Matches(t *testing.T) {
+	tests := []struct {
+		expr string
+		at   string
+		want bool
+	}{
+		{"0 2 * * 0", "2024-06-02 02:00", true}, // Sunday
+		{"0 2 * * 7", "2024-06-02 02:00", true}, // 7 is Sunday too
+		{"0 2 * * 0", "2024-06-03 02:00", false},
+		{"0 2 * * 0", "2024-06-02 02:01", false},
+		{"*/15 * * * *", "2024-06-03 10:45", true},
+		{"*/15 * * * *", "2024-06-03 10:50", false},
+		{"30 1-3 * * 1-5", "2024-06-03 03:30", true},
+		{"30 1-3 * * 1-5", "2024-06-03 04:30", false},
+		{"0 0 1,15 * *", "2024-06-15 00:00", true},
+		{"0 0 1 * 0", "2024-06-02 00:00", true}, // Either day field matches
+		{"0 0 1 * 0", "2024-06-01 00:00", true},
+		{"0 0 1 * 0", "2024-06-03 00:00", false},
+		{"5/20 * * 6 *", "2024-06-03 10:45", true},
+		{"5/20 * * 6 *", "2024-07-01 10:45", false},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) error = %v", tt.expr, err)
+		}
+		at, _ := time.Parse("2006-01-02 15:04", tt.at)
+		if got := s.Matches(at); got != tt.want {
+			t.Errorf("%q.Matches(%s) = %v, want %v", tt.expr, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "0 2 * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("ParseSchedule(%q) should fail", expr)
+		}
+	}
+}
+
+func TestSchedule_LastBefore(t *testing.T) {
+	s, _ := ParseSchedule("0 2 * * *")
+	at := time.Date(2024, 6, 3, 3, 30, 15, 0, time.UTC)
+
+	start, ok := s.LastBefore(at, 2*time.Hour)
+	if !ok || !start.Equal(time.Date(2024, 6, 3, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("LastBefore() = %v, %v; want 02:00", start, ok)
+	}
+	if _, ok := s.LastBefore(at, time.Hour); ok {
+		t.Error("LastBefore() should find nothing within the last hour")
+	}
+}
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/rg/aiops/internal/storage"
+)
+
+const (
+	// maxSearchResults bounds how many matches /search shows.
+	maxSearchResults = 20
+	// searchSnippetContext is how many characters around a match are shown.
+	searchSnippetContext = 80
+)
+
+// handleSearchCommand handles /search <term>, listing the current session's
+// messages that mention term, most recent first. /search all <term> searches
+// every session of the chat, best matches first.
+func (h *Handler) handleSearchCommand(chatID string, fields []string, replyToMessageID string) error {
+	all := len(fields) >= 2 && strings.EqualFold(fields[1], "all")
+	if all {
+		fields = fields[1:]
+	}
+	term := strings.TrimSpace(strings.Join(fields[1:], " "))
+	slog.Info("Processing /search command", "chat_id", chatID, "term", term, "all", all)
+
+	if term == "" {
+		return h.sendText(chatID, "Usage: `/search [all] <text>`\n\nExample: `/search payment service`", replyToMessageID)
+	}
+	if all {
+		return h.handleSearchAll(chatID, term, replyToMessageID)
+	}
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /search", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to search conversation history.", replyToMessageID)
+	}
+	if ctx == nil || !ctx.IsActive {
+		return h.sendText(chatID, "📜 No active session. Start chatting to build history!", replyToMessageID)
+	}
+
+	messages, err := h.storage.SearchMessages(chatID, ctx.SessionID, term, maxSearchResults)
+	if err != nil {
+		slog.Error("Failed to search messages", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to search messages.", replyToMessageID)
+	}
+	if len(messages) == 0 {
+		return h.sendText(chatID, fmt.Sprintf("🔍 No messages in this session mention %q.", term), replyToMessageID)
+	}
+
+	_, err = h.sendChunks(chatID, formatSearchResults(term, messages), replyToMessageID, true)
+	return err
+}
+
+// handleSearchAll lists messages from every session of the chat that match
+// term, ranked by the full-text index when it is available.
+func (h *Handler) handleSearchAll(chatID, term, replyToMessageID string) error {
+	messages, err := h.storage.SearchMessagesFTS(chatID, term, maxSearchResults)
+	if err != nil {
+		slog.Error("Failed to search messages", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to search messages.", replyToMessageID)
+	}
+	if len(messages) == 0 {
+		return h.sendText(chatID, fmt.Sprintf("🔍 No messages in this chat mention %q.", term), replyToMessageID)
+	}
+
+	_, err = h.sendChunks(chatID, formatSearchResults(term, messages), replyToMessageID, true)
+	return err
+}
+
+// formatSearchResults lists matching messages as plain text, each with its
+// time, role and a snippet with the match marked «like this».
+func formatSearchResults(term string, messages []*storage.Message) string {
+	match := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+
+	var b strings.Builder
+	header := fmt.Sprintf("🔍 %d message(s) mention %q", len(messages), term)
+	if len(messages) == maxSearchResults {
+		header = fmt.Sprintf("🔍 Latest %d messages mentioning %q", len(messages), term)
+	}
+	b.WriteString(header + "\n")
+
+	for _, msg := range messages {
+		b.WriteString(fmt.Sprintf("\n[%s] %s:\n%s\n",
+			msg.CreatedAt.Format("Jan 2, 3:04 PM"), roleLabel(msg.Role), searchSnippet(msg.Content, match)))
+	}
+	return b.String()
+}
+
+// searchSnippet returns the part of content around the first match, with
+// every match inside it wrapped in «».
+func searchSnippet(content string, match *regexp.Regexp) string {
+	content = strings.Join(strings.Fields(content), " ")
+	loc := match.FindStringIndex(content)
+	if loc == nil {
+		// The match spanned whitespace collapsed above; show the start instead
+		return truncateText(content, 2*searchSnippetContext)
+	}
+
+	start, end := 0, len(content)
+	prefix, suffix := "", ""
+	if runes := []rune(content[:loc[0]]); len(runes) > searchSnippetContext {
+		start = len(string(runes[:len(runes)-searchSnippetContext]))
+		prefix = "…"
+	}
+	if runes := []rune(content[loc[1]:]); len(runes) > searchSnippetContext {
+		end = loc[1] + len(string(runes[:searchSnippetContext]))
+		suffix = "…"
+	}
+	return prefix + match.ReplaceAllString(content[start:end], "«$0»") + suffix
+}
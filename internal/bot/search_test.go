@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestSearchCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx, err := store.CreateContext("1", "private", "session-1", time.Hour)
+	if err != nil {
+		t.Fatalf("CreateContext failed: %v", err)
+	}
+	_ = store.SaveMessage("1", ctx.SessionID, "alice", "", "user", "Is the Payment Service healthy?")
+	_ = store.SaveMessage("1", ctx.SessionID, "alice", "", "assistant", "The payment service has 3 pods running.")
+	_ = store.SaveMessage("1", ctx.SessionID, "alice", "", "user", "What about checkout?")
+	_ = store.SaveMessage("1", ctx.SessionID, "alice", "", "user", "100% of disk_usage")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+
+	for _, text := range []string{"/search payment service", "/search 0%", "/search refunds", "/search"} {
+		msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: text}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q) error = %v", text, err)
+		}
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 4 {
+		t.Fatalf("Expected 4 replies, got %q", texts)
+	}
+	found := texts[0]
+	if !strings.Contains(found, "2 message(s)") || strings.Contains(found, "checkout") {
+		t.Errorf("Expected both payment service messages only, got %q", found)
+	}
+	if !strings.Contains(found, "«Payment Service»") || !strings.Contains(found, "«payment service»") {
+		t.Errorf("Matches should be highlighted, got %q", found)
+	}
+	if strings.Index(found, "Assistant") > strings.Index(found, "User") {
+		t.Errorf("Most recent match should come first, got %q", found)
+	}
+	if !strings.Contains(texts[1], "«0%»") || strings.Contains(texts[1], "payment") {
+		t.Errorf("Wildcards in the term should match literally, got %q", texts[1])
+	}
+	if !strings.Contains(texts[2], "No messages") {
+		t.Errorf("Expected a no-match reply, got %q", texts[2])
+	}
+	if !strings.Contains(texts[3], "Usage") {
+		t.Errorf("Expected usage for an empty term, got %q", texts[3])
+	}
+}
+
+func TestSearchCommand_AllSessions(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_ = store.SaveMessage("1", "old-session", "alice", "", "user", "payment service was flaky last week")
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.SaveMessage("1", "session-1", "alice", "", "user", "is the payment service fine now?")
+	_ = store.SaveMessage("2", "session-2", "bob", "", "user", "payment service in another chat")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "alice"}, Text: "/search all payment service"}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 1 || !strings.Contains(texts[0], "2 message(s)") || !strings.Contains(texts[0], "last week") || strings.Contains(texts[0], "another chat") {
+		t.Errorf("Expected matches from both sessions of this chat only, got %q", texts)
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	match := regexp.MustCompile("(?i)" + regexp.QuoteMeta("payments"))
+	long := strings.Repeat("é", 200) + " PAYMENTS " + strings.Repeat("x", 200)
+
+	got := searchSnippet(long, match)
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "«PAYMENTS»") {
+		t.Errorf("searchSnippet() = %q, want an elided snippet around the match", got)
+	}
+	if n := len([]rune(got)); n > 2*searchSnippetContext+len("«PAYMENTS»")+2 {
+		t.Errorf("Snippet too long: %d runes", n)
+	}
+
+	if got := searchSnippet("payments\nare   down", match); got != "«payments» are down" {
+		t.Errorf("searchSnippet(short) = %q", got)
+	}
+}
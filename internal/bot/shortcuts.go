@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// ReactionAction is what a reaction on a bot answer triggers.
+type ReactionAction string
+
+const (
+	ReactionRetry    ReactionAction = "retry"    // Re-run the query that produced the answer
+	ReactionExport   ReactionAction = "export"   // Re-send the full answer as plain text
+	ReactionFeedback ReactionAction = "feedback" // Record negative feedback on the answer
+)
+
+// DefaultReactionShortcuts maps reactions to actions when none are configured.
+// Telegram only delivers reactions from its allowed set, so deployments there
+// should remap retry and export to supported emoji.
+var DefaultReactionShortcuts = map[string]ReactionAction{
+	"🔁": ReactionRetry,
+	"📋": ReactionExport,
+	"👎": ReactionFeedback,
+}
+
+// SetReactionShortcuts enables reaction shortcuts on bot answers. Answers are
+// linked to their queries in storage so a later reaction can act on them.
+// Retries are handled like newly sent messages, so maintenance mode and the
+// other checks on queries apply to them too.
+func (h *Handler) SetReactionShortcuts(shortcuts map[string]ReactionAction) {
+	if len(shortcuts) == 0 {
+		return
+	}
+	h.shortcuts = shortcuts
+	h.retryQuery = h.HandleMessage
+}
+
+// SetRetryHandler routes 🔁 retries through handler instead of HandleMessage
+// alone, so they pass the same middleware (such as rate limiting) as messages.
+// It must be called after SetReactionShortcuts.
+func (h *Handler) SetRetryHandler(handler messaging.MessageHandler) {
+	if h.shortcuts == nil {
+		return
+	}
+	h.retryQuery = handler
+}
+
+// linkResponse records which query produced each sent answer message. query
+// is the redacted question, so a retry asks it as stored in history.
+func (h *Handler) linkResponse(msg *messaging.IncomingMessage, sessionID, query, response string, messageIDs []string) {
+	if h.shortcuts == nil {
+		return
+	}
+	for _, id := range messageIDs {
+		link := &storage.ResponseLink{
+			ChatID:         msg.ChatID,
+			MessageID:      id,
+			SessionID:      sessionID,
+			QueryMessageID: msg.MessageID,
+			Query:          query,
+			Response:       response,
+		}
+		if err := h.storage.SaveResponseLink(link); err != nil {
+			slog.Warn("Failed to save response link", "chat_id", msg.ChatID, "message_id", id, "error", err)
+		}
+	}
+}
+
+// HandleReaction runs the shortcut action for a reaction on a bot answer.
+// Reactions on other messages, or with no mapped action, are ignored.
+func (h *Handler) HandleReaction(r *messaging.IncomingReaction) error {
+	action, ok := h.shortcuts[r.Emoji]
+	if !ok {
+		return nil
+	}
+	if !h.allowList.Allows(r.ChatID, r.From.ID) {
+		slog.Warn("Ignoring reaction from non-whitelisted chat", "chat_id", r.ChatID, "user_id", r.From.ID)
+		return nil
+	}
+
+	link, err := h.storage.GetResponseLink(r.ChatID, r.MessageID)
+	if err != nil {
+		return fmt.Errorf("failed to look up reacted message: %w", err)
+	}
+	if link == nil {
+		slog.Debug("Reaction on unlinked message, ignoring", "chat_id", r.ChatID, "message_id", r.MessageID)
+		return nil
+	}
+
+	slog.Info("Processing reaction shortcut",
+		"chat_id", r.ChatID,
+		"message_id", r.MessageID,
+		"user_id", r.From.ID,
+		"action", action)
+
+	switch action {
+	case ReactionRetry:
+		if h.isObserver(r.From.ID) {
+			slog.Info("Ignoring retry from observer", "chat_id", r.ChatID, "user_id", r.From.ID)
+			return nil
+		}
+		chatType, err := h.platform.GetChatType(r.ChatID)
+		if err != nil {
+			return fmt.Errorf("failed to get chat type: %w", err)
+		}
+		// Reacting to an answer counts as replying to the bot in groups
+		return h.retryQuery(&messaging.IncomingMessage{
+			ChatID:       r.ChatID,
+			MessageID:    link.QueryMessageID,
+			ChatType:     chatType,
+			From:         r.From,
+			Text:         link.Query,
+			IsReplyToBot: true,
+		})
+	case ReactionExport:
+		_, err := h.sendChunks(r.ChatID, link.Response, r.MessageID, true)
+		return err
+	case ReactionFeedback:
+		if err := h.storage.SetResponseFeedback(r.ChatID, r.MessageID, "negative"); err != nil {
+			return err
+		}
+		slog.Info("Negative feedback on response",
+			"chat_id", r.ChatID,
+			"session_id", link.SessionID,
+			"user_id", r.From.ID,
+			"query", truncateText(link.Query, 100))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestHandleReaction_Actions(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	h.SetReactionShortcuts(DefaultReactionShortcuts)
+
+	var retried []*messaging.IncomingMessage
+	h.retryQuery = func(msg *messaging.IncomingMessage) error {
+		retried = append(retried, msg)
+		return nil
+	}
+
+	// Two separate answers, so actions must hit the right one
+	for _, link := range []*storage.ResponseLink{
+		{ChatID: "1", MessageID: "11", SessionID: "s", QueryMessageID: "10", Query: "first?", Response: "first answer"},
+		{ChatID: "1", MessageID: "21", SessionID: "s", QueryMessageID: "20", Query: "second?", Response: "second answer"},
+	} {
+		if err := store.SaveResponseLink(link); err != nil {
+			t.Fatalf("SaveResponseLink failed: %v", err)
+		}
+	}
+
+	react := func(messageID, emoji string) {
+		t.Helper()
+		r := &messaging.IncomingReaction{ChatID: "1", MessageID: messageID, From: messaging.User{ID: "u"}, Emoji: emoji}
+		if err := h.HandleReaction(r); err != nil {
+			t.Fatalf("HandleReaction(%s, %s) error = %v", messageID, emoji, err)
+		}
+	}
+
+	react("21", "🔁")
+	if len(retried) != 1 || retried[0].Text != "second?" || retried[0].MessageID != "20" {
+		t.Errorf("Expected retry of second query, got %+v", retried)
+	}
+
+	react("11", "📋")
+	if texts := platform.sentTexts(); len(texts) != 1 || texts[0] != "first answer" {
+		t.Errorf("Expected export of first answer, got %v", texts)
+	}
+	if !platform.sent[0].PlainText || platform.sent[0].ReplyToMessageID != "11" {
+		t.Errorf("Export should be plain text replying to the answer, got %+v", platform.sent[0])
+	}
+
+	react("21", "👎")
+	if link, _ := store.GetResponseLink("1", "21"); link.Feedback != "negative" {
+		t.Errorf("Expected negative feedback on second answer, got %q", link.Feedback)
+	}
+	if link, _ := store.GetResponseLink("1", "11"); link.Feedback != "" {
+		t.Errorf("First answer should have no feedback, got %q", link.Feedback)
+	}
+
+	// Unmapped emoji and unlinked messages do nothing
+	react("11", "🔥")
+	react("99", "🔁")
+	if len(retried) != 1 || len(platform.sentTexts()) != 1 {
+		t.Errorf("Expected no further actions, got retries=%d sent=%d", len(retried), len(platform.sentTexts()))
+	}
+}
+
+func TestHandleReaction_RetryDuringMaintenance(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	platform.chatType = messaging.ChatTypeGroup
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+	h.SetReactionShortcuts(DefaultReactionShortcuts)
+	_ = store.SaveResponseLink(&storage.ResponseLink{ChatID: "1", MessageID: "11", SessionID: "s", QueryMessageID: "10", Query: "q", Response: "a"})
+
+	h.StartMaintenance(time.Time{}, "")
+	if err := h.HandleReaction(&messaging.IncomingReaction{ChatID: "1", MessageID: "11", From: messaging.User{ID: "u"}, Emoji: "🔁"}); err != nil {
+		t.Fatalf("HandleReaction() error = %v", err)
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 1 || !strings.Contains(strings.ToLower(texts[0]), "maintenance") {
+		t.Errorf("Retry during maintenance got %q, want the maintenance reply", texts)
+	}
+}
+
+func TestHandleReaction_RetryIsRateLimited(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"1"})
+	h.SetReactionShortcuts(DefaultReactionShortcuts)
+	h.SetRetryHandler(NewMiddleware(1, time.Minute, platform).RateLimit(h.HandleMessage))
+	_ = store.SaveResponseLink(&storage.ResponseLink{ChatID: "1", MessageID: "11", SessionID: "s", QueryMessageID: "10", Query: "q", Response: "a"})
+
+	// Maintenance keeps the first retry from reaching Claude
+	h.StartMaintenance(time.Time{}, "")
+	for i := 0; i < 2; i++ {
+		if err := h.HandleReaction(&messaging.IncomingReaction{ChatID: "1", MessageID: "11", From: messaging.User{ID: "u"}, Emoji: "🔁"}); err != nil {
+			t.Fatalf("HandleReaction() error = %v", err)
+		}
+	}
+	texts := platform.sentTexts()
+	if len(texts) != 2 || !strings.Contains(texts[1], "Rate limit exceeded") {
+		t.Errorf("Second retry got %q, want it rate limited", texts)
+	}
+}
+
+func TestHandleReaction_Disabled(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	_ = store.SaveResponseLink(&storage.ResponseLink{ChatID: "1", MessageID: "11", SessionID: "s", Query: "q", Response: "a"})
+
+	if err := h.HandleReaction(&messaging.IncomingReaction{ChatID: "1", MessageID: "11", Emoji: "📋"}); err != nil {
+		t.Fatalf("HandleReaction() error = %v", err)
+	}
+	if len(platform.sentTexts()) != 0 {
+		t.Error("Shortcuts should do nothing when not enabled")
+	}
+}
+
+func TestHandleQuery_LinksRedactedQuery(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer([]string{`hunter2`})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, sm, time.Hour), nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1"})
+	h.SetReactionShortcuts(DefaultReactionShortcuts)
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "5", ChatType: messaging.ChatTypePrivate, From: messaging.User{ID: "u"}, Text: "check the pods, password=hunter2"}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+
+	link, err := store.GetResponseLink("1", "1")
+	if err != nil || link == nil {
+		t.Fatalf("GetResponseLink() = %v, %v; want the answer linked", link, err)
+	}
+	if strings.Contains(link.Query, "hunter2") {
+		t.Errorf("Linked query = %q, want it redacted", link.Query)
+	}
+}
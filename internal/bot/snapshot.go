@@ -0,0 +1,80 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// handleSnapshotCommand handles /snapshot create|list|restore (admin only).
+// Restore requires an explicit "confirm" argument. It waits for queued and
+// running Claude queries to finish and holds back new ones until it is done.
+func (h *Handler) handleSnapshotCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /snapshot command", "chat_id", chatID, "args", fields)
+
+	usage := "Usage:\n" +
+		"`/snapshot create <name>` - Snapshot the database\n" +
+		"`/snapshot list` - List snapshots\n" +
+		"`/snapshot restore <name>` - Restore a snapshot"
+
+	if len(fields) < 2 {
+		return h.sendText(chatID, usage, replyToMessageID)
+	}
+
+	switch fields[1] {
+	case "create":
+		if len(fields) < 3 {
+			return h.sendText(chatID, usage, replyToMessageID)
+		}
+		name := fields[2]
+		if _, err := h.storage.CreateSnapshot(name); err != nil {
+			slog.Error("Failed to create snapshot", "chat_id", chatID, "name", name, "error", err)
+			return h.sendError(chatID, fmt.Sprintf("Failed to create snapshot: %v", err), replyToMessageID)
+		}
+		return h.sendText(chatID, fmt.Sprintf("📸 Snapshot `%s` created.", name), replyToMessageID)
+
+	case "list":
+		names, err := h.storage.ListSnapshots()
+		if err != nil {
+			slog.Error("Failed to list snapshots", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to list snapshots.", replyToMessageID)
+		}
+		if len(names) == 0 {
+			return h.sendText(chatID, "📸 No snapshots found.", replyToMessageID)
+		}
+		var b strings.Builder
+		b.WriteString("📸 *Snapshots*\n\n")
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("• `%s`\n", name))
+		}
+		return h.sendText(chatID, b.String(), replyToMessageID)
+
+	case "restore":
+		if len(fields) < 3 {
+			return h.sendText(chatID, usage, replyToMessageID)
+		}
+		name := fields[2]
+		if len(fields) < 4 || fields[3] != "confirm" {
+			return h.sendText(chatID, fmt.Sprintf(
+				"⚠️ Restoring replaces *all* sessions and history with snapshot `%s`.\n\n"+
+					"To proceed, send:\n`/snapshot restore %s confirm`", name, name), replyToMessageID)
+		}
+		if active := h.sessionManager.GetActiveQueryCount() + h.sessionManager.GetQueueDepth(); active > 0 {
+			if err := h.sendText(chatID, fmt.Sprintf(
+				"⏳ Waiting for %d running or queued queries to finish before restoring...", active), replyToMessageID); err != nil {
+				slog.Warn("Failed to send restore progress", "chat_id", chatID, "error", err)
+			}
+		}
+		resume := h.sessionManager.PauseQueries()
+		err := h.storage.RestoreSnapshot(name)
+		resume()
+		if err != nil {
+			slog.Error("Failed to restore snapshot", "chat_id", chatID, "name", name, "error", err)
+			return h.sendError(chatID, fmt.Sprintf("Failed to restore snapshot: %v", err), replyToMessageID)
+		}
+		return h.sendText(chatID, fmt.Sprintf("✅ Snapshot `%s` restored.", name), replyToMessageID)
+
+	default:
+		return h.sendText(chatID, usage, replyToMessageID)
+	}
+}
@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestStatusCommand_QueueNumbersForAdmins(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager("/nonexistent", t.TempDir(), "", 2, time.Second)
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, nil, nil, store, []string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "user"}}, []string{"/status"})
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}}, []string{"/status"})
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected two status replies, got %v", texts)
+	}
+	for _, text := range texts {
+		if !strings.Contains(text, "Bot load:* 0/2 queries running, 0 active sessions") || !strings.Contains(text, "start right away") {
+			t.Errorf("Status should show the bot's load to everyone: %s", text)
+		}
+	}
+	if strings.Contains(texts[0], "Query queue") {
+		t.Errorf("Non-admin status should not show queue numbers: %s", texts[0])
+	}
+	if !strings.Contains(texts[1], "Query queue:* 0 waiting, 0 dropped") {
+		t.Errorf("Admin status should show queue numbers: %s", texts[1])
+	}
+}
+
+func TestFormatLoadStatus_Saturated(t *testing.T) {
+	status := formatLoadStatus(7, 2, 2, 3)
+	if !strings.Contains(status, "2/2 queries running, 7 active sessions") || !strings.Contains(status, "wait in the queue behind 3 others") {
+		t.Errorf("formatLoadStatus() = %q, want a saturated bot to warn about queueing", status)
+	}
+}
@@ -0,0 +1,137 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// defaultStreamEditInterval throttles progress edits; Telegram rate-limits
+// message edits per chat.
+const defaultStreamEditInterval = 2 * time.Second
+
+// SetStreaming makes queries show Claude's answer while it is being written,
+// in one message that is edited as output arrives and finally replaced with
+// the complete response.
+func (h *Handler) SetStreaming(enabled bool) {
+	h.streaming = enabled
+	if enabled && h.streamInterval == 0 {
+		h.streamInterval = defaultStreamEditInterval
+	}
+}
+
+// streamProgress tracks the message showing a streamed answer in progress.
+type streamProgress struct {
+	h        *Handler
+	chatID   string
+	threadID string // Forum topic to answer in; empty = the chat itself
+	replyTo  string
+	interval time.Duration
+
+	mu        sync.Mutex
+	messageID string
+	lastEdit  time.Time
+}
+
+func (h *Handler) newStreamProgress(chatID, threadID, replyTo string) *streamProgress {
+	return &streamProgress{
+		h:        h,
+		chatID:   chatID,
+		threadID: threadID,
+		replyTo:  replyTo,
+		interval: h.streamInterval,
+	}
+}
+
+// update shows the partial answer, sending the progress message on first use
+// and editing it afterwards. Updates within the edit interval are dropped.
+func (p *streamProgress) update(text string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.lastEdit.IsZero() && time.Since(p.lastEdit) < p.interval {
+		return
+	}
+	preview := formatStreamPreview(p.h.sanitize(p.chatID, text))
+
+	if p.messageID == "" {
+		id, err := p.h.platform.SendMessage(&messaging.OutgoingMessage{
+			ChatID:           p.chatID,
+			Text:             preview,
+			ReplyToMessageID: p.replyTo,
+			PlainText:        true,
+			ThreadID:         p.threadID,
+		})
+		if err != nil {
+			slog.Warn("Failed to send streaming progress message", "chat_id", p.chatID, "error", err)
+			return
+		}
+		p.messageID = id
+	} else if err := p.h.platform.EditMessage(p.chatID, p.messageID, preview); err != nil {
+		slog.Debug("Failed to edit streaming progress message", "chat_id", p.chatID, "error", err)
+	}
+	p.lastEdit = time.Now()
+}
+
+// formatStreamPreview marks text as in progress and keeps its tail when it
+// is too long for one message.
+func formatStreamPreview(text string) string {
+	const prefix = "⏳ "
+	limit := maxTelegramMessageLen - len(prefix) - len("…")
+	if len(text) > limit {
+		cut := len(text) - limit
+		for cut < len(text) && !utf8.RuneStart(text[cut]) {
+			cut++
+		}
+		text = "…" + text[cut:]
+	}
+	return prefix + text
+}
+
+// sendAnswer delivers the final response, in forum topic threadID if set.
+// When a progress message was shown, it is replaced with the first chunk and
+// the rest follow as replies.
+func (h *Handler) sendAnswer(progress *streamProgress, chatID, threadID, text, replyToMessageID string, plain bool) ([]string, error) {
+	if progress == nil {
+		return h.sendChunksInThread(chatID, threadID, text, replyToMessageID, plain)
+	}
+	progress.mu.Lock()
+	messageID := progress.messageID
+	progress.mu.Unlock()
+	if messageID == "" {
+		return h.sendChunksInThread(chatID, threadID, text, replyToMessageID, plain)
+	}
+
+	if strings.TrimSpace(text) == "" {
+		text = "I received your message but have no response to provide."
+	}
+	chunks := splitResponse(text, maxTelegramMessageLen)
+
+	if err := h.platform.EditMessage(chatID, messageID, chunks[0]); err != nil {
+		slog.Warn("Failed to replace streaming progress message, sending answer separately", "chat_id", chatID, "error", err)
+		return h.sendChunksInThread(chatID, threadID, text, replyToMessageID, plain)
+	}
+
+	sentIDs := []string{messageID}
+	currentReplyTo := messageID
+	for i, chunk := range chunks[1:] {
+		sentMessageID, err := h.platform.SendMessage(&messaging.OutgoingMessage{
+			ChatID:           chatID,
+			Text:             chunk,
+			ReplyToMessageID: currentReplyTo,
+			PlainText:        plain,
+			ThreadID:         threadID,
+		})
+		if err != nil {
+			return sentIDs, fmt.Errorf("failed to send response chunk %d: %w", i+2, err)
+		}
+		sentIDs = append(sentIDs, sentMessageID)
+		currentReplyTo = sentMessageID
+	}
+	return sentIDs, nil
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+// streamingClaudeCLI writes a script that emits a stream-json answer in two parts.
+func streamingClaudeCLI(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+echo '{"type":"assistant","message":{"content":[{"type":"text","text":"Looking at pods"}]},"session_id":"claude-1"}'
+echo '{"type":"assistant","message":{"content":[{"type":"text","text":"password=hunter2"}]},"session_id":"claude-1"}'
+echo '{"type":"result","result":"All pods are healthy.","session_id":"claude-1"}'
+`
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	return path
+}
+
+func TestHandleQuery_Streaming(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	sm := claude.NewSessionManager(streamingClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer([]string{`password=(\S+)`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, sm, time.Hour), nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1"})
+	h.SetStreaming(true)
+	h.streamInterval = 0 // Edit on every partial
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "10", ChatType: messaging.ChatTypePrivate, Text: "are pods ok?"}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+
+	texts := platform.sentTexts()
+	if len(texts) != 1 || !strings.HasPrefix(texts[0], "⏳ Looking at pods") {
+		t.Fatalf("Expected a single progress message, got %q", texts)
+	}
+	if len(platform.edits) != 2 {
+		t.Fatalf("Expected a progress edit and the final answer, got %+v", platform.edits)
+	}
+	if strings.Contains(platform.edits[0].Text, "hunter2") {
+		t.Errorf("Partial output must be sanitized: %q", platform.edits[0].Text)
+	}
+	if final := platform.edits[1]; final.MessageID != "1" || final.Text != "All pods are healthy." {
+		t.Errorf("Final edit = %+v, want the answer in the progress message", final)
+	}
+}
+
+func TestFormatStreamPreview(t *testing.T) {
+	if got := formatStreamPreview("short"); got != "⏳ short" {
+		t.Errorf("formatStreamPreview(short) = %q", got)
+	}
+
+	long := strings.Repeat("a", maxTelegramMessageLen) + "END"
+	got := formatStreamPreview(long)
+	if len(got) > maxTelegramMessageLen || !strings.HasPrefix(got, "⏳ …") || !strings.HasSuffix(got, "END") {
+		t.Errorf("formatStreamPreview(long) should keep the tail within the limit, got len %d", len(got))
+	}
+}
@@ -0,0 +1,138 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+const (
+	// summaryPrefix starts the summary sent to the chat.
+	summaryPrefix = "📝 *Session summary:*\n"
+
+	// maxSummaryMessages bounds how many of the session's latest messages
+	// a summary covers.
+	maxSummaryMessages = 200
+	// maxSummaryTranscript bounds the transcript sent to Claude, in bytes;
+	// the oldest messages are dropped first.
+	maxSummaryTranscript = 60000
+	// defaultSummaryTimeout bounds a summary call when none is configured.
+	defaultSummaryTimeout = 2 * time.Minute
+)
+
+// summaryPrompt asks Claude for a handoff summary; the transcript is appended.
+const summaryPrompt = "Summarize this SRE chat session for an on-call engineer taking it over. " +
+	"In at most 5 short lines, cover what was investigated, what was found, what was done and what is still open. " +
+	"Reply with the summary only.\n\nTranscript:\n"
+
+// SetSummarizeEnabled registers the /summarize command, which asks Claude in a
+// one-shot call to summarize the session so far for a handoff. A non-empty
+// model replaces the configured one; timeout bounds the call.
+func (h *Handler) SetSummarizeEnabled(enabled bool, model string, timeout time.Duration) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/summarize"); exists {
+		return
+	}
+	if timeout <= 0 {
+		timeout = defaultSummaryTimeout
+	}
+	h.summaryModel = model
+	h.summaryTimeout = timeout
+	h.commands.Register(CommandHandler{
+		Name:        "/summarize",
+		Description: "Summarize this session in a few lines, e.g. for a handoff",
+		Handler: func(msg *messaging.IncomingMessage, _ []string) error {
+			return h.handleSummarizeCommand(msg)
+		},
+	})
+}
+
+// handleSummarizeCommand handles /summarize. The summary is stored with the
+// summary role so it shows up in /history and /export but not in later
+// summaries.
+func (h *Handler) handleSummarizeCommand(msg *messaging.IncomingMessage) error {
+	chatID, replyTo := msg.ChatID, msg.MessageID
+	slog.Info("Processing /summarize command", "chat_id", chatID)
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /summarize", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", replyTo)
+	}
+	if ctx == nil || !ctx.IsActive {
+		return h.sendText(chatID, "ℹ️ No active session to summarize.", replyTo)
+	}
+
+	messages, err := h.storage.GetRecentMessagesBySession(chatID, ctx.SessionID, maxSummaryMessages)
+	if err != nil {
+		slog.Error("Failed to get messages for /summarize", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session history.", replyTo)
+	}
+	transcript := formatSummaryTranscript(messages, maxSummaryTranscript)
+	if transcript == "" {
+		return h.sendText(chatID, "ℹ️ Nothing to summarize yet. Ask a question first.", replyTo)
+	}
+
+	if err := h.platform.SendTyping(chatID); err != nil {
+		slog.Warn("Failed to send typing indicator", "chat_id", chatID, "error", err)
+	}
+
+	callCtx, cancel := context.WithTimeout(context.Background(), h.summaryTimeout)
+	defer cancel()
+	summary, err := h.executor.ExecuteOneShot(callCtx, summaryPrompt+transcript, h.summaryModel)
+	if err != nil {
+		slog.Error("Failed to summarize session", "chat_id", chatID, "session_id", ctx.SessionID, "error", err)
+		return h.sendError(chatID, "Failed to summarize the session. Please try again.", replyTo)
+	}
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return h.sendError(chatID, "Claude returned an empty summary. Please try again.", replyTo)
+	}
+
+	summary = h.sanitize(chatID, summary)
+	if err := h.storage.SaveMessage(chatID, ctx.SessionID, msg.From.ID, "", storage.RoleSummary, summary); err != nil {
+		slog.Warn("Failed to save session summary", "chat_id", chatID, "error", err)
+	}
+	slog.Info("Summarized session", "chat_id", chatID, "session_id", ctx.SessionID, "messages", len(messages))
+
+	_, err = h.sendChunks(chatID, summaryPrefix+summary, replyTo, false)
+	return err
+}
+
+// formatSummaryTranscript renders messages, oldest first, as a transcript of
+// at most maxLen bytes, leaving out earlier summaries and system notes and
+// dropping the oldest messages that don't fit. Returns "" when nothing is
+// left.
+func formatSummaryTranscript(messages []*storage.Message, maxLen int) string {
+	var lines []string
+	size := 0
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		if m.Role != storage.RoleUser && m.Role != storage.RoleAssistant {
+			continue
+		}
+		speaker := "User"
+		if m.Role == storage.RoleAssistant {
+			speaker = "Assistant"
+		}
+		line := fmt.Sprintf("%s: %s", speaker, strings.TrimSpace(m.Content))
+		if size+len(line)+1 > maxLen {
+			break
+		}
+		size += len(line) + 1
+		lines = append(lines, line)
+	}
+
+	// Collected newest first
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestSummarizeCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	// The fake CLI records the prompt it was given and answers with a summary
+	dir := t.TempDir()
+	promptFile := filepath.Join(dir, "prompt")
+	cli := filepath.Join(dir, "claude")
+	script := "#!/bin/sh\nfor arg; do last=\"$arg\"; done\nprintf '%s' \"$last\" > " + promptFile + "\necho 'API was down; pods restarted. Root cause still open.'\n"
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := claude.NewSessionManager(cli, t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"1"})
+	h.SetSummarizeEnabled(true, "", 0)
+
+	msg := &messaging.IncomingMessage{ChatID: "1", MessageID: "9", From: messaging.User{ID: "alice"}, Text: "/summarize"}
+	if err := h.handleSummarizeCommand(msg); err != nil {
+		t.Fatalf("handleSummarizeCommand() error = %v", err)
+	}
+	if texts := platform.sentTexts(); len(texts) != 1 || !strings.Contains(texts[0], "No active session") {
+		t.Fatalf("Without a session got %q", texts)
+	}
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.SaveMessage("1", "session-1", "alice", "", "user", "why is the api down?")
+	_ = store.SaveMessage("1", "session-1", "alice", "", storage.RoleSummary, "an earlier summary")
+	_ = store.SaveMessage("1", "session-1", "alice", "", "assistant", "The api pods were crashlooping, I restarted them.")
+
+	if err := h.handleSummarizeCommand(msg); err != nil {
+		t.Fatalf("handleSummarizeCommand() error = %v", err)
+	}
+	texts := platform.sentTexts()
+	if got := texts[len(texts)-1]; !strings.HasPrefix(got, summaryPrefix) || !strings.Contains(got, "Root cause still open") {
+		t.Errorf("Reply = %q, want the summary", got)
+	}
+
+	prompt, err := os.ReadFile(promptFile)
+	if err != nil {
+		t.Fatalf("Fake CLI was not run: %v", err)
+	}
+	if !strings.Contains(string(prompt), "User: why is the api down?\nAssistant: The api pods were crashlooping") {
+		t.Errorf("Prompt = %q, want the session transcript", prompt)
+	}
+	if strings.Contains(string(prompt), "an earlier summary") {
+		t.Errorf("Prompt = %q, earlier summaries should be left out", prompt)
+	}
+
+	messages, _ := store.GetRecentMessagesBySession("1", "session-1", 10)
+	if last := messages[len(messages)-1]; last.Role != storage.RoleSummary || !strings.Contains(last.Content, "pods restarted") {
+		t.Errorf("Last stored message = %+v, want the summary", last)
+	}
+}
+
+func TestFormatSummaryTranscript(t *testing.T) {
+	messages := []*storage.Message{
+		{Role: "user", Content: "first question"},
+		{Role: "assistant", Content: "first answer"},
+		{Role: storage.RoleSummary, Content: "old"},
+		{Role: storage.RoleSystem, Content: "note"},
+		{Role: "user", Content: "second question"},
+	}
+	if got := formatSummaryTranscript(messages, 1000); got != "User: first question\nAssistant: first answer\nUser: second question" {
+		t.Errorf("formatSummaryTranscript() = %q", got)
+	}
+	// The oldest messages are dropped to fit
+	if got := formatSummaryTranscript(messages, 50); got != "Assistant: first answer\nUser: second question" {
+		t.Errorf("formatSummaryTranscript() with limit = %q", got)
+	}
+	if got := formatSummaryTranscript(messages[2:3], 1000); got != "" {
+		t.Errorf("formatSummaryTranscript() of only summaries = %q, want empty", got)
+	}
+}
@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// maxTopicQueryLength bounds how much of a session's first question names
+// its answer topic.
+const maxTopicQueryLength = 60
+
+// SetAnswerThreads posts each session's answers in a forum topic created for
+// it, in forum-enabled supergroups. Requires a platform with forum topics.
+func (h *Handler) SetAnswerThreads(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, ok := h.platform.(messaging.ForumTopics); !ok {
+		slog.Warn("Answer threads are not supported by this platform, ignoring")
+		return
+	}
+	h.answerThreads = true
+}
+
+// answerThread returns the forum topic the session's answers go to, creating
+// it on the session's first question, or "" to answer in the chat itself.
+// Failures fall back to the chat so the question still gets an answer.
+func (h *Handler) answerThread(msg *messaging.IncomingMessage, ctx *storage.ChatContext) string {
+	if !h.answerThreads || msg.ChatType != messaging.ChatTypeGroup {
+		return ""
+	}
+	topics := h.platform.(messaging.ForumTopics)
+
+	threadID, err := h.storage.GetSessionThread(msg.ChatID)
+	if err != nil {
+		slog.Warn("Failed to get session thread", "chat_id", msg.ChatID, "error", err)
+		return ""
+	}
+	if threadID != "" {
+		return threadID
+	}
+
+	forum, err := topics.IsForum(msg.ChatID)
+	if err != nil {
+		slog.Warn("Failed to check whether chat is a forum", "chat_id", msg.ChatID, "error", err)
+		return ""
+	}
+	if !forum {
+		return ""
+	}
+
+	name := "💬 " + truncateText(msg.Text, maxTopicQueryLength)
+	threadID, err = topics.CreateForumTopic(msg.ChatID, name)
+	if err != nil {
+		slog.Warn("Failed to create answer topic", "chat_id", msg.ChatID, "error", err)
+		return ""
+	}
+	if err := h.storage.SetSessionThread(msg.ChatID, threadID); err != nil {
+		slog.Warn("Failed to save session thread", "chat_id", msg.ChatID, "error", err)
+	}
+	slog.Info("Created answer topic", "chat_id", msg.ChatID, "session_id", ctx.SessionID, "thread_id", threadID)
+
+	// The answer can't reply across topics, so point the asker to it once
+	if err := h.sendText(msg.ChatID, fmt.Sprintf("🧵 Answers for this session are in the topic %q.", name), msg.MessageID); err != nil {
+		slog.Warn("Failed to announce answer topic", "chat_id", msg.ChatID, "error", err)
+	}
+	return threadID
+}
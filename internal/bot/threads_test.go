@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/security"
+)
+
+// fakeForumPlatform is a fakePlatform whose chats can have forum topics.
+type fakeForumPlatform struct {
+	*fakePlatform
+	forum  bool
+	topics []string // Names of created topics; thread IDs are 100, 101, ...
+}
+
+func (f *fakeForumPlatform) IsForum(chatID string) (bool, error) {
+	return f.forum, nil
+}
+
+func (f *fakeForumPlatform) CreateForumTopic(chatID, name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.topics = append(f.topics, name)
+	return fmt.Sprint(99 + len(f.topics)), nil
+}
+
+func newThreadTestHandler(t *testing.T, platform messaging.Platform) *Handler {
+	t.Helper()
+	store, cleanup := setupTestStorage(t)
+	t.Cleanup(cleanup)
+
+	sm := claude.NewSessionManager(fakeClaudeCLI(t), t.TempDir(), "", 2, 10*time.Second)
+	sanitizer, err := security.NewSanitizer(nil)
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	h := NewHandler(platform, context.NewManager(store, sm, time.Hour), nil, nil, sm, claude.NewExecutor(sm, "", 0), sanitizer, store, []string{"-100"})
+	h.SetAnswerThreads(true)
+	return h
+}
+
+func TestAnswerThreads_AnswersGoToSessionTopic(t *testing.T) {
+	platform := &fakeForumPlatform{fakePlatform: newFakePlatform(), forum: true}
+	platform.chatType = messaging.ChatTypeGroup
+	h := newThreadTestHandler(t, platform)
+
+	ask := func(id, text string) {
+		msg := &messaging.IncomingMessage{ChatID: "-100", MessageID: id, ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "alice"}, Text: text, IsMentioningBot: true}
+		if err := h.HandleMessage(msg); err != nil {
+			t.Fatalf("HandleMessage(%q) error = %v", text, err)
+		}
+	}
+
+	ask("10", "why is checkout slow?")
+	ask("11", "and payments?")
+
+	if len(platform.topics) != 1 || !strings.Contains(platform.topics[0], "why is checkout slow?") {
+		t.Fatalf("Expected one topic named after the first question, got %q", platform.topics)
+	}
+	if threadID, _ := h.storage.GetSessionThread("-100"); threadID != "100" {
+		t.Errorf("Stored thread ID = %q, want 100", threadID)
+	}
+
+	var answers int
+	for _, msg := range platform.sent {
+		if strings.HasPrefix(msg.Text, "🧵") {
+			if msg.ThreadID != "" || msg.ReplyToMessageID != "10" {
+				t.Errorf("Topic announcement should reply to the question in the chat, got %+v", msg)
+			}
+			continue
+		}
+		answers++
+		if msg.ThreadID != "100" || msg.ReplyToMessageID != "" {
+			t.Errorf("Answer should go to thread 100 without a reply, got %+v", msg)
+		}
+	}
+	if answers != 2 {
+		t.Errorf("Expected 2 answers, got %d (sent %q)", answers, platform.sentTexts())
+	}
+
+	// A new session gets a new topic
+	_, _ = h.storage.CreateContext("-100", "group", "session-2", time.Hour)
+	ask("12", "next incident")
+	if len(platform.topics) != 2 {
+		t.Errorf("Expected a second topic for the new session, got %q", platform.topics)
+	}
+}
+
+func TestAnswerThreads_NotForum(t *testing.T) {
+	platform := &fakeForumPlatform{fakePlatform: newFakePlatform(), forum: false}
+	platform.chatType = messaging.ChatTypeGroup
+	h := newThreadTestHandler(t, platform)
+
+	msg := &messaging.IncomingMessage{ChatID: "-100", MessageID: "10", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "alice"}, Text: "hello", IsMentioningBot: true}
+	if err := h.HandleMessage(msg); err != nil {
+		t.Fatalf("HandleMessage() error = %v", err)
+	}
+	if len(platform.topics) != 0 || len(platform.sent) != 1 || platform.sent[0].ThreadID != "" || platform.sent[0].ReplyToMessageID != "10" {
+		t.Errorf("Without topics the answer should reply in the chat, got topics %q, sent %+v", platform.topics, platform.sent)
+	}
+}
+
+func TestSetAnswerThreads_UnsupportedPlatform(t *testing.T) {
+	h := NewHandler(newFakePlatform(), nil, nil, nil, nil, nil, nil, nil, nil)
+	h.SetAnswerThreads(true)
+	if h.answerThreads {
+		t.Error("Answer threads should stay off for platforms without forum topics")
+	}
+}
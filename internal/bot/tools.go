@@ -0,0 +1,84 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rg/aiops/internal/storage"
+)
+
+const (
+	// maxToolsListed bounds /tools to the most recent executions
+	maxToolsListed = 20
+	// maxToolDetailLen bounds the input and output shown per tool in /tools
+	maxToolDetailLen = 200
+)
+
+// handleToolsCommand lists the tools Claude ran in the active session, with
+// their input and output when known.
+func (h *Handler) handleToolsCommand(chatID, replyToMessageID string) error {
+	slog.Info("Processing /tools command", "chat_id", chatID)
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /tools", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", replyToMessageID)
+	}
+	if ctx == nil || !ctx.IsActive {
+		return h.sendText(chatID, "🔧 No active session.", replyToMessageID)
+	}
+
+	tools, err := h.storage.GetToolExecutionsBySession(chatID, ctx.SessionID, maxToolsListed)
+	if err != nil {
+		slog.Error("Failed to get tool executions for /tools", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve tool executions.", replyToMessageID)
+	}
+	if len(tools) == 0 {
+		return h.sendText(chatID, "🔧 No tools have been used in this session yet.", replyToMessageID)
+	}
+
+	return h.sendResponse(chatID, formatToolsResponse(tools), replyToMessageID)
+}
+
+// formatToolsResponse renders a numbered list of tool executions, oldest
+// first, followed by a success/failure summary. tools is ordered newest first,
+// as returned by storage.
+func formatToolsResponse(tools []*storage.ToolExecution) string {
+	var b strings.Builder
+	b.WriteString("🔧 *Recent Tool Executions*\n\n")
+
+	n, succeeded, failed := 0, 0, 0
+	for i := len(tools) - 1; i >= 0; i-- {
+		tool := tools[i]
+		// Placeholder for tools dropped by the per-response cap
+		if strings.HasPrefix(tool.ToolName, "… ") {
+			b.WriteString(fmt.Sprintf("_%s_\n\n", tool.ToolName))
+			continue
+		}
+		n++
+		icon := "✅"
+		if tool.Status == "success" {
+			succeeded++
+		} else {
+			icon = "❌"
+			failed++
+		}
+		b.WriteString(fmt.Sprintf("%d. %s *%s* [%s]\n", n, icon, tool.ToolName, tool.CreatedAt.Format("3:04:05 PM")))
+		if tool.Input != "" {
+			b.WriteString(fmt.Sprintf("Input: `%s`\n", truncateText(oneLine(tool.Input), maxToolDetailLen)))
+		}
+		if tool.Output != "" {
+			b.WriteString(fmt.Sprintf("Output: `%s`\n", truncateText(oneLine(tool.Output), maxToolDetailLen)))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(fmt.Sprintf("*Total:* %d (✅ %d succeeded, ❌ %d failed)", n, succeeded, failed))
+	return b.String()
+}
+
+// oneLine collapses whitespace, including newlines, to single spaces.
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestToolsCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1"}, []string{"/tools"})
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.SaveToolExecution("1", "session-1", "Bash", "success", `{"command":"kubectl get pods -n prod"}`, "pod-a   Running\npod-b   CrashLoopBackOff")
+	_ = store.SaveToolExecution("1", "session-1", "kubectl", "success", "", "")
+	_ = store.SaveToolExecution("1", "session-1", "Bash", "error", `{"command":"kubectl logs x"}`, "not found")
+	_ = store.SaveToolExecution("1", "session-1", formatOmittedToolsNote(4), "success", "", "")
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1"}, []string{"/tools"})
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 replies, got %v", texts)
+	}
+	if !strings.Contains(texts[0], "No active session") {
+		t.Errorf("Expected no-session reply, got %q", texts[0])
+	}
+	for _, want := range []string{
+		"1. ✅ *Bash* [", `kubectl get pods -n prod`, "pod-a Running pod-b CrashLoopBackOff",
+		"2. ✅ *kubectl* [", "3. ❌ *Bash* [", "_… 4 more omitted_",
+		"*Total:* 3 (✅ 2 succeeded, ❌ 1 failed)",
+	} {
+		if !strings.Contains(texts[1], want) {
+			t.Errorf("/tools reply missing %q:\n%s", want, texts[1])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// trailEventLabels describes each trail event in /trail output.
+var trailEventLabels = map[string]string{
+	"owned":     "📥 Session started or received",
+	"transfer":  "➡️ Transferred away",
+	"expired":   "⌛ Expired",
+	"manual":    "🔄 Reset with /new",
+	"error":     "❌ Cleaned up after error",
+	"reconcile": "🧹 Deactivated as duplicate",
+}
+
+// SetSessionTrailEnabled registers the admin /trail command.
+func (h *Handler) SetSessionTrailEnabled(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/trail"); exists {
+		return
+	}
+	h.commands.Register(CommandHandler{
+		Name:        "/trail",
+		Description: "Show which chats a Claude session moved through",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleTrailCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+}
+
+// handleTrailCommand handles /trail <claude_session_id>.
+func (h *Handler) handleTrailCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /trail command", "chat_id", chatID, "args", fields)
+
+	if len(fields) < 2 {
+		return h.sendText(chatID, "Usage: `/trail <claude_session_id>`\n\nUse /session or /sessions to find the ID.", replyToMessageID)
+	}
+	claudeSessionID := fields[1]
+
+	trail, err := h.storage.GetSessionTrail(claudeSessionID)
+	if err != nil {
+		slog.Error("Failed to get session trail", "chat_id", chatID, "claude_session_id", claudeSessionID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session trail.", replyToMessageID)
+	}
+	if len(trail) == 0 {
+		return h.sendText(chatID, fmt.Sprintf("❌ No history found for session `%s`.", claudeSessionID), replyToMessageID)
+	}
+
+	return h.sendResponse(chatID, formatTrailResponse(claudeSessionID, trail), replyToMessageID)
+}
+
+// formatTrailResponse renders a session's journey, oldest event first.
+func formatTrailResponse(claudeSessionID string, trail []storage.TrailEvent) string {
+	var b strings.Builder
+
+	b.WriteString("🧭 *Session Trail*\n\n")
+	b.WriteString(fmt.Sprintf("*Claude Session:* `%s`\n\n", claudeSessionID))
+
+	for i, ev := range trail {
+		label, ok := trailEventLabels[ev.Event]
+		if !ok {
+			label = ev.Event
+		}
+		b.WriteString(fmt.Sprintf("*%d.* %s\n", i+1, label))
+		b.WriteString(fmt.Sprintf("   Chat: `%s` (%s)\n", ev.ChatID, ev.ChatType))
+		b.WriteString(fmt.Sprintf("   %s (%s)\n", ev.Time.Format("Jan 2, 3:04 PM"), formatDurationAgo(time.Since(ev.Time))))
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
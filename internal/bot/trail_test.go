@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestFormatTrailResponse(t *testing.T) {
+	now := time.Now()
+	trail := []storage.TrailEvent{
+		{Time: now.Add(-2 * time.Hour), ChatID: "100", ChatType: "private", Event: "owned"},
+		{Time: now.Add(-time.Hour), ChatID: "100", ChatType: "private", Event: "transfer"},
+		{Time: now.Add(-time.Hour), ChatID: "-200", ChatType: "group", Event: "owned"},
+		{Time: now, ChatID: "-200", ChatType: "group", Event: "custom"},
+	}
+
+	got := formatTrailResponse("claude-abc", trail)
+
+	for _, want := range []string{"`claude-abc`", "*1.* 📥", "*2.* ➡️ Transferred away", "Chat: `-200` (group)", "*4.* custom"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("formatTrailResponse() missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestTrailCommand_AdminOnly(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("1", "claude-abc")
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetSessionTrailEnabled(true)
+
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "user"}}, []string{"/trail", "claude-abc"})
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "1", From: messaging.User{ID: "admin"}}, []string{"/trail", "claude-abc"})
+
+	texts := platform.sentTexts()
+	if len(texts) != 2 {
+		t.Fatalf("Expected 2 replies, got %v", texts)
+	}
+	if strings.Contains(texts[0], "Session Trail") {
+		t.Error("Non-admin should not see the trail")
+	}
+	if !strings.Contains(texts[1], "Session Trail") || !strings.Contains(texts[1], "Chat: `1`") {
+		t.Errorf("Expected trail for admin, got:\n%s", texts[1])
+	}
+}
@@ -0,0 +1,72 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errTransferNotAllowed is returned by transferSession when the transfer
+// policy refuses the combination of chat types.
+var errTransferNotAllowed = errors.New("session transfer not allowed between these chat types")
+
+// transferChatTypes are the chat types a transfer rule may name.
+var transferChatTypes = map[string]bool{"private": true, "group": true, "channel": true, "*": true}
+
+// TransferPolicy restricts session transfers by the types of the source and
+// target chats. A nil policy allows every transfer.
+type TransferPolicy struct {
+	rules [][2]string // {source, target}; "*" matches any type
+}
+
+// ParseTransferPolicy parses rules of the form "source->target", e.g.
+// "private->group" or "*->private". Invalid rules are reported together in
+// the error; the returned policy still holds the valid ones.
+func ParseTransferPolicy(rules []string) (*TransferPolicy, error) {
+	p := &TransferPolicy{}
+	var errs []error
+	for _, rule := range rules {
+		source, target, ok := strings.Cut(rule, "->")
+		source, target = strings.TrimSpace(source), strings.TrimSpace(target)
+		if !ok || !transferChatTypes[source] || !transferChatTypes[target] {
+			errs = append(errs, fmt.Errorf("transfer rule %q: expected \"<private|group|channel|*>-><private|group|channel|*>\"", rule))
+			continue
+		}
+		p.rules = append(p.rules, [2]string{source, target})
+	}
+	return p, errors.Join(errs...)
+}
+
+// Allows reports whether a session may move from a sourceType chat to a
+// targetType chat.
+func (p *TransferPolicy) Allows(sourceType, targetType string) bool {
+	if p == nil {
+		return true
+	}
+	for _, r := range p.rules {
+		if (r[0] == "*" || r[0] == sourceType) && (r[1] == "*" || r[1] == targetType) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTransferPolicy restricts /resume and /handoff transfers to the chat
+// type combinations policy allows.
+func (h *Handler) SetTransferPolicy(policy *TransferPolicy) {
+	h.transferPolicy = policy
+}
+
+// formatTransferRefusal explains why a transfer was refused.
+func formatTransferRefusal(sourceType, targetType string) string {
+	return fmt.Sprintf("🚫 Sessions can't be transferred from a %s chat to a %s chat.\n\n"+
+		"Ask an admin if this transfer should be allowed.", chatTypeLabel(sourceType), chatTypeLabel(targetType))
+}
+
+// chatTypeLabel names a stored chat type for users.
+func chatTypeLabel(chatType string) string {
+	if chatType == "" {
+		return "unknown"
+	}
+	return chatType
+}
@@ -0,0 +1,152 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestTransferPolicy_Allows(t *testing.T) {
+	policy, err := ParseTransferPolicy([]string{"private->group", "group -> private", "*->private"})
+	if err != nil {
+		t.Fatalf("ParseTransferPolicy() error = %v", err)
+	}
+
+	tests := []struct {
+		source, target string
+		want           bool
+	}{
+		{"private", "group", true},
+		{"group", "private", true},
+		{"channel", "private", true}, // via wildcard
+		{"private", "private", true},
+		{"group", "group", false},
+		{"private", "channel", false},
+		{"channel", "group", false},
+	}
+	for _, tt := range tests {
+		if got := policy.Allows(tt.source, tt.target); got != tt.want {
+			t.Errorf("Allows(%s, %s) = %v, want %v", tt.source, tt.target, got, tt.want)
+		}
+	}
+
+	var none *TransferPolicy
+	if !none.Allows("channel", "group") {
+		t.Error("A nil policy should allow every transfer")
+	}
+}
+
+func TestParseTransferPolicy_Invalid(t *testing.T) {
+	policy, err := ParseTransferPolicy([]string{"private->group", "supergroup->private", "private"})
+	if err == nil || !strings.Contains(err.Error(), "supergroup->private") || !strings.Contains(err.Error(), `"private"`) {
+		t.Errorf("Expected both invalid rules to be reported, got %v", err)
+	}
+	if !policy.Allows("private", "group") {
+		t.Error("Valid rules should still be applied")
+	}
+}
+
+func TestResume_TransferPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		sourceType string
+		targetType messaging.ChatType
+		wantMoved  bool
+	}{
+		{"group_to_private_allowed", "group", messaging.ChatTypePrivate, true},
+		{"channel_to_private_refused", "channel", messaging.ChatTypePrivate, false},
+		{"private_to_channel_refused", "private", messaging.ChatTypeChannel, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, cleanup := setupTestStorage(t)
+			defer cleanup()
+
+			_, _ = store.CreateContext("source", tt.sourceType, "session-1", time.Hour)
+			_ = store.UpdateClaudeSessionID("source", "claude-1")
+			_ = store.SaveMessage("source", "session-1", "alice", "", storage.RoleUser, "why is checkout down?")
+
+			sm := claude.NewSessionManager("/nonexistent", t.TempDir(), "", 2, time.Second)
+			platform := newFakePlatform()
+			platform.chatType = tt.targetType
+			h := NewHandler(platform, context.NewManager(store, nil, time.Hour), nil, nil, sm, nil, nil, store, []string{"source", "target"})
+			policy, _ := ParseTransferPolicy([]string{"private->group", "group->private"})
+			h.SetTransferPolicy(policy)
+
+			msg := &messaging.IncomingMessage{ChatID: "target", From: messaging.User{ID: "alice"}}
+			if err := h.dispatchCommand(msg, []string{"/resume", "claude-1"}); err != nil {
+				t.Fatalf("dispatchCommand() error = %v", err)
+			}
+
+			target, _ := store.GetContext("target")
+			moved := target != nil && target.ClaudeSessionID == "claude-1"
+			if moved != tt.wantMoved {
+				t.Fatalf("Session moved = %v, want %v (replies %v)", moved, tt.wantMoved, platform.sentTexts())
+			}
+			if !tt.wantMoved {
+				texts := platform.sentTexts()
+				if len(texts) != 1 || !strings.Contains(texts[0], "can't be transferred from a "+tt.sourceType+" chat") {
+					t.Errorf("Expected a refusal naming the chat types, got %v", texts)
+				}
+				if source, _ := store.GetContext("source"); source == nil || !source.IsActive {
+					t.Error("Refused transfer must leave the source session active")
+				}
+			}
+		})
+	}
+}
+
+func TestResume_RequiresSourceParticipantOrAdmin(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    string
+		wantMoved bool
+	}{
+		{"participant", "alice", true},
+		{"admin", "admin", true},
+		{"outsider", "mallory", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, cleanup := setupTestStorage(t)
+			defer cleanup()
+
+			_, _ = store.CreateContext("source", "group", "session-1", time.Hour)
+			_ = store.UpdateClaudeSessionID("source", "claude-1")
+			_ = store.SaveMessage("source", "session-1", "alice", "", storage.RoleUser, "why is checkout down?")
+
+			sm := claude.NewSessionManager("/nonexistent", t.TempDir(), "", 2, time.Second)
+			platform := newFakePlatform()
+			platform.chatType = messaging.ChatTypeGroup
+			h := NewHandler(platform, context.NewManager(store, nil, time.Hour), nil, nil, sm, nil, nil, store, []string{"source", "target"})
+			h.SetAdminIDs([]string{"admin"})
+
+			msg := &messaging.IncomingMessage{ChatID: "target", From: messaging.User{ID: tt.userID}}
+			if err := h.dispatchCommand(msg, []string{"/resume", "claude-1"}); err != nil {
+				t.Fatalf("dispatchCommand() error = %v", err)
+			}
+
+			target, _ := store.GetContext("target")
+			moved := target != nil && target.ClaudeSessionID == "claude-1"
+			if moved != tt.wantMoved {
+				t.Fatalf("Session moved = %v, want %v (replies %v)", moved, tt.wantMoved, platform.sentTexts())
+			}
+			if !tt.wantMoved {
+				texts := platform.sentTexts()
+				if len(texts) != 1 || !strings.Contains(texts[0], "chats you took part in") {
+					t.Errorf("Expected a refusal, got %v", texts)
+				}
+				if source, _ := store.GetContext("source"); source == nil || !source.IsActive {
+					t.Error("Refused transfer must leave the source session active")
+				}
+			}
+		})
+	}
+}
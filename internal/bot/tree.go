@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// treeStatusLabels describes how each session in /tree output ended.
+var treeStatusLabels = map[string]string{
+	"active":    "active",
+	"transfer":  "transferred",
+	"expired":   "expired",
+	"manual":    "reset",
+	"error":     "cleaned up after error",
+	"reconcile": "deactivated as duplicate",
+	"fork":      "forked",
+	"inactive":  "inactive",
+}
+
+// SetSessionTreeEnabled registers the admin /tree command.
+func (h *Handler) SetSessionTreeEnabled(enabled bool) {
+	if !enabled {
+		return
+	}
+	if _, exists := h.commands.Lookup("/tree"); exists {
+		return
+	}
+	h.commands.Register(CommandHandler{
+		Name:        "/tree",
+		Description: "Show how a session branched across chats through transfers and forks",
+		AdminOnly:   true,
+		ReadOnly:    true,
+		Handler: func(msg *messaging.IncomingMessage, fields []string) error {
+			return h.handleTreeCommand(msg.ChatID, fields, msg.MessageID)
+		},
+	})
+}
+
+// handleTreeCommand handles /tree [claude_session_id]. Without an argument
+// it shows the tree of this chat's session.
+func (h *Handler) handleTreeCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /tree command", "chat_id", chatID, "args", fields)
+
+	var ctx *storage.ChatContext
+	var err error
+	if len(fields) >= 2 {
+		ctx, err = h.storage.GetContextByClaudeSessionID(fields[1])
+	} else {
+		ctx, err = h.storage.GetContext(chatID)
+	}
+	if err != nil {
+		slog.Error("Failed to look up session for tree", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to look up session.", replyToMessageID)
+	}
+	if ctx == nil {
+		return h.sendText(chatID, "❌ No session found.\n\nUsage: `/tree [claude_session_id]`", replyToMessageID)
+	}
+
+	tree, err := h.storage.GetSessionTree(ctx.SessionID)
+	if err != nil {
+		slog.Error("Failed to get session tree", "chat_id", chatID, "session_id", ctx.SessionID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session tree.", replyToMessageID)
+	}
+
+	_, err = h.sendChunks(chatID, formatSessionTree(tree, ctx.SessionID), replyToMessageID, true)
+	return err
+}
+
+// formatSessionTree renders tree as indented plain text, one session per
+// line, marking the session the tree was requested for.
+func formatSessionTree(tree *storage.SessionNode, current string) string {
+	var b strings.Builder
+	b.WriteString("🌳 Session tree\n\n")
+	writeSessionNode(&b, tree, current, "", "")
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writeSessionNode writes node after prefix, then its children indented
+// under childPrefix with box-drawing connectors.
+func writeSessionNode(b *strings.Builder, node *storage.SessionNode, current, prefix, childPrefix string) {
+	chat := "chat " + node.ChatID
+	if node.ChatID == "" {
+		chat = "unknown chat"
+	}
+	status, ok := treeStatusLabels[node.Status]
+	if !ok {
+		status = node.Status
+	}
+	b.WriteString(fmt.Sprintf("%s%s · %s · %s", prefix, chat, shortSessionID(node.SessionID), status))
+	if node.SessionID == current {
+		b.WriteString(" ◀")
+	}
+	b.WriteString("\n")
+
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			writeSessionNode(b, child, current, childPrefix+"└─ ", childPrefix+"   ")
+		} else {
+			writeSessionNode(b, child, current, childPrefix+"├─ ", childPrefix+"│  ")
+		}
+	}
+}
+
+// shortSessionID abbreviates a session ID to its first 8 characters.
+func shortSessionID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
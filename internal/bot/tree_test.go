@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/storage"
+)
+
+func TestFormatSessionTree(t *testing.T) {
+	tree := &storage.SessionNode{SessionID: "aaaaaaaa-1", ChatID: "100", Status: "transfer", Children: []*storage.SessionNode{
+		{SessionID: "bbbbbbbb-2", ChatID: "-200", Status: "transfer", Children: []*storage.SessionNode{
+			{SessionID: "cccccccc-3", ChatID: "-300", Status: "active"},
+		}},
+		{SessionID: "dddddddd-4", Status: "inactive"},
+	}}
+
+	got := formatSessionTree(tree, "cccccccc-3")
+	want := strings.Join([]string{
+		"🌳 Session tree",
+		"",
+		"chat 100 · aaaaaaaa · transferred",
+		"├─ chat -200 · bbbbbbbb · transferred",
+		"│  └─ chat -300 · cccccccc · active ◀",
+		"└─ unknown chat · dddddddd · inactive",
+	}, "\n")
+	if got != want {
+		t.Errorf("formatSessionTree() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTreeCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "private", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("1", "claude-abc")
+	if _, err := store.TransferSession("1", "2", "group", "session-2", time.Hour); err != nil {
+		t.Fatalf("TransferSession failed: %v", err)
+	}
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, nil, store, []string{"1", "2"})
+	h.SetAdminIDs([]string{"admin"})
+	h.SetSessionTreeEnabled(true)
+
+	admin := messaging.User{ID: "admin"}
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "2", From: admin}, []string{"/tree"})
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "3", From: admin}, []string{"/tree", "claude-abc"})
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "3", From: admin}, []string{"/tree"})
+	_ = h.dispatchCommand(&messaging.IncomingMessage{ChatID: "2", From: messaging.User{ID: "user"}}, []string{"/tree"})
+
+	texts := platform.sentTexts()
+	if len(texts) != 4 {
+		t.Fatalf("Expected 4 replies, got %d: %v", len(texts), texts)
+	}
+	for i := 0; i < 2; i++ {
+		if !strings.Contains(texts[i], "chat 1 · session- · transferred\n└─ chat 2 · session- · active ◀") {
+			t.Errorf("Reply %d = %q, want the transfer tree", i, texts[i])
+		}
+	}
+	if !strings.Contains(texts[2], "No session found") {
+		t.Errorf("Reply without a session = %q", texts[2])
+	}
+	if strings.Contains(texts[3], "Session tree") {
+		t.Errorf("/tree should be admin-only, got %q", texts[3])
+	}
+}
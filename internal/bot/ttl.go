@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	minChatTTL = time.Minute
+	maxChatTTL = 7 * 24 * time.Hour
+)
+
+// handleTTLCommand handles /ttl [duration|default]. Without an argument it
+// reports the chat's effective session TTL.
+func (h *Handler) handleTTLCommand(chatID string, fields []string, replyToMessageID string) error {
+	slog.Info("Processing /ttl command", "chat_id", chatID, "args", fields)
+
+	ctx, err := h.storage.GetContext(chatID)
+	if err != nil {
+		slog.Error("Failed to get context for /ttl", "chat_id", chatID, "error", err)
+		return h.sendError(chatID, "Failed to retrieve session.", replyToMessageID)
+	}
+	if ctx == nil {
+		return h.sendText(chatID, "❌ No session yet. Send a message first, then set its TTL.", replyToMessageID)
+	}
+
+	if len(fields) < 2 {
+		ttl, overridden, err := h.contextManager.EffectiveTTL(chatID)
+		if err != nil {
+			slog.Error("Failed to get ttl", "chat_id", chatID, "error", err)
+			return h.sendError(chatID, "Failed to retrieve session TTL.", replyToMessageID)
+		}
+		source := "default"
+		if overridden {
+			source = "set for this chat"
+		}
+		return h.sendText(chatID, fmt.Sprintf("⏱ *Session TTL:* %s (%s)\n\nUse `/ttl <duration>` (e.g. `/ttl 8h`) to change it or `/ttl default` to reset.",
+			formatDuration(ttl), source), replyToMessageID)
+	}
+
+	var ttl time.Duration
+	if fields[1] != "default" {
+		ttl, err = time.ParseDuration(fields[1])
+		if err != nil || ttl < minChatTTL || ttl > maxChatTTL {
+			return h.sendText(chatID, fmt.Sprintf("❌ Invalid duration `%s`. Use a value between %s and %s, e.g. `/ttl 8h`.",
+				fields[1], formatDuration(minChatTTL), formatDuration(maxChatTTL)), replyToMessageID)
+		}
+	}
+
+	if err := h.contextManager.SetTTL(chatID, ttl); err != nil {
+		slog.Error("Failed to set ttl", "chat_id", chatID, "ttl", ttl, "error", err)
+		return h.sendError(chatID, "Failed to update session TTL.", replyToMessageID)
+	}
+
+	if ttl == 0 {
+		return h.sendText(chatID, fmt.Sprintf("✅ Session TTL reset to the default (%s).", formatDuration(h.contextManager.GetTTL())), replyToMessageID)
+	}
+	return h.sendText(chatID, fmt.Sprintf("✅ Session TTL set to %s for this chat.", formatDuration(ttl)), replyToMessageID)
+}
@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/context"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestTTLCommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("1", "group", "session-1", 2*time.Hour)
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, context.NewManager(store, nil, 2*time.Hour), nil, nil, nil, nil, nil, store, []string{"1"})
+	msg := &messaging.IncomingMessage{ChatID: "1"}
+
+	for _, args := range [][]string{
+		{"/ttl"},
+		{"/ttl", "8h"},
+		{"/ttl"},
+		{"/ttl", "30s"},
+		{"/ttl", "default"},
+		{"/ttl"},
+	} {
+		if err := h.dispatchCommand(msg, args); err != nil {
+			t.Fatalf("dispatchCommand(%v) error = %v", args, err)
+		}
+	}
+
+	texts := platform.sentTexts()
+	want := []string{
+		"2h (default)",
+		"set to 8h",
+		"8h (set for this chat)",
+		"Invalid duration",
+		"reset to the default (2h)",
+		"2h (default)",
+	}
+	if len(texts) != len(want) {
+		t.Fatalf("Expected %d replies, got %v", len(want), texts)
+	}
+	for i, w := range want {
+		if !strings.Contains(texts[i], w) {
+			t.Errorf("reply %d = %q, want it to contain %q", i, texts[i], w)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// handleWhoAmICommand reports the caller's IDs and how they matched the
+// whitelist. Only the caller's own match is shown, never the whitelist.
+func (h *Handler) handleWhoAmICommand(msg *messaging.IncomingMessage) error {
+	slog.Info("Processing /whoami command", "chat_id", msg.ChatID, "user_id", msg.From.ID)
+	return h.sendText(msg.ChatID, h.formatWhoAmI(msg), msg.MessageID)
+}
+
+// formatWhoAmI renders the /whoami report for msg.
+func (h *Handler) formatWhoAmI(msg *messaging.IncomingMessage) string {
+	var b strings.Builder
+	b.WriteString("👤 *Who am I*\n\n")
+	b.WriteString(fmt.Sprintf("*User ID:* `%s`", msg.From.ID))
+	if msg.From.Username != "" {
+		b.WriteString(fmt.Sprintf(" (`@%s`)", msg.From.Username))
+	}
+	b.WriteString(fmt.Sprintf("\n*Chat ID:* `%s`\n", msg.ChatID))
+	chatType := msg.ChatType
+	if chatType == "" {
+		chatType = "unknown"
+	}
+	b.WriteString(fmt.Sprintf("*Chat type:* %s\n", chatType))
+
+	byChat, byUser := h.allowList.Match(msg.ChatID, msg.From.ID)
+	switch {
+	case byUser && byChat:
+		b.WriteString("*Authorized:* ✅ yes, by user ID and chat ID")
+	case byUser:
+		b.WriteString("*Authorized:* ✅ yes, by user ID")
+	case byChat:
+		b.WriteString("*Authorized:* ✅ yes, by chat ID")
+	default:
+		b.WriteString("*Authorized:* ❌ no, neither ID is whitelisted")
+	}
+	if h.isAdmin(msg) {
+		b.WriteString("\n*Admin:* yes")
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestWhoAmICommand(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	platform := newFakePlatform()
+	h := NewHandler(platform, nil, nil, nil, nil, nil, newTestSanitizer(t), store, []string{"group-1", "alice", "secret-chat"})
+	h.SetAdminIDs([]string{"alice"})
+
+	tests := []struct {
+		name string
+		msg  *messaging.IncomingMessage
+		want []string
+	}{
+		{
+			"by chat",
+			&messaging.IncomingMessage{ChatID: "group-1", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "bob", Username: "bob_b"}},
+			[]string{"*User ID:* `bob` (`@bob_b`)", "*Chat ID:* `group-1`", "*Chat type:* group", "yes, by chat ID"},
+		},
+		{
+			"by user and chat",
+			&messaging.IncomingMessage{ChatID: "group-1", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "alice"}},
+			[]string{"yes, by user ID and chat ID", "*Admin:* yes"},
+		},
+		{
+			"by user",
+			&messaging.IncomingMessage{ChatID: "group-2", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "alice"}},
+			[]string{"yes, by user ID"},
+		},
+		{
+			"rejected",
+			&messaging.IncomingMessage{ChatID: "group-2", ChatType: messaging.ChatTypeGroup, From: messaging.User{ID: "bob"}},
+			[]string{"❌ no", "*Chat ID:* `group-2`"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.msg.Text = "/whoami"
+			before := len(platform.sent)
+			if err := h.HandleMessage(tt.msg); err != nil {
+				t.Fatalf("HandleMessage() error = %v", err)
+			}
+			texts := platform.sentTexts()
+			if len(texts) != before+1 {
+				t.Fatalf("Expected one reply, got %v", texts[before:])
+			}
+			reply := texts[before]
+			for _, want := range tt.want {
+				if !strings.Contains(reply, want) {
+					t.Errorf("Reply missing %q:\n%s", want, reply)
+				}
+			}
+			if strings.Contains(reply, "secret-chat") {
+				t.Errorf("Reply leaks the whitelist:\n%s", reply)
+			}
+		})
+	}
+}
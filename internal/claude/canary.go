@@ -0,0 +1,34 @@
+package claude
+
+import "math/rand"
+
+// Canary routes a share of queries through an alternate model and/or system
+// prompt so its answers can be compared with the default ones.
+type Canary struct {
+	Percent int    // Share of queries sent to the canary, 0-100
+	Model   string // Model for canary queries; empty keeps the default
+	Prompt  string // Extra system prompt for canary queries
+}
+
+// SetCanary enables canary routing. A zero Percent disables it.
+func (e *Executor) SetCanary(canary Canary) {
+	e.canary = canary
+	if e.roll == nil {
+		e.roll = rand.Intn
+	}
+}
+
+// pickOptions decides whether the next query goes to the canary and returns
+// the options to run it with, starting from the chat's own. The canary model
+// takes precedence over the chat's.
+func (e *Executor) pickOptions(chat QueryOptions) (QueryOptions, bool) {
+	opts := chat
+	if e.canary.Percent <= 0 || e.roll(100) >= e.canary.Percent {
+		return opts, false
+	}
+	if e.canary.Model != "" {
+		opts.Model = e.canary.Model
+	}
+	opts.SystemPrompt = e.canary.Prompt
+	return opts, true
+}
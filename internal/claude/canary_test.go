@@ -0,0 +1,87 @@
+package claude
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecutor_CanaryFraction(t *testing.T) {
+	e := NewExecutor(nil, "", 0)
+	if _, canary := e.pickOptions(QueryOptions{}); canary {
+		t.Fatal("Canary should be disabled by default")
+	}
+
+	e.SetCanary(Canary{Percent: 20, Model: "opus", Prompt: "be brief"})
+	e.roll = rand.New(rand.NewSource(1)).Intn
+
+	const total = 10000
+	picked := 0
+	for i := 0; i < total; i++ {
+		opts, canary := e.pickOptions(QueryOptions{})
+		if !canary {
+			continue
+		}
+		picked++
+		if opts.Model != "opus" || opts.SystemPrompt != "be brief" {
+			t.Fatalf("canary opts = %+v", opts)
+		}
+	}
+	if got := float64(picked) / total; got < 0.18 || got > 0.22 {
+		t.Errorf("canary share = %.3f, want ~0.20", got)
+	}
+}
+
+func TestExecutor_CanaryQueryArgs(t *testing.T) {
+	cli := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho \"args: $*\"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := NewSessionManager(cli, t.TempDir(), "sonnet", 1, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("1", "s1"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+	e := NewExecutor(sm, "", 0)
+
+	e.SetCanary(Canary{Percent: 100, Model: "opus", Prompt: "be brief"})
+	resp, err := e.Execute("s1", "hello", "", QueryOptions{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !resp.Canary || !strings.Contains(resp.Result, "--model opus --append-system-prompt be brief") {
+		t.Errorf("canary response = %+v", resp)
+	}
+
+	e.SetCanary(Canary{})
+	resp, err = e.Execute("s1", "hello", "", QueryOptions{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp.Canary || !strings.Contains(resp.Result, "--model sonnet") || strings.Contains(resp.Result, "--append-system-prompt") {
+		t.Errorf("default response = %+v", resp)
+	}
+
+	resp, err = e.Execute("s1", "hello", "", QueryOptions{Model: "haiku"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !strings.Contains(resp.Result, "--model haiku") || strings.Contains(resp.Result, "--model sonnet") {
+		t.Errorf("chat model response = %+v", resp)
+	}
+}
+
+func TestExecutor_CanaryModelOverridesChatModel(t *testing.T) {
+	e := NewExecutor(nil, "", 0)
+	e.SetCanary(Canary{Percent: 100, Prompt: "be brief"})
+	if opts, _ := e.pickOptions(QueryOptions{Model: "haiku"}); opts.Model != "haiku" {
+		t.Errorf("Prompt-only canary should keep the chat model, got %q", opts.Model)
+	}
+
+	e.SetCanary(Canary{Percent: 100, Model: "opus"})
+	if opts, _ := e.pickOptions(QueryOptions{Model: "haiku"}); opts.Model != "opus" {
+		t.Errorf("Canary model should replace the chat model, got %q", opts.Model)
+	}
+}
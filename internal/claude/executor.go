@@ -1,6 +1,7 @@
 package claude
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"time"
@@ -10,6 +11,11 @@ import (
 // Configuration (projectPath, timeout) is managed by SessionManager.
 type Executor struct {
 	sm *SessionManager
+
+	canary Canary
+	roll   func(n int) int // Returns a value in [0, n) for canary selection
+
+	tracing bool // Give each query a trace ID
 }
 
 // NewExecutor creates a new Executor. The projectPath and timeout parameters
@@ -24,17 +30,69 @@ func NewExecutor(sm *SessionManager, projectPath string, timeout time.Duration)
 	}
 }
 
-func (e *Executor) Execute(sessionID, query string, claudeSessionID string) (*ClaudeJSONOutput, error) {
+// Execute runs query in the session. Set fields of chat, such as the chat's
+// model or project directory, replace the configured ones for this query.
+func (e *Executor) Execute(sessionID, query, claudeSessionID string, chat QueryOptions) (*ClaudeJSONOutput, error) {
+	logQuery := query
+	if len(logQuery) > 100 {
+		logQuery = logQuery[:100] + "..."
+	}
+	opts, canary := e.pickOptions(chat)
+	opts.TraceID = e.newTraceID()
+	slog.Info("Executing query", "session_id", sessionID, "query", logQuery, "model", opts.Model, "canary", canary, "trace_id", opts.TraceID)
+
+	response, err := e.sm.ExecuteQueryWith(sessionID, query, claudeSessionID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("execution failed: %w", err)
+	}
+	response.Canary = canary
+	response.TraceID = opts.TraceID
+
+	return response, nil
+}
+
+// ExecuteStream runs a query like Execute, reporting partial answer text to
+// onPartial as Claude produces it.
+func (e *Executor) ExecuteStream(sessionID, query, claudeSessionID string, chat QueryOptions, onPartial PartialHandler) (*ClaudeJSONOutput, error) {
 	logQuery := query
 	if len(logQuery) > 100 {
 		logQuery = logQuery[:100] + "..."
 	}
-	slog.Info("Executing query", "session_id", sessionID, "query", logQuery)
+	opts, canary := e.pickOptions(chat)
+	opts.TraceID = e.newTraceID()
+	slog.Info("Executing streamed query", "session_id", sessionID, "query", logQuery, "model", opts.Model, "canary", canary, "trace_id", opts.TraceID)
 
-	response, err := e.sm.ExecuteQuery(sessionID, query, claudeSessionID)
+	response, err := e.sm.ExecuteQueryStreamWith(sessionID, query, claudeSessionID, opts, onPartial)
 	if err != nil {
 		return nil, fmt.Errorf("execution failed: %w", err)
 	}
+	response.Canary = canary
+	response.TraceID = opts.TraceID
 
 	return response, nil
 }
+
+// ExecuteOneShot runs prompt in a fresh conversation outside any session and
+// returns Claude's answer, for quick side tasks like classifying a query. A
+// non-empty model replaces the configured one. It gives up when ctx is done.
+func (e *Executor) ExecuteOneShot(ctx context.Context, prompt, model string) (string, error) {
+	response, err := e.sm.ExecuteOneShot(ctx, prompt, QueryOptions{Model: model})
+	if err != nil {
+		return "", fmt.Errorf("one-shot execution failed: %w", err)
+	}
+	return response.Result, nil
+}
+
+// ForkConversation copies the Claude conversation claudeSessionID into a new
+// one, telling Claude about the fork with note, and returns the new
+// conversation's ID. Queries resuming either one don't affect the other. A
+// non-empty projectPath is the directory the conversation was started in. It
+// gives up when ctx is done.
+func (e *Executor) ForkConversation(ctx context.Context, claudeSessionID, projectPath, note string) (string, error) {
+	forkedID, err := e.sm.ForkConversation(ctx, claudeSessionID, note, QueryOptions{ProjectPath: projectPath})
+	if err != nil {
+		return "", fmt.Errorf("fork failed: %w", err)
+	}
+	slog.Info("Forked Claude conversation", "claude_session_id", claudeSessionID, "forked_claude_session_id", forkedID)
+	return forkedID, nil
+}
@@ -0,0 +1,103 @@
+package claude
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// CLIValidator verifies the Claude CLI is usable.
+type CLIValidator interface {
+	ValidateCLI() error
+}
+
+// HealthStatus is a snapshot of the most recent CLI health check.
+type HealthStatus struct {
+	Healthy   bool
+	LastError error
+	LastCheck time.Time
+}
+
+// HealthChecker periodically re-validates the Claude CLI so problems such as
+// expired auth surface before a user hits them.
+type HealthChecker struct {
+	validator CLIValidator
+	interval  time.Duration
+	onChange  func(status HealthStatus)
+
+	mu     sync.RWMutex
+	status HealthStatus
+}
+
+// NewHealthChecker creates a checker that starts out healthy, since the CLI
+// is validated once at startup before the checker runs.
+func NewHealthChecker(validator CLIValidator, interval time.Duration) *HealthChecker {
+	return &HealthChecker{
+		validator: validator,
+		interval:  interval,
+		status:    HealthStatus{Healthy: true, LastCheck: time.Now()},
+	}
+}
+
+// SetOnChange registers a callback invoked when health flips between
+// healthy and unhealthy. Must be called before Start.
+func (hc *HealthChecker) SetOnChange(fn func(status HealthStatus)) {
+	hc.onChange = fn
+}
+
+// Start runs health checks until ctx is cancelled.
+func (hc *HealthChecker) Start(ctx context.Context) {
+	ticker := time.NewTicker(hc.interval)
+	defer ticker.Stop()
+
+	slog.Info("Starting Claude CLI health checker", "interval", hc.interval)
+
+	for {
+		select {
+		case <-ticker.C:
+			hc.Check()
+		case <-ctx.Done():
+			slog.Info("Claude CLI health checker stopped")
+			return
+		}
+	}
+}
+
+// Check validates the CLI once, updates the status and fires the change
+// callback on transitions. Returns the new status.
+func (hc *HealthChecker) Check() HealthStatus {
+	err := hc.validator.ValidateCLI()
+	status := HealthStatus{Healthy: err == nil, LastError: err, LastCheck: time.Now()}
+
+	hc.mu.Lock()
+	changed := hc.status.Healthy != status.Healthy
+	hc.status = status
+	hc.mu.Unlock()
+
+	if changed {
+		if status.Healthy {
+			slog.Info("Claude CLI is healthy again")
+		} else {
+			slog.Error("Claude CLI became unhealthy", "error", err)
+		}
+		if hc.onChange != nil {
+			hc.onChange(status)
+		}
+	}
+
+	return status
+}
+
+// Status returns the most recent health status.
+func (hc *HealthChecker) Status() HealthStatus {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.status
+}
+
+// Ready returns the last check error, or nil when healthy. Suitable for
+// readiness probes.
+func (hc *HealthChecker) Ready() error {
+	return hc.Status().LastError
+}
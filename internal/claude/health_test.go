@@ -0,0 +1,61 @@
+package claude
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeValidator returns the configured error from ValidateCLI.
+type fakeValidator struct {
+	err error
+}
+
+func (f *fakeValidator) ValidateCLI() error {
+	return f.err
+}
+
+func TestHealthChecker_Transitions(t *testing.T) {
+	validator := &fakeValidator{}
+	hc := NewHealthChecker(validator, 0)
+
+	var changes []HealthStatus
+	hc.SetOnChange(func(status HealthStatus) {
+		changes = append(changes, status)
+	})
+
+	if !hc.Status().Healthy {
+		t.Fatal("Checker should start healthy")
+	}
+
+	// Healthy -> healthy: no transition
+	hc.Check()
+	if len(changes) != 0 {
+		t.Errorf("Expected no transitions, got %d", len(changes))
+	}
+
+	// Healthy -> unhealthy
+	validator.err = errors.New("auth expired")
+	status := hc.Check()
+	if status.Healthy || hc.Ready() == nil {
+		t.Error("Checker should be unhealthy after failed validation")
+	}
+	if len(changes) != 1 || changes[0].Healthy {
+		t.Fatalf("Expected one unhealthy transition, got %+v", changes)
+	}
+
+	// Unhealthy -> unhealthy: no repeated alert
+	hc.Check()
+	if len(changes) != 1 {
+		t.Errorf("Repeated failures should not fire callback, got %d transitions", len(changes))
+	}
+
+	// Unhealthy -> healthy
+	validator.err = nil
+	hc.Check()
+	if len(changes) != 2 || !changes[1].Healthy {
+		t.Errorf("Expected recovery transition, got %+v", changes)
+	}
+	if hc.Ready() != nil {
+		t.Errorf("Ready() = %v, want nil", hc.Ready())
+	}
+}
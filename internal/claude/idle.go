@@ -0,0 +1,53 @@
+package claude
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// IdleReaper periodically removes sessions that haven't run a query for a
+// while, so sessions of chats that went quiet don't hold
+// max_concurrent_sessions slots until their context expires. A reaped
+// session is recreated on the chat's next query.
+type IdleReaper struct {
+	sm       *SessionManager
+	interval time.Duration
+	maxIdle  time.Duration
+}
+
+// NewIdleReaper creates a reaper that removes sessions idle longer than
+// maxIdle, checking every interval.
+func NewIdleReaper(sm *SessionManager, interval, maxIdle time.Duration) *IdleReaper {
+	return &IdleReaper{sm: sm, interval: interval, maxIdle: maxIdle}
+}
+
+// Start runs the reaper until ctx is cancelled.
+func (r *IdleReaper) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	slog.Info("Starting idle session cleanup", "interval", r.interval, "max_idle", r.maxIdle)
+
+	for {
+		select {
+		case <-ticker.C:
+			r.reap()
+		case <-ctx.Done():
+			slog.Info("Idle session cleanup stopped")
+			return
+		}
+	}
+}
+
+// reap removes idle sessions and returns how many it removed.
+func (r *IdleReaper) reap() int {
+	reaped := r.sm.CleanupIdleSessions(r.maxIdle)
+	if reaped > 0 {
+		slog.Info("Reaped idle sessions",
+			"reaped", reaped,
+			"max_idle", r.maxIdle,
+			"remaining", r.sm.GetActiveSessionCount())
+	}
+	return reaped
+}
@@ -0,0 +1,195 @@
+package claude
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultResourcePatterns recognize the header lines of common kubectl get
+// listings, by resource type.
+var DefaultResourcePatterns = map[string]string{
+	"pods":         `^NAME\s+READY\s+STATUS\s+RESTARTS\s+AGE`,
+	"deployments":  `^NAME\s+READY\s+UP-TO-DATE\s+AVAILABLE\s+AGE`,
+	"statefulsets": `^NAME\s+READY\s+AGE$`,
+	"nodes":        `^NAME\s+STATUS\s+ROLES\s+AGE\s+VERSION`,
+	"services":     `^NAME\s+TYPE\s+CLUSTER-IP\s+EXTERNAL-IP\s+PORT\(S\)`,
+	"pvcs":         `^NAME\s+STATUS\s+VOLUME\s+CAPACITY`,
+	"jobs":         `^NAME\s+(STATUS\s+)?COMPLETIONS\s+DURATION\s+AGE`,
+}
+
+// healthyStatuses are resource statuses that need no attention.
+var healthyStatuses = map[string]bool{
+	"Running":   true,
+	"Completed": true,
+	"Succeeded": true,
+	"Complete":  true,
+	"Ready":     true,
+	"Bound":     true,
+	"Active":    true,
+}
+
+// readyCount matches a READY column such as "1/1" or "2/3".
+var readyCount = regexp.MustCompile(`^(\d+)/(\d+)$`)
+
+// Resource is one row of a kubectl-style listing.
+type Resource struct {
+	Namespace string // Empty unless the listing had a NAMESPACE column
+	Name      string
+	// Status is the STATUS column, or "Ready"/"NotReady" derived from a READY
+	// column like "1/2". Empty when the listing has neither.
+	Status string
+}
+
+// Healthy reports whether the resource's status needs no attention.
+// Resources without a status count as healthy.
+func (r Resource) Healthy() bool {
+	return r.Status == "" || healthyStatuses[r.Status]
+}
+
+// ResourceTable lists the resources of one type found in a response.
+type ResourceTable struct {
+	Type      string
+	Resources []Resource
+}
+
+// ResourceExtractor finds kubectl get listings in text. Each listing's type
+// is recognized by matching its header line, without a leading NAMESPACE
+// column, against a pattern.
+type ResourceExtractor struct {
+	types    []string // Sorted, so overlapping patterns match predictably
+	patterns map[string]*regexp.Regexp
+}
+
+// NewResourceExtractor returns an extractor for DefaultResourcePatterns plus
+// patterns, which add resource types or replace the default for a type.
+func NewResourceExtractor(patterns map[string]string) (*ResourceExtractor, error) {
+	merged := make(map[string]string, len(DefaultResourcePatterns)+len(patterns))
+	for t, p := range DefaultResourcePatterns {
+		merged[t] = p
+	}
+	for t, p := range patterns {
+		merged[t] = p
+	}
+
+	e := &ResourceExtractor{patterns: make(map[string]*regexp.Regexp, len(merged))}
+	for t, p := range merged {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for resource type %q: %w", t, err)
+		}
+		e.types = append(e.types, t)
+		e.patterns[t] = re
+	}
+	sort.Strings(e.types)
+	return e, nil
+}
+
+// Extract returns the resources listed in text, one table per resource type
+// in order of first appearance. A resource listed more than once, say before
+// and after a rollout, keeps its last status.
+func (e *ResourceExtractor) Extract(text string) []ResourceTable {
+	var tables []ResourceTable
+	index := make(map[string]int)           // Type -> position in tables
+	seen := make(map[string]map[string]int) // Type -> namespace/name -> position in Resources
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		columns := strings.Fields(lines[i])
+		if len(columns) < 2 || (columns[0] != "NAME" && columns[0] != "NAMESPACE") {
+			continue
+		}
+		resourceType := e.match(columns)
+		if resourceType == "" {
+			continue
+		}
+
+		rows, next := parseResourceRows(columns, lines[i+1:])
+		i += next
+		if len(rows) == 0 {
+			continue
+		}
+
+		pos, ok := index[resourceType]
+		if !ok {
+			pos = len(tables)
+			index[resourceType] = pos
+			tables = append(tables, ResourceTable{Type: resourceType})
+			seen[resourceType] = make(map[string]int)
+		}
+		for _, r := range rows {
+			key := r.Namespace + "/" + r.Name
+			if at, dup := seen[resourceType][key]; dup {
+				tables[pos].Resources[at] = r
+				continue
+			}
+			seen[resourceType][key] = len(tables[pos].Resources)
+			tables[pos].Resources = append(tables[pos].Resources, r)
+		}
+	}
+	return tables
+}
+
+// match returns the resource type whose pattern matches the header columns,
+// or "" if none does.
+func (e *ResourceExtractor) match(columns []string) string {
+	if columns[0] == "NAMESPACE" {
+		columns = columns[1:]
+	}
+	header := strings.Join(columns, "   ")
+	for _, t := range e.types {
+		if e.patterns[t].MatchString(header) {
+			return t
+		}
+	}
+	return ""
+}
+
+// parseResourceRows reads the rows under a listing's header, stopping at the
+// first blank line, code fence or next header. It returns the rows and how
+// many lines it consumed.
+func parseResourceRows(header []string, lines []string) ([]Resource, int) {
+	nameCol, statusCol, readyCol := 0, -1, -1
+	namespaced := header[0] == "NAMESPACE"
+	if namespaced {
+		nameCol = 1
+	}
+	for i, column := range header {
+		switch column {
+		case "STATUS":
+			statusCol = i
+		case "READY":
+			readyCol = i
+		}
+	}
+
+	var rows []Resource
+	n := 0
+	for ; n < len(lines); n++ {
+		fields := strings.Fields(lines[n])
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "```") || fields[0] == "NAME" || fields[0] == "NAMESPACE" {
+			break
+		}
+
+		r := Resource{Name: fields[nameCol]}
+		if namespaced {
+			r.Namespace = fields[0]
+		}
+		// Columns after STATUS may hold spaces (-o wide), so only the ones
+		// up to it are located by position
+		switch {
+		case statusCol >= 0 && statusCol < len(fields):
+			r.Status = fields[statusCol]
+		case readyCol >= 0 && readyCol < len(fields):
+			if m := readyCount.FindStringSubmatch(fields[readyCol]); m != nil {
+				r.Status = "NotReady"
+				if m[1] == m[2] {
+					r.Status = "Ready"
+				}
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows, n
+}
@@ -0,0 +1,90 @@
+package claude
+
+import (
+	"reflect"
+	"testing"
+)
+
+const samplePodsAnswer = "Here are the pods in `payments`:\n\n" +
+	"```\n" +
+	"NAME                          READY   STATUS             RESTARTS      AGE\n" +
+	"api-7d9f8b6c5d-abcde          1/1     Running            0             2d\n" +
+	"api-7d9f8b6c5d-fghij          1/1     Running            0             2d\n" +
+	"worker-5c6d7f8b9-xyz12        0/1     CrashLoopBackOff   5 (2m ago)    1h\n" +
+	"migrate-28491234-q8w7e        0/1     Completed          0             3h\n" +
+	"```\n\n" +
+	"The worker keeps crashing."
+
+func TestResourceExtractor_Pods(t *testing.T) {
+	e, err := NewResourceExtractor(nil)
+	if err != nil {
+		t.Fatalf("NewResourceExtractor() error = %v", err)
+	}
+
+	want := []ResourceTable{{
+		Type: "pods",
+		Resources: []Resource{
+			{Name: "api-7d9f8b6c5d-abcde", Status: "Running"},
+			{Name: "api-7d9f8b6c5d-fghij", Status: "Running"},
+			{Name: "worker-5c6d7f8b9-xyz12", Status: "CrashLoopBackOff"},
+			{Name: "migrate-28491234-q8w7e", Status: "Completed"},
+		},
+	}}
+	if got := e.Extract(samplePodsAnswer); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResourceExtractor_MultipleListings(t *testing.T) {
+	e, err := NewResourceExtractor(nil)
+	if err != nil {
+		t.Fatalf("NewResourceExtractor() error = %v", err)
+	}
+
+	text := "NAMESPACE   NAME        READY   STATUS    RESTARTS   AGE\n" +
+		"payments    api-1       1/1     Running   0          2d\n" +
+		"payments    db-0        0/1     Pending   0          5m\n" +
+		"NAME   READY   UP-TO-DATE   AVAILABLE   AGE\n" +
+		"api    2/2     2            2           2d\n" +
+		"db     0/1     1            0           5m\n" +
+		"\n" +
+		"After the restart:\n" +
+		"NAMESPACE   NAME   READY   STATUS    RESTARTS   AGE\n" +
+		"payments    db-0   1/1     Running   0          10s\n"
+
+	want := []ResourceTable{
+		{Type: "pods", Resources: []Resource{
+			{Namespace: "payments", Name: "api-1", Status: "Running"},
+			{Namespace: "payments", Name: "db-0", Status: "Running"},
+		}},
+		{Type: "deployments", Resources: []Resource{
+			{Name: "api", Status: "Ready"},
+			{Name: "db", Status: "NotReady"},
+		}},
+	}
+	if got := e.Extract(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResourceExtractor_CustomPatterns(t *testing.T) {
+	e, err := NewResourceExtractor(map[string]string{"ingresses": `^NAME\s+CLASS\s+HOSTS`})
+	if err != nil {
+		t.Fatalf("NewResourceExtractor() error = %v", err)
+	}
+
+	text := "NAME   CLASS   HOSTS             ADDRESS    PORTS   AGE\n" +
+		"web    nginx   shop.example.com  10.0.0.1   80      1d\n"
+	got := e.Extract(text)
+	if len(got) != 1 || got[0].Type != "ingresses" || got[0].Resources[0].Name != "web" {
+		t.Errorf("Extract() = %+v, want the ingress", got)
+	}
+
+	if got := e.Extract("NAME is just a word here\nnothing to see"); len(got) != 0 {
+		t.Errorf("Extract() of prose = %+v, want nothing", got)
+	}
+
+	if _, err := NewResourceExtractor(map[string]string{"bad": `(`}); err == nil {
+		t.Error("Expected error for an invalid pattern")
+	}
+}
@@ -0,0 +1,37 @@
+package claude
+
+// ToolRepeat describes a tool call that was repeated within one response.
+type ToolRepeat struct {
+	ToolName string
+	Count    int
+}
+
+// DetectToolLoops returns tool calls that appear at least threshold times in
+// tools, in first-seen order. Calls are identical when their parsed tool line
+// (name and input) matches. A threshold below 2 disables detection.
+func DetectToolLoops(tools []ToolExecution, threshold int) []ToolRepeat {
+	if threshold < 2 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, tool := range tools {
+		call := tool.ToolName
+		if tool.Input != "" {
+			call += " " + tool.Input
+		}
+		if counts[call] == 0 {
+			order = append(order, call)
+		}
+		counts[call]++
+	}
+
+	var repeats []ToolRepeat
+	for _, name := range order {
+		if counts[name] >= threshold {
+			repeats = append(repeats, ToolRepeat{ToolName: name, Count: counts[name]})
+		}
+	}
+	return repeats
+}
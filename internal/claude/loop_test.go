@@ -0,0 +1,48 @@
+package claude
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// bashCalls renders one stream-json tool_use event per command.
+func bashCalls(commands ...string) string {
+	var b strings.Builder
+	for i, cmd := range commands {
+		b.WriteString(fmt.Sprintf(`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t%d","name":"Bash","input":{"command":%q}}]}}`+"\n", i, cmd))
+	}
+	return b.String()
+}
+
+func TestDetectToolLoops(t *testing.T) {
+	repeated := bashCalls("kubectl get pods -n prod", "kubectl get pods -n prod", "kubectl get pods -n prod", "kubectl get pods -n prod", "kubectl get pods -n prod")
+	varied := bashCalls("kubectl get pods", "kubectl get svc", "kubectl get nodes", "argocd app list")
+
+	tests := []struct {
+		name      string
+		raw       string
+		threshold int
+		want      []ToolRepeat
+	}{
+		{"identical calls at threshold", repeated, 5, []ToolRepeat{{`Bash {"command":"kubectl get pods -n prod"}`, 5}}},
+		{"identical calls below threshold", repeated, 6, nil},
+		{"varied calls", varied, 2, nil},
+		{"mixed", varied + bashCalls("kubectl get svc", "kubectl get svc"), 3, []ToolRepeat{{`Bash {"command":"kubectl get svc"}`, 3}}},
+		{"disabled", repeated, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectToolLoops(ExtractToolExecutions(tt.raw), tt.threshold)
+			if len(got) != len(tt.want) {
+				t.Fatalf("DetectToolLoops() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("DetectToolLoops()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
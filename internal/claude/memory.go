@@ -0,0 +1,72 @@
+package claude
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// MemoryMonitor periodically checks heap usage and evicts least recently used
+// sessions when it exceeds a threshold. This complements idle cleanup by
+// shedding sessions proactively under memory pressure.
+type MemoryMonitor struct {
+	sm         *SessionManager
+	threshold  uint64
+	interval   time.Duration
+	evictCount int
+	heapInUse  func() uint64
+}
+
+// NewMemoryMonitor creates a monitor that evicts evictCount sessions per check
+// while heap in-use bytes exceed thresholdBytes.
+func NewMemoryMonitor(sm *SessionManager, thresholdBytes uint64, interval time.Duration, evictCount int) *MemoryMonitor {
+	return &MemoryMonitor{
+		sm:         sm,
+		threshold:  thresholdBytes,
+		interval:   interval,
+		evictCount: evictCount,
+		heapInUse:  readHeapInUse,
+	}
+}
+
+// Start runs the monitor until ctx is cancelled.
+func (m *MemoryMonitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	slog.Info("Starting memory monitor", "threshold_bytes", m.threshold, "interval", m.interval)
+
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-ctx.Done():
+			slog.Info("Memory monitor stopped")
+			return
+		}
+	}
+}
+
+// check evicts sessions if heap usage is above the threshold.
+// Returns the number of sessions evicted.
+func (m *MemoryMonitor) check() int {
+	inUse := m.heapInUse()
+	if inUse <= m.threshold {
+		return 0
+	}
+
+	evicted := m.sm.EvictLRU(m.evictCount)
+	slog.Warn("Memory pressure detected, evicted sessions",
+		"heap_in_use", inUse,
+		"threshold", m.threshold,
+		"evicted", len(evicted),
+		"remaining", m.sm.GetActiveSessionCount())
+	return len(evicted)
+}
+
+func readHeapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
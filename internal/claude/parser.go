@@ -1,39 +1,98 @@
 package claude
 
 import (
+	"encoding/json"
 	"strings"
 )
 
 type ToolExecution struct {
 	ToolName string `json:"tool_name"`
 	Status   string `json:"status"`
+	Input    string `json:"input,omitempty"`  // Tool arguments as JSON, when known
+	Output   string `json:"output,omitempty"` // Tool result text, when known
 }
 
+// ExtractToolExecutions returns the tool calls in raw Claude CLI stream-json
+// output, each with the status and output of its matching tool_result.
+// Lines that are not stream-json events are ignored.
 func ExtractToolExecutions(raw string) []ToolExecution {
-	var tools []ToolExecution
+	var tools toolCollector
+	for _, line := range strings.Split(raw, "\n") {
+		var ev streamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &ev); err != nil {
+			continue
+		}
+		tools.add(&ev)
+	}
+	return tools.tools
+}
+
+// toolCollector assembles tool executions from stream-json events, matching
+// each tool_result to the tool_use it answers.
+type toolCollector struct {
+	tools []ToolExecution
+	index map[string]int // tool_use ID -> index in tools
+}
 
-	lines := strings.Split(raw, "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "Tool:") {
-			tool := parseToolLine(line)
-			if tool != nil {
-				tools = append(tools, *tool)
+func (c *toolCollector) add(ev *streamEvent) {
+	switch ev.Type {
+	case "assistant":
+		for _, block := range ev.Message.Content {
+			if block.Type != "tool_use" {
+				continue
+			}
+			if c.index == nil {
+				c.index = make(map[string]int)
+			}
+			c.index[block.ID] = len(c.tools)
+			c.tools = append(c.tools, ToolExecution{
+				ToolName: block.Name,
+				Status:   "success",
+				Input:    string(block.Input),
+			})
+		}
+	case "user":
+		for _, block := range ev.Message.Content {
+			i, ok := c.index[block.ToolUseID]
+			if block.Type != "tool_result" || !ok {
+				continue
+			}
+			c.tools[i].Output = toolResultText(block.Content)
+			if block.IsError {
+				c.tools[i].Status = "error"
 			}
 		}
 	}
-
-	return tools
 }
 
-func parseToolLine(line string) *ToolExecution {
-	parts := strings.SplitN(line, "Tool:", 2)
-	if len(parts) != 2 {
-		return nil
+// toolResultText flattens a tool_result's content, which is either a string
+// or a list of text blocks.
+func toolResultText(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
 	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
 
-	toolInfo := strings.TrimSpace(parts[1])
-	return &ToolExecution{
-		ToolName: toolInfo,
-		Status:   "success",
+// LimitToolExecutions keeps the first max tools and returns how many were
+// dropped. A max of 0 or less keeps everything.
+func LimitToolExecutions(tools []ToolExecution, max int) ([]ToolExecution, int) {
+	if max <= 0 || len(tools) <= max {
+		return tools, 0
 	}
+	return tools[:max], len(tools) - max
 }
@@ -0,0 +1,56 @@
+package claude
+
+import "testing"
+
+func TestLimitToolExecutions(t *testing.T) {
+	tools := []ToolExecution{{ToolName: "a", Status: "success"}, {ToolName: "b", Status: "success"}, {ToolName: "c", Status: "error"}}
+
+	tests := []struct {
+		name        string
+		max         int
+		wantKept    int
+		wantOmitted int
+	}{
+		{"disabled", 0, 3, 0},
+		{"under cap", 5, 3, 0},
+		{"at cap", 3, 3, 0},
+		{"over cap", 2, 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, omitted := LimitToolExecutions(tools, tt.max)
+			if len(kept) != tt.wantKept || omitted != tt.wantOmitted {
+				t.Errorf("LimitToolExecutions(%d) = %d kept, %d omitted; want %d, %d",
+					tt.max, len(kept), omitted, tt.wantKept, tt.wantOmitted)
+			}
+			if len(kept) > 0 && kept[0].ToolName != "a" {
+				t.Errorf("Expected the first tools to be kept, got %v", kept)
+			}
+		})
+	}
+}
+
+func TestExtractToolExecutions(t *testing.T) {
+	raw := `{"type":"system","subtype":"init","session_id":"s"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Let me check."},{"type":"tool_use","id":"a","name":"Bash","input":{"command":"kubectl get pods"}}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","id":"b","name":"mcp__kubernetes__pods_list","input":{}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"b","is_error":true,"content":"forbidden"}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"a","content":"pod-a Running"}]}}
+Tool: not an event
+{"type":"result","result":"done"}`
+
+	got := ExtractToolExecutions(raw)
+	want := []ToolExecution{
+		{ToolName: "Bash", Status: "success", Input: `{"command":"kubectl get pods"}`, Output: "pod-a Running"},
+		{ToolName: "mcp__kubernetes__pods_list", Status: "error", Input: `{}`, Output: "forbidden"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ExtractToolExecutions() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ExtractToolExecutions()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
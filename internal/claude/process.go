@@ -4,12 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/rg/aiops/internal/metrics"
+	"github.com/rg/aiops/internal/storage"
 )
 
 // SessionManager tracks active sessions and executes Claude CLI queries.
@@ -24,6 +31,22 @@ type SessionManager struct {
 	projectPath string
 	model       string
 	timeout     time.Duration
+
+	queue            waitQueue
+	queueNotifier    QueueNotifier
+	queueNotifyAfter time.Duration
+	dropped          atomic.Int64 // Queries that timed out waiting for a slot
+	workspace        workspaceMonitor
+	recordRaw        bool // Keep the CLI's raw output in ClaudeJSONOutput.Raw
+
+	// resumeLocks serializes queries per Claude conversation, so overlapping
+	// --resume runs can't corrupt it; nil = disabled
+	resumeLocks  *resumeLocks
+	systemPrompt string // Appended to Claude's system prompt on every query
+
+	// pauseMu is held for reading by each query, queued or running, and for
+	// writing while queries are paused; see PauseQueries
+	pauseMu sync.RWMutex
 }
 
 // Session tracks an active chat session without any OS process.
@@ -33,6 +56,41 @@ type Session struct {
 	CreatedAt time.Time
 	LastUsed  time.Time
 	mu        sync.Mutex
+
+	// running holds the cancel functions of the session's queries from the
+	// moment they start waiting until their CLI processes exit, keyed by a
+	// per-session counter
+	running map[uint64]context.CancelFunc
+	nextRun uint64
+
+	// pending is set while the session has been handed out by
+	// GetOrCreateSession but its query hasn't started yet
+	pending bool
+}
+
+// trackQuery registers cancel for a running query until untrack is called.
+func (s *Session) trackQuery(cancel context.CancelFunc) (untrack func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running == nil {
+		s.running = make(map[uint64]context.CancelFunc)
+	}
+	s.pending = false
+	id := s.nextRun
+	s.nextRun++
+	s.running[id] = cancel
+	return func() {
+		s.mu.Lock()
+		delete(s.running, id)
+		s.mu.Unlock()
+	}
+}
+
+// busy reports whether the session has a query running.
+func (s *Session) busy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.running) > 0
 }
 
 func NewSessionManager(cliPath, projectPath, model string, maxSessions int, timeout time.Duration) *SessionManager {
@@ -40,6 +98,7 @@ func NewSessionManager(cliPath, projectPath, model string, maxSessions int, time
 		sessions:    make(map[string]*Session),
 		querySem:    make(chan struct{}, maxSessions),
 		maxSessions: maxSessions,
+		resumeLocks: newResumeLocks(),
 		cliPath:     cliPath,
 		projectPath: projectPath,
 		model:       model,
@@ -47,6 +106,30 @@ func NewSessionManager(cliPath, projectPath, model string, maxSessions int, time
 	}
 }
 
+// SetRecordRawOutput keeps the CLI's unparsed output in each response's Raw
+// field so it can be stored and replayed. Must be called before queries run.
+func (sm *SessionManager) SetRecordRawOutput(enabled bool) {
+	sm.recordRaw = enabled
+}
+
+// SetSystemPrompt appends prompt to Claude's system prompt on every query,
+// ahead of any per-query prompt. Must be called before queries run.
+func (sm *SessionManager) SetSystemPrompt(prompt string) {
+	sm.systemPrompt = prompt
+}
+
+// SetSerializeResumes makes queries resuming the same Claude conversation
+// wait for each other, so overlapping --resume runs can't corrupt it. Queries
+// for different conversations still run concurrently. Enabled by default.
+// Must be called before queries run.
+func (sm *SessionManager) SetSerializeResumes(enabled bool) {
+	if enabled {
+		sm.resumeLocks = newResumeLocks()
+	} else {
+		sm.resumeLocks = nil
+	}
+}
+
 // ValidateCLI checks if the Claude CLI is available and executable.
 func (sm *SessionManager) ValidateCLI() error {
 	info, err := os.Stat(sm.cliPath)
@@ -88,13 +171,21 @@ func (sm *SessionManager) ValidateCLI() error {
 }
 
 // GetOrCreateSession returns an existing session or creates a new one.
-// This is a lightweight operation - no OS processes are spawned.
+// This is a lightweight operation - no OS processes are spawned. When
+// maxSessions are tracked, the least recently used idle one is evicted to
+// make room; its chat gets a new session on its next query. Sessions handed
+// out here whose query hasn't started yet are evicted only when no other idle
+// session is left. If every session is running or waiting for a query, the
+// new one is tracked anyway and its query waits for a query slot; the excess
+// is evicted once sessions are idle again. Concurrency is bounded by query
+// slots, where excess queries wait instead of failing.
 // Note: LastUsed is only updated in ExecuteQuery to avoid race conditions.
 func (sm *SessionManager) GetOrCreateSession(chatID, sessionID string) (*Session, error) {
 	sm.mu.RLock()
 	if session, exists := sm.sessions[sessionID]; exists {
-		sm.mu.RUnlock()
 		// Don't update LastUsed here - ExecuteQuery handles it to avoid race
+		session.markPending()
+		sm.mu.RUnlock()
 		return session, nil
 	}
 	sm.mu.RUnlock()
@@ -105,11 +196,33 @@ func (sm *SessionManager) GetOrCreateSession(chatID, sessionID string) (*Session
 	// Double-check after acquiring write lock
 	if session, exists := sm.sessions[sessionID]; exists {
 		// Don't update LastUsed here - ExecuteQuery handles it to avoid race
+		session.markPending()
 		return session, nil
 	}
 
+	session := sm.addSessionLocked(chatID, sessionID)
+	session.markPending()
+	slog.Info("Created session", "session_id", sessionID, "chat_id", chatID)
+	return session, nil
+}
+
+// markPending flags the session as handed out for a query that hasn't
+// started yet.
+func (s *Session) markPending() {
+	s.mu.Lock()
+	s.pending = true
+	s.mu.Unlock()
+}
+
+// addSessionLocked tracks a new session, evicting idle ones to stay within
+// maxSessions. The caller must hold sm.mu for writing.
+func (sm *SessionManager) addSessionLocked(chatID, sessionID string) *Session {
 	if len(sm.sessions) >= sm.maxSessions {
-		return nil, fmt.Errorf("max concurrent sessions reached (%d)", sm.maxSessions)
+		sm.evictLRULocked(len(sm.sessions) - sm.maxSessions + 1)
+		if len(sm.sessions) >= sm.maxSessions {
+			slog.Warn("All sessions busy, tracking session over the limit",
+				"session_id", sessionID, "sessions", len(sm.sessions)+1, "max_sessions", sm.maxSessions)
+		}
 	}
 
 	session := &Session{
@@ -118,38 +231,204 @@ func (sm *SessionManager) GetOrCreateSession(chatID, sessionID string) (*Session
 		CreatedAt: time.Now(),
 		LastUsed:  time.Now(),
 	}
-
 	sm.sessions[sessionID] = session
-	slog.Info("Created session", "session_id", sessionID, "chat_id", chatID)
-	return session, nil
+	return session
+}
+
+// Rehydrate recreates session entries for contexts still active after a
+// restart, so session counts and idle cleanup include them. Expired and
+// inactive contexts are skipped, and only the maxSessions most recently used
+// are kept. Each session's LastUsed is its context's last interaction.
+// Returns the number of sessions recreated.
+func (sm *SessionManager) Rehydrate(contexts []*storage.ChatContext) int {
+	now := time.Now()
+	active := make([]*storage.ChatContext, 0, len(contexts))
+	for _, c := range contexts {
+		if c.IsActive && now.Before(c.ExpiresAt) {
+			active = append(active, c)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].LastInteraction.After(active[j].LastInteraction)
+	})
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	restored := 0
+	for _, c := range active {
+		if len(sm.sessions) >= sm.maxSessions {
+			break
+		}
+		if _, exists := sm.sessions[c.SessionID]; exists {
+			continue
+		}
+		sm.sessions[c.SessionID] = &Session{
+			SessionID: c.SessionID,
+			ChatID:    c.ChatID,
+			CreatedAt: c.CreatedAt,
+			LastUsed:  c.LastInteraction,
+		}
+		restored++
+	}
+	return restored
 }
 
 // ExecuteQuery runs a query against Claude CLI for the given session.
 // Concurrency is controlled via semaphore - this blocks if max concurrent queries reached.
 func (sm *SessionManager) ExecuteQuery(sessionID, query string, claudeSessionID string) (*ClaudeJSONOutput, error) {
-	sm.mu.RLock()
-	session, exists := sm.sessions[sessionID]
-	sm.mu.RUnlock()
+	return sm.ExecuteQueryWith(sessionID, query, claudeSessionID, QueryOptions{})
+}
 
-	if !exists {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
+// QueryOptions override the session manager's defaults for a single query.
+type QueryOptions struct {
+	Model        string // Replaces the configured model when set
+	SystemPrompt string // Appended to Claude's system prompt
+	TraceID      string // Passed to the CLI in TraceEnvVar when set
+	ForkSession  bool   // Resume into a new Claude conversation, leaving the original as is
+	ProjectPath  string // Replaces the configured working directory when set
+}
+
+// ExecuteQueryWith runs a query like ExecuteQuery with per-query options.
+func (sm *SessionManager) ExecuteQueryWith(sessionID, query, claudeSessionID string, opts QueryOptions) (*ClaudeJSONOutput, error) {
+	return sm.runQuery(sessionID, claudeSessionID, func(ctx context.Context) (*ClaudeJSONOutput, error) {
+		return sm.executeQuerySync(ctx, query, claudeSessionID, opts)
+	})
+}
+
+// ExecuteOneShot runs prompt in a fresh conversation that isn't tied to a
+// tracked session and isn't resumed later. It waits for a query slot and runs
+// until ctx is done, instead of the configured timeout.
+func (sm *SessionManager) ExecuteOneShot(ctx context.Context, prompt string, opts QueryOptions) (*ClaudeJSONOutput, error) {
+	sm.pauseMu.RLock()
+	defer sm.pauseMu.RUnlock()
+
+	select {
+	case sm.querySem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timeout waiting for available query slot: %w", ctx.Err())
+	}
+	defer func() { <-sm.querySem }()
+
+	return sm.executeQuerySync(ctx, prompt, "", opts)
+}
+
+// ForkConversation copies the Claude conversation claudeSessionID into a new
+// one by resuming it with --fork-session and prompt, and returns the new
+// conversation's ID. The original conversation is left as is. Like
+// ExecuteOneShot it isn't tied to a tracked session and runs until ctx is done.
+func (sm *SessionManager) ForkConversation(ctx context.Context, claudeSessionID, prompt string, opts QueryOptions) (string, error) {
+	sm.pauseMu.RLock()
+	defer sm.pauseMu.RUnlock()
+
+	if sm.resumeLocks != nil {
+		unlock, err := sm.resumeLocks.lock(ctx, claudeSessionID)
+		if err != nil {
+			return "", fmt.Errorf("timeout waiting for running query on the conversation: %w", err)
+		}
+		defer unlock()
 	}
 
-	// Acquire semaphore slot (blocks if at capacity)
 	select {
 	case sm.querySem <- struct{}{}:
-		defer func() { <-sm.querySem }()
-	case <-time.After(sm.timeout):
+	case <-ctx.Done():
+		return "", fmt.Errorf("timeout waiting for available query slot: %w", ctx.Err())
+	}
+	defer func() { <-sm.querySem }()
+
+	opts.ForkSession = true
+	output, err := sm.executeQuerySync(ctx, prompt, claudeSessionID, opts)
+	if err != nil {
+		return "", err
+	}
+	if output.SessionID == "" || output.SessionID == claudeSessionID {
+		return "", fmt.Errorf("claude CLI did not start a new conversation")
+	}
+	return output.SessionID, nil
+}
+
+// errQueryCancelled is returned by a query cancelled before it started.
+var errQueryCancelled = errors.New("query cancelled while waiting to run")
+
+// runQuery runs run for a tracked session once a query slot is free,
+// bounded by the configured timeout. With resumes serialized, it first waits
+// for other queries resuming claudeSessionID to finish. Both waits together
+// are bounded by the timeout too. The query counts as running from the start,
+// so its session isn't evicted or reaped while it waits, and CancelQueries
+// stops it while it waits too. A session evicted after GetOrCreateSession
+// handed it out is tracked again, without its chat.
+func (sm *SessionManager) runQuery(sessionID, claudeSessionID string, run func(ctx context.Context) (*ClaudeJSONOutput, error)) (*ClaudeJSONOutput, error) {
+	sm.pauseMu.RLock()
+	defer sm.pauseMu.RUnlock()
+
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+
+	sm.mu.Lock()
+	session, exists := sm.sessions[sessionID]
+	if !exists {
+		session = sm.addSessionLocked("", sessionID)
+		slog.Warn("Re-registered session evicted before its query", "session_id", sessionID)
+	}
+	// Track while holding sm.mu, so eviction can't slip in before
+	untrack := session.trackQuery(cancelRun)
+	sm.mu.Unlock()
+	defer untrack()
+
+	if err := sm.WorkspaceReady(); err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancelWait := context.WithTimeout(runCtx, sm.timeout)
+	defer cancelWait()
+
+	// Wait for the conversation before taking a slot, so queued resumes of
+	// one conversation can't hold every slot
+	if sm.resumeLocks != nil && claudeSessionID != "" {
+		unlock, err := sm.resumeLocks.lock(waitCtx, claudeSessionID)
+		if err != nil {
+			if runCtx.Err() != nil {
+				metrics.QueriesTotal.WithLabelValues(metrics.QueryStatusError).Inc()
+				return nil, errQueryCancelled
+			}
+			sm.recordDropped(session.ChatID, "conversation")
+			metrics.QueriesTotal.WithLabelValues(metrics.QueryStatusDropped).Inc()
+			return nil, fmt.Errorf("timeout waiting for running query on the conversation")
+		}
+		defer unlock()
+	}
+
+	// Acquire semaphore slot (blocks if at capacity)
+	if !sm.acquireQuerySlot(waitCtx, session.ChatID) {
+		if runCtx.Err() != nil {
+			metrics.QueriesTotal.WithLabelValues(metrics.QueryStatusError).Inc()
+			return nil, errQueryCancelled
+		}
+		metrics.QueriesTotal.WithLabelValues(metrics.QueryStatusDropped).Inc()
 		return nil, fmt.Errorf("timeout waiting for available query slot")
 	}
+	defer func() { <-sm.querySem }()
 
-	ctx, cancel := context.WithTimeout(context.Background(), sm.timeout)
+	ctx, cancel := context.WithTimeout(runCtx, sm.timeout)
 	defer cancel()
 
-	result, err := sm.executeQuerySync(ctx, query, claudeSessionID)
+	start := time.Now()
+	result, err := run(ctx)
+	metrics.QueryDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
+		status := metrics.QueryStatusError
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			status = metrics.QueryStatusTimeout
+		}
+		metrics.QueriesTotal.WithLabelValues(status).Inc()
+		// A vanished project directory makes the CLI fail to start with a
+		// cryptic chdir error; report it clearly instead
+		if wsErr := sm.CheckWorkspace(); wsErr != nil {
+			return nil, wsErr
+		}
 		return nil, err
 	}
+	metrics.QueriesTotal.WithLabelValues(metrics.QueryStatusSuccess).Inc()
 
 	session.mu.Lock()
 	session.LastUsed = time.Now()
@@ -158,15 +437,28 @@ func (sm *SessionManager) ExecuteQuery(sessionID, query string, claudeSessionID
 	return result, nil
 }
 
-// executeQuerySync runs a one-shot Claude CLI command.
-func (sm *SessionManager) executeQuerySync(ctx context.Context, query string, claudeSessionID string) (*ClaudeJSONOutput, error) {
-	args := []string{
-		"-p",
-		"--output-format", "json",
-	}
+// queryArgs builds Claude CLI arguments for a one-shot query with the given
+// output flags.
+func (sm *SessionManager) queryArgs(outputArgs []string, query, claudeSessionID string, opts QueryOptions) []string {
+	args := append([]string{"-p"}, outputArgs...)
 
-	if sm.model != "" {
-		args = append(args, "--model", sm.model)
+	model := sm.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	if model != "" {
+		args = append(args, "--model", model)
+	}
+	// The CLI takes one --append-system-prompt, so both prompts share it
+	systemPrompt := sm.systemPrompt
+	if opts.SystemPrompt != "" {
+		if systemPrompt != "" {
+			systemPrompt += "\n\n"
+		}
+		systemPrompt += opts.SystemPrompt
+	}
+	if systemPrompt != "" {
+		args = append(args, "--append-system-prompt", systemPrompt)
 	}
 
 	args = append(args, "--disable-slash-commands")
@@ -174,34 +466,65 @@ func (sm *SessionManager) executeQuerySync(ctx context.Context, query string, cl
 	// Use --resume to continue existing conversation
 	if claudeSessionID != "" {
 		args = append(args, "--resume", claudeSessionID)
-		slog.Debug("Resuming Claude session", "claude_session_id", claudeSessionID)
+		if opts.ForkSession {
+			args = append(args, "--fork-session")
+		}
+		slog.Debug("Resuming Claude session", "claude_session_id", claudeSessionID, "fork", opts.ForkSession)
 	} else {
 		slog.Debug("Creating new Claude session")
 	}
 
-	args = append(args, query)
+	// "--" ends option parsing so a query like "--help" is sent as the prompt
+	return append(args, "--", query)
+}
+
+// workDir returns the directory a query runs in. Claude keeps conversations
+// per directory, so a conversation can only be resumed from the one it
+// started in.
+func (sm *SessionManager) workDir(opts QueryOptions) string {
+	if opts.ProjectPath != "" {
+		return opts.ProjectPath
+	}
+	return sm.projectPath
+}
+
+// executeQuerySync runs a one-shot Claude CLI command. Output is requested as
+// stream-json, which unlike plain JSON reports the tools Claude ran.
+func (sm *SessionManager) executeQuerySync(ctx context.Context, query string, claudeSessionID string, opts QueryOptions) (*ClaudeJSONOutput, error) {
+	args := sm.queryArgs([]string{"--output-format", "stream-json", "--verbose"}, query, claudeSessionID, opts)
 
 	cmd := exec.CommandContext(ctx, sm.cliPath, args...)
-	cmd.Dir = sm.projectPath
+	cmd.Dir = sm.workDir(opts)
+	setTraceEnv(cmd, opts.TraceID)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
+		// The CLI exits non-zero on error results; report those specifically
+		var resultErr *ResultError
+		if _, parseErr := parseStream(&stdout, nil); errors.As(parseErr, &resultErr) {
+			return nil, resultErr
+		}
 		return nil, fmt.Errorf("command failed: %w, stderr: %s", err, stderr.String())
 	}
 
-	slog.Debug("Claude raw JSON output", "output", stdout.String())
+	slog.Debug("Claude raw output", "output", stdout.String())
 
-	parsedResponse, err := parseClaudeJSON(stdout.String())
+	raw := stdout.String()
+	parsedResponse, err := parseStream(&stdout, nil)
 	if err != nil {
 		return nil, err
 	}
+	if sm.recordRaw {
+		parsedResponse.Raw = raw
+	}
 
 	slog.Debug("Parsed Claude response",
 		"claude_session_id", parsedResponse.SessionID,
-		"response_length", len(parsedResponse.Result))
+		"response_length", len(parsedResponse.Result),
+		"tools", len(parsedResponse.Tools))
 
 	return parsedResponse, nil
 }
@@ -221,6 +544,41 @@ func (sm *SessionManager) KillSession(sessionID string) error {
 	return nil
 }
 
+// CancelQueries stops the queries sessionID is running, killing their CLI
+// processes, and returns how many there were. Queries still waiting for a
+// slot or for their conversation are counted and give up waiting. The
+// session stays tracked; see KillSession.
+func (sm *SessionManager) CancelQueries(sessionID string) int {
+	sm.mu.RLock()
+	session, exists := sm.sessions[sessionID]
+	sm.mu.RUnlock()
+	if !exists {
+		return 0
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	for _, cancel := range session.running {
+		cancel()
+	}
+	if n := len(session.running); n > 0 {
+		slog.Warn("Cancelled running queries", "session_id", sessionID, "queries", n)
+	}
+	return len(session.running)
+}
+
+// PauseQueries blocks new queries and waits for queued and running ones to
+// finish, so shared state such as the database can be replaced under them.
+// Queries started meanwhile wait until the returned resume is called.
+func (sm *SessionManager) PauseQueries() (resume func()) {
+	sm.pauseMu.Lock()
+	slog.Info("Paused Claude queries")
+	return func() {
+		sm.pauseMu.Unlock()
+		slog.Info("Resumed Claude queries")
+	}
+}
+
 // GetActiveSessionCount returns the number of active sessions.
 func (sm *SessionManager) GetActiveSessionCount() int {
 	sm.mu.RLock()
@@ -228,7 +586,20 @@ func (sm *SessionManager) GetActiveSessionCount() int {
 	return len(sm.sessions)
 }
 
-// CleanupIdleSessions removes sessions that have been idle longer than maxIdleTime.
+// GetMaxConcurrentQueries returns how many queries may run at once; further
+// queries wait for a free slot.
+func (sm *SessionManager) GetMaxConcurrentQueries() int {
+	return sm.maxSessions
+}
+
+// GetActiveQueryCount returns the number of Claude CLI queries currently running.
+func (sm *SessionManager) GetActiveQueryCount() int {
+	return len(sm.querySem)
+}
+
+// CleanupIdleSessions removes sessions that have been idle longer than
+// maxIdleTime. Sessions with a query running are never idle, however long
+// ago the query started.
 func (sm *SessionManager) CleanupIdleSessions(maxIdleTime time.Duration) int {
 	now := time.Now()
 
@@ -240,7 +611,7 @@ func (sm *SessionManager) CleanupIdleSessions(maxIdleTime time.Duration) int {
 		idle := now.Sub(session.LastUsed)
 		session.mu.Unlock()
 
-		if idle > maxIdleTime {
+		if idle > maxIdleTime && !session.busy() {
 			toCleanup = append(toCleanup, sessionID)
 		}
 	}
@@ -262,7 +633,7 @@ func (sm *SessionManager) CleanupIdleSessions(maxIdleTime time.Duration) int {
 			idle := time.Since(session.LastUsed) // Use fresh timestamp, not stale 'now'
 			session.mu.Unlock()
 
-			if idle > maxIdleTime {
+			if idle > maxIdleTime && !session.busy() {
 				slog.Info("Cleaning up idle session", "session_id", sessionID, "idle_duration", idle)
 				delete(sm.sessions, sessionID)
 				cleaned++
@@ -273,17 +644,118 @@ func (sm *SessionManager) CleanupIdleSessions(maxIdleTime time.Duration) int {
 	return cleaned
 }
 
+// EvictLRU removes up to count sessions with the oldest LastUsed time,
+// skipping sessions with a query running or waiting, and taking sessions
+// handed out for a query that hasn't started yet last. Returns the IDs of the evicted
+// sessions, least recently used first.
+func (sm *SessionManager) EvictLRU(count int) []string {
+	if count <= 0 {
+		return nil
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	return sm.evictLRULocked(count)
+}
+
+// evictLRULocked implements EvictLRU. The caller must hold sm.mu for writing.
+func (sm *SessionManager) evictLRULocked(count int) []string {
+	type entry struct {
+		sessionID string
+		lastUsed  time.Time
+		pending   bool
+	}
+	entries := make([]entry, 0, len(sm.sessions))
+	for sessionID, session := range sm.sessions {
+		// Evicting a session mid-query would orphan its CLI process
+		if session.busy() {
+			continue
+		}
+		session.mu.Lock()
+		entries = append(entries, entry{sessionID: sessionID, lastUsed: session.LastUsed, pending: session.pending})
+		session.mu.Unlock()
+	}
+
+	// Sessions about to run a query go last
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].pending != entries[j].pending {
+			return !entries[i].pending
+		}
+		return entries[i].lastUsed.Before(entries[j].lastUsed)
+	})
+
+	if count > len(entries) {
+		count = len(entries)
+	}
+
+	evicted := make([]string, 0, count)
+	for _, e := range entries[:count] {
+		delete(sm.sessions, e.sessionID)
+		evicted = append(evicted, e.sessionID)
+		slog.Info("Evicted least recently used session", "session_id", e.sessionID, "last_used", e.lastUsed)
+	}
+
+	return evicted
+}
+
 // ClaudeJSONOutput represents the parsed JSON response from Claude CLI.
 type ClaudeJSONOutput struct {
 	Result    string
 	SessionID string
+	Canary    bool   // Answered with the canary model/prompt
+	TraceID   string // The query's trace ID, set only when tracing is enabled
+	// Tools are the tool calls Claude made, with their input and output
+	Tools []ToolExecution
+	// Raw is the CLI's unparsed output, set only when recording is enabled
+	Raw string
+}
+
+// ParseOutput parses output recorded from the Claude CLI the same way a live
+// query's output is parsed.
+func ParseOutput(raw string) (*ClaudeJSONOutput, error) {
+	return parseStream(strings.NewReader(raw), nil)
+}
+
+// Result subtypes the Claude CLI reports when a query ends without an answer.
+const (
+	SubtypeMaxTurns       = "error_max_turns"
+	SubtypeExecutionError = "error_during_execution"
+)
+
+// ResultError is returned when Claude ends a query with an error result
+// instead of an answer, e.g. after running out of turns.
+type ResultError struct {
+	Subtype   string // e.g. SubtypeMaxTurns; "" if only is_error was set
+	Message   string // Claude's result text, often empty
+	SessionID string
 }
 
-// parseClaudeJSON extracts the text content and session ID from Claude's JSON output.
+func (e *ResultError) Error() string {
+	msg := "claude returned an error result"
+	if e.Subtype != "" {
+		msg += " (" + e.Subtype + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// resultError returns a *ResultError if a result event reports a failure.
+func resultError(subtype string, isError bool, result, sessionID string) error {
+	if !isError && !strings.HasPrefix(subtype, "error") {
+		return nil
+	}
+	return &ResultError{Subtype: subtype, Message: result, SessionID: sessionID}
+}
+
+// parseClaudeJSON extracts the text content and session ID from Claude's JSON
+// output, or returns a *ResultError if Claude reported a failure.
 func parseClaudeJSON(jsonOutput string) (*ClaudeJSONOutput, error) {
 	var result struct {
 		Type      string `json:"type"`
 		Subtype   string `json:"subtype"`
+		IsError   bool   `json:"is_error"`
 		Result    string `json:"result"`
 		SessionID string `json:"session_id"`
 	}
@@ -295,6 +767,9 @@ func parseClaudeJSON(jsonOutput string) (*ClaudeJSONOutput, error) {
 			SessionID: "",
 		}, nil
 	}
+	if err := resultError(result.Subtype, result.IsError, result.Result, result.SessionID); err != nil {
+		return nil, err
+	}
 
 	response := &ClaudeJSONOutput{
 		Result:    result.Result,
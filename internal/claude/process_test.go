@@ -1,9 +1,16 @@
 package claude
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/rg/aiops/internal/storage"
 )
 
 func TestNewSessionManager(t *testing.T) {
@@ -56,14 +63,51 @@ func TestGetOrCreateSession_Existing(t *testing.T) {
 func TestGetOrCreateSession_MaxSessions(t *testing.T) {
 	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 2, 5*time.Minute)
 
-	// Create max sessions
-	_, _ = sm.GetOrCreateSession("chat1", "session-1")
+	// Create max sessions, session-1 least recently used
+	session1, _ := sm.GetOrCreateSession("chat1", "session-1")
+	session1.mu.Lock()
+	session1.LastUsed = time.Now().Add(-time.Hour)
+	session1.mu.Unlock()
 	_, _ = sm.GetOrCreateSession("chat2", "session-2")
 
-	// Try to create one more
-	_, err := sm.GetOrCreateSession("chat3", "session-3")
-	if err == nil {
-		t.Error("Expected error when max sessions reached")
+	// One more makes room instead of failing
+	if _, err := sm.GetOrCreateSession("chat3", "session-3"); err != nil {
+		t.Fatalf("GetOrCreateSession() at max sessions error = %v", err)
+	}
+	if sm.GetActiveSessionCount() != 2 {
+		t.Errorf("ActiveSessionCount = %d, want 2", sm.GetActiveSessionCount())
+	}
+	if err := sm.KillSession("session-1"); err == nil {
+		t.Error("Least recently used session should have been evicted")
+	}
+}
+
+func TestRehydrate(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 2, 5*time.Minute)
+	now := time.Now()
+	contexts := []*storage.ChatContext{
+		{ChatID: "chat1", SessionID: "oldest", IsActive: true, LastInteraction: now.Add(-3 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{ChatID: "chat2", SessionID: "recent", IsActive: true, LastInteraction: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)},
+		{ChatID: "chat3", SessionID: "older", IsActive: true, LastInteraction: now.Add(-2 * time.Hour), ExpiresAt: now.Add(time.Hour)},
+		{ChatID: "chat4", SessionID: "expired", IsActive: true, LastInteraction: now, ExpiresAt: now.Add(-time.Minute)},
+		{ChatID: "chat5", SessionID: "inactive", IsActive: false, LastInteraction: now, ExpiresAt: now.Add(time.Hour)},
+	}
+
+	if restored := sm.Rehydrate(contexts); restored != 2 {
+		t.Errorf("Rehydrate() = %d, want 2 (max sessions)", restored)
+	}
+	if sm.GetActiveSessionCount() != 2 {
+		t.Errorf("ActiveSessionCount = %d, want 2", sm.GetActiveSessionCount())
+	}
+	for _, id := range []string{"recent", "older"} {
+		if _, exists := sm.sessions[id]; !exists {
+			t.Errorf("Session %s should have been restored", id)
+		}
+	}
+
+	// Restored sessions keep their idle time, so cleanup treats them right
+	if cleaned := sm.CleanupIdleSessions(time.Hour); cleaned != 1 {
+		t.Errorf("CleanupIdleSessions() = %d, want the restored idle session removed", cleaned)
 	}
 }
 
@@ -122,6 +166,134 @@ func TestKillSession_NotFound(t *testing.T) {
 	}
 }
 
+func TestCancelQueries(t *testing.T) {
+	cli := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := NewSessionManager(cli, t.TempDir(), "", 2, time.Minute)
+	_, _ = sm.GetOrCreateSession("chat123", "session-abc")
+
+	if n := sm.CancelQueries("session-abc"); n != 0 {
+		t.Errorf("CancelQueries() while idle = %d, want 0", n)
+	}
+	if n := sm.CancelQueries("nonexistent"); n != 0 {
+		t.Errorf("CancelQueries() for an unknown session = %d, want 0", n)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := sm.ExecuteQuery("session-abc", "loop forever", "")
+		done <- err
+	}()
+
+	// Wait for the query to start running
+	cancelled := 0
+	for deadline := time.Now().Add(5 * time.Second); cancelled == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		cancelled = sm.CancelQueries("session-abc")
+	}
+	if cancelled != 1 {
+		t.Fatalf("CancelQueries() = %d, want the running query", cancelled)
+	}
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected the cancelled query to fail")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Cancelled query kept running")
+	}
+	if n := sm.CancelQueries("session-abc"); n != 0 {
+		t.Errorf("CancelQueries() after the query ended = %d, want 0", n)
+	}
+}
+
+func TestCancelQueries_StopsQueuedQuery(t *testing.T) {
+	sm := NewSessionManager(echoCLI(t), t.TempDir(), "", 1, time.Minute)
+	_, _ = sm.GetOrCreateSession("chat123", "session-abc")
+
+	// Hold the only slot so the query queues
+	sm.querySem <- struct{}{}
+	defer func() { <-sm.querySem }()
+	dropped := sm.GetDroppedQueries()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := sm.ExecuteQuery("session-abc", "hello", "")
+		done <- err
+	}()
+	for deadline := time.Now().Add(5 * time.Second); sm.GetQueueDepth() == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Query never queued")
+		}
+	}
+
+	if n := sm.CancelQueries("session-abc"); n != 1 {
+		t.Fatalf("CancelQueries() = %d, want the queued query", n)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, errQueryCancelled) {
+			t.Errorf("ExecuteQuery() error = %v, want errQueryCancelled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancelled query kept waiting for a slot")
+	}
+	if sm.GetDroppedQueries() != dropped {
+		t.Error("A cancelled query should not count as dropped")
+	}
+}
+
+func TestPauseQueries(t *testing.T) {
+	sm := NewSessionManager(echoCLI(t), t.TempDir(), "", 1, time.Minute)
+	_, _ = sm.GetOrCreateSession("chat123", "session-abc")
+
+	// Queue a query behind a held slot
+	sm.querySem <- struct{}{}
+	queued := make(chan error, 1)
+	go func() {
+		_, err := sm.ExecuteQuery("session-abc", "hello", "")
+		queued <- err
+	}()
+	for deadline := time.Now().Add(5 * time.Second); sm.GetQueueDepth() == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Query never queued")
+		}
+	}
+
+	paused := make(chan func(), 1)
+	go func() { paused <- sm.PauseQueries() }()
+	select {
+	case <-paused:
+		t.Fatal("PauseQueries returned while a query was queued")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	<-sm.querySem
+	if err := <-queued; err != nil {
+		t.Fatalf("ExecuteQuery() error = %v", err)
+	}
+	resume := <-paused
+
+	// New queries wait until resumed
+	done := make(chan error, 1)
+	go func() {
+		_, err := sm.ExecuteQuery("session-abc", "hello", "")
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatal("Query ran while queries were paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+	resume()
+	if err := <-done; err != nil {
+		t.Errorf("ExecuteQuery() after resume error = %v", err)
+	}
+}
+
 func TestGetActiveSessionCount(t *testing.T) {
 	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 10, 5*time.Minute)
 
@@ -214,6 +386,31 @@ func TestParseClaudeJSON_EmptyResult(t *testing.T) {
 	}
 }
 
+func TestParseClaudeJSON_ErrorResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		wantSubtype string
+	}{
+		{"max_turns", `{"type":"result","subtype":"error_max_turns","session_id":"abc"}`, SubtypeMaxTurns},
+		{"during_execution", `{"type":"result","subtype":"error_during_execution","is_error":true}`, SubtypeExecutionError},
+		{"is_error_only", `{"type":"result","subtype":"success","is_error":true,"result":"API Error: 529"}`, "success"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseClaudeJSON(tt.json)
+			var resultErr *ResultError
+			if !errors.As(err, &resultErr) {
+				t.Fatalf("parseClaudeJSON() error = %v, want *ResultError", err)
+			}
+			if resultErr.Subtype != tt.wantSubtype {
+				t.Errorf("Subtype = %q, want %q", resultErr.Subtype, tt.wantSubtype)
+			}
+		})
+	}
+}
+
 // Test that LastUsed is NOT updated in GetOrCreateSession (only in ExecuteQuery)
 func TestGetOrCreateSession_DoesNotUpdateLastUsed(t *testing.T) {
 	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 10, 5*time.Minute)
@@ -233,3 +430,340 @@ func TestGetOrCreateSession_DoesNotUpdateLastUsed(t *testing.T) {
 		t.Error("GetOrCreateSession should not update LastUsed")
 	}
 }
+
+func TestCleanupIdleSessions_SkipsBusySessions(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 10, 5*time.Minute)
+
+	session, _ := sm.GetOrCreateSession("chat1", "session-1")
+	session.mu.Lock()
+	session.LastUsed = time.Now().Add(-2 * time.Hour)
+	session.mu.Unlock()
+	untrack := session.trackQuery(func() {})
+
+	if cleaned := sm.CleanupIdleSessions(time.Hour); cleaned != 0 {
+		t.Errorf("Cleaned = %d, want the session with a running query kept", cleaned)
+	}
+
+	untrack()
+	if cleaned := sm.CleanupIdleSessions(time.Hour); cleaned != 1 {
+		t.Errorf("Cleaned = %d, want 1 once the query finished", cleaned)
+	}
+}
+
+func TestEvictLRU(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 10, 5*time.Minute)
+
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"session-oldest": 3 * time.Hour,
+		"session-old":    2 * time.Hour,
+		"session-recent": time.Minute,
+		"session-newest": 0,
+	}
+	for id, age := range ages {
+		session, _ := sm.GetOrCreateSession("chat-"+id, id)
+		session.mu.Lock()
+		session.LastUsed = now.Add(-age)
+		session.mu.Unlock()
+	}
+
+	evicted := sm.EvictLRU(2)
+
+	if len(evicted) != 2 || evicted[0] != "session-oldest" || evicted[1] != "session-old" {
+		t.Errorf("EvictLRU(2) = %v, want [session-oldest session-old]", evicted)
+	}
+	if sm.GetActiveSessionCount() != 2 {
+		t.Errorf("ActiveSessionCount = %d, want 2", sm.GetActiveSessionCount())
+	}
+	if _, exists := sm.sessions["session-newest"]; !exists {
+		t.Error("Most recently used session should be kept")
+	}
+}
+
+func TestEvictLRU_SkipsBusySessions(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 2, 5*time.Minute)
+
+	busy, _ := sm.GetOrCreateSession("chat1", "session-busy")
+	busy.mu.Lock()
+	busy.LastUsed = time.Now().Add(-time.Hour)
+	busy.mu.Unlock()
+	untrack := busy.trackQuery(func() {})
+	defer untrack()
+
+	if evicted := sm.EvictLRU(1); len(evicted) != 0 {
+		t.Errorf("EvictLRU(1) = %v, want the busy session kept", evicted)
+	}
+
+	// With every session busy, a new one is tracked over the limit
+	other, _ := sm.GetOrCreateSession("chat2", "session-other")
+	untrackOther := other.trackQuery(func() {})
+	if _, err := sm.GetOrCreateSession("chat3", "session-new"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+	if n := sm.GetActiveSessionCount(); n != 3 {
+		t.Errorf("ActiveSessionCount = %d, want 3 while all are busy", n)
+	}
+
+	untrackOther()
+	if evicted := sm.EvictLRU(1); len(evicted) != 1 || evicted[0] == "session-busy" {
+		t.Errorf("EvictLRU(1) = %v, want an idle session evicted", evicted)
+	}
+}
+
+func TestEvictLRU_CountExceedsSessions(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 10, 5*time.Minute)
+	_, _ = sm.GetOrCreateSession("chat1", "session-1")
+
+	if evicted := sm.EvictLRU(5); len(evicted) != 1 {
+		t.Errorf("EvictLRU(5) evicted %d, want 1", len(evicted))
+	}
+	if evicted := sm.EvictLRU(0); len(evicted) != 0 {
+		t.Errorf("EvictLRU(0) evicted %d, want 0", len(evicted))
+	}
+}
+
+func TestEvictLRU_PendingSessionsLast(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 10, 5*time.Minute)
+
+	pending, _ := sm.GetOrCreateSession("chat1", "session-pending")
+	pending.mu.Lock()
+	pending.LastUsed = time.Now().Add(-time.Hour)
+	pending.mu.Unlock()
+
+	// A session whose query already ran is no longer pending
+	idle, _ := sm.GetOrCreateSession("chat2", "session-idle")
+	idle.trackQuery(func() {})()
+
+	if evicted := sm.EvictLRU(1); len(evicted) != 1 || evicted[0] != "session-idle" {
+		t.Errorf("EvictLRU(1) = %v, want [session-idle] before the pending session", evicted)
+	}
+}
+
+func TestRunQuery_QueuedSessionIsNotEvicted(t *testing.T) {
+	sm := NewSessionManager(echoCLI(t), t.TempDir(), "", 1, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("chat1", "session-1"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+
+	// Hold the only slot so the query queues
+	sm.querySem <- struct{}{}
+	done := make(chan error, 1)
+	go func() {
+		_, err := sm.ExecuteQuery("session-1", "hello", "")
+		done <- err
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for sm.GetQueueDepth() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Query never queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := sm.GetOrCreateSession("chat2", "session-2"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+	if _, exists := sm.sessions["session-1"]; !exists {
+		t.Error("Session with a queued query should not be evicted")
+	}
+
+	<-sm.querySem
+	if err := <-done; err != nil {
+		t.Errorf("ExecuteQuery() error = %v", err)
+	}
+}
+
+func TestRunQuery_ReRegistersEvictedSession(t *testing.T) {
+	sm := NewSessionManager(echoCLI(t), t.TempDir(), "", 1, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("chat1", "session-1"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+	sm.EvictLRU(1)
+
+	if _, err := sm.ExecuteQuery("session-1", "hello", ""); err != nil {
+		t.Fatalf("ExecuteQuery() error = %v", err)
+	}
+	if _, exists := sm.sessions["session-1"]; !exists {
+		t.Error("Evicted session should be tracked again by its query")
+	}
+}
+
+func TestMemoryMonitor_Check(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 10, 5*time.Minute)
+	_, _ = sm.GetOrCreateSession("chat1", "session-1")
+	_, _ = sm.GetOrCreateSession("chat2", "session-2")
+
+	m := NewMemoryMonitor(sm, 1000, time.Minute, 1)
+
+	m.heapInUse = func() uint64 { return 500 }
+	if evicted := m.check(); evicted != 0 {
+		t.Errorf("Below threshold evicted %d, want 0", evicted)
+	}
+
+	m.heapInUse = func() uint64 { return 2000 }
+	if evicted := m.check(); evicted != 1 {
+		t.Errorf("Above threshold evicted %d, want 1", evicted)
+	}
+	if sm.GetActiveSessionCount() != 1 {
+		t.Errorf("ActiveSessionCount = %d, want 1", sm.GetActiveSessionCount())
+	}
+}
+
+func TestIdleReaper_Reap(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 10, 5*time.Minute)
+	idle, _ := sm.GetOrCreateSession("chat1", "session-1")
+	idle.mu.Lock()
+	idle.LastUsed = time.Now().Add(-2 * time.Hour)
+	idle.mu.Unlock()
+	_, _ = sm.GetOrCreateSession("chat2", "session-2")
+
+	r := NewIdleReaper(sm, time.Minute, time.Hour)
+	if reaped := r.reap(); reaped != 1 {
+		t.Errorf("reap() = %d, want 1", reaped)
+	}
+	if reaped := r.reap(); reaped != 0 {
+		t.Errorf("Second reap() = %d, want 0", reaped)
+	}
+	if sm.GetActiveSessionCount() != 1 {
+		t.Errorf("ActiveSessionCount = %d, want 1", sm.GetActiveSessionCount())
+	}
+}
+
+func TestQueryArgs_QueryAfterSeparator(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 1, time.Second)
+	args := sm.queryArgs([]string{"--output-format", "json"}, "--help", "claude-1", QueryOptions{})
+
+	if n := len(args); n < 2 || args[n-2] != "--" || args[n-1] != "--help" {
+		t.Errorf("args = %v, want query last after \"--\"", args)
+	}
+}
+
+func TestQueryArgs_SystemPrompt(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "", 1, time.Second)
+	systemPrompt := func(opts QueryOptions) string {
+		args := sm.queryArgs(nil, "hi", "", opts)
+		for i, arg := range args {
+			if arg == "--append-system-prompt" {
+				return args[i+1]
+			}
+		}
+		return ""
+	}
+
+	if got := systemPrompt(QueryOptions{}); got != "" {
+		t.Errorf("No system prompt should be passed by default, got %q", got)
+	}
+
+	sm.SetSystemPrompt("Never run destructive commands.")
+	if got := systemPrompt(QueryOptions{}); got != "Never run destructive commands." {
+		t.Errorf("System prompt = %q, want the configured one", got)
+	}
+	if got := systemPrompt(QueryOptions{SystemPrompt: "Be brief."}); got != "Never run destructive commands.\n\nBe brief." {
+		t.Errorf("System prompt = %q, want the configured and per-query prompts combined", got)
+	}
+}
+
+func TestExecuteQuery_FlagLikeQueryIsPrompt(t *testing.T) {
+	// The fake CLI reports whether it saw --help before or after "--"
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --) echo "prompt: $2"; exit 0 ;;
+    --help) echo "parsed --help as a flag"; exit 0 ;;
+  esac
+  shift
+done
+`
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := NewSessionManager(cli, t.TempDir(), "", 1, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("1", "s1"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+
+	resp, err := sm.ExecuteQueryWith("s1", "--help", "", QueryOptions{})
+	if err != nil {
+		t.Fatalf("ExecuteQueryWith() error = %v", err)
+	}
+	if !strings.Contains(resp.Result, "prompt: --help") {
+		t.Errorf("Result = %q, want --help passed as the prompt", resp.Result)
+	}
+}
+
+func TestExecuteOneShot(t *testing.T) {
+	// The fake CLI echoes the model and prompt, or hangs on "slow"
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+model=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --model) model="$2"; shift ;;
+    --resume) echo "unexpected --resume"; exit 0 ;;
+    --) [ "$2" = slow ] && exec sleep 5; echo "$model: $2"; exit 0 ;;
+  esac
+  shift
+done
+`
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	e := NewExecutor(NewSessionManager(cli, t.TempDir(), "sonnet", 1, 10*time.Second), "", 0)
+
+	// No tracked session is needed
+	answer, err := e.ExecuteOneShot(context.Background(), "classify this", "haiku")
+	if err != nil {
+		t.Fatalf("ExecuteOneShot() error = %v", err)
+	}
+	if strings.TrimSpace(answer) != "haiku: classify this" {
+		t.Errorf("ExecuteOneShot() = %q, want the prompt run with the given model", answer)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := e.ExecuteOneShot(ctx, "slow", ""); err == nil {
+		t.Error("Expected error when the context expires")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("ExecuteOneShot() took %s, want it to stop at the context deadline", elapsed)
+	}
+}
+
+func TestForkConversation(t *testing.T) {
+	// The fake CLI reports a new conversation only when asked to fork
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+resume=""
+fork=""
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --resume) resume="$2"; shift ;;
+    --fork-session) fork=1 ;;
+  esac
+  shift
+done
+id="$resume"
+[ -n "$fork" ] && id="$resume-fork"
+echo '{"type":"system","subtype":"init","session_id":"'"$id"'"}'
+echo '{"type":"result","subtype":"success","result":"OK","session_id":"'"$id"'"}'
+`
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := NewSessionManager(cli, t.TempDir(), "", 1, 10*time.Second)
+	e := NewExecutor(sm, "", 0)
+
+	forkedID, err := e.ForkConversation(context.Background(), "claude-1", "", "forked")
+	if err != nil {
+		t.Fatalf("ForkConversation() error = %v", err)
+	}
+	if forkedID != "claude-1-fork" {
+		t.Errorf("ForkConversation() = %q, want the new conversation's ID", forkedID)
+	}
+
+	if args := sm.queryArgs(nil, "hi", "", QueryOptions{ForkSession: true}); strings.Contains(strings.Join(args, " "), "--fork-session") {
+		t.Errorf("args = %v, --fork-session needs a conversation to resume", args)
+	}
+}
@@ -0,0 +1,213 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/rg/aiops/internal/metrics"
+)
+
+// QueueNotifier is called when a query has waited longer than the notify
+// threshold for a free slot. position is 1-based.
+type QueueNotifier func(chatID string, position int)
+
+// waitQueue tracks queries waiting for a query slot in arrival order so a
+// waiter can be told its position.
+type waitQueue struct {
+	mu      sync.Mutex
+	waiters []uint64
+	next    uint64
+}
+
+// enter adds a waiter and returns its ticket.
+func (q *waitQueue) enter() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.next++
+	q.waiters = append(q.waiters, q.next)
+	metrics.QueryQueueDepth.Set(float64(len(q.waiters)))
+	return q.next
+}
+
+// leave removes the waiter holding ticket.
+func (q *waitQueue) leave(ticket uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, t := range q.waiters {
+		if t == ticket {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			metrics.QueryQueueDepth.Set(float64(len(q.waiters)))
+			return
+		}
+	}
+}
+
+// position returns the 1-based position of ticket, or 0 if not queued.
+func (q *waitQueue) position(ticket uint64) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, t := range q.waiters {
+		if t == ticket {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// depth returns the number of waiting queries.
+func (q *waitQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiters)
+}
+
+// SetQueueNotifier registers fn to be called once per query that waits longer
+// than after for a free query slot. Must be called before queries run.
+func (sm *SessionManager) SetQueueNotifier(after time.Duration, fn QueueNotifier) {
+	sm.queueNotifyAfter = after
+	sm.queueNotifier = fn
+}
+
+// GetQueueDepth returns the number of queries waiting for a free slot.
+func (sm *SessionManager) GetQueueDepth() int {
+	return sm.queue.depth()
+}
+
+// GetDroppedQueries returns how many queries timed out waiting for a slot or
+// for a running query on the same conversation.
+func (sm *SessionManager) GetDroppedQueries() int64 {
+	return sm.dropped.Load()
+}
+
+// recordDropped counts a query that gave up waiting, for a query slot or for
+// its conversation.
+func (sm *SessionManager) recordDropped(chatID, waitingFor string) {
+	sm.dropped.Add(1)
+	metrics.QueriesDropped.Inc()
+	slog.Warn("Query dropped while waiting", "chat_id", chatID, "waiting_for", waitingFor, "queue_depth", sm.queue.depth())
+}
+
+// acquireQuerySlot blocks until a query slot is free or ctx is done.
+// Waiters are tracked in the queue and, if configured, told their position
+// once they have waited longer than the notify threshold. Only a wait that
+// hits ctx's deadline counts as dropped.
+func (sm *SessionManager) acquireQuerySlot(ctx context.Context, chatID string) bool {
+	// Fast path: slot available, no queueing
+	select {
+	case sm.querySem <- struct{}{}:
+		return true
+	default:
+	}
+
+	ticket := sm.queue.enter()
+	defer sm.queue.leave(ticket)
+
+	var notify <-chan time.Time
+	if sm.queueNotifier != nil && sm.queueNotifyAfter > 0 {
+		timer := time.NewTimer(sm.queueNotifyAfter)
+		defer timer.Stop()
+		notify = timer.C
+	}
+
+	for {
+		select {
+		case sm.querySem <- struct{}{}:
+			return true
+		case <-notify:
+			notify = nil
+			// A re-registered session has no chat to tell
+			if pos := sm.queue.position(ticket); pos > 0 && chatID != "" {
+				sm.queueNotifier(chatID, pos)
+			}
+		case <-ctx.Done():
+			// A cancelled query didn't give up, it was stopped
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				sm.recordDropped(chatID, "slot")
+			}
+			return false
+		}
+	}
+}
+
+// QueueMonitor alerts when the query queue stays at or above a threshold for
+// longer than a sustain period, and again once it drains below it.
+type QueueMonitor struct {
+	sm        *SessionManager
+	threshold int
+	sustain   time.Duration
+	interval  time.Duration
+	onChange  func(backlogged bool, depth int)
+	now       func() time.Time
+
+	since    time.Time // when depth first reached threshold; zero while below
+	alerting bool
+}
+
+// NewQueueMonitor creates a monitor that checks the queue depth every interval.
+func NewQueueMonitor(sm *SessionManager, threshold int, sustain, interval time.Duration) *QueueMonitor {
+	return &QueueMonitor{
+		sm:        sm,
+		threshold: threshold,
+		sustain:   sustain,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// SetOnChange registers a callback invoked when the backlog alert fires or
+// clears. Must be called before Start.
+func (m *QueueMonitor) SetOnChange(fn func(backlogged bool, depth int)) {
+	m.onChange = fn
+}
+
+// Start runs the monitor until ctx is cancelled.
+func (m *QueueMonitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	slog.Info("Starting query queue monitor", "threshold", m.threshold, "sustain", m.sustain, "interval", m.interval)
+
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+		case <-ctx.Done():
+			slog.Info("Query queue monitor stopped")
+			return
+		}
+	}
+}
+
+// check samples the queue depth, fires the change callback on transitions
+// and returns whether the backlog alert is active.
+func (m *QueueMonitor) check() bool {
+	depth := m.sm.GetQueueDepth()
+	now := m.now()
+
+	if depth < m.threshold {
+		m.since = time.Time{}
+		if m.alerting {
+			m.alerting = false
+			slog.Info("Query queue backlog cleared", "depth", depth)
+			if m.onChange != nil {
+				m.onChange(false, depth)
+			}
+		}
+		return false
+	}
+
+	if m.since.IsZero() {
+		m.since = now
+	}
+	if !m.alerting && now.Sub(m.since) >= m.sustain {
+		m.alerting = true
+		slog.Warn("Query queue backlog", "depth", depth, "threshold", m.threshold, "since", m.since)
+		if m.onChange != nil {
+			m.onChange(true, depth)
+		}
+	}
+	return m.alerting
+}
@@ -0,0 +1,223 @@
+package claude
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rg/aiops/internal/metrics"
+)
+
+func TestWaitQueue_Positions(t *testing.T) {
+	var q waitQueue
+	a, b, c := q.enter(), q.enter(), q.enter()
+
+	if q.position(a) != 1 || q.position(b) != 2 || q.position(c) != 3 {
+		t.Errorf("positions = %d,%d,%d, want 1,2,3", q.position(a), q.position(b), q.position(c))
+	}
+
+	q.leave(a)
+	if q.position(b) != 1 || q.position(c) != 2 {
+		t.Errorf("after leave positions = %d,%d, want 1,2", q.position(b), q.position(c))
+	}
+	if q.position(a) != 0 {
+		t.Errorf("position of departed waiter = %d, want 0", q.position(a))
+	}
+	if q.depth() != 2 {
+		t.Errorf("depth = %d, want 2", q.depth())
+	}
+}
+
+func TestAcquireQuerySlot_NotifiesQueuePosition(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 1, 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var mu sync.Mutex
+	positions := make(map[string]int)
+	sm.SetQueueNotifier(30*time.Millisecond, func(chatID string, position int) {
+		mu.Lock()
+		positions[chatID] = position
+		mu.Unlock()
+	})
+
+	// Occupy the only slot so subsequent queries queue up
+	sm.querySem <- struct{}{}
+
+	var wg sync.WaitGroup
+	for i, chatID := range []string{"chat-a", "chat-b", "chat-c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sm.acquireQuerySlot(ctx, chatID) {
+				<-sm.querySem
+			}
+		}()
+		// Wait until this waiter is queued so arrival order is deterministic
+		for sm.GetQueueDepth() != i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	want := map[string]int{"chat-a": 1, "chat-b": 2, "chat-c": 3}
+	for chatID, pos := range want {
+		if positions[chatID] != pos {
+			t.Errorf("position for %s = %d, want %d", chatID, positions[chatID], pos)
+		}
+	}
+	mu.Unlock()
+
+	// Release the slot and let the backlog drain
+	<-sm.querySem
+	wg.Wait()
+
+	if sm.GetQueueDepth() != 0 {
+		t.Errorf("queue depth after drain = %d, want 0", sm.GetQueueDepth())
+	}
+}
+
+func TestAcquireQuerySlot_NoNotificationWithoutWait(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 1, time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	notified := false
+	sm.SetQueueNotifier(time.Millisecond, func(string, int) { notified = true })
+
+	if !sm.acquireQuerySlot(ctx, "chat") {
+		t.Fatal("acquireQuerySlot should succeed when a slot is free")
+	}
+	<-sm.querySem
+
+	if notified {
+		t.Error("Query that did not queue should not be notified")
+	}
+}
+
+func TestWaitQueue_DepthGauge(t *testing.T) {
+	var q waitQueue
+	a, b := q.enter(), q.enter()
+	if got := testutil.ToFloat64(metrics.QueryQueueDepth); got != 2 {
+		t.Errorf("gauge after enqueue = %v, want 2", got)
+	}
+
+	q.leave(a)
+	if got := testutil.ToFloat64(metrics.QueryQueueDepth); got != 1 {
+		t.Errorf("gauge after dequeue = %v, want 1", got)
+	}
+	q.leave(b)
+	if got := testutil.ToFloat64(metrics.QueryQueueDepth); got != 0 {
+		t.Errorf("gauge after draining = %v, want 0", got)
+	}
+}
+
+func TestAcquireQuerySlot_CountsDropped(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 1, 20*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	sm.querySem <- struct{}{}
+	defer func() { <-sm.querySem }()
+
+	before := testutil.ToFloat64(metrics.QueriesDropped)
+	if sm.acquireQuerySlot(ctx, "chat") {
+		t.Fatal("acquireQuerySlot should time out while the only slot is busy")
+	}
+	if sm.GetDroppedQueries() != 1 {
+		t.Errorf("GetDroppedQueries() = %d, want 1", sm.GetDroppedQueries())
+	}
+	if got := testutil.ToFloat64(metrics.QueriesDropped) - before; got != 1 {
+		t.Errorf("dropped counter increased by %v, want 1", got)
+	}
+}
+
+func TestQueueMonitor_AlertsOnSustainedBacklog(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 1, time.Second)
+	m := NewQueueMonitor(sm, 2, time.Minute, time.Second)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	var events []bool
+	m.SetOnChange(func(backlogged bool, _ int) { events = append(events, backlogged) })
+
+	a, b := sm.queue.enter(), sm.queue.enter()
+	if m.check() {
+		t.Error("Backlog should not alert before the sustain period")
+	}
+	now = now.Add(30 * time.Second)
+	if m.check() {
+		t.Error("Backlog should not alert halfway through the sustain period")
+	}
+	now = now.Add(30 * time.Second)
+	if !m.check() {
+		t.Error("Backlog should alert once sustained")
+	}
+	m.check()
+
+	sm.queue.leave(a)
+	if m.check() {
+		t.Error("Alert should clear once depth drops below the threshold")
+	}
+	sm.queue.leave(b)
+
+	if len(events) != 2 || !events[0] || events[1] {
+		t.Errorf("events = %v, want [true false]", events)
+	}
+}
+
+func TestRunQuery_RecordsStatusAndDuration(t *testing.T) {
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := "#!/bin/sh\ncase \"$*\" in *fail*) exit 1 ;; *slow*) exec sleep 5 ;; esac\necho ok\n"
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := NewSessionManager(cli, t.TempDir(), "", 1, 500*time.Millisecond)
+	if _, err := sm.GetOrCreateSession("1", "s1"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+
+	count := func(status string) float64 {
+		return testutil.ToFloat64(metrics.QueriesTotal.WithLabelValues(status))
+	}
+	success, failed, timedOut := count(metrics.QueryStatusSuccess), count(metrics.QueryStatusError), count(metrics.QueryStatusTimeout)
+	observed := histogramCount(t)
+
+	if _, err := sm.ExecuteQuery("s1", "hello", ""); err != nil {
+		t.Fatalf("ExecuteQuery() error = %v", err)
+	}
+	if _, err := sm.ExecuteQuery("s1", "fail", ""); err == nil {
+		t.Fatal("Expected error from failing CLI")
+	}
+	if _, err := sm.ExecuteQuery("s1", "slow", ""); err == nil {
+		t.Fatal("Expected error from slow CLI")
+	}
+
+	if got := count(metrics.QueryStatusSuccess) - success; got != 1 {
+		t.Errorf("success count increased by %v, want 1", got)
+	}
+	if got := count(metrics.QueryStatusError) - failed; got != 1 {
+		t.Errorf("error count increased by %v, want 1", got)
+	}
+	if got := count(metrics.QueryStatusTimeout) - timedOut; got != 1 {
+		t.Errorf("timeout count increased by %v, want 1", got)
+	}
+	if got := histogramCount(t) - observed; got != 3 {
+		t.Errorf("QueryDuration observed %d queries, want 3", got)
+	}
+}
+
+// histogramCount returns how many observations QueryDuration holds.
+func histogramCount(t *testing.T) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.QueryDuration.Write(&m); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
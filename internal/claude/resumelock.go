@@ -0,0 +1,62 @@
+package claude
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// resumeLocks holds one lock per Claude conversation being resumed. A
+// conversation's lock is dropped once no query holds or waits for it.
+type resumeLocks struct {
+	mu    sync.Mutex
+	locks map[string]*resumeLock
+}
+
+type resumeLock struct {
+	held chan struct{} // Holds a token while a query runs the conversation
+	refs int           // Queries holding or waiting for held
+}
+
+func newResumeLocks() *resumeLocks {
+	return &resumeLocks{locks: make(map[string]*resumeLock)}
+}
+
+// lock blocks until no other query holds claudeSessionID, then returns the
+// function that releases it. It gives up with ctx's error once ctx is done.
+func (l *resumeLocks) lock(ctx context.Context, claudeSessionID string) (func(), error) {
+	l.mu.Lock()
+	rl, exists := l.locks[claudeSessionID]
+	if !exists {
+		rl = &resumeLock{held: make(chan struct{}, 1)}
+		l.locks[claudeSessionID] = rl
+	}
+	rl.refs++
+	waiting := rl.refs > 1
+	l.mu.Unlock()
+
+	if waiting {
+		slog.Debug("Waiting for running query on the same Claude session", "claude_session_id", claudeSessionID)
+	}
+	select {
+	case rl.held <- struct{}{}:
+	case <-ctx.Done():
+		l.release(claudeSessionID, rl)
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-rl.held
+		l.release(claudeSessionID, rl)
+	}, nil
+}
+
+// release drops one reference to rl, and rl itself once unused.
+func (l *resumeLocks) release(claudeSessionID string, rl *resumeLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	rl.refs--
+	if rl.refs == 0 {
+		delete(l.locks, claudeSessionID)
+	}
+}
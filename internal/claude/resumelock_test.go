@@ -0,0 +1,97 @@
+package claude
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSerializeResumes(t *testing.T) {
+	// The fake CLI reports how many runs were in flight while it ran
+	running := t.TempDir()
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := fmt.Sprintf("#!/bin/sh\ntouch %[1]s/$$\nsleep 0.3\necho \"running: $(ls %[1]s | wc -l | tr -d ' ')\"\nrm %[1]s/$$\n", running)
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+
+	sm := NewSessionManager(cli, t.TempDir(), "", 4, 10*time.Second)
+	for _, id := range []string{"s1", "s2"} {
+		if _, err := sm.GetOrCreateSession("1", id); err != nil {
+			t.Fatalf("GetOrCreateSession() error = %v", err)
+		}
+	}
+
+	// maxRunning runs one query per Claude session ID concurrently and returns
+	// the most runs any of them saw in flight
+	maxRunning := func(claudeSessionIDs ...string) string {
+		var wg sync.WaitGroup
+		results := make([]string, len(claudeSessionIDs))
+		for i, id := range claudeSessionIDs {
+			wg.Add(1)
+			go func(i int, id string) {
+				defer wg.Done()
+				resp, err := sm.ExecuteQuery("s1", "hello", id)
+				if err != nil {
+					t.Errorf("ExecuteQuery(%s) error = %v", id, err)
+					return
+				}
+				results[i] = strings.TrimSpace(resp.Result)
+			}(i, id)
+		}
+		wg.Wait()
+		max := ""
+		for _, r := range results {
+			if r > max {
+				max = r
+			}
+		}
+		return max
+	}
+
+	if got := maxRunning("claude-a", "claude-a", "claude-a"); got != "running: 1" {
+		t.Errorf("Resumes of one conversation should run one at a time, got %q", got)
+	}
+	if got := maxRunning("claude-a", "claude-b"); got != "running: 2" {
+		t.Errorf("Different conversations should run concurrently, got %q", got)
+	}
+	if len(sm.resumeLocks.locks) != 0 {
+		t.Errorf("Locks should be dropped once unused, got %d", len(sm.resumeLocks.locks))
+	}
+}
+
+func TestResumeLocks_GivesUpWhenContextDone(t *testing.T) {
+	l := newResumeLocks()
+	unlock, err := l.lock(context.Background(), "claude-a")
+	if err != nil {
+		t.Fatalf("lock() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.lock(ctx, "claude-a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("lock() error = %v, want the wait to time out", err)
+	}
+
+	unlock()
+	if len(l.locks) != 0 {
+		t.Errorf("Locks should be dropped once unused, got %d", len(l.locks))
+	}
+}
+
+func TestSerializeResumes_Disabled(t *testing.T) {
+	sm := NewSessionManager("/usr/bin/claude", "/tmp/project", "sonnet", 1, time.Second)
+	if sm.resumeLocks == nil {
+		t.Fatal("Resumes should be serialized by default")
+	}
+	sm.SetSerializeResumes(false)
+	if sm.resumeLocks != nil {
+		t.Error("SetSerializeResumes(false) should disable the per-conversation locks")
+	}
+}
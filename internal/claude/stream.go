@@ -0,0 +1,184 @@
+package claude
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"strings"
+)
+
+// PartialHandler receives the text Claude has produced so far while a
+// streamed query is running.
+type PartialHandler func(text string)
+
+// maxStreamLineSize bounds a single stream-json event (large tool results).
+const maxStreamLineSize = 10 * 1024 * 1024
+
+// streamEvent is the subset of a Claude CLI stream-json event the bot uses.
+type streamEvent struct {
+	Type      string `json:"type"`
+	Subtype   string `json:"subtype"`
+	IsError   bool   `json:"is_error"`
+	Result    string `json:"result"`
+	SessionID string `json:"session_id"`
+	Message   struct {
+		Content []streamContent `json:"content"`
+	} `json:"message"`
+}
+
+// streamContent is a content block of an assistant or user event.
+type streamContent struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`          // tool_use
+	Name      string          `json:"name"`        // tool_use
+	Input     json.RawMessage `json:"input"`       // tool_use
+	ToolUseID string          `json:"tool_use_id"` // tool_result
+	Content   json.RawMessage `json:"content"`     // tool_result: string or text blocks
+	IsError   bool            `json:"is_error"`    // tool_result
+}
+
+// ExecuteQueryStream runs a query like ExecuteQuery but reads Claude's output
+// as it is produced, calling onPartial with the accumulated answer text after
+// each assistant message.
+func (sm *SessionManager) ExecuteQueryStream(sessionID, query, claudeSessionID string, onPartial PartialHandler) (*ClaudeJSONOutput, error) {
+	return sm.ExecuteQueryStreamWith(sessionID, query, claudeSessionID, QueryOptions{}, onPartial)
+}
+
+// ExecuteQueryStreamWith runs a streamed query with per-query options.
+func (sm *SessionManager) ExecuteQueryStreamWith(sessionID, query, claudeSessionID string, opts QueryOptions, onPartial PartialHandler) (*ClaudeJSONOutput, error) {
+	return sm.runQuery(sessionID, claudeSessionID, func(ctx context.Context) (*ClaudeJSONOutput, error) {
+		return sm.executeQueryStream(ctx, query, claudeSessionID, opts, onPartial)
+	})
+}
+
+// executeQueryStream runs a one-shot Claude CLI command with stream-json output.
+func (sm *SessionManager) executeQueryStream(ctx context.Context, query, claudeSessionID string, opts QueryOptions, onPartial PartialHandler) (*ClaudeJSONOutput, error) {
+	args := sm.queryArgs([]string{"--output-format", "stream-json", "--verbose"}, query, claudeSessionID, opts)
+
+	cmd := exec.CommandContext(ctx, sm.cliPath, args...)
+	cmd.Dir = sm.workDir(opts)
+	setTraceEnv(cmd, opts.TraceID)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start command: %w", err)
+	}
+
+	var raw strings.Builder
+	var src io.Reader = stdout
+	if sm.recordRaw {
+		src = io.TeeReader(stdout, &raw)
+	}
+	output, parseErr := parseStream(src, onPartial)
+	// Drain anything left so the CLI can't block on a full pipe
+	_, _ = io.Copy(io.Discard, src)
+
+	if err := cmd.Wait(); err != nil {
+		var resultErr *ResultError
+		if errors.As(parseErr, &resultErr) {
+			return nil, resultErr
+		}
+		return nil, fmt.Errorf("command failed: %w, stderr: %s", err, stderr.String())
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	if sm.recordRaw {
+		output.Raw = raw.String()
+	}
+
+	slog.Debug("Parsed streamed Claude response",
+		"claude_session_id", output.SessionID,
+		"response_length", len(output.Result))
+
+	return output, nil
+}
+
+// parseStream reads newline-delimited stream-json events until EOF. The
+// final "result" event provides the answer; if it is missing, the assistant
+// text seen so far is used instead. Tool calls are matched with their results.
+// A result event reporting a failure is returned as a *ResultError.
+// Output without any events is handed to parseClaudeJSON.
+func parseStream(r io.Reader, onPartial PartialHandler) (*ClaudeJSONOutput, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+
+	var parts []string
+	var tools toolCollector
+	var raw strings.Builder // Output seen before the first event
+	output := &ClaudeJSONOutput{}
+	gotEvent, gotResult := false, false
+	var resultErr error
+
+	for scanner.Scan() {
+		if !gotEvent {
+			raw.Write(scanner.Bytes())
+			raw.WriteString("\n")
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var ev streamEvent
+		if err := json.Unmarshal(line, &ev); err != nil || ev.Type == "" {
+			slog.Debug("Skipping unparseable stream line", "error", err)
+			continue
+		}
+		gotEvent = true
+		if ev.SessionID != "" {
+			output.SessionID = ev.SessionID
+		}
+		tools.add(&ev)
+
+		switch ev.Type {
+		case "assistant":
+			added := false
+			for _, c := range ev.Message.Content {
+				if c.Type == "text" && strings.TrimSpace(c.Text) != "" {
+					parts = append(parts, c.Text)
+					added = true
+				}
+			}
+			if added && onPartial != nil {
+				onPartial(strings.Join(parts, "\n\n"))
+			}
+		case "result":
+			output.Result = ev.Result
+			gotResult = true
+			resultErr = resultError(ev.Subtype, ev.IsError, ev.Result, output.SessionID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stream: %w", err)
+	}
+	if resultErr != nil {
+		return nil, resultErr
+	}
+
+	if !gotEvent {
+		return parseClaudeJSON(strings.TrimSpace(raw.String()))
+	}
+	output.Tools = tools.tools
+	if !gotResult {
+		output.Result = strings.Join(parts, "\n\n")
+	}
+	if output.Result == "" {
+		output.Result = "No response from Claude"
+	}
+	return output, nil
+}
@@ -0,0 +1,152 @@
+package claude
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testStream = `{"type":"system","subtype":"init","session_id":"claude-123"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Checking pods..."}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","name":"Bash"}]}}
+not json
+{"type":"assistant","message":{"content":[{"type":"text","text":"Found 2 crashing pods."}]}}
+{"type":"result","subtype":"success","result":"Final: 2 pods are crash-looping.","session_id":"claude-123"}
+`
+
+func TestParseStream(t *testing.T) {
+	var partials []string
+	output, err := parseStream(strings.NewReader(testStream), func(text string) {
+		partials = append(partials, text)
+	})
+	if err != nil {
+		t.Fatalf("parseStream() error = %v", err)
+	}
+
+	want := []string{"Checking pods...", "Checking pods...\n\nFound 2 crashing pods."}
+	if len(partials) != len(want) {
+		t.Fatalf("partials = %q, want %q", partials, want)
+	}
+	for i := range want {
+		if partials[i] != want[i] {
+			t.Errorf("partial %d = %q, want %q", i, partials[i], want[i])
+		}
+	}
+	if output.Result != "Final: 2 pods are crash-looping." || output.SessionID != "claude-123" {
+		t.Errorf("output = %+v", output)
+	}
+}
+
+func TestParseStream_NoResultEvent(t *testing.T) {
+	stream := `{"type":"assistant","message":{"content":[{"type":"text","text":"partial answer"}]},"session_id":"s1"}`
+	output, err := parseStream(strings.NewReader(stream), nil)
+	if err != nil {
+		t.Fatalf("parseStream() error = %v", err)
+	}
+	if output.Result != "partial answer" || output.SessionID != "s1" {
+		t.Errorf("output = %+v", output)
+	}
+}
+
+func TestParseStream_ErrorResult(t *testing.T) {
+	stream := `{"type":"system","subtype":"init","session_id":"s1"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Still looking..."}]}}
+{"type":"result","subtype":"error_max_turns","is_error":true,"session_id":"s1"}
+`
+	_, err := parseStream(strings.NewReader(stream), nil)
+	var resultErr *ResultError
+	if !errors.As(err, &resultErr) {
+		t.Fatalf("parseStream() error = %v, want *ResultError", err)
+	}
+	if resultErr.Subtype != SubtypeMaxTurns || resultErr.SessionID != "s1" {
+		t.Errorf("ResultError = %+v", resultErr)
+	}
+}
+
+func TestExecuteQuery_ErrorResultWithFailedExit(t *testing.T) {
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := "#!/bin/sh\necho '{\"type\":\"result\",\"subtype\":\"error_max_turns\",\"is_error\":true}'\nexit 1\n"
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := NewSessionManager(cli, t.TempDir(), "", 1, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("chat", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := sm.ExecuteQuery("s1", "question", "")
+	var resultErr *ResultError
+	if !errors.As(err, &resultErr) || resultErr.Subtype != SubtypeMaxTurns {
+		t.Errorf("ExecuteQuery() error = %v, want max-turns *ResultError", err)
+	}
+
+	_, err = sm.ExecuteQueryStream("s1", "question", "", nil)
+	if !errors.As(err, &resultErr) || resultErr.Subtype != SubtypeMaxTurns {
+		t.Errorf("ExecuteQueryStream() error = %v, want max-turns *ResultError", err)
+	}
+}
+
+func TestExecuteQueryStream(t *testing.T) {
+	dir := t.TempDir()
+	streamFile := filepath.Join(dir, "stream.jsonl")
+	if err := os.WriteFile(streamFile, []byte(testStream), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	argsFile := filepath.Join(dir, "args")
+	cli := filepath.Join(dir, "claude")
+	script := "#!/bin/sh\necho \"$*\" > " + argsFile + "\ncat " + streamFile + "\n"
+	if err := os.WriteFile(cli, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := NewSessionManager(cli, dir, "", 1, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("chat", "session-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	output, err := sm.ExecuteQueryStream("session-1", "why?", "", func(string) { calls++ })
+	if err != nil {
+		t.Fatalf("ExecuteQueryStream() error = %v", err)
+	}
+	if calls != 2 || !strings.HasPrefix(output.Result, "Final:") {
+		t.Errorf("calls = %d, output = %+v", calls, output)
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(args), "--output-format stream-json --verbose") {
+		t.Errorf("CLI args = %q, want stream-json output", args)
+	}
+}
+
+func TestParseStream_Tools(t *testing.T) {
+	stream := `{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"kubectl get pods"}}]}}
+{"type":"assistant","message":{"content":[{"type":"tool_use","id":"t2","name":"Bash","input":{"command":"kubectl logs x"}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"pod-a Running"}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t2","is_error":true,"content":[{"type":"text","text":"not found"}]}]}}
+{"type":"result","result":"done"}
+`
+	output, err := parseStream(strings.NewReader(stream), nil)
+	if err != nil {
+		t.Fatalf("parseStream() error = %v", err)
+	}
+
+	want := []ToolExecution{
+		{ToolName: "Bash", Status: "success", Input: `{"command":"kubectl get pods"}`, Output: "pod-a Running"},
+		{ToolName: "Bash", Status: "error", Input: `{"command":"kubectl logs x"}`, Output: "not found"},
+	}
+	if len(output.Tools) != len(want) {
+		t.Fatalf("Tools = %+v, want %+v", output.Tools, want)
+	}
+	for i := range want {
+		if output.Tools[i] != want[i] {
+			t.Errorf("Tools[%d] = %+v, want %+v", i, output.Tools[i], want[i])
+		}
+	}
+	if repeats := DetectToolLoops(output.Tools, 2); len(repeats) != 0 {
+		t.Errorf("Calls with different input should not count as a loop, got %v", repeats)
+	}
+}
@@ -0,0 +1,80 @@
+package claude
+
+import (
+	"strings"
+)
+
+// ToolMode describes whether a tool only reads state or can modify it.
+type ToolMode string
+
+const (
+	ToolModeRead  ToolMode = "read"
+	ToolModeWrite ToolMode = "write"
+)
+
+// ToolClassifier maps tool names to read/write modes.
+// Entries ending in "*" match any tool name with that prefix
+// (e.g. "mcp__kubernetes__*"). Unknown tools get the default mode.
+type ToolClassifier struct {
+	exact       map[string]ToolMode
+	prefixes    map[string]ToolMode
+	defaultMode ToolMode
+}
+
+// NewToolClassifier creates a classifier from a tool→mode map.
+// An empty defaultMode is treated as read.
+func NewToolClassifier(modes map[string]string, defaultMode string) *ToolClassifier {
+	c := &ToolClassifier{
+		exact:       make(map[string]ToolMode),
+		prefixes:    make(map[string]ToolMode),
+		defaultMode: ToolModeRead,
+	}
+	if ToolMode(defaultMode) == ToolModeWrite {
+		c.defaultMode = ToolModeWrite
+	}
+
+	for name, mode := range modes {
+		m := ToolModeRead
+		if ToolMode(mode) == ToolModeWrite {
+			m = ToolModeWrite
+		}
+		if strings.HasSuffix(name, "*") {
+			c.prefixes[strings.TrimSuffix(name, "*")] = m
+		} else {
+			c.exact[name] = m
+		}
+	}
+	return c
+}
+
+// Classify returns the mode for a tool. Exact matches win over prefix matches,
+// and the longest matching prefix wins among prefixes.
+func (c *ToolClassifier) Classify(toolName string) ToolMode {
+	if mode, ok := c.exact[toolName]; ok {
+		return mode
+	}
+
+	bestLen := -1
+	mode := c.defaultMode
+	for prefix, m := range c.prefixes {
+		if strings.HasPrefix(toolName, prefix) && len(prefix) > bestLen {
+			bestLen = len(prefix)
+			mode = m
+		}
+	}
+	return mode
+}
+
+// WriteTools returns the names of tools classified as write, in order of use.
+// Each name is listed once.
+func (c *ToolClassifier) WriteTools(tools []ToolExecution) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, tool := range tools {
+		if c.Classify(tool.ToolName) == ToolModeWrite && !seen[tool.ToolName] {
+			seen[tool.ToolName] = true
+			names = append(names, tool.ToolName)
+		}
+	}
+	return names
+}
@@ -0,0 +1,60 @@
+package claude
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToolClassifier_Classify(t *testing.T) {
+	c := NewToolClassifier(map[string]string{
+		"Bash":                     "write",
+		"Read":                     "read",
+		"mcp__kubernetes__*":       "read",
+		"mcp__kubernetes__delete*": "write",
+	}, "")
+
+	tests := []struct {
+		tool string
+		want ToolMode
+	}{
+		{"Bash", ToolModeWrite},
+		{"Read", ToolModeRead},
+		{"mcp__kubernetes__get_pods", ToolModeRead},
+		{"mcp__kubernetes__delete_pod", ToolModeWrite},
+		{"Unknown", ToolModeRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			if got := c.Classify(tt.tool); got != tt.want {
+				t.Errorf("Classify(%q) = %s, want %s", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolClassifier_DefaultWrite(t *testing.T) {
+	c := NewToolClassifier(map[string]string{"Read": "read"}, "write")
+
+	if got := c.Classify("Unknown"); got != ToolModeWrite {
+		t.Errorf("Classify(Unknown) = %s, want write", got)
+	}
+	if got := c.Classify("Read"); got != ToolModeRead {
+		t.Errorf("Classify(Read) = %s, want read", got)
+	}
+}
+
+func TestToolClassifier_WriteTools(t *testing.T) {
+	c := NewToolClassifier(map[string]string{"Bash": "write", "Edit": "write"}, "read")
+
+	readOnly := []ToolExecution{{ToolName: "Read"}, {ToolName: "Grep"}}
+	if got := c.WriteTools(readOnly); len(got) != 0 {
+		t.Errorf("WriteTools(read-only) = %v, want none", got)
+	}
+
+	mixed := []ToolExecution{{ToolName: "Read"}, {ToolName: "Bash"}, {ToolName: "Edit"}, {ToolName: "Bash"}}
+	want := []string{"Bash", "Edit"}
+	if got := c.WriteTools(mixed); !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteTools(mixed) = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package claude
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/google/uuid"
+)
+
+// TraceEnvVar is the environment variable holding a query's trace ID in the
+// Claude CLI process, inherited by the tools and MCP servers it runs so their
+// logs can be correlated with the bot's.
+const TraceEnvVar = "AIOPS_TRACE_ID"
+
+// SetTracing gives every query a fresh trace ID, logged with the query,
+// passed to the CLI in TraceEnvVar and reported in ClaudeJSONOutput.TraceID.
+func (e *Executor) SetTracing(enabled bool) {
+	e.tracing = enabled
+}
+
+// newTraceID returns a trace ID for a query, or "" with tracing disabled.
+func (e *Executor) newTraceID() string {
+	if !e.tracing {
+		return ""
+	}
+	return uuid.NewString()
+}
+
+// setTraceEnv passes traceID to cmd's environment when set.
+func setTraceEnv(cmd *exec.Cmd, traceID string) {
+	if traceID == "" {
+		return
+	}
+	cmd.Env = append(os.Environ(), TraceEnvVar+"="+traceID)
+}
@@ -0,0 +1,61 @@
+package claude
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTracing(t *testing.T) {
+	// The fake CLI reports the trace ID in its environment
+	cli := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho \"trace: $"+TraceEnvVar+"\"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	sm := NewSessionManager(cli, t.TempDir(), "", 2, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("1", "s1"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+	e := NewExecutor(sm, "", 0)
+
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	resp, err := e.Execute("s1", "hello", "", QueryOptions{})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if resp.TraceID != "" || strings.TrimSpace(resp.Result) != "trace:" {
+		t.Errorf("Without tracing: TraceID = %q, result %q; want no trace ID", resp.TraceID, resp.Result)
+	}
+
+	e.SetTracing(true)
+	seen := make(map[string]bool)
+	for _, stream := range []bool{false, true} {
+		logs.Reset()
+		if stream {
+			resp, err = e.ExecuteStream("s1", "hello", "", QueryOptions{}, nil)
+		} else {
+			resp, err = e.Execute("s1", "hello", "", QueryOptions{})
+		}
+		if err != nil {
+			t.Fatalf("stream=%v: error = %v", stream, err)
+		}
+		if resp.TraceID == "" || seen[resp.TraceID] {
+			t.Fatalf("stream=%v: TraceID = %q, want a fresh ID per query", stream, resp.TraceID)
+		}
+		seen[resp.TraceID] = true
+		if got := strings.TrimSpace(resp.Result); got != "trace: "+resp.TraceID {
+			t.Errorf("stream=%v: CLI saw %q, want the trace ID in %s", stream, got, TraceEnvVar)
+		}
+		if !strings.Contains(logs.String(), "trace_id="+resp.TraceID) {
+			t.Errorf("stream=%v: trace ID not logged:\n%s", stream, logs.String())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package claude
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrWorkspaceUnavailable is returned when the project directory queries run
+// in is missing or unreadable, e.g. after an NFS mount drops.
+var ErrWorkspaceUnavailable = errors.New("project workspace unavailable")
+
+// workspaceMonitor caches the result of checking the project directory.
+type workspaceMonitor struct {
+	ttl      time.Duration // 0 disables checks before each query
+	onChange func(err error)
+
+	mu        sync.Mutex
+	err       error
+	checkedAt time.Time
+}
+
+// SetWorkspaceCheck re-checks the project directory before each query,
+// reusing a result for up to ttl, so a vanished workspace fails fast with
+// ErrWorkspaceUnavailable. onChange, if set, is called when the workspace
+// becomes unavailable (err != nil) or available again (err == nil).
+// Must be called before queries run.
+func (sm *SessionManager) SetWorkspaceCheck(ttl time.Duration, onChange func(err error)) {
+	sm.workspace.ttl = ttl
+	sm.workspace.onChange = onChange
+}
+
+// CheckWorkspace verifies the project directory exists and can be listed,
+// updating the cached result. The returned error wraps ErrWorkspaceUnavailable.
+func (sm *SessionManager) CheckWorkspace() error {
+	err := checkProjectDir(sm.projectPath)
+
+	sm.workspace.mu.Lock()
+	changed := (err == nil) != (sm.workspace.err == nil)
+	sm.workspace.err = err
+	sm.workspace.checkedAt = time.Now()
+	sm.workspace.mu.Unlock()
+
+	if changed {
+		if err == nil {
+			slog.Info("Project workspace is available again", "path", sm.projectPath)
+		} else {
+			slog.Error("Project workspace became unavailable", "path", sm.projectPath, "error", err)
+		}
+		if sm.workspace.onChange != nil {
+			sm.workspace.onChange(err)
+		}
+	}
+	return err
+}
+
+// WorkspaceReady returns the cached workspace status, re-checking it once the
+// cached result is older than the configured ttl. Suitable for readiness
+// probes. Always nil when workspace checks are disabled.
+func (sm *SessionManager) WorkspaceReady() error {
+	if sm.workspace.ttl <= 0 {
+		return nil
+	}
+
+	sm.workspace.mu.Lock()
+	err, fresh := sm.workspace.err, time.Since(sm.workspace.checkedAt) < sm.workspace.ttl
+	sm.workspace.mu.Unlock()
+
+	if fresh {
+		return err
+	}
+	return sm.CheckWorkspace()
+}
+
+// checkProjectDir reports why path can't be used as the CLI's working
+// directory, or nil if it can.
+func checkProjectDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWorkspaceUnavailable, err)
+	}
+	defer dir.Close()
+
+	info, err := dir.Stat()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWorkspaceUnavailable, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", ErrWorkspaceUnavailable, path)
+	}
+	// Listing catches stale mounts that still stat successfully
+	if _, err := dir.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %v", ErrWorkspaceUnavailable, err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package claude
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoCLI writes a fake Claude CLI that answers every query with "ok".
+func echoCLI(t *testing.T) string {
+	t.Helper()
+	cli := filepath.Join(t.TempDir(), "claude")
+	if err := os.WriteFile(cli, []byte("#!/bin/sh\necho ok\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake CLI: %v", err)
+	}
+	return cli
+}
+
+func TestExecuteQuery_MissingProjectPathIsClearError(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "project")
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	sm := NewSessionManager(echoCLI(t), project, "", 1, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("1", "s1"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+
+	if _, err := sm.ExecuteQuery("s1", "hello", ""); err != nil {
+		t.Fatalf("ExecuteQuery() error = %v", err)
+	}
+
+	// Simulate the mount disappearing; checks before queries are disabled, so
+	// the error is recognised after the CLI fails to start
+	if err := os.Remove(project); err != nil {
+		t.Fatalf("Failed to remove project dir: %v", err)
+	}
+	_, err := sm.ExecuteQuery("s1", "hello", "")
+	if !errors.Is(err, ErrWorkspaceUnavailable) {
+		t.Fatalf("ExecuteQuery() error = %v, want ErrWorkspaceUnavailable", err)
+	}
+	if !strings.Contains(err.Error(), "project workspace unavailable") {
+		t.Errorf("Error %q should name the workspace", err)
+	}
+}
+
+func TestWorkspaceCheck_FailsFastAndReportsTransitions(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "project")
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	sm := NewSessionManager(echoCLI(t), project, "", 1, 10*time.Second)
+	if _, err := sm.GetOrCreateSession("1", "s1"); err != nil {
+		t.Fatalf("GetOrCreateSession() error = %v", err)
+	}
+
+	var changes []error
+	sm.SetWorkspaceCheck(time.Hour, func(err error) { changes = append(changes, err) })
+
+	if err := sm.WorkspaceReady(); err != nil {
+		t.Fatalf("WorkspaceReady() = %v, want nil", err)
+	}
+
+	if err := os.Remove(project); err != nil {
+		t.Fatalf("Failed to remove project dir: %v", err)
+	}
+	// The healthy result is still cached
+	if err := sm.WorkspaceReady(); err != nil {
+		t.Errorf("WorkspaceReady() = %v, want cached nil", err)
+	}
+
+	if err := sm.CheckWorkspace(); !errors.Is(err, ErrWorkspaceUnavailable) {
+		t.Fatalf("CheckWorkspace() = %v, want ErrWorkspaceUnavailable", err)
+	}
+	if _, err := sm.ExecuteQuery("s1", "hello", ""); !errors.Is(err, ErrWorkspaceUnavailable) {
+		t.Errorf("ExecuteQuery() error = %v, want ErrWorkspaceUnavailable", err)
+	}
+	if err := sm.WorkspaceReady(); !errors.Is(err, ErrWorkspaceUnavailable) {
+		t.Errorf("WorkspaceReady() = %v, want ErrWorkspaceUnavailable", err)
+	}
+
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatalf("Failed to recreate project dir: %v", err)
+	}
+	if err := sm.CheckWorkspace(); err != nil {
+		t.Fatalf("CheckWorkspace() = %v after restoring the dir", err)
+	}
+
+	if len(changes) != 2 || changes[0] == nil || changes[1] != nil {
+		t.Errorf("onChange calls = %v, want [unavailable, available]", changes)
+	}
+}
+
+func TestWorkspaceReady_DisabledByDefault(t *testing.T) {
+	sm := NewSessionManager("/nonexistent", "/nonexistent/project", "", 1, time.Second)
+	if err := sm.WorkspaceReady(); err != nil {
+		t.Errorf("WorkspaceReady() = %v, want nil when checks are disabled", err)
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,35 +19,399 @@ type Config struct {
 	Context  ContextConfig  `yaml:"context"`
 	Storage  StorageConfig  `yaml:"storage"`
 	Security SecurityConfig `yaml:"security"`
+	Tools    ToolsConfig    `yaml:"tools"`
+	API      APIConfig      `yaml:"api"`
+
+	Environment EnvironmentConfig `yaml:"environment"`
+	Incidents   IncidentsConfig   `yaml:"incidents"`
+	OnCall      OnCallConfig      `yaml:"oncall"`
+	Messaging   MessagingConfig   `yaml:"messaging"`
+	Slack       SlackConfig       `yaml:"slack"`
+	Discord     DiscordConfig     `yaml:"discord"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	// Scheduled maintenance windows
+	Maintenance MaintenanceConfig `yaml:"maintenance"`
+}
+
+// MaintenanceConfig schedules recurring maintenance windows. During a window
+// queries are refused, and chats with an active session are told when it
+// starts and ends.
+type MaintenanceConfig struct {
+	Timezone string              `yaml:"timezone"` // Zone schedules are evaluated in; default UTC
+	Windows  []MaintenanceWindow `yaml:"windows"`
+}
+
+// MaintenanceWindow starts whenever Schedule, a five-field cron expression,
+// fires and lasts Duration.
+type MaintenanceWindow struct {
+	Schedule string        `yaml:"schedule"`
+	Duration time.Duration `yaml:"duration"`
+	Message  string        `yaml:"message"` // Optional; replaces the default notice
+}
+
+// MetricsConfig controls the Prometheus metrics endpoint.
+type MetricsConfig struct {
+	Addr string `yaml:"addr"` // Serve /metrics on this address; empty disables
+}
+
+// MessagingConfig selects the chat platform the bot connects to.
+type MessagingConfig struct {
+	Platform string `yaml:"platform"` // telegram (default), slack or discord
+}
+
+// SlackConfig holds Slack credentials, used when messaging.platform is slack.
+type SlackConfig struct {
+	Token string `yaml:"token"`
+}
+
+// DiscordConfig holds Discord credentials, used when messaging.platform is discord.
+type DiscordConfig struct {
+	Token string `yaml:"token"`
+}
+
+// IncidentsConfig enables linking sessions to external incident IDs with
+// /incident, optionally posting a summary to a webhook when the session is reset.
+type IncidentsConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	WebhookURL     string        `yaml:"webhook_url"` // Empty disables the webhook
+	WebhookTimeout time.Duration `yaml:"webhook_timeout"`
+}
+
+// OnCallConfig enables /handoff, which transfers a chat's session to the DM
+// of whoever is on call according to Rotation.
+type OnCallConfig struct {
+	Enabled  bool           `yaml:"enabled"`
+	Rotation OnCallRotation `yaml:"rotation"`
+}
+
+// OnCallRotation hands the pager to the next user every Shift, starting with
+// the first user at Start.
+type OnCallRotation struct {
+	Start time.Time      `yaml:"start"`
+	Shift time.Duration  `yaml:"shift"`
+	Users []OnCallMember `yaml:"users"`
+}
+
+// OnCallMember is one person in the rotation. ChatID is their private chat
+// with the bot, which for Telegram is their user ID.
+type OnCallMember struct {
+	Name   string `yaml:"name"`
+	ChatID string `yaml:"chat_id"`
+}
+
+// EnvironmentConfig labels bot responses with the deployment environment
+// (e.g. "[PROD]") so users can tell otherwise identical bots apart.
+type EnvironmentConfig struct {
+	Name         string `yaml:"name"`          // Empty disables the label
+	LabelFormat  string `yaml:"label_format"`  // "{name}" is replaced with the upper-cased name
+	AllResponses bool   `yaml:"all_responses"` // Label Claude responses, not just /status and /new
+}
+
+// Label returns the formatted environment label, or "" when disabled.
+func (e EnvironmentConfig) Label() string {
+	if e.Name == "" {
+		return ""
+	}
+	return strings.ReplaceAll(e.LabelFormat, "{name}", strings.ToUpper(e.Name))
 }
 
 type TelegramConfig struct {
 	Token          string        `yaml:"token"`
 	AllowedChatIDs []string      `yaml:"allowed_chat_ids"`
+	AdminChatIDs   []string      `yaml:"admin_chat_ids"`
 	RateLimit      int           `yaml:"rate_limit"`
 	RateWindow     time.Duration `yaml:"rate_window"`
+	PasteMerge     PasteMerge    `yaml:"paste_merge"`
+	// RateLimitOverrides give specific chats or chat types their own quota
+	RateLimitOverrides RateLimitOverrides `yaml:"rate_limit_overrides"`
+	// MediaCaptions answers captions on photos, documents and videos as
+	// queries. Other non-text messages always get an "only text" reply.
+	MediaCaptions bool `yaml:"media_captions"`
+	// SandboxChatIDs are chats where every query runs in a fresh Claude
+	// session with nothing stored and no rate limit. Must also be allowed.
+	SandboxChatIDs []string `yaml:"sandbox_chat_ids"`
+	// ObserverUserIDs see answers in allowed chats but can't trigger queries
+	// or run commands other than read-only ones
+	ObserverUserIDs []string `yaml:"observer_user_ids"`
+	// CommandMenu registers the command list for Telegram's autocomplete.
+	// Admin-only commands are shown in admin chats only.
+	CommandMenu bool `yaml:"command_menu"`
+	// DebugCommand enables the admin /debug diagnostic report
+	DebugCommand bool `yaml:"debug_command"`
+	// FormatFallback retries failed sends with progressively simpler
+	// formatting: Markdown, escaped plain, truncated plain
+	FormatFallback bool `yaml:"format_fallback"`
+	// CommandDedupWindow drops repeated deliveries of the same command message
+	// within this window. 0 disables.
+	CommandDedupWindow time.Duration `yaml:"command_dedup_window"`
+	Reactions          Reactions     `yaml:"reactions"`
+	// ResponseDeadline is the end-to-end time allowed to answer a message
+	// before the user gets an apology and an SLO breach is recorded. 0 disables.
+	ResponseDeadline time.Duration `yaml:"response_deadline"`
+	// ReactionShortcuts lets users react to an answer to retry, export or
+	// rate it
+	ReactionShortcuts ReactionShortcuts `yaml:"reaction_shortcuts"`
+	// Inline answers "@bot query" typed in any chat for allow-listed users
+	Inline InlineMode `yaml:"inline"`
+	// ErrorEscalation tells users who to contact after repeated failed queries
+	ErrorEscalation ErrorEscalation `yaml:"error_escalation"`
+	// AnswerThreads posts each session's answers in a forum topic created
+	// for it, in forum-enabled supergroups
+	AnswerThreads bool `yaml:"answer_threads"`
+	// UpdateStallTimeout reconnects the update stream when a poll for new
+	// messages hasn't answered for this long. 0 disables.
+	UpdateStallTimeout time.Duration `yaml:"update_stall_timeout"`
+}
+
+// ErrorEscalation sends Contact to a chat once Threshold queries in a row have
+// failed there, optionally alerting admins. Disabled when Threshold is zero.
+type ErrorEscalation struct {
+	Threshold    int    `yaml:"threshold"`
+	Contact      string `yaml:"contact"`
+	NotifyAdmins bool   `yaml:"notify_admins"`
+}
+
+// RateLimitOverrides replace rate_limit/rate_window for chats by ID, or by
+// type (private, group, channel). A chat's own entry wins over its type's.
+type RateLimitOverrides struct {
+	Chats     map[string]RateLimitOverride `yaml:"chats"`
+	ChatTypes map[string]RateLimitOverride `yaml:"chat_types"`
+}
+
+// RateLimitOverride allows Limit requests per Window. An unset Window uses
+// rate_window.
+type RateLimitOverride struct {
+	Limit  int           `yaml:"limit"`
+	Window time.Duration `yaml:"window"`
+}
+
+// InlineMode configures Telegram inline queries. Answers to the same user's
+// same query text are reused for CacheTTL.
+type InlineMode struct {
+	Enabled  bool          `yaml:"enabled"`
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+}
+
+// ReactionShortcuts maps reaction emoji to actions (retry, export, feedback).
+// An empty Actions map uses the bot's defaults.
+type ReactionShortcuts struct {
+	Enabled bool              `yaml:"enabled"`
+	Actions map[string]string `yaml:"actions"`
+}
+
+// Reactions are the emoji set on a user's message per query outcome.
+// Empty values keep the defaults (👀 while processing, nothing afterwards).
+type Reactions struct {
+	Processing string `yaml:"processing"`
+	Success    string `yaml:"success"`
+	Error      string `yaml:"error"`
+}
+
+// PasteMerge controls reassembly of long pastes split across several messages.
+type PasteMerge struct {
+	Enabled   bool          `yaml:"enabled"`
+	Window    time.Duration `yaml:"window"`
+	MinLength int           `yaml:"min_length"`
 }
 
 type ClaudeConfig struct {
-	CLIPath               string        `yaml:"cli_path"`
-	ProjectPath           string        `yaml:"project_path"`
-	Model                 string        `yaml:"model"`
-	QueryTimeout          time.Duration `yaml:"query_timeout"`
-	MaxConcurrentSessions int           `yaml:"max_concurrent_sessions"`
+	CLIPath               string         `yaml:"cli_path"`
+	ProjectPath           string         `yaml:"project_path"`
+	Model                 string         `yaml:"model"`
+	QueryTimeout          time.Duration  `yaml:"query_timeout"`
+	MaxConcurrentSessions int            `yaml:"max_concurrent_sessions"`
+	MemoryPressure        MemoryPressure `yaml:"memory_pressure"`
+	HealthInterval        time.Duration  `yaml:"health_interval"` // 0 disables periodic CLI checks
+	// QueueNotifyAfter tells users their queue position once a query has waited
+	// this long for a free slot. 0 disables.
+	QueueNotifyAfter time.Duration `yaml:"queue_notify_after"`
+	// Streaming shows answers while Claude writes them by editing one message
+	Streaming bool `yaml:"streaming"`
+	// Canary sends a share of queries to an alternate model/prompt and labels
+	// their answers "[canary]"
+	Canary CanaryConfig `yaml:"canary"`
+	// QueueAlert notifies admins when queries pile up waiting for a slot
+	QueueAlert QueueAlert `yaml:"queue_alert"`
+	// AllowedModels enables /model, letting each chat pick one of these
+	// models instead of Model
+	AllowedModels []string `yaml:"allowed_models"`
+	// Projects enables /project, letting each chat run its queries in one of
+	// these directories (by name) instead of ProjectPath
+	Projects map[string]string `yaml:"projects"`
+	// AllowedProjectRoot, when set, is the directory ProjectPath and Projects
+	// must lie within
+	AllowedProjectRoot string `yaml:"allowed_project_root"`
+	// WorkspaceCheckTTL re-checks ProjectPath before queries, reusing the
+	// result for this long. 0 disables.
+	WorkspaceCheckTTL time.Duration `yaml:"workspace_check_ttl"`
+	// SerializeResumes makes queries that resume the same Claude conversation
+	// wait for each other instead of running --resume concurrently. Unset
+	// means enabled; see ResumesSerialized
+	SerializeResumes *bool `yaml:"serialize_resumes"`
+	// SystemPromptFile is a text file appended to Claude's system prompt on
+	// every query, for guardrails kept outside the project's CLAUDE.md
+	SystemPromptFile string `yaml:"system_prompt_file"`
+	// TraceQueries gives each query a trace ID, logged, recorded in the audit
+	// log and passed to the CLI's tools in the AIOPS_TRACE_ID variable
+	TraceQueries bool `yaml:"trace_queries"`
+	// IdleCleanupInterval is how often in-memory sessions that haven't run a
+	// query for MaxIdle are removed
+	IdleCleanupInterval time.Duration `yaml:"idle_cleanup_interval"`
+	MaxIdle             time.Duration `yaml:"max_idle"`
+}
+
+// CanaryConfig routes Percent of queries through Model and/or Prompt.
+// Disabled when Percent is zero.
+type CanaryConfig struct {
+	Percent int    `yaml:"percent"`
+	Model   string `yaml:"model"`
+	Prompt  string `yaml:"prompt"` // Appended to Claude's system prompt
+}
+
+// QueueAlert fires when at least Threshold queries have been waiting for a
+// query slot for Duration. Disabled when Threshold is zero.
+type QueueAlert struct {
+	Threshold     int           `yaml:"threshold"`
+	Duration      time.Duration `yaml:"duration"`
+	CheckInterval time.Duration `yaml:"check_interval"`
+}
+
+// MemoryPressure configures eviction of in-memory sessions when heap usage
+// exceeds a threshold. Disabled when ThresholdMB is zero.
+type MemoryPressure struct {
+	ThresholdMB   int           `yaml:"threshold_mb"`
+	CheckInterval time.Duration `yaml:"check_interval"`
+	EvictCount    int           `yaml:"evict_count"`
 }
 
 type ContextConfig struct {
-	TTL             time.Duration `yaml:"ttl"`
-	CleanupInterval time.Duration `yaml:"cleanup_interval"`
+	TTL               time.Duration `yaml:"ttl"`
+	CleanupInterval   time.Duration `yaml:"cleanup_interval"`
 	ValidationEnabled bool          `yaml:"validation_enabled"`
+	// PreferencesEnabled enables the /prefs command for per-chat display settings
+	PreferencesEnabled bool `yaml:"preferences_enabled"`
+	// ReconcileDuplicates deactivates all but the newest of any active contexts
+	// sharing a Claude session
+	ReconcileDuplicates bool `yaml:"reconcile_duplicates"`
+	// Overrides enables the admin /setcontext command for per-chat context
+	Overrides ContextOverrides `yaml:"overrides"`
+	// TransferRules limit /resume and /handoff transfers to these
+	// "source->target" chat type pairs. Empty allows all transfers.
+	TransferRules []string `yaml:"transfer_rules"`
+	// ValidationKeywords replace the built-in SRE keywords that mark a query
+	// as on-topic when validation is enabled
+	ValidationKeywords []string `yaml:"validation_keywords"`
+	// ValidationDenyPatterns are regular expressions rejecting matching
+	// queries regardless of keywords, even with validation disabled
+	ValidationDenyPatterns []string `yaml:"validation_deny_patterns"`
+	// ValidationMode is "keywords" (default) or "llm", which asks Claude
+	// whether queries failing the keyword checks are on-topic after all
+	ValidationMode string `yaml:"validation_mode"`
+	// ValidationLLMModel replaces claude.model for llm classification calls
+	ValidationLLMModel string `yaml:"validation_llm_model"`
+	// ValidationLLMTimeout bounds a classification call, after which the
+	// keyword verdict stands
+	ValidationLLMTimeout time.Duration `yaml:"validation_llm_timeout"`
+	// Summarize enables /summarize, a short Claude-written session summary
+	Summarize SummarizeConfig `yaml:"summarize"`
+}
+
+// SummarizeConfig controls the /summarize command.
+type SummarizeConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Model   string        `yaml:"model"`   // Empty = claude.model
+	Timeout time.Duration `yaml:"timeout"` // Bounds the summary call
+}
+
+// ContextOverrides controls per-chat context snippets added to queries.
+type ContextOverrides struct {
+	Enabled   bool `yaml:"enabled"`
+	MaxLength int  `yaml:"max_length"` // Max snippet length in characters
 }
 
 type StorageConfig struct {
-	DBPath string `yaml:"db_path"`
+	DBPath           string        `yaml:"db_path"`
+	SnapshotsEnabled bool          `yaml:"snapshots_enabled"`
+	SnapshotDir      string        `yaml:"snapshot_dir"`
+	BatchSize        int           `yaml:"batch_size"`        // Max rows per batched write
+	BatchInterval    time.Duration `yaml:"batch_interval"`    // 0 = synchronous writes
+	CommandAnalytics bool          `yaml:"command_analytics"` // Record command usage for /analytics
+	ReadWorkers      int           `yaml:"read_workers"`      // 0 = heavy reads run inline
+	ReadQueueSize    int           `yaml:"read_queue_size"`
+	ReplicaDSN       string        `yaml:"replica_dsn"`     // Empty = reads use db_path
+	SessionTrail     bool          `yaml:"session_trail"`   // Enable admin /trail
+	SessionTree      bool          `yaml:"session_tree"`    // Enable admin /tree
+	MaxContentLen    int           `yaml:"max_content_len"` // Max stored bytes per message; 0 = unlimited
+	// RecordRawOutput stores the unredacted CLI output behind each answer and
+	// enables admin /replay
+	RecordRawOutput bool `yaml:"record_raw_output"`
+	// MaxOpenConns caps open database connections, each holding file
+	// descriptors. Defaults to 50.
+	MaxOpenConns int `yaml:"max_open_conns"`
+	// CSVExport enables the admin /exportcsv command for reporting
+	CSVExport bool `yaml:"csv_export"`
 }
 
 type SecurityConfig struct {
-	SecretPatterns []string `yaml:"secret_patterns"`
+	SecretPatterns []string           `yaml:"secret_patterns"`
+	Confirmation   ConfirmationConfig `yaml:"confirmation"`
+	// ChatRedactions enables /redact for per-chat terms hidden from answers
+	ChatRedactions bool `yaml:"chat_redactions"`
+	// RedactionStats exports per-pattern redaction counts and enables /redactions
+	RedactionStats bool `yaml:"redaction_stats"`
+	// RedactionMode is "plain" (default) or "hash", which tags each redaction
+	// with a short hash of the secret so repeats can be correlated
+	RedactionMode string `yaml:"redaction_mode"`
+	// RedactQueries sends questions to Claude with secrets redacted too.
+	// Stored questions are always redacted.
+	RedactQueries bool `yaml:"redact_queries"`
+	// HeavyRedaction flags answers that redaction mostly removed
+	HeavyRedaction HeavyRedactionConfig `yaml:"heavy_redaction"`
+}
+
+// HeavyRedactionConfig flags answers of which redaction removes at least
+// Threshold (0-1), keeping the original for the admin /unredact command.
+// Disabled when Threshold is zero.
+type HeavyRedactionConfig struct {
+	Threshold  float64 `yaml:"threshold"`
+	NotifyUser bool    `yaml:"notify_user"` // Tell the chat its answer was flagged
+}
+
+// ConfirmationConfig lists destructive commands that must be sent twice in groups.
+type ConfirmationConfig struct {
+	Commands            []string      `yaml:"commands"`
+	Window              time.Duration `yaml:"window"`
+	RequireDistinctUser bool          `yaml:"require_distinct_user"`
+}
+
+// ToolsConfig classifies tools Claude may run as read or write.
+// When any write tool is used, the response is flagged to the user.
+type ToolsConfig struct {
+	Modes       map[string]string `yaml:"modes"`
+	DefaultMode string            `yaml:"default_mode"`
+	// LoopThreshold flags responses where the same tool call repeats this many
+	// times. 0 disables.
+	LoopThreshold int `yaml:"loop_threshold"`
+	// MaxPerResponse caps stored tool executions per response. 0 keeps all.
+	MaxPerResponse int `yaml:"max_per_response"`
+	// K8sSummary appends an overview of kubectl-style resource listings
+	// found in answers
+	K8sSummary K8sSummary `yaml:"k8s_summary"`
+}
+
+// K8sSummary recognizes resource listings by header line. Resources maps a
+// resource type to a regular expression matching its listing's header; it
+// adds to or replaces the built-in patterns.
+type K8sSummary struct {
+	Enabled   bool              `yaml:"enabled"`
+	Resources map[string]string `yaml:"resources"`
+}
+
+// APIConfig controls the authenticated HTTP API for programmatic queries.
+type APIConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Addr    string `yaml:"addr"`
+	Token   string `yaml:"token"`
 }
 
 func Load() (*Config, error) {
@@ -73,12 +441,65 @@ func Load() (*Config, error) {
 	return &cfg, nil
 }
 
+// validModelName matches model names that are safe to pass to --model, such
+// as "sonnet" or "claude-opus-4-1". Names can't start with "-" so they are
+// never read as CLI flags.
+var validModelName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// transferRulePattern matches a "source->target" chat type transfer rule.
+var transferRulePattern = regexp.MustCompile(`^\s*(private|group|channel|\*)\s*->\s*(private|group|channel|\*)\s*$`)
+
+// cronSchedulePattern matches the shape of a five-field cron expression.
+// Values are range-checked when the schedule is parsed at startup.
+var cronSchedulePattern = regexp.MustCompile(`^\s*[0-9*,/-]+(\s+[0-9*,/-]+){4}\s*$`)
+
+// maxMaintenanceWindow bounds maintenance windows; schedules are searched
+// minute by minute across a window's length.
+const maxMaintenanceWindow = 7 * 24 * time.Hour
+
+// maxSystemPromptSize bounds claude.system_prompt_file, which is passed as a
+// single command-line argument.
+const maxSystemPromptSize = 64 * 1024
+
+// minUpdateStallTimeout keeps the update watchdog from firing on healthy
+// polls, which wait up to 60s for messages before returning.
+const minUpdateStallTimeout = 90 * time.Second
+
+// validate checks the configuration and applies defaults. All problems are
+// collected and returned together so operators can fix them in a single pass.
 func (c *Config) validate() error {
-	if c.Telegram.Token == "" {
-		return fmt.Errorf("telegram.token is required (check TELEGRAM_BOT_TOKEN env var)")
+	var errs []error
+
+	switch c.Messaging.Platform {
+	case "":
+		c.Messaging.Platform = "telegram" // Default: Telegram
+		fallthrough
+	case "telegram":
+		if c.Telegram.Token == "" {
+			errs = append(errs, fmt.Errorf("telegram.token is required (check TELEGRAM_BOT_TOKEN env var)"))
+		}
+	case "slack":
+		if c.Slack.Token == "" {
+			errs = append(errs, fmt.Errorf("slack.token is required when messaging.platform is slack"))
+		}
+	case "discord":
+		if c.Discord.Token == "" {
+			errs = append(errs, fmt.Errorf("discord.token is required when messaging.platform is discord"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("messaging.platform must be telegram, slack or discord, got %q", c.Messaging.Platform))
 	}
 	if len(c.Telegram.AllowedChatIDs) == 0 {
-		return fmt.Errorf("telegram.allowed_chat_ids is required (at least one user or chat ID)")
+		errs = append(errs, fmt.Errorf("telegram.allowed_chat_ids is required (at least one user or chat ID)"))
+	}
+	allowed := make(map[string]bool, len(c.Telegram.AllowedChatIDs))
+	for _, id := range c.Telegram.AllowedChatIDs {
+		allowed[id] = true
+	}
+	for _, id := range c.Telegram.SandboxChatIDs {
+		if !allowed[id] {
+			errs = append(errs, fmt.Errorf("telegram.sandbox_chat_ids entry %q must also be in telegram.allowed_chat_ids", id))
+		}
 	}
 	// Apply defaults for rate limiting
 	if c.Telegram.RateLimit <= 0 {
@@ -87,53 +508,433 @@ func (c *Config) validate() error {
 	if c.Telegram.RateWindow <= 0 {
 		c.Telegram.RateWindow = time.Minute // Default: 1 minute window
 	}
+	for id, o := range c.Telegram.RateLimitOverrides.Chats {
+		errs = append(errs, validateRateLimitOverride(&o, c.Telegram.RateWindow, "chats."+id)...)
+		c.Telegram.RateLimitOverrides.Chats[id] = o
+	}
+	for chatType, o := range c.Telegram.RateLimitOverrides.ChatTypes {
+		if chatType != "private" && chatType != "group" && chatType != "channel" {
+			errs = append(errs, fmt.Errorf("telegram.rate_limit_overrides.chat_types key must be private, group or channel, got %q", chatType))
+		}
+		errs = append(errs, validateRateLimitOverride(&o, c.Telegram.RateWindow, "chat_types."+chatType)...)
+		c.Telegram.RateLimitOverrides.ChatTypes[chatType] = o
+	}
+	if c.Telegram.PasteMerge.Enabled {
+		if c.Telegram.PasteMerge.Window <= 0 {
+			c.Telegram.PasteMerge.Window = 2 * time.Second // Default: wait 2s for the next part
+		}
+		if c.Telegram.PasteMerge.MinLength <= 0 {
+			c.Telegram.PasteMerge.MinLength = 3000 // Default: near Telegram's 4096 split point
+		}
+	}
+	if c.Telegram.Inline.Enabled && c.Telegram.Inline.CacheTTL <= 0 {
+		c.Telegram.Inline.CacheTTL = 5 * time.Minute // Default: reuse answers for 5 minutes
+	}
+	switch c.Security.RedactionMode {
+	case "":
+		c.Security.RedactionMode = "plain"
+	case "plain", "hash":
+	default:
+		errs = append(errs, fmt.Errorf("security.redaction_mode must be \"plain\" or \"hash\", got %q", c.Security.RedactionMode))
+	}
+	if t := c.Security.HeavyRedaction.Threshold; t < 0 || t > 1 {
+		errs = append(errs, fmt.Errorf("security.heavy_redaction.threshold must be between 0 and 1, got %v", t))
+	}
+	if len(c.Security.Confirmation.Commands) > 0 && c.Security.Confirmation.Window <= 0 {
+		c.Security.Confirmation.Window = time.Minute // Default: 1 minute to confirm
+	}
 	if c.Claude.CLIPath == "" {
-		return fmt.Errorf("claude.cli_path is required")
+		errs = append(errs, fmt.Errorf("claude.cli_path is required"))
 	}
 	if c.Claude.ProjectPath == "" {
-		return fmt.Errorf("claude.project_path is required")
+		errs = append(errs, fmt.Errorf("claude.project_path is required"))
 	}
 	if c.Claude.QueryTimeout == 0 {
-		return fmt.Errorf("claude.query_timeout is required")
+		errs = append(errs, fmt.Errorf("claude.query_timeout is required"))
 	}
 	if c.Claude.MaxConcurrentSessions <= 0 {
-		return fmt.Errorf("claude.max_concurrent_sessions must be positive")
+		errs = append(errs, fmt.Errorf("claude.max_concurrent_sessions must be positive"))
+	}
+	if c.Claude.IdleCleanupInterval < 0 || c.Claude.MaxIdle < 0 {
+		errs = append(errs, fmt.Errorf("claude.idle_cleanup_interval and max_idle must not be negative"))
+	}
+	if c.Claude.IdleCleanupInterval == 0 {
+		c.Claude.IdleCleanupInterval = 5 * time.Minute // Default: check every 5 minutes
+	}
+	if c.Claude.MaxIdle == 0 {
+		c.Claude.MaxIdle = time.Hour // Default: free sessions idle for an hour
+	}
+	if mp := &c.Claude.MemoryPressure; mp.ThresholdMB < 0 {
+		errs = append(errs, fmt.Errorf("claude.memory_pressure.threshold_mb must not be negative"))
+	} else if mp.ThresholdMB > 0 {
+		if mp.CheckInterval == 0 {
+			mp.CheckInterval = 30 * time.Second // Default: check twice a minute
+		}
+		if mp.EvictCount <= 0 {
+			mp.EvictCount = 5 // Default: shed a handful of sessions per check
+		}
+	}
+	if qa := &c.Claude.QueueAlert; qa.Threshold < 0 {
+		errs = append(errs, fmt.Errorf("claude.queue_alert.threshold must not be negative"))
+	} else if qa.Threshold > 0 {
+		if qa.Duration < 0 || qa.CheckInterval < 0 {
+			errs = append(errs, fmt.Errorf("claude.queue_alert.duration and check_interval must not be negative"))
+		}
+		if qa.Duration == 0 {
+			qa.Duration = time.Minute // Default: ignore short bursts
+		}
+		if qa.CheckInterval == 0 {
+			qa.CheckInterval = 10 * time.Second
+		}
+	}
+	if c.Tools.LoopThreshold < 0 || c.Tools.LoopThreshold == 1 {
+		errs = append(errs, fmt.Errorf("tools.loop_threshold must be 0 (disabled) or at least 2"))
+	}
+	if c.Tools.MaxPerResponse < 0 {
+		errs = append(errs, fmt.Errorf("tools.max_per_response must not be negative"))
+	}
+	for _, rule := range c.Context.TransferRules {
+		if !transferRulePattern.MatchString(rule) {
+			errs = append(errs, fmt.Errorf("context.transfer_rules entry %q must look like \"private->group\" (types: private, group, channel, *)", rule))
+		}
+	}
+	if c.Telegram.ErrorEscalation.Threshold < 0 {
+		errs = append(errs, fmt.Errorf("telegram.error_escalation.threshold must not be negative"))
+	}
+	if c.Telegram.CommandDedupWindow < 0 {
+		errs = append(errs, fmt.Errorf("telegram.command_dedup_window must not be negative"))
+	}
+	for emoji, action := range c.Telegram.ReactionShortcuts.Actions {
+		if action != "retry" && action != "export" && action != "feedback" {
+			errs = append(errs, fmt.Errorf("telegram.reaction_shortcuts.actions[%s] must be retry, export or feedback, got %q", emoji, action))
+		}
+	}
+	if c.Telegram.ResponseDeadline < 0 {
+		errs = append(errs, fmt.Errorf("telegram.response_deadline must not be negative"))
+	}
+	if t := c.Telegram.UpdateStallTimeout; t != 0 && t < minUpdateStallTimeout {
+		errs = append(errs, fmt.Errorf("telegram.update_stall_timeout must be 0 or at least %s, got %s", minUpdateStallTimeout, t))
+	}
+	if c.Claude.QueueNotifyAfter < 0 {
+		errs = append(errs, fmt.Errorf("claude.queue_notify_after must not be negative"))
+	}
+	if c.Claude.HealthInterval < 0 {
+		errs = append(errs, fmt.Errorf("claude.health_interval must not be negative"))
+	}
+	if c.Claude.WorkspaceCheckTTL < 0 {
+		errs = append(errs, fmt.Errorf("claude.workspace_check_ttl must not be negative"))
+	}
+	if c.Claude.Model != "" && !validModelName.MatchString(c.Claude.Model) {
+		errs = append(errs, fmt.Errorf("claude.model %q is not a valid model name", c.Claude.Model))
+	}
+	for _, model := range c.Claude.AllowedModels {
+		if !validModelName.MatchString(model) {
+			errs = append(errs, fmt.Errorf("claude.allowed_models entry %q must be a lowercase model name", model))
+		}
+	}
+	for name, path := range c.Claude.Projects {
+		if !validModelName.MatchString(name) {
+			errs = append(errs, fmt.Errorf("claude.projects name %q must be lowercase", name))
+		}
+		if path == "" {
+			errs = append(errs, fmt.Errorf("claude.projects.%s path is required", name))
+		} else if err := validateProjectPath(path, c.Claude.AllowedProjectRoot, "claude.projects."+name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if canary := c.Claude.Canary; canary.Percent < 0 || canary.Percent > 100 {
+		errs = append(errs, fmt.Errorf("claude.canary.percent must be between 0 and 100"))
+	} else if canary.Percent > 0 && canary.Model == "" && canary.Prompt == "" {
+		errs = append(errs, fmt.Errorf("claude.canary requires a model or prompt when percent is set"))
 	}
 	if c.Context.TTL == 0 {
-		return fmt.Errorf("context.ttl is required")
+		errs = append(errs, fmt.Errorf("context.ttl is required"))
 	}
 	if c.Context.CleanupInterval == 0 {
-		return fmt.Errorf("context.cleanup_interval is required")
+		errs = append(errs, fmt.Errorf("context.cleanup_interval is required"))
 	}
 	if c.Storage.DBPath == "" {
-		return fmt.Errorf("storage.db_path is required")
+		errs = append(errs, fmt.Errorf("storage.db_path is required"))
+	}
+
+	if c.Storage.BatchSize < 0 || c.Storage.BatchInterval < 0 {
+		errs = append(errs, fmt.Errorf("storage.batch_size and storage.batch_interval must not be negative"))
+	} else if c.Storage.BatchInterval > 0 && c.Storage.BatchSize == 0 {
+		c.Storage.BatchSize = 50 // Default: flush every 50 rows or every interval
+	}
+
+	if c.Context.Overrides.MaxLength < 0 {
+		errs = append(errs, fmt.Errorf("context.overrides.max_length must not be negative"))
+	} else if c.Context.Overrides.Enabled && c.Context.Overrides.MaxLength == 0 {
+		c.Context.Overrides.MaxLength = 2000 // Default: a few paragraphs
+	}
+	if c.Context.Summarize.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("context.summarize.timeout must not be negative"))
+	} else if c.Context.Summarize.Enabled && c.Context.Summarize.Timeout == 0 {
+		c.Context.Summarize.Timeout = 2 * time.Minute // Default: long sessions take a while to read
+	}
+	if c.Storage.MaxContentLen < 0 {
+		errs = append(errs, fmt.Errorf("storage.max_content_len must not be negative"))
+	}
+	if c.Storage.MaxOpenConns < 0 {
+		errs = append(errs, fmt.Errorf("storage.max_open_conns must not be negative"))
+	} else if c.Storage.MaxOpenConns == 0 {
+		c.Storage.MaxOpenConns = 50 // Default: the pool size before this was configurable
+	}
+	if c.Storage.ReadWorkers < 0 || c.Storage.ReadQueueSize < 0 {
+		errs = append(errs, fmt.Errorf("storage.read_workers and storage.read_queue_size must not be negative"))
+	} else if c.Storage.ReadWorkers > 0 && c.Storage.ReadQueueSize == 0 {
+		c.Storage.ReadQueueSize = 20 // Default: queue a burst of /history and /sessions requests
+	}
+
+	if m := c.Tools.DefaultMode; m != "" && m != "read" && m != "write" {
+		errs = append(errs, fmt.Errorf("tools.default_mode must be read or write, got %q", m))
+	}
+	for name, mode := range c.Tools.Modes {
+		if mode != "read" && mode != "write" {
+			errs = append(errs, fmt.Errorf("tools.modes[%s] must be read or write, got %q", name, mode))
+		}
+	}
+	switch c.Context.ValidationMode {
+	case "":
+		c.Context.ValidationMode = "keywords" // Default: no extra Claude calls
+	case "keywords", "llm":
+	default:
+		errs = append(errs, fmt.Errorf("context.validation_mode must be keywords or llm, got %q", c.Context.ValidationMode))
+	}
+	if c.Context.ValidationLLMTimeout < 0 {
+		errs = append(errs, fmt.Errorf("context.validation_llm_timeout must not be negative"))
+	} else if c.Context.ValidationMode == "llm" && c.Context.ValidationLLMTimeout == 0 {
+		c.Context.ValidationLLMTimeout = 10 * time.Second // Default: a short wait before falling back to keywords
+	}
+	for _, pattern := range c.Context.ValidationDenyPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("context.validation_deny_patterns entry %q is not a valid regular expression: %w", pattern, err))
+		}
+	}
+	for name, pattern := range c.Tools.K8sSummary.Resources {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("tools.k8s_summary.resources[%s] is not a valid regular expression: %w", name, err))
+		}
+	}
+
+	if c.API.Enabled {
+		if c.API.Token == "" {
+			errs = append(errs, fmt.Errorf("api.token is required when api.enabled is true (check API_TOKEN env var)"))
+		}
+		if c.API.Addr == "" {
+			c.API.Addr = ":8080" // Default: listen on all interfaces, port 8080
+		}
+	}
+
+	if c.Environment.Name != "" {
+		if c.Environment.LabelFormat == "" {
+			c.Environment.LabelFormat = "[{name}]" // Default: [PROD]
+		} else if !strings.Contains(c.Environment.LabelFormat, "{name}") {
+			errs = append(errs, fmt.Errorf("environment.label_format must contain {name}, got %q", c.Environment.LabelFormat))
+		}
+	}
+
+	if oc := &c.OnCall; oc.Enabled {
+		if len(oc.Rotation.Users) == 0 {
+			errs = append(errs, fmt.Errorf("oncall.rotation.users is required when oncall is enabled"))
+		}
+		for i, u := range oc.Rotation.Users {
+			if u.ChatID == "" {
+				errs = append(errs, fmt.Errorf("oncall.rotation.users[%d].chat_id is required", i))
+			}
+		}
+		if oc.Rotation.Start.IsZero() {
+			errs = append(errs, fmt.Errorf("oncall.rotation.start is required when oncall is enabled"))
+		}
+		if oc.Rotation.Shift < 0 {
+			errs = append(errs, fmt.Errorf("oncall.rotation.shift must not be negative"))
+		} else if oc.Rotation.Shift == 0 {
+			oc.Rotation.Shift = 7 * 24 * time.Hour // Default: weekly rotation
+		}
+	}
+	if m := &c.Maintenance; len(m.Windows) > 0 {
+		if m.Timezone == "" {
+			m.Timezone = "UTC"
+		} else if _, err := time.LoadLocation(m.Timezone); err != nil {
+			errs = append(errs, fmt.Errorf("maintenance.timezone %q: %w", m.Timezone, err))
+		}
+		for i, w := range m.Windows {
+			if !cronSchedulePattern.MatchString(w.Schedule) {
+				errs = append(errs, fmt.Errorf("maintenance.windows[%d].schedule %q must be a cron expression like \"0 2 * * 0\"", i, w.Schedule))
+			}
+			if w.Duration < time.Minute || w.Duration > maxMaintenanceWindow {
+				errs = append(errs, fmt.Errorf("maintenance.windows[%d].duration must be between 1m and %s", i, maxMaintenanceWindow))
+			}
+		}
+	}
+	if c.Incidents.WebhookURL != "" {
+		if !strings.HasPrefix(c.Incidents.WebhookURL, "http://") && !strings.HasPrefix(c.Incidents.WebhookURL, "https://") {
+			errs = append(errs, fmt.Errorf("incidents.webhook_url must be an http(s) URL, got %q", c.Incidents.WebhookURL))
+		}
+		if c.Incidents.WebhookTimeout <= 0 {
+			c.Incidents.WebhookTimeout = 10 * time.Second // Default: don't hold up /new for long
+		}
 	}
 
 	// Validate CLI path exists and is executable
-	if info, err := os.Stat(c.Claude.CLIPath); err != nil {
+	if c.Claude.CLIPath != "" {
+		if err := validateCLIPath(c.Claude.CLIPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Claude.AllowedProjectRoot != "" {
+		if !filepath.IsAbs(c.Claude.AllowedProjectRoot) {
+			errs = append(errs, fmt.Errorf("claude.allowed_project_root must be an absolute path"))
+		} else if err := validateProjectDir(c.Claude.AllowedProjectRoot, "claude.allowed_project_root"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Validate project path is allowed, exists and is a directory
+	if c.Claude.ProjectPath != "" {
+		if err := validateProjectPath(c.Claude.ProjectPath, c.Claude.AllowedProjectRoot, "claude.project_path"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Claude.SystemPromptFile != "" {
+		if err := validateSystemPromptFile(c.Claude.SystemPromptFile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateCLIPath checks that the Claude CLI path exists and is an executable file.
+func validateCLIPath(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("claude.cli_path does not exist: %s", c.Claude.CLIPath)
+			return fmt.Errorf("claude.cli_path does not exist: %s", path)
 		}
 		return fmt.Errorf("claude.cli_path stat failed: %w", err)
-	} else if info.IsDir() {
-		return fmt.Errorf("claude.cli_path is a directory, not a file: %s", c.Claude.CLIPath)
-	} else if info.Mode()&0111 == 0 {
-		return fmt.Errorf("claude.cli_path is not executable: %s", c.Claude.CLIPath)
 	}
+	if info.IsDir() {
+		return fmt.Errorf("claude.cli_path is a directory, not a file: %s", path)
+	}
+	if info.Mode()&0111 == 0 {
+		return fmt.Errorf("claude.cli_path is not executable: %s", path)
+	}
+	return nil
+}
+
+// validateRateLimitOverride checks one override, defaulting its window to
+// defaultWindow. name locates it in error messages.
+func validateRateLimitOverride(o *RateLimitOverride, defaultWindow time.Duration, name string) []error {
+	var errs []error
+	if o.Limit <= 0 {
+		errs = append(errs, fmt.Errorf("telegram.rate_limit_overrides.%s.limit must be positive", name))
+	}
+	if o.Window < 0 {
+		errs = append(errs, fmt.Errorf("telegram.rate_limit_overrides.%s.window must not be negative", name))
+	}
+	if o.Window == 0 {
+		o.Window = defaultWindow
+	}
+	return errs
+}
+
+// validateProjectPath checks that a project path has no ".." elements, lies
+// within root when root is set, and is an existing directory. Symlinks are
+// resolved before the containment check, so a link inside root can't point
+// out of it. field names the option in error messages.
+func validateProjectPath(path, root, field string) error {
+	for _, elem := range strings.Split(filepath.ToSlash(path), "/") {
+		if elem == ".." {
+			return fmt.Errorf("%s must not contain \"..\": %s", field, path)
+		}
+	}
+	if root != "" {
+		abs, err := resolvePath(path)
+		if err != nil {
+			return fmt.Errorf("%s could not be resolved: %w", field, err)
+		}
+		absRoot, err := resolvePath(root)
+		if err != nil {
+			return fmt.Errorf("claude.allowed_project_root could not be resolved: %w", err)
+		}
+		rel, err := filepath.Rel(absRoot, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s is outside claude.allowed_project_root: %s", field, path)
+		}
+	}
+	return validateProjectDir(path, field)
+}
 
-	// Validate project path exists and is a directory
-	if info, err := os.Stat(c.Claude.ProjectPath); err != nil {
+// resolvePath returns path made absolute with symlinks resolved. A path that
+// doesn't exist is only made absolute; validateProjectDir reports it.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
+}
+
+// validateProjectDir checks that a project path exists and is a directory.
+// field names the option in error messages.
+func validateProjectDir(path, field string) error {
+	info, err := os.Stat(path)
+	if err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("claude.project_path does not exist: %s", c.Claude.ProjectPath)
+			return fmt.Errorf("%s does not exist: %s", field, path)
 		}
-		return fmt.Errorf("claude.project_path stat failed: %w", err)
-	} else if !info.IsDir() {
-		return fmt.Errorf("claude.project_path is not a directory: %s", c.Claude.ProjectPath)
+		return fmt.Errorf("%s stat failed: %w", field, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory: %s", field, path)
 	}
+	return nil
+}
 
+// validateSystemPromptFile checks that the system prompt file is a regular
+// file small enough to pass on the command line.
+func validateSystemPromptFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("claude.system_prompt_file does not exist: %s", path)
+		}
+		return fmt.Errorf("claude.system_prompt_file stat failed: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("claude.system_prompt_file is not a regular file: %s", path)
+	}
+	if info.Size() > maxSystemPromptSize {
+		return fmt.Errorf("claude.system_prompt_file must be at most %d bytes, got %d", maxSystemPromptSize, info.Size())
+	}
 	return nil
 }
 
+// SystemPrompt returns the contents of SystemPromptFile, or "" when unset.
+func (c *ClaudeConfig) SystemPrompt() (string, error) {
+	if c.SystemPromptFile == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(c.SystemPromptFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read claude.system_prompt_file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ResumesSerialized reports whether queries resuming the same Claude
+// conversation run one at a time: always, unless serialize_resumes is false.
+func (c *ClaudeConfig) ResumesSerialized() bool {
+	return c.SerializeResumes == nil || *c.SerializeResumes
+}
+
 func expandEnv(s string) string {
 	return os.Expand(s, func(key string) string {
 		return os.Getenv(key)
@@ -143,17 +944,121 @@ func expandEnv(s string) string {
 func (c *Config) String() string {
 	var sb strings.Builder
 	sb.WriteString("Configuration:\n")
+	if label := c.Environment.Label(); label != "" {
+		sb.WriteString(fmt.Sprintf("  Environment: %s\n", label))
+	}
+	sb.WriteString(fmt.Sprintf("  Messaging Platform: %s\n", c.Messaging.Platform))
 	sb.WriteString(fmt.Sprintf("  Telegram Token: %s\n", maskSecret(c.Telegram.Token)))
 	sb.WriteString(fmt.Sprintf("  Telegram Rate Limit: %d/%s\n", c.Telegram.RateLimit, c.Telegram.RateWindow))
+	if o := c.Telegram.RateLimitOverrides; len(o.Chats)+len(o.ChatTypes) > 0 {
+		sb.WriteString(fmt.Sprintf("  Telegram Rate Limit Overrides: %d chats, %d chat types\n", len(o.Chats), len(o.ChatTypes)))
+	}
+	if ee := c.Telegram.ErrorEscalation; ee.Threshold > 0 {
+		sb.WriteString(fmt.Sprintf("  Error Escalation: after %d failures (notify admins: %v)\n", ee.Threshold, ee.NotifyAdmins))
+	}
+	if c.Telegram.AnswerThreads {
+		sb.WriteString("  Telegram Answer Threads: enabled\n")
+	}
+	if c.Telegram.UpdateStallTimeout > 0 {
+		sb.WriteString(fmt.Sprintf("  Telegram Update Stall Timeout: %s\n", c.Telegram.UpdateStallTimeout))
+	}
 	sb.WriteString(fmt.Sprintf("  Claude CLI Path: %s\n", c.Claude.CLIPath))
 	sb.WriteString(fmt.Sprintf("  Claude Project Path: %s\n", c.Claude.ProjectPath))
 	sb.WriteString(fmt.Sprintf("  Claude Model: %s\n", c.Claude.Model))
 	sb.WriteString(fmt.Sprintf("  Claude Query Timeout: %s\n", c.Claude.QueryTimeout))
 	sb.WriteString(fmt.Sprintf("  Claude Max Sessions: %d\n", c.Claude.MaxConcurrentSessions))
+	if len(c.Claude.AllowedModels) > 0 {
+		sb.WriteString(fmt.Sprintf("  Claude Allowed Models: %s\n", strings.Join(c.Claude.AllowedModels, ", ")))
+	}
+	if c.Claude.AllowedProjectRoot != "" {
+		sb.WriteString(fmt.Sprintf("  Claude Allowed Project Root: %s\n", c.Claude.AllowedProjectRoot))
+	}
+	if len(c.Claude.Projects) > 0 {
+		names := make([]string, 0, len(c.Claude.Projects))
+		for name := range c.Claude.Projects {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		sb.WriteString(fmt.Sprintf("  Claude Projects: %s\n", strings.Join(names, ", ")))
+	}
+	if qa := c.Claude.QueueAlert; qa.Threshold > 0 {
+		sb.WriteString(fmt.Sprintf("  Claude Queue Alert: %d for %s\n", qa.Threshold, qa.Duration))
+	}
+	if c.Claude.WorkspaceCheckTTL > 0 {
+		sb.WriteString(fmt.Sprintf("  Claude Workspace Check: every %s\n", c.Claude.WorkspaceCheckTTL))
+	}
+	if !c.Claude.ResumesSerialized() {
+		sb.WriteString("  Claude Serialize Resumes: disabled\n")
+	}
+	if c.Claude.SystemPromptFile != "" {
+		sb.WriteString(fmt.Sprintf("  Claude System Prompt File: %s\n", c.Claude.SystemPromptFile))
+	}
+	if c.Claude.TraceQueries {
+		sb.WriteString("  Claude Query Tracing: enabled\n")
+	}
+	sb.WriteString(fmt.Sprintf("  Claude Idle Session Cleanup: every %s, after %s idle\n", c.Claude.IdleCleanupInterval, c.Claude.MaxIdle))
+	if c.Claude.MemoryPressure.ThresholdMB > 0 {
+		sb.WriteString(fmt.Sprintf("  Claude Memory Threshold: %d MB\n", c.Claude.MemoryPressure.ThresholdMB))
+	}
 	sb.WriteString(fmt.Sprintf("  Context TTL: %s\n", c.Context.TTL))
 	sb.WriteString(fmt.Sprintf("  Context Cleanup Interval: %s\n", c.Context.CleanupInterval))
 	sb.WriteString(fmt.Sprintf("  Context Validation: %v\n", c.Context.ValidationEnabled))
+	if c.Context.ValidationMode == "llm" {
+		sb.WriteString(fmt.Sprintf("  Context Validation Mode: llm (timeout %s)\n", c.Context.ValidationLLMTimeout))
+	}
+	if n := len(c.Context.ValidationKeywords); n > 0 {
+		sb.WriteString(fmt.Sprintf("  Context Validation Keywords: %d\n", n))
+	}
+	if n := len(c.Context.ValidationDenyPatterns); n > 0 {
+		sb.WriteString(fmt.Sprintf("  Context Validation Deny Patterns: %d\n", n))
+	}
+	if len(c.Context.TransferRules) > 0 {
+		sb.WriteString(fmt.Sprintf("  Context Transfer Rules: %s\n", strings.Join(c.Context.TransferRules, ", ")))
+	}
+	if c.Context.Summarize.Enabled {
+		sb.WriteString(fmt.Sprintf("  Context Summarize: enabled (timeout %s)\n", c.Context.Summarize.Timeout))
+	}
+	sb.WriteString(fmt.Sprintf("  Confirmed Commands: %v\n", c.Security.Confirmation.Commands))
+	if ks := c.Tools.K8sSummary; ks.Enabled {
+		sb.WriteString(fmt.Sprintf("  K8s Summary: enabled (%d custom patterns)\n", len(ks.Resources)))
+	}
+	if c.Security.RedactionStats {
+		sb.WriteString("  Redaction Stats: enabled\n")
+	}
+	if c.Security.RedactionMode == "hash" {
+		sb.WriteString("  Redaction Mode: hash\n")
+	}
+	if c.Security.RedactQueries {
+		sb.WriteString("  Redact Queries: enabled\n")
+	}
+	if hr := c.Security.HeavyRedaction; hr.Threshold > 0 {
+		sb.WriteString(fmt.Sprintf("  Heavy Redaction Threshold: %.0f%% (notify user: %v)\n", hr.Threshold*100, hr.NotifyUser))
+	}
+	sb.WriteString(fmt.Sprintf("  API Enabled: %v (addr: %s, token: %s)\n", c.API.Enabled, c.API.Addr, maskSecret(c.API.Token)))
+	if c.Metrics.Addr != "" {
+		sb.WriteString(fmt.Sprintf("  Metrics Addr: %s\n", c.Metrics.Addr))
+	}
+	if m := c.Maintenance; len(m.Windows) > 0 {
+		sb.WriteString(fmt.Sprintf("  Maintenance Windows: %d (%s)\n", len(m.Windows), m.Timezone))
+	}
 	sb.WriteString(fmt.Sprintf("  Storage DB Path: %s\n", c.Storage.DBPath))
+	sb.WriteString(fmt.Sprintf("  Storage Snapshots: %v\n", c.Storage.SnapshotsEnabled))
+	if c.Storage.BatchInterval > 0 {
+		sb.WriteString(fmt.Sprintf("  Storage Write Batching: %d rows / %s\n", c.Storage.BatchSize, c.Storage.BatchInterval))
+	}
+	if c.Storage.ReplicaDSN != "" {
+		sb.WriteString(fmt.Sprintf("  Storage Read Replica: %s\n", c.Storage.ReplicaDSN))
+	}
+	sb.WriteString(fmt.Sprintf("  Storage Max Open Connections: %d\n", c.Storage.MaxOpenConns))
+	if c.Storage.CSVExport {
+		sb.WriteString("  Storage CSV Export: enabled\n")
+	}
+	if c.Storage.RecordRawOutput {
+		sb.WriteString("  Storage Raw Output Recording: enabled\n")
+	}
+	if c.Storage.ReadWorkers > 0 {
+		sb.WriteString(fmt.Sprintf("  Storage Read Pool: %d workers / %d queued\n", c.Storage.ReadWorkers, c.Storage.ReadQueueSize))
+	}
 	return sb.String()
 }
 
@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestMaskSecret(t *testing.T) {
@@ -372,3 +375,601 @@ func TestConfig_String(t *testing.T) {
 		t.Error("String() should contain CLI path")
 	}
 }
+
+func TestLoad_ReportsAllErrors(t *testing.T) {
+	config := `
+telegram:
+  token: ""
+  allowed_chat_ids: []
+
+claude:
+  cli_path: "/nonexistent/path/to/claude"
+  project_path: "/nonexistent/project/path"
+  max_concurrent_sessions: 0
+
+storage:
+  db_path: ""
+`
+
+	configPath, cleanup := createTestConfig(t, config)
+	defer cleanup()
+
+	os.Setenv("CONFIG_PATH", configPath)
+	defer os.Unsetenv("CONFIG_PATH")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("Expected error for invalid config")
+	}
+
+	expected := []string{
+		"telegram.token",
+		"telegram.allowed_chat_ids",
+		"claude.cli_path does not exist",
+		"claude.project_path does not exist",
+		"claude.query_timeout",
+		"claude.max_concurrent_sessions",
+		"context.ttl",
+		"context.cleanup_interval",
+		"storage.db_path",
+	}
+	for _, want := range expected {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error should mention %q: %v", want, err)
+		}
+	}
+}
+
+func TestValidate_ValidConfigReturnsNil(t *testing.T) {
+	tmpDir, _ := os.MkdirTemp("", "config-test-*")
+	defer os.RemoveAll(tmpDir)
+
+	cliPath := filepath.Join(tmpDir, "claude")
+	if err := os.WriteFile(cliPath, []byte("#!/bin/bash"), 0755); err != nil {
+		t.Fatalf("Failed to create CLI: %v", err)
+	}
+
+	cfg := &Config{
+		Telegram: TelegramConfig{Token: "token", AllowedChatIDs: []string{"1"}},
+		Claude: ClaudeConfig{
+			CLIPath:               cliPath,
+			ProjectPath:           tmpDir,
+			QueryTimeout:          time.Minute,
+			MaxConcurrentSessions: 1,
+		},
+		Context: ContextConfig{TTL: time.Hour, CleanupInterval: time.Minute},
+		Storage: StorageConfig{DBPath: "./data/test.db"},
+	}
+
+	if err := cfg.validate(); err != nil {
+		t.Errorf("validate() = %v, want nil", err)
+	}
+}
+
+func TestEnvironmentConfig_Label(t *testing.T) {
+	tests := []struct {
+		env  EnvironmentConfig
+		want string
+	}{
+		{EnvironmentConfig{}, ""},
+		{EnvironmentConfig{Name: "prod", LabelFormat: "[{name}]"}, "[PROD]"},
+		{EnvironmentConfig{Name: "staging", LabelFormat: "🟡 {name}"}, "🟡 STAGING"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.env.Label(); got != tt.want {
+			t.Errorf("Label() for %+v = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestValidate_EnvironmentLabelFormat(t *testing.T) {
+	cfg := &Config{Environment: EnvironmentConfig{Name: "prod"}}
+	_ = cfg.validate()
+	if cfg.Environment.LabelFormat != "[{name}]" {
+		t.Errorf("LabelFormat default = %q, want [{name}]", cfg.Environment.LabelFormat)
+	}
+
+	cfg = &Config{Environment: EnvironmentConfig{Name: "prod", LabelFormat: "PROD"}}
+	err := cfg.validate()
+	if err == nil || !strings.Contains(err.Error(), "environment.label_format") {
+		t.Errorf("Expected label_format error, got %v", err)
+	}
+}
+
+func TestLoad_MessagingPlatform(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "slack_requires_token",
+			config: `
+messaging:
+  platform: slack
+telegram:
+  allowed_chat_ids: ["123456"]
+`,
+			wantErr: "slack.token",
+		},
+		{
+			name: "discord_requires_token",
+			config: `
+messaging:
+  platform: discord
+telegram:
+  allowed_chat_ids: ["123456"]
+`,
+			wantErr: "discord.token",
+		},
+		{
+			name: "unknown_platform",
+			config: `
+messaging:
+  platform: matrix
+telegram:
+  allowed_chat_ids: ["123456"]
+`,
+			wantErr: "messaging.platform",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath, cleanup := createTestConfig(t, tt.config)
+			defer cleanup()
+
+			os.Setenv("CONFIG_PATH", configPath)
+			defer os.Unsetenv("CONFIG_PATH")
+
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning %s, got %v", tt.wantErr, err)
+			}
+			if err != nil && strings.Contains(err.Error(), "telegram.token") {
+				t.Errorf("telegram.token should not be required for %s: %v", tt.name, err)
+			}
+		})
+	}
+}
+
+func TestValidate_SandboxChatsMustBeAllowed(t *testing.T) {
+	cfg := &Config{
+		Telegram: TelegramConfig{Token: "token", AllowedChatIDs: []string{"1"}, SandboxChatIDs: []string{"1", "2"}},
+	}
+
+	err := cfg.validate()
+	if err == nil || !strings.Contains(err.Error(), `sandbox_chat_ids entry "2"`) {
+		t.Errorf("Expected error for sandbox chat not in allowed_chat_ids, got %v", err)
+	}
+	if strings.Contains(err.Error(), `entry "1"`) {
+		t.Errorf("Allowed sandbox chat should not be reported: %v", err)
+	}
+}
+
+func TestValidate_Canary(t *testing.T) {
+	tests := []struct {
+		name    string
+		canary  CanaryConfig
+		wantErr string
+	}{
+		{"disabled", CanaryConfig{}, ""},
+		{"model only", CanaryConfig{Percent: 10, Model: "opus"}, ""},
+		{"out of range", CanaryConfig{Percent: 101, Model: "opus"}, "claude.canary.percent"},
+		{"nothing to change", CanaryConfig{Percent: 10}, "requires a model or prompt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Claude: ClaudeConfig{Canary: tt.canary}}
+			err := cfg.validate()
+			mentions := err != nil && strings.Contains(err.Error(), "canary")
+			if tt.wantErr == "" && mentions {
+				t.Errorf("Unexpected canary error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_QueueAlertDefaults(t *testing.T) {
+	cfg := &Config{Claude: ClaudeConfig{QueueAlert: QueueAlert{Threshold: 5}}}
+	_ = cfg.validate()
+	if cfg.Claude.QueueAlert.Duration != time.Minute || cfg.Claude.QueueAlert.CheckInterval != 10*time.Second {
+		t.Errorf("Unexpected defaults: %+v", cfg.Claude.QueueAlert)
+	}
+
+	cfg = &Config{Claude: ClaudeConfig{QueueAlert: QueueAlert{Threshold: -1}}}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "claude.queue_alert.threshold") {
+		t.Errorf("Expected negative threshold error, got %v", err)
+	}
+}
+
+func TestValidate_IdleCleanup(t *testing.T) {
+	cfg := &Config{}
+	_ = cfg.validate()
+	if cfg.Claude.IdleCleanupInterval != 5*time.Minute || cfg.Claude.MaxIdle != time.Hour {
+		t.Errorf("Unexpected defaults: interval %s, max idle %s", cfg.Claude.IdleCleanupInterval, cfg.Claude.MaxIdle)
+	}
+
+	cfg = &Config{Claude: ClaudeConfig{MaxIdle: -time.Minute}}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "claude.idle_cleanup_interval and max_idle") {
+		t.Errorf("Expected negative max_idle error, got %v", err)
+	}
+}
+
+func TestValidate_ModelNames(t *testing.T) {
+	cfg := &Config{Claude: ClaudeConfig{Model: "claude-opus-4-1", AllowedModels: []string{"sonnet", "opus"}}}
+	if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "model") {
+		t.Errorf("Unexpected model error: %v", err)
+	}
+
+	cfg = &Config{Claude: ClaudeConfig{Model: "--help", AllowedModels: []string{"Opus", "opus --verbose"}}}
+	err := cfg.validate()
+	for _, want := range []string{`claude.model "--help"`, `"Opus"`, `"opus --verbose"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error mentioning %s, got %v", want, err)
+		}
+	}
+}
+
+func TestValidate_OnCall(t *testing.T) {
+	cfg := &Config{OnCall: OnCallConfig{Enabled: true, Rotation: OnCallRotation{
+		Start: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC),
+		Users: []OnCallMember{{Name: "alice", ChatID: "1"}},
+	}}}
+	if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "oncall") {
+		t.Errorf("Unexpected oncall error: %v", err)
+	}
+	if cfg.OnCall.Rotation.Shift != 7*24*time.Hour {
+		t.Errorf("Shift default = %v, want 168h", cfg.OnCall.Rotation.Shift)
+	}
+
+	cfg = &Config{OnCall: OnCallConfig{Enabled: true, Rotation: OnCallRotation{Users: []OnCallMember{{Name: "bob"}}}}}
+	err := cfg.validate()
+	for _, want := range []string{"oncall.rotation.users[0].chat_id", "oncall.rotation.start"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error mentioning %s, got %v", want, err)
+		}
+	}
+}
+
+func TestLoad_OnCallRotationStart(t *testing.T) {
+	var cfg Config
+	if err := yaml.Unmarshal([]byte("oncall:\n  rotation:\n    start: 2024-01-01T09:00:00Z\n"), &cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if want := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC); !cfg.OnCall.Rotation.Start.Equal(want) {
+		t.Errorf("Start = %v, want %v", cfg.OnCall.Rotation.Start, want)
+	}
+}
+
+func TestValidate_WorkspaceCheckTTL(t *testing.T) {
+	cfg := &Config{Claude: ClaudeConfig{WorkspaceCheckTTL: -time.Second}}
+	err := cfg.validate()
+	if err == nil || !strings.Contains(err.Error(), "claude.workspace_check_ttl") {
+		t.Errorf("Expected workspace_check_ttl error, got %v", err)
+	}
+}
+
+func TestValidate_InlineCacheDefault(t *testing.T) {
+	cfg := &Config{Telegram: TelegramConfig{Inline: InlineMode{Enabled: true}}}
+	_ = cfg.validate()
+	if cfg.Telegram.Inline.CacheTTL != 5*time.Minute {
+		t.Errorf("Inline.CacheTTL default = %v, want 5m", cfg.Telegram.Inline.CacheTTL)
+	}
+}
+
+func TestValidate_ErrorEscalationThreshold(t *testing.T) {
+	cfg := &Config{Telegram: TelegramConfig{ErrorEscalation: ErrorEscalation{Threshold: -1}}}
+	err := cfg.validate()
+	if err == nil || !strings.Contains(err.Error(), "telegram.error_escalation.threshold") {
+		t.Errorf("Expected error_escalation.threshold error, got %v", err)
+	}
+}
+
+func TestValidate_RateLimitOverrides(t *testing.T) {
+	cfg := &Config{Telegram: TelegramConfig{
+		RateWindow: 2 * time.Minute,
+		RateLimitOverrides: RateLimitOverrides{
+			Chats:     map[string]RateLimitOverride{"-100": {Limit: 60}},
+			ChatTypes: map[string]RateLimitOverride{"group": {Limit: 5, Window: time.Minute}},
+		},
+	}}
+	err := cfg.validate()
+	if err != nil && strings.Contains(err.Error(), "rate_limit_overrides") {
+		t.Errorf("Unexpected override error: %v", err)
+	}
+	if got := cfg.Telegram.RateLimitOverrides.Chats["-100"].Window; got != 2*time.Minute {
+		t.Errorf("Unset override window = %v, want rate_window (2m)", got)
+	}
+	if got := cfg.Telegram.RateLimitOverrides.ChatTypes["group"].Window; got != time.Minute {
+		t.Errorf("Explicit override window = %v, want 1m", got)
+	}
+
+	cfg = &Config{Telegram: TelegramConfig{
+		RateLimitOverrides: RateLimitOverrides{
+			Chats:     map[string]RateLimitOverride{"-100": {Limit: 0}},
+			ChatTypes: map[string]RateLimitOverride{"supergroup": {Limit: 5}},
+		},
+	}}
+	err = cfg.validate()
+	for _, want := range []string{"chats.-100.limit", "chat_types key"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error mentioning %q, got %v", want, err)
+		}
+	}
+}
+
+func TestValidate_TransferRules(t *testing.T) {
+	cfg := &Config{Context: ContextConfig{TransferRules: []string{"private->group", "* -> private"}}}
+	if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "transfer_rules") {
+		t.Errorf("Unexpected transfer_rules error: %v", err)
+	}
+
+	cfg = &Config{Context: ContextConfig{TransferRules: []string{"private=>group"}}}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "context.transfer_rules") {
+		t.Errorf("Expected transfer_rules error, got %v", err)
+	}
+}
+
+func TestValidate_MaintenanceWindows(t *testing.T) {
+	cfg := &Config{Maintenance: MaintenanceConfig{Windows: []MaintenanceWindow{{Schedule: "0 2 * * 0", Duration: 2 * time.Hour}}}}
+	if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "maintenance") {
+		t.Errorf("Unexpected maintenance error: %v", err)
+	}
+	if cfg.Maintenance.Timezone != "UTC" {
+		t.Errorf("Timezone = %q, want UTC by default", cfg.Maintenance.Timezone)
+	}
+
+	cfg = &Config{Maintenance: MaintenanceConfig{
+		Timezone: "Mars/Olympus",
+		Windows: []MaintenanceWindow{
+			{Schedule: "0 2 * *", Duration: time.Hour},
+			{Schedule: "0 2 * * 0", Duration: 0},
+		},
+	}}
+	err := cfg.validate()
+	for _, want := range []string{"maintenance.timezone", "windows[0].schedule", "windows[1].duration"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %s error, got %v", want, err)
+		}
+	}
+}
+
+func TestValidate_RedactionMode(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "redaction_mode") {
+		t.Errorf("Unexpected redaction_mode error: %v", err)
+	}
+	if cfg.Security.RedactionMode != "plain" {
+		t.Errorf("RedactionMode = %q, want plain by default", cfg.Security.RedactionMode)
+	}
+
+	cfg = &Config{Security: SecurityConfig{RedactionMode: "sha1"}}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "security.redaction_mode") {
+		t.Errorf("Expected redaction_mode error, got %v", err)
+	}
+}
+
+func TestValidate_UpdateStallTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, 90 * time.Second, 5 * time.Minute} {
+		cfg := &Config{Telegram: TelegramConfig{UpdateStallTimeout: timeout}}
+		if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "update_stall_timeout") {
+			t.Errorf("update_stall_timeout %s: unexpected error %v", timeout, err)
+		}
+	}
+
+	for _, timeout := range []time.Duration{-time.Second, 30 * time.Second} {
+		cfg := &Config{Telegram: TelegramConfig{UpdateStallTimeout: timeout}}
+		if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "telegram.update_stall_timeout") {
+			t.Errorf("update_stall_timeout %s: expected error, got %v", timeout, err)
+		}
+	}
+}
+
+func TestValidate_SystemPromptFile(t *testing.T) {
+	dir := t.TempDir()
+	promptFile := filepath.Join(dir, "prompt.md")
+	if err := os.WriteFile(promptFile, []byte("\nNever run destructive commands.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Claude: ClaudeConfig{SystemPromptFile: promptFile}}
+	if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "system_prompt_file") {
+		t.Errorf("Unexpected system_prompt_file error: %v", err)
+	}
+	if prompt, err := cfg.Claude.SystemPrompt(); err != nil || prompt != "Never run destructive commands." {
+		t.Errorf("SystemPrompt() = %q, %v", prompt, err)
+	}
+
+	bigFile := filepath.Join(dir, "big.md")
+	if err := os.WriteFile(bigFile, []byte(strings.Repeat("x", maxSystemPromptSize+1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "missing.md"), dir, bigFile} {
+		cfg := &Config{Claude: ClaudeConfig{SystemPromptFile: path}}
+		if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "claude.system_prompt_file") {
+			t.Errorf("system_prompt_file %s: expected error, got %v", path, err)
+		}
+	}
+
+	if prompt, err := (&ClaudeConfig{}).SystemPrompt(); err != nil || prompt != "" {
+		t.Errorf("SystemPrompt() without a file = %q, %v", prompt, err)
+	}
+}
+
+func TestClaudeConfig_ResumesSerialized(t *testing.T) {
+	for _, tt := range []struct {
+		yaml string
+		want bool
+	}{
+		{"", true},
+		{"serialize_resumes: true", true},
+		{"serialize_resumes: false", false},
+	} {
+		var cfg ClaudeConfig
+		if err := yaml.Unmarshal([]byte(tt.yaml), &cfg); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", tt.yaml, err)
+		}
+		if got := cfg.ResumesSerialized(); got != tt.want {
+			t.Errorf("%q: ResumesSerialized() = %v, want %v", tt.yaml, got, tt.want)
+		}
+	}
+}
+
+func TestValidate_K8sSummaryPatterns(t *testing.T) {
+	cfg := &Config{Tools: ToolsConfig{K8sSummary: K8sSummary{Enabled: true, Resources: map[string]string{"ingresses": `^NAME\s+CLASS`}}}}
+	if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "k8s_summary") {
+		t.Errorf("Unexpected k8s_summary error: %v", err)
+	}
+
+	cfg = &Config{Tools: ToolsConfig{K8sSummary: K8sSummary{Resources: map[string]string{"bad": `(`}}}}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "tools.k8s_summary.resources[bad]") {
+		t.Errorf("Expected k8s_summary error, got %v", err)
+	}
+}
+
+func TestValidate_ValidationDenyPatterns(t *testing.T) {
+	cfg := &Config{Context: ContextConfig{ValidationDenyPatterns: []string{`(?i)ignore previous`, `[`}}}
+	err := cfg.validate()
+	if err == nil || !strings.Contains(err.Error(), `context.validation_deny_patterns entry "["`) {
+		t.Errorf("Expected deny pattern error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "ignore previous") {
+		t.Errorf("Valid pattern should not be reported: %v", err)
+	}
+}
+
+func TestValidate_MaxOpenConns(t *testing.T) {
+	cfg := &Config{}
+	_ = cfg.validate()
+	if cfg.Storage.MaxOpenConns != 50 {
+		t.Errorf("MaxOpenConns = %d, want 50 by default", cfg.Storage.MaxOpenConns)
+	}
+
+	cfg = &Config{Storage: StorageConfig{MaxOpenConns: -1}}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "storage.max_open_conns") {
+		t.Errorf("Expected max_open_conns error, got %v", err)
+	}
+}
+
+func TestValidate_ValidationMode(t *testing.T) {
+	cfg := &Config{}
+	_ = cfg.validate()
+	if cfg.Context.ValidationMode != "keywords" || cfg.Context.ValidationLLMTimeout != 0 {
+		t.Errorf("ValidationMode = %q, timeout %s; want keywords without a timeout by default",
+			cfg.Context.ValidationMode, cfg.Context.ValidationLLMTimeout)
+	}
+
+	cfg = &Config{Context: ContextConfig{ValidationMode: "llm"}}
+	_ = cfg.validate()
+	if cfg.Context.ValidationLLMTimeout != 10*time.Second {
+		t.Errorf("ValidationLLMTimeout = %s, want 10s by default in llm mode", cfg.Context.ValidationLLMTimeout)
+	}
+
+	cfg = &Config{Context: ContextConfig{ValidationMode: "regex"}}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "context.validation_mode") {
+		t.Errorf("Expected validation_mode error, got %v", err)
+	}
+}
+
+func TestValidate_HeavyRedactionThreshold(t *testing.T) {
+	for threshold, wantErr := range map[float64]bool{0: false, 0.5: false, 1: false, -0.1: true, 1.5: true} {
+		cfg := &Config{Security: SecurityConfig{HeavyRedaction: HeavyRedactionConfig{Threshold: threshold}}}
+		err := cfg.validate()
+		if got := err != nil && strings.Contains(err.Error(), "security.heavy_redaction.threshold"); got != wantErr {
+			t.Errorf("threshold %v: error = %v, want error %v", threshold, err, wantErr)
+		}
+	}
+}
+
+func TestValidate_Projects(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{Claude: ClaudeConfig{Projects: map[string]string{"cluster-a": dir}}}
+	if err := cfg.validate(); err != nil && strings.Contains(err.Error(), "claude.projects") {
+		t.Errorf("Unexpected projects error: %v", err)
+	}
+
+	cfg = &Config{Claude: ClaudeConfig{Projects: map[string]string{
+		"Cluster-A": dir,
+		"missing":   "/nonexistent/project/path",
+		"empty":     "",
+	}}}
+	err := cfg.validate()
+	for _, want := range []string{`"Cluster-A" must be lowercase`, "claude.projects.missing does not exist", "claude.projects.empty path is required"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error mentioning %s, got %v", want, err)
+		}
+	}
+}
+
+func TestValidateProjectPath(t *testing.T) {
+	root := t.TempDir()
+	inside := filepath.Join(root, "cluster-a")
+	if err := os.Mkdir(inside, 0o755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	outside := t.TempDir()
+	link := filepath.Join(root, "escape")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+	linkedRoot := filepath.Join(t.TempDir(), "root-link")
+	if err := os.Symlink(root, linkedRoot); err != nil {
+		t.Fatalf("Failed to create symlink: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		root    string
+		wantErr string
+	}{
+		{"no root", outside, "", ""},
+		{"inside root", inside, root, ""},
+		{"root itself", root, root, ""},
+		{"outside root", outside, root, "outside claude.allowed_project_root"},
+		{"relative traversal", "../../etc", "", `must not contain ".."`},
+		{"traversal out of root", root + "/cluster-a/../../etc", root, `must not contain ".."`},
+		{"sibling with root prefix", root + "-other", root, "outside claude.allowed_project_root"},
+		{"missing", filepath.Join(root, "missing"), root, "does not exist"},
+		{"symlink out of root", link, root, "outside claude.allowed_project_root"},
+		{"symlinked root", inside, linkedRoot, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProjectPath(tt.path, tt.root, "claude.project_path")
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidate_AllowedProjectRoot(t *testing.T) {
+	root := t.TempDir()
+	cfg := &Config{Claude: ClaudeConfig{
+		ProjectPath:        t.TempDir(),
+		AllowedProjectRoot: root,
+		Projects:           map[string]string{"escape": root + "/../.."},
+	}}
+	err := cfg.validate()
+	for _, want := range []string{"claude.project_path is outside", `claude.projects.escape must not contain ".."`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error mentioning %s, got %v", want, err)
+		}
+	}
+
+	cfg = &Config{Claude: ClaudeConfig{AllowedProjectRoot: "srv/ops"}}
+	if err := cfg.validate(); err == nil || !strings.Contains(err.Error(), "allowed_project_root must be an absolute path") {
+		t.Errorf("Expected relative root error, got %v", err)
+	}
+}
@@ -17,6 +17,7 @@ type ExpiryWorker struct {
 	sessionManager  *claude.SessionManager
 	interval        time.Duration
 	cleanupCallback CleanupCallback
+	reconcile       bool
 }
 
 func NewExpiryWorker(storage *storage.Storage, sm *claude.SessionManager, interval time.Duration) *ExpiryWorker {
@@ -32,11 +33,24 @@ func (ew *ExpiryWorker) SetCleanupCallback(cb CleanupCallback) {
 	ew.cleanupCallback = cb
 }
 
+// SetReconcileDuplicates makes the worker deactivate all but the most
+// recently used of any active contexts sharing a Claude session, on startup
+// and on every cleanup tick.
+func (ew *ExpiryWorker) SetReconcileDuplicates(enabled bool) {
+	ew.reconcile = enabled
+}
+
 func (ew *ExpiryWorker) Start(ctx context.Context) {
 	ticker := time.NewTicker(ew.interval)
 	defer ticker.Stop()
 
-	slog.Info("Starting expiry worker", "interval", ew.interval)
+	slog.Info("Starting expiry worker", "interval", ew.interval, "reconcile_duplicates", ew.reconcile)
+
+	if ew.reconcile {
+		if _, err := ew.ReconcileDuplicates(); err != nil {
+			slog.Error("Error during duplicate session reconciliation", "error", err)
+		}
+	}
 
 	for {
 		select {
@@ -44,6 +58,11 @@ func (ew *ExpiryWorker) Start(ctx context.Context) {
 			if err := ew.cleanupExpired(); err != nil {
 				slog.Error("Error during cleanup", "error", err)
 			}
+			if ew.reconcile {
+				if _, err := ew.ReconcileDuplicates(); err != nil {
+					slog.Error("Error during duplicate session reconciliation", "error", err)
+				}
+			}
 		case <-ctx.Done():
 			slog.Info("Expiry worker stopped")
 			return
@@ -73,6 +92,34 @@ func (ew *ExpiryWorker) cleanupExpired() error {
 	return nil
 }
 
+// ReconcileDuplicates finds active contexts sharing a Claude session and
+// cleans up all but the most recently used one. Returns the number of
+// contexts deactivated.
+func (ew *ExpiryWorker) ReconcileDuplicates() (int, error) {
+	duplicates, err := ew.storage.FindDuplicateActiveClaudeSessions()
+	if err != nil {
+		return 0, err
+	}
+
+	reconciled := 0
+	for _, dup := range duplicates {
+		keep := dup.Contexts[0]
+		for _, ctx := range dup.Contexts[1:] {
+			slog.Warn("Reconciling duplicate Claude session",
+				"claude_session_id", dup.ClaudeSessionID,
+				"kept_chat_id", keep.ChatID,
+				"deactivated_chat_id", ctx.ChatID)
+			if err := ew.cleanupContext(ctx, "reconcile"); err != nil {
+				slog.Warn("Failed to reconcile context", "chat_id", ctx.ChatID, "error", err)
+				continue
+			}
+			reconciled++
+		}
+	}
+
+	return reconciled, nil
+}
+
 func (ew *ExpiryWorker) cleanupContext(ctx *storage.ChatContext, cleanupType string) error {
 	slog.Info("Cleaning up context", "chat_id", ctx.ChatID, "session_id", ctx.SessionID, "type", cleanupType)
 
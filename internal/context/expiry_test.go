@@ -0,0 +1,74 @@
+package context
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rg/aiops/internal/claude"
+	"github.com/rg/aiops/internal/storage"
+)
+
+// setupTestStorage creates a storage backed by a temp database with the real
+// migrations applied (they are resolved relative to the module root).
+func setupTestStorage(t *testing.T) *storage.Storage {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	if err := os.Chdir(filepath.Join(oldWd, "..", "..")); err != nil {
+		t.Fatalf("Failed to chdir to module root: %v", err)
+	}
+	store, err := storage.NewStorage(filepath.Join(tmpDir, "test.db"))
+	os.Chdir(oldWd)
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestReconcileDuplicates_KeepsMostRecent(t *testing.T) {
+	store := setupTestStorage(t)
+	sm := claude.NewSessionManager("/bin/true", t.TempDir(), "", 10, time.Minute)
+	ew := NewExpiryWorker(store, sm, time.Minute)
+
+	var cleaned []string
+	ew.SetCleanupCallback(func(chatID string) { cleaned = append(cleaned, chatID) })
+
+	for _, chatID := range []string{"chat1", "chat2", "chat3"} {
+		if _, err := store.CreateContext(chatID, "group", "session-"+chatID, time.Hour); err != nil {
+			t.Fatalf("CreateContext failed: %v", err)
+		}
+		if err := store.UpdateClaudeSessionID(chatID, "claude-shared"); err != nil {
+			t.Fatalf("UpdateClaudeSessionID failed: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	n, err := ew.ReconcileDuplicates()
+	if err != nil {
+		t.Fatalf("ReconcileDuplicates failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 contexts reconciled, got %d", n)
+	}
+	if len(cleaned) != 2 {
+		t.Errorf("Expected cleanup callback for 2 chats, got %v", cleaned)
+	}
+
+	if ctx, _ := store.GetContext("chat3"); ctx == nil || !ctx.IsActive {
+		t.Error("Expected most recent context chat3 to stay active")
+	}
+	for _, chatID := range []string{"chat1", "chat2"} {
+		if ctx, _ := store.GetContext(chatID); ctx == nil || ctx.IsActive {
+			t.Errorf("Expected %s to be deactivated", chatID)
+		}
+	}
+
+	dups, err := store.FindDuplicateActiveClaudeSessions()
+	if err != nil || len(dups) != 0 {
+		t.Errorf("Expected no duplicates after reconciliation, got %v (err %v)", dups, err)
+	}
+}
@@ -114,3 +114,24 @@ func (m *Manager) GenerateSessionID() string {
 func (m *Manager) GetTTL() time.Duration {
 	return m.ttl
 }
+
+// SetTTL overrides the session TTL for a chat. A ttl of 0 restores the default.
+func (m *Manager) SetTTL(chatID string, ttl time.Duration) error {
+	if err := m.storage.SetTTL(chatID, ttl); err != nil {
+		return fmt.Errorf("failed to set ttl: %w", err)
+	}
+	return nil
+}
+
+// EffectiveTTL returns the chat's TTL override, or the configured TTL if none
+// is set. The second return value reports whether an override is in effect.
+func (m *Manager) EffectiveTTL(chatID string) (time.Duration, bool, error) {
+	override, err := m.storage.GetTTL(chatID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get ttl: %w", err)
+	}
+	if override > 0 {
+		return override, true, nil
+	}
+	return m.ttl, false, nil
+}
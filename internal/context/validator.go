@@ -1,15 +1,68 @@
 package context
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rg/aiops/internal/storage"
 )
 
+// DefaultKeywords mark a query as on-topic when no keywords are configured.
+var DefaultKeywords = []string{
+	"pod", "deployment", "service", "namespace", "kubectl",
+	"argocd", "application", "sync", "deploy",
+	"jira", "ticket", "issue", "sprint", "story",
+	"log", "logs", "error", "crash", "incident",
+	"monitor", "metric", "dashboard", "alert",
+	"kafka", "redis", "database", "postgres",
+	"payment", "provider", "transaction",
+	"kubernetes", "k8s", "helm", "kustomize",
+	"datadog", "slack", "github", "pr", "pull request",
+}
+
+const (
+	// llmVerdictTTL is how long a classification is reused for the same query.
+	llmVerdictTTL = 10 * time.Minute
+	// maxLLMVerdicts bounds the classification cache.
+	maxLLMVerdicts = 1000
+)
+
+// llmPrompt asks Claude whether a query is on-topic; the query is appended.
+const llmPrompt = "You screen messages sent to an SRE assistant bot. Is the following message a question or request " +
+	"about infrastructure, operations, deployments, incidents, monitoring or other SRE work? " +
+	"Answer with only yes or no.\n\nMessage: "
+
+const offTopicReason = "Query doesn't appear to be related to SRE operations. Please ask about infrastructure, deployments, incidents, or related topics."
+
+// OneShotExecutor answers a prompt in a fresh conversation (avoids circular
+// import with claude package)
+type OneShotExecutor interface {
+	ExecuteOneShot(ctx context.Context, prompt, model string) (string, error)
+}
+
 type Validator struct {
 	storage           *storage.Storage
 	validationEnabled bool
+	keywords          []string         // Empty = DefaultKeywords
+	denyPatterns      []*regexp.Regexp // Reject matching queries, even with validation disabled
+
+	// LLM mode: queries without keywords or history are classified by Claude
+	llm        OneShotExecutor // nil = keyword matching only
+	llmModel   string
+	llmTimeout time.Duration
+	verdictsMu sync.Mutex
+	verdicts   map[string]llmVerdict // Normalized query -> cached classification
+}
+
+// llmVerdict is a cached classification of a query.
+type llmVerdict struct {
+	onTopic bool
+	expires time.Time
 }
 
 // NewValidator creates a new Validator. The projectPath parameter is accepted
@@ -23,7 +76,53 @@ func NewValidator(storage *storage.Storage, projectPath string, validationEnable
 	}, nil
 }
 
+// SetKeywords replaces DefaultKeywords as the words that mark a query as
+// on-topic. Matching is case-insensitive. An empty list keeps the defaults.
+func (v *Validator) SetKeywords(keywords []string) {
+	v.keywords = nil
+	for _, k := range keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			v.keywords = append(v.keywords, k)
+		}
+	}
+}
+
+// SetDenyPatterns rejects queries matching any of patterns, whatever their
+// keywords or session history. Unlike keyword checks, they apply even with
+// validation disabled.
+func (v *Validator) SetDenyPatterns(patterns []string) error {
+	denyPatterns := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("invalid deny pattern %q: %w", p, err)
+		}
+		denyPatterns = append(denyPatterns, re)
+	}
+	v.denyPatterns = denyPatterns
+	return nil
+}
+
+// SetLLMClassifier asks Claude, through executor, whether queries that the
+// keyword checks would reject are about infrastructure after all. A non-empty
+// model replaces the configured one for these calls. When the call fails or
+// takes longer than timeout, the keyword verdict stands. Answers are cached
+// for a few minutes per query.
+func (v *Validator) SetLLMClassifier(executor OneShotExecutor, model string, timeout time.Duration) {
+	v.llm = executor
+	v.llmModel = model
+	v.llmTimeout = timeout
+	v.verdicts = make(map[string]llmVerdict)
+}
+
 func (v *Validator) ValidateQuery(ctx *storage.ChatContext, query string) (bool, string, error) {
+	for _, re := range v.denyPatterns {
+		if re.MatchString(query) {
+			slog.Warn("Query matched a deny pattern", "chat_id", ctx.ChatID, "pattern", re.String())
+			return false, "This query isn't allowed.", nil
+		}
+	}
+
 	if !v.validationEnabled {
 		return true, "", nil
 	}
@@ -33,16 +132,9 @@ func (v *Validator) ValidateQuery(ctx *storage.ChatContext, query string) (bool,
 		return false, "empty query", nil
 	}
 
-	keywords := []string{
-		"pod", "deployment", "service", "namespace", "kubectl",
-		"argocd", "application", "sync", "deploy",
-		"jira", "ticket", "issue", "sprint", "story",
-		"log", "logs", "error", "crash", "incident",
-		"monitor", "metric", "dashboard", "alert",
-		"kafka", "redis", "database", "postgres",
-		"payment", "provider", "transaction",
-		"kubernetes", "k8s", "helm", "kustomize",
-		"datadog", "slack", "github", "pr", "pull request",
+	keywords := v.keywords
+	if len(keywords) == 0 {
+		keywords = DefaultKeywords
 	}
 
 	queryLower := strings.ToLower(query)
@@ -66,5 +158,63 @@ func (v *Validator) ValidateQuery(ctx *storage.ChatContext, query string) (bool,
 		return true, "", nil
 	}
 
-	return false, "Query doesn't appear to be related to SRE operations. Please ask about infrastructure, deployments, incidents, or related topics.", nil
+	if v.llm != nil && v.classify(ctx.ChatID, query) {
+		return true, "", nil
+	}
+
+	return false, offTopicReason, nil
+}
+
+// classify reports whether Claude considers query on-topic, using a cached
+// answer when there is one. Failures and unclear answers count as off-topic
+// and aren't cached, so keyword matching decides.
+func (v *Validator) classify(chatID, query string) bool {
+	key := strings.ToLower(strings.Join(strings.Fields(query), " "))
+	now := time.Now()
+
+	v.verdictsMu.Lock()
+	verdict, ok := v.verdicts[key]
+	v.verdictsMu.Unlock()
+	if ok && now.Before(verdict.expires) {
+		return verdict.onTopic
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), v.llmTimeout)
+	defer cancel()
+	answer, err := v.llm.ExecuteOneShot(ctx, llmPrompt+query, v.llmModel)
+	if err != nil {
+		slog.Warn("LLM query validation failed, falling back to keywords", "chat_id", chatID, "error", err)
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	var onTopic bool
+	switch {
+	case strings.HasPrefix(answer, "yes"):
+		onTopic = true
+	case strings.HasPrefix(answer, "no"):
+		onTopic = false
+	default:
+		if len(answer) > 100 {
+			answer = answer[:100] + "..."
+		}
+		slog.Warn("Unclear LLM query validation answer, falling back to keywords", "chat_id", chatID, "answer", answer)
+		return false
+	}
+	slog.Info("LLM query validation", "chat_id", chatID, "on_topic", onTopic)
+
+	v.verdictsMu.Lock()
+	defer v.verdictsMu.Unlock()
+	if len(v.verdicts) >= maxLLMVerdicts {
+		for k, old := range v.verdicts {
+			if !now.Before(old.expires) {
+				delete(v.verdicts, k)
+			}
+		}
+		if len(v.verdicts) >= maxLLMVerdicts {
+			v.verdicts = make(map[string]llmVerdict)
+		}
+	}
+	v.verdicts[key] = llmVerdict{onTopic: onTopic, expires: now.Add(llmVerdictTTL)}
+	return onTopic
 }
@@ -0,0 +1,365 @@
+package discord
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// maxMessageLength is Discord's limit on message content, in characters.
+const maxMessageLength = 2000
+
+// eventBuffer bounds how many gateway events may wait for the handler.
+const eventBuffer = 100
+
+type Client struct {
+	session         *discordgo.Session
+	reactionHandler messaging.ReactionHandler
+	events          chan func()
+	stop            chan struct{}
+	stopOnce        sync.Once
+}
+
+// Ensure Client implements messaging.Platform
+var _ messaging.Platform = (*Client)(nil)
+
+func NewClient(token string) (*Client, error) {
+	if token == "" {
+		return nil, fmt.Errorf("discord token is required")
+	}
+
+	session, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discord session: %w", err)
+	}
+	session.Identify.Intents = discordgo.IntentsGuildMessages |
+		discordgo.IntentsDirectMessages |
+		discordgo.IntentsMessageContent |
+		discordgo.IntentsGuildMessageReactions |
+		discordgo.IntentsDirectMessageReactions
+
+	return &Client{
+		session: session,
+		events:  make(chan func(), eventBuffer),
+		stop:    make(chan struct{}),
+	}, nil
+}
+
+// SetReactionHandler enables delivery of reactions users add to messages.
+func (c *Client) SetReactionHandler(handler messaging.ReactionHandler) {
+	c.reactionHandler = handler
+}
+
+// SendMessage sends text to a channel, splitting it into several messages
+// when it exceeds Discord's length limit. The ID of the first message is
+// returned so replies and edits target the start of the response.
+func (c *Client) SendMessage(outMsg *messaging.OutgoingMessage) (string, error) {
+	var firstID string
+	for i, chunk := range splitMessage(outMsg.Text, maxMessageLength) {
+		send := &discordgo.MessageSend{
+			Content:         chunk,
+			AllowedMentions: noMentions(),
+		}
+		if i == 0 && outMsg.ReplyToMessageID != "" {
+			send.Reference = &discordgo.MessageReference{
+				MessageID: outMsg.ReplyToMessageID,
+				ChannelID: outMsg.ChatID,
+			}
+		}
+
+		sent, err := c.session.ChannelMessageSendComplex(outMsg.ChatID, send)
+		if err != nil {
+			return firstID, fmt.Errorf("failed to send message: %w", err)
+		}
+		if i == 0 {
+			firstID = sent.ID
+		}
+	}
+	return firstID, nil
+}
+
+// SendDocument uploads content as a file attachment with an optional caption.
+func (c *Client) SendDocument(chatID, filename string, content []byte, caption string) (string, error) {
+	sent, err := c.session.ChannelMessageSendComplex(chatID, &discordgo.MessageSend{
+		Content: truncate(caption, maxMessageLength),
+		Files: []*discordgo.File{{
+			Name:   filename,
+			Reader: bytes.NewReader(content),
+		}},
+		AllowedMentions: noMentions(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to send document: %w", err)
+	}
+	return sent.ID, nil
+}
+
+// EditMessage replaces the content of a message the bot sent. Text over the
+// length limit is truncated since an edit cannot add messages.
+func (c *Client) EditMessage(chatID, messageID, text string) error {
+	content := truncate(text, maxMessageLength)
+	edit := discordgo.NewMessageEdit(chatID, messageID).SetContent(content)
+	edit.AllowedMentions = noMentions()
+	if _, err := c.session.ChannelMessageEditComplex(edit); err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) AddReaction(chatID, messageID, emoji string) error {
+	if err := c.session.MessageReactionAdd(chatID, messageID, emoji); err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) SendTyping(chatID string) error {
+	if err := c.session.ChannelTyping(chatID); err != nil {
+		return fmt.Errorf("failed to send typing indicator: %w", err)
+	}
+	return nil
+}
+
+// GetChatType looks the channel up in the gateway state cache, falling back
+// to the REST API for channels the cache has not seen.
+func (c *Client) GetChatType(chatID string) (messaging.ChatType, error) {
+	channel, err := c.session.State.Channel(chatID)
+	if err != nil {
+		channel, err = c.session.Channel(chatID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get channel: %w", err)
+		}
+	}
+	return convertChannelType(channel.Type), nil
+}
+
+func (c *Client) IsGroupOrChannel(chatID string) bool {
+	chatType, err := c.GetChatType(chatID)
+	if err != nil {
+		slog.Warn("Failed to get chat type", "chat_id", chatID, "error", err)
+		return false
+	}
+	return chatType.IsGroupOrChannel()
+}
+
+// Start connects to the gateway and handles events until Stop is called.
+// Events are handled one at a time, in the order they arrive, so the gateway
+// connection keeps reading while a long query runs.
+func (c *Client) Start(handler messaging.MessageHandler) error {
+	c.session.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+		if m.Author == nil || m.Author.Bot {
+			return
+		}
+		msg := convertMessage(m.Message, s.State.User.ID, c.chatTypeOf(m.Message))
+		c.enqueue(func() {
+			if err := handler(msg); err != nil {
+				slog.Error("Error handling message", "error", err)
+			}
+		})
+	})
+
+	if c.reactionHandler != nil {
+		c.session.AddHandler(func(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+			if r.MessageReaction == nil || r.UserID == s.State.User.ID {
+				return
+			}
+			reaction := convertReaction(r)
+			c.enqueue(func() {
+				if err := c.reactionHandler(reaction); err != nil {
+					slog.Error("Error handling reaction", "error", err)
+				}
+			})
+		})
+	}
+
+	if err := c.session.Open(); err != nil {
+		return fmt.Errorf("failed to connect to discord: %w", err)
+	}
+	slog.Info("Discord bot started, listening for messages", "username", c.session.State.User.Username)
+
+	for {
+		select {
+		case <-c.stop:
+			return nil
+		case event := <-c.events:
+			event()
+		}
+	}
+}
+
+// Stop gracefully shuts down the Discord client
+func (c *Client) Stop() {
+	slog.Info("Stopping Discord bot")
+	c.stopOnce.Do(func() {
+		close(c.stop)
+		if err := c.session.Close(); err != nil {
+			slog.Warn("Failed to close discord session", "error", err)
+		}
+	})
+}
+
+// enqueue hands an event to the Start loop, dropping it if the bot is
+// stopping or too far behind.
+func (c *Client) enqueue(event func()) {
+	select {
+	case c.events <- event:
+	case <-c.stop:
+	default:
+		slog.Warn("Discord event queue full, dropping event")
+	}
+}
+
+// chatTypeOf resolves the chat type of the channel a message was posted in.
+// Messages outside a guild are direct messages when the channel is unknown.
+func (c *Client) chatTypeOf(m *discordgo.Message) messaging.ChatType {
+	if chatType, err := c.GetChatType(m.ChannelID); err == nil {
+		return chatType
+	}
+	if m.GuildID == "" {
+		return messaging.ChatTypePrivate
+	}
+	return messaging.ChatTypeGroup
+}
+
+func convertMessage(m *discordgo.Message, botID string, chatType messaging.ChatType) *messaging.IncomingMessage {
+	msg := &messaging.IncomingMessage{
+		ChatID:    m.ChannelID,
+		MessageID: m.ID,
+		Text:      m.ContentWithMentionsReplaced(),
+		Kind:      detectMessageKind(m),
+		Timestamp: m.Timestamp,
+
+		// Filtering metadata
+		ChatType:         chatType,
+		IsMentioningBot:  detectBotMention(m, botID),
+		IsReplyToBot:     detectReplyToBot(m, botID),
+		ReplyToMessageID: getReplyToMessageID(m),
+	}
+
+	if m.Author != nil {
+		msg.From = messaging.User{
+			ID:        m.Author.ID,
+			Username:  m.Author.Username,
+			FirstName: m.Author.GlobalName,
+		}
+	}
+
+	return msg
+}
+
+// detectMessageKind classifies a Discord message by its first attachment.
+func detectMessageKind(m *discordgo.Message) messaging.MessageKind {
+	switch {
+	case len(m.Attachments) > 0:
+		contentType := m.Attachments[0].ContentType
+		switch {
+		case strings.HasPrefix(contentType, "image/"):
+			return messaging.MessageKindPhoto
+		case strings.HasPrefix(contentType, "video/"):
+			return messaging.MessageKindVideo
+		case strings.HasPrefix(contentType, "audio/"):
+			return messaging.MessageKindAudio
+		default:
+			return messaging.MessageKindDocument
+		}
+	case len(m.StickerItems) > 0:
+		return messaging.MessageKindSticker
+	case m.Content != "":
+		return messaging.MessageKindText
+	default:
+		return messaging.MessageKindOther
+	}
+}
+
+func detectBotMention(m *discordgo.Message, botID string) bool {
+	for _, user := range m.Mentions {
+		if user != nil && user.ID == botID {
+			return true
+		}
+	}
+	return false
+}
+
+func detectReplyToBot(m *discordgo.Message, botID string) bool {
+	ref := m.ReferencedMessage
+	return ref != nil && ref.Author != nil && ref.Author.ID == botID
+}
+
+func getReplyToMessageID(m *discordgo.Message) string {
+	if m.MessageReference == nil {
+		return ""
+	}
+	return m.MessageReference.MessageID
+}
+
+func convertReaction(r *discordgo.MessageReactionAdd) *messaging.IncomingReaction {
+	reaction := &messaging.IncomingReaction{
+		ChatID:    r.ChannelID,
+		MessageID: r.MessageID,
+		From:      messaging.User{ID: r.UserID},
+		Emoji:     r.Emoji.Name,
+	}
+	if r.Member != nil && r.Member.User != nil {
+		reaction.From.Username = r.Member.User.Username
+		reaction.From.FirstName = r.Member.User.GlobalName
+	}
+	return reaction
+}
+
+// convertChannelType maps Discord channel types onto platform chat types.
+// Announcement channels behave like Telegram channels; everything else in a
+// guild is a group.
+func convertChannelType(t discordgo.ChannelType) messaging.ChatType {
+	switch t {
+	case discordgo.ChannelTypeDM:
+		return messaging.ChatTypePrivate
+	case discordgo.ChannelTypeGuildNews, discordgo.ChannelTypeGuildNewsThread:
+		return messaging.ChatTypeChannel
+	default:
+		return messaging.ChatTypeGroup
+	}
+}
+
+// noMentions stops bot output from pinging users, roles or @everyone.
+func noMentions() *discordgo.MessageAllowedMentions {
+	return &discordgo.MessageAllowedMentions{Parse: []discordgo.AllowedMentionType{}}
+}
+
+// splitMessage breaks text into chunks of at most limit characters,
+// preferring to split at line breaks.
+func splitMessage(text string, limit int) []string {
+	runes := []rune(text)
+	var chunks []string
+	for len(runes) > limit {
+		cut := limit
+		if i := lastIndexRune(runes[:limit], '\n'); i > 0 {
+			cut = i + 1
+		}
+		chunks = append(chunks, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	return append(chunks, string(runes))
+}
+
+func lastIndexRune(runes []rune, r rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// truncate shortens text to at most limit characters.
+func truncate(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,184 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+const botID = "999"
+
+func TestConvertMessage(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	bot := &discordgo.User{ID: botID, Username: "aiops"}
+	m := &discordgo.Message{
+		ID:        "42",
+		ChannelID: "100",
+		GuildID:   "7",
+		Content:   "<@999> check pods",
+		Timestamp: ts,
+		Author:    &discordgo.User{ID: "5", Username: "alice", GlobalName: "Alice"},
+		Mentions:  []*discordgo.User{bot},
+	}
+
+	msg := convertMessage(m, botID, messaging.ChatTypeGroup)
+
+	if msg.ChatID != "100" || msg.MessageID != "42" {
+		t.Errorf("IDs = %q/%q, want 100/42", msg.ChatID, msg.MessageID)
+	}
+	if msg.Text != "@aiops check pods" {
+		t.Errorf("Text = %q, want mention replaced with username", msg.Text)
+	}
+	if msg.Kind != messaging.MessageKindText {
+		t.Errorf("Kind = %q, want text", msg.Kind)
+	}
+	if !msg.Timestamp.Equal(ts) {
+		t.Errorf("Timestamp = %v, want %v", msg.Timestamp, ts)
+	}
+	if msg.From.ID != "5" || msg.From.Username != "alice" || msg.From.FirstName != "Alice" {
+		t.Errorf("From = %+v", msg.From)
+	}
+	if msg.ChatType != messaging.ChatTypeGroup {
+		t.Errorf("ChatType = %q, want group", msg.ChatType)
+	}
+	if !msg.IsMentioningBot {
+		t.Error("IsMentioningBot = false, want true")
+	}
+	if msg.IsReplyToBot || msg.ReplyToMessageID != "" {
+		t.Errorf("unexpected reply metadata: %v %q", msg.IsReplyToBot, msg.ReplyToMessageID)
+	}
+}
+
+func TestConvertMessage_Reply(t *testing.T) {
+	tests := []struct {
+		name        string
+		refAuthorID string
+		want        bool
+	}{
+		{name: "reply_to_bot", refAuthorID: botID, want: true},
+		{name: "reply_to_user", refAuthorID: "5", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &discordgo.Message{
+				ID:                "43",
+				ChannelID:         "100",
+				Content:           "and now?",
+				Author:            &discordgo.User{ID: "6"},
+				MessageReference:  &discordgo.MessageReference{MessageID: "41", ChannelID: "100"},
+				ReferencedMessage: &discordgo.Message{ID: "41", Author: &discordgo.User{ID: tt.refAuthorID}},
+			}
+
+			msg := convertMessage(m, botID, messaging.ChatTypeGroup)
+			if msg.IsReplyToBot != tt.want {
+				t.Errorf("IsReplyToBot = %v, want %v", msg.IsReplyToBot, tt.want)
+			}
+			if msg.ReplyToMessageID != "41" {
+				t.Errorf("ReplyToMessageID = %q, want 41", msg.ReplyToMessageID)
+			}
+			if msg.IsMentioningBot {
+				t.Error("IsMentioningBot = true, want false")
+			}
+		})
+	}
+}
+
+func TestDetectMessageKind(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *discordgo.Message
+		want messaging.MessageKind
+	}{
+		{"text", &discordgo.Message{Content: "hi"}, messaging.MessageKindText},
+		{"photo", &discordgo.Message{Attachments: []*discordgo.MessageAttachment{{ContentType: "image/png"}}}, messaging.MessageKindPhoto},
+		{"video", &discordgo.Message{Attachments: []*discordgo.MessageAttachment{{ContentType: "video/mp4"}}}, messaging.MessageKindVideo},
+		{"audio", &discordgo.Message{Attachments: []*discordgo.MessageAttachment{{ContentType: "audio/ogg"}}}, messaging.MessageKindAudio},
+		{"document_with_caption", &discordgo.Message{Content: "log", Attachments: []*discordgo.MessageAttachment{{ContentType: "text/plain"}}}, messaging.MessageKindDocument},
+		{"sticker", &discordgo.Message{StickerItems: []*discordgo.StickerItem{{ID: "1"}}}, messaging.MessageKindSticker},
+		{"empty", &discordgo.Message{}, messaging.MessageKindOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectMessageKind(tt.msg); got != tt.want {
+				t.Errorf("detectMessageKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertChannelType(t *testing.T) {
+	tests := []struct {
+		in   discordgo.ChannelType
+		want messaging.ChatType
+	}{
+		{discordgo.ChannelTypeDM, messaging.ChatTypePrivate},
+		{discordgo.ChannelTypeGroupDM, messaging.ChatTypeGroup},
+		{discordgo.ChannelTypeGuildText, messaging.ChatTypeGroup},
+		{discordgo.ChannelTypeGuildPublicThread, messaging.ChatTypeGroup},
+		{discordgo.ChannelTypeGuildNews, messaging.ChatTypeChannel},
+	}
+
+	for _, tt := range tests {
+		if got := convertChannelType(tt.in); got != tt.want {
+			t.Errorf("convertChannelType(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvertReaction(t *testing.T) {
+	r := &discordgo.MessageReactionAdd{
+		MessageReaction: &discordgo.MessageReaction{
+			UserID:    "5",
+			MessageID: "42",
+			ChannelID: "100",
+			Emoji:     discordgo.Emoji{Name: "🔁"},
+		},
+		Member: &discordgo.Member{User: &discordgo.User{ID: "5", Username: "alice"}},
+	}
+
+	got := convertReaction(r)
+	if got.ChatID != "100" || got.MessageID != "42" || got.Emoji != "🔁" {
+		t.Errorf("convertReaction() = %+v", got)
+	}
+	if got.From.ID != "5" || got.From.Username != "alice" {
+		t.Errorf("From = %+v", got.From)
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	if got := splitMessage("short", 10); len(got) != 1 || got[0] != "short" {
+		t.Errorf("splitMessage(short) = %q", got)
+	}
+
+	text := strings.Repeat("a", 6) + "\n" + strings.Repeat("b", 6)
+	got := splitMessage(text, 10)
+	if len(got) != 2 || got[0] != "aaaaaa\n" || got[1] != "bbbbbb" {
+		t.Errorf("splitMessage() = %q, want split at newline", got)
+	}
+
+	long := strings.Repeat("ж", 25)
+	got = splitMessage(long, 10)
+	if len(got) != 3 || strings.Join(got, "") != long {
+		t.Errorf("splitMessage() = %q, want 3 chunks covering the input", got)
+	}
+	for _, chunk := range got {
+		if utf8.RuneCountInString(chunk) > 10 {
+			t.Errorf("chunk %q exceeds limit", chunk)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("hello", 10); got != "hello" {
+		t.Errorf("truncate() = %q, want unchanged", got)
+	}
+	if got := truncate("hello world", 5); got != "hell…" {
+		t.Errorf("truncate() = %q, want hell…", got)
+	}
+}
@@ -4,6 +4,8 @@ import "time"
 
 type Platform interface {
 	SendMessage(msg *OutgoingMessage) (string, error)
+	SendDocument(chatID, filename string, content []byte, caption string) (string, error)
+	EditMessage(chatID, messageID, text string) error
 	AddReaction(chatID, messageID, emoji string) error
 	SendTyping(chatID string) error
 	GetChatType(chatID string) (ChatType, error)
@@ -14,11 +16,69 @@ type Platform interface {
 
 type MessageHandler func(msg *IncomingMessage) error
 
+// ReactionHandler handles a reaction a user added to a message.
+type ReactionHandler func(reaction *IncomingReaction) error
+
+// ReactionSource is implemented by platforms that can deliver reaction updates.
+// The handler must be set before Start.
+type ReactionSource interface {
+	SetReactionHandler(handler ReactionHandler)
+}
+
+// InlineQueryHandler answers a query typed as "@bot query" in any chat with
+// the text to offer as the result. An empty answer offers no result.
+type InlineQueryHandler func(query *IncomingInlineQuery) (string, error)
+
+// InlineQuerySource is implemented by platforms with an inline mode, such as
+// Telegram's. The handler must be set before Start.
+type InlineQuerySource interface {
+	SetInlineQueryHandler(handler InlineQueryHandler)
+}
+
+// CommandMenu is implemented by platforms that can show users a list of the
+// bot's commands, e.g. Telegram's autocomplete menu.
+type CommandMenu interface {
+	SetCommands(commands []BotCommand) error
+}
+
+// ForumTopics is implemented by platforms whose group chats can be split
+// into topics, such as Telegram forum supergroups.
+type ForumTopics interface {
+	IsForum(chatID string) (bool, error)
+	// CreateForumTopic creates a topic and returns its thread ID for
+	// OutgoingMessage.ThreadID
+	CreateForumTopic(chatID, name string) (string, error)
+}
+
+// BotCommand is an entry in the platform's command menu.
+type BotCommand struct {
+	Name        string // Command without the leading slash
+	Description string
+	ChatIDs     []string // Chats that see the command; empty means everyone
+}
+
+// IncomingReaction is a single emoji reaction added to a message
+type IncomingReaction struct {
+	ChatID    string
+	MessageID string // Message that was reacted to
+	From      User
+	Emoji     string
+}
+
+// IncomingInlineQuery is a query a user typed after the bot's username.
+// It is not tied to a chat the bot is in.
+type IncomingInlineQuery struct {
+	ID    string
+	From  User
+	Query string
+}
+
 type IncomingMessage struct {
 	ChatID    string
 	MessageID string
 	From      User
-	Text      string
+	Text      string // Message text, or the caption for media messages
+	Kind      MessageKind
 	Timestamp time.Time
 
 	// Filtering metadata (platform-agnostic)
@@ -28,11 +88,35 @@ type IncomingMessage struct {
 	ReplyToMessageID string   // ID of message being replied to (empty if not a reply)
 }
 
+// MessageKind is the type of content an incoming message carries.
+// An empty kind is treated as text.
+type MessageKind string
+
+const (
+	MessageKindText     MessageKind = "text"
+	MessageKindPhoto    MessageKind = "photo"
+	MessageKindDocument MessageKind = "document"
+	MessageKindVideo    MessageKind = "video"
+	MessageKindVoice    MessageKind = "voice"
+	MessageKindAudio    MessageKind = "audio"
+	MessageKindSticker  MessageKind = "sticker"
+	MessageKindLocation MessageKind = "location"
+	MessageKindContact  MessageKind = "contact"
+	MessageKindOther    MessageKind = "other"
+)
+
+// IsText reports whether the message is plain text.
+func (k MessageKind) IsText() bool {
+	return k == "" || k == MessageKindText
+}
+
 // OutgoingMessage represents a message to be sent by the bot
 type OutgoingMessage struct {
 	ChatID           string
 	Text             string
 	ReplyToMessageID string // Optional: message ID to reply to (empty = no reply)
+	PlainText        bool   // Optional: send without Markdown parsing
+	ThreadID         string // Optional: forum topic to post in (empty = main chat)
 }
 
 type User struct {
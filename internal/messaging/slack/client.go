@@ -11,6 +11,9 @@ type Client struct {
 	token string
 }
 
+// Ensure Client implements messaging.Platform
+var _ messaging.Platform = (*Client)(nil)
+
 func NewClient(token string) (*Client, error) {
 	if token == "" {
 		return nil, fmt.Errorf("slack token is required")
@@ -27,6 +30,14 @@ func (c *Client) SendMessage(msg *messaging.OutgoingMessage) (string, error) {
 	return "", fmt.Errorf("slack integration not yet implemented")
 }
 
+func (c *Client) SendDocument(chatID, filename string, content []byte, caption string) (string, error) {
+	return "", fmt.Errorf("slack integration not yet implemented")
+}
+
+func (c *Client) EditMessage(chatID, messageID, text string) error {
+	return fmt.Errorf("slack integration not yet implemented")
+}
+
 func (c *Client) AddReaction(chatID, messageID, emoji string) error {
 	return fmt.Errorf("slack integration not yet implemented")
 }
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -12,9 +13,20 @@ import (
 )
 
 type Client struct {
-	bot *tgbotapi.BotAPI
+	bot             *tgbotapi.BotAPI
+	reactionHandler messaging.ReactionHandler
+	inlineHandler   messaging.InlineQueryHandler
+	inlineLatest    sync.Map      // User ID -> ID of their latest inline query
+	inlineTimeout   time.Duration // Before a placeholder answers an inline query
+	formatFallback  bool
+	stallTimeout    time.Duration // 0 = wait on getUpdates indefinitely
+	stop            chan struct{}
+	stopOnce        sync.Once
 }
 
+// Ensure Client implements messaging.Platform
+var _ messaging.Platform = (*Client)(nil)
+
 // ReactionType represents a Telegram reaction for the setMessageReaction API call.
 // This is needed because go-telegram-bot-api/v5.5.1 predates native reaction support.
 type ReactionType struct {
@@ -41,7 +53,8 @@ func NewClient(token string) (*Client, error) {
 	slog.Info("Authorized on Telegram account", "username", bot.Self.UserName)
 
 	return &Client{
-		bot: bot,
+		bot:  bot,
+		stop: make(chan struct{}),
 	}, nil
 }
 
@@ -52,7 +65,6 @@ func (c *Client) SendMessage(outMsg *messaging.OutgoingMessage) (string, error)
 	}
 
 	msg := tgbotapi.NewMessage(chatIDInt, outMsg.Text)
-	msg.ParseMode = "Markdown"
 
 	// Add reply-to if specified
 	if outMsg.ReplyToMessageID != "" {
@@ -67,19 +79,108 @@ func (c *Client) SendMessage(outMsg *messaging.OutgoingMessage) (string, error)
 		}
 	}
 
-	// Send with markdown, fallback to plain text
-	sentMsg, err := c.bot.Send(msg)
-	if err != nil {
-		msg.ParseMode = ""
-		sentMsg, err = c.bot.Send(msg)
-		if err != nil {
-			return "", fmt.Errorf("failed to send message: %w", err)
+	// Try each format in turn until Telegram accepts one. With the fallback
+	// ladder, stop early when the message is rejected for something other than
+	// its formatting; without it, any error gets one plain text retry
+	var lastErr error
+	for i, format := range c.sendFormats(outMsg.PlainText) {
+		msg.ParseMode = format.parseMode
+		msg.Text = outMsg.Text
+		if format.transform != nil {
+			msg.Text = format.transform(outMsg.Text)
+		}
+		if i > 0 {
+			slog.Debug("Retrying message with simpler formatting",
+				"chat_id", outMsg.ChatID,
+				"format", format.name,
+				"error", lastErr)
+		}
+
+		sentMsg, err := c.send(msg, outMsg.ThreadID)
+		if err == nil {
+			return strconv.Itoa(sentMsg.MessageID), nil
 		}
+		lastErr = err
+		if c.formatFallback && !isFormatError(err) {
+			break
+		}
+	}
+
+	return "", fmt.Errorf("failed to send message: %w", lastErr)
+}
+
+// EditMessage replaces the text of a message the bot sent earlier. Editing a
+// message to its current text is not an error.
+func (c *Client) EditMessage(chatID, messageID, text string) error {
+	chatIDInt, err := parseChatID(chatID)
+	if err != nil {
+		return err
+	}
+	msgID, err := strconv.Atoi(messageID)
+	if err != nil {
+		return fmt.Errorf("invalid message ID: %w", err)
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatIDInt, msgID, text)
+	edit.ParseMode = "Markdown"
+
+	// Edit with markdown, fallback to plain text
+	_, err = c.bot.Send(edit)
+	if err != nil && !isNotModified(err) {
+		edit.ParseMode = ""
+		_, err = c.bot.Send(edit)
+	}
+	if err != nil && !isNotModified(err) {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	return nil
+}
+
+// isNotModified reports whether err is Telegram rejecting an edit that would
+// leave the message unchanged.
+func isNotModified(err error) bool {
+	return strings.Contains(err.Error(), "message is not modified")
+}
+
+// maxCaptionLength is Telegram's limit for media captions, in characters.
+const maxCaptionLength = 1024
+
+// defaultDocumentName is used when SendDocument is called without a filename.
+const defaultDocumentName = "document.txt"
+
+// SendDocument uploads content as a file attachment and returns the message ID.
+// Captions longer than Telegram allows are truncated.
+func (c *Client) SendDocument(chatID, filename string, content []byte, caption string) (string, error) {
+	chatIDInt, err := parseChatID(chatID)
+	if err != nil {
+		return "", err
+	}
+
+	if filename == "" {
+		filename = defaultDocumentName
+	}
+
+	doc := tgbotapi.NewDocument(chatIDInt, tgbotapi.FileBytes{Name: filename, Bytes: content})
+	doc.Caption = truncateCaption(caption)
+
+	sentMsg, err := c.bot.Send(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to send document: %w", err)
 	}
 
 	return strconv.Itoa(sentMsg.MessageID), nil
 }
 
+// truncateCaption shortens caption to maxCaptionLength characters.
+func truncateCaption(caption string) string {
+	runes := []rune(caption)
+	if len(runes) <= maxCaptionLength {
+		return caption
+	}
+	return string(runes[:maxCaptionLength-1]) + "…"
+}
+
 func (c *Client) AddReaction(chatID, messageID, emoji string) error {
 	chatIDInt, err := parseChatID(chatID)
 	if err != nil {
@@ -91,6 +192,9 @@ func (c *Client) AddReaction(chatID, messageID, emoji string) error {
 		return fmt.Errorf("invalid message ID: %w", err)
 	}
 
+	// Telegram rejects emoji outside its reaction set, so substitute a default
+	emoji = resolveReaction(emoji)
+
 	// Build params for the setMessageReaction API call
 	params := make(tgbotapi.Params)
 	params.AddNonZero64("chat_id", chatIDInt)
@@ -156,8 +260,16 @@ func (c *Client) IsGroupOrChannel(chatID string) bool {
 }
 
 func (c *Client) Start(handler messaging.MessageHandler) error {
+	if c.reactionHandler != nil || c.inlineHandler != nil || c.stallTimeout > 0 {
+		slog.Info("Telegram bot started, listening for messages",
+			"reactions", c.reactionHandler != nil,
+			"inline_queries", c.inlineHandler != nil,
+			"stall_timeout", c.stallTimeout)
+		return c.pollUpdates(handler)
+	}
+
 	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	u.Timeout = pollTimeout
 
 	updates := c.bot.GetUpdatesChan(u)
 
@@ -180,14 +292,22 @@ func (c *Client) Start(handler messaging.MessageHandler) error {
 // Stop gracefully shuts down the Telegram client
 func (c *Client) Stop() {
 	slog.Info("Stopping Telegram bot")
+	c.stopOnce.Do(func() { close(c.stop) })
 	c.bot.StopReceivingUpdates()
 }
 
 func convertMessage(tgMsg *tgbotapi.Message, botUsername string) *messaging.IncomingMessage {
+	kind := detectMessageKind(tgMsg)
+	text := tgMsg.Text
+	if !kind.IsText() {
+		text = tgMsg.Caption
+	}
+
 	msg := &messaging.IncomingMessage{
 		ChatID:    strconv.FormatInt(tgMsg.Chat.ID, 10),
 		MessageID: strconv.Itoa(tgMsg.MessageID),
-		Text:      tgMsg.Text,
+		Text:      text,
+		Kind:      kind,
 		Timestamp: time.Unix(int64(tgMsg.Date), 0),
 
 		// Filtering metadata
@@ -210,46 +330,84 @@ func convertMessage(tgMsg *tgbotapi.Message, botUsername string) *messaging.Inco
 	return msg
 }
 
-// detectBotMention checks if the message contains an @mention of the bot.
+// detectMessageKind classifies the content of a Telegram message.
+func detectMessageKind(tgMsg *tgbotapi.Message) messaging.MessageKind {
+	switch {
+	case tgMsg.Text != "":
+		return messaging.MessageKindText
+	case len(tgMsg.Photo) > 0:
+		return messaging.MessageKindPhoto
+	case tgMsg.Document != nil:
+		return messaging.MessageKindDocument
+	case tgMsg.Video != nil, tgMsg.VideoNote != nil, tgMsg.Animation != nil:
+		return messaging.MessageKindVideo
+	case tgMsg.Voice != nil:
+		return messaging.MessageKindVoice
+	case tgMsg.Audio != nil:
+		return messaging.MessageKindAudio
+	case tgMsg.Sticker != nil:
+		return messaging.MessageKindSticker
+	case tgMsg.Location != nil, tgMsg.Venue != nil:
+		return messaging.MessageKindLocation
+	case tgMsg.Contact != nil:
+		return messaging.MessageKindContact
+	default:
+		return messaging.MessageKindOther
+	}
+}
+
+// detectBotMention checks if the message (or media caption) contains an
+// @mention of the bot.
 func detectBotMention(tgMsg *tgbotapi.Message, botUsername string) bool {
-	if tgMsg.Entities == nil || botUsername == "" {
+	// Chat is always set for real updates, but guard against partial messages
+	var chatID int64
+	if tgMsg.Chat != nil {
+		chatID = tgMsg.Chat.ID
+	}
+
+	text, entities := tgMsg.Text, tgMsg.Entities
+	if text == "" {
+		text, entities = tgMsg.Caption, tgMsg.CaptionEntities
+	}
+
+	if entities == nil || botUsername == "" {
 		slog.Debug("No entities or empty botUsername",
-			"has_entities", tgMsg.Entities != nil,
+			"has_entities", entities != nil,
 			"bot_username", botUsername,
-			"chat_id", tgMsg.Chat.ID)
+			"chat_id", chatID)
 		return false
 	}
 
 	slog.Debug("Checking for bot mention",
-		"chat_id", tgMsg.Chat.ID,
-		"text", tgMsg.Text,
+		"chat_id", chatID,
+		"text", text,
 		"bot_username", botUsername,
-		"num_entities", len(tgMsg.Entities))
+		"num_entities", len(entities))
 
-	for _, entity := range tgMsg.Entities {
+	for _, entity := range entities {
 		slog.Debug("Processing entity",
-			"chat_id", tgMsg.Chat.ID,
+			"chat_id", chatID,
 			"type", entity.Type,
 			"offset", entity.Offset,
 			"length", entity.Length)
 
 		if entity.Type == "mention" {
-			mention := extractEntityText(tgMsg.Text, entity)
+			mention := extractEntityText(text, entity)
 			slog.Debug("Found mention entity",
-				"chat_id", tgMsg.Chat.ID,
+				"chat_id", chatID,
 				"mention", mention,
 				"bot_username", botUsername,
 				"expected", "@"+botUsername)
 
 			// Compare case-insensitively (Telegram usernames are case-insensitive)
 			if strings.EqualFold(mention, "@"+botUsername) {
-				slog.Info("Bot mention detected", "chat_id", tgMsg.Chat.ID, "mention", mention)
+				slog.Info("Bot mention detected", "chat_id", chatID, "mention", mention)
 				return true
 			}
 		}
 	}
 
-	slog.Debug("No bot mention found", "chat_id", tgMsg.Chat.ID)
+	slog.Debug("No bot mention found", "chat_id", chatID)
 	return false
 }
 
@@ -1,9 +1,17 @@
 package telegram
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
 	"testing"
+	"unicode/utf8"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rg/aiops/internal/messaging"
 )
 
 func TestDetectBotMention(t *testing.T) {
@@ -107,6 +115,16 @@ func TestDetectBotMention(t *testing.T) {
 	}
 }
 
+func TestDetectBotMention_NoChat(t *testing.T) {
+	msg := &tgbotapi.Message{
+		Text:     "@mybot hello",
+		Entities: []tgbotapi.MessageEntity{{Type: "mention", Offset: 0, Length: 6}},
+	}
+	if !detectBotMention(msg, "mybot") {
+		t.Error("detectBotMention() = false, want the mention detected without a Chat")
+	}
+}
+
 func TestDetectReplyToBot(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -207,3 +225,326 @@ func TestGetReplyToMessageID(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveReaction(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji string
+		want  string
+	}{
+		{"allowed", "👍", "👍"},
+		{"variation_selector_stripped", "❤️", "❤"},
+		{"unsupported_falls_back", "✅", defaultReaction},
+		{"empty_falls_back", "", defaultReaction},
+		{"text_falls_back", "ok", defaultReaction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveReaction(tt.emoji); got != tt.want {
+				t.Errorf("resolveReaction(%q) = %q, want %q", tt.emoji, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertReactions_OnlyAdded(t *testing.T) {
+	update := &messageReactionUpdated{
+		Chat:      tgbotapi.Chat{ID: -100},
+		MessageID: 42,
+		User:      &tgbotapi.User{ID: 7, UserName: "alice"},
+		OldReaction: []ReactionType{
+			{Type: "emoji", Emoji: "👍"},
+		},
+		NewReaction: []ReactionType{
+			{Type: "emoji", Emoji: "👍"},
+			{Type: "emoji", Emoji: "👎"},
+			{Type: "custom_emoji"},
+		},
+	}
+
+	got := convertReactions(update)
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 added reaction, got %d", len(got))
+	}
+	r := got[0]
+	if r.ChatID != "-100" || r.MessageID != "42" || r.From.ID != "7" || r.From.Username != "alice" || r.Emoji != "👎" {
+		t.Errorf("Unexpected reaction %+v", r)
+	}
+}
+
+func TestTruncateCaption(t *testing.T) {
+	short := "📜 12 messages"
+	if got := truncateCaption(short); got != short {
+		t.Errorf("truncateCaption(short) = %q, want unchanged", got)
+	}
+
+	long := strings.Repeat("é", maxCaptionLength+10)
+	got := truncateCaption(long)
+	if n := len([]rune(got)); n != maxCaptionLength {
+		t.Errorf("truncateCaption(long) length = %d, want %d", n, maxCaptionLength)
+	}
+	if !strings.HasSuffix(got, "…") {
+		t.Errorf("truncateCaption(long) should end with an ellipsis")
+	}
+}
+
+func TestConvertMessage_PhotoWithCaption(t *testing.T) {
+	tgMsg := &tgbotapi.Message{
+		MessageID:       7,
+		Chat:            &tgbotapi.Chat{ID: -100, Type: "supergroup"},
+		Photo:           []tgbotapi.PhotoSize{{FileID: "p1", Width: 90, Height: 90}},
+		Caption:         "@mybot why is this graph spiking?",
+		CaptionEntities: []tgbotapi.MessageEntity{{Type: "mention", Offset: 0, Length: 6}},
+	}
+
+	msg := convertMessage(tgMsg, "mybot")
+
+	if msg.Kind != messaging.MessageKindPhoto {
+		t.Errorf("Kind = %q, want photo", msg.Kind)
+	}
+	if msg.Text != "@mybot why is this graph spiking?" {
+		t.Errorf("Text = %q, want the caption", msg.Text)
+	}
+	if !msg.IsMentioningBot {
+		t.Error("Mention in caption should be detected")
+	}
+}
+
+func TestConvertMessage_Voice(t *testing.T) {
+	tgMsg := &tgbotapi.Message{
+		MessageID: 8,
+		Chat:      &tgbotapi.Chat{ID: 42, Type: "private"},
+		Voice:     &tgbotapi.Voice{FileID: "v1", Duration: 5},
+	}
+
+	msg := convertMessage(tgMsg, "mybot")
+
+	if msg.Kind != messaging.MessageKindVoice {
+		t.Errorf("Kind = %q, want voice", msg.Kind)
+	}
+	if msg.Text != "" {
+		t.Errorf("Text = %q, want empty", msg.Text)
+	}
+	if msg.Kind.IsText() {
+		t.Error("Voice message should not be text")
+	}
+}
+
+func TestConvertMessage_Text(t *testing.T) {
+	msg := convertMessage(&tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 42, Type: "private"}, Text: "hello"}, "mybot")
+	if msg.Kind != messaging.MessageKindText || msg.Text != "hello" {
+		t.Errorf("convertMessage() = kind %q, text %q", msg.Kind, msg.Text)
+	}
+}
+
+func TestIsNotModified(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&tgbotapi.Error{Code: 400, Message: "Bad Request: message is not modified: specified new message content and reply markup are exactly the same as a current content and reply markup of the message"}, true},
+		{&tgbotapi.Error{Code: 400, Message: "Bad Request: can't parse entities"}, false},
+		{errors.New("connection reset"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isNotModified(tt.err); got != tt.want {
+			t.Errorf("isNotModified(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestBuildCommandScopes(t *testing.T) {
+	commands := []messaging.BotCommand{
+		{Name: "help", Description: "Show help"},
+		{Name: "status", Description: ""},
+		{Name: "Bad-Name", Description: "skipped"},
+		{Name: "snapshot", Description: strings.Repeat("x", 300), ChatIDs: []string{"100"}},
+	}
+
+	defaults, perChat := buildCommandScopes(commands)
+
+	if len(defaults) != 2 || defaults[0].Command != "help" || defaults[1].Description != "status" {
+		t.Errorf("defaults = %+v, want help and status with a fallback description", defaults)
+	}
+	admin := perChat["100"]
+	if len(perChat) != 1 || len(admin) != 3 || admin[2].Command != "snapshot" {
+		t.Fatalf("perChat = %+v, want chat 100 with defaults plus snapshot", perChat)
+	}
+	if n := len([]rune(admin[2].Description)); n != maxCommandDescription {
+		t.Errorf("Description length = %d, want %d", n, maxCommandDescription)
+	}
+}
+
+// newFakeAPIClient returns a Client talking to a fake Bot API whose
+// sendMessage calls are answered by sendMessage.
+func newFakeAPIClient(t *testing.T, sendMessage http.HandlerFunc) *Client {
+	t.Helper()
+	return newFakeAPIClientWith(t, map[string]http.HandlerFunc{"sendMessage": sendMessage})
+}
+
+// newFakeAPIClientWith returns a Client talking to a fake Bot API that
+// answers each method in handlers, plus getMe.
+func newFakeAPIClientWith(t *testing.T, handlers map[string]http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := path.Base(r.URL.Path)
+		if method == "getMe" {
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"testbot"}}`)
+			return
+		}
+		if handler, ok := handlers[method]; ok {
+			handler(w, r)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", server.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatalf("NewBotAPIWithAPIEndpoint() error = %v", err)
+	}
+	return &Client{bot: bot, stop: make(chan struct{})}
+}
+
+func TestSendMessage_FormatFallback(t *testing.T) {
+	var parseModes, texts []string
+	client := newFakeAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		parseModes = append(parseModes, r.FormValue("parse_mode"))
+		texts = append(texts, r.FormValue("text"))
+		if len(parseModes) <= 1 {
+			fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: can't parse entities"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":42,"chat":{"id":1}}}`)
+	})
+	client.SetFormatFallback(true)
+
+	id, err := client.SendMessage(&messaging.OutgoingMessage{ChatID: "1", Text: "*bold* \x00text"})
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if id != "42" {
+		t.Errorf("SendMessage() id = %q, want 42", id)
+	}
+
+	wantModes := []string{"Markdown", ""}
+	if len(parseModes) != len(wantModes) {
+		t.Fatalf("parse modes tried = %q, want %q", parseModes, wantModes)
+	}
+	for i := range wantModes {
+		if parseModes[i] != wantModes[i] {
+			t.Errorf("attempt %d parse_mode = %q, want %q", i+1, parseModes[i], wantModes[i])
+		}
+	}
+	if texts[1] != "*bold* text" {
+		t.Errorf("escaped plain text = %q, want control characters removed", texts[2])
+	}
+}
+
+func TestSendMessage_AllFormatsRejected(t *testing.T) {
+	attempts := 0
+	client := newFakeAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: message is too long"}`)
+	})
+
+	if _, err := client.SendMessage(&messaging.OutgoingMessage{ChatID: "1", Text: "hi"}); err == nil {
+		t.Error("SendMessage() should fail when every format is rejected")
+	}
+	if attempts != 2 {
+		t.Errorf("Without fallback enabled expected 2 attempts (Markdown, plain), got %d", attempts)
+	}
+}
+
+func TestSendMessage_NoFallbackOnOtherErrors(t *testing.T) {
+	for _, description := range []string{
+		`"error_code":429,"description":"Too Many Requests: retry after 5"`,
+		`"error_code":403,"description":"Forbidden: bot was blocked by the user"`,
+	} {
+		attempts := 0
+		client := newFakeAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			fmt.Fprint(w, `{"ok":false,`+description+`}`)
+		})
+		client.SetFormatFallback(true)
+
+		if _, err := client.SendMessage(&messaging.OutgoingMessage{ChatID: "1", Text: "hi"}); err == nil {
+			t.Errorf("SendMessage() should fail for %s", description)
+		}
+		if attempts != 1 {
+			t.Errorf("%s: expected 1 attempt, got %d", description, attempts)
+		}
+	}
+}
+
+func TestSendMessage_DefaultRetriesPlainOnAnyError(t *testing.T) {
+	var parseModes []string
+	client := newFakeAPIClient(t, func(w http.ResponseWriter, r *http.Request) {
+		parseModes = append(parseModes, r.FormValue("parse_mode"))
+		fmt.Fprint(w, `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`)
+	})
+
+	if _, err := client.SendMessage(&messaging.OutgoingMessage{ChatID: "1", Text: "hi"}); err == nil {
+		t.Error("SendMessage() should fail when every attempt is rejected")
+	}
+	if len(parseModes) != 2 || parseModes[0] != "Markdown" || parseModes[1] != "" {
+		t.Errorf("parse modes tried = %q, want Markdown then plain", parseModes)
+	}
+}
+
+func TestTruncatePlain(t *testing.T) {
+	short := "hello"
+	if got := truncatePlain(short); got != short {
+		t.Errorf("truncatePlain(%q) = %q", short, got)
+	}
+
+	// Emoji take two UTF-16 code units each
+	long := strings.Repeat("😀", maxMessageUTF16)
+	got := truncatePlain(long)
+	if n := utf16Len(got); n > maxMessageUTF16 {
+		t.Errorf("truncated length = %d UTF-16 units, want <= %d", n, maxMessageUTF16)
+	}
+	if !strings.HasSuffix(got, "…") || !utf8.ValidString(got) {
+		t.Errorf("truncatePlain should end with an ellipsis on a rune boundary")
+	}
+}
+
+func TestSendMessage_Thread(t *testing.T) {
+	var threadIDs []string
+	client := newFakeAPIClientWith(t, map[string]http.HandlerFunc{
+		"sendMessage": func(w http.ResponseWriter, r *http.Request) {
+			threadIDs = append(threadIDs, r.FormValue("message_thread_id"))
+			fmt.Fprint(w, `{"ok":true,"result":{"message_id":42,"chat":{"id":1}}}`)
+		},
+		"createForumTopic": func(w http.ResponseWriter, r *http.Request) {
+			if name := r.FormValue("name"); utf8.RuneCountInString(name) > maxTopicNameLength {
+				t.Errorf("Topic name has %d characters, want at most %d", utf8.RuneCountInString(name), maxTopicNameLength)
+			}
+			fmt.Fprint(w, `{"ok":true,"result":{"message_thread_id":7,"name":"topic"}}`)
+		},
+		"getChat": func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"type":"supergroup","is_forum":true}}`)
+		},
+	})
+
+	if forum, err := client.IsForum("1"); err != nil || !forum {
+		t.Fatalf("IsForum() = %v, %v; want true", forum, err)
+	}
+	threadID, err := client.CreateForumTopic("1", strings.Repeat("a", 200))
+	if err != nil || threadID != "7" {
+		t.Fatalf("CreateForumTopic() = %q, %v; want 7", threadID, err)
+	}
+
+	for _, thread := range []string{threadID, ""} {
+		id, err := client.SendMessage(&messaging.OutgoingMessage{ChatID: "1", Text: "hi", ThreadID: thread})
+		if err != nil || id != "42" {
+			t.Fatalf("SendMessage(thread %q) = %q, %v", thread, id, err)
+		}
+	}
+	if len(threadIDs) != 2 || threadIDs[0] != "7" || threadIDs[1] != "" {
+		t.Errorf("message_thread_id sent = %q, want [7, none]", threadIDs)
+	}
+}
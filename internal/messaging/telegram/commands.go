@@ -0,0 +1,91 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// maxCommandDescription is Telegram's limit for a command description.
+const maxCommandDescription = 256
+
+// validCommandName matches the command names setMyCommands accepts.
+var validCommandName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// SetCommands registers the command menu Telegram shows for autocomplete.
+// Commands without chat IDs form the default menu; chats listed on any
+// command get their own menu with those commands added to the default ones.
+func (c *Client) SetCommands(commands []messaging.BotCommand) error {
+	defaults, perChat := buildCommandScopes(commands)
+
+	if _, err := c.bot.Request(tgbotapi.NewSetMyCommands(defaults...)); err != nil {
+		return fmt.Errorf("failed to set default commands: %w", err)
+	}
+
+	var errs []error
+	for chatID, cmds := range perChat {
+		id, err := parseChatID(chatID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("chat %s: %w", chatID, err))
+			continue
+		}
+		if _, err := c.bot.Request(tgbotapi.NewSetMyCommandsWithScope(tgbotapi.NewBotCommandScopeChat(id), cmds...)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set commands for chat %s: %w", chatID, err))
+		}
+	}
+
+	slog.Info("Registered Telegram command menu", "commands", len(defaults), "scoped_chats", len(perChat))
+	return errors.Join(errs...)
+}
+
+// buildCommandScopes splits commands into the default menu and one menu per
+// chat that has extra commands. Names Telegram would reject are skipped.
+func buildCommandScopes(commands []messaging.BotCommand) ([]tgbotapi.BotCommand, map[string][]tgbotapi.BotCommand) {
+	var defaults []tgbotapi.BotCommand
+	scoped := make(map[string][]tgbotapi.BotCommand)
+
+	for _, cmd := range commands {
+		name := strings.TrimPrefix(cmd.Name, "/")
+		if !validCommandName.MatchString(name) {
+			slog.Warn("Skipping command Telegram can't show in its menu", "command", cmd.Name)
+			continue
+		}
+		entry := tgbotapi.BotCommand{Command: name, Description: truncateDescription(cmd.Description, name)}
+		if len(cmd.ChatIDs) == 0 {
+			defaults = append(defaults, entry)
+			continue
+		}
+		for _, chatID := range cmd.ChatIDs {
+			scoped[chatID] = append(scoped[chatID], entry)
+		}
+	}
+
+	// A chat scope replaces the default menu, so it must repeat it
+	perChat := make(map[string][]tgbotapi.BotCommand, len(scoped))
+	for chatID, extra := range scoped {
+		cmds := make([]tgbotapi.BotCommand, 0, len(defaults)+len(extra))
+		cmds = append(cmds, defaults...)
+		perChat[chatID] = append(cmds, extra...)
+	}
+	return defaults, perChat
+}
+
+// truncateDescription fits a description into Telegram's limit. Telegram
+// rejects empty descriptions, so the command name stands in for a missing one.
+func truncateDescription(description, name string) string {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return name
+	}
+	if utf8.RuneCountInString(description) <= maxCommandDescription {
+		return description
+	}
+	runes := []rune(description)
+	return string(runes[:maxCommandDescription-1]) + "…"
+}
@@ -0,0 +1,117 @@
+package telegram
+
+import (
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxMessageUTF16 is Telegram's message length limit, counted in UTF-16 code units.
+const maxMessageUTF16 = 4096
+
+// sendFormat is one way of formatting a message tried by SendMessage.
+type sendFormat struct {
+	name      string
+	parseMode string
+	transform func(string) string // nil sends the text unchanged
+}
+
+var (
+	formatMarkdown      = sendFormat{name: "markdown", parseMode: tgbotapi.ModeMarkdown}
+	formatPlain         = sendFormat{name: "plain"}
+	formatEscapedPlain  = sendFormat{name: "escaped plain", transform: escapePlain}
+	formatTruncatedText = sendFormat{name: "truncated plain", transform: truncatePlain}
+)
+
+// SetFormatFallback makes SendMessage try progressively simpler formatting
+// (Markdown, escaped plain text, truncated plain text) until one is accepted,
+// instead of only Markdown then plain text. Answers are written in legacy
+// Markdown, so there is no MarkdownV2 rung: its escaping rules differ.
+func (c *Client) SetFormatFallback(enabled bool) {
+	c.formatFallback = enabled
+}
+
+// sendFormats returns the formats to try, in order, for a message.
+func (c *Client) sendFormats(plain bool) []sendFormat {
+	switch {
+	case plain && c.formatFallback:
+		return []sendFormat{formatPlain, formatEscapedPlain, formatTruncatedText}
+	case plain:
+		return []sendFormat{formatPlain}
+	case c.formatFallback:
+		return []sendFormat{formatMarkdown, formatEscapedPlain, formatTruncatedText}
+	default:
+		return []sendFormat{formatMarkdown, formatPlain}
+	}
+}
+
+// isFormatError reports whether Telegram rejected a message for its
+// formatting or length, which a simpler format may fix. Rate limits,
+// permission and network errors are not: every format would fail the same way.
+func isFormatError(err error) bool {
+	msg := err.Error()
+	for _, s := range []string{
+		"can't parse entities",
+		"message is too long",
+		"text must be encoded in UTF-8",
+		"message text is empty",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapePlain makes text safe to send without parsing: invalid UTF-8 and
+// control characters other than newlines and tabs are dropped.
+func escapePlain(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' || !unicode.IsControl(r) {
+			return r
+		}
+		return -1
+	}, text)
+	if strings.TrimSpace(text) == "" {
+		return "(empty message)"
+	}
+	return text
+}
+
+// truncatePlain escapes text and cuts it to fit Telegram's length limit.
+func truncatePlain(text string) string {
+	text = escapePlain(text)
+	if utf16Len(text) <= maxMessageUTF16 {
+		return text
+	}
+
+	const ellipsis = "…"
+	limit := maxMessageUTF16 - utf16Len(ellipsis)
+	units := 0
+	for i, r := range text {
+		units += utf16RuneLen(r)
+		if units > limit {
+			return text[:i] + ellipsis
+		}
+	}
+	return text
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16RuneLen(r)
+	}
+	return n
+}
+
+// utf16RuneLen returns the number of UTF-16 code units needed to encode r.
+func utf16RuneLen(r rune) int {
+	if r > 0xFFFF {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+const (
+	// inlineDebounce is how long a user must stop typing before an inline
+	// query is answered. Telegram sends a query for every pause in typing.
+	inlineDebounce = time.Second
+	// inlineCacheTime is how long Telegram may cache an answer, in seconds.
+	// Results are personal, so one user's answer is never shown to another.
+	inlineCacheTime = 300
+	// maxInlineDescription bounds the answer preview shown in the results list.
+	maxInlineDescription = 100
+	// inlineAnswerTimeout is how long an answer may take before a placeholder
+	// is sent instead. Telegram drops inline queries that aren't answered
+	// within about 10 seconds, and the debounce already used one of them.
+	inlineAnswerTimeout = 8 * time.Second
+	// inlinePlaceholder is offered while the answer is still being worked on.
+	// The handler keeps running, so the same query typed again can be answered
+	// from the bot's cache.
+	inlinePlaceholder = "⏳ Still working on it. Type the question again in a minute to see the answer."
+)
+
+// SetInlineQueryHandler enables inline mode. Inline mode must also be turned
+// on for the bot with @BotFather.
+func (c *Client) SetInlineQueryHandler(handler messaging.InlineQueryHandler) {
+	c.inlineHandler = handler
+	c.inlineTimeout = inlineAnswerTimeout
+}
+
+// dispatchInlineQuery answers q in the background so a slow answer does not
+// hold up other updates. Only the latest query per user is answered.
+func (c *Client) dispatchInlineQuery(q *tgbotapi.InlineQuery) {
+	query := convertInlineQuery(q)
+	c.inlineLatest.Store(query.From.ID, query.ID)
+
+	go func() {
+		select {
+		case <-c.stop:
+			return
+		case <-time.After(inlineDebounce):
+		}
+		if latest, _ := c.inlineLatest.Load(query.From.ID); latest != query.ID {
+			return // Superseded while the user kept typing
+		}
+		if err := c.answerInlineQuery(query); err != nil {
+			slog.Error("Error handling inline query", "user_id", query.From.ID, "error", err)
+		}
+	}()
+}
+
+// answerInlineQuery runs the handler and sends its answer as a single
+// article result. An empty answer is sent as an empty result list. If the
+// handler hasn't answered before Telegram would expire the query, a
+// placeholder is sent instead and the handler is left to finish.
+func (c *Client) answerInlineQuery(query *messaging.IncomingInlineQuery) error {
+	type result struct {
+		answer string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		answer, err := c.inlineHandler(query)
+		done <- result{answer, err}
+	}()
+
+	var config tgbotapi.InlineConfig
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return r.err
+		}
+		config = buildInlineAnswer(query.ID, r.answer)
+	case <-time.After(c.inlineTimeout):
+		slog.Info("Inline answer is taking too long, sending placeholder", "user_id", query.From.ID)
+		config = buildInlineAnswer(query.ID, inlinePlaceholder)
+		// Don't let Telegram keep showing the placeholder once the answer is ready
+		config.CacheTime = 1
+	case <-c.stop:
+		return nil
+	}
+
+	if _, err := c.bot.Request(config); err != nil {
+		return fmt.Errorf("failed to answer inline query: %w", err)
+	}
+	return nil
+}
+
+func convertInlineQuery(q *tgbotapi.InlineQuery) *messaging.IncomingInlineQuery {
+	query := &messaging.IncomingInlineQuery{
+		ID:    q.ID,
+		Query: strings.TrimSpace(q.Query),
+	}
+	if q.From != nil {
+		query.From = messaging.User{
+			ID:        strconv.FormatInt(q.From.ID, 10),
+			Username:  q.From.UserName,
+			FirstName: q.From.FirstName,
+			LastName:  q.From.LastName,
+		}
+	}
+	return query
+}
+
+// buildInlineAnswer wraps answer in an article result that posts it to the
+// chat as plain text when picked.
+func buildInlineAnswer(queryID, answer string) tgbotapi.InlineConfig {
+	results := []interface{}{}
+	if answer != "" {
+		article := tgbotapi.NewInlineQueryResultArticle(queryID, "Answer", truncatePlain(answer))
+		article.Description = inlinePreview(answer)
+		results = append(results, article)
+	}
+	return tgbotapi.InlineConfig{
+		InlineQueryID: queryID,
+		Results:       results,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+	}
+}
+
+// inlinePreview returns the start of answer on one line.
+func inlinePreview(answer string) string {
+	preview := strings.Join(strings.Fields(answer), " ")
+	if runes := []rune(preview); len(runes) > maxInlineDescription {
+		preview = string(runes[:maxInlineDescription-1]) + "…"
+	}
+	return preview
+}
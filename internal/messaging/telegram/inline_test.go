@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+func TestConvertInlineQuery(t *testing.T) {
+	q := &tgbotapi.InlineQuery{
+		ID:    "q1",
+		From:  &tgbotapi.User{ID: 42, UserName: "alice", FirstName: "Alice"},
+		Query: "  why is prod slow? ",
+	}
+
+	got := convertInlineQuery(q)
+	if got.ID != "q1" || got.Query != "why is prod slow?" {
+		t.Errorf("convertInlineQuery() = %+v", got)
+	}
+	if got.From.ID != "42" || got.From.Username != "alice" || got.From.FirstName != "Alice" {
+		t.Errorf("From = %+v", got.From)
+	}
+}
+
+func TestBuildInlineAnswer(t *testing.T) {
+	answer := "Pods are *crashlooping*\nbecause of OOM " + strings.Repeat("x", 200)
+	cfg := buildInlineAnswer("q1", answer)
+
+	if cfg.InlineQueryID != "q1" || !cfg.IsPersonal || cfg.CacheTime != inlineCacheTime {
+		t.Errorf("InlineConfig = %+v", cfg)
+	}
+	if len(cfg.Results) != 1 {
+		t.Fatalf("Results = %d, want 1", len(cfg.Results))
+	}
+	article, ok := cfg.Results[0].(tgbotapi.InlineQueryResultArticle)
+	if !ok {
+		t.Fatalf("Result type = %T, want article", cfg.Results[0])
+	}
+	content, ok := article.InputMessageContent.(tgbotapi.InputTextMessageContent)
+	if !ok || content.Text != answer || content.ParseMode != "" {
+		t.Errorf("Message content = %+v, want the plain answer", article.InputMessageContent)
+	}
+	if strings.Contains(article.Description, "\n") || len([]rune(article.Description)) != maxInlineDescription {
+		t.Errorf("Description = %q, want a one-line %d character preview", article.Description, maxInlineDescription)
+	}
+
+	if empty := buildInlineAnswer("q2", ""); len(empty.Results) != 0 {
+		t.Errorf("Empty answer should offer no results, got %v", empty.Results)
+	}
+}
+
+func TestAnswerInlineQuery(t *testing.T) {
+	var queryID string
+	var results []map[string]any
+	client := newFakeAPIClientWith(t, map[string]http.HandlerFunc{
+		"answerInlineQuery": func(w http.ResponseWriter, r *http.Request) {
+			queryID = r.FormValue("inline_query_id")
+			if err := json.Unmarshal([]byte(r.FormValue("results")), &results); err != nil {
+				t.Errorf("Failed to decode results: %v", err)
+			}
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		},
+	})
+
+	var got *messaging.IncomingInlineQuery
+	client.SetInlineQueryHandler(func(q *messaging.IncomingInlineQuery) (string, error) {
+		got = q
+		return "All pods healthy", nil
+	})
+
+	query := convertInlineQuery(&tgbotapi.InlineQuery{ID: "q1", From: &tgbotapi.User{ID: 42}, Query: "pods?"})
+	if err := client.answerInlineQuery(query); err != nil {
+		t.Fatalf("answerInlineQuery() error = %v", err)
+	}
+
+	if got == nil || got.Query != "pods?" || got.From.ID != "42" {
+		t.Errorf("Handler got %+v", got)
+	}
+	if queryID != "q1" {
+		t.Errorf("inline_query_id = %q, want q1", queryID)
+	}
+	if len(results) != 1 || results[0]["type"] != "article" {
+		t.Fatalf("results = %v, want one article", results)
+	}
+	content, _ := results[0]["input_message_content"].(map[string]any)
+	if content["message_text"] != "All pods healthy" {
+		t.Errorf("message_text = %v, want the answer", content["message_text"])
+	}
+}
+
+func TestAnswerInlineQuery_SlowAnswerGetsPlaceholder(t *testing.T) {
+	var cacheTime string
+	var results []map[string]any
+	client := newFakeAPIClientWith(t, map[string]http.HandlerFunc{
+		"answerInlineQuery": func(w http.ResponseWriter, r *http.Request) {
+			cacheTime = r.FormValue("cache_time")
+			if err := json.Unmarshal([]byte(r.FormValue("results")), &results); err != nil {
+				t.Errorf("Failed to decode results: %v", err)
+			}
+			fmt.Fprint(w, `{"ok":true,"result":true}`)
+		},
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	client.SetInlineQueryHandler(func(q *messaging.IncomingInlineQuery) (string, error) {
+		<-release
+		return "too late", nil
+	})
+	client.inlineTimeout = 50 * time.Millisecond
+
+	query := convertInlineQuery(&tgbotapi.InlineQuery{ID: "q1", From: &tgbotapi.User{ID: 42}, Query: "pods?"})
+	if err := client.answerInlineQuery(query); err != nil {
+		t.Fatalf("answerInlineQuery() error = %v", err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("results = %v, want the placeholder", results)
+	}
+	content, _ := results[0]["input_message_content"].(map[string]any)
+	if content["message_text"] != inlinePlaceholder {
+		t.Errorf("message_text = %v, want the placeholder", content["message_text"])
+	}
+	if cacheTime != "1" {
+		t.Errorf("cache_time = %q, want the placeholder cached briefly", cacheTime)
+	}
+}
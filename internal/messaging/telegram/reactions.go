@@ -0,0 +1,51 @@
+package telegram
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// defaultReaction is used when a configured reaction is not accepted by Telegram.
+const defaultReaction = "👀"
+
+// allowedReactions is the set of emoji Telegram accepts for setMessageReaction.
+// Anything else is rejected by the API, so the bot would silently not react.
+var allowedReactions = map[string]bool{
+	"👍": true, "👎": true, "❤": true, "🔥": true, "🥰": true, "👏": true,
+	"😁": true, "🤔": true, "🤯": true, "😱": true, "🤬": true, "😢": true,
+	"🎉": true, "🤩": true, "🤮": true, "💩": true, "🙏": true, "👌": true,
+	"🕊": true, "🤡": true, "🥱": true, "🥴": true, "😍": true, "🐳": true,
+	"❤‍🔥": true, "🌚": true, "🌭": true, "💯": true, "🤣": true, "⚡": true,
+	"🍌": true, "🏆": true, "💔": true, "🤨": true, "😐": true, "🍓": true,
+	"🍾": true, "💋": true, "🖕": true, "😈": true, "😴": true, "😭": true,
+	"🤓": true, "👻": true, "👨‍💻": true, "👀": true, "🎃": true, "🙈": true,
+	"😇": true, "😨": true, "🤝": true, "✍": true, "🤗": true, "🫡": true,
+	"🎅": true, "🎄": true, "☃": true, "💅": true, "🤪": true, "🗿": true,
+	"🆒": true, "💘": true, "🙉": true, "🦄": true, "😘": true, "💊": true,
+	"🙊": true, "😎": true, "👾": true, "🤷‍♂": true, "🤷": true, "🤷‍♀": true,
+	"😡": true,
+}
+
+// normalizeReaction strips emoji variation selectors (U+FE0F), which Telegram
+// omits from its reaction list but editors often insert (e.g. "❤️").
+func normalizeReaction(emoji string) string {
+	return strings.ReplaceAll(emoji, "\uFE0F", "")
+}
+
+// IsAllowedReaction reports whether Telegram accepts emoji as a reaction.
+func IsAllowedReaction(emoji string) bool {
+	return allowedReactions[normalizeReaction(emoji)]
+}
+
+// resolveReaction returns the emoji to send for a requested reaction, falling
+// back to defaultReaction with a warning when Telegram would reject it.
+func resolveReaction(emoji string) string {
+	normalized := normalizeReaction(emoji)
+	if allowedReactions[normalized] {
+		return normalized
+	}
+	slog.Warn("Unsupported Telegram reaction, using default",
+		"emoji", emoji,
+		"default", defaultReaction)
+	return defaultReaction
+}
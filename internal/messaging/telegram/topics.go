@@ -0,0 +1,98 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rg/aiops/internal/messaging"
+)
+
+// go-telegram-bot-api/v5.5.1 predates forum topics, so the calls below are
+// made with MakeRequest.
+
+// Ensure Client implements messaging.ForumTopics
+var _ messaging.ForumTopics = (*Client)(nil)
+
+// maxTopicNameLength is Telegram's limit for forum topic names, in characters.
+const maxTopicNameLength = 128
+
+// IsForum reports whether the chat is a supergroup with topics enabled.
+func (c *Client) IsForum(chatID string) (bool, error) {
+	chatIDInt, err := parseChatID(chatID)
+	if err != nil {
+		return false, err
+	}
+
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", chatIDInt)
+	resp, err := c.bot.MakeRequest("getChat", params)
+	if err != nil {
+		return false, fmt.Errorf("failed to get chat: %w", err)
+	}
+
+	var chat struct {
+		IsForum bool `json:"is_forum"`
+	}
+	if err := json.Unmarshal(resp.Result, &chat); err != nil {
+		return false, fmt.Errorf("failed to decode chat: %w", err)
+	}
+	return chat.IsForum, nil
+}
+
+// CreateForumTopic creates a topic in a forum supergroup and returns its
+// message thread ID. Names longer than Telegram allows are truncated.
+func (c *Client) CreateForumTopic(chatID, name string) (string, error) {
+	chatIDInt, err := parseChatID(chatID)
+	if err != nil {
+		return "", err
+	}
+	if runes := []rune(name); len(runes) > maxTopicNameLength {
+		name = string(runes[:maxTopicNameLength-1]) + "…"
+	}
+
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", chatIDInt)
+	params["name"] = name
+	resp, err := c.bot.MakeRequest("createForumTopic", params)
+	if err != nil {
+		return "", fmt.Errorf("failed to create forum topic: %w", err)
+	}
+
+	var topic struct {
+		MessageThreadID int `json:"message_thread_id"`
+	}
+	if err := json.Unmarshal(resp.Result, &topic); err != nil {
+		return "", fmt.Errorf("failed to decode forum topic: %w", err)
+	}
+	return strconv.Itoa(topic.MessageThreadID), nil
+}
+
+// send sends msg, into the forum topic threadID when it is set.
+func (c *Client) send(msg tgbotapi.MessageConfig, threadID string) (tgbotapi.Message, error) {
+	if threadID == "" {
+		return c.bot.Send(msg)
+	}
+	thread, err := strconv.Atoi(threadID)
+	if err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("invalid thread ID: %w", err)
+	}
+
+	params := make(tgbotapi.Params)
+	params.AddNonZero64("chat_id", msg.ChatID)
+	params.AddNonZero("message_thread_id", thread)
+	params["text"] = msg.Text
+	params.AddNonEmpty("parse_mode", msg.ParseMode)
+	params.AddNonZero("reply_to_message_id", msg.ReplyToMessageID)
+
+	resp, err := c.bot.MakeRequest("sendMessage", params)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	var sent tgbotapi.Message
+	if err := json.Unmarshal(resp.Result, &sent); err != nil {
+		return tgbotapi.Message{}, fmt.Errorf("failed to decode sent message: %w", err)
+	}
+	return sent, nil
+}
@@ -0,0 +1,241 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
+)
+
+// pollTimeout is how long, in seconds, each getUpdates long poll waits for
+// updates before returning empty.
+const pollTimeout = 60
+
+var (
+	// errUpdatesStalled is returned when getUpdates doesn't answer within the
+	// stall timeout.
+	errUpdatesStalled = errors.New("getUpdates stalled")
+	// errStopped is returned when the client is stopped mid-request.
+	errStopped = errors.New("client stopped")
+)
+
+// rawUpdate decodes the parts of a getUpdates result the bot handles.
+// go-telegram-bot-api/v5.5.1 predates message_reaction updates, so they are
+// decoded here alongside regular messages.
+type rawUpdate struct {
+	UpdateID        int                     `json:"update_id"`
+	Message         *tgbotapi.Message       `json:"message"`
+	MessageReaction *messageReactionUpdated `json:"message_reaction"`
+	InlineQuery     *tgbotapi.InlineQuery   `json:"inline_query"`
+}
+
+// messageReactionUpdated mirrors Telegram's MessageReactionUpdated object.
+type messageReactionUpdated struct {
+	Chat        tgbotapi.Chat  `json:"chat"`
+	MessageID   int            `json:"message_id"`
+	User        *tgbotapi.User `json:"user"`
+	OldReaction []ReactionType `json:"old_reaction"`
+	NewReaction []ReactionType `json:"new_reaction"`
+}
+
+// SetStallTimeout reconnects the update stream when a getUpdates long poll
+// hasn't answered within timeout, instead of waiting on it forever. Long polls
+// return at least every pollTimeout seconds even with no updates, so timeout
+// must be longer than that. 0 disables.
+//
+// The bot's HTTP requests then time out a little after timeout, so an
+// abandoned poll releases its goroutine and connection soon after. Must be
+// called before Start.
+func (c *Client) SetStallTimeout(timeout time.Duration) {
+	c.stallTimeout = timeout
+	if timeout > 0 {
+		c.bot.Client = &http.Client{Timeout: timeout + timeout/10}
+	}
+}
+
+// SetReactionHandler enables delivery of message reactions. Telegram only
+// sends them in groups where the bot is an administrator, and in private chats.
+func (c *Client) SetReactionHandler(handler messaging.ReactionHandler) {
+	c.reactionHandler = handler
+}
+
+// pollUpdates long-polls getUpdates directly so message_reaction updates can
+// be requested and decoded, and stalled polls detected. Used instead of
+// GetUpdatesChan when a reaction or inline query handler or a stall timeout
+// is set.
+func (c *Client) pollUpdates(handler messaging.MessageHandler) error {
+	allowed := []string{"message"}
+	if c.reactionHandler != nil {
+		allowed = append(allowed, "message_reaction")
+	}
+	if c.inlineHandler != nil {
+		allowed = append(allowed, "inline_query")
+	}
+
+	offset := 0
+	for {
+		select {
+		case <-c.stop:
+			return nil
+		default:
+		}
+
+		params := make(tgbotapi.Params)
+		params.AddNonZero("offset", offset)
+		params.AddNonZero("timeout", pollTimeout)
+		if err := params.AddInterface("allowed_updates", allowed); err != nil {
+			return err
+		}
+
+		resp, err := c.requestUpdates(params)
+		if errors.Is(err, errStopped) {
+			return nil
+		}
+		if errors.Is(err, errUpdatesStalled) {
+			// Updates weren't confirmed, so the new poll gets them again
+			slog.Warn("No response from getUpdates, reconnecting", "stall_timeout", c.stallTimeout)
+			metrics.UpdateStreamReconnects.Inc()
+			continue
+		}
+		if err != nil {
+			slog.Warn("Failed to get updates, retrying in 3 seconds", "error", err)
+			if !c.waitRetry() {
+				return nil
+			}
+			continue
+		}
+
+		var batch []json.RawMessage
+		if err := json.Unmarshal(resp.Result, &batch); err != nil {
+			slog.Error("Failed to decode updates, retrying in 3 seconds", "error", err)
+			if !c.waitRetry() {
+				return nil
+			}
+			continue
+		}
+
+		// Updates are decoded one by one so a bad one is skipped, and confirmed
+		// with the offset, instead of being fetched again on every poll
+		startOffset := offset
+		for _, raw := range batch {
+			var id struct {
+				UpdateID int `json:"update_id"`
+			}
+			if err := json.Unmarshal(raw, &id); err == nil && id.UpdateID >= offset {
+				offset = id.UpdateID + 1
+			}
+
+			var update rawUpdate
+			if err := json.Unmarshal(raw, &update); err != nil {
+				slog.Error("Failed to decode update, skipping", "update_id", id.UpdateID, "error", err)
+				continue
+			}
+
+			if update.Message != nil {
+				msg := convertMessage(update.Message, c.bot.Self.UserName)
+				if err := handler(msg); err != nil {
+					slog.Error("Error handling message", "error", err)
+				}
+			}
+
+			if update.MessageReaction != nil && c.reactionHandler != nil {
+				for _, reaction := range convertReactions(update.MessageReaction) {
+					if err := c.reactionHandler(reaction); err != nil {
+						slog.Error("Error handling reaction", "error", err)
+					}
+				}
+			}
+
+			if update.InlineQuery != nil && c.inlineHandler != nil {
+				c.dispatchInlineQuery(update.InlineQuery)
+			}
+		}
+
+		if len(batch) > 0 && offset == startOffset {
+			slog.Error("No update in the batch could be confirmed, retrying in 3 seconds", "updates", len(batch))
+			if !c.waitRetry() {
+				return nil
+			}
+		}
+	}
+}
+
+// waitRetry waits before polling again after an error. It reports false if
+// the client was stopped meanwhile.
+func (c *Client) waitRetry() bool {
+	select {
+	case <-c.stop:
+		return false
+	case <-time.After(3 * time.Second):
+		return true
+	}
+}
+
+// requestUpdates runs one getUpdates poll. With a stall timeout, a poll that
+// hasn't answered in time is abandoned and errUpdatesStalled returned; its
+// late response, if any, is dropped, and the HTTP client's timeout ends it.
+func (c *Client) requestUpdates(params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	if c.stallTimeout <= 0 {
+		return c.bot.MakeRequest("getUpdates", params)
+	}
+
+	type result struct {
+		resp *tgbotapi.APIResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := c.bot.MakeRequest("getUpdates", params)
+		done <- result{resp, err}
+	}()
+
+	timer := time.NewTimer(c.stallTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.resp, r.err
+	case <-timer.C:
+		return nil, errUpdatesStalled
+	case <-c.stop:
+		return nil, errStopped
+	}
+}
+
+// convertReactions returns one IncomingReaction per emoji the user added.
+// Removed reactions and custom emoji are ignored.
+func convertReactions(update *messageReactionUpdated) []*messaging.IncomingReaction {
+	old := make(map[string]bool, len(update.OldReaction))
+	for _, r := range update.OldReaction {
+		old[r.Emoji] = true
+	}
+
+	var from messaging.User
+	if update.User != nil {
+		from = messaging.User{
+			ID:        strconv.FormatInt(update.User.ID, 10),
+			Username:  update.User.UserName,
+			FirstName: update.User.FirstName,
+			LastName:  update.User.LastName,
+		}
+	}
+
+	var reactions []*messaging.IncomingReaction
+	for _, r := range update.NewReaction {
+		if r.Type != "emoji" || old[r.Emoji] {
+			continue
+		}
+		reactions = append(reactions, &messaging.IncomingReaction{
+			ChatID:    strconv.FormatInt(update.Chat.ID, 10),
+			MessageID: strconv.Itoa(update.MessageID),
+			From:      from,
+			Emoji:     r.Emoji,
+		})
+	}
+	return reactions
+}
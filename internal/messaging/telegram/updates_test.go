@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rg/aiops/internal/messaging"
+	"github.com/rg/aiops/internal/metrics"
+)
+
+func TestStart_StalledPollReconnects(t *testing.T) {
+	var mu sync.Mutex
+	var polls int
+	release := make(chan struct{})
+	abandoned := make(chan struct{})
+	client := newFakeAPIClientWith(t, map[string]http.HandlerFunc{
+		"getUpdates": func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			polls++
+			poll := polls
+			mu.Unlock()
+
+			if poll == 1 {
+				// Read the body so the server notices when the client hangs up
+				_ = r.ParseForm()
+				// Hang like a silently dropped connection
+				select {
+				case <-release:
+				case <-r.Context().Done():
+					close(abandoned)
+				}
+				return
+			}
+			fmt.Fprint(w, `{"ok":true,"result":[{"update_id":7,"message":{"message_id":5,"date":1,"chat":{"id":1,"type":"private"},"text":"hello"}}]}`)
+		},
+	})
+	// Registered after the server, so runs before server.Close waits on the hung poll
+	t.Cleanup(func() { close(release) })
+	client.SetStallTimeout(100 * time.Millisecond)
+
+	reconnects := testutil.ToFloat64(metrics.UpdateStreamReconnects)
+	received := make(chan *messaging.IncomingMessage, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Start(func(msg *messaging.IncomingMessage) error {
+			received <- msg
+			client.Stop()
+			return nil
+		})
+	}()
+
+	select {
+	case msg := <-received:
+		if msg.Text != "hello" || msg.ChatID != "1" {
+			t.Errorf("Received %+v, want the update from the new poll", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("No message received after the first poll stalled")
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return after Stop()")
+	}
+
+	if got := testutil.ToFloat64(metrics.UpdateStreamReconnects) - reconnects; got != 1 {
+		t.Errorf("Reconnects = %v, want 1", got)
+	}
+
+	// The HTTP client's timeout ends the abandoned poll's request
+	select {
+	case <-abandoned:
+	case <-time.After(5 * time.Second):
+		t.Error("The stalled poll's request was never cancelled")
+	}
+}
+
+func TestStart_SkipsUndecodableUpdate(t *testing.T) {
+	offsets := make(chan string, 2)
+	var client *Client
+	client = newFakeAPIClientWith(t, map[string]http.HandlerFunc{
+		"getUpdates": func(w http.ResponseWriter, r *http.Request) {
+			offsets <- r.FormValue("offset")
+			if len(offsets) == 2 {
+				client.Stop()
+				fmt.Fprint(w, `{"ok":true,"result":[]}`)
+				return
+			}
+			fmt.Fprint(w, `{"ok":true,"result":[`+
+				`{"update_id":7,"message":"not a message"},`+
+				`{"update_id":8,"message":{"message_id":5,"date":1,"chat":{"id":1,"type":"private"},"text":"hello"}}]}`)
+		},
+	})
+	client.SetStallTimeout(5 * time.Second)
+
+	var received []string
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Start(func(msg *messaging.IncomingMessage) error {
+			received = append(received, msg.Text)
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start() did not return after Stop()")
+	}
+
+	if len(received) != 1 || received[0] != "hello" {
+		t.Errorf("Received %q, want only the update that decodes", received)
+	}
+	<-offsets
+	if next := <-offsets; next != "9" {
+		t.Errorf("Next poll offset = %q, want 9 so the bad update isn't fetched again", next)
+	}
+}
@@ -0,0 +1,98 @@
+// Package metrics defines the Prometheus collectors exported by the bot.
+// Collectors are registered with the default registry on import.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "aiops_bot"
+
+// ToolLoopsDetected counts responses in which the same tool call was repeated
+// at least the configured threshold, labelled by tool.
+var ToolLoopsDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "tool_loops_detected_total",
+	Help:      "Responses with repeated identical tool calls, by tool.",
+}, []string{"tool"})
+
+// ResponseDeadlineBreaches counts messages not answered within the configured
+// end-to-end response deadline.
+var ResponseDeadlineBreaches = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "response_deadline_breaches_total",
+	Help:      "Messages not answered within the response deadline.",
+})
+
+// QueryQueueDepth is the number of queries currently waiting for a free
+// Claude query slot.
+var QueryQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "query_queue_depth",
+	Help:      "Queries waiting for a free Claude query slot.",
+})
+
+// QueriesDropped counts queries abandoned because no query slot freed up
+// before the query timeout.
+var QueriesDropped = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "queries_dropped_total",
+	Help:      "Queries dropped after timing out in the query queue.",
+})
+
+// RedactionHits counts secrets redacted from output, labelled by the secret
+// pattern that matched. Only populated when security.redaction_stats is on.
+var RedactionHits = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "redaction_hits_total",
+	Help:      "Secrets redacted from output, by secret pattern.",
+}, []string{"pattern"})
+
+// HeavyRedactions counts answers whose redacted share exceeded
+// security.heavy_redaction.threshold.
+var HeavyRedactions = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "heavy_redactions_total",
+	Help:      "Answers with more redacted than security.heavy_redaction.threshold allows.",
+})
+
+// Query statuses recorded by QueriesTotal.
+const (
+	QueryStatusSuccess = "success"
+	QueryStatusError   = "error"
+	QueryStatusTimeout = "timeout"
+	QueryStatusDropped = "dropped"
+)
+
+// QueryDuration observes how long Claude queries take to run, excluding time
+// spent waiting for a query slot.
+var QueryDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "query_duration_seconds",
+	Help:      "Duration of Claude queries, excluding time queued for a slot.",
+	Buckets:   []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300, 600},
+})
+
+// QueriesTotal counts finished Claude queries by status: success, error,
+// timeout (the query ran past claude.timeout) or dropped (no slot freed up).
+var QueriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "queries_total",
+	Help:      "Claude queries by status.",
+}, []string{"status"})
+
+// RateLimitRejections counts messages refused by the per-chat rate limiter.
+var RateLimitRejections = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "rate_limit_rejections_total",
+	Help:      "Messages rejected by the per-chat rate limiter.",
+})
+
+// UpdateStreamReconnects counts Telegram update polls abandoned as stalled
+// and restarted by the watchdog.
+var UpdateStreamReconnects = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "update_stream_reconnects_total",
+	Help:      "Stalled Telegram update polls restarted by the watchdog.",
+})
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Server exposes the default Prometheus registry at /metrics.
+type Server struct {
+	httpServer *http.Server
+}
+
+func NewServer(addr string) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Start serves until Shutdown is called.
+func (s *Server) Start() error {
+	slog.Info("Metrics server listening", "addr", s.httpServer.Addr)
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight scrapes.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// RegisterActivityGauges registers gauges reporting the number of active
+// Claude sessions and chat contexts, read on every scrape. A failing context
+// count is logged and reported as zero.
+func RegisterActivityGauges(activeSessions func() int, activeContexts func() (int, error)) {
+	prometheus.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_sessions",
+			Help:      "Claude sessions currently tracked in memory.",
+		}, func() float64 {
+			return float64(activeSessions())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_contexts",
+			Help:      "Chat contexts that have not expired.",
+		}, func() float64 {
+			count, err := activeContexts()
+			if err != nil {
+				slog.Warn("Failed to count active contexts for metrics", "error", err)
+				return 0
+			}
+			return float64(count)
+		}),
+	)
+}
+
+// RegisterDBPoolGauges registers gauges reporting the database connection
+// pool's usage, read from stats on every scrape, so operators can see the
+// pool saturating before connections fail.
+func RegisterDBPoolGauges(stats func() sql.DBStats) {
+	gauge := func(name, help string, value func(sql.DBStats) float64) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return value(stats())
+		})
+	}
+	prometheus.MustRegister(
+		gauge("db_connections_max_open", "Maximum open database connections.",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }),
+		gauge("db_connections_in_use", "Database connections currently in use.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }),
+		gauge("db_connections_idle", "Open database connections currently idle.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_connection_waits_total",
+			Help:      "Queries that waited for a free database connection.",
+		}, func() float64 {
+			return float64(stats().WaitCount)
+		}),
+	)
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer_ExposesMetrics(t *testing.T) {
+	RegisterActivityGauges(
+		func() int { return 3 },
+		func() (int, error) { return 0, errors.New("database is locked") },
+	)
+	RateLimitRejections.Inc()
+
+	s := NewServer(":0")
+	rec := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics status = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"aiops_bot_active_sessions 3",
+		"aiops_bot_active_contexts 0",
+		"aiops_bot_rate_limit_rejections_total",
+		"aiops_bot_query_duration_seconds_bucket",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("GET /metrics missing %q", want)
+		}
+	}
+}
@@ -1,13 +1,43 @@
 package security
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
 	"regexp"
+	"sort"
+	"sync/atomic"
+
+	"github.com/rg/aiops/internal/metrics"
+)
+
+// redactionMarker replaces every redacted match.
+const redactionMarker = "***REDACTED***"
+
+// RedactionMode selects what replaces redacted text.
+type RedactionMode string
+
+const (
+	// RedactionModePlain replaces every match with ***REDACTED***.
+	RedactionModePlain RedactionMode = "plain"
+	// RedactionModeHash replaces a match with ***REDACTED:<hash>***, where
+	// hash is the first 8 hex digits of its SHA-256, so repeated secrets can
+	// be told apart without being shown.
+	RedactionModeHash RedactionMode = "hash"
 )
 
 type Sanitizer struct {
-	patterns []*regexp.Regexp
+	patterns      []*regexp.Regexp
+	hits          []atomic.Int64 // Matches redacted per pattern, by index
+	exportMetrics bool
+	mode          RedactionMode
+}
+
+// PatternHits is how many matches of one secret pattern were redacted.
+type PatternHits struct {
+	Pattern string
+	Hits    int64
 }
 
 func NewSanitizer(patterns []string) (*Sanitizer, error) {
@@ -21,17 +51,47 @@ func NewSanitizer(patterns []string) (*Sanitizer, error) {
 	}
 	return &Sanitizer{
 		patterns: compiled,
+		hits:     make([]atomic.Int64, len(compiled)),
+		mode:     RedactionModePlain,
 	}, nil
 }
 
+// SetRedactionMode selects what replaces redacted text. Call before the
+// sanitizer is in use.
+func (s *Sanitizer) SetRedactionMode(mode RedactionMode) {
+	s.mode = mode
+}
+
+// marker returns the text that replaces a redacted match.
+func (s *Sanitizer) marker(match string) string {
+	if s.mode != RedactionModeHash {
+		return redactionMarker
+	}
+	sum := sha256.Sum256([]byte(match))
+	return "***REDACTED:" + hex.EncodeToString(sum[:4]) + "***"
+}
+
+// SetMetricsEnabled exports per-pattern hit counts as a Prometheus counter.
+// Call before the sanitizer is in use.
+func (s *Sanitizer) SetMetricsEnabled(enabled bool) {
+	s.exportMetrics = enabled
+}
+
 func (s *Sanitizer) Sanitize(text string) string {
 	result := text
 	redacted := false
 
-	for _, pattern := range s.patterns {
-		if pattern.MatchString(result) {
-			result = pattern.ReplaceAllString(result, "***REDACTED***")
-			redacted = true
+	for i, pattern := range s.patterns {
+		matches := len(pattern.FindAllStringIndex(result, -1))
+		if matches == 0 {
+			continue
+		}
+		result = pattern.ReplaceAllStringFunc(result, s.marker)
+		redacted = true
+
+		s.hits[i].Add(int64(matches))
+		if s.exportMetrics {
+			metrics.RedactionHits.WithLabelValues(pattern.String()).Add(float64(matches))
 		}
 	}
 
@@ -42,6 +102,65 @@ func (s *Sanitizer) Sanitize(text string) string {
 	return result
 }
 
+// SanitizeWithTerms sanitizes text and also redacts each of terms, such as a
+// chat's project codenames. Terms match literally and case-insensitively, so
+// regex syntax in a term has no special meaning.
+func (s *Sanitizer) SanitizeWithTerms(text string, terms []string) string {
+	result := s.Sanitize(text)
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+		result = re.ReplaceAllStringFunc(result, s.marker)
+	}
+	return result
+}
+
+// RedactedFraction returns the share of text, from 0 to 1, that
+// SanitizeWithTerms would redact. Overlapping matches count once. Unlike
+// Sanitize it doesn't count hits.
+func (s *Sanitizer) RedactedFraction(text string, terms []string) float64 {
+	if text == "" {
+		return 0
+	}
+	var spans [][]int
+	for _, pattern := range s.patterns {
+		spans = append(spans, pattern.FindAllStringIndex(text, -1)...)
+	}
+	for _, term := range terms {
+		if term == "" {
+			continue
+		}
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(term))
+		spans = append(spans, re.FindAllStringIndex(text, -1)...)
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	redacted, end := 0, 0
+	for _, span := range spans {
+		start := max(span[0], end)
+		if span[1] > start {
+			redacted += span[1] - start
+			end = span[1]
+		}
+	}
+	return float64(redacted) / float64(len(text))
+}
+
+// HitCounts returns how many matches each pattern has redacted since the
+// sanitizer was created, most frequent first. Ties keep configuration order.
+func (s *Sanitizer) HitCounts() []PatternHits {
+	counts := make([]PatternHits, len(s.patterns))
+	for i, pattern := range s.patterns {
+		counts[i] = PatternHits{Pattern: pattern.String(), Hits: s.hits[i].Load()}
+	}
+	sort.SliceStable(counts, func(a, b int) bool {
+		return counts[a].Hits > counts[b].Hits
+	})
+	return counts
+}
+
 var DefaultPatterns = []string{
 	`api[_-]?key[s]?\s*[:=]\s*["']?([^"'\s]+)`,
 	`token[s]?\s*[:=]\s*["']?([^"'\s]+)`,
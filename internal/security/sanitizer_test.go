@@ -1,8 +1,12 @@
 package security
 
 import (
+	"regexp"
 	"strings"
 	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rg/aiops/internal/metrics"
 )
 
 func TestNewSanitizer_ValidPatterns(t *testing.T) {
@@ -26,7 +30,7 @@ func TestNewSanitizer_ValidPatterns(t *testing.T) {
 func TestNewSanitizer_InvalidPattern(t *testing.T) {
 	patterns := []string{
 		`valid.*pattern`,
-		`[invalid`,  // Unclosed bracket
+		`[invalid`, // Unclosed bracket
 	}
 
 	sanitizer, err := NewSanitizer(patterns)
@@ -154,3 +158,152 @@ func TestSanitize_Password(t *testing.T) {
 	}
 }
 
+func TestSanitizeWithTerms_AddsToGlobalPatterns(t *testing.T) {
+	sanitizer, err := NewSanitizer(DefaultPatterns)
+	if err != nil {
+		t.Fatalf("NewSanitizer failed: %v", err)
+	}
+
+	input := "Project FALCON uses api_key=abc123 and db (prod).internal; falcon.* is fine"
+	result := sanitizer.SanitizeWithTerms(input, []string{"falcon", "(prod).internal", ""})
+
+	for _, leaked := range []string{"FALCON", "falcon", "abc123", "(prod).internal"} {
+		if strings.Contains(result, leaked) {
+			t.Errorf("Expected %q to be redacted, got: %s", leaked, result)
+		}
+	}
+	if !strings.Contains(result, "Project ***REDACTED*** uses") || !strings.Contains(result, "db ***REDACTED***;") {
+		t.Errorf("Unexpected result: %s", result)
+	}
+}
+
+func TestSanitizeWithTerms_LiteralMatching(t *testing.T) {
+	sanitizer, _ := NewSanitizer(nil)
+
+	// Regex syntax in a term must not match anything but itself
+	result := sanitizer.SanitizeWithTerms("abc and a.c and .*", []string{".*", "a.c"})
+	if result != "abc and ***REDACTED*** and ***REDACTED***" {
+		t.Errorf("Unexpected result: %s", result)
+	}
+}
+
+func TestSanitizer_HitCounts(t *testing.T) {
+	sanitizer, err := NewSanitizer([]string{`token=\S+`, `password=\S+`, `never-matches`})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	sanitizer.Sanitize("token=a password=b")
+	sanitizer.Sanitize("token=c and token=d")
+	sanitizer.Sanitize("nothing secret here")
+
+	want := []PatternHits{
+		{Pattern: `token=\S+`, Hits: 3},
+		{Pattern: `password=\S+`, Hits: 1},
+		{Pattern: `never-matches`, Hits: 0},
+	}
+	got := sanitizer.HitCounts()
+	if len(got) != len(want) {
+		t.Fatalf("HitCounts() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("HitCounts()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSanitizer_HitCountsExportedAsMetric(t *testing.T) {
+	pattern := `metric-test-[0-9]+`
+	sanitizer, err := NewSanitizer([]string{pattern})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+
+	sanitizer.Sanitize("metric-test-1")
+	if got := testutil.ToFloat64(metrics.RedactionHits.WithLabelValues(pattern)); got != 0 {
+		t.Errorf("Metric = %v before SetMetricsEnabled, want 0", got)
+	}
+
+	sanitizer.SetMetricsEnabled(true)
+	sanitizer.Sanitize("metric-test-2 metric-test-3")
+	if got := testutil.ToFloat64(metrics.RedactionHits.WithLabelValues(pattern)); got != 2 {
+		t.Errorf("Metric = %v, want 2", got)
+	}
+}
+
+func TestSanitize_HashMode(t *testing.T) {
+	sanitizer, err := NewSanitizer([]string{`password=\S+`})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	sanitizer.SetRedactionMode(RedactionModeHash)
+
+	tag := regexp.MustCompile(`\*\*\*REDACTED:([0-9a-f]{8})\*\*\*`)
+	tags := func(text string) []string {
+		var found []string
+		for _, m := range tag.FindAllStringSubmatch(sanitizer.Sanitize(text), -1) {
+			found = append(found, m[1])
+		}
+		return found
+	}
+
+	got := tags("password=hunter2 then password=hunter2 and password=letmein")
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 hash tags, got %q", got)
+	}
+	if got[0] != got[1] {
+		t.Errorf("Identical secrets should get identical tags, got %q and %q", got[0], got[1])
+	}
+	if got[0] == got[2] {
+		t.Errorf("Different secrets should get different tags, both got %q", got[0])
+	}
+	if again := tags("password=hunter2"); len(again) != 1 || again[0] != got[0] {
+		t.Errorf("Tags should be stable across calls, got %q, want %q", again, got[0])
+	}
+
+	result := sanitizer.SanitizeWithTerms("project falcon", []string{"falcon"})
+	if !tag.MatchString(result) || strings.Contains(result, "falcon") {
+		t.Errorf("Chat terms should be tagged too, got %q", result)
+	}
+}
+
+func TestSanitize_PlainModeByDefault(t *testing.T) {
+	sanitizer, _ := NewSanitizer([]string{`password=\S+`})
+	if result := sanitizer.Sanitize("password=hunter2"); result != "***REDACTED***" {
+		t.Errorf("Sanitize() = %q, want the plain marker", result)
+	}
+}
+
+func TestRedactedFraction(t *testing.T) {
+	s, err := NewSanitizer([]string{`token=\S+`, `[A-Za-z0-9+/]{40,}`})
+	if err != nil {
+		t.Fatalf("NewSanitizer() error = %v", err)
+	}
+	hash := strings.Repeat("abcd", 10)
+
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  float64
+	}{
+		{"nothing redacted", "all pods are running", nil, 0},
+		{"empty", "", nil, 0},
+		{"all redacted", hash, nil, 1},
+		{"half redacted", hash + strings.Repeat(" ", 40), nil, 0.5},
+		{"overlaps count once", "token=" + hash, nil, 1},
+		{"terms", "project apollo", []string{"APOLLO"}, 6.0 / 14},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.RedactedFraction(tt.text, tt.terms); got != tt.want {
+				t.Errorf("RedactedFraction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if hits := s.HitCounts(); hits[0].Hits != 0 || hits[1].Hits != 0 {
+		t.Errorf("RedactedFraction() should not count hits, got %+v", hits)
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// CommandUsageCount is the number of times a command was invoked.
+type CommandUsageCount struct {
+	Command string
+	Count   int
+}
+
+// RecordCommandUsage records a slash command invocation.
+func (s *Storage) RecordCommandUsage(chatID, userID, command string) error {
+	err := s.exec(`
+		INSERT INTO command_usage (chat_id, user_id, command, created_at)
+		VALUES (?, NULLIF(?, ''), ?, ?)
+	`, chatID, userID, command, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record command usage: %w", err)
+	}
+	return nil
+}
+
+// GetCommandUsage returns invocation counts per command since the given time,
+// most used first.
+func (s *Storage) GetCommandUsage(since time.Time) ([]CommandUsageCount, error) {
+	rows, err := s.readDB().Query(`
+		SELECT command, COUNT(*) AS uses
+		FROM command_usage
+		WHERE created_at >= ?
+		GROUP BY command
+		ORDER BY uses DESC, command ASC
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get command usage: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []CommandUsageCount
+	for rows.Next() {
+		var c CommandUsageCount
+		if err := rows.Scan(&c.Command, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan command usage: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating command usage: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetQueryCount returns the number of user queries sent to Claude since the given time.
+func (s *Storage) GetQueryCount(since time.Time) (int, error) {
+	var count int
+	err := s.readDB().QueryRow(`
+		SELECT COUNT(*) FROM messages WHERE role = 'user' AND created_at >= ?
+	`, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get query count: %w", err)
+	}
+	return count, nil
+}
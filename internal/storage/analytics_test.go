@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetCommandUsage(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	invocations := []struct{ chatID, userID, command string }{
+		{"chat1", "alice", "/status"},
+		{"chat1", "bob", "/status"},
+		{"chat2", "carol", "/status"},
+		{"chat1", "alice", "/history"},
+		{"chat2", "carol", "/history"},
+		{"chat1", "alice", "/new"},
+	}
+	for _, inv := range invocations {
+		if err := store.RecordCommandUsage(inv.chatID, inv.userID, inv.command); err != nil {
+			t.Fatalf("RecordCommandUsage failed: %v", err)
+		}
+	}
+
+	// An old invocation outside the period
+	_, _ = store.db().Exec(`INSERT INTO command_usage (chat_id, command, created_at) VALUES (?, ?, ?)`,
+		"chat1", "/new", time.Now().Add(-48*time.Hour))
+
+	usage, err := store.GetCommandUsage(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatalf("GetCommandUsage failed: %v", err)
+	}
+
+	want := []CommandUsageCount{{"/status", 3}, {"/history", 2}, {"/new", 1}}
+	if len(usage) != len(want) {
+		t.Fatalf("GetCommandUsage() = %v, want %v", usage, want)
+	}
+	for i := range want {
+		if usage[i] != want[i] {
+			t.Errorf("usage[%d] = %v, want %v", i, usage[i], want[i])
+		}
+	}
+
+	all, _ := store.GetCommandUsage(time.Now().Add(-72 * time.Hour))
+	if len(all) != 3 || all[2] != (CommandUsageCount{"/new", 2}) {
+		t.Errorf("Usage over 72h = %v, want /new counted twice", all)
+	}
+}
+
+func TestGetQueryCount(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "session-1", 2*time.Hour)
+	_ = store.SaveMessage("chat1", "session-1", "", "", "user", "q1")
+	_ = store.SaveMessage("chat1", "session-1", "", "", "assistant", "a1")
+	_ = store.SaveMessage("chat1", "session-1", "", "", "user", "q2")
+
+	count, err := store.GetQueryCount(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("GetQueryCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("GetQueryCount() = %d, want 2", count)
+	}
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Audit actions recorded by RecordAudit.
+const (
+	AuditCommand = "command"
+	AuditQuery   = "query"
+	AuditDenied  = "denied" // A message from a user or chat not on the allowlist
+)
+
+// AuditEntry is one event in the audit log: a session cleanup, a command or
+// query a user sent, or a query's trace ID.
+type AuditEntry struct {
+	Time   time.Time
+	ChatID string
+	UserID string // Empty for cleanups
+	Event  string // "cleanup", "command", "query", "denied" or "trace"
+	Detail string // The cleanup type, the message text, or the trace ID
+}
+
+// RecordAudit records that userID sent a command or query (action) in chatID,
+// or was denied. detail is the message text, which callers must redact.
+func (s *Storage) RecordAudit(chatID, userID, action, detail string) error {
+	err := s.exec(`
+		INSERT INTO audit_log (chat_id, user_id, action, detail, created_at)
+		VALUES (?, NULLIF(?, ''), ?, ?, ?)
+	`, chatID, userID, action, detail, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
+
+// GetRecentAuditEntries returns the last limit entries recorded
+// by RecordAudit across all chats, newest first.
+func (s *Storage) GetRecentAuditEntries(limit int) ([]AuditEntry, error) {
+	// Include buffered entries, such as the request for them
+	if err := s.Flush(); err != nil {
+		slog.Warn("Failed to flush batched writes", "error", err)
+	}
+	rows, err := s.readDB().Query(`
+		SELECT created_at, chat_id, COALESCE(user_id, ''), action, COALESCE(detail, '')
+		FROM audit_log
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.Time, &e.ChatID, &e.UserID, &e.Event, &e.Detail); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+// SaveQueryTrace records the trace ID of a query asked by userID.
+func (s *Storage) SaveQueryTrace(chatID, sessionID, userID, traceID string) error {
+	err := s.exec(`
+		INSERT INTO query_traces (chat_id, session_id, user_id, trace_id, created_at)
+		VALUES (?, ?, NULLIF(?, ''), ?, ?)
+	`, chatID, sessionID, userID, traceID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save query trace: %w", err)
+	}
+	return nil
+}
+
+// GetMessagesBetween returns up to limit messages from all chats created in
+// [from, to), oldest first.
+func (s *Storage) GetMessagesBetween(from, to time.Time, limit int) ([]*Message, error) {
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, COALESCE(session_id, ''), COALESCE(user_id, ''), COALESCE(username, ''), role, content, created_at
+		FROM messages
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?
+	`, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages between dates: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.SessionID, &msg.UserID, &msg.Username, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+	return messages, nil
+}
+
+// GetToolExecutionsBetween returns up to limit tool executions from all chats
+// created in [from, to), oldest first.
+func (s *Storage) GetToolExecutionsBetween(from, to time.Time, limit int) ([]*ToolExecution, error) {
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, COALESCE(session_id, ''), tool_name, status, COALESCE(input, ''), COALESCE(output, ''), created_at
+		FROM tool_executions
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?
+	`, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tool executions between dates: %w", err)
+	}
+	defer rows.Close()
+
+	var tools []*ToolExecution
+	for rows.Next() {
+		var tool ToolExecution
+		if err := rows.Scan(&tool.ID, &tool.ChatID, &tool.SessionID, &tool.ToolName, &tool.Status, &tool.Input, &tool.Output, &tool.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tool execution: %w", err)
+		}
+		tools = append(tools, &tool)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tool executions: %w", err)
+	}
+	return tools, nil
+}
+
+// GetAuditEntriesBetween returns up to limit cleanups, commands, queries and
+// query trace IDs from all chats in [from, to), oldest first.
+func (s *Storage) GetAuditEntriesBetween(from, to time.Time, limit int) ([]AuditEntry, error) {
+	rows, err := s.readDB().Query(`
+		SELECT created_at, chat_id, '', 'cleanup', cleanup_type FROM cleanup_log
+		WHERE created_at >= ? AND created_at < ?
+		UNION ALL
+		SELECT created_at, chat_id, COALESCE(user_id, ''), action, COALESCE(detail, '') FROM audit_log
+		WHERE created_at >= ? AND created_at < ?
+		UNION ALL
+		SELECT created_at, chat_id, COALESCE(user_id, ''), 'trace', trace_id FROM query_traces
+		WHERE created_at >= ? AND created_at < ?
+		ORDER BY 1 ASC
+		LIMIT ?
+	`, from, to, from, to, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.Time, &e.ChatID, &e.UserID, &e.Event, &e.Detail); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetBetween(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "session-1", time.Hour)
+	_ = store.SaveMessage("chat1", "session-1", "alice", "", "user", "hello")
+	_ = store.SaveToolExecution("chat1", "session-1", "Bash", "success", "ls", "a, b")
+	_ = store.RecordAudit("chat1", "alice", AuditCommand, "/status")
+	if _, err := store.CleanupContextTx("chat1", "manual"); err != nil {
+		t.Fatalf("CleanupContextTx failed: %v", err)
+	}
+
+	from, to := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+
+	messages, err := store.GetMessagesBetween(from, to, 10)
+	if err != nil || len(messages) != 1 || messages[0].Content != "hello" || messages[0].UserID != "alice" {
+		t.Errorf("GetMessagesBetween() = %v, %v; want the one message", messages, err)
+	}
+	tools, err := store.GetToolExecutionsBetween(from, to, 10)
+	if err != nil || len(tools) != 1 || tools[0].Output != "a, b" {
+		t.Errorf("GetToolExecutionsBetween() = %v, %v; want the one tool", tools, err)
+	}
+	entries, err := store.GetAuditEntriesBetween(from, to, 10)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("GetAuditEntriesBetween() = %v, %v; want a command and a cleanup", entries, err)
+	}
+	if entries[0].Event != "command" || entries[0].Detail != "/status" || entries[0].UserID != "alice" {
+		t.Errorf("entries[0] = %+v, want the /status command first", entries[0])
+	}
+	if entries[1].Event != "cleanup" || entries[1].Detail != "manual" || entries[1].Time.IsZero() {
+		t.Errorf("entries[1] = %+v, want the manual cleanup", entries[1])
+	}
+
+	// Outside the range
+	past := time.Now().Add(-48 * time.Hour)
+	if messages, _ := store.GetMessagesBetween(past, past.Add(time.Hour), 10); len(messages) != 0 {
+		t.Errorf("Expected no messages outside the range, got %v", messages)
+	}
+	if entries, _ := store.GetAuditEntriesBetween(past, past.Add(time.Hour), 10); len(entries) != 0 {
+		t.Errorf("Expected no audit entries outside the range, got %v", entries)
+	}
+}
+
+func TestGetAuditEntriesBetween_QueryTraces(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := store.SaveQueryTrace("chat1", "session-1", "alice", "trace-1"); err != nil {
+		t.Fatalf("SaveQueryTrace() error = %v", err)
+	}
+	entries, err := store.GetAuditEntriesBetween(time.Now().Add(-time.Hour), time.Now().Add(time.Hour), 10)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("GetAuditEntriesBetween() = %v, %v; want the query", entries, err)
+	}
+	if e := entries[0]; e.Event != "trace" || e.Detail != "trace-1" || e.UserID != "alice" || e.ChatID != "chat1" {
+		t.Errorf("entries[0] = %+v, want alice's query with its trace ID", e)
+	}
+}
+
+func TestGetRecentAuditEntries(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_ = store.RecordAudit("chat1", "alice", AuditQuery, "why is checkout down?")
+	_ = store.RecordAudit("chat2", "", AuditCommand, "/model opus")
+	_ = store.RecordAudit("chat1", "bob", AuditCommand, "/status")
+
+	entries, err := store.GetRecentAuditEntries(2)
+	if err != nil {
+		t.Fatalf("GetRecentAuditEntries() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("GetRecentAuditEntries() = %+v, want the last 2", entries)
+	}
+	if e := entries[0]; e.ChatID != "chat1" || e.UserID != "bob" || e.Event != AuditCommand || e.Detail != "/status" {
+		t.Errorf("entries[0] = %+v, want bob's /status first", e)
+	}
+	if e := entries[1]; e.ChatID != "chat2" || e.UserID != "" || e.Detail != "/model opus" {
+		t.Errorf("entries[1] = %+v, want the anonymous /model", e)
+	}
+}
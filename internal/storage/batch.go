@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// pendingWrite is a buffered INSERT waiting to be flushed.
+type pendingWrite struct {
+	query string
+	args  []any
+}
+
+// writeBatcher buffers inserts and flushes them in a single transaction when
+// the buffer reaches size or interval elapses, whichever comes first.
+type writeBatcher struct {
+	s        *Storage
+	size     int
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending []pendingWrite
+	flushMu sync.Mutex // Serializes flushes; held while paused
+
+	flushCh  chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// EnableWriteBatching buffers message and tool execution inserts, writing them
+// in batches of up to size rows at most interval apart. Buffered rows are not
+// visible to reads until flushed. An interval of 0 keeps writes synchronous.
+// Close flushes any remaining rows.
+//
+// Batched saves are best-effort: they return nil once queued, so a row that
+// later fails to write is only logged, never reported to the caller.
+func (s *Storage) EnableWriteBatching(size int, interval time.Duration) {
+	if interval <= 0 || s.batcher != nil {
+		return
+	}
+	if size <= 0 {
+		size = 1
+	}
+
+	b := &writeBatcher{
+		s:        s,
+		size:     size,
+		interval: interval,
+		flushCh:  make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
+	}
+	s.batcher = b
+	go b.run()
+
+	slog.Info("Write batching enabled", "size", size, "interval", interval)
+}
+
+// exec runs an insert immediately, or buffers it when batching is enabled.
+// A buffered insert returns nil before it is persisted.
+func (s *Storage) exec(query string, args ...any) error {
+	if s.batcher != nil {
+		s.batcher.add(pendingWrite{query: query, args: args})
+		return nil
+	}
+	_, err := s.db().Exec(query, args...)
+	return err
+}
+
+// Flush writes any buffered inserts to the database.
+func (s *Storage) Flush() error {
+	if s.batcher == nil {
+		return nil
+	}
+	return s.batcher.flush()
+}
+
+func (b *writeBatcher) add(w pendingWrite) {
+	b.mu.Lock()
+	b.pending = append(b.pending, w)
+	full := len(b.pending) >= b.size
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.flushCh <- struct{}{}:
+		default: // Flush already requested
+		}
+	}
+}
+
+func (b *writeBatcher) run() {
+	defer close(b.doneCh)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-b.flushCh:
+		case <-b.stopCh:
+			return
+		}
+		if err := b.flush(); err != nil {
+			slog.Error("Failed to flush batched writes", "error", err)
+		}
+	}
+}
+
+// flush writes all pending inserts in one transaction. If the transaction
+// fails the rows are retried one at a time, so only rows that fail on their
+// own are dropped and a bad row cannot block later writes indefinitely.
+func (b *writeBatcher) flush() error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+	return b.flushLocked()
+}
+
+// pause flushes pending writes and holds off further flushes until resume is
+// called. Writes queued meanwhile stay buffered.
+func (b *writeBatcher) pause() error {
+	b.flushMu.Lock()
+	return b.flushLocked()
+}
+
+func (b *writeBatcher) resume() {
+	b.flushMu.Unlock()
+}
+
+// flushLocked implements flush. The caller must hold b.flushMu.
+func (b *writeBatcher) flushLocked() error {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := b.writeBatch(batch); err != nil {
+		slog.Warn("Batched write failed, retrying rows individually", "rows", len(batch), "error", err)
+		return b.writeEach(batch)
+	}
+
+	slog.Debug("Flushed batched writes", "rows", len(batch))
+	return nil
+}
+
+func (b *writeBatcher) writeBatch(batch []pendingWrite) error {
+	tx, err := b.s.db().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin batch transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, w := range batch {
+		if _, err := tx.Exec(w.query, w.args...); err != nil {
+			return fmt.Errorf("failed to execute batched write: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+	return nil
+}
+
+// writeEach writes rows outside a transaction, dropping those that fail.
+func (b *writeBatcher) writeEach(batch []pendingWrite) error {
+	var dropped int
+	var firstErr error
+	for _, w := range batch {
+		if _, err := b.s.db().Exec(w.query, w.args...); err != nil {
+			dropped++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if dropped > 0 {
+		return fmt.Errorf("failed to execute batched writes (%d of %d rows dropped): %w", dropped, len(batch), firstErr)
+	}
+	return nil
+}
+
+// stop halts the background loop and flushes remaining writes. Safe to call
+// more than once.
+func (b *writeBatcher) stop() error {
+	b.stopOnce.Do(func() { close(b.stopCh) })
+	<-b.doneCh
+	return b.flush()
+}
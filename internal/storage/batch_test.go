@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteBatching_EventuallyPersists(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
+	store.EnableWriteBatching(100, 20*time.Millisecond)
+
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "Hello")
+	_ = store.SaveToolExecution("chat123", "session-1", "kubectl", "success", "", "")
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		count, _ := store.GetMessageCountBySession("chat123", "session-1")
+		tools, _ := store.GetToolExecutionsBySession("chat123", "session-1", 10)
+		if count == 1 && len(tools) == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Batched writes were not persisted within the interval")
+}
+
+func TestWriteBatching_FlushesWhenFull(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
+	store.EnableWriteBatching(3, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		_ = store.SaveMessage("chat123", "session-1", "", "", "user", "msg")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if count, _ := store.GetMessageCountBySession("chat123", "session-1"); count == 3 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Full batch was not flushed before the interval")
+}
+
+func TestWriteBatching_FlushOnClose(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
+	store.EnableWriteBatching(100, time.Hour)
+
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "Hello")
+	_ = store.SaveMessage("chat123", "session-1", "", "", "assistant", "Hi")
+
+	if count, _ := store.GetMessageCountBySession("chat123", "session-1"); count != 0 {
+		t.Fatalf("Messages should be buffered before flush, got %d", count)
+	}
+
+	dbPath := store.dbPath
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := NewStorage(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen storage: %v", err)
+	}
+	defer reopened.Close()
+
+	if count, _ := reopened.GetMessageCountBySession("chat123", "session-1"); count != 2 {
+		t.Errorf("Messages after close = %d, want 2", count)
+	}
+}
+
+func TestWriteBatching_BadRowDropsOnlyItself(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
+	store.EnableWriteBatching(100, time.Hour)
+
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "before")
+	_ = store.exec(`INSERT INTO missing_table (id) VALUES (?)`, 1)
+	_ = store.SaveMessage("chat123", "session-1", "", "", "assistant", "after")
+
+	if err := store.Flush(); err == nil {
+		t.Error("Flush should report the dropped row")
+	}
+	if count, _ := store.GetMessageCountBySession("chat123", "session-1"); count != 2 {
+		t.Errorf("Messages after flush = %d, want 2", count)
+	}
+}
+
+func TestWriteBatching_DisabledIsSynchronous(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
+	store.EnableWriteBatching(100, 0)
+
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "Hello")
+	if count, _ := store.GetMessageCountBySession("chat123", "session-1"); count != 1 {
+		t.Errorf("Message count = %d, want 1 with batching disabled", count)
+	}
+}
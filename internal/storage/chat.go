@@ -3,19 +3,20 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"time"
 )
 
 type ChatContext struct {
-	ID               int64
-	ChatID           string
-	ChatType         string
-	SessionID        string
-	ClaudeSessionID  string
-	CreatedAt        time.Time
-	LastInteraction  time.Time
-	ExpiresAt        time.Time
-	IsActive         bool
+	ID              int64
+	ChatID          string
+	ChatType        string
+	SessionID       string
+	ClaudeSessionID string
+	CreatedAt       time.Time
+	LastInteraction time.Time
+	ExpiresAt       time.Time
+	IsActive        bool
 }
 
 // scanChatContexts is a helper that scans ChatContext rows from a query result.
@@ -51,14 +52,22 @@ func scanChatContexts(rows *sql.Rows) ([]*ChatContext, error) {
 	return contexts, nil
 }
 
+// CreateContext creates (or replaces) the chat's context. A TTL override set
+// with SetTTL is kept and takes precedence over ttl, and a context override
+// set with SetContextOverride, a model set with SetChatModel and a project set
+// with SetChatProject are kept.
 func (s *Storage) CreateContext(chatID, chatType, sessionID string, ttl time.Duration) (*ChatContext, error) {
 	now := time.Now()
-	expiresAt := now.Add(ttl)
-
-	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO chat_contexts (chat_id, chat_type, session_id, created_at, last_interaction, expires_at, is_active)
-		VALUES (?, ?, ?, ?, ?, ?, 1)
-	`, chatID, chatType, sessionID, now, now, expiresAt)
+	expiresAt := now.Add(s.effectiveTTL(chatID, ttl))
+
+	_, err := s.db().Exec(`
+		INSERT OR REPLACE INTO chat_contexts (chat_id, chat_type, session_id, created_at, last_interaction, expires_at, is_active, ttl_override_seconds, context_override, model, project)
+		SELECT ?, ?, ?, ?, ?, ?, 1,
+		       (SELECT ttl_override_seconds FROM chat_contexts WHERE chat_id = ?),
+		       (SELECT context_override FROM chat_contexts WHERE chat_id = ?),
+		       (SELECT model FROM chat_contexts WHERE chat_id = ?),
+		       (SELECT project FROM chat_contexts WHERE chat_id = ?)
+	`, chatID, chatType, sessionID, now, now, expiresAt, chatID, chatID, chatID, chatID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create context: %w", err)
 	}
@@ -66,7 +75,7 @@ func (s *Storage) CreateContext(chatID, chatType, sessionID string, ttl time.Dur
 	// Get the actual record ID via SELECT instead of LastInsertId()
 	// because LastInsertId() is unreliable after INSERT OR REPLACE
 	var id int64
-	err = s.db.QueryRow(`
+	err = s.db().QueryRow(`
 		SELECT id FROM chat_contexts WHERE chat_id = ?
 	`, chatID).Scan(&id)
 	if err != nil {
@@ -88,7 +97,7 @@ func (s *Storage) CreateContext(chatID, chatType, sessionID string, ttl time.Dur
 func (s *Storage) GetContext(chatID string) (*ChatContext, error) {
 	var ctx ChatContext
 	var claudeSessionID sql.NullString
-	err := s.db.QueryRow(`
+	err := s.db().QueryRow(`
 		SELECT id, chat_id, chat_type, session_id, claude_session_id, created_at, last_interaction, expires_at, is_active
 		FROM chat_contexts
 		WHERE chat_id = ?
@@ -118,11 +127,13 @@ func (s *Storage) GetContext(chatID string) (*ChatContext, error) {
 	return &ctx, nil
 }
 
+// RefreshContext extends an active context by ttl, or by the chat's TTL
+// override if one is set.
 func (s *Storage) RefreshContext(chatID string, ttl time.Duration) error {
 	now := time.Now()
-	expiresAt := now.Add(ttl)
+	expiresAt := now.Add(s.effectiveTTL(chatID, ttl))
 
-	result, err := s.db.Exec(`
+	result, err := s.db().Exec(`
 		UPDATE chat_contexts
 		SET last_interaction = ?, expires_at = ?
 		WHERE chat_id = ? AND is_active = 1
@@ -155,7 +166,7 @@ func (s *Storage) GetAllContexts(includeInactive bool) ([]*ChatContext, error) {
 	}
 	query += " ORDER BY last_interaction ASC"
 
-	rows, err := s.db.Query(query)
+	rows, err := s.readDB().Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all contexts: %w", err)
 	}
@@ -166,7 +177,7 @@ func (s *Storage) GetAllContexts(includeInactive bool) ([]*ChatContext, error) {
 
 func (s *Storage) GetExpiredContexts() ([]*ChatContext, error) {
 	now := time.Now()
-	rows, err := s.db.Query(`
+	rows, err := s.db().Query(`
 		SELECT id, chat_id, chat_type, session_id, claude_session_id,
 		       created_at, last_interaction, expires_at, is_active
 		FROM chat_contexts
@@ -181,7 +192,7 @@ func (s *Storage) GetExpiredContexts() ([]*ChatContext, error) {
 }
 
 func (s *Storage) DeactivateContext(chatID string) error {
-	result, err := s.db.Exec(`
+	result, err := s.db().Exec(`
 		UPDATE chat_contexts
 		SET is_active = 0
 		WHERE chat_id = ?
@@ -203,7 +214,7 @@ func (s *Storage) DeactivateContext(chatID string) error {
 
 func (s *Storage) GetActiveContextCount() (int, error) {
 	var count int
-	err := s.db.QueryRow(`
+	err := s.db().QueryRow(`
 		SELECT COUNT(*) FROM chat_contexts WHERE is_active = 1
 	`).Scan(&count)
 	if err != nil {
@@ -213,7 +224,7 @@ func (s *Storage) GetActiveContextCount() (int, error) {
 }
 
 func (s *Storage) UpdateClaudeSessionID(chatID, claudeSessionID string) error {
-	result, err := s.db.Exec(`
+	result, err := s.db().Exec(`
 		UPDATE chat_contexts
 		SET claude_session_id = ?
 		WHERE chat_id = ? AND is_active = 1
@@ -244,7 +255,12 @@ type CleanupResult struct {
 // Messages and tool executions are kept for audit/analysis purposes.
 // Session isolation is maintained via session_id filtering in retrieval queries.
 func (s *Storage) CleanupContextTx(chatID, cleanupType string) (*CleanupResult, error) {
-	tx, err := s.db.Begin()
+	// Make buffered messages/tools visible so counts and updates include them
+	if err := s.Flush(); err != nil {
+		slog.Warn("Failed to flush batched writes", "error", err)
+	}
+
+	tx, err := s.db().Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -264,11 +280,14 @@ func (s *Storage) CleanupContextTx(chatID, cleanupType string) (*CleanupResult,
 		return nil, fmt.Errorf("failed to deactivate context: %w", err)
 	}
 
-	// Log cleanup (with 0 deleted since we preserve data)
+	// Log cleanup (with 0 deleted since we preserve data), recording the
+	// session that ended so its place in the session tree is kept
 	_, err = tx.Exec(`
-		INSERT INTO cleanup_log (chat_id, cleanup_type, messages_deleted, tools_deleted, created_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, chatID, cleanupType, 0, 0, time.Now())
+		INSERT INTO cleanup_log (chat_id, cleanup_type, messages_deleted, tools_deleted, created_at, session_id, parent_session_id)
+		VALUES (?, ?, ?, ?, ?,
+		        (SELECT session_id FROM chat_contexts WHERE chat_id = ?),
+		        (SELECT parent_session_id FROM chat_contexts WHERE chat_id = ?))
+	`, chatID, cleanupType, 0, 0, time.Now(), chatID, chatID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to log cleanup: %w", err)
 	}
@@ -302,7 +321,7 @@ func (s *Storage) GetContextByClaudeSessionID(claudeSessionID string) (*ChatCont
 
 	// ORDER BY is_active DESC puts active (1) before inactive (0)
 	// Then by last_interaction DESC to get most recent
-	err := s.db.QueryRow(`
+	err := s.db().QueryRow(`
 		SELECT id, chat_id, chat_type, session_id, claude_session_id,
 		       created_at, last_interaction, expires_at, is_active
 		FROM chat_contexts
@@ -329,11 +348,89 @@ func (s *Storage) GetContextByClaudeSessionID(claudeSessionID string) (*ChatCont
 	return &ctx, nil
 }
 
+// GetContextBySessionID finds the context currently using sessionID.
+// Returns (nil, nil) if no chat is on that session.
+func (s *Storage) GetContextBySessionID(sessionID string) (*ChatContext, error) {
+	var ctx ChatContext
+	var claudeSID sql.NullString
+
+	err := s.db().QueryRow(`
+		SELECT id, chat_id, chat_type, session_id, claude_session_id,
+		       created_at, last_interaction, expires_at, is_active
+		FROM chat_contexts
+		WHERE session_id = ?
+	`, sessionID).Scan(
+		&ctx.ID, &ctx.ChatID, &ctx.ChatType, &ctx.SessionID,
+		&claudeSID, &ctx.CreatedAt, &ctx.LastInteraction,
+		&ctx.ExpiresAt, &ctx.IsActive,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get context by session id: %w", err)
+	}
+
+	if claudeSID.Valid {
+		ctx.ClaudeSessionID = claudeSID.String
+	}
+
+	return &ctx, nil
+}
+
+// DuplicateClaudeSession lists the active contexts sharing one Claude session,
+// most recently used first.
+type DuplicateClaudeSession struct {
+	ClaudeSessionID string
+	Contexts        []*ChatContext
+}
+
+// FindDuplicateActiveClaudeSessions returns Claude sessions that more than one
+// active context points at. This can happen when a transfer races with a
+// resume, and leaves GetContextByClaudeSessionID choosing between them.
+func (s *Storage) FindDuplicateActiveClaudeSessions() ([]DuplicateClaudeSession, error) {
+	rows, err := s.db().Query(`
+		SELECT id, chat_id, chat_type, session_id, claude_session_id,
+		       created_at, last_interaction, expires_at, is_active
+		FROM chat_contexts
+		WHERE is_active = 1 AND claude_session_id IN (
+			SELECT claude_session_id FROM chat_contexts
+			WHERE is_active = 1 AND claude_session_id IS NOT NULL AND claude_session_id != ''
+			GROUP BY claude_session_id
+			HAVING COUNT(*) > 1
+		)
+		ORDER BY claude_session_id, last_interaction DESC, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate claude sessions: %w", err)
+	}
+	defer rows.Close()
+
+	contexts, err := scanChatContexts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []DuplicateClaudeSession
+	for _, ctx := range contexts {
+		if n := len(duplicates); n > 0 && duplicates[n-1].ClaudeSessionID == ctx.ClaudeSessionID {
+			duplicates[n-1].Contexts = append(duplicates[n-1].Contexts, ctx)
+			continue
+		}
+		duplicates = append(duplicates, DuplicateClaudeSession{
+			ClaudeSessionID: ctx.ClaudeSessionID,
+			Contexts:        []*ChatContext{ctx},
+		})
+	}
+	return duplicates, nil
+}
+
 // HasActiveContextWithClaudeSessionID checks if any chat has an active context
 // with the given Claude session ID, excluding the specified chat.
 func (s *Storage) HasActiveContextWithClaudeSessionID(claudeSessionID, excludeChatID string) (bool, error) {
 	var count int
-	err := s.db.QueryRow(`
+	err := s.db().QueryRow(`
 		SELECT COUNT(*) FROM chat_contexts
 		WHERE claude_session_id = ? AND is_active = 1 AND chat_id != ?
 	`, claudeSessionID, excludeChatID).Scan(&count)
@@ -343,12 +440,13 @@ func (s *Storage) HasActiveContextWithClaudeSessionID(claudeSessionID, excludeCh
 	return count > 0, nil
 }
 
-// ReactivateContext reactivates an inactive context and refreshes its TTL.
+// ReactivateContext reactivates an inactive context and refreshes its TTL,
+// honoring the chat's TTL override.
 func (s *Storage) ReactivateContext(chatID string, ttl time.Duration) error {
 	now := time.Now()
-	expiresAt := now.Add(ttl)
+	expiresAt := now.Add(s.effectiveTTL(chatID, ttl))
 
-	result, err := s.db.Exec(`
+	result, err := s.db().Exec(`
 		UPDATE chat_contexts
 		SET is_active = 1, last_interaction = ?, expires_at = ?
 		WHERE chat_id = ? AND is_active = 0
@@ -372,7 +470,12 @@ func (s *Storage) ReactivateContext(chatID string, ttl time.Duration) error {
 // Handles both active and inactive source sessions.
 // Returns transfer details including whether source was active (for notification logic).
 func (s *Storage) TransferSession(sourceChatID, targetChatID, targetChatType, newSessionID string, ttl time.Duration) (*TransferResult, error) {
-	tx, err := s.db.Begin()
+	// Make buffered messages/tools visible so counts and updates include them
+	if err := s.Flush(); err != nil {
+		slog.Warn("Failed to flush batched writes", "error", err)
+	}
+
+	tx, err := s.db().Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -380,13 +483,13 @@ func (s *Storage) TransferSession(sourceChatID, targetChatID, targetChatType, ne
 
 	// Get source context details
 	var sourceSessionID string
-	var claudeSessionID sql.NullString
+	var claudeSessionID, sourceParentID, project sql.NullString
 	var sourceIsActive bool
 	err = tx.QueryRow(`
-		SELECT session_id, claude_session_id, is_active
+		SELECT session_id, claude_session_id, is_active, parent_session_id, project
 		FROM chat_contexts
 		WHERE chat_id = ?
-	`, sourceChatID).Scan(&sourceSessionID, &claudeSessionID, &sourceIsActive)
+	`, sourceChatID).Scan(&sourceSessionID, &claudeSessionID, &sourceIsActive, &sourceParentID, &project)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("source context not found")
 	}
@@ -409,14 +512,23 @@ func (s *Storage) TransferSession(sourceChatID, targetChatID, targetChatType, ne
 		return nil, fmt.Errorf("failed to deactivate source context: %w", err)
 	}
 
-	// Create/replace target context with same claude_session_id but new session_id
+	// Create/replace target context with same claude_session_id but new session_id,
+	// keeping the target chat's TTL and context overrides and model. The source
+	// session becomes its parent. The source's project comes along, since
+	// Claude can only resume the conversation in its project directory.
+	var override sql.NullInt64
+	var contextOverride, model sql.NullString
+	_ = tx.QueryRow(`SELECT ttl_override_seconds, context_override, model FROM chat_contexts WHERE chat_id = ?`, targetChatID).Scan(&override, &contextOverride, &model)
+	if override.Valid && override.Int64 > 0 {
+		ttl = time.Duration(override.Int64) * time.Second
+	}
 	now := time.Now()
 	expiresAt := now.Add(ttl)
 	_, err = tx.Exec(`
 		INSERT OR REPLACE INTO chat_contexts
-		(chat_id, chat_type, session_id, claude_session_id, created_at, last_interaction, expires_at, is_active)
-		VALUES (?, ?, ?, ?, ?, ?, ?, 1)
-	`, targetChatID, targetChatType, newSessionID, claudeSessionID.String, now, now, expiresAt)
+		(chat_id, chat_type, session_id, claude_session_id, created_at, last_interaction, expires_at, is_active, ttl_override_seconds, context_override, model, parent_session_id, project)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?)
+	`, targetChatID, targetChatType, newSessionID, claudeSessionID.String, now, now, expiresAt, override, contextOverride, model, sourceSessionID, project)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create target context: %w", err)
 	}
@@ -439,9 +551,9 @@ func (s *Storage) TransferSession(sourceChatID, targetChatID, targetChatType, ne
 
 	// Log transfer in cleanup_log
 	_, err = tx.Exec(`
-		INSERT INTO cleanup_log (chat_id, cleanup_type, messages_deleted, tools_deleted, created_at)
-		VALUES (?, 'transfer', 0, 0, ?)
-	`, sourceChatID, now)
+		INSERT INTO cleanup_log (chat_id, cleanup_type, messages_deleted, tools_deleted, created_at, session_id, parent_session_id)
+		VALUES (?, 'transfer', 0, 0, ?, ?, ?)
+	`, sourceChatID, now, sourceSessionID, sourceParentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to log transfer: %w", err)
 	}
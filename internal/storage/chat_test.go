@@ -3,6 +3,7 @@ package storage
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -34,13 +35,21 @@ CREATE TABLE IF NOT EXISTS chat_contexts (
     created_at DATETIME NOT NULL,
     last_interaction DATETIME NOT NULL,
     expires_at DATETIME NOT NULL,
-    is_active BOOLEAN NOT NULL DEFAULT 1
+    is_active BOOLEAN NOT NULL DEFAULT 1,
+    ttl_override_seconds INTEGER,
+    context_override TEXT,
+    model TEXT,
+    thread_id TEXT,
+    parent_session_id TEXT,
+    project TEXT
 );
 
 CREATE TABLE IF NOT EXISTS messages (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     chat_id TEXT NOT NULL,
     session_id TEXT,
+    user_id TEXT,
+    username TEXT,
     role TEXT NOT NULL,
     content TEXT NOT NULL,
     created_at DATETIME NOT NULL,
@@ -53,6 +62,8 @@ CREATE TABLE IF NOT EXISTS tool_executions (
     session_id TEXT,
     tool_name TEXT NOT NULL,
     status TEXT NOT NULL,
+    input TEXT,
+    output TEXT,
     created_at DATETIME NOT NULL,
     FOREIGN KEY (chat_id) REFERENCES chat_contexts(chat_id) ON DELETE CASCADE
 );
@@ -63,6 +74,86 @@ CREATE TABLE IF NOT EXISTS cleanup_log (
     cleanup_type TEXT NOT NULL,
     messages_deleted INTEGER NOT NULL,
     tools_deleted INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    session_id TEXT,
+    parent_session_id TEXT,
+    claude_session_id TEXT
+);
+
+CREATE TABLE IF NOT EXISTS chat_preferences (
+    chat_id TEXT PRIMARY KEY,
+    preferences TEXT NOT NULL DEFAULT '{}',
+    updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS chat_redactions (
+    chat_id TEXT NOT NULL,
+    term TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (chat_id, term)
+);
+
+CREATE TABLE IF NOT EXISTS response_links (
+    chat_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    query_message_id TEXT NOT NULL DEFAULT '',
+    query TEXT NOT NULL,
+    response TEXT NOT NULL,
+    feedback TEXT,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (chat_id, message_id)
+);
+
+CREATE TABLE IF NOT EXISTS session_incidents (
+    session_id TEXT PRIMARY KEY,
+    chat_id TEXT NOT NULL,
+    incident_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS command_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    user_id TEXT,
+    command TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS raw_outputs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    query TEXT NOT NULL,
+    raw TEXT NOT NULL,
+    canary BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    user_id TEXT,
+    action TEXT NOT NULL,
+    detail TEXT,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS query_traces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    user_id TEXT,
+    trace_id TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS redacted_answers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    original TEXT NOT NULL,
+    fraction REAL NOT NULL,
     created_at DATETIME NOT NULL
 );
 
@@ -318,6 +409,28 @@ func TestGetAllContexts_WithClaudeSessionID(t *testing.T) {
 	}
 }
 
+func TestGetContextBySessionID(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "group", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+
+	ctx, err := store.GetContextBySessionID("session-1")
+	if err != nil {
+		t.Fatalf("GetContextBySessionID failed: %v", err)
+	}
+	if ctx == nil || ctx.ChatID != "chat1" || ctx.ClaudeSessionID != "claude-abc" || !ctx.IsActive {
+		t.Errorf("GetContextBySessionID() = %+v, want chat1's active context", ctx)
+	}
+
+	// A chat that moved on no longer owns its old session
+	_, _ = store.CreateContext("chat1", "group", "session-2", time.Hour)
+	if ctx, err := store.GetContextBySessionID("session-1"); err != nil || ctx != nil {
+		t.Errorf("GetContextBySessionID(old) = %+v, %v; want nil", ctx, err)
+	}
+}
+
 func TestDeactivateContext(t *testing.T) {
 	store, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -351,9 +464,9 @@ func TestCleanupContextTx(t *testing.T) {
 
 	// Create context with messages and tool executions
 	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
-	_ = store.SaveMessage("chat123", "session-1", "user", "Hello")
-	_ = store.SaveMessage("chat123", "session-1", "assistant", "Hi there")
-	_ = store.SaveToolExecution("chat123", "session-1", "kubectl", "success")
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "Hello")
+	_ = store.SaveMessage("chat123", "session-1", "", "", "assistant", "Hi there")
+	_ = store.SaveToolExecution("chat123", "session-1", "kubectl", "success", "", "")
 
 	// Run transactional cleanup
 	result, err := store.CleanupContextTx("chat123", "test")
@@ -394,14 +507,14 @@ func TestSessionIsolation(t *testing.T) {
 
 	// Create first session and add messages
 	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
-	_ = store.SaveMessage("chat123", "session-1", "user", "Message from session 1")
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "Message from session 1")
 
 	// Cleanup (simulate expiry) - data preserved but context deactivated
 	_, _ = store.CleanupContextTx("chat123", "expired")
 
 	// Create second session and add messages
 	_, _ = store.CreateContext("chat123", "group", "session-2", 2*time.Hour)
-	_ = store.SaveMessage("chat123", "session-2", "user", "Message from session 2")
+	_ = store.SaveMessage("chat123", "session-2", "", "", "user", "Message from session 2")
 
 	// Session-scoped query should only return session-2 messages
 	session2Messages, _ := store.GetRecentMessagesBySession("chat123", "session-2", 100)
@@ -456,3 +569,256 @@ func TestGetActiveContextCount(t *testing.T) {
 		t.Errorf("Active count = %d, want 2", count)
 	}
 }
+
+func TestGetMessagesByUser(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
+	_ = store.SaveMessage("chat123", "session-1", "alice", "", "user", "Alice question")
+	_ = store.SaveMessage("chat123", "session-1", "alice", "", "assistant", "Answer for Alice")
+	_ = store.SaveMessage("chat123", "session-1", "bob", "", "user", "Bob question")
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "Legacy message")
+	_ = store.SaveMessage("chat123", "session-2", "alice", "", "user", "Other session")
+
+	messages, err := store.GetMessagesByUser("chat123", "session-1", "alice", 100)
+	if err != nil {
+		t.Fatalf("GetMessagesByUser failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages for alice, got %d", len(messages))
+	}
+	if messages[0].Content != "Alice question" || messages[1].Content != "Answer for Alice" {
+		t.Errorf("Unexpected messages or order: %q, %q", messages[0].Content, messages[1].Content)
+	}
+	if messages[0].UserID != "alice" {
+		t.Errorf("UserID = %q, want alice", messages[0].UserID)
+	}
+
+	// Unattributed messages have an empty UserID
+	all, _ := store.GetRecentMessagesBySession("chat123", "session-1", 100)
+	if len(all) != 4 {
+		t.Fatalf("Expected 4 messages in session-1, got %d", len(all))
+	}
+	if all[3].UserID != "" {
+		t.Errorf("Legacy message UserID = %q, want empty", all[3].UserID)
+	}
+}
+
+func TestSaveMessage_MaxContentLen(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	store.SetMaxContentLen(10)
+	_, _ = store.CreateContext("chat123", "private", "session-1", 2*time.Hour)
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "short")
+	_ = store.SaveMessage("chat123", "session-1", "", "", "assistant", "exactly10!")
+	_ = store.SaveMessage("chat123", "session-1", "", "", "assistant", "0123456789abcdef")
+	_ = store.SaveMessage("chat123", "session-1", "", "", "assistant", "ééééééé") // 14 bytes; cut must not split a rune
+
+	messages, err := store.GetRecentMessagesBySession("chat123", "session-1", 10)
+	if err != nil || len(messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d (err %v)", len(messages), err)
+	}
+
+	want := []string{
+		"short",
+		"exactly10!",
+		"0123456789\n\n[truncated 6 bytes]",
+		"ééééé\n\n[truncated 4 bytes]",
+	}
+	for i, w := range want {
+		if messages[i].Content != w {
+			t.Errorf("message %d content = %q, want %q", i, messages[i].Content, w)
+		}
+	}
+}
+
+func TestGetRecentMessagesBySessionPaged(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat123", "private", "session-1", 2*time.Hour)
+	for _, content := range []string{"m1", "m2", "m3", "m4", "m5"} {
+		_ = store.SaveMessage("chat123", "session-1", "", "", "user", content)
+	}
+
+	tests := []struct {
+		limit, offset int
+		want          []string
+	}{
+		{2, 0, []string{"m4", "m5"}},
+		{2, 2, []string{"m2", "m3"}},
+		{2, 4, []string{"m1"}},
+		{2, 6, nil},
+	}
+	for _, tt := range tests {
+		messages, err := store.GetRecentMessagesBySessionPaged("chat123", "session-1", tt.limit, tt.offset)
+		if err != nil {
+			t.Fatalf("GetRecentMessagesBySessionPaged(%d, %d) failed: %v", tt.limit, tt.offset, err)
+		}
+		var got []string
+		for _, m := range messages {
+			got = append(got, m.Content)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("GetRecentMessagesBySessionPaged(%d, %d) = %v, want %v", tt.limit, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestFindDuplicateActiveClaudeSessions(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// chat1 and chat2 share claude-a; chat3 is alone; chat4 shares claude-a but is inactive
+	_, _ = store.CreateContext("chat1", "group", "session-1", 2*time.Hour)
+	_, _ = store.CreateContext("chat4", "group", "session-4", 2*time.Hour)
+	time.Sleep(20 * time.Millisecond)
+	_, _ = store.CreateContext("chat2", "group", "session-2", 2*time.Hour)
+	_, _ = store.CreateContext("chat3", "group", "session-3", 2*time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-a")
+	_ = store.UpdateClaudeSessionID("chat2", "claude-a")
+	_ = store.UpdateClaudeSessionID("chat3", "claude-b")
+	_ = store.UpdateClaudeSessionID("chat4", "claude-a")
+	_ = store.DeactivateContext("chat4")
+
+	dups, err := store.FindDuplicateActiveClaudeSessions()
+	if err != nil {
+		t.Fatalf("FindDuplicateActiveClaudeSessions failed: %v", err)
+	}
+
+	if len(dups) != 1 {
+		t.Fatalf("Expected 1 duplicated Claude session, got %d", len(dups))
+	}
+	if dups[0].ClaudeSessionID != "claude-a" || len(dups[0].Contexts) != 2 {
+		t.Fatalf("Unexpected duplicate %+v", dups[0])
+	}
+	if dups[0].Contexts[0].ChatID != "chat2" {
+		t.Errorf("Expected most recently used context chat2 first, got %s", dups[0].Contexts[0].ChatID)
+	}
+}
+
+func TestSearchMessagesFTS(t *testing.T) {
+	migration, err := os.ReadFile(filepath.Join("..", "..", "migrations", "018_add_messages_fts.sql"))
+	if err != nil {
+		t.Fatalf("Failed to read FTS migration: %v", err)
+	}
+
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Indexed by the migration's rebuild
+	_ = store.SaveMessage("chat123", "session-1", "", "", "user", "payment service is down")
+
+	if err := os.WriteFile(filepath.Join("migrations", "018_add_messages_fts.sql"), migration, 0644); err != nil {
+		t.Fatalf("Failed to write migration: %v", err)
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+	t.Logf("FTS5 available: %v", store.fts)
+
+	// Indexed by the insert trigger
+	_ = store.SaveMessage("chat123", "session-2", "", "", "assistant", "The payment service payment queue is backed up")
+	_ = store.SaveMessage("chat123", "session-2", "", "", "user", "unrelated")
+	_ = store.SaveMessage("other", "session-3", "", "", "user", "payment service in another chat")
+
+	messages, err := store.SearchMessagesFTS("chat123", "payment service", 10)
+	if err != nil {
+		t.Fatalf("SearchMessagesFTS() failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("SearchMessagesFTS() returned %d messages, want 2 from both sessions", len(messages))
+	}
+	for _, m := range messages {
+		if m.ChatID != "chat123" || !strings.Contains(m.Content, "payment service") {
+			t.Errorf("Unexpected match %+v", m)
+		}
+	}
+
+	if _, err := store.SearchMessagesFTS("chat123", `"payment" OR *`, 10); err != nil {
+		t.Errorf("Query syntax in the term should be matched literally, got %v", err)
+	}
+}
+
+func TestMessageRoles(t *testing.T) {
+	names := []string{"018_add_messages_fts.sql", "022_add_message_roles.sql", "023_restore_messages_fts_triggers.sql", "027_add_message_username.sql"}
+	migrations := make(map[string][]byte)
+	for _, name := range names {
+		migration, err := os.ReadFile(filepath.Join("..", "..", "migrations", name))
+		if err != nil {
+			t.Fatalf("Failed to read migration: %v", err)
+		}
+		migrations[name] = migration
+	}
+
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := store.SaveMessage("chat123", "session-1", "", "", "bogus", "text"); err == nil {
+		t.Error("Expected error for an invalid role")
+	}
+	_ = store.SaveMessage("chat123", "session-1", "", "", RoleUser, "payment service is down")
+
+	// Rebuilding messages for the new roles keeps rows and, with FTS5,
+	// search indexing
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join("migrations", name), migrations[name], 0644); err != nil {
+			t.Fatalf("Failed to write migration: %v", err)
+		}
+	}
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("Migrate() failed: %v", err)
+	}
+
+	for _, role := range []string{RoleAssistant, RoleSystem, RoleSummary} {
+		if err := store.SaveMessage("chat123", "session-1", "", "", role, "payment service "+role); err != nil {
+			t.Errorf("SaveMessage(%s) error = %v", role, err)
+		}
+	}
+	if _, err := store.db().Exec(`INSERT INTO messages (chat_id, role, content) VALUES ('chat123', 'bogus', 'x')`); err == nil {
+		t.Error("Expected the schema to reject an invalid role")
+	}
+
+	messages, err := store.GetRecentMessagesBySession("chat123", "session-1", 10)
+	if err != nil || len(messages) != 4 || messages[0].Role != RoleUser || messages[3].Role != RoleSummary {
+		t.Fatalf("GetRecentMessagesBySession() = %v, %v; want all four messages", messages, err)
+	}
+	if store.fts {
+		found, err := store.SearchMessagesFTS("chat123", "payment service", 10)
+		if err != nil || len(found) != 4 {
+			t.Errorf("SearchMessagesFTS() = %d messages, %v; want old and new messages indexed", len(found), err)
+		}
+	}
+}
+
+func TestSaveMessage_Authors(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "group", "s1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+	_ = store.SaveMessage("chat1", "s1", "42", "alice", RoleUser, "why is checkout down?")
+	_ = store.SaveMessage("chat1", "s1", "43", "", RoleUser, "and payments?")
+
+	messages, err := store.GetRecentMessagesBySession("chat1", "s1", 10)
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("GetRecentMessagesBySession() = %v, %v; want 2 messages", messages, err)
+	}
+	if m := messages[0]; m.UserID != "42" || m.Username != "alice" {
+		t.Errorf("messages[0] = %+v, want alice's ID and username", m)
+	}
+	if m := messages[1]; m.UserID != "43" || m.Username != "" {
+		t.Errorf("messages[1] = %+v, want a user ID without username", m)
+	}
+
+	// Forks keep the authors
+	if _, err := store.ForkContext("chat1", "s2"); err != nil {
+		t.Fatalf("ForkContext failed: %v", err)
+	}
+	forked, _ := store.GetMessagesByUser("chat1", "s2", "42", 10)
+	if len(forked) != 1 || forked[0].Username != "alice" {
+		t.Errorf("Forked messages = %+v, want alice's username kept", forked)
+	}
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SetContextOverride stores a context snippet for a chat that is added to
+// every query. The override survives session resets. An empty text removes it.
+func (s *Storage) SetContextOverride(chatID, text string) error {
+	value := sql.NullString{String: text, Valid: text != ""}
+
+	result, err := s.db().Exec(`
+		UPDATE chat_contexts SET context_override = ? WHERE chat_id = ?
+	`, value, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to set context override: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("context not found")
+	}
+
+	return nil
+}
+
+// GetContextOverride returns the chat's context snippet, or "" if none is set.
+func (s *Storage) GetContextOverride(chatID string) (string, error) {
+	var text sql.NullString
+	err := s.db().QueryRow(`
+		SELECT context_override FROM chat_contexts WHERE chat_id = ?
+	`, chatID).Scan(&text)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get context override: %w", err)
+	}
+	return text.String, nil
+}
@@ -8,13 +8,24 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 type Storage struct {
-	db *sql.DB
+	// pool is the primary connection pool. It is kept across snapshot
+	// restores, which drain its connections instead; see swapDatabaseFile.
+	pool        *sql.DB
+	restoreMu   sync.RWMutex // Held for writing while a snapshot is restored
+	connecting  atomic.Int64 // Connections waiting for a restore to finish
+	dbPath      string
+	snapshotDir string
+	batcher     *writeBatcher
+	replica     *sql.DB // Optional; see EnableReadReplica
+	maxContent  int     // 0 = store message content in full
+	fts         bool    // messages_fts exists; see SearchMessagesFTS
+	maxOpen     int     // Connection pool size; see SetMaxOpenConns
 }
 
 func NewStorage(dbPath string) (*Storage, error) {
@@ -23,32 +34,67 @@ func NewStorage(dbPath string) (*Storage, error) {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	storage := &Storage{
+		dbPath:      dbPath,
+		snapshotDir: filepath.Join(dir, "snapshots"),
+		maxOpen:     defaultMaxOpenConns,
+	}
+	db, err := preparePool(sql.OpenDB(restoreConnector{storage}), defaultMaxOpenConns)
+	if err != nil {
+		return nil, err
+	}
+	storage.pool = db
+
+	if err := storage.Migrate(); err != nil {
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	return storage, nil
+}
+
+// openDatabase opens a SQLite connection pool of up to maxOpen connections
+// and verifies it is reachable.
+func openDatabase(dbPath string, maxOpen int) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	return preparePool(db, maxOpen)
+}
 
-	db.SetMaxOpenConns(50)
-	db.SetMaxIdleConns(10)
+// preparePool sizes db's connection pool and verifies it is reachable,
+// closing it if not.
+func preparePool(db *sql.DB, maxOpen int) (*sql.DB, error) {
+	configurePool(db, maxOpen)
 	db.SetConnMaxLifetime(30 * time.Minute)
 	db.SetConnMaxIdleTime(5 * time.Minute)
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	storage := &Storage{db: db}
+	return db, nil
+}
 
-	if err := storage.Migrate(); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
-	}
+// db returns the primary connection pool. While a snapshot is restored, its
+// queries wait for a connection until the restore finishes.
+func (s *Storage) db() *sql.DB {
+	return s.pool
+}
 
-	return storage, nil
+// SchemaVersion returns the newest applied migration, e.g. "012_add_context_override".
+func (s *Storage) SchemaVersion() (string, error) {
+	var version sql.NullString
+	if err := s.db().QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version.String, nil
 }
 
 func (s *Storage) Migrate() error {
 	// Create schema_migrations table to track applied migrations
-	_, err := s.db.Exec(`
+	_, err := s.db().Exec(`
 		CREATE TABLE IF NOT EXISTS schema_migrations (
 			version TEXT PRIMARY KEY,
 			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
@@ -60,7 +106,7 @@ func (s *Storage) Migrate() error {
 
 	// Get list of applied migrations
 	applied := make(map[string]bool)
-	rows, err := s.db.Query("SELECT version FROM schema_migrations")
+	rows, err := s.db().Query("SELECT version FROM schema_migrations")
 	if err != nil {
 		return fmt.Errorf("failed to query applied migrations: %w", err)
 	}
@@ -96,8 +142,13 @@ func (s *Storage) Migrate() error {
 		if err != nil {
 			return fmt.Errorf("failed to read migration %s: %w", version, err)
 		}
+		if requiresFTS5(string(data)) && !s.hasFTS5() {
+			slog.Warn("SQLite was built without FTS5, skipping migration; message search falls back to LIKE",
+				"version", version)
+			continue
+		}
 
-		tx, err := s.db.Begin()
+		tx, err := s.db().Begin()
 		if err != nil {
 			return fmt.Errorf("failed to begin transaction for migration %s: %w", version, err)
 		}
@@ -119,13 +170,50 @@ func (s *Storage) Migrate() error {
 		slog.Info("Applied migration", "version", version)
 	}
 
+	s.fts = s.tableExists("messages_fts")
 	return nil
 }
 
+// requiresFTS5 reports whether a migration is marked as needing FTS5 with a
+// leading "-- requires: fts5" line. Such migrations are skipped, and retried
+// on the next start, when SQLite lacks the module.
+func requiresFTS5(migration string) bool {
+	return strings.HasPrefix(strings.TrimSpace(migration), "-- requires: fts5")
+}
+
+// hasFTS5 reports whether SQLite was built with the FTS5 module
+// (go-sqlite3's sqlite_fts5 build tag).
+func (s *Storage) hasFTS5() bool {
+	if _, err := s.db().Exec(`CREATE VIRTUAL TABLE temp.fts5_probe USING fts5(x)`); err != nil {
+		return false
+	}
+	if _, err := s.db().Exec(`DROP TABLE temp.fts5_probe`); err != nil {
+		slog.Debug("Failed to drop FTS5 probe table", "error", err)
+	}
+	return true
+}
+
+// tableExists reports whether a table (including virtual tables) exists.
+func (s *Storage) tableExists(name string) bool {
+	var count int
+	err := s.db().QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	return err == nil && count > 0
+}
+
 func (s *Storage) Close() error {
-	return s.db.Close()
+	if s.batcher != nil {
+		if err := s.batcher.stop(); err != nil {
+			slog.Error("Failed to flush batched writes on close", "error", err)
+		}
+	}
+	if s.replica != nil {
+		if err := s.replica.Close(); err != nil {
+			slog.Warn("Failed to close read replica", "error", err)
+		}
+	}
+	return s.db().Close()
 }
 
 func (s *Storage) Begin() (*sql.Tx, error) {
-	return s.db.Begin()
+	return s.db().Begin()
 }
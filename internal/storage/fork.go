@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ForkResult holds the details of a session fork.
+type ForkResult struct {
+	ParentSessionID string
+	ClaudeSessionID string
+	MessagesCopied  int
+}
+
+// ForkContext replaces the chat's active context with a fork of it under
+// newSessionID. The fork starts with a copy of the session's messages and
+// Claude session ID, keeps the chat's expiry, TTL and context overrides,
+// model and project, and records the original session as its parent. The
+// original session's messages are left in place and it is logged as forked
+// with its Claude session ID, so /tree and the audit trail still show it and
+// RestoreForkedSession can switch back to it.
+func (s *Storage) ForkContext(chatID, newSessionID string) (*ForkResult, error) {
+	// Make buffered messages visible so the copy includes them
+	if err := s.Flush(); err != nil {
+		slog.Warn("Failed to flush batched writes", "error", err)
+	}
+
+	tx, err := s.db().Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var chatType, sessionID string
+	var claudeSessionID, parentID, contextOverride, model, project sql.NullString
+	var override sql.NullInt64
+	var expiresAt time.Time
+	var isActive bool
+	err = tx.QueryRow(`
+		SELECT chat_type, session_id, claude_session_id, parent_session_id, expires_at, is_active,
+		       ttl_override_seconds, context_override, model, project
+		FROM chat_contexts
+		WHERE chat_id = ?
+	`, chatID).Scan(&chatType, &sessionID, &claudeSessionID, &parentID, &expiresAt, &isActive,
+		&override, &contextOverride, &model, &project)
+	if err == sql.ErrNoRows || (err == nil && !isActive) {
+		return nil, fmt.Errorf("context not found or inactive")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get context: %w", err)
+	}
+	if !claudeSessionID.Valid || claudeSessionID.String == "" {
+		return nil, fmt.Errorf("context has no claude_session_id")
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO chat_contexts
+		(chat_id, chat_type, session_id, claude_session_id, created_at, last_interaction, expires_at, is_active, ttl_override_seconds, context_override, model, parent_session_id, project)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?)
+	`, chatID, chatType, newSessionID, claudeSessionID.String, now, now, expiresAt, override, contextOverride, model, sessionID, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create forked context: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO messages (chat_id, session_id, user_id, username, role, content, created_at)
+		SELECT chat_id, ?, user_id, username, role, content, created_at
+		FROM messages
+		WHERE session_id = ?
+		ORDER BY id
+	`, newSessionID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy messages: %w", err)
+	}
+	copied, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO cleanup_log (chat_id, cleanup_type, messages_deleted, tools_deleted, created_at, session_id, parent_session_id, claude_session_id)
+		VALUES (?, 'fork', 0, 0, ?, ?, ?, ?)
+	`, chatID, now, sessionID, parentID, claudeSessionID.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to log fork: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &ForkResult{
+		ParentSessionID: sessionID,
+		ClaudeSessionID: claudeSessionID.String,
+		MessagesCopied:  int(copied),
+	}, nil
+}
+
+// GetForkedSessionChat returns the chat that forked away from the session
+// with claudeSessionID, or "" if no chat did.
+func (s *Storage) GetForkedSessionChat(claudeSessionID string) (string, error) {
+	var chatID string
+	err := s.db().QueryRow(`
+		SELECT chat_id
+		FROM cleanup_log
+		WHERE cleanup_type = 'fork' AND claude_session_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, claudeSessionID).Scan(&chatID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get forked session: %w", err)
+	}
+	return chatID, nil
+}
+
+// RestoreForkedSession switches chatID back to a session it forked away
+// from, identified by its Claude session ID. The session the chat is leaving
+// is logged the same way a forked session is, so it can be restored in turn.
+// The chat keeps its TTL and context overrides, model and project. It
+// reports false if the chat never forked away from claudeSessionID.
+func (s *Storage) RestoreForkedSession(chatID, claudeSessionID string, ttl time.Duration) (bool, error) {
+	tx, err := s.db().Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var forkedSessionID string
+	var forkedParentID sql.NullString
+	err = tx.QueryRow(`
+		SELECT session_id, parent_session_id
+		FROM cleanup_log
+		WHERE chat_id = ? AND cleanup_type = 'fork' AND claude_session_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, chatID, claudeSessionID).Scan(&forkedSessionID, &forkedParentID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get forked session: %w", err)
+	}
+
+	var chatType, sessionID string
+	var currentClaudeID, parentID, contextOverride, model, project sql.NullString
+	var override sql.NullInt64
+	err = tx.QueryRow(`
+		SELECT chat_type, session_id, claude_session_id, parent_session_id,
+		       ttl_override_seconds, context_override, model, project
+		FROM chat_contexts
+		WHERE chat_id = ?
+	`, chatID).Scan(&chatType, &sessionID, &currentClaudeID, &parentID,
+		&override, &contextOverride, &model, &project)
+	if err == sql.ErrNoRows {
+		return false, fmt.Errorf("context not found")
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get context: %w", err)
+	}
+
+	now := time.Now()
+	if currentClaudeID.Valid && currentClaudeID.String != "" && sessionID != forkedSessionID {
+		_, err = tx.Exec(`
+			INSERT INTO cleanup_log (chat_id, cleanup_type, messages_deleted, tools_deleted, created_at, session_id, parent_session_id, claude_session_id)
+			VALUES (?, 'fork', 0, 0, ?, ?, ?, ?)
+		`, chatID, now, sessionID, parentID, currentClaudeID.String)
+		if err != nil {
+			return false, fmt.Errorf("failed to log replaced session: %w", err)
+		}
+	}
+
+	if override.Valid && override.Int64 > 0 {
+		ttl = time.Duration(override.Int64) * time.Second
+	}
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO chat_contexts
+		(chat_id, chat_type, session_id, claude_session_id, created_at, last_interaction, expires_at, is_active, ttl_override_seconds, context_override, model, parent_session_id, project)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?, ?, ?)
+	`, chatID, chatType, forkedSessionID, claudeSessionID, now, now, now.Add(ttl), override, contextOverride, model, forkedParentID, project)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore forked session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForkContext(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "group", "s1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+	_ = store.SetChatModel("chat1", "opus")
+	_ = store.SaveMessage("chat1", "s1", "alice", "", RoleUser, "Why is checkout down?")
+	_ = store.SaveMessage("chat1", "s1", "alice", "", RoleAssistant, "Pods are OOMKilled.")
+
+	result, err := store.ForkContext("chat1", "s2")
+	if err != nil {
+		t.Fatalf("ForkContext failed: %v", err)
+	}
+	if result.ParentSessionID != "s1" || result.ClaudeSessionID != "claude-abc" || result.MessagesCopied != 2 {
+		t.Errorf("ForkContext = %+v, want parent s1, claude-abc and 2 messages copied", result)
+	}
+
+	ctx, err := store.GetContext("chat1")
+	if err != nil {
+		t.Fatalf("GetContext failed: %v", err)
+	}
+	if ctx.SessionID != "s2" || ctx.ClaudeSessionID != "claude-abc" || !ctx.IsActive {
+		t.Errorf("Context = %+v, want the active fork s2 on claude-abc", ctx)
+	}
+	if model, _ := store.GetChatModel("chat1"); model != "opus" {
+		t.Errorf("Chat model = %q, want it kept", model)
+	}
+
+	// Both sessions hold the messages; new ones only go to the fork
+	_ = store.SaveMessage("chat1", "s2", "alice", "", RoleUser, "What if we roll back?")
+	for session, want := range map[string]int{"s1": 2, "s2": 3} {
+		if n, _ := store.GetMessageCountBySession("chat1", session); n != want {
+			t.Errorf("Messages in %s = %d, want %d", session, n, want)
+		}
+	}
+	forked, _ := store.GetRecentMessagesBySession("chat1", "s2", 10)
+	if len(forked) != 3 || forked[0].Content != "Why is checkout down?" || forked[0].UserID != "alice" {
+		t.Errorf("Forked messages = %+v, want the copied history first", forked)
+	}
+
+	tree, err := store.GetSessionTree("s2")
+	if err != nil {
+		t.Fatalf("GetSessionTree failed: %v", err)
+	}
+	var got []string
+	treeShape(tree, 0, &got)
+	want := []string{"chat1:fork", "  chat1:active"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("GetSessionTree = %q, want %q", got, want)
+	}
+}
+
+func TestForkContext_RequiresActiveClaudeSession(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := store.ForkContext("missing", "s2"); err == nil {
+		t.Error("Expected error forking a chat without a context")
+	}
+
+	_, _ = store.CreateContext("chat1", "private", "s1", time.Hour)
+	if _, err := store.ForkContext("chat1", "s2"); err == nil {
+		t.Error("Expected error forking a session without a Claude session ID")
+	}
+
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+	_ = store.DeactivateContext("chat1")
+	if _, err := store.ForkContext("chat1", "s2"); err == nil {
+		t.Error("Expected error forking an inactive session")
+	}
+}
+
+func TestRestoreForkedSession(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "group", "s1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+	if _, err := store.ForkContext("chat1", "s2"); err != nil {
+		t.Fatalf("ForkContext failed: %v", err)
+	}
+	_ = store.UpdateClaudeSessionID("chat1", "claude-fork")
+
+	if chatID, _ := store.GetForkedSessionChat("claude-abc"); chatID != "chat1" {
+		t.Errorf("GetForkedSessionChat = %q, want chat1", chatID)
+	}
+	if restored, err := store.RestoreForkedSession("chat2", "claude-abc", time.Hour); err != nil || restored {
+		t.Errorf("RestoreForkedSession in another chat = %v, %v, want false", restored, err)
+	}
+
+	restored, err := store.RestoreForkedSession("chat1", "claude-abc", time.Hour)
+	if err != nil || !restored {
+		t.Fatalf("RestoreForkedSession = %v, %v, want true", restored, err)
+	}
+	ctx, _ := store.GetContext("chat1")
+	if ctx.SessionID != "s1" || ctx.ClaudeSessionID != "claude-abc" || !ctx.IsActive {
+		t.Errorf("Context = %+v, want s1 on claude-abc", ctx)
+	}
+
+	// The fork it left is logged and can be restored in turn
+	if restored, _ := store.RestoreForkedSession("chat1", "claude-fork", time.Hour); !restored {
+		t.Fatal("The replaced fork should be restorable")
+	}
+	ctx, _ = store.GetContext("chat1")
+	if parent, _ := store.sessionParent(ctx.SessionID); ctx.SessionID != "s2" || parent != "s1" {
+		t.Errorf("Context = %+v with parent %q, want the fork s2 with parent s1", ctx, parent)
+	}
+}
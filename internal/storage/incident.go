@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SetSessionIncident links a session to an external incident ID, replacing
+// any previous link.
+func (s *Storage) SetSessionIncident(chatID, sessionID, incidentID string) error {
+	_, err := s.db().Exec(`
+		INSERT INTO session_incidents (session_id, chat_id, incident_id, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET incident_id = excluded.incident_id, created_at = excluded.created_at
+	`, sessionID, chatID, incidentID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save session incident: %w", err)
+	}
+	return nil
+}
+
+// GetSessionIncident returns the incident ID linked to a session, or "" if none.
+func (s *Storage) GetSessionIncident(sessionID string) (string, error) {
+	var incidentID string
+	err := s.db().QueryRow(`
+		SELECT incident_id FROM session_incidents WHERE session_id = ?
+	`, sessionID).Scan(&incidentID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get session incident: %w", err)
+	}
+	return incidentID, nil
+}
+
+// ClearSessionIncident removes the incident link for a session.
+func (s *Storage) ClearSessionIncident(sessionID string) error {
+	if _, err := s.db().Exec(`DELETE FROM session_incidents WHERE session_id = ?`, sessionID); err != nil {
+		return fmt.Errorf("failed to clear session incident: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import "testing"
+
+func TestSessionIncident(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	got, err := store.GetSessionIncident("session1")
+	if err != nil || got != "" {
+		t.Fatalf("GetSessionIncident() on empty store = %q, %v; want \"\", nil", got, err)
+	}
+
+	if err := store.SetSessionIncident("chat1", "session1", "INC-1"); err != nil {
+		t.Fatalf("SetSessionIncident failed: %v", err)
+	}
+	if err := store.SetSessionIncident("chat1", "session1", "INC-2"); err != nil {
+		t.Fatalf("SetSessionIncident (replace) failed: %v", err)
+	}
+
+	if got, _ := store.GetSessionIncident("session1"); got != "INC-2" {
+		t.Errorf("Expected replaced incident INC-2, got %q", got)
+	}
+	if got, _ := store.GetSessionIncident("session2"); got != "" {
+		t.Errorf("Expected no incident for another session, got %q", got)
+	}
+
+	if err := store.ClearSessionIncident("session1"); err != nil {
+		t.Fatalf("ClearSessionIncident failed: %v", err)
+	}
+	if got, _ := store.GetSessionIncident("session1"); got != "" {
+		t.Errorf("Expected incident cleared, got %q", got)
+	}
+}
@@ -2,23 +2,72 @@ package storage
 
 import (
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+// Message roles. Only user and assistant messages are conversation turns;
+// system notes and summaries are the bot's own annotations.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleSystem    = "system"  // A note by the bot, such as a pinned annotation
+	RoleSummary   = "summary" // A /summarize result
+)
+
+// ValidRole reports whether role is one of the message roles.
+func ValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleAssistant, RoleSystem, RoleSummary:
+		return true
+	}
+	return false
+}
+
 type Message struct {
 	ID        int64
 	ChatID    string
 	SessionID string
-	Role      string
+	UserID    string // Requesting user; empty for legacy rows
+	Username  string // Requesting user's username, if they have one
+	Role      string // One of the Role constants
 	Content   string
 	CreatedAt time.Time
 }
 
-func (s *Storage) SaveMessage(chatID, sessionID, role, content string) error {
-	_, err := s.db.Exec(`
-		INSERT INTO messages (chat_id, session_id, role, content, created_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, chatID, sessionID, role, content, time.Now())
+// truncatedMarker is appended to message content cut short by SetMaxContentLen.
+const truncatedMarker = "\n\n[truncated %d bytes]"
+
+// SetMaxContentLen limits how many bytes of message content are stored.
+// Longer content is cut and marked as truncated. 0 stores content in full.
+func (s *Storage) SetMaxContentLen(n int) {
+	s.maxContent = n
+}
+
+// limitContent truncates content to maxContent bytes on a rune boundary.
+func (s *Storage) limitContent(content string) string {
+	if s.maxContent <= 0 || len(content) <= s.maxContent {
+		return content
+	}
+	cut := s.maxContent
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut] + fmt.Sprintf(truncatedMarker, len(content)-cut)
+}
+
+// SaveMessage saves a message attributed to userID and username, either of
+// which may be empty. Assistant replies are attributed to the user whose query
+// produced them; the username is shown as the author in group chat history.
+func (s *Storage) SaveMessage(chatID, sessionID, userID, username, role, content string) error {
+	if !ValidRole(role) {
+		return fmt.Errorf("failed to save message: invalid role %q", role)
+	}
+	err := s.exec(`
+		INSERT INTO messages (chat_id, session_id, user_id, username, role, content, created_at)
+		VALUES (?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?)
+	`, chatID, sessionID, userID, username, role, s.limitContent(content), time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to save message: %w", err)
 	}
@@ -28,8 +77,8 @@ func (s *Storage) SaveMessage(chatID, sessionID, role, content string) error {
 // GetRecentMessages returns all recent messages for a chat (across all sessions).
 // Use GetRecentMessagesBySession for session-isolated queries.
 func (s *Storage) GetRecentMessages(chatID string, limit int) ([]*Message, error) {
-	rows, err := s.db.Query(`
-		SELECT id, chat_id, COALESCE(session_id, ''), role, content, created_at
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, COALESCE(session_id, ''), COALESCE(user_id, ''), COALESCE(username, ''), role, content, created_at
 		FROM messages
 		WHERE chat_id = ?
 		ORDER BY created_at DESC
@@ -43,7 +92,7 @@ func (s *Storage) GetRecentMessages(chatID string, limit int) ([]*Message, error
 	var messages []*Message
 	for rows.Next() {
 		var msg Message
-		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.SessionID, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.SessionID, &msg.UserID, &msg.Username, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 		messages = append(messages, &msg)
@@ -63,22 +112,146 @@ func (s *Storage) GetRecentMessages(chatID string, limit int) ([]*Message, error
 
 // GetRecentMessagesBySession returns recent messages for a specific session only.
 func (s *Storage) GetRecentMessagesBySession(chatID, sessionID string, limit int) ([]*Message, error) {
-	rows, err := s.db.Query(`
-		SELECT id, chat_id, session_id, role, content, created_at
+	return s.GetRecentMessagesBySessionPaged(chatID, sessionID, limit, 0)
+}
+
+// GetRecentMessagesBySessionPaged returns up to limit messages of a session
+// after skipping the offset most recent ones, in chronological order.
+func (s *Storage) GetRecentMessagesBySessionPaged(chatID, sessionID string, limit, offset int) ([]*Message, error) {
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, session_id, COALESCE(user_id, ''), COALESCE(username, ''), role, content, created_at
 		FROM messages
 		WHERE chat_id = ? AND session_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, chatID, sessionID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent messages by session: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.SessionID, &msg.UserID, &msg.Username, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+
+	// Reverse to get chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
+	return messages, nil
+}
+
+// likeEscaper escapes LIKE wildcards so a search term matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchMessages returns up to limit messages of a session whose content
+// contains term, ignoring ASCII case, most recent first.
+func (s *Storage) SearchMessages(chatID, sessionID, term string, limit int) ([]*Message, error) {
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, session_id, COALESCE(user_id, ''), COALESCE(username, ''), role, content, created_at
+		FROM messages
+		WHERE chat_id = ? AND session_id = ? AND content LIKE ? ESCAPE '\'
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`, chatID, sessionID, "%"+likeEscaper.Replace(term)+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.SessionID, &msg.UserID, &msg.Username, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+	return messages, nil
+}
+
+// SearchMessagesFTS returns up to limit messages from any session of a chat
+// that match term, best matches first. Words in term are matched as a phrase
+// and the last one as a prefix. Without the FTS5 index (see migration 018) it
+// falls back to a LIKE search, most recent first.
+func (s *Storage) SearchMessagesFTS(chatID, term string, limit int) ([]*Message, error) {
+	query := `
+		SELECT m.id, m.chat_id, m.session_id, COALESCE(m.user_id, ''), COALESCE(m.username, ''), m.role, m.content, m.created_at
+		FROM messages_fts f
+		JOIN messages m ON m.id = f.rowid
+		WHERE messages_fts MATCH ? AND m.chat_id = ?
+		ORDER BY f.rank
+		LIMIT ?
+	`
+	args := []any{ftsPhrasePrefix(term), chatID, limit}
+	if !s.fts {
+		query = `
+			SELECT id, chat_id, session_id, COALESCE(user_id, ''), COALESCE(username, ''), role, content, created_at
+			FROM messages
+			WHERE chat_id = ? AND content LIKE ? ESCAPE '\'
+			ORDER BY created_at DESC, id DESC
+			LIMIT ?
+		`
+		args = []any{chatID, "%" + likeEscaper.Replace(term) + "%", limit}
+	}
+
+	rows, err := s.readDB().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*Message
+	for rows.Next() {
+		var msg Message
+		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.SessionID, &msg.UserID, &msg.Username, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message: %w", err)
+		}
+		messages = append(messages, &msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating messages: %w", err)
+	}
+	return messages, nil
+}
+
+// ftsPhrasePrefix quotes term as an FTS5 phrase with a trailing prefix match,
+// so user input is never parsed as query syntax.
+func ftsPhrasePrefix(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+}
+
+// GetMessagesByUser returns recent messages in a session attributed to userID.
+func (s *Storage) GetMessagesByUser(chatID, sessionID, userID string, limit int) ([]*Message, error) {
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, session_id, user_id, COALESCE(username, ''), role, content, created_at
+		FROM messages
+		WHERE chat_id = ? AND session_id = ? AND user_id = ?
 		ORDER BY created_at DESC
 		LIMIT ?
-	`, chatID, sessionID, limit)
+	`, chatID, sessionID, userID, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get recent messages by session: %w", err)
+		return nil, fmt.Errorf("failed to get messages by user: %w", err)
 	}
 	defer rows.Close()
 
 	var messages []*Message
 	for rows.Next() {
 		var msg Message
-		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.SessionID, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+		if err := rows.Scan(&msg.ID, &msg.ChatID, &msg.SessionID, &msg.UserID, &msg.Username, &msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 		messages = append(messages, &msg)
@@ -99,7 +272,7 @@ func (s *Storage) GetRecentMessagesBySession(chatID, sessionID string, limit int
 // GetMessageCount returns the total message count for a chat (across all sessions).
 func (s *Storage) GetMessageCount(chatID string) (int, error) {
 	var count int
-	err := s.db.QueryRow(`
+	err := s.db().QueryRow(`
 		SELECT COUNT(*) FROM messages WHERE chat_id = ?
 	`, chatID).Scan(&count)
 	if err != nil {
@@ -111,7 +284,7 @@ func (s *Storage) GetMessageCount(chatID string) (int, error) {
 // GetMessageCountBySession returns the message count for a specific session only.
 func (s *Storage) GetMessageCountBySession(chatID, sessionID string) (int, error) {
 	var count int
-	err := s.db.QueryRow(`
+	err := s.readDB().QueryRow(`
 		SELECT COUNT(*) FROM messages WHERE chat_id = ? AND session_id = ?
 	`, chatID, sessionID).Scan(&count)
 	if err != nil {
@@ -119,4 +292,3 @@ func (s *Storage) GetMessageCountBySession(chatID, sessionID string) (int, error
 	}
 	return count, nil
 }
-
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SetChatModel stores the Claude model used for a chat's queries. The choice
+// survives session resets. An empty model removes it.
+func (s *Storage) SetChatModel(chatID, model string) error {
+	value := sql.NullString{String: model, Valid: model != ""}
+
+	result, err := s.db().Exec(`
+		UPDATE chat_contexts SET model = ? WHERE chat_id = ?
+	`, value, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to set chat model: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("context not found")
+	}
+
+	return nil
+}
+
+// GetChatModel returns the chat's model, or "" if none is set.
+func (s *Storage) GetChatModel(chatID string) (string, error) {
+	var model sql.NullString
+	err := s.db().QueryRow(`
+		SELECT model FROM chat_contexts WHERE chat_id = ?
+	`, chatID).Scan(&model)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get chat model: %w", err)
+	}
+	return model.String, nil
+}
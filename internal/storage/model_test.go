@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChatModel_SetAndSurvivesReset(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := store.SetChatModel("chat123", "opus"); err == nil {
+		t.Error("SetChatModel should fail without a context")
+	}
+	if model, err := store.GetChatModel("chat123"); err != nil || model != "" {
+		t.Errorf("GetChatModel() = %q, %v; want empty", model, err)
+	}
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", time.Hour)
+	if err := store.SetChatModel("chat123", "opus"); err != nil {
+		t.Fatalf("SetChatModel failed: %v", err)
+	}
+
+	_ = store.DeactivateContext("chat123")
+	_, _ = store.CreateContext("chat123", "group", "session-2", time.Hour)
+	if model, _ := store.GetChatModel("chat123"); model != "opus" {
+		t.Errorf("Model after reset = %q, want opus", model)
+	}
+
+	if err := store.SetChatModel("chat123", ""); err != nil {
+		t.Fatalf("SetChatModel(\"\") failed: %v", err)
+	}
+	if model, _ := store.GetChatModel("chat123"); model != "" {
+		t.Errorf("Model after clearing = %q, want empty", model)
+	}
+}
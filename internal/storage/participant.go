@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// IsChatParticipant reports whether userID ever sent a message, command or
+// query in chatID. Messages move with transferred sessions, but audit entries
+// stay with the chat they were sent in. Denied messages don't count.
+func (s *Storage) IsChatParticipant(chatID, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	// Include buffered writes, such as the user's latest message
+	if err := s.Flush(); err != nil {
+		slog.Warn("Failed to flush batched writes", "error", err)
+	}
+	var participant bool
+	err := s.db().QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM messages WHERE chat_id = ? AND user_id = ?)
+		    OR EXISTS (SELECT 1 FROM audit_log WHERE chat_id = ? AND user_id = ? AND action != ?)
+	`, chatID, userID, chatID, userID, AuditDenied).Scan(&participant)
+	if err != nil {
+		return false, fmt.Errorf("failed to check chat participant: %w", err)
+	}
+	return participant, nil
+}
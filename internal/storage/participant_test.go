@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsChatParticipant(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "group", "s1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+	_ = store.SaveMessage("chat1", "s1", "alice", "", RoleUser, "why is checkout down?")
+	_ = store.RecordAudit("chat1", "bob", AuditCommand, "/session")
+	_ = store.RecordAudit("chat2", "mallory", AuditCommand, "/sessions")
+	_ = store.RecordAudit("chat1", "eve", AuditDenied, "/resume")
+
+	// The transfer moves alice's message out of chat1
+	if _, err := store.TransferSession("chat1", "chat3", "group", "s2", time.Hour); err != nil {
+		t.Fatalf("TransferSession failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		chatID, userID string
+		want           bool
+	}{
+		{"chat3", "alice", true},
+		{"chat1", "bob", true},
+		{"chat1", "mallory", false},
+		{"chat1", "eve", false},
+		{"chat1", "", false},
+	} {
+		got, err := store.IsChatParticipant(tt.chatID, tt.userID)
+		if err != nil {
+			t.Fatalf("IsChatParticipant(%s, %s) error = %v", tt.chatID, tt.userID, err)
+		}
+		if got != tt.want {
+			t.Errorf("IsChatParticipant(%s, %s) = %v, want %v", tt.chatID, tt.userID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// driverName is the SQLite driver with file descriptor exhaustion
+	// reported as ErrTooManyOpenFiles.
+	driverName = "sqlite3_fdcheck"
+
+	defaultMaxOpenConns = 50
+	// maxIdleConns bounds the connections kept open while unused.
+	maxIdleConns = 10
+)
+
+// ErrTooManyOpenFiles is returned, wrapped, when a database connection can't
+// be opened because the process ran out of file descriptors.
+var ErrTooManyOpenFiles = errors.New("database connection failed: too many open files " +
+	"(raise the bot's open file limit, e.g. ulimit -n, or lower storage.max_open_conns)")
+
+func init() {
+	sql.Register(driverName, fdCheckDriver{&sqlite3.SQLiteDriver{}})
+}
+
+// fdCheckDriver reports connections that failed for lack of file
+// descriptors as ErrTooManyOpenFiles instead of SQLite's generic "unable to
+// open database file".
+type fdCheckDriver struct {
+	driver.Driver
+}
+
+func (d fdCheckDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil && isFDExhausted(err) {
+		return nil, fmt.Errorf("%w: %v", ErrTooManyOpenFiles, err)
+	}
+	return conn, err
+}
+
+// isFDExhausted reports whether err was caused by the process or system
+// running out of file descriptors.
+func isFDExhausted(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.SystemErrno == syscall.EMFILE || sqliteErr.SystemErrno == syscall.ENFILE
+	}
+	return errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENFILE)
+}
+
+// restoreConnector opens connections to the primary database, holding them
+// back while a snapshot is restored so none opens the file mid-swap.
+type restoreConnector struct {
+	s *Storage
+}
+
+func (c restoreConnector) Connect(context.Context) (driver.Conn, error) {
+	c.s.connecting.Add(1)
+	c.s.restoreMu.RLock()
+	c.s.connecting.Add(-1)
+	defer c.s.restoreMu.RUnlock()
+	return c.Driver().Open(c.s.dbPath)
+}
+
+func (c restoreConnector) Driver() driver.Driver {
+	return fdCheckDriver{&sqlite3.SQLiteDriver{}}
+}
+
+// configurePool sizes db's connection pool.
+func configurePool(db *sql.DB, maxOpen int) {
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(min(maxOpen, maxIdleConns))
+}
+
+// SetMaxOpenConns limits how many database connections, and so file
+// descriptors, the pool holds open. Applies to the read replica and to
+// databases restored from snapshots too. Defaults to 50.
+func (s *Storage) SetMaxOpenConns(n int) {
+	s.maxOpen = n
+	configurePool(s.db(), n)
+	if s.replica != nil {
+		configurePool(s.replica, n)
+	}
+}
+
+// PoolStats reports the primary database's connection pool usage: open,
+// in-use and idle connections, and how often queries waited for one.
+func (s *Storage) PoolStats() sql.DBStats {
+	return s.db().Stats()
+}
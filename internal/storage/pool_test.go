@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestPoolStats(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	store.SetMaxOpenConns(3)
+
+	ctx := context.Background()
+	conn, err := store.db().Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn() error = %v", err)
+	}
+	stats := store.PoolStats()
+	if stats.InUse != 1 || stats.MaxOpenConnections != 3 {
+		t.Errorf("With a connection held: in use %d of %d max, want 1 of 3", stats.InUse, stats.MaxOpenConnections)
+	}
+
+	conn.Close()
+	stats = store.PoolStats()
+	if stats.InUse != 0 || stats.Idle < 1 {
+		t.Errorf("After release: %d in use, %d idle; want 0 in use and the connection idle", stats.InUse, stats.Idle)
+	}
+}
+
+// failingDriver fails every Open with err.
+type failingDriver struct {
+	err error
+}
+
+func (d failingDriver) Open(string) (driver.Conn, error) {
+	return nil, d.err
+}
+
+func TestFDCheckDriver(t *testing.T) {
+	exhausted := sqlite3.Error{Code: sqlite3.ErrCantOpen, SystemErrno: syscall.EMFILE}
+	_, err := fdCheckDriver{failingDriver{exhausted}}.Open("test.db")
+	if !errors.Is(err, ErrTooManyOpenFiles) {
+		t.Errorf("Open() error = %v, want ErrTooManyOpenFiles", err)
+	}
+
+	_, err = fdCheckDriver{failingDriver{syscall.ENFILE}}.Open("test.db")
+	if !errors.Is(err, ErrTooManyOpenFiles) {
+		t.Errorf("Open() error = %v, want ErrTooManyOpenFiles for ENFILE", err)
+	}
+
+	other := sqlite3.Error{Code: sqlite3.ErrCantOpen, SystemErrno: syscall.EACCES}
+	_, err = fdCheckDriver{failingDriver{other}}.Open("test.db")
+	if errors.Is(err, ErrTooManyOpenFiles) || !errors.As(err, new(sqlite3.Error)) {
+		t.Errorf("Open() error = %v, want the SQLite error unchanged", err)
+	}
+}
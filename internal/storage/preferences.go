@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// GetPreferences returns the stored preferences for a chat.
+// Returns an empty (non-nil) map when none are stored.
+func (s *Storage) GetPreferences(chatID string) (map[string]string, error) {
+	var blob string
+	err := s.db().QueryRow(`
+		SELECT preferences FROM chat_preferences WHERE chat_id = ?
+	`, chatID).Scan(&blob)
+	if err == sql.ErrNoRows {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+
+	prefs := map[string]string{}
+	if err := json.Unmarshal([]byte(blob), &prefs); err != nil {
+		return nil, fmt.Errorf("failed to decode preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// SetPreferences replaces the stored preferences for a chat.
+func (s *Storage) SetPreferences(chatID string, prefs map[string]string) error {
+	if prefs == nil {
+		prefs = map[string]string{}
+	}
+	blob, err := json.Marshal(prefs)
+	if err != nil {
+		return fmt.Errorf("failed to encode preferences: %w", err)
+	}
+
+	_, err = s.db().Exec(`
+		INSERT INTO chat_preferences (chat_id, preferences, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET preferences = excluded.preferences, updated_at = excluded.updated_at
+	`, chatID, string(blob), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreferences_GetSet(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	prefs, err := store.GetPreferences("chat123")
+	if err != nil {
+		t.Fatalf("GetPreferences failed: %v", err)
+	}
+	if prefs == nil || len(prefs) != 0 {
+		t.Errorf("GetPreferences for unknown chat = %v, want empty map", prefs)
+	}
+
+	if err := store.SetPreferences("chat123", map[string]string{"plain": "on", "language": "German"}); err != nil {
+		t.Fatalf("SetPreferences failed: %v", err)
+	}
+	if err := store.SetPreferences("chat123", map[string]string{"plain": "off"}); err != nil {
+		t.Fatalf("SetPreferences (update) failed: %v", err)
+	}
+
+	prefs, _ = store.GetPreferences("chat123")
+	if len(prefs) != 1 || prefs["plain"] != "off" {
+		t.Errorf("GetPreferences = %v, want map[plain:off]", prefs)
+	}
+
+	other, _ := store.GetPreferences("chat456")
+	if len(other) != 0 {
+		t.Errorf("Preferences should be per chat, got %v", other)
+	}
+}
+
+func TestPreferences_SurviveSessionReset(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat123", "private", "session-1", 2*time.Hour)
+	_ = store.SetPreferences("chat123", map[string]string{"footer": "on"})
+
+	_, _ = store.CleanupContextTx("chat123", "manual")
+	_, _ = store.CreateContext("chat123", "private", "session-2", 2*time.Hour)
+
+	prefs, _ := store.GetPreferences("chat123")
+	if prefs["footer"] != "on" {
+		t.Errorf("Preferences lost after session reset: %v", prefs)
+	}
+}
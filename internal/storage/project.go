@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SetChatProject stores the name of the project a chat's queries run in. The
+// choice survives session resets. An empty name removes it.
+func (s *Storage) SetChatProject(chatID, project string) error {
+	value := sql.NullString{String: project, Valid: project != ""}
+
+	result, err := s.db().Exec(`
+		UPDATE chat_contexts SET project = ? WHERE chat_id = ?
+	`, value, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to set chat project: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("context not found")
+	}
+
+	return nil
+}
+
+// GetChatProject returns the chat's project name, or "" if none is set.
+func (s *Storage) GetChatProject(chatID string) (string, error) {
+	var project sql.NullString
+	err := s.db().QueryRow(`
+		SELECT project FROM chat_contexts WHERE chat_id = ?
+	`, chatID).Scan(&project)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get chat project: %w", err)
+	}
+	return project.String, nil
+}
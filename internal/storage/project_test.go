@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChatProject_SetAndSurvivesReset(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := store.SetChatProject("chat123", "cluster-a"); err == nil {
+		t.Error("SetChatProject should fail without a context")
+	}
+	if project, err := store.GetChatProject("chat123"); err != nil || project != "" {
+		t.Errorf("GetChatProject() = %q, %v; want empty", project, err)
+	}
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", time.Hour)
+	if err := store.SetChatProject("chat123", "cluster-a"); err != nil {
+		t.Fatalf("SetChatProject failed: %v", err)
+	}
+
+	_ = store.DeactivateContext("chat123")
+	_, _ = store.CreateContext("chat123", "group", "session-2", time.Hour)
+	if project, _ := store.GetChatProject("chat123"); project != "cluster-a" {
+		t.Errorf("Project after reset = %q, want cluster-a", project)
+	}
+
+	if err := store.SetChatProject("chat123", ""); err != nil {
+		t.Fatalf("SetChatProject(\"\") failed: %v", err)
+	}
+	if project, _ := store.GetChatProject("chat123"); project != "" {
+		t.Errorf("Project after clearing = %q, want empty", project)
+	}
+}
+
+func TestChatProject_FollowsTransferredSession(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "group", "s1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+	_ = store.SetChatProject("chat1", "cluster-b")
+	_, _ = store.CreateContext("chat2", "group", "s2", time.Hour)
+	_ = store.SetChatProject("chat2", "cluster-a")
+
+	if _, err := store.TransferSession("chat1", "chat2", "group", "s3", time.Hour); err != nil {
+		t.Fatalf("TransferSession failed: %v", err)
+	}
+	// The conversation can only be resumed in the project it started in
+	if project, _ := store.GetChatProject("chat2"); project != "cluster-b" {
+		t.Errorf("Target project = %q, want the source's cluster-b", project)
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RawOutput is the unparsed Claude CLI output behind one answer. It is not
+// redacted, so it must only be shown after sanitizing.
+type RawOutput struct {
+	ID        int64
+	ChatID    string
+	SessionID string
+	Query     string
+	Raw       string
+	Canary    bool
+	CreatedAt time.Time
+}
+
+// SaveRawOutput records the raw output of the query asked in a chat.
+func (s *Storage) SaveRawOutput(chatID, sessionID, query, raw string, canary bool) error {
+	err := s.exec(`
+		INSERT INTO raw_outputs (chat_id, session_id, query, raw, canary, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, chatID, sessionID, query, raw, canary, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save raw output: %w", err)
+	}
+	return nil
+}
+
+// GetRawOutput returns the chat's raw output with the given ID, or the latest
+// one when id is 0. Returns (nil, nil) if there is none.
+func (s *Storage) GetRawOutput(chatID string, id int64) (*RawOutput, error) {
+	var out RawOutput
+	err := s.db().QueryRow(`
+		SELECT id, chat_id, session_id, query, raw, canary, created_at
+		FROM raw_outputs
+		WHERE chat_id = ? AND (? = 0 OR id = ?)
+		ORDER BY id DESC
+		LIMIT 1
+	`, chatID, id, id).Scan(&out.ID, &out.ChatID, &out.SessionID, &out.Query, &out.Raw, &out.Canary, &out.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get raw output: %w", err)
+	}
+	return &out, nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import "testing"
+
+func TestRawOutputs(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if rec, err := store.GetRawOutput("chat123", 0); err != nil || rec != nil {
+		t.Fatalf("GetRawOutput() on empty table = %v, %v; want nil, nil", rec, err)
+	}
+
+	if err := store.SaveRawOutput("chat123", "s1", "first?", `{"type":"result","result":"one"}`, false); err != nil {
+		t.Fatalf("SaveRawOutput failed: %v", err)
+	}
+	if err := store.SaveRawOutput("chat123", "s1", "second?", `{"type":"result","result":"two"}`, true); err != nil {
+		t.Fatalf("SaveRawOutput failed: %v", err)
+	}
+	_ = store.SaveRawOutput("other", "s2", "elsewhere?", "raw", false)
+
+	latest, err := store.GetRawOutput("chat123", 0)
+	if err != nil || latest == nil {
+		t.Fatalf("GetRawOutput(latest) = %v, %v", latest, err)
+	}
+	if latest.Query != "second?" || !latest.Canary || latest.SessionID != "s1" {
+		t.Errorf("latest = %+v, want the second, canary recording", latest)
+	}
+
+	first, err := store.GetRawOutput("chat123", latest.ID-1)
+	if err != nil || first == nil || first.Raw != `{"type":"result","result":"one"}` || first.Canary {
+		t.Errorf("GetRawOutput(first) = %+v, %v", first, err)
+	}
+
+	other, _ := store.GetRawOutput("other", 0)
+	if rec, _ := store.GetRawOutput("chat123", other.ID); rec != nil {
+		t.Errorf("Recordings of another chat must not be returned, got %+v", rec)
+	}
+}
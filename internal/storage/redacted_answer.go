@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RedactedAnswer is an answer as Claude gave it, before redaction removed
+// Fraction of it. It must only be shown to admins.
+type RedactedAnswer struct {
+	ID        int64
+	ChatID    string
+	SessionID string
+	Original  string
+	Fraction  float64
+	CreatedAt time.Time
+}
+
+// SaveRedactedAnswer keeps the unredacted original of a heavily redacted
+// answer and returns its ID.
+func (s *Storage) SaveRedactedAnswer(chatID, sessionID, original string, fraction float64) (int64, error) {
+	res, err := s.db().Exec(`
+		INSERT INTO redacted_answers (chat_id, session_id, original, fraction, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, chatID, sessionID, original, fraction, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to save redacted answer: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get redacted answer ID: %w", err)
+	}
+	return id, nil
+}
+
+// GetRedactedAnswer returns the redacted answer with the given ID, or
+// (nil, nil) if there is none.
+func (s *Storage) GetRedactedAnswer(id int64) (*RedactedAnswer, error) {
+	var a RedactedAnswer
+	err := s.db().QueryRow(`
+		SELECT id, chat_id, session_id, original, fraction, created_at
+		FROM redacted_answers
+		WHERE id = ?
+	`, id).Scan(&a.ID, &a.ChatID, &a.SessionID, &a.Original, &a.Fraction, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redacted answer: %w", err)
+	}
+	return &a, nil
+}
@@ -0,0 +1,24 @@
+package storage
+
+import "testing"
+
+func TestRedactedAnswers(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	id, err := store.SaveRedactedAnswer("chat1", "session-1", "sha256 abcdef", 0.8)
+	if err != nil {
+		t.Fatalf("SaveRedactedAnswer() error = %v", err)
+	}
+	a, err := store.GetRedactedAnswer(id)
+	if err != nil || a == nil {
+		t.Fatalf("GetRedactedAnswer() = %v, %v", a, err)
+	}
+	if a.ChatID != "chat1" || a.SessionID != "session-1" || a.Original != "sha256 abcdef" || a.Fraction != 0.8 || a.CreatedAt.IsZero() {
+		t.Errorf("GetRedactedAnswer() = %+v", a)
+	}
+
+	if a, err := store.GetRedactedAnswer(id + 1); a != nil || err != nil {
+		t.Errorf("GetRedactedAnswer(missing) = %v, %v; want nil, nil", a, err)
+	}
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// AddRedactionTerm stores a term to redact from the chat's answers. Adding a
+// term that is already stored is a no-op.
+func (s *Storage) AddRedactionTerm(chatID, term string) error {
+	_, err := s.db().Exec(`
+		INSERT OR IGNORE INTO chat_redactions (chat_id, term, created_at)
+		VALUES (?, ?, ?)
+	`, chatID, term, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to add redaction term: %w", err)
+	}
+	return nil
+}
+
+// RemoveRedactionTerm deletes a stored term. Returns false if the chat had no
+// such term.
+func (s *Storage) RemoveRedactionTerm(chatID, term string) (bool, error) {
+	result, err := s.db().Exec(`
+		DELETE FROM chat_redactions WHERE chat_id = ? AND term = ?
+	`, chatID, term)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove redaction term: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// GetRedactionTerms returns the chat's redaction terms in the order they were
+// added. Returns an empty slice (not nil) when there are none.
+func (s *Storage) GetRedactionTerms(chatID string) ([]string, error) {
+	rows, err := s.db().Query(`
+		SELECT term FROM chat_redactions WHERE chat_id = ? ORDER BY created_at, rowid
+	`, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get redaction terms: %w", err)
+	}
+	defer rows.Close()
+
+	terms := make([]string, 0)
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, fmt.Errorf("failed to scan redaction term: %w", err)
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
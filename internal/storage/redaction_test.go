@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactionTerms(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, term := range []string{"Falcon", "db-prod.internal", "Falcon"} {
+		if err := store.AddRedactionTerm("chat123", term); err != nil {
+			t.Fatalf("AddRedactionTerm(%q) failed: %v", term, err)
+		}
+	}
+	_ = store.AddRedactionTerm("other", "Heron")
+
+	terms, err := store.GetRedactionTerms("chat123")
+	if err != nil {
+		t.Fatalf("GetRedactionTerms failed: %v", err)
+	}
+	if want := []string{"Falcon", "db-prod.internal"}; !reflect.DeepEqual(terms, want) {
+		t.Errorf("terms = %v, want %v", terms, want)
+	}
+
+	removed, err := store.RemoveRedactionTerm("chat123", "Falcon")
+	if err != nil || !removed {
+		t.Errorf("RemoveRedactionTerm() = %v, %v; want true, nil", removed, err)
+	}
+	if removed, _ := store.RemoveRedactionTerm("chat123", "Falcon"); removed {
+		t.Error("Removing a missing term should report false")
+	}
+
+	terms, _ = store.GetRedactionTerms("chat123")
+	if want := []string{"db-prod.internal"}; !reflect.DeepEqual(terms, want) {
+		t.Errorf("terms after remove = %v, want %v", terms, want)
+	}
+	if terms, _ := store.GetRedactionTerms("missing"); terms == nil || len(terms) != 0 {
+		t.Errorf("terms for unknown chat = %#v, want empty slice", terms)
+	}
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// EnableReadReplica opens a second connection pool for heavy read queries
+// (/history, /sessions, tool and usage stats). Writes, and reads that must see
+// the latest state such as GetContext or the rows /replay and /unredact look up
+// right after they are saved, stay on the primary. The DSN is passed to the
+// SQLite driver as-is, e.g. "file:/data/replica.db?mode=ro".
+func (s *Storage) EnableReadReplica(dsn string) error {
+	db, err := openDatabase(dsn, s.maxOpen)
+	if err != nil {
+		return fmt.Errorf("failed to open read replica: %w", err)
+	}
+	s.replica = db
+	slog.Info("Read replica enabled")
+	return nil
+}
+
+// readDB returns the connection pool for heavy reads: the replica when
+// configured, otherwise the primary.
+func (s *Storage) readDB() *sql.DB {
+	if s.replica != nil {
+		return s.replica
+	}
+	return s.db()
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadReplica_RoutesHeavyReads(t *testing.T) {
+	primary, cleanupPrimary := setupTestDB(t)
+	defer cleanupPrimary()
+	replica, cleanupReplica := setupTestDB(t)
+	defer cleanupReplica()
+
+	// Data only the replica has, so reads served from it are distinguishable
+	_, _ = replica.CreateContext("replica-chat", "group", "replica-session", time.Hour)
+	_ = replica.SaveMessage("replica-chat", "replica-session", "", "", "user", "from replica")
+	_ = replica.SaveToolExecution("replica-chat", "replica-session", "Bash", "success", "", "")
+
+	if err := primary.EnableReadReplica(replica.dbPath); err != nil {
+		t.Fatalf("EnableReadReplica failed: %v", err)
+	}
+
+	contexts, err := primary.GetAllContexts(true)
+	if err != nil || len(contexts) != 1 || contexts[0].ChatID != "replica-chat" {
+		t.Errorf("GetAllContexts should read from replica, got %v (err %v)", contexts, err)
+	}
+
+	msgs, err := primary.GetRecentMessagesBySession("replica-chat", "replica-session", 10)
+	if err != nil || len(msgs) != 1 || msgs[0].Content != "from replica" {
+		t.Errorf("GetRecentMessagesBySession should read from replica, got %v (err %v)", msgs, err)
+	}
+
+	// Counts come from the same handle as the pages they number
+	if n, err := primary.GetMessageCountBySession("replica-chat", "replica-session"); err != nil || n != 1 {
+		t.Errorf("GetMessageCountBySession should read from replica, got %d (err %v)", n, err)
+	}
+
+	tools, err := primary.GetToolExecutionsBySession("replica-chat", "replica-session", 10)
+	if err != nil || len(tools) != 1 {
+		t.Errorf("GetToolExecutionsBySession should read from replica, got %v (err %v)", tools, err)
+	}
+
+	// Point lookups and writes stay on the primary
+	if ctx, _ := primary.GetContext("replica-chat"); ctx != nil {
+		t.Error("GetContext should read from the primary")
+	}
+	_, _ = primary.CreateContext("primary-chat", "group", "primary-session", time.Hour)
+	_ = primary.SaveRawOutput("primary-chat", "primary-session", "q", "raw", false)
+	if out, _ := primary.GetRawOutput("primary-chat", 0); out == nil {
+		t.Error("GetRawOutput should read its own writes from the primary")
+	}
+	id, _ := primary.SaveRedactedAnswer("primary-chat", "primary-session", "original", 0.5)
+	if a, _ := primary.GetRedactedAnswer(id); a == nil {
+		t.Error("GetRedactedAnswer should read its own writes from the primary")
+	}
+	if ctx, _ := replica.GetContext("primary-chat"); ctx != nil {
+		t.Error("Writes should go to the primary only")
+	}
+}
+
+func TestReadReplica_FallsBackToPrimary(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if store.readDB() != store.db() {
+		t.Error("Without a replica, reads should use the primary")
+	}
+}
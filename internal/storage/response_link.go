@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ResponseLink ties a bot answer message to the query that produced it.
+type ResponseLink struct {
+	ChatID         string
+	MessageID      string // Bot message carrying (part of) the answer
+	SessionID      string
+	QueryMessageID string // User message that asked the query
+	Query          string
+	Response       string
+	Feedback       string
+	CreatedAt      time.Time
+}
+
+// SaveResponseLink records the link for one bot message, replacing any
+// previous link for the same message.
+func (s *Storage) SaveResponseLink(link *ResponseLink) error {
+	_, err := s.db().Exec(`
+		INSERT OR REPLACE INTO response_links
+		(chat_id, message_id, session_id, query_message_id, query, response, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, link.ChatID, link.MessageID, link.SessionID, link.QueryMessageID, link.Query, link.Response, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save response link: %w", err)
+	}
+	return nil
+}
+
+// GetResponseLink returns the link for a bot message, or (nil, nil) if the
+// message is not a linked answer.
+func (s *Storage) GetResponseLink(chatID, messageID string) (*ResponseLink, error) {
+	var link ResponseLink
+	var feedback sql.NullString
+	err := s.db().QueryRow(`
+		SELECT chat_id, message_id, session_id, query_message_id, query, response, feedback, created_at
+		FROM response_links
+		WHERE chat_id = ? AND message_id = ?
+	`, chatID, messageID).Scan(
+		&link.ChatID, &link.MessageID, &link.SessionID, &link.QueryMessageID,
+		&link.Query, &link.Response, &feedback, &link.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response link: %w", err)
+	}
+	link.Feedback = feedback.String
+	return &link, nil
+}
+
+// SetResponseFeedback records user feedback (e.g. "negative") on a linked answer.
+func (s *Storage) SetResponseFeedback(chatID, messageID, feedback string) error {
+	result, err := s.db().Exec(`
+		UPDATE response_links SET feedback = ? WHERE chat_id = ? AND message_id = ?
+	`, feedback, chatID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to save response feedback: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("response link not found")
+	}
+	return nil
+}
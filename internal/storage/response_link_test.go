@@ -0,0 +1,42 @@
+package storage
+
+import "testing"
+
+func TestResponseLink(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	link := &ResponseLink{
+		ChatID:         "chat1",
+		MessageID:      "101",
+		SessionID:      "session-1",
+		QueryMessageID: "100",
+		Query:          "why is the pod crashing?",
+		Response:       "OOMKilled",
+	}
+	if err := store.SaveResponseLink(link); err != nil {
+		t.Fatalf("SaveResponseLink failed: %v", err)
+	}
+
+	got, err := store.GetResponseLink("chat1", "101")
+	if err != nil || got == nil {
+		t.Fatalf("GetResponseLink() = %v, %v", got, err)
+	}
+	if got.Query != link.Query || got.Response != link.Response || got.QueryMessageID != "100" || got.Feedback != "" {
+		t.Errorf("Unexpected link %+v", got)
+	}
+
+	if err := store.SetResponseFeedback("chat1", "101", "negative"); err != nil {
+		t.Fatalf("SetResponseFeedback failed: %v", err)
+	}
+	if got, _ := store.GetResponseLink("chat1", "101"); got.Feedback != "negative" {
+		t.Errorf("Expected negative feedback, got %q", got.Feedback)
+	}
+
+	if got, err := store.GetResponseLink("chat1", "999"); got != nil || err != nil {
+		t.Errorf("Expected (nil, nil) for unlinked message, got %v, %v", got, err)
+	}
+	if err := store.SetResponseFeedback("chat1", "999", "negative"); err == nil {
+		t.Error("Expected error setting feedback on unlinked message")
+	}
+}
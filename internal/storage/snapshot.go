@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotNamePattern restricts snapshot names to safe file names.
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// restoreDrainTimeout bounds how long a restore waits for in-flight
+// database queries to finish.
+const restoreDrainTimeout = 30 * time.Second
+
+// SetSnapshotDir overrides where database snapshots are stored.
+// Defaults to a "snapshots" directory next to the database file.
+func (s *Storage) SetSnapshotDir(dir string) {
+	s.snapshotDir = dir
+}
+
+// snapshotPath validates the name and returns the snapshot file path.
+func (s *Storage) snapshotPath(name string) (string, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q (use letters, digits, - and _)", name)
+	}
+	return filepath.Join(s.snapshotDir, name+".db"), nil
+}
+
+// CreateSnapshot copies the current database into a named snapshot file using
+// VACUUM INTO, which produces a consistent copy without blocking readers.
+// Returns the snapshot file path.
+func (s *Storage) CreateSnapshot(name string) (string, error) {
+	path, err := s.snapshotPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(s.snapshotDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("snapshot %q already exists", name)
+	}
+
+	if err := s.Flush(); err != nil {
+		slog.Warn("Failed to flush batched writes before snapshot", "error", err)
+	}
+
+	if _, err := s.db().Exec(`VACUUM INTO ?`, path); err != nil {
+		return "", fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	slog.Info("Created database snapshot", "name", name, "path", path)
+	return path, nil
+}
+
+// ListSnapshots returns the names of available snapshots, sorted alphabetically.
+func (s *Storage) ListSnapshots() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.snapshotDir, "*.db"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), ".db"))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// RestoreSnapshot replaces the active database with a named snapshot.
+// Batched writes are flushed and paused, and queries wait until the restore
+// finishes. Every connection is closed, once its query finishes, before the
+// snapshot is renamed over the database file, so nothing writes to the
+// replaced file, and a stale rollback journal is removed so SQLite can't
+// replay it onto the snapshot. If the snapshot can't be opened, the original
+// database is put back. The restored database is then migrated.
+func (s *Storage) RestoreSnapshot(name string) error {
+	path, err := s.snapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("snapshot %q not found", name)
+		}
+		return fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+
+	// Copy to a temp file first so the rename over the live DB is atomic
+	tmpPath := s.dbPath + ".restore"
+	if err := copyFile(path, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to copy snapshot: %w", err)
+	}
+
+	if s.batcher != nil {
+		if err := s.batcher.pause(); err != nil {
+			slog.Warn("Failed to flush batched writes before restore", "error", err)
+		}
+		defer s.batcher.resume()
+	}
+
+	if err := s.swapDatabaseFile(tmpPath); err != nil {
+		return err
+	}
+
+	// Snapshot may predate newer migrations
+	if err := s.Migrate(); err != nil {
+		return fmt.Errorf("failed to migrate restored database: %w", err)
+	}
+
+	slog.Info("Restored database snapshot", "name", name)
+	return nil
+}
+
+// swapDatabaseFile renames newPath over the database file once every pool
+// connection is closed, holding back new connections meanwhile. The original
+// file is set aside until the new one opens, and put back if it doesn't or
+// the rename fails.
+func (s *Storage) swapDatabaseFile(newPath string) error {
+	defer os.Remove(newPath)
+
+	s.restoreMu.Lock()
+	defer s.restoreMu.Unlock()
+	defer configurePool(s.pool, s.maxOpen)
+
+	if err := s.drainConnections(restoreDrainTimeout); err != nil {
+		return err
+	}
+
+	originalPath := s.dbPath + ".orig"
+	if err := os.Rename(s.dbPath, originalPath); err != nil {
+		return fmt.Errorf("failed to set aside database file: %w", err)
+	}
+	if err := os.Rename(newPath, s.dbPath); err != nil {
+		s.putBackDatabaseFile(originalPath)
+		return fmt.Errorf("failed to replace database file: %w", err)
+	}
+	if err := os.Remove(s.dbPath + "-journal"); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove stale journal", "error", err)
+	}
+
+	if err := checkDatabaseFile(s.dbPath); err != nil {
+		s.putBackDatabaseFile(originalPath)
+		return fmt.Errorf("restored database is unusable: %w", err)
+	}
+	if err := os.Remove(originalPath); err != nil {
+		slog.Warn("Failed to remove replaced database file", "path", originalPath, "error", err)
+	}
+	return nil
+}
+
+// putBackDatabaseFile moves the original database file set aside by
+// swapDatabaseFile back into place.
+func (s *Storage) putBackDatabaseFile(originalPath string) {
+	if err := os.Rename(originalPath, s.dbPath); err != nil {
+		slog.Error("Failed to put back original database file", "path", originalPath, "error", err)
+	}
+}
+
+// drainConnections closes the pool's idle connections and waits up to
+// timeout for in-use ones to be returned, which closes them too. The caller
+// must hold restoreMu for writing, so no new connection opens meanwhile;
+// connections waiting to open are left out of the count.
+func (s *Storage) drainConnections(timeout time.Duration) error {
+	s.pool.SetMaxIdleConns(0)
+	deadline := time.Now().Add(timeout)
+	for int64(s.pool.Stats().OpenConnections) > s.connecting.Load() {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for database queries to finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+// checkDatabaseFile reports whether path opens as a SQLite database.
+func checkDatabaseFile(path string) error {
+	db, err := sql.Open(driverName, path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCreateSnapshot(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "session1", time.Hour)
+
+	path, err := store.CreateSnapshot("before-test")
+	if err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Snapshot file not created: %v", err)
+	}
+	if filepath.Base(path) != "before-test.db" {
+		t.Errorf("Snapshot path = %s, want before-test.db", path)
+	}
+
+	names, err := store.ListSnapshots()
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"before-test"}) {
+		t.Errorf("ListSnapshots = %v, want [before-test]", names)
+	}
+}
+
+func TestCreateSnapshot_Duplicate(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if _, err := store.CreateSnapshot("snap"); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+	if _, err := store.CreateSnapshot("snap"); err == nil {
+		t.Error("Expected error for duplicate snapshot name")
+	}
+}
+
+func TestCreateSnapshot_InvalidName(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, name := range []string{"", "../escape", "a/b", "has space"} {
+		if _, err := store.CreateSnapshot(name); err == nil {
+			t.Errorf("Expected error for snapshot name %q", name)
+		}
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "session1", time.Hour)
+
+	if _, err := store.CreateSnapshot("one-context"); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	_, _ = store.CreateContext("chat2", "private", "session2", time.Hour)
+
+	if err := store.RestoreSnapshot("one-context"); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+
+	count, err := store.GetActiveContextCount()
+	if err != nil {
+		t.Fatalf("GetActiveContextCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Active contexts after restore = %d, want 1", count)
+	}
+
+	ctx, _ := store.GetContext("chat2")
+	if ctx != nil {
+		t.Error("chat2 should not exist after restoring snapshot")
+	}
+}
+
+func TestRestoreSnapshot_FlushesAndKeepsBatching(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "session1", time.Hour)
+	if _, err := store.CreateSnapshot("base"); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	store.EnableWriteBatching(100, time.Hour)
+	_ = store.SaveMessage("chat1", "session1", "", "", RoleUser, "before restore")
+
+	// A stale journal must not be replayed onto the snapshot
+	if err := os.WriteFile(store.dbPath+"-journal", []byte("stale"), 0o644); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	if err := store.RestoreSnapshot("base"); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+	if _, err := os.Stat(store.dbPath + "-journal"); !os.IsNotExist(err) {
+		t.Errorf("Stale journal should be removed, stat error = %v", err)
+	}
+	if n, _ := store.GetMessageCountBySession("chat1", "session1"); n != 0 {
+		t.Errorf("Messages after restore = %d, want the snapshot's 0", n)
+	}
+
+	_ = store.SaveMessage("chat1", "session1", "", "", RoleUser, "after restore")
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if n, _ := store.GetMessageCountBySession("chat1", "session1"); n != 1 {
+		t.Errorf("Messages after flush = %d, want 1 written to the restored database", n)
+	}
+}
+
+func TestRestoreSnapshot_WaitsForInFlightQueries(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "session1", time.Hour)
+	if _, err := store.CreateSnapshot("base"); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	// A caller holding the pool from before the restore keeps working
+	db := store.db()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- store.RestoreSnapshot("base") }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("RestoreSnapshot finished during a transaction: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM chat_contexts").Scan(&n); err != nil {
+		t.Fatalf("Query after restore failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Contexts after restore = %d, want 1", n)
+	}
+}
+
+func TestRestoreSnapshot_UnusableSnapshotKeepsDatabase(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "session1", time.Hour)
+	if err := os.MkdirAll(store.snapshotDir, 0o755); err != nil {
+		t.Fatalf("Failed to create snapshot dir: %v", err)
+	}
+	bad := filepath.Join(store.snapshotDir, "bad.db")
+	if err := os.WriteFile(bad, []byte("not a database, just some text padding it out"), 0o644); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	if err := store.RestoreSnapshot("bad"); err == nil {
+		t.Fatal("Expected error restoring an unusable snapshot")
+	}
+	ctx, err := store.GetContext("chat1")
+	if err != nil || ctx == nil {
+		t.Errorf("GetContext() = %v, %v, want the original database kept", ctx, err)
+	}
+	if _, err := os.Stat(store.dbPath + ".orig"); !os.IsNotExist(err) {
+		t.Errorf("Set-aside database file should be moved back, stat error = %v", err)
+	}
+}
+
+func TestRestoreSnapshot_NotFound(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := store.RestoreSnapshot("missing"); err == nil {
+		t.Error("Expected error for missing snapshot")
+	}
+}
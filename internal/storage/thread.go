@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// SetSessionThread stores the forum topic the chat's current session answers
+// in. It is cleared when a new session starts.
+func (s *Storage) SetSessionThread(chatID, threadID string) error {
+	value := sql.NullString{String: threadID, Valid: threadID != ""}
+
+	result, err := s.db().Exec(`
+		UPDATE chat_contexts SET thread_id = ? WHERE chat_id = ?
+	`, value, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to set session thread: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("context not found")
+	}
+
+	return nil
+}
+
+// GetSessionThread returns the forum topic of the chat's current session, or
+// "" if it has none.
+func (s *Storage) GetSessionThread(chatID string) (string, error) {
+	var threadID sql.NullString
+	err := s.db().QueryRow(`
+		SELECT thread_id FROM chat_contexts WHERE chat_id = ?
+	`, chatID).Scan(&threadID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get session thread: %w", err)
+	}
+	return threadID.String, nil
+}
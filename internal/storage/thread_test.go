@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionThread(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := store.SetSessionThread("chat123", "7"); err == nil {
+		t.Error("SetSessionThread() should fail without a context")
+	}
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", time.Hour)
+	if threadID, err := store.GetSessionThread("chat123"); err != nil || threadID != "" {
+		t.Fatalf("GetSessionThread() = %q, %v; want no thread", threadID, err)
+	}
+
+	if err := store.SetSessionThread("chat123", "7"); err != nil {
+		t.Fatalf("SetSessionThread() error = %v", err)
+	}
+	if threadID, _ := store.GetSessionThread("chat123"); threadID != "7" {
+		t.Errorf("GetSessionThread() = %q, want 7", threadID)
+	}
+
+	// A new session gets a new topic
+	_, _ = store.CreateContext("chat123", "group", "session-2", time.Hour)
+	if threadID, _ := store.GetSessionThread("chat123"); threadID != "" {
+		t.Errorf("GetSessionThread() after a new session = %q, want none", threadID)
+	}
+}
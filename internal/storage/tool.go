@@ -11,14 +11,18 @@ type ToolExecution struct {
 	SessionID string
 	ToolName  string
 	Status    string
+	Input     string // Empty when the tool's arguments weren't reported
+	Output    string // Empty when the tool's result wasn't reported
 	CreatedAt time.Time
 }
 
-func (s *Storage) SaveToolExecution(chatID, sessionID, toolName, status string) error {
-	_, err := s.db.Exec(`
-		INSERT INTO tool_executions (chat_id, session_id, tool_name, status, created_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, chatID, sessionID, toolName, status, time.Now())
+// SaveToolExecution records a tool call. input and output may be empty; both
+// are capped like message content.
+func (s *Storage) SaveToolExecution(chatID, sessionID, toolName, status, input, output string) error {
+	err := s.exec(`
+		INSERT INTO tool_executions (chat_id, session_id, tool_name, status, input, output, created_at)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?)
+	`, chatID, sessionID, toolName, status, s.limitContent(input), s.limitContent(output), time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to save tool execution: %w", err)
 	}
@@ -28,11 +32,11 @@ func (s *Storage) SaveToolExecution(chatID, sessionID, toolName, status string)
 // GetToolExecutions returns all tool executions for a chat (across all sessions).
 // Use GetToolExecutionsBySession for session-isolated queries.
 func (s *Storage) GetToolExecutions(chatID string, limit int) ([]*ToolExecution, error) {
-	rows, err := s.db.Query(`
-		SELECT id, chat_id, COALESCE(session_id, ''), tool_name, status, created_at
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, COALESCE(session_id, ''), tool_name, status, COALESCE(input, ''), COALESCE(output, ''), created_at
 		FROM tool_executions
 		WHERE chat_id = ?
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT ?
 	`, chatID, limit)
 	if err != nil {
@@ -43,7 +47,7 @@ func (s *Storage) GetToolExecutions(chatID string, limit int) ([]*ToolExecution,
 	var tools []*ToolExecution
 	for rows.Next() {
 		var tool ToolExecution
-		if err := rows.Scan(&tool.ID, &tool.ChatID, &tool.SessionID, &tool.ToolName, &tool.Status, &tool.CreatedAt); err != nil {
+		if err := rows.Scan(&tool.ID, &tool.ChatID, &tool.SessionID, &tool.ToolName, &tool.Status, &tool.Input, &tool.Output, &tool.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan tool execution: %w", err)
 		}
 		tools = append(tools, &tool)
@@ -58,11 +62,11 @@ func (s *Storage) GetToolExecutions(chatID string, limit int) ([]*ToolExecution,
 
 // GetToolExecutionsBySession returns tool executions for a specific session only.
 func (s *Storage) GetToolExecutionsBySession(chatID, sessionID string, limit int) ([]*ToolExecution, error) {
-	rows, err := s.db.Query(`
-		SELECT id, chat_id, session_id, tool_name, status, created_at
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, session_id, tool_name, status, COALESCE(input, ''), COALESCE(output, ''), created_at
 		FROM tool_executions
 		WHERE chat_id = ? AND session_id = ?
-		ORDER BY created_at DESC
+		ORDER BY created_at DESC, id DESC
 		LIMIT ?
 	`, chatID, sessionID, limit)
 	if err != nil {
@@ -73,7 +77,7 @@ func (s *Storage) GetToolExecutionsBySession(chatID, sessionID string, limit int
 	var tools []*ToolExecution
 	for rows.Next() {
 		var tool ToolExecution
-		if err := rows.Scan(&tool.ID, &tool.ChatID, &tool.SessionID, &tool.ToolName, &tool.Status, &tool.CreatedAt); err != nil {
+		if err := rows.Scan(&tool.ID, &tool.ChatID, &tool.SessionID, &tool.ToolName, &tool.Status, &tool.Input, &tool.Output, &tool.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan tool execution: %w", err)
 		}
 		tools = append(tools, &tool)
@@ -85,4 +89,3 @@ func (s *Storage) GetToolExecutionsBySession(chatID, sessionID string, limit int
 
 	return tools, nil
 }
-
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveToolExecution_InputOutput(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+	store.SetMaxContentLen(100)
+
+	_, _ = store.CreateContext("chat123", "private", "session-1", time.Hour)
+	_ = store.SaveToolExecution("chat123", "session-1", "Bash", "success", `{"command":"kubectl get pods -n prod"}`, "pod-a Running")
+	_ = store.SaveToolExecution("chat123", "session-1", "kubectl", "success", "", "")
+	_ = store.SaveToolExecution("chat123", "session-1", "Bash", "error", "{}", strings.Repeat("x", 500))
+
+	tools, err := store.GetToolExecutionsBySession("chat123", "session-1", 10)
+	if err != nil {
+		t.Fatalf("GetToolExecutionsBySession failed: %v", err)
+	}
+	if len(tools) != 3 {
+		t.Fatalf("Expected 3 tools, got %d", len(tools))
+	}
+
+	byStatus := map[string]*ToolExecution{}
+	for _, tool := range tools {
+		byStatus[tool.ToolName+"/"+tool.Status] = tool
+	}
+	if got := byStatus["Bash/success"]; got.Input != `{"command":"kubectl get pods -n prod"}` || got.Output != "pod-a Running" {
+		t.Errorf("Bash/success = %+v, want input and output stored", got)
+	}
+	if got := byStatus["kubectl/success"]; got.Input != "" || got.Output != "" {
+		t.Errorf("kubectl/success = %+v, want empty input and output", got)
+	}
+	if got := byStatus["Bash/error"]; !strings.Contains(got.Output, "[truncated 400 bytes]") {
+		t.Errorf("Expected long output to be capped, got %d bytes", len(got.Output))
+	}
+
+	all, _ := store.GetToolExecutions("chat123", 10)
+	if len(all) != 3 {
+		t.Errorf("GetToolExecutions returned %d tools, want 3", len(all))
+	}
+}
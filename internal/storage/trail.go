@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TrailEvent is one step in a Claude session's journey between chats.
+// Event is "owned" when a chat took the session, otherwise the cleanup_log
+// type that ended that chat's ownership (transfer, expired, manual, ...).
+type TrailEvent struct {
+	Time     time.Time
+	ChatID   string
+	ChatType string
+	Event    string
+}
+
+// GetSessionTrail reconstructs which chats owned a Claude session and how each
+// ownership ended, oldest first. It combines the chat_contexts rows pointing at
+// the session with cleanup_log entries for those chats. A chat that later
+// started an unrelated session no longer references it and drops out of the trail.
+func (s *Storage) GetSessionTrail(claudeSessionID string) ([]TrailEvent, error) {
+	rows, err := s.readDB().Query(`
+		SELECT id, chat_id, chat_type, session_id, claude_session_id,
+		       created_at, last_interaction, expires_at, is_active
+		FROM chat_contexts
+		WHERE claude_session_id = ?
+		ORDER BY created_at ASC, id ASC
+	`, claudeSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session contexts: %w", err)
+	}
+	contexts, err := scanChatContexts(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []TrailEvent
+	for _, ctx := range contexts {
+		events = append(events, TrailEvent{
+			Time:     ctx.CreatedAt,
+			ChatID:   ctx.ChatID,
+			ChatType: ctx.ChatType,
+			Event:    "owned",
+		})
+
+		logRows, err := s.readDB().Query(`
+			SELECT cleanup_type, created_at FROM cleanup_log
+			WHERE chat_id = ?
+			ORDER BY created_at ASC, id ASC
+		`, ctx.ChatID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cleanup log: %w", err)
+		}
+		for logRows.Next() {
+			var cleanupType string
+			var at time.Time
+			if err := logRows.Scan(&cleanupType, &at); err != nil {
+				logRows.Close()
+				return nil, fmt.Errorf("failed to scan cleanup log: %w", err)
+			}
+			// Earlier entries belong to sessions this chat had before
+			if at.Before(ctx.CreatedAt) {
+				continue
+			}
+			events = append(events, TrailEvent{
+				Time:     at,
+				ChatID:   ctx.ChatID,
+				ChatType: ctx.ChatType,
+				Event:    cleanupType,
+			})
+		}
+		err = logRows.Err()
+		logRows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating cleanup log: %w", err)
+		}
+	}
+
+	// Stable so a transfer out of one chat stays ahead of the ownership it
+	// created, which is logged with the same timestamp
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events, nil
+}
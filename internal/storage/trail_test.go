@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetSessionTrail(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "session-1", time.Hour)
+	if err := store.UpdateClaudeSessionID("chat1", "claude-abc"); err != nil {
+		t.Fatalf("UpdateClaudeSessionID failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := store.TransferSession("chat1", "chat2", "group", "session-2", time.Hour); err != nil {
+		t.Fatalf("TransferSession failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := store.CleanupContextTx("chat2", "manual"); err != nil {
+		t.Fatalf("CleanupContextTx failed: %v", err)
+	}
+
+	trail, err := store.GetSessionTrail("claude-abc")
+	if err != nil {
+		t.Fatalf("GetSessionTrail failed: %v", err)
+	}
+
+	want := []struct{ chatID, event string }{
+		{"chat1", "owned"},
+		{"chat1", "transfer"},
+		{"chat2", "owned"},
+		{"chat2", "manual"},
+	}
+	if len(trail) != len(want) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(want), len(trail), trail)
+	}
+	for i, w := range want {
+		if trail[i].ChatID != w.chatID || trail[i].Event != w.event {
+			t.Errorf("Event %d = %s/%s, want %s/%s", i, trail[i].ChatID, trail[i].Event, w.chatID, w.event)
+		}
+	}
+	if trail[2].ChatType != "group" {
+		t.Errorf("Expected chat2 type group, got %q", trail[2].ChatType)
+	}
+}
+
+func TestGetSessionTrail_IgnoresEarlierCleanups(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// chat1 had an unrelated session that expired before it picked up claude-abc
+	_, _ = store.CreateContext("chat1", "private", "old-session", time.Hour)
+	_, _ = store.CleanupContextTx("chat1", "expired")
+	time.Sleep(10 * time.Millisecond)
+	_, _ = store.CreateContext("chat1", "private", "session-1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+
+	trail, err := store.GetSessionTrail("claude-abc")
+	if err != nil {
+		t.Fatalf("GetSessionTrail failed: %v", err)
+	}
+	if len(trail) != 1 || trail[0].Event != "owned" {
+		t.Errorf("Expected only the ownership event, got %+v", trail)
+	}
+
+	if trail, _ := store.GetSessionTrail("unknown"); len(trail) != 0 {
+		t.Errorf("Expected empty trail for unknown session, got %+v", trail)
+	}
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// maxTreeDepth bounds how far GetSessionTree walks up to the root session,
+// guarding against cycles in corrupted data.
+const maxTreeDepth = 100
+
+// SessionNode is one session in a tree of transfers. Status is "active" for
+// a chat's live session, otherwise the cleanup_log type that ended it
+// (transfer, expired, manual, ...) or "inactive" if it was not logged.
+// ChatID is empty when nothing is recorded about the session any more.
+type SessionNode struct {
+	SessionID string
+	ChatID    string
+	Status    string
+	Children  []*SessionNode
+}
+
+// GetSessionTree returns the tree of transfers sessionID belongs to, starting
+// from the session it ultimately came from. Parent links come from
+// chat_contexts for current sessions and from cleanup_log for sessions their
+// chat has since replaced.
+func (s *Storage) GetSessionTree(sessionID string) (*SessionNode, error) {
+	rootID := sessionID
+	seen := map[string]bool{sessionID: true}
+	for i := 0; i < maxTreeDepth; i++ {
+		parentID, err := s.sessionParent(rootID)
+		if err != nil {
+			return nil, err
+		}
+		if parentID == "" || seen[parentID] {
+			break
+		}
+		seen[parentID] = true
+		rootID = parentID
+	}
+
+	return s.buildSessionNode(rootID, make(map[string]bool))
+}
+
+// sessionParent returns the session sessionID was transferred from, or "".
+func (s *Storage) sessionParent(sessionID string) (string, error) {
+	var parentID sql.NullString
+	err := s.readDB().QueryRow(`
+		SELECT parent_session_id FROM chat_contexts WHERE session_id = ?
+		UNION ALL
+		SELECT parent_session_id FROM cleanup_log WHERE session_id = ?
+		LIMIT 1
+	`, sessionID, sessionID).Scan(&parentID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get parent session: %w", err)
+	}
+	return parentID.String, nil
+}
+
+// buildSessionNode loads sessionID and its descendants, skipping sessions
+// already in the tree.
+func (s *Storage) buildSessionNode(sessionID string, visited map[string]bool) (*SessionNode, error) {
+	visited[sessionID] = true
+	node, err := s.sessionNode(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	childIDs, err := s.sessionChildren(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, childID := range childIDs {
+		if visited[childID] {
+			continue
+		}
+		child, err := s.buildSessionNode(childID, visited)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	return node, nil
+}
+
+// sessionNode describes sessionID from its chat_contexts row if the chat
+// still holds it, or from the latest cleanup_log entry that ended it.
+func (s *Storage) sessionNode(sessionID string) (*SessionNode, error) {
+	node := &SessionNode{SessionID: sessionID, Status: "inactive"}
+
+	var isActive bool
+	err := s.readDB().QueryRow(`
+		SELECT chat_id, is_active FROM chat_contexts WHERE session_id = ?
+	`, sessionID).Scan(&node.ChatID, &isActive)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get session context: %w", err)
+	}
+	if isActive {
+		node.Status = "active"
+		return node, nil
+	}
+
+	var chatID, cleanupType string
+	err = s.readDB().QueryRow(`
+		SELECT chat_id, cleanup_type FROM cleanup_log
+		WHERE session_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`, sessionID).Scan(&chatID, &cleanupType)
+	if err == sql.ErrNoRows {
+		return node, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session cleanup: %w", err)
+	}
+	node.ChatID = chatID
+	node.Status = cleanupType
+	return node, nil
+}
+
+// sessionChildren returns the sessions transferred from sessionID, oldest
+// first.
+func (s *Storage) sessionChildren(sessionID string) ([]string, error) {
+	rows, err := s.readDB().Query(`
+		SELECT session_id FROM (
+			SELECT session_id, created_at FROM chat_contexts WHERE parent_session_id = ?
+			UNION ALL
+			SELECT session_id, created_at FROM cleanup_log WHERE parent_session_id = ? AND session_id IS NOT NULL
+		)
+		GROUP BY session_id
+		ORDER BY MIN(created_at) ASC
+	`, sessionID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get child sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan child session: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating child sessions: %w", err)
+	}
+	return ids, nil
+}
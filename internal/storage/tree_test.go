@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// treeShape flattens a tree into "chat:status" lines indented by depth.
+func treeShape(node *SessionNode, depth int, out *[]string) {
+	line := ""
+	for i := 0; i < depth; i++ {
+		line += "  "
+	}
+	*out = append(*out, line+node.ChatID+":"+node.Status)
+	for _, child := range node.Children {
+		treeShape(child, depth+1, out)
+	}
+}
+
+func TestGetSessionTree(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// chat1 → chat2 → chat3, then chat2's copy is also resumed in chat4 after
+	// chat2 started an unrelated session
+	_, _ = store.CreateContext("chat1", "private", "s1", time.Hour)
+	_ = store.UpdateClaudeSessionID("chat1", "claude-abc")
+	time.Sleep(10 * time.Millisecond)
+	if _, err := store.TransferSession("chat1", "chat2", "group", "s2", time.Hour); err != nil {
+		t.Fatalf("TransferSession(chat1→chat2) failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := store.TransferSession("chat2", "chat3", "group", "s3", time.Hour); err != nil {
+		t.Fatalf("TransferSession(chat2→chat3) failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := store.TransferSession("chat1", "chat4", "private", "s4", time.Hour); err != nil {
+		t.Fatalf("TransferSession(chat1→chat4) failed: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// chat2 moves on; s2 now only survives in cleanup_log
+	_, _ = store.CreateContext("chat2", "group", "s5", time.Hour)
+
+	want := []string{
+		"chat1:transfer",
+		"  chat2:transfer",
+		"    chat3:active",
+		"  chat4:active",
+	}
+	for _, from := range []string{"s3", "s1", "s4"} {
+		tree, err := store.GetSessionTree(from)
+		if err != nil {
+			t.Fatalf("GetSessionTree(%s) failed: %v", from, err)
+		}
+		var got []string
+		treeShape(tree, 0, &got)
+		if len(got) != len(want) {
+			t.Fatalf("GetSessionTree(%s) = %q, want %q", from, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("GetSessionTree(%s) line %d = %q, want %q", from, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestGetSessionTree_Standalone(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, _ = store.CreateContext("chat1", "private", "s1", time.Hour)
+	tree, err := store.GetSessionTree("s1")
+	if err != nil {
+		t.Fatalf("GetSessionTree failed: %v", err)
+	}
+	if tree.SessionID != "s1" || tree.ChatID != "chat1" || tree.Status != "active" || len(tree.Children) != 0 {
+		t.Errorf("GetSessionTree = %+v, want a single active node", tree)
+	}
+
+	if _, err := store.CleanupContextTx("chat1", "manual"); err != nil {
+		t.Fatalf("CleanupContextTx failed: %v", err)
+	}
+	tree, err = store.GetSessionTree("s1")
+	if err != nil {
+		t.Fatalf("GetSessionTree failed: %v", err)
+	}
+	if tree.Status != "manual" {
+		t.Errorf("Status = %q after /new, want manual", tree.Status)
+	}
+}
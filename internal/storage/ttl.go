@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// SetTTL overrides the session TTL for a chat. The override survives session
+// resets and is applied when the context is created, refreshed or
+// reactivated. An active context's expiry is recomputed from now. A ttl of 0
+// removes the override.
+func (s *Storage) SetTTL(chatID string, ttl time.Duration) error {
+	var seconds sql.NullInt64
+	if ttl > 0 {
+		seconds = sql.NullInt64{Int64: int64(ttl / time.Second), Valid: true}
+	}
+
+	result, err := s.db().Exec(`
+		UPDATE chat_contexts
+		SET ttl_override_seconds = ?,
+		    expires_at = CASE WHEN ? > 0 AND is_active = 1 THEN ? ELSE expires_at END
+		WHERE chat_id = ?
+	`, seconds, seconds.Int64, time.Now().Add(ttl), chatID)
+	if err != nil {
+		return fmt.Errorf("failed to set ttl: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("context not found")
+	}
+
+	return nil
+}
+
+// GetTTL returns the chat's TTL override, or 0 if none is set.
+func (s *Storage) GetTTL(chatID string) (time.Duration, error) {
+	var seconds sql.NullInt64
+	err := s.db().QueryRow(`
+		SELECT ttl_override_seconds FROM chat_contexts WHERE chat_id = ?
+	`, chatID).Scan(&seconds)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ttl: %w", err)
+	}
+	if !seconds.Valid {
+		return 0, nil
+	}
+	return time.Duration(seconds.Int64) * time.Second, nil
+}
+
+// effectiveTTL returns the chat's TTL override if set, otherwise defaultTTL.
+func (s *Storage) effectiveTTL(chatID string, defaultTTL time.Duration) time.Duration {
+	override, err := s.GetTTL(chatID)
+	if err != nil {
+		slog.Warn("Failed to get ttl override, using default", "chat_id", chatID, "error", err)
+		return defaultTTL
+	}
+	if override > 0 {
+		return override
+	}
+	return defaultTTL
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetTTL_OverridesRefreshAndSurvivesReset(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := store.SetTTL("chat123", 8*time.Hour); err == nil {
+		t.Error("SetTTL should fail without a context")
+	}
+
+	_, _ = store.CreateContext("chat123", "group", "session-1", 2*time.Hour)
+	if err := store.SetTTL("chat123", 8*time.Hour); err != nil {
+		t.Fatalf("SetTTL failed: %v", err)
+	}
+
+	assertExpiresIn := func(step string, want time.Duration) {
+		t.Helper()
+		ctx, _ := store.GetContext("chat123")
+		if got := time.Until(ctx.ExpiresAt); got < want-time.Minute || got > want+time.Minute {
+			t.Errorf("%s: expires in %v, want ~%v", step, got, want)
+		}
+	}
+
+	assertExpiresIn("after SetTTL", 8*time.Hour)
+
+	_ = store.RefreshContext("chat123", 2*time.Hour)
+	assertExpiresIn("after refresh", 8*time.Hour)
+
+	_ = store.DeactivateContext("chat123")
+	_ = store.ReactivateContext("chat123", 2*time.Hour)
+	assertExpiresIn("after reactivate", 8*time.Hour)
+
+	// A new session in the same chat keeps the override
+	_, _ = store.CreateContext("chat123", "group", "session-2", 2*time.Hour)
+	assertExpiresIn("after new session", 8*time.Hour)
+	if ttl, _ := store.GetTTL("chat123"); ttl != 8*time.Hour {
+		t.Errorf("GetTTL = %v, want 8h", ttl)
+	}
+
+	// Clearing restores the default on next refresh
+	_ = store.SetTTL("chat123", 0)
+	_ = store.RefreshContext("chat123", 2*time.Hour)
+	assertExpiresIn("after reset", 2*time.Hour)
+	if ttl, _ := store.GetTTL("chat123"); ttl != 0 {
+		t.Errorf("GetTTL after reset = %v, want 0", ttl)
+	}
+}
+
+func TestGetTTL_NoContext(t *testing.T) {
+	store, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ttl, err := store.GetTTL("missing")
+	if err != nil || ttl != 0 {
+		t.Errorf("GetTTL(missing) = %v, %v; want 0, nil", ttl, err)
+	}
+}
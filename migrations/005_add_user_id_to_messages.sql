@@ -0,0 +1,5 @@
+-- Attribute messages to the user who sent (or, for assistant replies, triggered) them
+-- Existing rows will have NULL user_id (legacy data)
+ALTER TABLE messages ADD COLUMN user_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_messages_user_id ON messages(chat_id, session_id, user_id, created_at);
@@ -0,0 +1,7 @@
+-- Per-chat display preferences stored as a JSON object of key/value strings.
+-- Not tied to chat_contexts so preferences survive session resets.
+CREATE TABLE IF NOT EXISTS chat_preferences (
+    chat_id TEXT PRIMARY KEY,
+    preferences TEXT NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,10 @@
+-- Slash command invocations for usage analytics
+CREATE TABLE IF NOT EXISTS command_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    user_id TEXT,
+    command TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_command_usage_created_at ON command_usage(created_at, command);
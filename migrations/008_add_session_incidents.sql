@@ -0,0 +1,9 @@
+-- Links a session to an external incident (e.g. a Jira or PagerDuty ID) for post-mortems.
+CREATE TABLE IF NOT EXISTS session_incidents (
+    session_id TEXT PRIMARY KEY,
+    chat_id TEXT NOT NULL,
+    incident_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_incidents_incident ON session_incidents(incident_id);
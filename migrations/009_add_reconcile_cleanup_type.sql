@@ -0,0 +1,20 @@
+-- Add 'reconcile' as valid cleanup_type for contexts deactivated because another
+-- active context shares their Claude session.
+-- SQLite doesn't support ALTER TABLE to modify CHECK constraints, so we recreate the table
+
+CREATE TABLE IF NOT EXISTS cleanup_log_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    cleanup_type TEXT NOT NULL CHECK(cleanup_type IN ('expired', 'manual', 'error', 'transfer', 'reconcile')),
+    messages_deleted INTEGER DEFAULT 0,
+    tools_deleted INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO cleanup_log_new (id, chat_id, cleanup_type, messages_deleted, tools_deleted, created_at)
+SELECT id, chat_id, cleanup_type, messages_deleted, tools_deleted, created_at
+FROM cleanup_log;
+
+DROP TABLE cleanup_log;
+
+ALTER TABLE cleanup_log_new RENAME TO cleanup_log;
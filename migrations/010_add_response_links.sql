@@ -0,0 +1,13 @@
+-- Links each bot answer message to the query that produced it, so reactions on
+-- an answer can retry, export or rate that specific response.
+CREATE TABLE IF NOT EXISTS response_links (
+    chat_id TEXT NOT NULL,
+    message_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    query_message_id TEXT NOT NULL DEFAULT '',
+    query TEXT NOT NULL,
+    response TEXT NOT NULL,
+    feedback TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_id, message_id)
+);
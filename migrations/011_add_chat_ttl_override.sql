@@ -0,0 +1,2 @@
+-- Per-chat session TTL set with /ttl. NULL uses the configured context.ttl.
+ALTER TABLE chat_contexts ADD COLUMN ttl_override_seconds INTEGER;
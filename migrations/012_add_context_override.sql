@@ -0,0 +1,2 @@
+-- Per-chat context snippet set with /setcontext, added to every query in the chat.
+ALTER TABLE chat_contexts ADD COLUMN context_override TEXT;
@@ -0,0 +1,4 @@
+-- Tool arguments and results, so operators can audit the exact commands run.
+-- Existing rows have NULL input/output.
+ALTER TABLE tool_executions ADD COLUMN input TEXT;
+ALTER TABLE tool_executions ADD COLUMN output TEXT;
@@ -0,0 +1,2 @@
+-- Per-chat Claude model set with /model. NULL uses the configured claude.model.
+ALTER TABLE chat_contexts ADD COLUMN model TEXT;
@@ -0,0 +1,9 @@
+-- Per-chat redaction terms set with /redact, removed from answers on top of
+-- the global security patterns. Not tied to chat_contexts so terms survive
+-- session resets.
+CREATE TABLE IF NOT EXISTS chat_redactions (
+    chat_id TEXT NOT NULL,
+    term TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (chat_id, term)
+);
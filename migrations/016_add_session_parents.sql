@@ -0,0 +1,10 @@
+-- Track which session a context was transferred from so /tree can show how a
+-- session branched across chats. cleanup_log keeps the session and its parent
+-- so the tree survives the chat starting a new session.
+ALTER TABLE chat_contexts ADD COLUMN parent_session_id TEXT;
+ALTER TABLE cleanup_log ADD COLUMN session_id TEXT;
+ALTER TABLE cleanup_log ADD COLUMN parent_session_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_chat_contexts_parent_session ON chat_contexts(parent_session_id);
+CREATE INDEX IF NOT EXISTS idx_cleanup_log_session ON cleanup_log(session_id);
+CREATE INDEX IF NOT EXISTS idx_cleanup_log_parent_session ON cleanup_log(parent_session_id);
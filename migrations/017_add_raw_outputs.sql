@@ -0,0 +1,14 @@
+-- Raw Claude CLI output behind each answer, kept when storage.record_raw_output
+-- is enabled so /replay can re-render an answer without calling Claude again.
+-- Unlike messages.content this is stored before redaction.
+CREATE TABLE IF NOT EXISTS raw_outputs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    query TEXT NOT NULL,
+    raw TEXT NOT NULL,
+    canary BOOLEAN NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_raw_outputs_chat_id ON raw_outputs(chat_id, id);
@@ -0,0 +1,3 @@
+-- Forum topic holding the session's answers (telegram.answer_threads). Not
+-- carried over by CreateContext, so each new session gets its own topic.
+ALTER TABLE chat_contexts ADD COLUMN thread_id TEXT;
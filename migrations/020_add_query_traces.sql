@@ -0,0 +1,12 @@
+-- Trace ID of each answered query (claude.trace_queries), also passed to the
+-- tools Claude ran, so their logs can be matched to the chat that asked.
+CREATE TABLE IF NOT EXISTS query_traces (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    user_id TEXT,
+    trace_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_traces_trace_id ON query_traces(trace_id);
@@ -0,0 +1,11 @@
+-- Answers whose redaction exceeded security.heavy_redaction.threshold, kept
+-- unredacted so admins can check with /unredact whether a secret pattern
+-- destroyed legitimate output. Never shown outside admins' private chats.
+CREATE TABLE IF NOT EXISTS redacted_answers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    original TEXT NOT NULL,
+    fraction REAL NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
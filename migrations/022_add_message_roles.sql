@@ -0,0 +1,27 @@
+-- Add 'system' and 'summary' as valid message roles for the bot's own notes
+-- and /summarize results, which aren't conversation turns.
+-- SQLite doesn't support ALTER TABLE to modify CHECK constraints, so we recreate the table
+
+CREATE TABLE IF NOT EXISTS messages_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    role TEXT NOT NULL CHECK(role IN ('user', 'assistant', 'system', 'summary')),
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    session_id TEXT,
+    user_id TEXT,
+    FOREIGN KEY (chat_id) REFERENCES chat_contexts(chat_id) ON DELETE CASCADE
+);
+
+INSERT INTO messages_new (id, chat_id, role, content, created_at, session_id, user_id)
+SELECT id, chat_id, role, content, created_at, session_id, user_id
+FROM messages;
+
+-- Also drops the full-text search triggers, restored by the next migration
+DROP TABLE messages;
+
+ALTER TABLE messages_new RENAME TO messages;
+
+CREATE INDEX IF NOT EXISTS idx_messages_chat_id ON messages(chat_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_messages_session_id ON messages(session_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_messages_user_id ON messages(chat_id, session_id, user_id, created_at);
@@ -0,0 +1,18 @@
+-- requires: fts5
+-- Recreate the triggers keeping messages_fts in sync, dropped along with the
+-- old messages table by 022_add_message_roles. Row IDs were kept, so the
+-- index stays valid; it is rebuilt anyway in case rows changed in between.
+CREATE TRIGGER IF NOT EXISTS messages_fts_insert AFTER INSERT ON messages BEGIN
+    INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_delete AFTER DELETE ON messages BEGIN
+    INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS messages_fts_update AFTER UPDATE OF content ON messages BEGIN
+    INSERT INTO messages_fts(messages_fts, rowid, content) VALUES ('delete', old.id, old.content);
+    INSERT INTO messages_fts(rowid, content) VALUES (new.id, new.content);
+END;
+
+INSERT INTO messages_fts(messages_fts) VALUES ('rebuild');
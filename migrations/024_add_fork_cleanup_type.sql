@@ -0,0 +1,25 @@
+-- Add 'fork' as valid cleanup_type for sessions a chat replaced with a fork
+-- of them (/fork), so /tree keeps showing where the fork came from.
+-- SQLite doesn't support ALTER TABLE to modify CHECK constraints, so we recreate the table
+
+CREATE TABLE IF NOT EXISTS cleanup_log_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL,
+    cleanup_type TEXT NOT NULL CHECK(cleanup_type IN ('expired', 'manual', 'error', 'transfer', 'reconcile', 'fork')),
+    messages_deleted INTEGER DEFAULT 0,
+    tools_deleted INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    session_id TEXT,
+    parent_session_id TEXT
+);
+
+INSERT INTO cleanup_log_new (id, chat_id, cleanup_type, messages_deleted, tools_deleted, created_at, session_id, parent_session_id)
+SELECT id, chat_id, cleanup_type, messages_deleted, tools_deleted, created_at, session_id, parent_session_id
+FROM cleanup_log;
+
+DROP TABLE cleanup_log;
+
+ALTER TABLE cleanup_log_new RENAME TO cleanup_log;
+
+CREATE INDEX IF NOT EXISTS idx_cleanup_log_session ON cleanup_log(session_id);
+CREATE INDEX IF NOT EXISTS idx_cleanup_log_parent_session ON cleanup_log(parent_session_id);
@@ -0,0 +1,3 @@
+-- Per-chat project set with /project, by name from claude.projects. NULL runs
+-- queries in claude.project_path.
+ALTER TABLE chat_contexts ADD COLUMN project TEXT;
@@ -0,0 +1,4 @@
+-- Username of the user a message is attributed to, so group history can show
+-- who asked what. NULL for rows saved before this migration and messages from
+-- users without one.
+ALTER TABLE messages ADD COLUMN username TEXT;
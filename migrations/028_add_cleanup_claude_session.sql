@@ -0,0 +1,5 @@
+-- Claude session ID of a session a chat replaced with a fork, so /resume can
+-- switch the chat back to it. NULL for other cleanup types.
+ALTER TABLE cleanup_log ADD COLUMN claude_session_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_cleanup_log_claude_session ON cleanup_log(claude_session_id);